@@ -171,9 +171,9 @@ func (d *EurekaTestSuit) loadConfig() error {
 	d.cfg = new(TestConfig)
 
 	confFileName := testdata.Path("eureka_apiserver_test.yaml")
-	if os.Getenv("STORE_MODE") == "sqldb" {
-		fmt.Printf("run store mode : sqldb\n")
-		confFileName = testdata.Path("eureka_apiserver_test_sqldb.yaml")
+	if mode := os.Getenv("STORE_MODE"); mode == "sqldb" || mode == "postgres" {
+		fmt.Printf("run store mode : %s\n", mode)
+		confFileName = testdata.Path("eureka_apiserver_test_" + mode + ".yaml")
 	}
 	file, err := os.Open(confFileName)
 	if err != nil {
@@ -19,6 +19,7 @@ package eurekaserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -231,11 +232,7 @@ func (h *EurekaServer) Initialize(ctx context.Context, option map[string]interfa
 		if err != nil {
 			return err
 		}
-		h.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
-		}
+		h.tlsInfo = tlsConfig.ToTLSInfo()
 	}
 
 	h.refreshInterval = time.Duration(refreshInterval) * time.Second
@@ -335,7 +332,15 @@ func (h *EurekaServer) Run(errCh chan error) {
 	if h.tlsInfo.IsEmpty() {
 		err = server.Serve(ln)
 	} else {
-		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+		// 证书由 tls.Config 负责加载，证书文件变更时自动热加载
+		var tlsConfig *tls.Config
+		if tlsConfig, err = h.tlsInfo.ServerConfig(); err != nil {
+			log.Errorf("build tls config err: %s", err.Error())
+			errCh <- err
+			return
+		}
+		server.TLSConfig = tlsConfig
+		err = server.ServeTLS(ln, "", "")
 	}
 	if err != nil && err != http.ErrServerClosed {
 		log.Errorf("%+v", err)
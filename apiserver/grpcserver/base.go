@@ -22,10 +22,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -41,6 +41,7 @@ import (
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 )
@@ -65,6 +66,7 @@ type BaseGrpcServer struct {
 	server     *grpc.Server
 	statis     plugin.Statis
 	ratelimit  plugin.Ratelimit
+	whitelist  plugin.Whitelist
 	OpenMethod map[string]bool
 
 	cache   Cache
@@ -100,11 +102,7 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 		if err != nil {
 			return err
 		}
-		b.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
-		}
+		b.tlsInfo = tlsConfig.ToTLSInfo()
 	}
 
 	if ratelimit := plugin.GetRatelimit(); ratelimit != nil {
@@ -112,6 +110,14 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 		b.ratelimit = ratelimit
 	}
 
+	// 白名单需要在监听器上显式开启，避免升级后 SDK 的 gRPC 长连接被默认拒绝
+	if enable, _ := conf["whitelist"].(bool); enable {
+		if whitelist := plugin.GetWhitelist(); whitelist != nil {
+			b.log.Infof("[API-Server] %s server open the whitelist", b.protocol)
+			b.whitelist = whitelist
+		}
+	}
+
 	return nil
 }
 
@@ -159,15 +165,16 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 		bz: b.bz,
 	})
 
-	// 指定使用服务端证书创建一个 TLS credentials
+	// 指定使用服务端证书创建一个 TLS credentials，证书文件变更时自动热加载
 	var creds credentials.TransportCredentials
 	if !b.tlsInfo.IsEmpty() {
-		creds, err = credentials.NewServerTLSFromFile(b.tlsInfo.CertFile, b.tlsInfo.KeyFile)
+		tlsConfig, err := b.tlsInfo.ServerConfig()
 		if err != nil {
 			b.log.Error("failed to create credentials: %v", zap.Error(err))
 			errCh <- err
 			return
 		}
+		creds = credentials.NewTLS(tlsConfig)
 	}
 
 	// 设置 grpc server options
@@ -212,6 +219,11 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 		WithVirtualStreamPostProcessFunc(b.postprocess),
 	)
 
+	meta, _ := metadata.FromIncomingContext(ctx)
+	ctx, span := tracing.StartServerSpan(tracing.ExtractGRPC(ctx, meta), info.FullMethod,
+		attribute.String("rpc.system", "grpc"), attribute.String("net.peer.ip", stream.ClientIP))
+	defer span.End()
+
 	func() {
 		_, ok := notPrintableMethods[info.FullMethod]
 		var printable = !ok
@@ -225,6 +237,11 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 			return
 		}
 
+		if ok := b.EnterWhitelist(stream.ClientIP, stream.Method); !ok {
+			rsp = api.NewResponse(apimodel.Code_NotAllowedAccess)
+			return
+		}
+
 		// handler执行前，限流
 		if code := b.EnterRatelimit(stream.ClientIP, stream.Method); code != uint32(api.ExecuteSuccess) {
 			rsp = api.NewResponse(apimodel.Code(code))
@@ -234,6 +251,9 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 	}()
 
 	b.postprocess(stream, rsp)
+	if response, ok := rsp.(api.ResponseMessage); ok {
+		span.SetAttributes(attribute.Int64("polaris.code", int64(response.GetCode().GetValue())))
+	}
 
 	return
 }
@@ -248,6 +268,10 @@ func (b *BaseGrpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream
 		WithVirtualStreamPostProcessFunc(b.postprocess),
 	)
 
+	if ok := b.EnterWhitelist(stream.ClientIP, stream.Method); !ok {
+		return status.Error(codes.PermissionDenied, api.Code2Info(api.NotAllowedAccess))
+	}
+
 	err = handler(srv, stream)
 	if err != nil {
 		fromError, ok := status.FromError(err)
@@ -408,6 +432,19 @@ func (b *BaseGrpcServer) EnterRatelimit(ip string, method string) uint32 {
 	return api.ExecuteSuccess
 }
 
+// EnterWhitelist 检查客户端 IP 是否在访问方法的白名单中
+func (b *BaseGrpcServer) EnterWhitelist(ip string, method string) bool {
+	if b.whitelist == nil {
+		return true
+	}
+	if !b.whitelist.Contain(plugin.WhitelistEntry{IP: ip, API: method}) {
+		b.log.Error("[API-Server][GRPC] access is not allowed", zap.String("client-ip", ip),
+			zap.String("method", method))
+		return false
+	}
+	return true
+}
+
 // AllowAccess api allow access
 func (b *BaseGrpcServer) AllowAccess(method string) bool {
 	if len(b.OpenMethod) == 0 {
@@ -420,6 +457,7 @@ func (b *BaseGrpcServer) AllowAccess(method string) bool {
 // ConvertContext 将GRPC上下文转换成内部上下文
 func ConvertContext(ctx context.Context) context.Context {
 	var (
+		srcCtx    = ctx
 		requestID = ""
 		userAgent = ""
 	)
@@ -440,12 +478,15 @@ func ConvertContext(ctx context.Context) context.Context {
 	var (
 		clientIP = ""
 		address  = ""
+		identity *secure.PeerIdentity
 	)
 	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
 		address = pr.Addr.String()
-		addrSlice := strings.Split(address, ":")
-		if len(addrSlice) == 2 {
-			clientIP = addrSlice[0]
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			clientIP = host
+		}
+		if tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+			identity = secure.ParsePeerIdentity(&tlsInfo.State)
 		}
 	}
 
@@ -455,6 +496,17 @@ func ConvertContext(ctx context.Context) context.Context {
 	ctx = context.WithValue(ctx, utils.StringContext("client-ip"), clientIP)
 	ctx = context.WithValue(ctx, utils.ContextClientAddress, address)
 	ctx = context.WithValue(ctx, utils.StringContext("user-agent"), userAgent)
+	if values := meta.Get(utils.HeaderSourceServiceKey); len(values) > 0 {
+		ctx = context.WithValue(ctx, utils.ContextSourceServiceKey, values[0])
+	}
+	if values := meta.Get(utils.HeaderSourceNamespaceKey); len(values) > 0 {
+		ctx = context.WithValue(ctx, utils.ContextSourceNamespaceKey, values[0])
+	}
+	if identity != nil {
+		ctx = context.WithValue(ctx, utils.ContextClientCertIdentity, identity)
+	}
+	// 优先沿用拦截器中创建的 span，流式请求则直接使用调用方传递的 trace 上下文
+	ctx = tracing.ContextWithSpanFrom(tracing.ExtractGRPC(ctx, meta), srcCtx)
 
 	return ctx
 }
@@ -25,6 +25,7 @@ import (
 
 	modeapi "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -33,6 +34,7 @@ import (
 	"github.com/polarismesh/polaris/apiserver/grpcserver"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -126,24 +128,31 @@ func (g *DiscoverServer) Discover(server apiservice.PolarisGRPC_DiscoverServer)
 			continue
 		}
 
+		// 每个 discover 包单独作为一个 span
+		discoverCtx, span := tracing.StartServerSpan(ctx, method+"/"+in.Type.String(),
+			attribute.String("polaris.namespace", in.GetService().GetNamespace().GetValue()),
+			attribute.String("polaris.service", in.GetService().GetName().GetValue()))
 		var out *apiservice.DiscoverResponse
 		switch in.Type {
 		case apiservice.DiscoverRequest_INSTANCE:
-			out = g.namingServer.ServiceInstancesCache(ctx, in.Service)
+			out = g.namingServer.ServiceInstancesCache(discoverCtx, in.Service)
 		case apiservice.DiscoverRequest_ROUTING:
-			out = g.namingServer.GetRoutingConfigWithCache(ctx, in.Service)
+			out = g.namingServer.GetRoutingConfigWithCache(discoverCtx, in.Service)
 		case apiservice.DiscoverRequest_RATE_LIMIT:
-			out = g.namingServer.GetRateLimitWithCache(ctx, in.Service)
+			out = g.namingServer.GetRateLimitWithCache(discoverCtx, in.Service)
 		case apiservice.DiscoverRequest_CIRCUIT_BREAKER:
-			out = g.namingServer.GetCircuitBreakerWithCache(ctx, in.Service)
+			out = g.namingServer.GetCircuitBreakerWithCache(discoverCtx, in.Service)
 		case apiservice.DiscoverRequest_SERVICES:
-			out = g.namingServer.GetServiceWithCache(ctx, in.Service)
+			out = g.namingServer.GetServiceWithCache(discoverCtx, in.Service)
 		case apiservice.DiscoverRequest_FAULT_DETECTOR:
-			out = g.namingServer.GetFaultDetectWithCache(ctx, in.Service)
+			out = g.namingServer.GetFaultDetectWithCache(discoverCtx, in.Service)
 		default:
 			out = api.NewDiscoverRoutingResponse(modeapi.Code_InvalidDiscoverResource, in.Service)
 		}
 
+		span.SetAttributes(attribute.Int64("polaris.code", int64(out.GetCode().GetValue())))
+		span.End()
+
 		err = server.Send(out)
 		if err != nil {
 			return err
@@ -20,7 +20,7 @@ package grpcserver
 import (
 	"context"
 	"io"
-	"strings"
+	"net"
 	"time"
 
 	"go.uber.org/zap"
@@ -85,10 +85,9 @@ func newVirtualStream(ctx context.Context, initOptions ...initVirtualStream) *Vi
 	peerAddress, exist := peer.FromContext(ctx)
 	if exist {
 		clientAddress = peerAddress.Addr.String()
-		// 解析获取clientIP
-		items := strings.Split(clientAddress, ":")
-		if len(items) == 2 {
-			clientIP = items[0]
+		// 解析获取clientIP，兼容 IPv6 地址
+		if host, _, err := net.SplitHostPort(clientAddress); err == nil {
+			clientIP = host
 		}
 	}
 
@@ -41,9 +41,12 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(enrichDeleteUsersApiDocs(ws.POST("/users/delete").To(h.DeleteUsers)))
 	ws.Route(enrichUpdateUserApiDocs(ws.PUT("/user").To(h.UpdateUser)))
 	ws.Route(enrichUpdateUserPasswordApiDocs(ws.PUT("/user/password").To(h.UpdateUserPassword)))
+	ws.Route(enrichChangeExpiredPasswordApiDocs(ws.PUT("/user/password/expired").To(h.ChangeExpiredPassword)))
 	ws.Route(enrichGetUserTokenApiDocs(ws.GET("/user/token").To(h.GetUserToken)))
 	ws.Route(enrichUpdateUserTokenApiDocs(ws.PUT("/user/token/status").To(h.UpdateUserToken)))
 	ws.Route(enrichResetUserTokenApiDocs(ws.PUT("/user/token/refresh").To(h.ResetUserToken)))
+	ws.Route(enrichUnlockUserApiDocs(ws.PUT("/user/unlock").To(h.UnlockUser)))
+	ws.Route(enrichUnlockLoginAddressApiDocs(ws.PUT("/user/login/address/unlock").To(h.UnlockLoginAddress)))
 	//
 	ws.Route(enrichCreateGroupApiDocs(ws.POST("/usergroup").To(h.CreateGroup)))
 	ws.Route(enrichUpdateGroupsApiDocs(ws.PUT("/usergroups").To(h.UpdateGroups)))
@@ -93,13 +96,13 @@ func (h *HTTPServer) Login(req *restful.Request, rsp *restful.Response) {
 
 	loginReq := &apisecurity.LoginRequest{}
 
-	_, err := handler.Parse(loginReq)
+	ctx, err := handler.Parse(loginReq)
 	if err != nil {
 		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_ParseException, err.Error()))
 		return
 	}
 
-	handler.WriteHeaderAndProto(h.authServer.Login(loginReq))
+	handler.WriteHeaderAndProto(h.authServer.Login(ctx, loginReq))
 }
 
 // CreateUsers 批量创建用户
@@ -160,6 +163,24 @@ func (h *HTTPServer) UpdateUserPassword(req *restful.Request, rsp *restful.Respo
 	handler.WriteHeaderAndProto(h.authServer.UpdateUserPassword(ctx, user))
 }
 
+// ChangeExpiredPassword 密码过期的用户通过原密码修改密码
+func (h *HTTPServer) ChangeExpiredPassword(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	user := &apisecurity.ModifyUserPassword{}
+
+	ctx, err := handler.Parse(user)
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.authServer.ChangeExpiredPassword(ctx, user))
+}
+
 // DeleteUsers 批量删除用户
 func (h *HTTPServer) DeleteUsers(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -246,6 +267,37 @@ func (h *HTTPServer) ResetUserToken(req *restful.Request, rsp *restful.Response)
 	handler.WriteHeaderAndProto(h.authServer.ResetUserToken(ctx, user))
 }
 
+// UnlockUser 解除用户的登录锁定
+func (h *HTTPServer) UnlockUser(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	user := &apisecurity.User{}
+
+	ctx, err := handler.Parse(user)
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.authServer.UnlockUser(ctx, user))
+}
+
+// UnlockLoginAddress 解除来源地址的登录锁定
+func (h *HTTPServer) UnlockLoginAddress(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	ctx := handler.ParseHeaderContext()
+	address := req.QueryParameter("address")
+
+	handler.WriteHeaderAndProto(h.authServer.UnlockLoginAddress(ctx, address))
+}
+
 // CreateGroup 创建用户组
 func (h *HTTPServer) CreateGroup(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -159,6 +159,14 @@ func enrichUpdateUserPasswordApiDocs(r *restful.RouteBuilder) *restful.RouteBuil
 		Notes(enrichUpdateUserPasswordApiNotes)
 }
 
+func enrichChangeExpiredPasswordApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("修改过期的用户密码").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(apisecurity.ModifyUserPassword{}, "change expired password").
+		Notes(enrichChangeExpiredPasswordApiNotes)
+}
+
 func enrichGetUserTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("获取用户Token").
@@ -183,6 +191,23 @@ func enrichResetUserTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 		Notes(enrichResetUserTokenApiNotes)
 }
 
+func enrichUnlockUserApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("解除用户登录锁定").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(apisecurity.User{}, "unlock user").
+		Notes(enrichUnlockUserApiNotes)
+}
+
+func enrichUnlockLoginAddressApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("解除来源地址登录锁定").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.QueryParameter("address", "被锁定的来源IP").DataType("string").
+			Required(true)).
+		Notes(enrichUnlockLoginAddressApiNotes)
+}
+
 func enrichCreateGroupApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("创建用户组").
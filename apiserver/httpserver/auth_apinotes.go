@@ -424,6 +424,9 @@ POST /core/v1/user/login
 |               | role    | string | 当前用户角色, (admin:超级账户, main:主账户, sub:子账户) |
 |               | user_id | string | 当前用户ID                                              |
 
+密码超过有效期时返回 401000，loginResponse 中只包含 user_id、owner_id 以及 name，不返回 token，
+需要通过 PUT /core/v1/user/password/expired 修改密码
+
 `
 
 	enrichGetUsersApiNotes = `
@@ -620,6 +623,33 @@ Header X-Polaris-Token: {访问凭据}
 
 
 响应示例：
+`
+
+	enrichChangeExpiredPasswordApiNotes = `
+密码超过有效期的用户通过原密码修改密码，不需要携带访问凭据，修改成功后直接返回登录结果。
+原密码错误同样计入登录失败次数，密码没有过期时需要登录后通过 PUT /core/v1/user/password 修改
+
+请求示例：
+
+~~~
+PUT /core/v1/user/password/expired
+~~~
+
+~~~json
+{
+	"id": "xxx",
+	"old_password": "xxx",
+	"new_password": "xxx"
+}
+~~~
+
+| 参数名       | 类型   | 描述                         | 是否必填 |
+|--------------|--------|----------------------------|---------|
+| id           | string | 用户ID，登录接口在密码过期时返回 | 是       |
+| old_password | string | 旧密码                       | 是       |
+| new_password | string | 新密码，需要满足密码策略       | 是       |
+
+应答示例与登录接口相同
 `
 
 	enrichGetUserTokenApiNotes = `
@@ -705,6 +735,66 @@ Header X-Polaris-Token: {访问凭据}
 | id     | string | 用户ID | 是       |
 
 
+响应示例：
+
+~~~json
+{
+	"code": 200000,
+	"info": "execute success"
+}
+~~~
+`
+
+	enrichUnlockUserApiNotes = `
+解除用户因连续登录失败而被临时锁定的状态, 需使用主账户或者超级账户进行操作, 不允许解除自身的锁定
+
+登录锁定未配置 redis 时, 失败记录只保存在各个节点的内存中, 解除锁定只对处理该请求的节点生效, 需要对每个节点分别调用
+
+请求示例：
+
+~~~
+PUT /core/v1/user/unlock
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+~~~json
+{
+	"id": "xxx"
+}
+~~~
+
+| 参数名 | 类型   | 描述   | 是否必填 |
+|--------|--------|------|---------|
+| id     | string | 用户ID | 是       |
+
+
+响应示例：
+
+~~~json
+{
+	"code": 200000,
+	"info": "execute success"
+}
+~~~
+`
+
+	enrichUnlockLoginAddressApiNotes = `
+解除来源IP因连续登录失败而被临时锁定的状态, 仅超级账户可以操作
+
+登录锁定未配置 redis 时, 失败记录只保存在各个节点的内存中, 解除锁定只对处理该请求的节点生效, 需要对每个节点分别调用
+
+请求示例：
+
+~~~
+PUT /core/v1/user/login/address/unlock?address=127.0.0.1
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+| 参数名  | 类型   | 描述           | 是否必填 |
+|---------|--------|--------------|---------|
+| address | string | 被锁定的来源IP | 是       |
+
+
 响应示例：
 
 ~~~json
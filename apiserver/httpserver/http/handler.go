@@ -41,6 +41,8 @@ import (
 	"github.com/polarismesh/polaris/apiserver/httpserver/i18n"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -86,16 +88,25 @@ func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
 	platformToken := h.Request.HeaderParameter("Platform-Token")
 	token := h.Request.HeaderParameter("Polaris-Token")
 	authToken := h.Request.HeaderParameter(utils.HeaderAuthTokenKey)
-	ctx := context.Background()
+	ctx := tracing.ContextWithSpanFrom(context.Background(), h.Request.Request.Context())
 	ctx = context.WithValue(ctx, utils.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
+	ctx = context.WithValue(ctx, utils.ContextClientAddress, h.Request.Request.RemoteAddr)
+	if identity := secure.ParsePeerIdentity(h.Request.Request.TLS); identity != nil {
+		ctx = context.WithValue(ctx, utils.ContextClientCertIdentity, identity)
+	}
 	if token != "" {
 		ctx = context.WithValue(ctx, utils.StringContext("polaris-token"), token)
 	}
 	if authToken != "" {
 		ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, authToken)
 	}
+	if sourceService := h.Request.HeaderParameter(utils.HeaderSourceServiceKey); sourceService != "" {
+		ctx = context.WithValue(ctx, utils.ContextSourceServiceKey, sourceService)
+		ctx = context.WithValue(ctx, utils.ContextSourceNamespaceKey,
+			h.Request.HeaderParameter(utils.HeaderSourceNamespaceKey))
+	}
 
 	var operator string
 	addrSlice := strings.Split(h.Request.Request.RemoteAddr, ":")
@@ -131,11 +142,14 @@ func (h *Handler) ParseHeaderContext() context.Context {
 	token := h.Request.HeaderParameter("Polaris-Token")
 	authToken := h.Request.HeaderParameter(utils.HeaderAuthTokenKey)
 
-	ctx := context.Background()
+	ctx := tracing.ContextWithSpanFrom(context.Background(), h.Request.Request.Context())
 	ctx = context.WithValue(ctx, utils.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
 	ctx = context.WithValue(ctx, utils.ContextClientAddress, h.Request.Request.RemoteAddr)
+	if identity := secure.ParsePeerIdentity(h.Request.Request.TLS); identity != nil {
+		ctx = context.WithValue(ctx, utils.ContextClientCertIdentity, identity)
+	}
 	if token != "" {
 		ctx = context.WithValue(ctx, utils.StringContext("polaris-token"), token)
 	}
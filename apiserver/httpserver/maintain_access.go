@@ -18,10 +18,15 @@
 package httpserver
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -30,6 +35,8 @@ import (
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/http"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/maintain"
 )
@@ -52,6 +59,24 @@ func (h *HTTPServer) GetMaintainAccessServer() *restful.WebService {
 	ws.Route(enrichReleaseLeaderElectionApiDocs(ws.POST("/leaders/release").To(h.ReleaseLeaderElection)))
 	ws.Route(enrichGetCMDBInfoApiDocs(ws.GET("/cmdb/info").To(h.GetCMDBInfo)))
 	ws.Route(enrichGetReportClientsApiDocs(ws.GET("/report/clients").To(h.GetReportClients)))
+	ws.Route(enrichGetCacheStatusApiDocs(ws.GET("/cache/status").To(h.GetCacheStatus)))
+	ws.Route(enrichGetServiceCacheViewApiDocs(ws.GET("/cache/service").To(h.GetServiceCacheView)))
+	ws.Route(enrichDiffServiceCacheApiDocs(ws.GET("/cache/service/diff").To(h.DiffServiceCache)))
+	ws.Route(enrichResyncCacheApiDocs(ws.POST("/cache/resync").To(h.ResyncCache)))
+	ws.Route(enrichGetNodeStatusApiDocs(ws.GET("/cluster/node").To(h.GetNodeStatus)))
+	ws.Route(enrichListClusterNodesApiDocs(ws.GET("/cluster/nodes").To(h.ListClusterNodes)))
+	ws.Route(enrichReloadConfigApiDocs(ws.POST("/config/reload").To(h.ReloadConfig)))
+	ws.Route(enrichListMaintainJobsApiDocs(ws.GET("/jobs").To(h.ListMaintainJobs)))
+	ws.Route(enrichTriggerMaintainJobApiDocs(ws.POST("/jobs/trigger").To(h.TriggerMaintainJob)))
+	ws.Route(enrichEnableMaintainJobApiDocs(ws.POST("/jobs/enable").To(h.EnableMaintainJob)))
+	ws.Route(enrichPauseMaintainJobsApiDocs(ws.POST("/jobs/pause").To(h.PauseMaintainJobs)))
+	ws.Route(enrichGetMaintainJobRunsApiDocs(ws.GET("/jobs/runs").To(h.GetMaintainJobRuns)))
+	ws.Route(enrichGetWhitelistRulesApiDocs(ws.GET("/whitelist/rules").To(h.GetWhitelistRules)))
+	ws.Route(enrichAddWhitelistRuleApiDocs(ws.POST("/whitelist/rules").To(h.AddWhitelistRule)))
+	ws.Route(enrichDeleteWhitelistRuleApiDocs(ws.POST("/whitelist/rules/delete").To(h.DeleteWhitelistRule)))
+	ws.Route(enrichGetServiceDependenciesApiDocs(ws.GET("/service/dependencies").To(h.GetServiceDependencies)))
+	ws.Route(enrichGetInstanceEventsApiDocs(ws.GET("/instance/events").To(h.GetInstanceEvents)))
+	ws.Route(enrichBackupStoreApiDocs(ws.GET("/store/backup").To(h.BackupStore)))
 	return ws
 }
 
@@ -279,13 +304,380 @@ func (h *HTTPServer) GetCMDBInfo(req *restful.Request, rsp *restful.Response) {
 	_ = rsp.WriteAsJson(ret)
 }
 
+// GetCacheStatus 查看各个缓存的同步状态
+func (h *HTTPServer) GetCacheStatus(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.GetCacheStatus(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetServiceCacheView 查看本节点缓存中指定服务的实例及 revision
+func (h *HTTPServer) GetServiceCacheView(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	ret, err := h.maintainServer.GetServiceCacheView(ctx, params["namespace"], params["service"])
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// DiffServiceCache 对比本节点缓存与存储或者其他节点缓存中指定服务的实例
+// query参数：namespace、service，必须
+//
+//	peer，可选，对比的集群节点 host，为空时和存储对比
+func (h *HTTPServer) DiffServiceCache(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	ret, err := h.maintainServer.DiffServiceCache(ctx, params["namespace"], params["service"], params["peer"])
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ResyncCache 强制指定缓存从存储全量同步
+func (h *HTTPServer) ResyncCache(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param struct {
+		Name string `json:"name"`
+	}
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.ResyncCache(ctx, param.Name); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// GetNodeStatus 查看本节点的运行状态
+func (h *HTTPServer) GetNodeStatus(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.GetNodeStatus(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ListClusterNodes 查看集群中所有存活的节点
+func (h *HTTPServer) ListClusterNodes(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.ListClusterNodes(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ReloadConfig 重新加载服务端配置文件
+func (h *HTTPServer) ReloadConfig(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.ReloadConfig(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ListMaintainJobs 查看本节点的运维任务以及运行状态
+func (h *HTTPServer) ListMaintainJobs(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.ListMaintainJobs(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// TriggerMaintainJob 在本节点立即执行一次运维任务
+func (h *HTTPServer) TriggerMaintainJob(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param maintain.MaintainJobReq
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.TriggerMaintainJob(ctx, &param)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// EnableMaintainJob 开启或者关闭运维任务的定时执行
+func (h *HTTPServer) EnableMaintainJob(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param maintain.MaintainJobReq
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.EnableMaintainJob(ctx, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// PauseMaintainJobs 暂停或者恢复全部运维任务的定时执行
+func (h *HTTPServer) PauseMaintainJobs(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param maintain.MaintainJobPauseReq
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.PauseMaintainJobs(ctx, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// GetMaintainJobRuns 查询运维任务的执行记录
+// query参数：name，可选，为空时查询全部任务
+//
+//	offset、limit，可选，limit 默认为 100
+func (h *HTTPServer) GetMaintainJobRuns(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	param := &maintain.MaintainJobRunsReq{Name: params["name"]}
+	var err error
+	if param.Offset, param.Limit, err = parsePageParams(params, 100); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ret, err := h.maintainServer.GetMaintainJobRuns(ctx, param)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetWhitelistRules 查询白名单的运行时规则
+func (h *HTTPServer) GetWhitelistRules(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.GetWhitelistRules(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// AddWhitelistRule 新增白名单的运行时规则
+func (h *HTTPServer) AddWhitelistRule(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param model.WhitelistRule
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.AddWhitelistRule(ctx, &param)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// DeleteWhitelistRule 删除白名单的运行时规则
+func (h *HTTPServer) DeleteWhitelistRule(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var param maintain.WhitelistRuleDeleteReq
+	if err := httpcommon.ParseJsonBody(req, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.DeleteWhitelistRule(ctx, &param); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// GetServiceDependencies 查询服务依赖关系
+func (h *HTTPServer) GetServiceDependencies(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	param := &maintain.ServiceDependenciesReq{
+		CallerNamespace: params["callerNamespace"],
+		CallerService:   params["callerService"],
+		CallerHost:      params["callerHost"],
+		CalleeNamespace: params["calleeNamespace"],
+		CalleeService:   params["calleeService"],
+	}
+	var err error
+	if param.Offset, param.Limit, err = parsePageParams(params, 100); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ret, err := h.maintainServer.GetServiceDependencies(ctx, param)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetInstanceEvents 查询服务或者实例的事件时间线
+func (h *HTTPServer) GetInstanceEvents(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	param := &maintain.InstanceEventsReq{
+		Namespace:  params["namespace"],
+		Service:    params["service"],
+		InstanceID: params["instanceId"],
+	}
+	if value := params["eventType"]; value != "" {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				param.EventTypes = append(param.EventTypes, model.InstanceEventType(eventType))
+			}
+		}
+	}
+	var err error
+	if param.StartTime, err = parseTimeParam(params, "startTime"); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if param.EndTime, err = parseTimeParam(params, "endTime"); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if param.Offset, param.Limit, err = parsePageParams(params, 100); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ret, err := h.maintainServer.GetInstanceEvents(ctx, param)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// BackupStore 以备份文件的格式导出存储中的数据，服务端运行时持有 boltdb 文件锁，boltdb 存储只能通过该接口备份
+// query参数：resources，可选，需要导出的资源，多个资源使用逗号分隔
+func (h *HTTPServer) BackupStore(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	var resources []string
+	for _, resource := range strings.Split(params["resources"], ",") {
+		if resource = strings.TrimSpace(resource); resource != "" {
+			resources = append(resources, resource)
+		}
+	}
+
+	// 备份文件写出一部分之后响应码已经无法修改，导出失败时备份文件缺少结尾的校验行，恢复时会校验失败
+	w := &lazyResponseWriter{rsp: rsp}
+	bw := bufio.NewWriter(w)
+	err := h.maintainServer.BackupStore(ctx, bw, resources)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		return
+	}
+	log.Errorf("[MAINTAIN] backup store err: %s", err.Error())
+	if !w.written {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+	}
+}
+
+// lazyResponseWriter 第一次写入数据时才写出响应头，在此之前出错时仍然可以返回错误的响应码
+type lazyResponseWriter struct {
+	rsp     *restful.Response
+	written bool
+}
+
+func (w *lazyResponseWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.written = true
+		w.rsp.Header().Set("Content-Type", "application/x-ndjson")
+		w.rsp.Header().Set("Content-Disposition", "attachment; filename=polaris-backup.jsonl")
+		w.rsp.WriteHeader(http.StatusOK)
+	}
+	return w.rsp.Write(p)
+}
+
+// parseTimeParam 解析时间参数，支持 RFC3339 格式以及秒级时间戳，未指定时返回零值
+func parseTimeParam(params map[string]string, key string) (time.Time, error) {
+	value := params[key]
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s", key)
+	}
+	return t, nil
+}
+
+// parsePageParams 解析分页参数，未指定 limit 时使用 defaultLimit
+func parsePageParams(params map[string]string, defaultLimit uint32) (uint32, uint32, error) {
+	var offset, limit uint32 = 0, defaultLimit
+	if value, ok := params["offset"]; ok {
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, 0, errors.New("invalid offset")
+		}
+		offset = uint32(v)
+	}
+	if value, ok := params["limit"]; ok {
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || v == 0 {
+			return 0, 0, errors.New("invalid limit")
+		}
+		limit = uint32(v)
+	}
+	return offset, limit, nil
+}
+
 func initContext(req *restful.Request) context.Context {
-	ctx := context.Background()
+	ctx := tracing.ContextWithSpanFrom(context.Background(), req.Request.Context())
 
 	authToken := req.HeaderParameter(utils.HeaderAuthTokenKey)
 	if authToken != "" {
 		ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, authToken)
 	}
+	if identity := secure.ParsePeerIdentity(req.Request.TLS); identity != nil {
+		ctx = context.WithValue(ctx, utils.ContextClientCertIdentity, identity)
+	}
 
 	return ctx
 }
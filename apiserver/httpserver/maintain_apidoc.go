@@ -132,3 +132,134 @@ func enrichGetReportClientsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilde
 		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
 		Notes(enrichReleaseLeaderElectionApiNotes)
 }
+
+func enrichGetCacheStatusApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询缓存同步状态").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetCacheStatusApiNotes)
+}
+
+func enrichGetServiceCacheViewApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询本节点缓存中的服务实例").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType("string").Required(true)).
+		Param(restful.QueryParameter("service", "服务名").DataType("string").Required(true)).
+		Notes(enrichGetServiceCacheViewApiNotes)
+}
+
+func enrichDiffServiceCacheApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("对比服务实例缓存的一致性").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType("string").Required(true)).
+		Param(restful.QueryParameter("service", "服务名").DataType("string").Required(true)).
+		Param(restful.QueryParameter("peer", "对比的节点地址，为空时和存储对比").DataType("string").Required(false)).
+		Notes(enrichDiffServiceCacheApiNotes)
+}
+
+func enrichResyncCacheApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("强制缓存全量同步").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichResyncCacheApiNotes)
+}
+
+func enrichGetNodeStatusApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询本节点运行状态").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetNodeStatusApiNotes)
+}
+
+func enrichListClusterNodesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询集群节点列表").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichListClusterNodesApiNotes)
+}
+
+func enrichReloadConfigApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("重新加载配置文件").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichReloadConfigApiNotes)
+}
+
+func enrichListMaintainJobsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询运维任务").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichListMaintainJobsApiNotes)
+}
+
+func enrichTriggerMaintainJobApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("立即执行运维任务").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichTriggerMaintainJobApiNotes)
+}
+
+func enrichEnableMaintainJobApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("开启或关闭运维任务").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichEnableMaintainJobApiNotes)
+}
+
+func enrichPauseMaintainJobsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("暂停或恢复全部运维任务").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichPauseMaintainJobsApiNotes)
+}
+
+func enrichGetMaintainJobRunsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询运维任务执行记录").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetMaintainJobRunsApiNotes)
+}
+
+func enrichGetWhitelistRulesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询白名单运行时规则").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetWhitelistRulesApiNotes)
+}
+
+func enrichAddWhitelistRuleApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("新增白名单运行时规则").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichAddWhitelistRuleApiNotes)
+}
+
+func enrichDeleteWhitelistRuleApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("删除白名单运行时规则").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichDeleteWhitelistRuleApiNotes)
+}
+
+func enrichGetServiceDependenciesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询服务依赖关系").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetServiceDependenciesApiNotes)
+}
+
+func enrichGetInstanceEventsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询实例事件时间线").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichGetInstanceEventsApiNotes)
+}
+
+func enrichBackupStoreApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("导出存储数据备份").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Notes(enrichBackupStoreApiNotes)
+}
@@ -208,5 +208,545 @@ Header X-Polaris-Token: {访问凭据}
 {
     "ElectKey": "polaris.checker"
 }
+`
+	enrichGetCacheStatusApiNotes = `
+请求示例：
+
+~~~
+GET /maintain/v1/cache/status
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+[
+ {
+  "name": "instance",
+  "firstUpdate": false,
+  "lastFetchTime": "2023-03-01T10:00:00+08:00",
+  "lastMtimes": {
+   "instance": "2023-03-01T09:59:58+08:00"
+  },
+  "count": 120
+ }
+]
+~~~
+`
+	enrichGetServiceCacheViewApiNotes = `
+请求示例：
+
+~~~
+GET /maintain/v1/cache/service?namespace=default&service=polaris.checker
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "namespace": "default",
+ "service": "polaris.checker",
+ "serviceId": "fbca9bfa04ae4ead86e1ecf5811e32a9",
+ "revision": "6d1a3b1ef4b9f1e9c3b0d1b8f0c7e6a5",
+ "instances": {
+  "ae8f1d8d8b5d4e5c9b2a4f1e3c6d7b8a": "a1b2c3d4e5f60718293a4b5c6d7e8f90"
+ }
+}
+~~~
+`
+	enrichDiffServiceCacheApiNotes = `
+请求示例：
+
+~~~
+GET /maintain/v1/cache/service/diff?namespace=default&service=polaris.checker&peer=127.0.0.2
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+| 参数名    | 类型   | 描述                                            | 是否必填 |
+| --------- | ------ | ----------------------------------------------- | -------- |
+| namespace | string | 命名空间                                        | 是       |
+| service   | string | 服务名                                          | 是       |
+| peer      | string | 对比的集群节点 host，为空时和存储中的数据对比   | 否       |
+
+返回示例：
+~~~
+{
+ "namespace": "default",
+ "service": "polaris.checker",
+ "target": "127.0.0.2",
+ "consistent": false,
+ "localRevision": "6d1a3b1ef4b9f1e9c3b0d1b8f0c7e6a5",
+ "targetRevision": "0e9b8a7c6d5f4e3d2c1b0a9f8e7d6c5b",
+ "localCount": 2,
+ "targetCount": 1,
+ "onlyLocal": ["ae8f1d8d8b5d4e5c9b2a4f1e3c6d7b8a"],
+ "onlyTarget": [],
+ "changed": []
+}
+~~~
+`
+	enrichResyncCacheApiNotes = `
+请求示例：
+
+~~~
+POST /maintain/v1/cache/resync
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "name": "instance"
+}
+~~~
+`
+	enrichGetNodeStatusApiNotes = `
+请求示例：
+
+~~~
+GET /maintain/v1/cluster/node
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "host": "10.0.0.1",
+ "version": "v1.16.0",
+ "revision": "3b5c9a1",
+ "startTime": "2023-03-01T10:00:00+08:00",
+ "uptime": "26h3m12s",
+ "listeners": [
+  {
+   "protocol": "http",
+   "port": 8090,
+   "connCount": 12
+  },
+  {
+   "protocol": "grpc",
+   "port": 8091,
+   "connCount": 230
+  }
+ ],
+ "caches": [
+  {
+   "name": "instance",
+   "firstUpdate": false,
+   "lastFetchTime": "2023-03-02T12:03:11+08:00",
+   "lastMtimes": {
+    "instance": "2023-03-02T12:03:09+08:00"
+   },
+   "count": 120
+  }
+ ],
+ "checkerLeader": true
+}
+~~~
+
+connCount 为 -1 表示该监听没有开启连接限制，无法统计连接数
+`
+	enrichListClusterNodesApiNotes = `
+根据健康检查服务（默认为 Polaris 命名空间下的 polaris.checker）下自注册的实例列出集群中的节点，
+hashRanges 为节点在健康检查一致性 hash 环上负责的区间，以处理请求的节点的视图计算；
+status 为通过节点自注册的 http 端口获取的运行状态，获取失败时返回 error
+
+请求示例：
+
+~~~
+GET /maintain/v1/cluster/nodes
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+[
+ {
+  "host": "10.0.0.1",
+  "version": "v1.16.0",
+  "healthy": true,
+  "isolated": false,
+  "listeners": [
+   {
+    "protocol": "http",
+    "port": 8090,
+    "connCount": 0
+   }
+  ],
+  "checkerLeader": true,
+  "hashRanges": [
+   {
+    "start": 0,
+    "end": 2147483647
+   }
+  ],
+  "hashRatio": 0.5,
+  "status": {
+   "host": "10.0.0.1",
+   "version": "v1.16.0",
+   "uptime": "26h3m12s"
+  }
+ },
+ {
+  "host": "10.0.0.2",
+  "version": "v1.16.0",
+  "healthy": true,
+  "isolated": false,
+  "listeners": [
+   {
+    "protocol": "http",
+    "port": 8090,
+    "connCount": 0
+   }
+  ],
+  "checkerLeader": false,
+  "hashRanges": [
+   {
+    "start": 2147483648,
+    "end": 4294967295
+   }
+  ],
+  "hashRatio": 0.5,
+  "error": "Get \"http://10.0.0.2:8090/maintain/v1/cluster/node\": context deadline exceeded"
+ }
+]
+~~~
+`
+	enrichReloadConfigApiNotes = `
+重新读取服务端配置文件，只重新加载发生变化的配置项。支持热加载的配置项：
+
+- plugin 下 ratelimit、whitelist 等支持重新加载的插件的配置，插件名称不能修改
+- bootstrap.logger 下各个日志的输出级别
+- apiservers 下已有 apiserver 的配置，会重启对应的 apiserver
+- cache.changeFeed、cache.snapshot 的间隔等配置，开关不能修改
+- healthcheck 的 minCheckInterval、maxCheckInterval、clientCheckTtl
+
+其他配置项发生变化时拒绝整个配置文件的重新加载，并返回不支持热加载的配置项
+
+请求示例：
+
+~~~
+POST /maintain/v1/config/reload
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "reloaded": [
+  "plugin.ratelimit",
+  "bootstrap.logger.naming"
+ ]
+}
+~~~
+`
+	enrichListMaintainJobsApiNotes = `
+查询本节点配置的运维任务以及运行状态，leader 表示本节点是否为该任务的 leader，只有 leader 会定时执行任务。
+运行时开启、关闭以及暂停的状态只在本节点生效，重启后以配置文件为准
+
+请求示例：
+
+~~~
+GET /maintain/v1/jobs
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "paused": false,
+ "jobs": [
+  {
+   "name": "DeleteUnHealthyInstance",
+   "cronSpec": "*/1 * * * *",
+   "enable": true,
+   "running": false,
+   "leader": true,
+   "option": {
+    "instanceDeleteTimeout": "60m"
+   }
+  }
+ ]
+}
+~~~
+`
+	enrichTriggerMaintainJobApiNotes = `
+在本节点立即异步执行一次运维任务，不受暂停的影响，任务正在执行时返回失败。
+手动执行和定时执行使用同一个选主锁，本节点参与选主时只有 leader 可以执行；本节点没有参与选主时，
+其他节点是该任务的 leader 则返回失败，需要在返回的 leader 节点上执行。
+只能执行配置文件中配置过的任务，返回本次执行的记录，执行结果通过 /maintain/v1/jobs/runs 查询
+
+请求示例：
+
+~~~
+POST /maintain/v1/jobs/trigger
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "name": "DeleteUnHealthyInstance"
+}
+~~~
+
+返回示例：
+~~~
+{
+ "id": "4b1c8f0e2d6a4c4f9a3e1b7d5c2f8a90",
+ "name": "DeleteUnHealthyInstance",
+ "trigger": "manual",
+ "operator": "polaris",
+ "host": "10.0.0.1",
+ "status": "running",
+ "message": "",
+ "affected": null,
+ "startTime": "2023-05-01T10:00:00+08:00",
+ "endTime": "0001-01-01T00:00:00Z"
+}
+~~~
+`
+	enrichEnableMaintainJobApiNotes = `
+开启或者关闭本节点运维任务的定时执行，配置文件中未开启的任务在首次开启时完成初始化并参与选主
+
+请求示例：
+
+~~~
+POST /maintain/v1/jobs/enable
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "name": "DeleteEmptyAutoCreatedService",
+    "enable": true
+}
+~~~
+`
+	enrichPauseMaintainJobsApiNotes = `
+暂停或者恢复本节点全部运维任务的定时执行，暂停期间仍然可以手动触发
+
+请求示例：
+
+~~~
+POST /maintain/v1/jobs/pause
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "pause": true
+}
+~~~
+`
+	enrichGetMaintainJobRunsApiNotes = `
+查询运维任务的执行记录，按照开始时间倒序返回，需要存储插件支持保存执行记录。
+每个任务只保留最新的 100 条执行记录，每条记录最多保存 1000 个影响的资源
+
+| 参数名 | 类型   | 描述                             | 是否必填 |
+| ------ | ------ | -------------------------------- | -------- |
+| name   | string | 任务名称，为空时查询全部任务     | 否       |
+| offset | uint32 | 分页偏移量，默认为 0             | 否       |
+| limit  | uint32 | 分页大小，默认为 100             | 否       |
+
+请求示例：
+
+~~~
+GET /maintain/v1/jobs/runs?name=DeleteUnHealthyInstance&offset=0&limit=10
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "total": 1,
+ "runs": [
+  {
+   "id": "4b1c8f0e2d6a4c4f9a3e1b7d5c2f8a90",
+   "name": "DeleteUnHealthyInstance",
+   "trigger": "cron",
+   "operator": "",
+   "host": "10.0.0.1",
+   "status": "success",
+   "message": "delete unhealthy instance count 2",
+   "affected": [
+    "1b2c3d4e",
+    "5f6a7b8c"
+   ],
+   "startTime": "2023-05-01T10:00:00+08:00",
+   "endTime": "2023-05-01T10:00:01+08:00"
+  }
+ ]
+}
+~~~
+`
+	enrichGetWhitelistRulesApiNotes = `
+查询白名单插件的运行时规则，运行时规则保存在存储层，白名单插件开启 dynamic 后定期加载
+
+请求示例：
+
+~~~
+GET /maintain/v1/whitelist/rules
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+[
+ {
+  "id": "6c1a1b4f0f4a4c2a9c6ad5c1a5c8e2a1",
+  "api": "/maintain/v1",
+  "cidr": "10.1.0.0/16",
+  "action": "allow",
+  "comment": "ops subnet",
+  "operator": "polaris",
+  "createTime": "2023-01-01T00:00:00+08:00"
+ }
+]
+~~~
+`
+	enrichAddWhitelistRuleApiNotes = `
+新增白名单插件的运行时规则，本节点立即生效，其他节点在白名单插件的同步周期 syncInterval 内生效
+
+| 参数名 | 类型 | 描述 | 是否必填 |
+| --- | --- | --- | --- |
+| api | string | 规则作用的接口路径前缀，HTTP 接口为 url 路径，gRPC 接口为方法全名，为空表示全部接口 | 否 |
+| cidr | string | IP 地址或者网段，支持 IPv6 | 是 |
+| action | string | allow 在配置文件的基础上放通匹配的地址，deny 拒绝匹配的地址且优先于任何放通规则，默认为 allow | 否 |
+| comment | string | 描述 | 否 |
+
+请求示例：
+
+~~~
+POST /maintain/v1/whitelist/rules
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "api": "/maintain/v1",
+    "cidr": "10.1.0.0/16",
+    "action": "allow",
+    "comment": "ops subnet"
+}
+~~~
+
+返回新增的规则，格式和查询接口一致
+`
+	enrichDeleteWhitelistRuleApiNotes = `
+删除白名单插件的运行时规则
+
+请求示例：
+
+~~~
+POST /maintain/v1/whitelist/rules/delete
+Header X-Polaris-Token: {访问凭据}
+
+{
+    "id": "6c1a1b4f0f4a4c2a9c6ad5c1a5c8e2a1"
+}
+~~~
+
+返回示例：
+~~~
+ok
+~~~
+`
+	enrichGetServiceDependenciesApiNotes = `
+查询服务发现统计插件记录的服务依赖关系，按照最近发现时间倒序返回，需要开启 discoverLocal 插件的 dependency 选项并且存储插件支持保存依赖关系。
+依赖关系以主调服务到被调服务为唯一标识，callerHosts 为最近发现的主调方地址，最多保留 16 个。
+主调服务优先取自客户端证书 SPIFFE ID（spiffe://{trust-domain}/ns/{namespace}/sa/{service}），此时 callerVerified 为 true，
+否则取自请求头中声明的来源服务，可能被伪造；没有声明来源服务的调用不会记录。
+依赖关系通过运维任务 CleanServiceDependencies 按照最近发现时间清理
+
+| 参数名          | 类型   | 描述                   | 是否必填 |
+| --------------- | ------ | ---------------------- | -------- |
+| calleeNamespace | string | 被调服务所在的命名空间 | 否       |
+| calleeService   | string | 被调服务名             | 否       |
+| callerNamespace | string | 主调服务所在的命名空间 | 否       |
+| callerService   | string | 主调服务名             | 否       |
+| callerHost      | string | 主调方地址             | 否       |
+| offset          | uint32 | 分页偏移量，默认为 0   | 否       |
+| limit           | uint32 | 分页大小，默认为 100   | 否       |
+
+请求示例，查询依赖 default 命名空间下 payment 服务的主调方：
+
+~~~
+GET /maintain/v1/service/dependencies?calleeNamespace=default&calleeService=payment
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "total": 1,
+ "dependencies": [
+  {
+   "callerNamespace": "default",
+   "callerService": "order",
+   "callerHosts": ["10.0.0.1", "10.0.0.2"],
+   "callerVerified": true,
+   "calleeNamespace": "default",
+   "calleeService": "payment",
+   "firstSeen": "2023-05-01T10:00:00+08:00",
+   "lastSeen": "2023-05-08T16:30:00+08:00"
+  }
+ ]
+}
+~~~
+`
+	enrichGetInstanceEventsApiNotes = `
+查询 discoverEventTimeline 插件记录的实例事件时间线，按照事件时间倒序返回，需要开启 discoverEventTimeline 插件并且存储插件支持保存实例事件。
+namespace + service 与 instanceId 至少需要指定一项
+
+| 参数名     | 类型   | 描述                                                                 | 是否必填 |
+| ---------- | ------ | -------------------------------------------------------------------- | -------- |
+| namespace  | string | 命名空间                                                             | 否       |
+| service    | string | 服务名                                                               | 否       |
+| instanceId | string | 实例 ID                                                              | 否       |
+| eventType  | string | 事件类型，多个类型以逗号分隔，例如 InstanceOffline,InstanceTurnUnHealth | 否       |
+| startTime  | string | 起始时间（包含），RFC3339 格式或者秒级时间戳                         | 否       |
+| endTime    | string | 结束时间（不包含），RFC3339 格式或者秒级时间戳                       | 否       |
+| offset     | uint32 | 分页偏移量，默认为 0                                                 | 否       |
+| limit      | uint32 | 分页大小，默认为 100                                                 | 否       |
+
+请求示例，查询 default 命名空间下 order 服务的实例下线以及健康检查失败事件：
+
+~~~
+GET /maintain/v1/instance/events?namespace=default&service=order&eventType=InstanceOffline,InstanceTurnUnHealth
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例：
+~~~
+{
+ "total": 1,
+ "events": [
+  {
+   "eventType": "InstanceTurnUnHealth",
+   "namespace": "default",
+   "service": "order",
+   "instanceId": "b6d3ab2cd7c8a0b2d6d3b1e8a2f0c4d1e5f6a7b8",
+   "host": "10.0.0.2",
+   "port": 8080,
+   "weight": 100,
+   "healthy": false,
+   "isolate": false,
+   "server": "10.0.0.1",
+   "createTime": "2023-05-08T16:30:00.123+08:00"
+  }
+ ]
+}
+~~~
+`
+	enrichBackupStoreApiNotes = `
+以 polaris-server backup 的备份文件格式导出存储中的数据，导出的文件可以通过 polaris-server restore 导入。
+MySQL 存储的数据在同一个一致性快照中读取，boltdb 存储的数据从数据库文件的快照副本中读取。
+服务端运行时持有 boltdb 数据库文件的锁，boltdb 存储只能通过该接口备份。
+导出过程中出错时，如果响应已经开始写出，返回的备份文件缺少结尾的校验行，导入时会校验失败。
+
+| 参数名    | 类型   | 描述                                                       | 是否必填 |
+| --------- | ------ | ---------------------------------------------------------- | -------- |
+| resources | string | 需要导出的资源，多个资源以逗号分隔，例如 namespace,service，默认导出全部资源 | 否       |
+
+请求示例，导出命名空间数据：
+
+~~~
+GET /maintain/v1/store/backup?resources=namespace
+Header X-Polaris-Token: {访问凭据}
+~~~
+
+返回示例，每一行为一条 JSON 记录，第一行为文件头，最后一行为记录数以及校验和：
+~~~
+{"type":"header","version":1,"store":"boltdbStore","createTime":"2023-05-08T16:30:00+08:00","resources":["namespace"]}
+{"type":"record","resource":"namespace","key":"default","namespace":"default","data":{...},"checksum":"..."}
+{"type":"footer","count":1,"checksum":"..."}
+~~~
 `
 )
@@ -19,6 +19,7 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/apiserver"
@@ -40,6 +42,7 @@ import (
 	commonlog "github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/config"
 	"github.com/polarismesh/polaris/maintain"
@@ -128,11 +131,7 @@ func (h *HTTPServer) Initialize(_ context.Context, option map[string]interface{}
 		if err != nil {
 			return err
 		}
-		h.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
-		}
+		h.tlsInfo = tlsConfig.ToTLSInfo()
 	}
 
 	metrics.SetMetricsPort(int32(h.listenPort))
@@ -239,7 +238,15 @@ func (h *HTTPServer) Run(errCh chan error) {
 	if h.tlsInfo.IsEmpty() {
 		err = server.Serve(ln)
 	} else {
-		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+		// 证书由 tls.Config 负责加载，证书文件变更时自动热加载
+		var tlsConfig *tls.Config
+		if tlsConfig, err = h.tlsInfo.ServerConfig(); err != nil {
+			log.Errorf("build tls config err: %s", err.Error())
+			errCh <- err
+			return
+		}
+		server.TLSConfig = tlsConfig
+		err = server.ServeTLS(ln, "", "")
 	}
 	if err != nil {
 		log.Errorf("%+v", err)
@@ -416,6 +423,13 @@ func (h *HTTPServer) enablePrometheusAccess(wsContainer *restful.Container) {
 
 // process 在接收和回复时统一处理请求
 func (h *HTTPServer) process(req *restful.Request, rsp *restful.Response, chain *restful.FilterChain) {
+	ctx, span := tracing.StartServerSpan(tracing.ExtractHTTP(req.Request.Context(), req.Request.Header),
+		req.Request.Method+" "+req.Request.URL.Path,
+		attribute.String("http.method", req.Request.Method), attribute.String("http.target", req.Request.URL.Path))
+	defer span.End()
+	// 后续构造业务上下文时从 Request 中继承 span
+	req.Request = req.Request.WithContext(ctx)
+
 	func() {
 		if err := h.preprocess(req, rsp); err != nil {
 			return
@@ -425,6 +439,7 @@ func (h *HTTPServer) process(req *restful.Request, rsp *restful.Response, chain
 	}()
 
 	h.postProcess(req, rsp)
+	span.SetAttributes(attribute.Int("http.status_code", rsp.StatusCode()))
 }
 
 // preprocess 请求预处理
@@ -535,11 +550,11 @@ func (h *HTTPServer) enterAuth(req *restful.Request, rsp *restful.Response) erro
 	rid := req.HeaderParameter("Request-Id")
 
 	address := req.Request.RemoteAddr
-	segments := strings.Split(address, ":")
-	if len(segments) != 2 {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
 		return nil
 	}
-	if !h.whitelist.Contain(segments[0]) {
+	if !h.whitelist.Contain(plugin.WhitelistEntry{IP: host, API: req.Request.URL.Path}) {
 		log.Error("http access is not allowed",
 			zap.String("client", address),
 			utils.ZapRequestID(rid))
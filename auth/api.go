@@ -43,7 +43,10 @@ type AuthServer interface {
 	AfterResourceOperation(afterCtx *model.AcquireContext) error
 
 	// Login 登录动作
-	Login(req *apisecurity.LoginRequest) *apiservice.Response
+	Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response
+
+	// ChangeExpiredPassword 密码过期的用户通过原密码修改密码，修改成功后直接登录
+	ChangeExpiredPassword(ctx context.Context, req *apisecurity.ModifyUserPassword) *apiservice.Response
 
 	// UserOperator 用户操作
 	UserOperator
@@ -97,6 +100,12 @@ type UserOperator interface {
 
 	// ResetUserToken 重置用户的token
 	ResetUserToken(ctx context.Context, user *apisecurity.User) *apiservice.Response
+
+	// UnlockUser 解除用户因登录失败次数过多而被临时锁定的状态
+	UnlockUser(ctx context.Context, user *apisecurity.User) *apiservice.Response
+
+	// UnlockLoginAddress 解除来源地址因登录失败次数过多而被临时锁定的状态
+	UnlockLoginAddress(ctx context.Context, address string) *apiservice.Response
 }
 
 // GroupOperator 用户组相关操作
@@ -18,7 +18,7 @@
 package defaultauth
 
 import (
-	"encoding/json"
+	"github.com/mitchellh/mapstructure"
 
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
@@ -32,13 +32,17 @@ type defaultAuthChecker struct {
 
 // Initialize 执行初始化动作
 func (d *defaultAuthChecker) Initialize(options *auth.Config, s store.Store, cacheMgn *cache.CacheManager) error {
-	contentBytes, err := json.Marshal(options.Option)
+	// 密码策略、登录锁定等嵌套配置由 yaml 解析而来，需要通过 mapstructure 进行解析
+	cfg := DefaultAuthConfig()
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		TagName:    "json",
+		Result:     cfg,
+	})
 	if err != nil {
 		return err
 	}
-
-	cfg := DefaultAuthConfig()
-	if err := json.Unmarshal(contentBytes, cfg); err != nil {
+	if err := decoder.Decode(options.Option); err != nil {
 		return err
 	}
 
@@ -178,7 +178,7 @@ func canDowngradeAnonymous(authCtx *model.AcquireContext, err error) bool {
 }
 
 // VerifyCredential 对 token 进行检查验证，并将 verify 过程中解析出的数据注入到 model.AcquireContext 中
-// step 1. 首先对 token 进行解析，获取相关的数据信息，注入到整个的 AcquireContext 中
+// step 1. 首先对 token 进行解析，获取相关的数据信息，注入到整个的 AcquireContext 中（未携带 token 时使用客户端证书映射的身份）
 // step 2. 最后对 token 进行一些验证步骤的执行
 // step 3. 兜底措施：如果开启了鉴权的非严格模式，则根据错误的类型，判断是否转为匿名用户进行访问
 //   - 如果不是访问权限控制相关模块（用户、用户组、权限策略），不得转为匿名用户
@@ -187,6 +187,13 @@ func (d *defaultAuthChecker) VerifyCredential(authCtx *model.AcquireContext) err
 
 	checkErr := func() error {
 		authToken := utils.ParseAuthToken(authCtx.GetRequestContext())
+		if authToken == "" {
+			// 没有携带 token 时，尝试使用 mTLS 客户端证书映射的用户/用户组身份
+			if authToken = d.findCertIdentityToken(authCtx.GetRequestContext()); authToken != "" {
+				authCtx.SetRequestContext(context.WithValue(authCtx.GetRequestContext(),
+					utils.ContextAuthTokenKey, authToken))
+			}
+		}
 		operator, err := d.decodeToken(authToken)
 		if err != nil {
 			log.Error("[Auth][Checker] decode token", zap.Error(err))
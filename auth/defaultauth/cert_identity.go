@@ -0,0 +1,117 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CertIdentityMapping 客户端证书身份到北极星用户/用户组的映射
+// SpiffeID、CommonName、DNSName 只能设置其中一个，UserName、GroupID 只能设置其中一个
+type CertIdentityMapping struct {
+	// SpiffeID 证书 URI SAN 中的 SPIFFE ID
+	SpiffeID string `json:"spiffeId"`
+	// CommonName 证书 Subject 中的 CN
+	CommonName string `json:"commonName"`
+	// DNSName 证书 SAN 中的 DNS 名称
+	DNSName string `json:"dnsName"`
+	// UserName 映射到的用户名称
+	UserName string `json:"userName"`
+	// OwnerName 用户所属的主账户名称，为空时表示 UserName 本身就是主账户
+	OwnerName string `json:"ownerName"`
+	// GroupID 映射到的用户组 ID
+	GroupID string `json:"groupId"`
+}
+
+// Verify 检查证书身份映射配置是否合法
+func (m *CertIdentityMapping) Verify() error {
+	matchers := 0
+	for _, v := range []string{m.SpiffeID, m.CommonName, m.DNSName} {
+		if v != "" {
+			matchers++
+		}
+	}
+	if matchers != 1 {
+		return errors.New("[Auth][Config] certIdentities need exactly one of spiffeId, commonName, dnsName")
+	}
+	if (m.UserName == "") == (m.GroupID == "") {
+		return errors.New("[Auth][Config] certIdentities need exactly one of userName, groupId")
+	}
+	return nil
+}
+
+// Match 判断客户端证书身份是否命中该映射
+func (m *CertIdentityMapping) Match(identity *secure.PeerIdentity) bool {
+	if identity == nil {
+		return false
+	}
+	switch {
+	case m.SpiffeID != "":
+		return m.SpiffeID == identity.SpiffeID
+	case m.CommonName != "":
+		return m.CommonName == identity.CommonName
+	case m.DNSName != "":
+		for _, name := range identity.DNSNames {
+			if name == m.DNSName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findCertIdentityToken 根据请求中的客户端证书身份，找到映射的用户/用户组的 token
+// 没有客户端证书或者没有命中任何映射时返回空字符串
+func (d *defaultAuthChecker) findCertIdentityToken(ctx context.Context) string {
+	identity, _ := ctx.Value(utils.ContextClientCertIdentity).(*secure.PeerIdentity)
+	if identity == nil {
+		return ""
+	}
+
+	for _, mapping := range AuthOption.CertIdentities {
+		if !mapping.Match(identity) {
+			continue
+		}
+		if mapping.GroupID != "" {
+			group := d.Cache().User().GetGroup(mapping.GroupID)
+			if group == nil {
+				log.Warn("[Auth][Checker] cert identity mapping group not found", zap.String("group", mapping.GroupID))
+				return ""
+			}
+			return group.Token
+		}
+		ownerName := mapping.OwnerName
+		if ownerName == "" {
+			ownerName = mapping.UserName
+		}
+		user := d.Cache().User().GetUserByName(mapping.UserName, ownerName)
+		if user == nil {
+			log.Warn("[Auth][Checker] cert identity mapping user not found", zap.String("user", mapping.UserName),
+				zap.String("owner", ownerName))
+			return ""
+		}
+		return user.Token
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/secure"
+)
+
+func TestCertIdentityMapping_Verify(t *testing.T) {
+	assert.NoError(t, (&CertIdentityMapping{CommonName: "order", UserName: "polaris"}).Verify())
+	assert.NoError(t, (&CertIdentityMapping{SpiffeID: "spiffe://polaris.io/order", GroupID: "g1"}).Verify())
+
+	// 没有匹配条件
+	assert.Error(t, (&CertIdentityMapping{UserName: "polaris"}).Verify())
+	// 多个匹配条件
+	assert.Error(t, (&CertIdentityMapping{CommonName: "order", DNSName: "order.polaris.io", UserName: "polaris"}).Verify())
+	// 同时映射用户以及用户组
+	assert.Error(t, (&CertIdentityMapping{CommonName: "order", UserName: "polaris", GroupID: "g1"}).Verify())
+	// 没有映射目标
+	assert.Error(t, (&CertIdentityMapping{CommonName: "order"}).Verify())
+}
+
+func TestCertIdentityMapping_Match(t *testing.T) {
+	identity := &secure.PeerIdentity{
+		CommonName: "order",
+		DNSNames:   []string{"order.polaris.io", "order.default.svc"},
+		SpiffeID:   "spiffe://polaris.io/ns/default/sa/order",
+	}
+
+	assert.True(t, (&CertIdentityMapping{CommonName: "order"}).Match(identity))
+	assert.False(t, (&CertIdentityMapping{CommonName: "payment"}).Match(identity))
+	assert.True(t, (&CertIdentityMapping{DNSName: "order.default.svc"}).Match(identity))
+	assert.False(t, (&CertIdentityMapping{DNSName: "payment.default.svc"}).Match(identity))
+	assert.True(t, (&CertIdentityMapping{SpiffeID: "spiffe://polaris.io/ns/default/sa/order"}).Match(identity))
+	assert.False(t, (&CertIdentityMapping{SpiffeID: "spiffe://polaris.io/ns/default/sa/payment"}).Match(identity))
+	assert.False(t, (&CertIdentityMapping{CommonName: "order"}).Match(nil))
+}
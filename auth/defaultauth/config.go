@@ -17,7 +17,10 @@
 
 package defaultauth
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // AuthOption 鉴权的配置信息
 var AuthOption = DefaultAuthConfig()
@@ -32,6 +35,49 @@ type AuthConfig struct {
 	Salt string `json:"salt" xml:"salt"`
 	// Strict 是否启用鉴权的严格模式，即对于没有任何鉴权策略的资源，也必须带上正确的token才能操作, 默认关闭
 	Strict bool `json:"strict"`
+	// PasswordPolicy 用户密码策略
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy"`
+	// LoginLock 登录失败锁定策略
+	LoginLock *LoginLockConfig `json:"loginLock"`
+	// CertIdentities 客户端证书身份与用户/用户组的映射，用于 mTLS 场景下免 token 鉴权
+	CertIdentities []*CertIdentityMapping `json:"certIdentities"`
+}
+
+// PasswordPolicy 密码策略配置
+type PasswordPolicy struct {
+	// MinLength 密码最小长度
+	MinLength int `json:"minLength"`
+	// MaxLength 密码最大长度
+	MaxLength int `json:"maxLength"`
+	// RequireUpper 是否必须包含大写字母
+	RequireUpper bool `json:"requireUpper"`
+	// RequireLower 是否必须包含小写字母
+	RequireLower bool `json:"requireLower"`
+	// RequireDigit 是否必须包含数字
+	RequireDigit bool `json:"requireDigit"`
+	// RequireSpecial 是否必须包含特殊字符
+	RequireSpecial bool `json:"requireSpecial"`
+	// HistoryCount 新密码不能与最近多少次使用过的密码相同，0 表示不检查
+	HistoryCount int `json:"historyCount"`
+	// MaxAge 密码最长有效期，超过后必须修改密码才能登录，0 表示永不过期
+	MaxAge time.Duration `json:"maxAge"`
+}
+
+// LoginLockConfig 登录失败锁定配置
+type LoginLockConfig struct {
+	// Open 是否开启登录失败锁定
+	Open bool `json:"open"`
+	// MaxUserFailures 单个用户在统计窗口内允许的最大失败次数
+	MaxUserFailures int `json:"maxUserFailures"`
+	// MaxIPFailures 单个来源 IP 在统计窗口内允许的最大失败次数
+	MaxIPFailures int `json:"maxIpFailures"`
+	// FailureWindow 失败次数的统计窗口
+	FailureWindow time.Duration `json:"failureWindow"`
+	// LockDuration 触发锁定后的锁定时长
+	LockDuration time.Duration `json:"lockDuration"`
+	// Redis redis 连接配置，与 heartbeatRedis 插件的配置相同。配置后失败记录以及锁定状态在所有节点之间共享，
+	// 未配置时只保存在各个节点的内存中，多个节点时实际允许的失败次数是节点数的倍数，解除锁定也只对处理请求的节点生效
+	Redis map[string]interface{} `json:"redis"`
 }
 
 // Verify 检查配置是否合法
@@ -44,6 +90,58 @@ func (cfg *AuthConfig) Verify() error {
 		return errors.New("[Auth][Config] salt len must 16 | 24 | 32")
 	}
 
+	if cfg.PasswordPolicy == nil {
+		cfg.PasswordPolicy = DefaultPasswordPolicy()
+	}
+	if err := cfg.PasswordPolicy.Verify(); err != nil {
+		return err
+	}
+
+	if cfg.LoginLock == nil {
+		cfg.LoginLock = DefaultLoginLockConfig()
+	}
+	if err := cfg.LoginLock.Verify(); err != nil {
+		return err
+	}
+
+	for i := range cfg.CertIdentities {
+		if err := cfg.CertIdentities[i].Verify(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Verify 检查密码策略是否合法
+func (p *PasswordPolicy) Verify() error {
+	if p.MinLength <= 0 || p.MaxLength < p.MinLength {
+		return errors.New("[Auth][Config] passwordPolicy need 0 < minLength <= maxLength")
+	}
+	// bcrypt 只会处理密码的前 72 个字节
+	if p.MaxLength > 72 {
+		return errors.New("[Auth][Config] passwordPolicy maxLength must not exceed 72")
+	}
+	if p.HistoryCount < 0 || p.HistoryCount > maxPasswordHistoryCount {
+		return errors.New("[Auth][Config] passwordPolicy historyCount must in [0, 10]")
+	}
+	if p.MaxAge < 0 {
+		return errors.New("[Auth][Config] passwordPolicy maxAge must not be negative")
+	}
+	return nil
+}
+
+// Verify 检查登录锁定配置是否合法
+func (c *LoginLockConfig) Verify() error {
+	if !c.Open {
+		return nil
+	}
+	if c.MaxUserFailures <= 0 || c.MaxIPFailures <= 0 {
+		return errors.New("[Auth][Config] loginLock maxUserFailures and maxIpFailures must be positive")
+	}
+	if c.FailureWindow <= 0 || c.LockDuration <= 0 {
+		return errors.New("[Auth][Config] loginLock failureWindow and lockDuration must be positive")
+	}
 	return nil
 }
 
@@ -56,6 +154,27 @@ func DefaultAuthConfig() *AuthConfig {
 		ClientOpen: false,
 		Salt:       "polarismesh@2021",
 		// 这里默认开启强 Token 检查模式
-		Strict: true,
+		Strict:         true,
+		PasswordPolicy: DefaultPasswordPolicy(),
+		LoginLock:      DefaultLoginLockConfig(),
+	}
+}
+
+// DefaultPasswordPolicy 返回默认的密码策略，与历史版本的密码校验规则保持一致
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 6,
+		MaxLength: 17,
+	}
+}
+
+// DefaultLoginLockConfig 返回默认的登录锁定配置，默认不开启，与历史版本的登录行为保持一致
+func DefaultLoginLockConfig() *LoginLockConfig {
+	return &LoginLockConfig{
+		Open:            false,
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		FailureWindow:   10 * time.Minute,
+		LockDuration:    15 * time.Minute,
 	}
 }
@@ -0,0 +1,239 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 失败记录超过该数量时，在记录新的失败前清理已经过期的记录
+	maxLoginFailureRecords = 10000
+)
+
+var (
+	// ErrorLoginLocked 登录失败次数过多，用户或者来源 IP 被临时锁定
+	ErrorLoginLocked = errors.New("too many failed login attempts, try again later")
+)
+
+// loginFailure 一个用户或者来源 IP 的登录失败记录
+type loginFailure struct {
+	count     int
+	firstTime time.Time
+	lockUntil time.Time
+}
+
+// isLocked 判断当前是否处于锁定状态
+func (f *loginFailure) isLocked(now time.Time) bool {
+	return now.Before(f.lockUntil)
+}
+
+// isExpired 判断该记录是否已经可以被清理
+func (f *loginFailure) isExpired(now time.Time, window time.Duration) bool {
+	return !f.isLocked(now) && now.Sub(f.firstTime) > window
+}
+
+// loginLocker 记录登录失败的次数，在失败次数过多时临时锁定用户或者来源 IP。
+// 配置了 redis 时失败记录保存在 redis 中，访问 redis 失败时退化为使用当前节点内存中的记录
+type loginLocker struct {
+	lock  sync.Mutex
+	users map[string]*loginFailure
+	ips   map[string]*loginFailure
+	// shared 所有节点共享的失败记录，为空时只使用内存中的记录
+	shared *redisLoginLock
+}
+
+func newLoginLocker() *loginLocker {
+	return &loginLocker{
+		users: map[string]*loginFailure{},
+		ips:   map[string]*loginFailure{},
+	}
+}
+
+// newSharedLoginLocker 根据登录锁定配置创建 loginLocker，配置了 redis 时在所有节点之间共享失败记录
+func newSharedLoginLocker(cfg *LoginLockConfig) (*loginLocker, error) {
+	locker := newLoginLocker()
+	if !cfg.Open || len(cfg.Redis) == 0 {
+		return locker, nil
+	}
+	shared, err := newRedisLoginLock(cfg)
+	if err != nil {
+		return nil, err
+	}
+	locker.shared = shared
+	return locker, nil
+}
+
+// records 获取对应类型的内存记录
+func (l *loginLocker) records(kind string) map[string]*loginFailure {
+	if kind == loginLockKindUser {
+		return l.users
+	}
+	return l.ips
+}
+
+// isUserLocked 判断用户是否被锁定
+func (l *loginLocker) isUserLocked(userID string, now time.Time) bool {
+	return l.isLocked(loginLockKindUser, userID, now)
+}
+
+// isIPLocked 判断来源 IP 是否被锁定
+func (l *loginLocker) isIPLocked(ip string, now time.Time) bool {
+	return l.isLocked(loginLockKindIP, ip, now)
+}
+
+func (l *loginLocker) isLocked(kind, key string, now time.Time) bool {
+	if !AuthOption.LoginLock.Open || key == "" {
+		return false
+	}
+	if l.shared != nil {
+		locked, err := l.shared.isLocked(kind, key)
+		if err == nil {
+			return locked
+		}
+		log.Error("[Auth][Login] check login lock in redis failed, fallback to local records",
+			zap.String(kind, key), zap.Error(err))
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	record, ok := l.records(kind)[key]
+	return ok && record.isLocked(now)
+}
+
+// onFailure 记录一次登录失败，userID 为空表示用户不存在，返回本次失败是否触发了用户或者 IP 的锁定
+func (l *loginLocker) onFailure(userID, ip string, now time.Time) (userLocked bool, ipLocked bool) {
+	cfg := AuthOption.LoginLock
+	if !cfg.Open {
+		return false, false
+	}
+
+	if userID != "" {
+		userLocked = l.addFailure(loginLockKindUser, userID, cfg.MaxUserFailures, now)
+	}
+	if ip != "" {
+		ipLocked = l.addFailure(loginLockKindIP, ip, cfg.MaxIPFailures, now)
+	}
+	return userLocked, ipLocked
+}
+
+func (l *loginLocker) addFailure(kind, key string, maxFailures int, now time.Time) bool {
+	cfg := AuthOption.LoginLock
+	if l.shared != nil {
+		locked, err := l.shared.addFailure(kind, key, maxFailures, cfg.FailureWindow, cfg.LockDuration)
+		if err == nil {
+			return locked
+		}
+		log.Error("[Auth][Login] record login failure in redis failed, fallback to local records",
+			zap.String(kind, key), zap.Error(err))
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	records := l.records(kind)
+	if len(records) >= maxLoginFailureRecords {
+		for k, v := range records {
+			if v.isExpired(now, cfg.FailureWindow) {
+				delete(records, k)
+			}
+		}
+	}
+
+	record, ok := records[key]
+	if !ok || record.isExpired(now, cfg.FailureWindow) {
+		record = &loginFailure{firstTime: now}
+		records[key] = record
+	}
+	if record.isLocked(now) {
+		return false
+	}
+
+	record.count++
+	if record.count < maxFailures {
+		return false
+	}
+
+	// 触发锁定后重新开始计数
+	record.count = 0
+	record.firstTime = now
+	record.lockUntil = now.Add(cfg.LockDuration)
+	return true
+}
+
+// onSuccess 登录成功后清理用户的失败记录
+func (l *loginLocker) onSuccess(userID string) {
+	if l.shared != nil {
+		if err := l.shared.reset(loginLockKindUser, userID); err != nil {
+			log.Error("[Auth][Login] reset login failures in redis failed", zap.String(loginLockKindUser, userID),
+				zap.Error(err))
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.users, userID)
+}
+
+// unlockUser 解除用户的锁定，返回用户之前是否处于锁定状态
+func (l *loginLocker) unlockUser(userID string, now time.Time) bool {
+	return l.unlock(loginLockKindUser, userID, now)
+}
+
+// unlockIP 解除来源 IP 的锁定，返回来源 IP 之前是否处于锁定状态
+func (l *loginLocker) unlockIP(ip string, now time.Time) bool {
+	return l.unlock(loginLockKindIP, ip, now)
+}
+
+// unlock 同时清理 redis 以及内存中的记录，内存中的记录可能是访问 redis 失败期间产生的
+func (l *loginLocker) unlock(kind, key string, now time.Time) bool {
+	sharedLocked := false
+	if l.shared != nil {
+		locked, err := l.shared.unlock(kind, key)
+		if err != nil {
+			log.Error("[Auth][Login] unlock in redis failed", zap.String(kind, key), zap.Error(err))
+		}
+		sharedLocked = locked
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	records := l.records(kind)
+	record, ok := records[key]
+	if !ok {
+		return sharedLocked
+	}
+	delete(records, key)
+	return record.isLocked(now) || sharedLocked
+}
+
+// parseLoginIP 从客户端地址中解析出 IP
+func parseLoginIP(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
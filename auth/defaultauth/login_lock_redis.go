@@ -0,0 +1,126 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/polarismesh/polaris/common/redispool"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// loginLockKeyPrefix redis 中登录失败记录以及锁定状态的 key 前缀
+	loginLockKeyPrefix = "polaris_login_lock:"
+	// loginLockRedisTimeout 单次访问 redis 的超时时间
+	loginLockRedisTimeout = time.Second
+
+	loginLockKindUser = "user"
+	loginLockKindIP   = "ip"
+)
+
+// addFailureScript 记录一次登录失败，失败次数达到上限时删除计数并设置锁定，返回 1 表示本次失败触发了锁定
+// KEYS[1] 失败计数，KEYS[2] 锁定状态
+// ARGV[1] 统计窗口(ms)，ARGV[2] 最大失败次数，ARGV[3] 锁定时长(ms)
+var addFailureScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+if count < tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('SET', KEYS[2], '1', 'PX', ARGV[3])
+return 1
+`)
+
+// redisLoginLock 失败记录以及锁定状态保存在 redis 中，所有节点共享同一份计数，解除锁定对所有节点生效
+type redisLoginLock struct {
+	client redis.UniversalClient
+}
+
+func newRedisLoginLock(cfg *LoginLockConfig) (*redisLoginLock, error) {
+	redisBytes, err := json.Marshal(cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal login lock redis config, err is %v", err)
+	}
+	var redisConfig redispool.Config
+	if err = json.Unmarshal(redisBytes, &redisConfig); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal login lock redis config, err is %v", err)
+	}
+	if redisConfig.KvPasswd, err = plugin.ParseSecret(redisConfig.KvPasswd); err != nil {
+		return nil, fmt.Errorf("fail to parse login lock redis password, err is %v", err)
+	}
+	if redisConfig.SentinelConfig.SentinelPassword, err = plugin.ParseSecret(
+		redisConfig.SentinelConfig.SentinelPassword); err != nil {
+		return nil, fmt.Errorf("fail to parse login lock sentinel password, err is %v", err)
+	}
+	return &redisLoginLock{client: redispool.NewRedisClient(&redisConfig)}, nil
+}
+
+// keys 同一对象的失败计数和锁定状态使用相同的 hash tag，保证在 redis 集群中位于同一个 slot
+func (r *redisLoginLock) keys(kind, key string) (string, string) {
+	tag := "{" + kind + ":" + key + "}"
+	return loginLockKeyPrefix + "failure:" + tag, loginLockKeyPrefix + "lock:" + tag
+}
+
+func (r *redisLoginLock) isLocked(kind, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginLockRedisTimeout)
+	defer cancel()
+	_, lockKey := r.keys(kind, key)
+	count, err := r.client.Exists(ctx, lockKey).Result()
+	return count > 0, err
+}
+
+func (r *redisLoginLock) addFailure(kind, key string, maxFailures int, window, lockDuration time.Duration) (
+	bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginLockRedisTimeout)
+	defer cancel()
+	failureKey, lockKey := r.keys(kind, key)
+	locked, err := addFailureScript.Run(ctx, r.client, []string{failureKey, lockKey},
+		window.Milliseconds(), maxFailures, lockDuration.Milliseconds()).Int()
+	return locked == 1, err
+}
+
+func (r *redisLoginLock) reset(kind, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), loginLockRedisTimeout)
+	defer cancel()
+	failureKey, _ := r.keys(kind, key)
+	return r.client.Del(ctx, failureKey).Err()
+}
+
+// unlock 删除锁定状态以及失败计数，返回之前是否处于锁定状态
+func (r *redisLoginLock) unlock(kind, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginLockRedisTimeout)
+	defer cancel()
+	failureKey, lockKey := r.keys(kind, key)
+	count, err := r.client.Del(ctx, lockKey).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, r.client.Del(ctx, failureKey).Err()
+}
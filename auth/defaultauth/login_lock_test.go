@@ -0,0 +1,166 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loginLocker(t *testing.T) {
+	AuthOption = DefaultAuthConfig()
+	AuthOption.LoginLock = &LoginLockConfig{
+		Open:            true,
+		MaxUserFailures: 3,
+		MaxIPFailures:   5,
+		FailureWindow:   time.Minute,
+		LockDuration:    time.Minute,
+	}
+	defer func() {
+		AuthOption = DefaultAuthConfig()
+	}()
+
+	var (
+		locker = newLoginLocker()
+		now    = time.Now()
+	)
+
+	t.Run("lock user", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			userLocked, ipLocked := locker.onFailure("user-1", "127.0.0.1", now)
+			assert.False(t, userLocked)
+			assert.False(t, ipLocked)
+		}
+		userLocked, _ := locker.onFailure("user-1", "127.0.0.1", now)
+		assert.True(t, userLocked)
+		assert.True(t, locker.isUserLocked("user-1", now))
+		assert.False(t, locker.isUserLocked("user-1", now.Add(2*time.Minute)))
+	})
+
+	t.Run("lock ip", func(t *testing.T) {
+		_, ipLocked := locker.onFailure("", "127.0.0.1", now)
+		assert.False(t, ipLocked)
+		_, ipLocked = locker.onFailure("", "127.0.0.1", now)
+		assert.True(t, ipLocked)
+		assert.True(t, locker.isIPLocked("127.0.0.1", now))
+		assert.False(t, locker.isIPLocked("127.0.0.2", now))
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		assert.True(t, locker.unlockUser("user-1", now))
+		assert.False(t, locker.isUserLocked("user-1", now))
+		assert.True(t, locker.unlockIP("127.0.0.1", now))
+		assert.False(t, locker.isIPLocked("127.0.0.1", now))
+		assert.False(t, locker.unlockIP("127.0.0.1", now))
+	})
+
+	t.Run("failure window", func(t *testing.T) {
+		locker.onFailure("user-2", "", now)
+		locker.onFailure("user-2", "", now)
+		userLocked, _ := locker.onFailure("user-2", "", now.Add(2*time.Minute))
+		assert.False(t, userLocked)
+	})
+
+	t.Run("success reset", func(t *testing.T) {
+		locker.onFailure("user-3", "", now)
+		locker.onFailure("user-3", "", now)
+		locker.onSuccess("user-3")
+		userLocked, _ := locker.onFailure("user-3", "", now)
+		assert.False(t, userLocked)
+	})
+}
+
+func Test_loginLocker_Redis(t *testing.T) {
+	s := miniredis.RunT(t)
+	AuthOption = DefaultAuthConfig()
+	AuthOption.LoginLock = &LoginLockConfig{
+		Open:            true,
+		MaxUserFailures: 3,
+		MaxIPFailures:   5,
+		FailureWindow:   time.Minute,
+		LockDuration:    time.Minute,
+		Redis:           map[string]interface{}{"kvAddr": s.Addr()},
+	}
+	defer func() {
+		AuthOption = DefaultAuthConfig()
+	}()
+
+	// 两个节点共享失败记录
+	node1, err := newSharedLoginLocker(AuthOption.LoginLock)
+	assert.NoError(t, err)
+	node2, err := newSharedLoginLocker(AuthOption.LoginLock)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	t.Run("failures on all nodes are counted", func(t *testing.T) {
+		userLocked, _ := node1.onFailure("user-1", "127.0.0.1", now)
+		assert.False(t, userLocked)
+		userLocked, _ = node2.onFailure("user-1", "127.0.0.1", now)
+		assert.False(t, userLocked)
+		userLocked, _ = node1.onFailure("user-1", "127.0.0.1", now)
+		assert.True(t, userLocked)
+		assert.True(t, node2.isUserLocked("user-1", now))
+		// 锁定期间的失败不再计数
+		userLocked, _ = node2.onFailure("user-1", "127.0.0.1", now)
+		assert.False(t, userLocked)
+	})
+
+	t.Run("unlock applies to all nodes", func(t *testing.T) {
+		assert.True(t, node2.unlockUser("user-1", now))
+		assert.False(t, node1.isUserLocked("user-1", now))
+		assert.False(t, node1.unlockUser("user-1", now))
+	})
+
+	t.Run("lock expires", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			node1.onFailure("user-2", "", now)
+		}
+		assert.True(t, node2.isUserLocked("user-2", now))
+		s.FastForward(2 * time.Minute)
+		assert.False(t, node2.isUserLocked("user-2", now))
+	})
+
+	t.Run("success reset", func(t *testing.T) {
+		node1.onFailure("user-3", "", now)
+		node1.onFailure("user-3", "", now)
+		node2.onSuccess("user-3")
+		userLocked, _ := node1.onFailure("user-3", "", now)
+		assert.False(t, userLocked)
+	})
+
+	t.Run("fallback to local records when redis is unavailable", func(t *testing.T) {
+		s.Close()
+		for i := 0; i < 2; i++ {
+			userLocked, _ := node1.onFailure("user-4", "", now)
+			assert.False(t, userLocked)
+		}
+		userLocked, _ := node1.onFailure("user-4", "", now)
+		assert.True(t, userLocked)
+		assert.True(t, node1.isUserLocked("user-4", now))
+		assert.False(t, node2.isUserLocked("user-4", now))
+	})
+}
+
+func Test_parseLoginIP(t *testing.T) {
+	assert.Equal(t, "127.0.0.1", parseLoginIP("127.0.0.1:8090"))
+	assert.Equal(t, "::1", parseLoginIP("[::1]:8090"))
+	assert.Equal(t, "127.0.0.1", parseLoginIP("127.0.0.1"))
+}
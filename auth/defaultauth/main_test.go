@@ -19,6 +19,7 @@ package defaultauth
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
@@ -54,6 +55,7 @@ import (
 	_ "github.com/polarismesh/polaris/store/boltdb"
 	_ "github.com/polarismesh/polaris/store/mysql"
 	sqldb "github.com/polarismesh/polaris/store/mysql"
+	"github.com/polarismesh/polaris/store/postgres"
 	testdata "github.com/polarismesh/polaris/test/data"
 )
 
@@ -93,9 +95,9 @@ func (d *AuthTestSuit) loadConfig() error {
 	d.cfg = new(TestConfig)
 
 	confFileName := testdata.Path("auth_test.yaml")
-	if os.Getenv("STORE_MODE") == "sqldb" {
-		fmt.Printf("run store mode : sqldb\n")
-		confFileName = testdata.Path("auth_test_sqldb.yaml")
+	if mode := os.Getenv("STORE_MODE"); mode == "sqldb" || mode == "postgres" {
+		fmt.Printf("run store mode : %s\n", mode)
+		confFileName = testdata.Path("auth_test_" + mode + ".yaml")
 		d.defaultCtx = context.WithValue(d.defaultCtx, utils.ContextAuthTokenKey,
 			"nu/0WRA4EqSR1FagrjRj0fZwPXuGlMpX+zCuWu4uMqy8xr1vRjisSbA25aAC3mtU8MeeRsKhQiDAynUR09I=")
 	}
@@ -199,19 +201,36 @@ func (d *AuthTestSuit) Destroy() {
 	time.Sleep(5 * time.Second)
 }
 
+// sqlTx mysql 以及 postgres 存储的事务对象，清理测试数据时统一使用
+type sqlTx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Commit() error
+	Rollback() error
+}
+
+// isSQLStore 判断当前是否为关系型数据库存储
+func isSQLStore(name string) bool {
+	return name == sqldb.STORENAME || name == postgres.STORENAME
+}
+
 func (d *AuthTestSuit) cleanAllUser() {
-	if d.storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.storage.Name()) {
 		func() {
 			tx, err := d.storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer dbTx.Rollback()
 
-			if _, err := dbTx.Exec("delete from user where name like 'test%'"); err != nil {
+			// user 在 postgres 中是保留字，需要使用双引号
+			userTable := "user"
+			if d.storage.Name() == postgres.STORENAME {
+				userTable = "\"user\""
+			}
+			if _, err := dbTx.Exec("delete from " + userTable + " where name like 'test%'"); err != nil {
 				dbTx.Rollback()
 				panic(err)
 			}
@@ -240,14 +259,14 @@ func (d *AuthTestSuit) cleanAllUser() {
 }
 
 func (d *AuthTestSuit) cleanAllUserGroup() {
-	if d.storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.storage.Name()) {
 		func() {
 			tx, err := d.storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer dbTx.Rollback()
 
@@ -284,14 +303,14 @@ func (d *AuthTestSuit) cleanAllUserGroup() {
 }
 
 func (d *AuthTestSuit) cleanAllAuthStrategy() {
-	if d.storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.storage.Name()) {
 		func() {
 			tx, err := d.storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer dbTx.Rollback()
 
@@ -0,0 +1,133 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// maxPasswordHistoryCount 最多保留的历史密码个数
+	maxPasswordHistoryCount = 10
+)
+
+var (
+	// ErrorPasswordReused 新密码与历史密码重复
+	ErrorPasswordReused = errors.New("password has been used recently")
+	// ErrorPasswordExpired 密码已经过期
+	ErrorPasswordExpired = errors.New("password expired, please change the password before login")
+	// ErrorPasswordNotExpired 密码没有过期，不能通过原密码直接修改
+	ErrorPasswordNotExpired = errors.New("password not expired, please login to change the password")
+)
+
+// CheckPassword 检查明文密码是否满足长度以及字符类型的要求
+func (p *PasswordPolicy) CheckPassword(password string) error {
+	if pLen := len(password); pLen < p.MinLength || pLen > p.MaxLength {
+		return fmt.Errorf("password len need %d ~ %d", p.MinLength, p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return errors.New("password must contain upper case letter")
+	}
+	if p.RequireLower && !hasLower {
+		return errors.New("password must contain lower case letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errors.New("password must contain digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		return errors.New("password must contain special character")
+	}
+	return nil
+}
+
+// CheckReuse 检查新密码是否与当前密码以及最近使用过的密码相同
+func (p *PasswordPolicy) CheckReuse(user *model.User, password string) error {
+	if p.HistoryCount <= 0 {
+		return nil
+	}
+
+	hashes := make([]string, 0, p.HistoryCount+1)
+	hashes = append(hashes, user.Password)
+	hashes = append(hashes, user.PasswordHistory...)
+	if len(hashes) > p.HistoryCount+1 {
+		hashes = hashes[:p.HistoryCount+1]
+	}
+
+	for i := range hashes {
+		if hashes[i] == "" {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hashes[i]), []byte(password)) == nil {
+			return ErrorPasswordReused
+		}
+	}
+	return nil
+}
+
+// IsExpired 判断用户的密码是否已经过期，超级账户不受密码有效期限制，避免整个系统无法登录
+func (p *PasswordPolicy) IsExpired(user *model.User, now time.Time) bool {
+	if p.MaxAge <= 0 || user.Type == model.AdminUserRole {
+		return false
+	}
+
+	modifyTime := user.PasswordModifyTime
+	if modifyTime.Unix() <= 0 {
+		modifyTime = user.CreateTime
+	}
+	if modifyTime.Unix() <= 0 {
+		return false
+	}
+	return now.Sub(modifyTime) > p.MaxAge
+}
+
+// changeUserPassword 更新用户的密码，同时把旧密码记入历史密码列表
+func changeUserPassword(user *model.User, hashPassword string) {
+	if user.Password != "" {
+		history := make([]string, 0, len(user.PasswordHistory)+1)
+		history = append(history, user.Password)
+		history = append(history, user.PasswordHistory...)
+		if len(history) > maxPasswordHistoryCount {
+			history = history[:maxPasswordHistoryCount]
+		}
+		user.PasswordHistory = history
+	}
+	user.Password = hashPassword
+	user.PasswordModifyTime = time.Now()
+}
@@ -0,0 +1,99 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestPasswordPolicy_CheckPassword(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      20,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+	}
+	assert.NoError(t, policy.Verify())
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{password: "Aa1@", wantErr: true},
+		{password: "Aa1@Aa1@Aa1@Aa1@Aa1@A", wantErr: true},
+		{password: "aa1@aa1@", wantErr: true},
+		{password: "AA1@AA1@", wantErr: true},
+		{password: "Aab@Aab@", wantErr: true},
+		{password: "Aa12Aa12", wantErr: true},
+		{password: "Aa1@Aa1@", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if err := policy.CheckPassword(tt.password); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_CheckReuse(t *testing.T) {
+	user := &model.User{}
+	for _, pwd := range []string{"password-1", "password-2", "password-3"} {
+		hash, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.MinCost)
+		assert.NoError(t, err)
+		changeUserPassword(user, string(hash))
+	}
+	assert.Len(t, user.PasswordHistory, 2)
+
+	policy := DefaultPasswordPolicy()
+	assert.NoError(t, policy.CheckReuse(user, "password-3"))
+
+	policy.HistoryCount = 1
+	assert.ErrorIs(t, policy.CheckReuse(user, "password-3"), ErrorPasswordReused)
+	assert.ErrorIs(t, policy.CheckReuse(user, "password-2"), ErrorPasswordReused)
+	assert.NoError(t, policy.CheckReuse(user, "password-1"))
+
+	policy.HistoryCount = 2
+	assert.ErrorIs(t, policy.CheckReuse(user, "password-1"), ErrorPasswordReused)
+	assert.NoError(t, policy.CheckReuse(user, "password-4"))
+}
+
+func TestPasswordPolicy_IsExpired(t *testing.T) {
+	now := time.Now()
+	user := &model.User{
+		Type:               model.SubAccountUserRole,
+		PasswordModifyTime: now.Add(-48 * time.Hour),
+	}
+
+	policy := DefaultPasswordPolicy()
+	assert.False(t, policy.IsExpired(user, now))
+
+	policy.MaxAge = 24 * time.Hour
+	assert.True(t, policy.IsExpired(user, now))
+
+	user.Type = model.AdminUserRole
+	assert.False(t, policy.IsExpired(user, now))
+}
@@ -18,7 +18,10 @@
 package defaultauth
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -29,6 +32,7 @@ import (
 	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
+	commontime "github.com/polarismesh/polaris/common/time"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/store"
@@ -39,6 +43,7 @@ type server struct {
 	history  plugin.History
 	cacheMgn *cache.CacheManager
 	authMgn  *defaultAuthChecker
+	locker   *loginLocker
 }
 
 // initialize
@@ -53,27 +58,128 @@ func (svr *server) initialize() error {
 }
 
 // Login 登录动作
-func (svr *server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
-	username := req.GetName().GetValue()
-	ownerName := req.GetOwner().GetValue()
+func (svr *server) Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response {
+	var (
+		now       = time.Now()
+		username  = req.GetName().GetValue()
+		ownerName = req.GetOwner().GetValue()
+		clientIP  = parseLoginIP(utils.ParseClientAddress(ctx))
+	)
 	if ownerName == "" {
 		ownerName = username
 	}
+
+	if svr.locker.isIPLocked(clientIP, now) {
+		log.Warn("[Auth][Login] client address is locked", zap.String("address", clientIP),
+			zap.String("name", username))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorLoginLocked.Error())
+	}
+
 	user := svr.cacheMgn.User().GetUserByName(username, ownerName)
 	if user == nil {
+		svr.onLoginFailure(ctx, nil, clientIP, now)
 		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
 	}
 
+	if svr.locker.isUserLocked(user.ID, now) {
+		log.Warn("[Auth][Login] user is locked", zap.String("address", clientIP),
+			zap.String("name", username))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorLoginLocked.Error())
+	}
+
 	// TODO AES 解密操作，在进行密码比对计算
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.GetPassword().GetValue()))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			svr.onLoginFailure(ctx, user, clientIP, now)
 			return api.NewAuthResponseWithMsg(
 				apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
 		}
 		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, model.ErrorWrongUsernameOrPassword.Error())
 	}
+	svr.locker.onSuccess(user.ID)
+
+	if AuthOption.PasswordPolicy.IsExpired(user, now) {
+		// 不返回 token，只返回用户 ID 用于调用 ChangeExpiredPassword 修改密码
+		rsp := api.NewLoginResponse(apimodel.Code_NotAllowedAccess, &apisecurity.LoginResponse{
+			UserId:  utils.NewStringValue(user.ID),
+			OwnerId: utils.NewStringValue(user.Owner),
+			Name:    utils.NewStringValue(user.Name),
+		})
+		rsp.Info = utils.NewStringValue(ErrorPasswordExpired.Error())
+		return rsp
+	}
+
+	return newLoginResponse(user)
+}
+
+// ChangeExpiredPassword 密码过期的用户通过原密码修改密码，原密码校验失败同样计入登录失败次数，
+// 修改成功后直接返回登录结果
+func (svr *server) ChangeExpiredPassword(ctx context.Context,
+	req *apisecurity.ModifyUserPassword) *apiservice.Response {
+	var (
+		now       = time.Now()
+		requestID = utils.ParseRequestID(ctx)
+		clientIP  = parseLoginIP(utils.ParseClientAddress(ctx))
+	)
+	if svr.locker.isIPLocked(clientIP, now) {
+		log.Warn("[Auth][Login] client address is locked", zap.String("address", clientIP),
+			zap.String("user-id", req.GetId().GetValue()))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorLoginLocked.Error())
+	}
 
+	user, err := svr.storage.GetUser(req.GetId().GetValue())
+	if err != nil {
+		log.Error("[Auth][Login] get user", utils.ZapRequestID(requestID),
+			zap.String("user-id", req.GetId().GetValue()), zap.Error(err))
+		return api.NewAuthResponse(apimodel.Code_StoreLayerException)
+	}
+	if user == nil {
+		svr.onLoginFailure(ctx, nil, clientIP, now)
+		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
+	}
+	if svr.locker.isUserLocked(user.ID, now) {
+		log.Warn("[Auth][Login] user is locked", zap.String("address", clientIP),
+			zap.String("name", user.Name))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorLoginLocked.Error())
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.GetOldPassword().GetValue()))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			svr.onLoginFailure(ctx, user, clientIP, now)
+			return api.NewAuthResponseWithMsg(
+				apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+		}
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, model.ErrorWrongUsernameOrPassword.Error())
+	}
+	svr.locker.onSuccess(user.ID)
+
+	// 密码未过期时需要登录后通过 UpdateUserPassword 修改
+	if !AuthOption.PasswordPolicy.IsExpired(user, now) {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorPasswordNotExpired.Error())
+	}
+
+	// 原密码已经校验过，这里不需要再次比对
+	data, _, err := updateUserPasswordAttribute(true, user, req)
+	if err != nil {
+		log.Error("[Auth][Login] compute user update attribute", zap.Error(err),
+			zap.String("user-id", user.ID))
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
+	if err := svr.storage.UpdateUser(data); err != nil {
+		log.Error("[Auth][Login] update expired password from store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthResponse(StoreCode2APICode(err))
+	}
+
+	log.Info("[Auth][Login] change expired password", utils.ZapRequestID(requestID),
+		zap.String("user-id", user.ID), zap.String("address", clientIP))
+	return newLoginResponse(data)
+}
+
+// newLoginResponse 构造登录成功的返回结果
+func newLoginResponse(user *model.User) *apiservice.Response {
 	return api.NewLoginResponse(apimodel.Code_ExecuteSuccess, &apisecurity.LoginResponse{
 		UserId:  utils.NewStringValue(user.ID),
 		OwnerId: utils.NewStringValue(user.Owner),
@@ -83,6 +189,41 @@ func (svr *server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
 	})
 }
 
+// onLoginFailure 记录登录失败，如果触发了锁定则记录操作历史
+func (svr *server) onLoginFailure(ctx context.Context, user *model.User, clientIP string, now time.Time) {
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+
+	userLocked, ipLocked := svr.locker.onFailure(userID, clientIP, now)
+	lockUntil := commontime.Time2String(now.Add(AuthOption.LoginLock.LockDuration))
+	if userLocked {
+		log.Warn("[Auth][Login] too many failed login attempts, lock user", zap.String("name", user.Name),
+			zap.String("address", clientIP), zap.String("until", lockUntil))
+		svr.RecordHistory(&model.RecordEntry{
+			ResourceType:  model.RUser,
+			ResourceName:  fmt.Sprintf("%s(%s)", user.Name, user.ID),
+			OperationType: model.OLock,
+			Operator:      utils.ParseOperator(ctx),
+			Detail:        fmt.Sprintf("login failed from %s, locked until %s", clientIP, lockUntil),
+			HappenTime:    now,
+		})
+	}
+	if ipLocked {
+		log.Warn("[Auth][Login] too many failed login attempts, lock client address",
+			zap.String("address", clientIP), zap.String("until", lockUntil))
+		svr.RecordHistory(&model.RecordEntry{
+			ResourceType:  model.RClientAddress,
+			ResourceName:  clientIP,
+			OperationType: model.OLock,
+			Operator:      utils.ParseOperator(ctx),
+			Detail:        fmt.Sprintf("login failed too many times, locked until %s", lockUntil),
+			HappenTime:    now,
+		})
+	}
+}
+
 // RecordHistory server对外提供history插件的简单封装
 func (svr *server) RecordHistory(entry *model.RecordEntry) {
 	// 如果插件没有初始化，那么不记录history
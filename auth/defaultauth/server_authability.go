@@ -18,6 +18,8 @@
 package defaultauth
 
 import (
+	"context"
+
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
@@ -44,6 +46,10 @@ func (svr *serverAuthAbility) Initialize(authOpt *auth.Config, storage store.Sto
 	if err := authMgn.Initialize(authOpt, storage, cacheMgn); err != nil {
 		return err
 	}
+	locker, err := newSharedLoginLocker(AuthOption.LoginLock)
+	if err != nil {
+		return err
+	}
 
 	svr.authMgn = authMgn
 	svr.target = &server{
@@ -51,14 +57,21 @@ func (svr *serverAuthAbility) Initialize(authOpt *auth.Config, storage store.Sto
 		history:  history,
 		cacheMgn: cacheMgn,
 		authMgn:  authMgn,
+		locker:   locker,
 	}
 
 	return nil
 }
 
 // Login login servers
-func (svr *serverAuthAbility) Login(req *apisecurity.LoginRequest) *apiservice.Response {
-	return svr.target.Login(req)
+func (svr *serverAuthAbility) Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response {
+	return svr.target.Login(ctx, req)
+}
+
+// ChangeExpiredPassword 修改过期的密码，调用方通过原密码证明身份，不需要 token
+func (svr *serverAuthAbility) ChangeExpiredPassword(ctx context.Context,
+	req *apisecurity.ModifyUserPassword) *apiservice.Response {
+	return svr.target.ChangeExpiredPassword(ctx, req)
 }
 
 // AfterResourceOperation is called after resource operation
@@ -466,6 +466,67 @@ func (svr *server) ResetUserToken(ctx context.Context, req *apisecurity.User) *a
 	return api.NewUserResponse(apimodel.Code_ExecuteSuccess, req)
 }
 
+// UnlockUser 解除用户因登录失败次数过多而被临时锁定的状态，不允许自己解除自己的锁定
+func (svr *server) UnlockUser(ctx context.Context, req *apisecurity.User) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	if req.GetId().GetValue() == "" {
+		return api.NewUserResponse(apimodel.Code_BadRequest, req)
+	}
+
+	user, err := svr.storage.GetUser(req.Id.GetValue())
+	if err != nil {
+		log.Error("[Auth][User] get user from store", utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewUserResponse(apimodel.Code_StoreLayerException, req)
+	}
+	if user == nil {
+		return api.NewUserResponse(apimodel.Code_NotFoundUser, req)
+	}
+
+	if !checkUserViewPermission(ctx, user) {
+		return api.NewUserResponse(apimodel.Code_NotAllowedAccess, req)
+	}
+	if user.ID == utils.ParseUserID(ctx) && authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		return api.NewUserResponse(apimodel.Code_NotAllowedAccess, req)
+	}
+
+	if !svr.locker.unlockUser(user.ID, time.Now()) {
+		return api.NewUserResponse(apimodel.Code_NoNeedUpdate, req)
+	}
+
+	log.Info("[Auth][User] unlock user", utils.ZapRequestID(requestID),
+		zap.String("id", req.Id.GetValue()))
+	svr.RecordHistory(userRecordEntry(ctx, req, user, model.OUnlock))
+
+	return api.NewUserResponse(apimodel.Code_ExecuteSuccess, req)
+}
+
+// UnlockLoginAddress 解除来源地址因登录失败次数过多而被临时锁定的状态，只允许超级账户操作
+func (svr *server) UnlockLoginAddress(ctx context.Context, address string) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		return api.NewAuthResponse(apimodel.Code_OperationRoleForbidden)
+	}
+
+	ip := parseLoginIP(address)
+	if ip == "" {
+		return api.NewAuthResponse(apimodel.Code_BadRequest)
+	}
+	if !svr.locker.unlockIP(ip, time.Now()) {
+		return api.NewAuthResponse(apimodel.Code_NoNeedUpdate)
+	}
+
+	log.Info("[Auth][User] unlock login address", utils.ZapRequestID(requestID), zap.String("address", ip))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RClientAddress,
+		ResourceName:  ip,
+		OperationType: model.OUnlock,
+		Operator:      utils.ParseOperator(ctx),
+		HappenTime:    time.Now(),
+	})
+
+	return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+}
+
 // checkUserViewPermission 检查是否可以操作该用户
 // Case 1: 如果是自己操作自己，通过
 // Case 2: 如果是主账户操作自己的子账户，通过
@@ -645,12 +706,15 @@ func updateUserPasswordAttribute(
 	}
 
 	if req.GetNewPassword().GetValue() != "" {
+		if err := AuthOption.PasswordPolicy.CheckReuse(user, req.GetNewPassword().GetValue()); err != nil {
+			return nil, false, err
+		}
 		pwd, err := bcrypt.GenerateFromPassword([]byte(req.GetNewPassword().GetValue()), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, false, err
 		}
 		needUpdate = true
-		user.Password = string(pwd)
+		changeUserPassword(user, string(pwd))
 		// newToken, err := createUserToken(user.ID)
 		// if err != nil {
 		// 	return nil, false, err
@@ -687,6 +751,8 @@ func createUserModel(req *apisecurity.User, role model.UserRoleType) (*model.Use
 		CreateTime:  time.Now(),
 		ModifyTime:  time.Now(),
 		TokenEnable: true,
+
+		PasswordModifyTime: time.Now(),
 	}
 
 	// 如果不是子账户的话，owner 就是自己
@@ -130,3 +130,24 @@ func (svr *serverAuthAbility) ResetUserToken(ctx context.Context, user *apisecur
 
 	return svr.target.ResetUserToken(ctx, user)
 }
+
+// UnlockUser 解除用户的登录锁定，只允许超级、主账户进行操作
+func (svr *serverAuthAbility) UnlockUser(ctx context.Context, user *apisecurity.User) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		rsp.User = user
+		return rsp
+	}
+
+	return svr.target.UnlockUser(ctx, user)
+}
+
+// UnlockLoginAddress 解除来源地址的登录锁定，只允许超级账户进行操作
+func (svr *serverAuthAbility) UnlockLoginAddress(ctx context.Context, address string) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return rsp
+	}
+
+	return svr.target.UnlockLoginAddress(ctx, address)
+}
@@ -113,6 +113,7 @@ func newUserTest(t *testing.T) *UserTest {
 			storage:  storage,
 			cacheMgn: cacheMgn,
 			authMgn:  checker,
+			locker:   newLoginLocker(),
 		},
 	}
 
@@ -570,6 +571,63 @@ func Test_server_UpdateUserPassword(t *testing.T) {
 	})
 }
 
+func Test_server_ChangeExpiredPassword(t *testing.T) {
+
+	userTest := newUserTest(t)
+	defer userTest.Clean()
+
+	oldMaxAge := AuthOption.PasswordPolicy.MaxAge
+	AuthOption.PasswordPolicy.MaxAge = 24 * time.Hour
+	defer func() {
+		AuthOption.PasswordPolicy.MaxAge = oldMaxAge
+	}()
+	newUser := func(modifyTime time.Time) *model.User {
+		user := *userTest.users[1]
+		user.PasswordModifyTime = modifyTime
+		return &user
+	}
+
+	t.Run("密码未过期不能直接修改", func(t *testing.T) {
+		req := &apisecurity.ModifyUserPassword{
+			Id:          &wrappers.StringValue{Value: userTest.users[1].ID},
+			OldPassword: &wrappers.StringValue{Value: "polaris"},
+			NewPassword: &wrappers.StringValue{Value: "polaris@2021"},
+		}
+
+		userTest.storage.EXPECT().GetUser(gomock.Any()).Return(newUser(time.Now()), nil)
+
+		resp := userTest.svr.ChangeExpiredPassword(context.Background(), req)
+		assert.Equal(t, api.NotAllowedAccess, resp.Code.GetValue(), "change password must fail")
+	})
+
+	t.Run("密码过期-原密码错误", func(t *testing.T) {
+		req := &apisecurity.ModifyUserPassword{
+			Id:          &wrappers.StringValue{Value: userTest.users[1].ID},
+			OldPassword: &wrappers.StringValue{Value: "polaris-wrong"},
+			NewPassword: &wrappers.StringValue{Value: "polaris@2021"},
+		}
+
+		userTest.storage.EXPECT().GetUser(gomock.Any()).Return(newUser(time.Now().Add(-48*time.Hour)), nil)
+
+		resp := userTest.svr.ChangeExpiredPassword(context.Background(), req)
+		assert.Equal(t, api.NotAllowedAccess, resp.Code.GetValue(), "change password must fail")
+	})
+
+	t.Run("密码过期-修改成功后直接登录", func(t *testing.T) {
+		req := &apisecurity.ModifyUserPassword{
+			Id:          &wrappers.StringValue{Value: userTest.users[1].ID},
+			OldPassword: &wrappers.StringValue{Value: "polaris"},
+			NewPassword: &wrappers.StringValue{Value: "polaris@2021"},
+		}
+
+		userTest.storage.EXPECT().GetUser(gomock.Any()).Return(newUser(time.Now().Add(-48*time.Hour)), nil)
+
+		resp := userTest.svr.ChangeExpiredPassword(context.Background(), req)
+		assert.Equal(t, api.ExecuteSuccess, resp.Code.GetValue(), "change password must success")
+		assert.Equal(t, userTest.users[1].Token, resp.GetLoginResponse().GetToken().GetValue())
+	})
+}
+
 func Test_server_DeleteUser(t *testing.T) {
 	userTest := newUserTest(t)
 	defer userTest.Clean()
@@ -107,11 +107,7 @@ func checkPassword(password *wrappers.StringValue) error {
 		return errors.New(utils.EmptyErrString)
 	}
 
-	if pLen := len(password.GetValue()); pLen < 6 || pLen > 17 {
-		return errors.New("password len need 6 ~ 17")
-	}
-
-	return nil
+	return AuthOption.PasswordPolicy.CheckPassword(password.GetValue())
 }
 
 // checkOwner 检查用户的 owner 信息
@@ -71,6 +71,20 @@ func (mr *MockAuthServerMockRecorder) AfterResourceOperation(afterCtx interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterResourceOperation", reflect.TypeOf((*MockAuthServer)(nil).AfterResourceOperation), afterCtx)
 }
 
+// ChangeExpiredPassword mocks base method.
+func (m *MockAuthServer) ChangeExpiredPassword(ctx context.Context, req *security.ModifyUserPassword) *service_manage.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeExpiredPassword", ctx, req)
+	ret0, _ := ret[0].(*service_manage.Response)
+	return ret0
+}
+
+// ChangeExpiredPassword indicates an expected call of ChangeExpiredPassword.
+func (mr *MockAuthServerMockRecorder) ChangeExpiredPassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeExpiredPassword", reflect.TypeOf((*MockAuthServer)(nil).ChangeExpiredPassword), ctx, req)
+}
+
 // CreateGroup mocks base method.
 func (m *MockAuthServer) CreateGroup(ctx context.Context, group *security.UserGroup) *service_manage.Response {
 	m.ctrl.T.Helper()
@@ -296,17 +310,17 @@ func (mr *MockAuthServerMockRecorder) Initialize(authOpt, storage, cacheMgn inte
 }
 
 // Login mocks base method.
-func (m *MockAuthServer) Login(req *security.LoginRequest) *service_manage.Response {
+func (m *MockAuthServer) Login(ctx context.Context, req *security.LoginRequest) *service_manage.Response {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", req)
+	ret := m.ctrl.Call(m, "Login", ctx, req)
 	ret0, _ := ret[0].(*service_manage.Response)
 	return ret0
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockAuthServerMockRecorder) Login(req interface{}) *gomock.Call {
+func (mr *MockAuthServerMockRecorder) Login(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthServer)(nil).Login), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthServer)(nil).Login), ctx, req)
 }
 
 // Name mocks base method.
@@ -351,6 +365,34 @@ func (mr *MockAuthServerMockRecorder) ResetUserToken(ctx, user interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserToken", reflect.TypeOf((*MockAuthServer)(nil).ResetUserToken), ctx, user)
 }
 
+// UnlockLoginAddress mocks base method.
+func (m *MockAuthServer) UnlockLoginAddress(ctx context.Context, address string) *service_manage.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLoginAddress", ctx, address)
+	ret0, _ := ret[0].(*service_manage.Response)
+	return ret0
+}
+
+// UnlockLoginAddress indicates an expected call of UnlockLoginAddress.
+func (mr *MockAuthServerMockRecorder) UnlockLoginAddress(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLoginAddress", reflect.TypeOf((*MockAuthServer)(nil).UnlockLoginAddress), ctx, address)
+}
+
+// UnlockUser mocks base method.
+func (m *MockAuthServer) UnlockUser(ctx context.Context, user *security.User) *service_manage.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, user)
+	ret0, _ := ret[0].(*service_manage.Response)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockAuthServerMockRecorder) UnlockUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockAuthServer)(nil).UnlockUser), ctx, user)
+}
+
 // UpdateGroupToken mocks base method.
 func (m *MockAuthServer) UpdateGroupToken(ctx context.Context, group *security.UserGroup) *service_manage.Response {
 	m.ctrl.T.Helper()
@@ -637,6 +679,34 @@ func (mr *MockUserOperatorMockRecorder) ResetUserToken(ctx, user interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserToken", reflect.TypeOf((*MockUserOperator)(nil).ResetUserToken), ctx, user)
 }
 
+// UnlockLoginAddress mocks base method.
+func (m *MockUserOperator) UnlockLoginAddress(ctx context.Context, address string) *service_manage.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLoginAddress", ctx, address)
+	ret0, _ := ret[0].(*service_manage.Response)
+	return ret0
+}
+
+// UnlockLoginAddress indicates an expected call of UnlockLoginAddress.
+func (mr *MockUserOperatorMockRecorder) UnlockLoginAddress(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLoginAddress", reflect.TypeOf((*MockUserOperator)(nil).UnlockLoginAddress), ctx, address)
+}
+
+// UnlockUser mocks base method.
+func (m *MockUserOperator) UnlockUser(ctx context.Context, user *security.User) *service_manage.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, user)
+	ret0, _ := ret[0].(*service_manage.Response)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockUserOperatorMockRecorder) UnlockUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockUserOperator)(nil).UnlockUser), ctx, user)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUserOperator) UpdateUser(ctx context.Context, user *security.User) *service_manage.Response {
 	m.ctrl.T.Helper()
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -58,6 +59,15 @@ type Bootstrap struct {
 	Logger         map[string]*log.Options
 	StartInOrder   map[string]interface{} `yaml:"startInOrder"`
 	PolarisService PolarisService         `yaml:"polaris_service"`
+	Reload         Reload                 `yaml:"reload"`
+}
+
+// Reload 配置文件热加载的配置
+type Reload struct {
+	// Watch 是否监听配置文件的变化，变化后自动重新加载
+	Watch bool `yaml:"watch"`
+	// Interval 检查配置文件变化的间隔
+	Interval time.Duration `yaml:"interval"`
 }
 
 // PolarisService polaris-server的自注册配置
@@ -92,14 +102,22 @@ const (
 	DefaultFilePath = "polaris-server.yaml"
 	// DefaultHeartbeatInterval default interval second for heartbeat
 	DefaultHeartbeatInterval = 5
+	// DefaultReloadInterval default interval for checking config file changes
+	DefaultReloadInterval = 10 * time.Second
 )
 
 // Load 加载配置
 func Load(filePath string) (*Config, error) {
+	conf, _, err := LoadWithContent(filePath)
+	return conf, err
+}
+
+// LoadWithContent 加载配置，同时返回配置文件的原始内容，用于重新加载时对比配置
+func LoadWithContent(filePath string) (*Config, []byte, error) {
 	if filePath == "" {
 		err := errors.New("invalid config file path")
 		fmt.Printf("[ERROR] %v\n", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	fmt.Printf("[INFO] load config from %v\n", filePath)
@@ -107,22 +125,31 @@ func Load(filePath string) (*Config, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		fmt.Printf("[ERROR] %v\n", err)
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 	buf, err := ioutil.ReadFile(filePath)
 	if nil != err {
-		return nil, fmt.Errorf("read file %s error", filePath)
+		return nil, nil, fmt.Errorf("read file %s error", filePath)
 	}
 
-	conf := &Config{}
-	if err = parseYamlContent(string(buf), conf); err != nil {
+	conf, err := Parse(buf)
+	if err != nil {
 		fmt.Printf("[ERROR] %v\n", err)
-		return nil, err
+		return nil, nil, err
 	}
 
+	return conf, buf, nil
+}
+
+// Parse 解析配置文件内容
+func Parse(buf []byte) (*Config, error) {
+	conf := &Config{}
+	if err := parseYamlContent(string(buf), conf); err != nil {
+		return nil, err
+	}
 	return conf, nil
 }
 
@@ -0,0 +1,244 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/apiserver"
+	boot_config "github.com/polarismesh/polaris/bootstrap/config"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service/healthcheck"
+)
+
+var (
+	reloadLock sync.Mutex
+	// configContent 当前生效的配置文件内容
+	configContent []byte
+	// serverErrCh apiserver 运行出错的通知管道，重启 apiserver 时使用
+	serverErrCh chan error
+)
+
+// ReloadConfig 重新读取配置文件，对比当前生效的配置，只重新加载发生变化的配置项，返回重新加载的配置项。
+// 存在不支持热加载的配置项变化时，拒绝整个配置文件的重新加载
+func ReloadConfig() ([]string, error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	buf, err := ioutil.ReadFile(ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(buf, configContent) {
+		return []string{}, nil
+	}
+	cur, err := boot_config.Parse(configContent)
+	if err != nil {
+		return nil, err
+	}
+	next, err := boot_config.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	if keys := checkReloadConfig(cur, next); len(keys) > 0 {
+		return nil, fmt.Errorf("config %s can not be reloaded, restart the server to apply it",
+			strings.Join(keys, ", "))
+	}
+	reloaded, err := applyReloadConfig(cur, next)
+	if err != nil {
+		log.Errorf("[Bootstrap] reload config err: %s, reloaded: %v", err.Error(), reloaded)
+		return reloaded, err
+	}
+	configContent = buf
+	log.Infof("[Bootstrap] reload config success, reloaded: %v", reloaded)
+	return reloaded, nil
+}
+
+// checkReloadConfig 返回发生变化但是不支持热加载的配置项，插件配置由插件模块自行检查
+func checkReloadConfig(cur, next *boot_config.Config) []string {
+	var keys []string
+	notEqual := func(key string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			keys = append(keys, key)
+		}
+	}
+
+	notEqual("bootstrap.startInOrder", cur.Bootstrap.StartInOrder, next.Bootstrap.StartInOrder)
+	notEqual("bootstrap.polaris_service", cur.Bootstrap.PolarisService, next.Bootstrap.PolarisService)
+	notEqual("bootstrap.reload", cur.Bootstrap.Reload, next.Bootstrap.Reload)
+	// 日志只支持调整输出级别
+	notEqual("bootstrap.logger", withoutOutputLevel(cur.Bootstrap.Logger), withoutOutputLevel(next.Bootstrap.Logger))
+
+	// 只支持调整已有 apiserver 的配置，不支持增删
+	if len(cur.APIServers) != len(next.APIServers) {
+		keys = append(keys, "apiservers")
+	} else {
+		for i := range cur.APIServers {
+			notEqual("apiservers", cur.APIServers[i].Name, next.APIServers[i].Name)
+		}
+	}
+
+	// 缓存只支持调整变更日志以及快照的间隔等配置
+	notEqual("cache.open", cur.Cache.Open, next.Cache.Open)
+	notEqual("cache.diffTime", cur.Cache.DiffTime, next.Cache.DiffTime)
+	notEqual("cache.resources", cur.Cache.Resources, next.Cache.Resources)
+	notEqual("cache.changeFeed.open", cur.Cache.ChangeFeed.Open, next.Cache.ChangeFeed.Open)
+	notEqual("cache.snapshot.open", cur.Cache.Snapshot.Open, next.Cache.Snapshot.Open)
+
+	// 健康检查只支持调整检查间隔
+	notEqual("healthcheck", withoutCheckInterval(cur.HealthChecks), withoutCheckInterval(next.HealthChecks))
+
+	notEqual("namespace", cur.Namespace, next.Namespace)
+	notEqual("naming", cur.Naming, next.Naming)
+	notEqual("config", cur.Config, next.Config)
+	notEqual("maintain", cur.Maintain, next.Maintain)
+	notEqual("store", cur.Store, next.Store)
+	notEqual("auth", cur.Auth, next.Auth)
+
+	sort.Strings(keys)
+	ret := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			ret = append(ret, key)
+		}
+	}
+	return ret
+}
+
+func withoutOutputLevel(options map[string]*log.Options) map[string]log.Options {
+	ret := make(map[string]log.Options, len(options))
+	for scope, option := range options {
+		if option == nil {
+			continue
+		}
+		item := *option
+		item.OutputLevel = ""
+		ret[scope] = item
+	}
+	return ret
+}
+
+func withoutCheckInterval(conf healthcheck.Config) healthcheck.Config {
+	conf.MinCheckInterval = 0
+	conf.MaxCheckInterval = 0
+	conf.ClientCheckTtl = 0
+	return conf
+}
+
+// applyReloadConfig 重新加载发生变化的配置项，调用前需要通过 checkReloadConfig 的检查
+func applyReloadConfig(cur, next *boot_config.Config) ([]string, error) {
+	reloaded, err := plugin.ReloadPluginConfig(&next.Plugin)
+	if err != nil {
+		return reloaded, err
+	}
+
+	for scope, option := range next.Bootstrap.Logger {
+		if option == nil || cur.Bootstrap.Logger[scope] == nil ||
+			option.OutputLevel == cur.Bootstrap.Logger[scope].OutputLevel {
+			continue
+		}
+		level := option.OutputLevel
+		if level == "" {
+			level = log.InfoLevel.Name()
+		}
+		if err := log.SetLogOutputLevel(scope, level); err != nil {
+			return reloaded, fmt.Errorf("reload bootstrap.logger.%s err: %w", scope, err)
+		}
+		reloaded = append(reloaded, "bootstrap.logger."+scope)
+	}
+
+	if !reflect.DeepEqual(cur.Cache, next.Cache) {
+		cacheMgn, err := cache.GetCacheManager()
+		if err != nil {
+			return reloaded, err
+		}
+		cacheMgn.Reload(&next.Cache)
+		reloaded = append(reloaded, "cache")
+	}
+
+	if !reflect.DeepEqual(cur.HealthChecks, next.HealthChecks) {
+		healthCheckServer, err := healthcheck.GetServer()
+		if err != nil {
+			return reloaded, err
+		}
+		healthCheckServer.Reload(&next.HealthChecks)
+		reloaded = append(reloaded, "healthcheck")
+	}
+
+	// apiserver 的配置变化时重启对应的 apiserver，会断开该 apiserver 上的连接
+	for i, protocol := range next.APIServers {
+		if reflect.DeepEqual(cur.APIServers[i], protocol) {
+			continue
+		}
+		slot, exist := apiserver.Slots[protocol.Name]
+		if !exist {
+			return reloaded, fmt.Errorf("apiserver %s not exists", protocol.Name)
+		}
+		log.Infof("[Bootstrap] restart apiserver %s to reload config", protocol.Name)
+		if err := slot.Restart(protocol.Option, protocol.API, serverErrCh); err != nil {
+			return reloaded, fmt.Errorf("restart apiserver %s err: %w", protocol.Name, err)
+		}
+		reloaded = append(reloaded, "apiservers."+protocol.Name)
+	}
+	return reloaded, nil
+}
+
+// watchConfigFile 定时检查配置文件的内容，发生变化后重新加载
+func watchConfigFile(ctx context.Context, conf boot_config.Reload) {
+	if !conf.Watch {
+		return
+	}
+	interval := conf.Interval
+	if interval <= 0 {
+		interval = boot_config.DefaultReloadInterval
+	}
+	log.Infof("[Bootstrap] watch config file %s, interval: %s", ConfigFilePath, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// lastSeen 最近一次尝试加载的内容，加载失败时不会重复尝试同样的内容
+	lastSeen := configContent
+	for {
+		select {
+		case <-ticker.C:
+			buf, err := ioutil.ReadFile(ConfigFilePath)
+			if err != nil {
+				log.Errorf("[Bootstrap] read config file %s err: %s", ConfigFilePath, err.Error())
+				continue
+			}
+			if bytes.Equal(buf, lastSeen) {
+				continue
+			}
+			lastSeen = buf
+			log.Infof("[Bootstrap] config file %s changed, reload it", ConfigFilePath)
+			_, _ = ReloadConfig()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
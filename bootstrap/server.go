@@ -62,11 +62,12 @@ func Start(configFilePath string) {
 	// 加载配置
 	ConfigFilePath = configFilePath
 	utils.ConfDir = parseConfDir(configFilePath)
-	cfg, err := boot_config.Load(configFilePath)
+	cfg, content, err := boot_config.LoadWithContent(configFilePath)
 	if err != nil {
 		fmt.Printf("[ERROR] load config fail\n")
 		return
 	}
+	configContent = content
 
 	c, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -136,6 +137,11 @@ func Start(configFilePath string) {
 	_ = FinishBootstrapOrder(tx) // 启动完成，解锁
 	fmt.Println("finish starting server")
 
+	// 配置文件热加载
+	serverErrCh = errCh
+	maintain.SetConfigReloader(ReloadConfig)
+	go watchConfigFile(ctx, cfg.Bootstrap.Reload)
+
 	// 等待信号量
 	WaitSignal(servers, errCh)
 	fmt.Println("begin stop server")
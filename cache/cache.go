@@ -237,6 +237,28 @@ func (bc *baseCache) doCacheUpdate(name string, executor func() (map[string]time
 	return nil
 }
 
+// snapshotMeta 获取快照需要记录的拉取时间以及最后修改时间
+func (bc *baseCache) snapshotMeta() (int64, map[string]time.Time) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	lastMtimes := make(map[string]time.Time, len(bc.lastMtimes))
+	for label, mtime := range bc.lastMtimes {
+		lastMtimes[label] = mtime
+	}
+	return bc.lastFetchTime, lastMtimes
+}
+
+// restoreMeta 从快照恢复拉取时间以及最后修改时间，之后的 update 从该时间点增量拉取
+func (bc *baseCache) restoreMeta(lastFetchTime int64, lastMtimes map[string]time.Time) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	bc.lastFetchTime = lastFetchTime
+	if lastMtimes != nil {
+		bc.lastMtimes = lastMtimes
+	}
+	bc.firtstUpdate = false
+}
+
 func (bc *baseCache) clear() {
 	bc.lock.Lock()
 	defer bc.lock.Unlock()
@@ -286,6 +308,11 @@ type CacheManager struct {
 	comRevisionCh chan *revisionNotify
 	revisions     map[string]string // service id -> reversion (所有instance reversion 的累计计算值)
 	lock          sync.RWMutex      // for revisions rw lock
+	// updateLock 缓存刷新持有读锁，ResyncCache 清空缓存重新加载时持有写锁
+	updateLock sync.RWMutex
+
+	feed      *changeFeed
+	snapshots *snapshotManager
 }
 
 // initialize 缓存对象初始化
@@ -312,12 +339,23 @@ func (nc *CacheManager) initialize() error {
 
 // update 缓存更新
 func (nc *CacheManager) update() error {
+	return nc.updateCaches(nil)
+}
+
+// updateCaches 更新指定的缓存，indexes 为空时更新全部缓存
+func (nc *CacheManager) updateCaches(indexes map[int]struct{}) error {
+	nc.updateLock.RLock()
+	defer nc.updateLock.RUnlock()
+
 	var wg sync.WaitGroup
 	for _, entry := range config.Resources {
 		index, exist := cacheSet[entry.Name]
 		if !exist {
 			return fmt.Errorf("cache resource %s not exists", entry.Name)
 		}
+		if _, ok := indexes[index]; len(indexes) > 0 && !ok {
+			continue
+		}
 		wg.Add(1)
 		go func(c Cache) {
 			defer wg.Done()
@@ -366,6 +404,17 @@ func (nc *CacheManager) Start(ctx context.Context) error {
 	// 先启动revision计算协程
 	go nc.revisionWorker(ctx)
 
+	feed := newChangeFeed(nc, config.ChangeFeed)
+	nc.feed = feed
+
+	// 开启了快照时，先从本地快照恢复，首次更新只需要增量拉取
+	snapshots := newSnapshotManager(nc, config.Snapshot)
+	nc.snapshots = snapshots
+	var restored map[int]snapshotCache
+	if snapshots != nil {
+		restored = snapshots.restore()
+	}
+
 	// 启动的时候，先更新一版缓存
 	log.Infof("[Cache] cache update now first time")
 	if err := nc.update(); err != nil {
@@ -373,6 +422,17 @@ func (nc *CacheManager) Start(ctx context.Context) error {
 	}
 	log.Infof("[Cache] cache update done")
 
+	if snapshots != nil {
+		snapshots.verify(restored)
+		go snapshots.run(ctx)
+	}
+
+	// 开启了变更日志时，按照变更按需刷新缓存
+	if feed != nil {
+		go feed.run(ctx)
+		return nil
+	}
+
 	// 启动协程，开始定时更新缓存数据
 	go func() {
 		ticker := time.NewTicker(nc.GetUpdateCacheInterval())
@@ -391,6 +451,17 @@ func (nc *CacheManager) Start(ctx context.Context) error {
 	return nil
 }
 
+// Reload 重新加载缓存配置，只处理变更日志以及快照的间隔等配置项，
+// 缓存资源以及各个功能的开关等配置项的变更需要由调用方提前拒绝
+func (nc *CacheManager) Reload(conf *Config) {
+	if nc.feed != nil {
+		nc.feed.reload(conf.ChangeFeed)
+	}
+	if nc.snapshots != nil {
+		nc.snapshots.reload(conf.Snapshot)
+	}
+}
+
 // Clear 主动清除缓存数据
 func (nc *CacheManager) Clear() error {
 	nc.lock.Lock()
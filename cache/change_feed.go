@@ -0,0 +1,268 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// DefaultChangeFeedInterval 默认拉取变更日志的间隔
+	DefaultChangeFeedInterval = 200 * time.Millisecond
+	// DefaultChangeFeedFallbackInterval 默认的兜底全量轮询间隔
+	DefaultChangeFeedFallbackInterval = 30 * time.Second
+	// DefaultChangeFeedBatchSize 默认每次拉取变更日志的条数
+	DefaultChangeFeedBatchSize = 1000
+	// DefaultChangeLogRetention 默认的变更日志保留时长
+	DefaultChangeLogRetention = time.Hour
+	// changeLogCleanInterval 清理变更日志的间隔
+	changeLogCleanInterval = time.Minute
+	// changeLogGapTimeout 变更序号空洞的最长等待时间，超过后认为对应的事务已经回滚，
+	// 更晚提交的变更由兜底的全量轮询恢复
+	changeLogGapTimeout = 10 * time.Second
+)
+
+// changeResourceCaches 变更资源类型对应需要刷新的缓存
+var changeResourceCaches = map[model.ChangeResource][]int{
+	model.ChangeResourceNamespace:      {CacheNamespace},
+	model.ChangeResourceService:        {CacheService},
+	model.ChangeResourceInstance:       {CacheInstance},
+	model.ChangeResourceRouting:        {CacheRoutingConfig},
+	model.ChangeResourceRateLimit:      {CacheRateLimit},
+	model.ChangeResourceCircuitBreaker: {CacheCircuitBreaker},
+	model.ChangeResourceFaultDetect:    {CacheFaultDetector},
+	model.ChangeResourceUser:           {CacheUser},
+	model.ChangeResourceAuthStrategy:   {CacheAuthStrategy},
+	model.ChangeResourceConfigFile:     {CacheConfigFile},
+	model.ChangeResourceL5:             {CacheCL5},
+}
+
+// pollingCaches 不记录变更日志的缓存，仍然按照缓存的更新间隔定时轮询
+var pollingCaches = map[int]struct{}{
+	CacheClient: {},
+}
+
+// changeFeed 跟随存储层的变更日志，只在对应资源发生变更时刷新缓存，
+// 缓存仍然通过 mtime 增量拉取数据，变更日志只决定刷新的时机，因此遗漏的变更会由兜底的全量轮询恢复。
+// 自增序号在事务开始时分配、提交时才可见，较小的序号可能晚于较大的序号提交，
+// 因此 lastSeq 只推进到第一个空洞之前，空洞之后已经处理过的序号记录在 seen 中
+type changeFeed struct {
+	storage store.ChangeLogStore
+	conf    ChangeFeedConfig
+	// pollInterval 定时轮询 pollingCaches 的间隔
+	pollInterval time.Duration
+	// lastSeq 不大于该序号的变更都已经处理
+	lastSeq uint64
+	// seen 大于 lastSeq 且已经处理的序号
+	seen map[uint64]struct{}
+	// gaps 大于 lastSeq 但还没有读到的序号，以及首次发现的时间
+	gaps map[uint64]time.Time
+	// update 刷新指定的缓存，indexes 为空时刷新全部缓存
+	update func(indexes map[int]struct{}) error
+	// pending 待生效的新配置
+	pending  atomic.Value
+	reloadCh chan struct{}
+}
+
+// newChangeFeed 存储插件不支持或者未开启变更日志时返回 nil，缓存继续使用定时轮询
+func newChangeFeed(nc *CacheManager, conf ChangeFeedConfig) *changeFeed {
+	if !conf.Open {
+		return nil
+	}
+	storage, ok := nc.storage.(store.ChangeLogStore)
+	if !ok || !storage.ChangeLogEnabled() {
+		log.Warnf("[Cache][ChangeFeed] store %s does not enable change log, fallback to polling", nc.storage.Name())
+		return nil
+	}
+	// 在首次全量刷新之前记录序号，避免遗漏首次刷新过程中产生的变更
+	seq, err := storage.GetLatestChangeSeq()
+	if err != nil {
+		log.Errorf("[Cache][ChangeFeed] get latest change seq err: %s, fallback to polling", err.Error())
+		return nil
+	}
+	return &changeFeed{
+		storage:      storage,
+		conf:         conf.withDefault(),
+		pollInterval: nc.GetUpdateCacheInterval(),
+		lastSeq:      seq,
+		seen:         map[uint64]struct{}{},
+		gaps:         map[uint64]time.Time{},
+		update:       nc.updateCaches,
+		reloadCh:     make(chan struct{}, 1),
+	}
+}
+
+func (conf ChangeFeedConfig) withDefault() ChangeFeedConfig {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultChangeFeedInterval
+	}
+	if conf.FallbackInterval <= 0 {
+		conf.FallbackInterval = DefaultChangeFeedFallbackInterval
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = DefaultChangeFeedBatchSize
+	}
+	if conf.Retention <= 0 {
+		conf.Retention = DefaultChangeLogRetention
+	}
+	return conf
+}
+
+// reload 通知 run 协程使用新的配置，配置只在 run 协程内读写
+func (f *changeFeed) reload(conf ChangeFeedConfig) {
+	f.pending.Store(conf.withDefault())
+	select {
+	case f.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// run 拉取变更日志、定时轮询、兜底全量轮询以及清理变更日志都在同一个协程内执行，同一个缓存不会被并发刷新
+func (f *changeFeed) run(ctx context.Context) {
+	log.Infof("[Cache][ChangeFeed] start tailing change log from seq %d", f.lastSeq)
+	tailTicker := time.NewTicker(f.conf.Interval)
+	defer tailTicker.Stop()
+	fallbackTicker := time.NewTicker(f.conf.FallbackInterval)
+	defer fallbackTicker.Stop()
+	cleanTicker := time.NewTicker(changeLogCleanInterval)
+	defer cleanTicker.Stop()
+	pollTicker := time.NewTicker(f.pollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-tailTicker.C:
+			f.tail()
+		case <-fallbackTicker.C:
+			f.fallback()
+		case <-cleanTicker.C:
+			f.clean()
+		case <-pollTicker.C:
+			_ = f.update(pollingCaches)
+		case <-f.reloadCh:
+			f.conf = f.pending.Load().(ChangeFeedConfig)
+			tailTicker.Reset(f.conf.Interval)
+			fallbackTicker.Reset(f.conf.FallbackInterval)
+			log.Infof("[Cache][ChangeFeed] reload config, interval: %s, fallback interval: %s",
+				f.conf.Interval, f.conf.FallbackInterval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tail 拉取 lastSeq 之后的全部变更，跳过已经处理过的序号，合并后一次性刷新涉及的缓存
+func (f *changeFeed) tail() {
+	indexes := map[int]struct{}{}
+	fresh := map[uint64]struct{}{}
+	seq, maxSeq := f.lastSeq, f.lastSeq
+	for {
+		logs, err := f.storage.GetMoreChangeLogs(seq, f.conf.BatchSize)
+		if err != nil {
+			log.Errorf("[Cache][ChangeFeed] get change logs after seq %d err: %s", seq, err.Error())
+			return
+		}
+		for _, item := range logs {
+			if _, ok := f.seen[item.Seq]; ok {
+				continue
+			}
+			fresh[item.Seq] = struct{}{}
+			for _, index := range changeResourceCaches[item.Resource] {
+				indexes[index] = struct{}{}
+			}
+		}
+		if len(logs) > 0 {
+			seq = logs[len(logs)-1].Seq
+			if seq > maxSeq {
+				maxSeq = seq
+			}
+		}
+		if len(logs) < f.conf.BatchSize {
+			break
+		}
+	}
+	if len(indexes) > 0 {
+		if err := f.update(indexes); err != nil {
+			log.Errorf("[Cache][ChangeFeed] update caches err: %s", err.Error())
+			return
+		}
+	}
+	for item := range fresh {
+		f.seen[item] = struct{}{}
+		delete(f.gaps, item)
+	}
+	f.advance(maxSeq, time.Now())
+}
+
+// advance 记录新发现的序号空洞，并把 lastSeq 推进到第一个未超时的空洞之前
+func (f *changeFeed) advance(maxSeq uint64, now time.Time) {
+	for item := f.lastSeq + 1; item <= maxSeq; item++ {
+		if _, ok := f.seen[item]; ok {
+			continue
+		}
+		if _, ok := f.gaps[item]; !ok {
+			f.gaps[item] = now
+		}
+	}
+	for {
+		next := f.lastSeq + 1
+		if _, ok := f.seen[next]; ok {
+			delete(f.seen, next)
+			f.lastSeq = next
+			continue
+		}
+		found, ok := f.gaps[next]
+		if ok && now.Sub(found) >= changeLogGapTimeout {
+			log.Warnf("[Cache][ChangeFeed] change seq %d not found after %s, skip", next, changeLogGapTimeout)
+			delete(f.gaps, next)
+			f.lastSeq = next
+			continue
+		}
+		return
+	}
+}
+
+// fallback 全量轮询一次，同时处理变更序号回退的情况，比如变更日志被清空或者 raft 节点从快照恢复
+func (f *changeFeed) fallback() {
+	seq, err := f.storage.GetLatestChangeSeq()
+	if err != nil {
+		log.Errorf("[Cache][ChangeFeed] get latest change seq err: %s", err.Error())
+	} else if seq < f.lastSeq {
+		log.Warnf("[Cache][ChangeFeed] change seq goes back from %d to %d, reset", f.lastSeq, seq)
+		f.lastSeq = seq
+		f.seen = map[uint64]struct{}{}
+		f.gaps = map[uint64]time.Time{}
+	}
+	_ = f.update(nil)
+}
+
+func (f *changeFeed) clean() {
+	count, err := f.storage.CleanChangeLogs(f.conf.Retention)
+	if err != nil {
+		log.Errorf("[Cache][ChangeFeed] clean change logs err: %s", err.Error())
+		return
+	}
+	if count > 0 {
+		log.Infof("[Cache][ChangeFeed] clean %d change logs", count)
+	}
+}
@@ -0,0 +1,258 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+type fakeChangeLogStore struct {
+	logs []*model.ChangeLog
+	err  error
+}
+
+func (f *fakeChangeLogStore) ChangeLogEnabled() bool {
+	return true
+}
+
+func (f *fakeChangeLogStore) GetLatestChangeSeq() (uint64, error) {
+	if len(f.logs) == 0 {
+		return 0, f.err
+	}
+	return f.logs[len(f.logs)-1].Seq, f.err
+}
+
+func (f *fakeChangeLogStore) GetMoreChangeLogs(seq uint64, limit int) ([]*model.ChangeLog, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var ret []*model.ChangeLog
+	for _, item := range f.logs {
+		if item.Seq > seq && len(ret) < limit {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+func (f *fakeChangeLogStore) CleanChangeLogs(retention time.Duration) (uint64, error) {
+	return 0, nil
+}
+
+func (f *fakeChangeLogStore) append(resources ...model.ChangeResource) {
+	for _, resource := range resources {
+		f.logs = append(f.logs, &model.ChangeLog{
+			Seq:        uint64(len(f.logs) + 1),
+			Resource:   resource,
+			CreateTime: time.Now(),
+		})
+	}
+}
+
+// insert 模拟较小的序号晚于较大的序号提交
+func (f *fakeChangeLogStore) insert(seq uint64, resource model.ChangeResource) {
+	item := &model.ChangeLog{Seq: seq, Resource: resource, CreateTime: time.Now()}
+	for i := range f.logs {
+		if f.logs[i].Seq > seq {
+			f.logs = append(f.logs[:i], append([]*model.ChangeLog{item}, f.logs[i:]...)...)
+			return
+		}
+	}
+	f.logs = append(f.logs, item)
+}
+
+func newTestChangeFeed(storage *fakeChangeLogStore) (*changeFeed, *[]map[int]struct{}) {
+	var updates []map[int]struct{}
+	f := &changeFeed{
+		storage: storage,
+		conf:    ChangeFeedConfig{BatchSize: 2},
+		seen:    map[uint64]struct{}{},
+		gaps:    map[uint64]time.Time{},
+		update: func(indexes map[int]struct{}) error {
+			updates = append(updates, indexes)
+			return nil
+		},
+	}
+	return f, &updates
+}
+
+func TestChangeFeed_Tail(t *testing.T) {
+	storage := &fakeChangeLogStore{}
+	f, updates := newTestChangeFeed(storage)
+
+	// 没有变更时不刷新缓存
+	f.tail()
+	assert.Empty(t, *updates)
+
+	// 多个批次的变更合并后一次性刷新
+	storage.append(model.ChangeResourceInstance, model.ChangeResourceRouting, model.ChangeResourceService,
+		model.ChangeResource("unknown"), model.ChangeResourceConfigFile)
+	f.tail()
+	assert.Equal(t, uint64(5), f.lastSeq)
+	assert.Len(t, *updates, 1)
+	assert.Equal(t, map[int]struct{}{
+		CacheInstance:      {},
+		CacheRoutingConfig: {},
+		CacheService:       {},
+		CacheConfigFile:    {},
+	}, (*updates)[0])
+
+	// 只有无需刷新缓存的变更时，仅推进序号
+	storage.append(model.ChangeResource("unknown"))
+	f.tail()
+	assert.Equal(t, uint64(6), f.lastSeq)
+	assert.Len(t, *updates, 1)
+}
+
+func TestChangeFeed_TailGap(t *testing.T) {
+	storage := &fakeChangeLogStore{}
+	f, updates := newTestChangeFeed(storage)
+
+	// 序号 2 的事务还未提交，lastSeq 停在空洞之前
+	storage.insert(1, model.ChangeResourceService)
+	storage.insert(3, model.ChangeResourceUser)
+	storage.insert(4, model.ChangeResourceConfigFile)
+	f.tail()
+	assert.Equal(t, uint64(1), f.lastSeq)
+	assert.Len(t, *updates, 1)
+	assert.Contains(t, f.gaps, uint64(2))
+
+	// 已经处理过的序号不会重复刷新缓存
+	f.tail()
+	assert.Len(t, *updates, 1)
+
+	// 空洞被填上后只刷新新提交的变更，并推进到最大序号
+	storage.insert(2, model.ChangeResourceRouting)
+	f.tail()
+	assert.Equal(t, uint64(4), f.lastSeq)
+	assert.Len(t, *updates, 2)
+	assert.Equal(t, map[int]struct{}{CacheRoutingConfig: {}}, (*updates)[1])
+	assert.Empty(t, f.seen)
+	assert.Empty(t, f.gaps)
+
+	// 回滚的事务留下的空洞超时后跳过
+	storage.insert(6, model.ChangeResourceService)
+	f.tail()
+	assert.Equal(t, uint64(4), f.lastSeq)
+	f.gaps[5] = time.Now().Add(-changeLogGapTimeout)
+	f.tail()
+	assert.Equal(t, uint64(6), f.lastSeq)
+	assert.Len(t, *updates, 3)
+	assert.Empty(t, f.seen)
+	assert.Empty(t, f.gaps)
+}
+
+func TestChangeFeed_TailError(t *testing.T) {
+	storage := &fakeChangeLogStore{}
+	storage.append(model.ChangeResourceUser)
+	f, updates := newTestChangeFeed(storage)
+
+	// 拉取失败时不推进序号
+	storage.err = errors.New("mock error")
+	f.tail()
+	assert.Equal(t, uint64(0), f.lastSeq)
+	assert.Empty(t, *updates)
+
+	// 刷新缓存失败时不推进序号，下次重新拉取
+	storage.err = nil
+	f.update = func(indexes map[int]struct{}) error {
+		return errors.New("mock error")
+	}
+	f.tail()
+	assert.Equal(t, uint64(0), f.lastSeq)
+}
+
+func TestChangeFeed_Fallback(t *testing.T) {
+	storage := &fakeChangeLogStore{}
+	f, updates := newTestChangeFeed(storage)
+	f.lastSeq = 10
+
+	// 变更序号回退时重置，并且全量刷新一次
+	storage.append(model.ChangeResourceNamespace)
+	f.fallback()
+	assert.Equal(t, uint64(1), f.lastSeq)
+	assert.Len(t, *updates, 1)
+	assert.Nil(t, (*updates)[0])
+}
+
+func TestChangeFeed_Reload(t *testing.T) {
+	storage := &fakeChangeLogStore{}
+	storage.append(model.ChangeResourceService)
+	updated := make(chan map[int]struct{}, 1)
+	f := &changeFeed{
+		storage:      storage,
+		conf:         ChangeFeedConfig{Interval: time.Hour, FallbackInterval: time.Hour, BatchSize: 10},
+		pollInterval: time.Hour,
+		seen:         map[uint64]struct{}{},
+		gaps:         map[uint64]time.Time{},
+		update: func(indexes map[int]struct{}) error {
+			updated <- indexes
+			return nil
+		},
+		reloadCh: make(chan struct{}, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.run(ctx)
+
+	// 缩短拉取间隔后，新的间隔立即生效
+	f.reload(ChangeFeedConfig{Interval: 10 * time.Millisecond, FallbackInterval: time.Hour})
+	select {
+	case indexes := <-updated:
+		assert.Equal(t, map[int]struct{}{CacheService: {}}, indexes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change feed interval not reloaded")
+	}
+}
+
+func TestChangeFeed_Poll(t *testing.T) {
+	updated := make(chan map[int]struct{}, 1)
+	f := &changeFeed{
+		storage:      &fakeChangeLogStore{},
+		conf:         ChangeFeedConfig{Interval: time.Hour, FallbackInterval: time.Hour, BatchSize: 10},
+		pollInterval: 10 * time.Millisecond,
+		seen:         map[uint64]struct{}{},
+		gaps:         map[uint64]time.Time{},
+		update: func(indexes map[int]struct{}) error {
+			select {
+			case updated <- indexes:
+			default:
+			}
+			return nil
+		},
+		reloadCh: make(chan struct{}, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.run(ctx)
+
+	// 客户端不记录变更日志，没有变更时也按照缓存的更新间隔轮询
+	select {
+	case indexes := <-updated:
+		assert.Equal(t, map[int]struct{}{CacheClient: {}}, indexes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("polling caches not updated")
+	}
+}
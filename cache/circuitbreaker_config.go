@@ -19,6 +19,8 @@ package cache
 
 import (
 	"crypto/sha1"
+	"encoding/json"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -378,3 +380,59 @@ func (c *circuitBreakerCache) GetCircuitBreakerCount() int {
 	}
 	return len(names)
 }
+
+// allRules 获取缓存的全部熔断规则，同一条规则会关联到多个服务，按照规则ID去重
+func (c *circuitBreakerCache) allRules() map[string]*model.CircuitBreakerRule {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	rules := make(map[string]*model.CircuitBreakerRule)
+	collect := func(rule *model.CircuitBreakerRule) {
+		rules[rule.ID] = rule
+	}
+	c.allWildcardRules.IterateCircuitBreakerRules(collect)
+	for _, values := range c.nsWildcardRules {
+		values.IterateCircuitBreakerRules(collect)
+	}
+	for _, svcRules := range c.circuitBreakers {
+		for _, values := range svcRules {
+			values.IterateCircuitBreakerRules(collect)
+		}
+	}
+	return rules
+}
+
+// dumpSnapshot 导出缓存的全部熔断规则
+func (c *circuitBreakerCache) dumpSnapshot() ([]byte, int, error) {
+	rules := c.allRules()
+	items := make([]*model.CircuitBreakerRule, 0, len(rules))
+	for _, rule := range rules {
+		item := *rule
+		item.Proto = nil
+		items = append(items, &item)
+	}
+	data, err := json.Marshal(items)
+	return data, len(items), err
+}
+
+// loadSnapshot 使用快照中的熔断规则恢复缓存，和从存储加载走相同的流程
+func (c *circuitBreakerCache) loadSnapshot(data []byte) error {
+	var rules []*model.CircuitBreakerRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	c.setCircuitBreaker(rules)
+	return nil
+}
+
+// verifySnapshot 校验缓存的熔断规则总数与存储是否一致
+func (c *circuitBreakerCache) verifySnapshot() error {
+	total, _, err := c.storage.GetCircuitBreakerRules(map[string]string{}, 0, 1)
+	if err != nil {
+		return err
+	}
+	if count := len(c.allRules()); count != int(total) {
+		return fmt.Errorf("circuit breaker rule count not match, expect %d, actual %d", total, count)
+	}
+	return nil
+}
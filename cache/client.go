@@ -53,6 +53,9 @@ type ClientCache interface {
 
 	// GetClientsByFilter Query client information
 	GetClientsByFilter(filters map[string]string, offset, limit uint32) (uint32, []*model.Client, error)
+
+	// GetClientByHost 根据客户端地址获取最近上报的客户端
+	GetClientByHost(host string) *model.Client
 }
 
 // clientCache 客户端缓存的类
@@ -62,6 +65,7 @@ type clientCache struct {
 	storage         store.Store
 	lastMtimeLogged int64
 	clients         map[string]*model.Client // instance id -> instance
+	hosts           map[string]string        // client host -> client id
 	lock            sync.RWMutex
 	singleFlight    *singleflight.Group
 	lastUpdateTime  time.Time
@@ -83,6 +87,7 @@ func newClientCache(storage store.Store) *clientCache {
 		baseCache: newBaseCache(storage),
 		storage:   storage,
 		clients:   map[string]*model.Client{},
+		hosts:     map[string]string{},
 	}
 }
 
@@ -137,16 +142,33 @@ func (c *clientCache) getClient(id string) (*model.Client, bool) {
 
 func (c *clientCache) deleteClient(id string) {
 	c.lock.Lock()
+	c.removeHost(id)
 	delete(c.clients, id)
 	c.lock.Unlock()
 }
 
 func (c *clientCache) storeClient(id string, client *model.Client) {
 	c.lock.Lock()
+	c.removeHost(id)
 	c.clients[id] = client
+	if host := client.Proto().GetHost().GetValue(); host != "" {
+		c.hosts[host] = id
+	}
 	c.lock.Unlock()
 }
 
+// removeHost 移除client的地址索引，调用方需要持有写锁
+func (c *clientCache) removeHost(id string) {
+	client, ok := c.clients[id]
+	if !ok {
+		return
+	}
+	host := client.Proto().GetHost().GetValue()
+	if c.hosts[host] == id {
+		delete(c.hosts, host)
+	}
+}
+
 // setClients 保存client到内存中
 // 返回：更新个数，删除个数
 func (c *clientCache) setClients(clients map[string]*model.Client) (map[string]time.Time, int, int) {
@@ -203,6 +225,7 @@ func (c *clientCache) clear() error {
 	c.baseCache.clear()
 	c.lock.Lock()
 	c.clients = map[string]*model.Client{}
+	c.hosts = map[string]string{}
 	c.lock.Unlock()
 	return nil
 }
@@ -223,6 +246,22 @@ func (c *clientCache) GetClient(id string) *model.Client {
 	return value
 }
 
+// GetClientByHost 根据客户端地址获取最近上报的客户端
+func (c *clientCache) GetClientByHost(host string) *model.Client {
+	if host == "" {
+		return nil
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	id, ok := c.hosts[host]
+	if !ok {
+		return nil
+	}
+	return c.clients[id]
+}
+
 // IteratorClients 迭代
 func (c *clientCache) IteratorClients(iterProc ClientIterProc) {
 	c.lock.RLock()
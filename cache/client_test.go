@@ -124,3 +124,31 @@ func Test_clientCache_GetClientByFilter(t *testing.T) {
 		assert.Equal(t, ret[id], item[0])
 	})
 }
+
+func Test_clientCache_GetClientByHost(t *testing.T) {
+
+	t.Run("测试根据地址获取client", func(t *testing.T) {
+		ctrl, store, clientCache := newTestClientCache(t)
+		defer ctrl.Finish()
+
+		ret := mockClients(10)
+
+		id := ""
+		for k := range ret {
+			id = k
+			break
+		}
+		host := ret[id].Proto().Host.Value
+
+		store.EXPECT().GetMoreClients(gomock.Any(), gomock.Any()).Return(ret, nil)
+
+		err := clientCache.update()
+		assert.NoError(t, err)
+
+		assert.Equal(t, ret[id], clientCache.GetClientByHost(host))
+		assert.Nil(t, clientCache.GetClientByHost("10.0.0.1"))
+
+		clientCache.deleteClient(id)
+		assert.Nil(t, clientCache.GetClientByHost(host))
+	})
+}
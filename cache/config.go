@@ -24,6 +24,36 @@ type Config struct {
 	Open      bool          `yaml:"open"`
 	DiffTime  time.Duration `yaml:"diffTime"`
 	Resources []ConfigEntry `yaml:"resources"`
+	// ChangeFeed 基于存储层的变更日志按需刷新缓存
+	ChangeFeed ChangeFeedConfig `yaml:"changeFeed"`
+	// Snapshot 缓存的本地快照，加快重启时的缓存加载
+	Snapshot SnapshotConfig `yaml:"snapshot"`
+}
+
+// SnapshotConfig 缓存快照配置，重启时先从本地快照恢复缓存，再从快照的时间点增量拉取
+// 支持快照的缓存包括服务、实例、限流规则以及熔断规则。路由规则不做快照，v1 版本的规则需要借助服务缓存转换为 v2 版本，
+// 缓存中只保留转换后的规则，存储也无法统计 v2 规则的总数用于校验快照；配置文件缓存在首次访问时按需加载，没有全量加载的过程
+type SnapshotConfig struct {
+	Open bool `yaml:"open"`
+	// Dir 快照文件目录
+	Dir string `yaml:"dir"`
+	// Interval 写入快照的间隔
+	Interval time.Duration `yaml:"interval"`
+	// MaxAge 快照的最长有效时间，超过后启动时不再使用
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// ChangeFeedConfig 变更日志驱动的缓存刷新配置，需要存储插件同时开启变更日志
+type ChangeFeedConfig struct {
+	Open bool `yaml:"open"`
+	// Interval 拉取变更日志的间隔
+	Interval time.Duration `yaml:"interval"`
+	// FallbackInterval 兜底的全量轮询间隔，用于恢复遗漏或者已被清理的变更
+	FallbackInterval time.Duration `yaml:"fallbackInterval"`
+	// BatchSize 每次拉取变更日志的最大条数
+	BatchSize int `yaml:"batchSize"`
+	// Retention 变更日志的保留时长
+	Retention time.Duration `yaml:"retention"`
 }
 
 // ConfigEntry 单个缓存资源配置
@@ -0,0 +1,101 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// CacheStatus 缓存的运行状态，用于排查节点的缓存与存储不一致的问题
+type CacheStatus struct {
+	Name string `json:"name"`
+	// FirstUpdate 是否还未完成首次加载
+	FirstUpdate bool `json:"firstUpdate"`
+	// LastFetchTime 最近一次从存储拉取数据时存储的时间
+	LastFetchTime time.Time `json:"lastFetchTime"`
+	// LastMtimes 缓存数据的最后修改时间
+	LastMtimes map[string]time.Time `json:"lastMtimes"`
+	// Count 缓存的数据条数，-1 表示该缓存不统计条数
+	Count int `json:"count"`
+}
+
+// fetchStateCache 基于 baseCache 增量拉取数据的缓存
+type fetchStateCache interface {
+	Cache
+	isFirstUpdate() bool
+	snapshotMeta() (int64, map[string]time.Time)
+}
+
+func cacheItemCount(c Cache) int {
+	switch v := c.(type) {
+	case *serviceCache:
+		return v.GetServicesCount()
+	case *instanceCache:
+		return v.GetInstancesCount()
+	case *routingConfigCache:
+		return v.GetRoutingConfigCount()
+	case *rateLimitCache:
+		return v.GetRateLimitsCount()
+	case *circuitBreakerCache:
+		return v.GetCircuitBreakerCount()
+	}
+	return -1
+}
+
+// GetCacheStatus 获取已开启的各个缓存的运行状态
+func (nc *CacheManager) GetCacheStatus() []*CacheStatus {
+	ret := make([]*CacheStatus, 0, len(config.Resources))
+	for _, entry := range config.Resources {
+		index, ok := cacheSet[entry.Name]
+		if !ok {
+			continue
+		}
+		c := nc.caches[index]
+		status := &CacheStatus{
+			Name:  c.name(),
+			Count: cacheItemCount(c),
+		}
+		if fc, ok := c.(fetchStateCache); ok {
+			lastFetchTime, lastMtimes := fc.snapshotMeta()
+			status.FirstUpdate = fc.isFirstUpdate()
+			status.LastFetchTime = time.Unix(lastFetchTime, 0)
+			status.LastMtimes = lastMtimes
+		}
+		ret = append(ret, status)
+	}
+	return ret
+}
+
+// ResyncCache 清空指定的缓存后从存储全量加载，用于修复遗漏增量变更或者数据被物理删除导致的缓存不一致，
+// 加载期间暂停定时刷新，读取该缓存的请求可能短暂得到不完整的数据
+func (nc *CacheManager) ResyncCache(name string) error {
+	index, ok := cacheSet[name]
+	if !ok {
+		return fmt.Errorf("cache resource %s not exists", name)
+	}
+	c := nc.caches[index]
+
+	nc.updateLock.Lock()
+	defer nc.updateLock.Unlock()
+	log.Infof("[Cache] resync cache %s", name)
+	if err := c.clear(); err != nil {
+		return err
+	}
+	return c.update()
+}
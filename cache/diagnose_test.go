@@ -0,0 +1,56 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManager_ResyncCache(t *testing.T) {
+	ctl, storage, ic := newTestInstanceCache(t)
+	defer ctl.Finish()
+
+	nc := &CacheManager{caches: make([]Cache, CacheLast)}
+	nc.caches[CacheInstance] = ic
+
+	instances := genModelInstances("service1", 2)
+	storage.EXPECT().GetInstancesCount().AnyTimes().Return(uint32(2), nil)
+	storage.EXPECT().GetMoreInstances(gomock.Any(), true, ic.needMeta, ic.systemServiceID).Return(instances, nil)
+	assert.NoError(t, ic.update())
+	assert.Equal(t, 2, ic.GetInstancesCount())
+
+	// 其中一个实例在存储中被物理删除，重新同步后缓存中不再存在
+	var deleted string
+	for id := range instances {
+		deleted = id
+		delete(instances, id)
+		break
+	}
+	storage.EXPECT().GetMoreInstances(gomock.Any(), true, ic.needMeta, ic.systemServiceID).Return(instances, nil)
+	assert.NoError(t, nc.ResyncCache(InstanceName))
+	assert.Equal(t, 1, ic.GetInstancesCount())
+	assert.Nil(t, ic.GetInstance(deleted))
+}
+
+func TestCacheManager_ResyncCacheNotExist(t *testing.T) {
+	nc := &CacheManager{}
+	assert.Error(t, nc.ResyncCache("not_exist"))
+}
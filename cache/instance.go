@@ -18,9 +18,12 @@
 package cache
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"go.uber.org/zap"
@@ -498,3 +501,77 @@ func iteratorInstancesProc(data *sync.Map, iterProc InstanceIterProc) error {
 	data.Range(proc)
 	return err
 }
+
+// instanceRecord 快照中的实例，实例数据使用 protobuf 编码
+type instanceRecord struct {
+	Proto             []byte    `json:"proto"`
+	ServiceID         string    `json:"serviceId"`
+	ServicePlatformID string    `json:"servicePlatformId,omitempty"`
+	ModifyTime        time.Time `json:"modifyTime"`
+}
+
+// dumpSnapshot 导出缓存的全部实例
+func (ic *instanceCache) dumpSnapshot() ([]byte, int, error) {
+	var (
+		records []*instanceRecord
+		err     error
+	)
+	ic.ids.Range(func(_, value interface{}) bool {
+		item := value.(*model.Instance)
+		var buf []byte
+		if buf, err = proto.Marshal(item.Proto); err != nil {
+			return false
+		}
+		records = append(records, &instanceRecord{
+			Proto:             buf,
+			ServiceID:         item.ServiceID,
+			ServicePlatformID: item.ServicePlatformID,
+			ModifyTime:        item.ModifyTime,
+		})
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := json.Marshal(records)
+	return data, len(records), err
+}
+
+// loadSnapshot 使用快照中的实例恢复缓存，和从存储加载走相同的流程
+func (ic *instanceCache) loadSnapshot(data []byte) error {
+	var records []*instanceRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	instances := make(map[string]*model.Instance, len(records))
+	for _, record := range records {
+		item := &model.Instance{
+			Proto:             &apiservice.Instance{},
+			ServiceID:         record.ServiceID,
+			ServicePlatformID: record.ServicePlatformID,
+			Valid:             true,
+			ModifyTime:        record.ModifyTime,
+		}
+		if err := proto.Unmarshal(record.Proto, item.Proto); err != nil {
+			return err
+		}
+		instances[item.ID()] = item
+	}
+	ic.setInstances(instances)
+	return nil
+}
+
+// verifySnapshot 校验缓存的实例总数与存储是否一致，只加载系统服务时不做校验
+func (ic *instanceCache) verifySnapshot() error {
+	if ic.disableBusiness {
+		return nil
+	}
+	count, err := ic.storage.GetInstancesCount()
+	if err != nil {
+		return err
+	}
+	if ic.instanceCount != int64(count) {
+		return fmt.Errorf("instance count not match, expect %d, actual %d", count, ic.instanceCount)
+	}
+	return nil
+}
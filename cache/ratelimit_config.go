@@ -19,6 +19,7 @@ package cache
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
@@ -265,3 +266,54 @@ func (rlc *rateLimitCache) GetRateLimitsCount() int {
 	})
 	return count
 }
+
+// rateLimitSnapshot 限流规则缓存的快照数据，Proto 在恢复时根据 Rule 重新生成
+type rateLimitSnapshot struct {
+	RateLimits []*model.RateLimit         `json:"rateLimits"`
+	Revisions  []*model.RateLimitRevision `json:"revisions"`
+}
+
+// dumpSnapshot 导出缓存的全部限流规则以及各个服务的最新版本号
+func (rlc *rateLimitCache) dumpSnapshot() ([]byte, int, error) {
+	snapshot := &rateLimitSnapshot{}
+	rlc.ids.Range(func(_, value interface{}) bool {
+		value.(*sync.Map).Range(func(_, value interface{}) bool {
+			item := *value.(*model.RateLimit)
+			item.Proto = nil
+			snapshot.RateLimits = append(snapshot.RateLimits, &item)
+			return true
+		})
+		return true
+	})
+	rlc.revisions.Range(func(key, value interface{}) bool {
+		snapshot.Revisions = append(snapshot.Revisions, &model.RateLimitRevision{
+			ServiceID:    key.(string),
+			LastRevision: value.(string),
+		})
+		return true
+	})
+	data, err := json.Marshal(snapshot)
+	return data, len(snapshot.RateLimits), err
+}
+
+// loadSnapshot 使用快照中的限流规则恢复缓存，和从存储加载走相同的流程
+func (rlc *rateLimitCache) loadSnapshot(data []byte) error {
+	snapshot := &rateLimitSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return err
+	}
+	rlc.setRateLimit(snapshot.RateLimits, snapshot.Revisions)
+	return nil
+}
+
+// verifySnapshot 校验缓存的限流规则总数与存储是否一致
+func (rlc *rateLimitCache) verifySnapshot() error {
+	total, _, err := rlc.storage.GetExtendRateLimits(map[string]string{}, 0, 1)
+	if err != nil {
+		return err
+	}
+	if count := rlc.GetRateLimitsCount(); count != int(total) {
+		return fmt.Errorf("rate limit count not match, expect %d, actual %d", total, count)
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
@@ -204,6 +206,7 @@ func (sc *serviceCache) clear() error {
 	sc.namespaceServiceCnt = new(sync.Map)
 	sc.pendingServices = make(map[string]int8)
 	sc.serviceList = newServiceNamespaceBucket()
+	sc.serviceCount = 0
 	return nil
 }
 
@@ -570,3 +573,43 @@ func (fc *WatchInstanceReload) OnBatchUpdated(value interface{}) {
 func (fc *WatchInstanceReload) OnBatchDeleted(value interface{}) {
 
 }
+
+// dumpSnapshot 导出缓存的全部服务
+func (sc *serviceCache) dumpSnapshot() ([]byte, int, error) {
+	var services []*model.Service
+	sc.ids.Range(func(_, value interface{}) bool {
+		services = append(services, value.(*model.Service))
+		return true
+	})
+	data, err := json.Marshal(services)
+	return data, len(services), err
+}
+
+// loadSnapshot 使用快照中的服务恢复缓存，和从存储加载走相同的流程
+func (sc *serviceCache) loadSnapshot(data []byte) error {
+	var services []*model.Service
+	if err := json.Unmarshal(data, &services); err != nil {
+		return err
+	}
+	values := make(map[string]*model.Service, len(services))
+	for _, service := range services {
+		values[service.ID] = service
+	}
+	sc.setServices(values)
+	return nil
+}
+
+// verifySnapshot 校验缓存的服务总数与存储是否一致，只加载系统服务时不做校验
+func (sc *serviceCache) verifySnapshot() error {
+	if sc.disableBusiness {
+		return nil
+	}
+	count, err := sc.storage.GetServicesCount()
+	if err != nil {
+		return err
+	}
+	if sc.serviceCount != int64(count) {
+		return fmt.Errorf("service count not match, expect %d, actual %d", count, sc.serviceCount)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SnapshotVersion 缓存快照文件的格式版本
+	SnapshotVersion = 1
+	// DefaultSnapshotDir 默认的快照目录
+	DefaultSnapshotDir = "./cache_snapshot"
+	// DefaultSnapshotInterval 默认的快照间隔
+	DefaultSnapshotInterval = 5 * time.Minute
+	// DefaultSnapshotMaxAge 默认快照的最长有效时间，过期的快照不再使用
+	DefaultSnapshotMaxAge = time.Hour
+)
+
+// snapshotCache 支持持久化快照的缓存，只有全量加载代价较大的缓存需要实现
+type snapshotCache interface {
+	Cache
+	// dumpSnapshot 导出缓存的全部数据以及数据条数
+	dumpSnapshot() ([]byte, int, error)
+	// loadSnapshot 使用快照数据恢复缓存
+	loadSnapshot(data []byte) error
+	// verifySnapshot 从快照恢复并完成首次增量更新之后，校验缓存数据与存储是否一致
+	verifySnapshot() error
+	// snapshotMeta 获取快照需要记录的拉取时间以及最后修改时间
+	snapshotMeta() (int64, map[string]time.Time)
+	// restoreMeta 从快照恢复拉取时间以及最后修改时间
+	restoreMeta(lastFetchTime int64, lastMtimes map[string]time.Time)
+}
+
+// snapshotFile 单个缓存的快照文件内容
+type snapshotFile struct {
+	Version       int                  `json:"version"`
+	Store         string               `json:"store"`
+	Name          string               `json:"name"`
+	CreateTime    time.Time            `json:"createTime"`
+	LastFetchTime int64                `json:"lastFetchTime"`
+	LastMtimes    map[string]time.Time `json:"lastMtimes"`
+	Count         int                  `json:"count"`
+	Checksum      string               `json:"checksum"`
+	Data          json.RawMessage      `json:"data"`
+}
+
+func snapshotChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// snapshotManager 定时将缓存写入本地快照，启动时从快照恢复缓存，然后从快照的时间点增量拉取
+type snapshotManager struct {
+	mgr       *CacheManager
+	conf      SnapshotConfig
+	storeName string
+	// pending 待生效的新配置
+	pending  atomic.Value
+	reloadCh chan struct{}
+}
+
+// newSnapshotManager 未开启快照时返回 nil
+func newSnapshotManager(nc *CacheManager, conf SnapshotConfig) *snapshotManager {
+	if !conf.Open {
+		return nil
+	}
+	return &snapshotManager{
+		mgr:       nc,
+		conf:      conf.withDefault(),
+		storeName: nc.storage.Name(),
+		reloadCh:  make(chan struct{}, 1),
+	}
+}
+
+func (conf SnapshotConfig) withDefault() SnapshotConfig {
+	if conf.Dir == "" {
+		conf.Dir = DefaultSnapshotDir
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultSnapshotInterval
+	}
+	if conf.MaxAge <= 0 {
+		conf.MaxAge = DefaultSnapshotMaxAge
+	}
+	return conf
+}
+
+// reload 通知 run 协程使用新的配置，配置只在 run 协程内读写
+func (m *snapshotManager) reload(conf SnapshotConfig) {
+	m.pending.Store(conf.withDefault())
+	select {
+	case m.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// maxAge 当前生效的快照最长有效时间，可以在 run 协程之外读取
+func (m *snapshotManager) maxAge() time.Duration {
+	if conf, ok := m.pending.Load().(SnapshotConfig); ok {
+		return conf.MaxAge
+	}
+	// 没有重新加载过配置时 conf 不会被修改
+	return m.conf.MaxAge
+}
+
+// RetentionHorizon 软删除的数据至少需要保留的时长，增量更新缓存以及从快照恢复后的增量拉取都依赖软删除的记录，
+// 集群中其他节点的快照配置可能不同，因此不小于默认的快照最长有效时间
+func (nc *CacheManager) RetentionHorizon() time.Duration {
+	maxAge := DefaultSnapshotMaxAge
+	if nc.snapshots != nil && nc.snapshots.maxAge() > maxAge {
+		maxAge = nc.snapshots.maxAge()
+	}
+	return maxAge - DefaultTimeDiff
+}
+
+func (m *snapshotManager) snapshotCaches() map[int]snapshotCache {
+	ret := map[int]snapshotCache{}
+	for _, entry := range config.Resources {
+		index, ok := cacheSet[entry.Name]
+		if !ok {
+			continue
+		}
+		if c, ok := m.mgr.caches[index].(snapshotCache); ok {
+			ret[index] = c
+		}
+	}
+	return ret
+}
+
+func (m *snapshotManager) path(name string) string {
+	return filepath.Join(m.conf.Dir, name+".snapshot.gz")
+}
+
+// restore 按照缓存的顺序从快照恢复，返回成功恢复的缓存，恢复失败的缓存会被清理，随后全量加载
+func (m *snapshotManager) restore() map[int]snapshotCache {
+	restored := map[int]snapshotCache{}
+	caches := m.snapshotCaches()
+	for index := 0; index < CacheLast; index++ {
+		c, ok := caches[index]
+		if !ok {
+			continue
+		}
+		start := time.Now()
+		file, err := m.read(c.name())
+		if err == nil {
+			err = c.loadSnapshot(file.Data)
+		}
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Warnf("[Cache][Snapshot] restore %s from snapshot err: %s, fallback to load all", c.name(), err)
+			}
+			_ = c.clear()
+			continue
+		}
+		c.restoreMeta(file.LastFetchTime, file.LastMtimes)
+		restored[index] = c
+		log.Infof("[Cache][Snapshot] restore %d %s from snapshot created at %s, used %s",
+			file.Count, c.name(), file.CreateTime, time.Since(start))
+	}
+	return restored
+}
+
+// read 读取并校验快照文件
+func (m *snapshotManager) read(name string) (*snapshotFile, error) {
+	f, err := os.Open(m.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gr.Close() }()
+
+	file := &snapshotFile{}
+	if err := json.NewDecoder(gr).Decode(file); err != nil {
+		return nil, err
+	}
+	if file.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", file.Version)
+	}
+	if file.Store != m.storeName || file.Name != name {
+		return nil, fmt.Errorf("snapshot of %s/%s does not match %s/%s", file.Store, file.Name, m.storeName, name)
+	}
+	if age := time.Since(file.CreateTime); age > m.conf.MaxAge {
+		return nil, fmt.Errorf("snapshot expired, created %s ago", age)
+	}
+	if snapshotChecksum(file.Data) != file.Checksum {
+		return nil, errors.New("snapshot checksum mismatch")
+	}
+	return file, nil
+}
+
+// verify 首次增量更新之后校验从快照恢复的缓存，不一致时清理缓存并全量加载
+func (m *snapshotManager) verify(restored map[int]snapshotCache) {
+	for index, c := range restored {
+		if err := c.verifySnapshot(); err != nil {
+			log.Warnf("[Cache][Snapshot] verify %s restored from snapshot err: %s, fallback to load all",
+				c.name(), err)
+			_ = c.clear()
+			_ = m.mgr.updateCaches(map[int]struct{}{index: {}})
+		}
+	}
+}
+
+// run 定时写入快照
+func (m *snapshotManager) run(ctx context.Context) {
+	ticker := time.NewTicker(m.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.save()
+		case <-m.reloadCh:
+			m.conf = m.pending.Load().(SnapshotConfig)
+			ticker.Reset(m.conf.Interval)
+			log.Infof("[Cache][Snapshot] reload config, dir: %s, interval: %s", m.conf.Dir, m.conf.Interval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *snapshotManager) save() {
+	if err := os.MkdirAll(m.conf.Dir, 0700); err != nil {
+		log.Errorf("[Cache][Snapshot] create snapshot dir %s err: %s", m.conf.Dir, err)
+		return
+	}
+	for _, c := range m.snapshotCaches() {
+		start := time.Now()
+		count, err := m.write(c)
+		if err != nil {
+			log.Errorf("[Cache][Snapshot] save %s snapshot err: %s", c.name(), err)
+			continue
+		}
+		log.Infof("[Cache][Snapshot] save %d %s to snapshot, used %s", count, c.name(), time.Since(start))
+	}
+}
+
+// write 先记录拉取时间再导出数据，恢复之后从更早的时间点增量拉取，重复拉取的数据会被覆盖
+func (m *snapshotManager) write(c snapshotCache) (int, error) {
+	lastFetchTime, lastMtimes := c.snapshotMeta()
+	data, count, err := c.dumpSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	file := &snapshotFile{
+		Version:       SnapshotVersion,
+		Store:         m.storeName,
+		Name:          c.name(),
+		CreateTime:    time.Now(),
+		LastFetchTime: lastFetchTime,
+		LastMtimes:    lastMtimes,
+		Count:         count,
+		Checksum:      snapshotChecksum(data),
+		Data:          data,
+	}
+
+	// 快照中包含服务的 token 等敏感数据，只允许当前用户读写
+	path := m.path(c.name())
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	gw := gzip.NewWriter(f)
+	if err := json.NewEncoder(gw).Encode(file); err != nil {
+		_ = f.Close()
+		return 0, err
+	}
+	if err := gw.Close(); err != nil {
+		_ = f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return count, os.Rename(tmp, path)
+}
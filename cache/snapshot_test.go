@@ -0,0 +1,253 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func newTestSnapshotManager(t *testing.T) *snapshotManager {
+	return &snapshotManager{
+		conf:      SnapshotConfig{Dir: t.TempDir(), MaxAge: time.Hour},
+		storeName: "mockStore",
+	}
+}
+
+func TestInstanceCache_Snapshot(t *testing.T) {
+	ctl, storage, ic := newTestInstanceCache(t)
+	defer ctl.Finish()
+
+	instances := genModelInstances("snapshot", 100)
+	ic.setInstances(instances)
+	ic.restoreMeta(time.Now().Unix(), map[string]time.Time{ic.name(): time.Unix(1000, 0)})
+
+	m := newTestSnapshotManager(t)
+	count, err := m.write(ic)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count)
+
+	file, err := m.read(ic.name())
+	assert.NoError(t, err)
+	assert.Equal(t, 100, file.Count)
+
+	_, _, restored := newTestInstanceCache(t)
+	assert.NoError(t, restored.loadSnapshot(file.Data))
+	restored.restoreMeta(file.LastFetchTime, file.LastMtimes)
+	assert.False(t, restored.isFirstUpdate())
+	assert.Equal(t, time.Unix(1000, 0).Unix(), restored.LastMtime().Unix())
+	assert.Equal(t, 100, restored.GetInstancesCount())
+	for id, item := range instances {
+		got := restored.GetInstance(id)
+		if assert.NotNil(t, got, id) {
+			assert.Equal(t, item.Host(), got.Host())
+			assert.Equal(t, item.Port(), got.Port())
+			assert.Equal(t, item.ServiceID, got.ServiceID)
+			assert.Equal(t, item.Location().GetZone().GetValue(), got.Location().GetZone().GetValue())
+		}
+	}
+
+	restored.storage = storage
+	storage.EXPECT().GetInstancesCount().Return(uint32(100), nil)
+	assert.NoError(t, restored.verifySnapshot())
+	storage.EXPECT().GetInstancesCount().Return(uint32(101), nil)
+	assert.Error(t, restored.verifySnapshot())
+}
+
+func TestServiceCache_Snapshot(t *testing.T) {
+	ctl, storage, sc, _ := newTestServiceCache(t)
+	defer ctl.Finish()
+
+	services := map[string]*model.Service{}
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("service-%d", i)
+		services[id] = &model.Service{
+			ID:        id,
+			Name:      id,
+			Namespace: "default",
+			Meta:      map[string]string{"k": "v"},
+			Valid:     true,
+		}
+	}
+	sc.setServices(services)
+
+	m := newTestSnapshotManager(t)
+	count, err := m.write(sc)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+	file, err := m.read(sc.name())
+	assert.NoError(t, err)
+
+	_, _, restored, _ := newTestServiceCache(t)
+	assert.NoError(t, restored.loadSnapshot(file.Data))
+	assert.Equal(t, 10, restored.GetServicesCount())
+	got := restored.GetServiceByName("service-1", "default")
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "v", got.Meta["k"])
+	}
+
+	restored.storage = storage
+	storage.EXPECT().GetServicesCount().Return(uint32(10), nil)
+	assert.NoError(t, restored.verifySnapshot())
+}
+
+func TestRateLimitCache_Snapshot(t *testing.T) {
+	ctl, storage, rlc := newTestRateLimitCache(t)
+	defer ctl.Finish()
+
+	rateLimits, revisions := genRateLimits(0, 5, 20, false)
+	rlc.setRateLimit(rateLimits, revisions)
+
+	m := newTestSnapshotManager(t)
+	count, err := m.write(rlc)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, count)
+	file, err := m.read(rlc.name())
+	assert.NoError(t, err)
+
+	_, _, restored := newTestRateLimitCache(t)
+	assert.NoError(t, restored.loadSnapshot(file.Data))
+	assert.Equal(t, 20, restored.GetRateLimitsCount())
+	assert.Equal(t, "last-revision-1", restored.GetLastRevision("service-1"))
+	for _, item := range restored.GetRateLimitByServiceID("service-1") {
+		// Proto 没有写入快照，恢复时根据 Rule 重新生成
+		if assert.NotNil(t, item.Proto) {
+			assert.Equal(t, item.Method, item.Proto.GetMethod().GetValue().GetValue())
+		}
+	}
+
+	restored.storage = storage
+	storage.EXPECT().GetExtendRateLimits(map[string]string{}, uint32(0), uint32(1)).Return(uint32(20), nil, nil)
+	assert.NoError(t, restored.verifySnapshot())
+	storage.EXPECT().GetExtendRateLimits(map[string]string{}, uint32(0), uint32(1)).Return(uint32(21), nil, nil)
+	assert.Error(t, restored.verifySnapshot())
+}
+
+func TestCircuitBreakerCache_Snapshot(t *testing.T) {
+	ctl, storage, cbc := newTestCircuitBreakerCache(t)
+	defer ctl.Finish()
+
+	rules := genModelCircuitBreakers(0, 10)
+	rules = append(rules, &model.CircuitBreakerRule{
+		ID:           "id-all",
+		Name:         "rule-all",
+		DstService:   allMatched,
+		DstNamespace: allMatched,
+		Valid:        true,
+	})
+	cbc.setCircuitBreaker(rules)
+
+	m := newTestSnapshotManager(t)
+	count, err := m.write(cbc)
+	assert.NoError(t, err)
+	// 通配规则关联到所有服务，快照中只保存一份
+	assert.Equal(t, 11, count)
+	file, err := m.read(cbc.name())
+	assert.NoError(t, err)
+
+	_, _, restored := newTestCircuitBreakerCache(t)
+	assert.NoError(t, restored.loadSnapshot(file.Data))
+	assert.Equal(t, 11, restored.GetCircuitBreakerCount())
+	svcRules := restored.GetCircuitBreakerConfig("svc-1", "test")
+	assert.Equal(t, 2, svcRules.CountCircuitBreakerRules())
+	assert.Equal(t, cbc.GetCircuitBreakerConfig("svc-1", "test").Revision, svcRules.Revision)
+
+	restored.storage = storage
+	storage.EXPECT().GetCircuitBreakerRules(map[string]string{}, uint32(0), uint32(1)).Return(uint32(11), nil, nil)
+	assert.NoError(t, restored.verifySnapshot())
+	storage.EXPECT().GetCircuitBreakerRules(map[string]string{}, uint32(0), uint32(1)).Return(uint32(10), nil, nil)
+	assert.Error(t, restored.verifySnapshot())
+}
+
+func TestSnapshotManager_ReadInvalid(t *testing.T) {
+	ctl, _, ic := newTestInstanceCache(t)
+	defer ctl.Finish()
+	ic.setInstances(genModelInstances("invalid", 3))
+
+	m := newTestSnapshotManager(t)
+	_, err := m.read(ic.name())
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = m.write(ic)
+	assert.NoError(t, err)
+
+	// 存储不一致
+	other := *m
+	other.storeName = "otherStore"
+	_, err = other.read(ic.name())
+	assert.Error(t, err)
+
+	// 快照过期
+	expired := *m
+	expired.conf.MaxAge = time.Nanosecond
+	_, err = expired.read(ic.name())
+	assert.Error(t, err)
+
+	// 数据被篡改
+	writeSnapshot := func(file *snapshotFile) {
+		f, err := os.Create(m.path(ic.name()))
+		assert.NoError(t, err)
+		gw := gzip.NewWriter(f)
+		assert.NoError(t, json.NewEncoder(gw).Encode(file))
+		assert.NoError(t, gw.Close())
+		assert.NoError(t, f.Close())
+	}
+	file := &snapshotFile{
+		Version:    SnapshotVersion,
+		Store:      m.storeName,
+		Name:       ic.name(),
+		CreateTime: time.Now(),
+		Data:       json.RawMessage(`[]`),
+		Checksum:   snapshotChecksum([]byte(`[{}]`)),
+	}
+	writeSnapshot(file)
+	_, err = m.read(ic.name())
+	assert.EqualError(t, err, "snapshot checksum mismatch")
+
+	file.Checksum = snapshotChecksum(file.Data)
+	file.Version = SnapshotVersion + 1
+	writeSnapshot(file)
+	_, err = m.read(ic.name())
+	assert.Error(t, err)
+
+	file.Version = SnapshotVersion
+	writeSnapshot(file)
+	_, err = m.read(ic.name())
+	assert.NoError(t, err)
+}
+
+func TestCacheManager_RetentionHorizon(t *testing.T) {
+	nc := &CacheManager{}
+	assert.Equal(t, DefaultSnapshotMaxAge-DefaultTimeDiff, nc.RetentionHorizon())
+
+	// 快照的有效时间短于默认值时按照默认值计算
+	nc.snapshots = newTestSnapshotManager(t)
+	nc.snapshots.conf.MaxAge = time.Minute
+	assert.Equal(t, DefaultSnapshotMaxAge-DefaultTimeDiff, nc.RetentionHorizon())
+
+	nc.snapshots.reload(SnapshotConfig{MaxAge: 24 * time.Hour})
+	assert.Equal(t, 24*time.Hour-DefaultTimeDiff, nc.RetentionHorizon())
+}
@@ -0,0 +1,112 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	boot_config "github.com/polarismesh/polaris/bootstrap/config"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+	"github.com/polarismesh/polaris/store/migrate"
+)
+
+var (
+	backupConfigPath = ""
+	backupFile       = ""
+	backupOptions    = migrate.BackupOptions{}
+	restoreOptions   = migrate.RestoreOptions{}
+
+	backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "export all data of the store into a backup file",
+		Long: "export all data of the configured store into a backup file of json lines with checksums, " +
+			"the file is compressed by gzip when its name ends with .gz. the data is read from a consistent " +
+			"snapshot of the store. a running server holds the lock of the boltdb file, " +
+			"use GET /maintain/v1/store/backup of the server to back up a boltdb store in use",
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := openConfiguredStore(backupConfigPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = s.Destroy() }()
+
+			backupOptions.Output = os.Stdout
+			stats, err := migrate.Backup(s, backupFile, backupOptions)
+			printMigrateStats(stats)
+			return err
+		},
+	}
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore",
+		Short: "import data from a backup file into the store",
+		Long: "import data from a backup file into a newly initialized store, the restore fails without " +
+			"writing any record when records of the backup already exist in the store, except the built-in " +
+			"records created by the store initialization. use --skip-existing to keep the existing records " +
+			"and restore the others",
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := openConfiguredStore(backupConfigPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = s.Destroy() }()
+
+			restoreOptions.Output = os.Stdout
+			stats, err := migrate.Restore(s, backupFile, restoreOptions)
+			printMigrateStats(stats)
+			return err
+		},
+	}
+)
+
+// init 解析命令参数
+func init() {
+	for _, c := range []*cobra.Command{backupCmd, restoreCmd} {
+		c.Flags().StringVarP(&backupConfigPath, "config", "c", "conf/polaris-server.yaml", "config file path")
+		c.Flags().StringVarP(&backupFile, "file", "f", "", "backup file path")
+		_ = c.MarkFlagRequired("file")
+	}
+	backupCmd.Flags().StringSliceVar(&backupOptions.Resources, "resources", nil,
+		"resources to export, default all resources")
+
+	restoreCmd.Flags().StringVar(&restoreOptions.Namespace, "namespace", "",
+		"only restore data belongs to the namespace, global data such as users are skipped")
+	restoreCmd.Flags().StringSliceVar(&restoreOptions.Resources, "resources", nil,
+		"resources to restore, default all resources in the backup file")
+	restoreCmd.Flags().IntVar(&restoreOptions.BatchSize, "batch-size", migrate.DefaultBatchSize,
+		"records written per batch")
+	restoreCmd.Flags().BoolVar(&restoreOptions.SkipExisting, "skip-existing", false,
+		"skip the records already existing in the store instead of failing, existing records are not overwritten")
+}
+
+// openConfiguredStore 根据配置文件初始化存储插件
+func openConfiguredStore(configPath string) (store.Store, error) {
+	if configPath == "" {
+		return nil, errors.New("config file path is required")
+	}
+	conf, err := boot_config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	plugin.SetPluginConfig(&conf.Plugin)
+	return openStore(&conf.Store)
+}
@@ -0,0 +1,247 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	applyFiles  []string
+	applyDryRun = false
+
+	applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "create or update resources declared in yaml manifests",
+		Long: "create or update resources declared in yaml manifests, each document of a manifest has a kind " +
+			"and a spec in the json format of the console api, the spec is compared with the current state " +
+			"and only the changed fields are shown and applied, use - as the file name to read stdin",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(applyFiles) == 0 {
+				return errors.New("--filename is required")
+			}
+			var manifests []*manifest
+			for _, name := range applyFiles {
+				items, err := loadManifests(name)
+				if err != nil {
+					return err
+				}
+				manifests = append(manifests, items...)
+			}
+			return applyManifests(newClient(), os.Stdout, manifests, applyDryRun)
+		},
+	}
+)
+
+func init() {
+	applyCmd.Flags().StringSliceVarP(&applyFiles, "filename", "f", nil, "manifest files to apply")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "only show the changes without applying them")
+}
+
+// manifest 声明式清单中的一个资源
+type manifest struct {
+	Kind string                 `yaml:"kind"`
+	Spec map[string]interface{} `yaml:"spec"`
+}
+
+// fieldChange 资源中一个字段的变更
+type fieldChange struct {
+	key  string
+	from interface{}
+	to   interface{}
+}
+
+// loadManifests 读取文件中以 --- 分隔的所有清单
+func loadManifests(name string) ([]*manifest, error) {
+	var reader io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		reader = f
+	}
+	return parseManifests(reader)
+}
+
+func parseManifests(reader io.Reader) ([]*manifest, error) {
+	var ret []*manifest
+	decoder := yaml.NewDecoder(reader)
+	for {
+		doc := map[string]interface{}{}
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				return ret, nil
+			}
+			return nil, err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		// yaml 解析出的嵌套对象的 key 不是 string，通过 json 转换为和接口响应一致的格式
+		normalized, err := normalize(doc)
+		if err != nil {
+			return nil, err
+		}
+		item := &manifest{}
+		item.Kind, _ = normalized["kind"].(string)
+		item.Spec, _ = normalized["spec"].(map[string]interface{})
+		if item.Kind == "" || len(item.Spec) == 0 {
+			return nil, fmt.Errorf("kind and spec are required in manifest %d", len(ret)+1)
+		}
+		ret = append(ret, item)
+	}
+}
+
+func normalize(doc map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(convertYAML(doc))
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func convertYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ret[fmt.Sprintf("%v", k)] = convertYAML(item)
+		}
+		return ret
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ret[k] = convertYAML(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			ret = append(ret, convertYAML(item))
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+// applyManifests 依次比较清单和资源的当前状态，创建不存在的资源，更新有变化的资源
+func applyManifests(c *Client, w io.Writer, manifests []*manifest, dryRun bool) error {
+	for _, item := range manifests {
+		r, err := resourceOfKind(item.Kind)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(r.identity))
+		for _, key := range r.identity {
+			if _, ok := item.Spec[key]; !ok {
+				return fmt.Errorf("%s is required in the spec of %s", key, r.kind)
+			}
+			names = append(names, formatValue(item.Spec[key]))
+		}
+		title := r.kind + " " + strings.Join(names, "/")
+
+		existing, err := r.find(c, item.Spec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", title, err)
+		}
+		if existing == nil {
+			_, _ = fmt.Fprintf(w, "%s created\n", title)
+			if !dryRun {
+				if err := r.create(c, item.Spec); err != nil {
+					return fmt.Errorf("%s: %w", title, err)
+				}
+			}
+			continue
+		}
+
+		changes := diffFields(existing, item.Spec)
+		if len(changes) == 0 {
+			_, _ = fmt.Fprintf(w, "%s unchanged\n", title)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s updated\n", title)
+		for _, change := range changes {
+			_, _ = fmt.Fprintf(w, "  %s: %s -> %s\n", change.key, diffValue(change.from), diffValue(change.to))
+		}
+		if !dryRun {
+			if err := r.update(c, existing, item.Spec); err != nil {
+				return fmt.Errorf("%s: %w", title, err)
+			}
+		}
+	}
+	return nil
+}
+
+// diffFields 比较清单中声明的字段和资源当前的值，清单中未声明的字段不参与比较
+func diffFields(existing, desired map[string]interface{}) []fieldChange {
+	var changes []fieldChange
+	for key, value := range desired {
+		if !valueEqual(existing[key], value) {
+			changes = append(changes, fieldChange{key: key, from: existing[key], to: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].key < changes[j].key
+	})
+	return changes
+}
+
+// valueEqual 比较两个字段值，64 位整数在接口响应中为字符串，
+// 因此标量按照字符串形式比较
+func valueEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	switch a.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	switch b.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return a != nil && b != nil && formatValue(a) == formatValue(b)
+}
+
+func diffValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return formatValue(value)
+	}
+	return string(data)
+}
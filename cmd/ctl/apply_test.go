@@ -0,0 +1,106 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifests = `
+kind: Service
+spec:
+  namespace: default
+  name: svc-a
+  comment: changed
+  metadata:
+    env: prod
+---
+kind: Service
+spec:
+  namespace: default
+  name: svc-b
+  comment: same
+---
+kind: Service
+spec:
+  namespace: default
+  name: svc-c
+`
+
+func TestParseManifests(t *testing.T) {
+	manifests, err := parseManifests(strings.NewReader(testManifests))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(manifests))
+	assert.Equal(t, "Service", manifests[0].Kind)
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, manifests[0].Spec["metadata"])
+
+	_, err = parseManifests(strings.NewReader("kind: Service\n"))
+	assert.Error(t, err)
+}
+
+func TestDiffFields(t *testing.T) {
+	existing := map[string]interface{}{
+		"id": "1", "name": "svc", "port": float64(8080), "revision": "10", "metadata": map[string]interface{}{},
+	}
+	desired := map[string]interface{}{
+		"name": "svc", "port": float64(8080), "revision": float64(10), "metadata": map[string]interface{}{"a": "b"},
+		"comment": "c",
+	}
+	changes := diffFields(existing, desired)
+	assert.Equal(t, []fieldChange{
+		{key: "comment", from: nil, to: "c"},
+		{key: "metadata", from: map[string]interface{}{}, to: map[string]interface{}{"a": "b"}},
+	}, changes)
+}
+
+func TestApplyManifests(t *testing.T) {
+	console := &fakeConsole{services: []map[string]interface{}{
+		{"id": "a", "namespace": "default", "name": "svc-a", "comment": "origin"},
+		{"id": "b", "namespace": "default", "name": "svc-b", "comment": "same"},
+	}}
+	c := newTestClient(t, console)
+	manifests, err := parseManifests(strings.NewReader(testManifests))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, applyManifests(c, buf, manifests, true))
+	assert.Equal(t, "Service default/svc-a updated\n"+
+		"  comment: \"origin\" -> \"changed\"\n"+
+		"  metadata: <none> -> {\"env\":\"prod\"}\n"+
+		"Service default/svc-b unchanged\n"+
+		"Service default/svc-c created\n", buf.String())
+	assert.Empty(t, console.requests)
+
+	buf.Reset()
+	assert.NoError(t, applyManifests(c, buf, manifests, false))
+	assert.Equal(t, []string{
+		http.MethodPut + " /naming/v1/services",
+		http.MethodPost + " /naming/v1/services",
+	}, console.requests)
+	updated := console.bodies[0].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "a", updated["id"])
+	assert.Equal(t, "changed", updated["comment"])
+
+	manifests[0].Kind = "Unknown"
+	assert.EqualError(t, applyManifests(c, buf, manifests, true), "unknown kind Unknown")
+}
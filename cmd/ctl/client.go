@@ -0,0 +1,151 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// codeNotFoundResource 资源不存在的返回码
+	codeNotFoundResource = 400202
+)
+
+// Client polaris 控制台 HTTP 接口的客户端
+type Client struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+// NewClient 创建控制台接口的客户端
+func NewClient(server, token string, timeout time.Duration) *Client {
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+	return &Client{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Response 控制台接口的响应，字段和 proto 的 json 格式保持一致
+type Response map[string]interface{}
+
+// Code 返回响应码
+func (r Response) Code() uint32 {
+	v, _ := r["code"].(float64)
+	return uint32(v)
+}
+
+// Err 将失败的响应转换为错误，批量接口会附带每一条记录的失败原因
+func (r Response) Err() error {
+	if success(r.Code()) {
+		return nil
+	}
+	msg := fmt.Sprintf("code: %d, info: %v", r.Code(), r["info"])
+	items, _ := r["responses"].([]interface{})
+	for _, item := range items {
+		sub, ok := item.(map[string]interface{})
+		if !ok || success(Response(sub).Code()) {
+			continue
+		}
+		msg += fmt.Sprintf("; code: %d, info: %v", Response(sub).Code(), sub["info"])
+	}
+	return errors.New(msg)
+}
+
+// Items 返回响应中 key 对应的对象列表
+func (r Response) Items(key string) []map[string]interface{} {
+	values, _ := r[key].([]interface{})
+	items := make([]map[string]interface{}, 0, len(values))
+	for _, v := range values {
+		if item, ok := v.(map[string]interface{}); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Object 返回响应中 key 对应的对象
+func (r Response) Object(key string) map[string]interface{} {
+	item, _ := r[key].(map[string]interface{})
+	return item
+}
+
+// success 返回码为 200xxx 时表示执行成功，没有返回码的接口通过 http 状态码判断
+func success(code uint32) bool {
+	return code == 0 || code/1000 == 200
+}
+
+// Do 调用控制台接口，body 不为空时以 json 格式发送
+func (c *Client) Do(method, path string, query url.Values, body interface{}) (Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := c.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set(utils.HeaderAuthTokenKey, c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := Response{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := ret.Err(); err != nil {
+		return ret, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return ret, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return ret, nil
+}
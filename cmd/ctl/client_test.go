@@ -0,0 +1,114 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsole 模拟控制台接口，记录收到的写请求
+type fakeConsole struct {
+	lock     sync.Mutex
+	services []map[string]interface{}
+	requests []string
+	bodies   []interface{}
+}
+
+func (f *fakeConsole) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.Header.Get("X-Polaris-Token") != "test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code": 401000, "info": "token invalid"}`))
+		return
+	}
+	if r.Method == http.MethodGet {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":     200000,
+			"amount":   len(f.services),
+			"services": f.services,
+		})
+		return
+	}
+	var body interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.bodies = append(f.bodies, body)
+	_, _ = w.Write([]byte(`{"code": 200000, "info": "execute success"}`))
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "test-token", time.Second)
+}
+
+func TestClientDo(t *testing.T) {
+	console := &fakeConsole{services: []map[string]interface{}{{"namespace": "default", "name": "svc"}}}
+	c := newTestClient(t, console)
+
+	items, err := serviceResource.list(c, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"namespace": "default", "name": "svc"}}, items)
+
+	c.token = ""
+	_, err = serviceResource.list(c, nil)
+	assert.EqualError(t, err, "code: 401000, info: token invalid")
+}
+
+func TestResponseErr(t *testing.T) {
+	resp := Response{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"code": 400000, "info": "invalid request", "responses": [
+		{"code": 200000, "info": "execute success"},
+		{"code": 400301, "info": "existed resource"}]}`), &resp))
+	assert.EqualError(t, resp.Err(), "code: 400000, info: invalid request; code: 400301, info: existed resource")
+	assert.NoError(t, Response{"code": float64(200001)}.Err())
+}
+
+func TestPrintItems(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": "1", "host": "127.0.0.1", "port": float64(8080), "weight": float64(100), "isolate": false},
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, printItems(buf, outputTable, items, instanceResource.columns))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, []string{"ID", "HOST", "PORT", "WEIGHT", "HEALTHY", "ISOLATE", "MTIME"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"1", "127.0.0.1", "8080", "100", "false"}, strings.Fields(lines[1]))
+
+	buf.Reset()
+	assert.NoError(t, printItems(buf, outputYAML, items, instanceResource.columns))
+	assert.Contains(t, buf.String(), "port: 8080")
+
+	assert.Error(t, printItems(buf, "xml", items, instanceResource.columns))
+}
+
+func TestLineDiff(t *testing.T) {
+	assert.Nil(t, lineDiff("a\nb\n", "a\nb\n"))
+	assert.Equal(t, []string{" a", "-b", "+c", " d"}, lineDiff("a\nb\nd\n", "a\nc\nd\n"))
+	assert.Equal(t, []string{"+a"}, lineDiff("", "a"))
+}
@@ -0,0 +1,311 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newEditConfigCommand 使用编辑器修改配置文件的内容
+func newEditConfigCommand() *cobra.Command {
+	args := identityArgs{}
+	publish := false
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "edit the content of a config file with $EDITOR",
+		RunE: func(c *cobra.Command, _ []string) error {
+			client := newClient()
+			file, err := findRequired(client, configFileResource, args)
+			if err != nil {
+				return err
+			}
+			content := formatValue(file["content"])
+			edited, err := editContent(content, formatValue(file["format"]))
+			if err != nil {
+				return err
+			}
+			if edited == content {
+				fmt.Println("config file not changed")
+				return nil
+			}
+			file["content"] = edited
+			if err := configFileResource.update(client, file, file); err != nil {
+				return err
+			}
+			fmt.Println("config file updated")
+			if publish {
+				return publishConfigFile(client, file, "")
+			}
+			return nil
+		},
+	}
+	args.bind(configFileResource, cmd.Flags())
+	cmd.Flags().BoolVar(&publish, "publish", false, "publish the config file after editing")
+	return cmd
+}
+
+// editContent 将内容写入临时文件并打开编辑器，返回编辑后的内容
+func editContent(content, format string) (string, error) {
+	if format == "" {
+		format = "txt"
+	}
+	f, err := os.CreateTemp("", "polaris-config-*."+format)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// newPublishConfigCommand 发布配置文件
+func newPublishConfigCommand() *cobra.Command {
+	args := identityArgs{}
+	comment := ""
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "publish the current content of a config file",
+		RunE: func(c *cobra.Command, _ []string) error {
+			file, err := args.require(configFileResource)
+			if err != nil {
+				return err
+			}
+			return publishConfigFile(newClient(), file, comment)
+		},
+	}
+	args.bind(configFileResource, cmd.Flags())
+	cmd.Flags().StringVar(&comment, "comment", "", "comment of the release")
+	return cmd
+}
+
+func publishConfigFile(c *Client, file map[string]interface{}, comment string) error {
+	_, err := c.Do(http.MethodPost, "/config/v1/configfiles/release", nil, map[string]interface{}{
+		"namespace": file["namespace"],
+		"group":     file["group"],
+		"fileName":  file["name"],
+		"comment":   comment,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("config file published")
+	return nil
+}
+
+// newConfigHistoryCommand 查询配置文件的发布历史
+func newConfigHistoryCommand() *cobra.Command {
+	args := identityArgs{}
+	var offset, limit uint32
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "list the release histories of a config file",
+		RunE: func(c *cobra.Command, _ []string) error {
+			file, err := args.require(configFileResource)
+			if err != nil {
+				return err
+			}
+			items, err := getConfigHistories(newClient(), file, offset, limit, 0)
+			if err != nil {
+				return err
+			}
+			return printItems(os.Stdout, outputFormat, items, []column{
+				{"ID", "id"}, {"TYPE", "type"}, {"STATUS", "status"}, {"MD5", "md5"},
+				{"CREATE_TIME", "createTime"}, {"CREATE_BY", "createBy"},
+			})
+		},
+	}
+	args.bind(configFileResource, cmd.Flags())
+	cmd.Flags().Uint32Var(&offset, "offset", 0, "offset of the query")
+	cmd.Flags().Uint32Var(&limit, "limit", 20, "max count of the query")
+	return cmd
+}
+
+// getConfigHistories 按照 id 倒序查询发布历史，endID 大于 0 时只查询 id 小于 endID 的历史
+func getConfigHistories(c *Client, file map[string]interface{}, offset, limit uint32,
+	endID uint64) ([]map[string]interface{}, error) {
+	query := url.Values{}
+	for _, key := range configFileResource.identity {
+		query.Set(key, formatValue(file[key]))
+	}
+	query.Set("offset", strconv.FormatUint(uint64(offset), 10))
+	query.Set("limit", strconv.FormatUint(uint64(limit), 10))
+	if endID > 0 {
+		query.Set("endId", strconv.FormatUint(endID, 10))
+	}
+	resp, err := c.Do(http.MethodGet, "/config/v1/configfiles/releasehistory", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items("configFileReleaseHistories"), nil
+}
+
+// getConfigHistory 查询指定 id 的发布历史
+func getConfigHistory(c *Client, file map[string]interface{}, id uint64) (map[string]interface{}, error) {
+	items, err := getConfigHistories(c, file, 0, 1, id+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 || formatValue(items[0]["id"]) != strconv.FormatUint(id, 10) {
+		return nil, fmt.Errorf("release history %d not found", id)
+	}
+	return items[0], nil
+}
+
+// newRollbackConfigCommand 将配置文件回滚到历史发布的内容并重新发布
+func newRollbackConfigCommand() *cobra.Command {
+	args := identityArgs{}
+	var id uint64
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "rollback a config file to the content of a release history and publish it",
+		RunE: func(c *cobra.Command, _ []string) error {
+			if id == 0 {
+				return errors.New("--id is required")
+			}
+			client := newClient()
+			file, err := findRequired(client, configFileResource, args)
+			if err != nil {
+				return err
+			}
+			history, err := getConfigHistory(client, file, id)
+			if err != nil {
+				return err
+			}
+			file["content"] = history["content"]
+			if format, ok := history["format"]; ok && formatValue(format) != "" {
+				file["format"] = format
+			}
+			if err := configFileResource.update(client, file, file); err != nil {
+				return err
+			}
+			return publishConfigFile(client, file, fmt.Sprintf("rollback to release history %d", id))
+		},
+	}
+	args.bind(configFileResource, cmd.Flags())
+	cmd.Flags().Uint64Var(&id, "id", 0, "id of the release history to rollback to")
+	return cmd
+}
+
+// newDiffConfigCommand 比较配置文件的内容
+func newDiffConfigCommand() *cobra.Command {
+	args := identityArgs{}
+	var historyID uint64
+	localFile := ""
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "show the difference between the released and the current content of a config file",
+		Long: "show the difference between the released and the current content of a config file, " +
+			"--history compares a release history with the current content instead, " +
+			"--file compares the current content with a local file",
+		RunE: func(c *cobra.Command, _ []string) error {
+			client := newClient()
+			file, err := findRequired(client, configFileResource, args)
+			if err != nil {
+				return err
+			}
+			current := formatValue(file["content"])
+			var from, to, fromName, toName string
+			switch {
+			case localFile != "":
+				data, err := os.ReadFile(localFile)
+				if err != nil {
+					return err
+				}
+				from, to, fromName, toName = current, string(data), "current", localFile
+			case historyID > 0:
+				history, err := getConfigHistory(client, file, historyID)
+				if err != nil {
+					return err
+				}
+				from, to = formatValue(history["content"]), current
+				fromName, toName = fmt.Sprintf("history %d", historyID), "current"
+			default:
+				release, err := getConfigRelease(client, file)
+				if err != nil {
+					return err
+				}
+				from, to = formatValue(release["content"]), current
+				fromName, toName = "released", "current"
+			}
+			printDiff(fromName, toName, from, to)
+			return nil
+		},
+	}
+	args.bind(configFileResource, cmd.Flags())
+	cmd.Flags().Uint64Var(&historyID, "history", 0, "id of the release history to compare")
+	cmd.Flags().StringVarP(&localFile, "file", "f", "", "local file to compare")
+	return cmd
+}
+
+// getConfigRelease 查询配置文件当前发布的版本，未发布时返回空对象
+func getConfigRelease(c *Client, file map[string]interface{}) (map[string]interface{}, error) {
+	query := url.Values{}
+	for _, key := range configFileResource.identity {
+		query.Set(key, formatValue(file[key]))
+	}
+	resp, err := c.Do(http.MethodGet, "/config/v1/configfiles/release", query, nil)
+	if err != nil {
+		if resp != nil && resp.Code() == codeNotFoundResource {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+	return resp.Object("configFileRelease"), nil
+}
+
+func printDiff(fromName, toName, from, to string) {
+	lines := lineDiff(from, to)
+	if len(lines) == 0 {
+		fmt.Println("no difference")
+		return
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", fromName, toName)
+	buf.WriteString(strings.Join(lines, "\n"))
+	fmt.Println(buf.String())
+}
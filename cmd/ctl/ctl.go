@@ -0,0 +1,168 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serverAddr     = ""
+	authToken      = ""
+	outputFormat   = ""
+	requestTimeout = 10 * time.Second
+
+	// Command 命令行客户端，通过控制台接口管理 polaris 的资源
+	Command = &cobra.Command{
+		Use:   "ctl",
+		Short: "command line client of the polaris console api",
+		Long: "manage namespaces, services, instances, rules, config files, users and strategies of " +
+			"a polaris server through its console api, the server address and token can also be set " +
+			"by the environment variables POLARIS_SERVER and POLARIS_TOKEN",
+		SilenceUsage: true,
+	}
+)
+
+// init 注册全局参数和各类资源的子命令
+func init() {
+	server := os.Getenv("POLARIS_SERVER")
+	if server == "" {
+		server = "127.0.0.1:8090"
+	}
+	flags := Command.PersistentFlags()
+	flags.StringVarP(&serverAddr, "server", "s", server, "address of the polaris console api")
+	flags.StringVarP(&authToken, "token", "t", os.Getenv("POLARIS_TOKEN"), "auth token of the user")
+	flags.StringVarP(&outputFormat, "output", "o", outputTable, "output format, one of table, json, yaml")
+	flags.DurationVar(&requestTimeout, "timeout", requestTimeout, "timeout of each request")
+
+	for _, r := range resources {
+		cmd := newResourceCommand(r)
+		switch r {
+		case instanceResource:
+			cmd.AddCommand(newIsolateCommand(), newWeightCommand())
+		case configFileResource:
+			cmd.AddCommand(newEditConfigCommand(), newPublishConfigCommand(), newConfigHistoryCommand(),
+				newRollbackConfigCommand(), newDiffConfigCommand())
+		}
+		Command.AddCommand(cmd)
+	}
+	Command.AddCommand(loginCmd, applyCmd)
+}
+
+// newClient 根据全局参数创建客户端
+func newClient() *Client {
+	return NewClient(serverAddr, authToken, requestTimeout)
+}
+
+// newResourceCommand 创建资源的 list、get、delete 子命令
+func newResourceCommand(r *resource) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     r.use,
+		Aliases: r.aliases,
+		Short:   "manage " + r.kind + " resources",
+	}
+
+	listArgs := identityArgs{}
+	var offset, limit uint32
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list " + r.kind + " resources, the identity flags are used as query filters",
+		RunE: func(c *cobra.Command, args []string) error {
+			query := url.Values{}
+			for k, v := range listArgs.values() {
+				query.Set(k, formatValue(v))
+			}
+			query.Set("offset", strconv.FormatUint(uint64(offset), 10))
+			query.Set("limit", strconv.FormatUint(uint64(limit), 10))
+			items, err := r.list(newClient(), query)
+			if err != nil {
+				return err
+			}
+			return printItems(os.Stdout, outputFormat, items, r.columns)
+		},
+	}
+	listArgs.bind(r, listCmd.Flags())
+	listCmd.Flags().Uint32Var(&offset, "offset", 0, "offset of the query")
+	listCmd.Flags().Uint32Var(&limit, "limit", 100, "max count of the query")
+
+	getArgs := identityArgs{}
+	getCmd := &cobra.Command{
+		Use:   "get",
+		Short: "show the detail of a " + r.kind,
+		RunE: func(c *cobra.Command, args []string) error {
+			item, err := findRequired(newClient(), r, getArgs)
+			if err != nil {
+				return err
+			}
+			return printObject(os.Stdout, outputFormat, item)
+		},
+	}
+	getArgs.bind(r, getCmd.Flags())
+
+	deleteArgs := identityArgs{}
+	id := ""
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a " + r.kind,
+		RunE: func(c *cobra.Command, args []string) error {
+			client := newClient()
+			item := map[string]interface{}{"id": id}
+			if id == "" {
+				var err error
+				if item, err = findRequired(client, r, deleteArgs); err != nil {
+					return err
+				}
+			}
+			if err := r.remove(client, item); err != nil {
+				return err
+			}
+			fmt.Printf("%s deleted\n", r.kind)
+			return nil
+		},
+	}
+	deleteArgs.bind(r, deleteCmd.Flags())
+	if r.deleteKeys[0] == "id" {
+		deleteCmd.Flags().StringVar(&id, "id", "", "id of the "+r.use+", used instead of the identity flags")
+	}
+
+	cmd.AddCommand(listCmd, getCmd, deleteCmd)
+	return cmd
+}
+
+// findRequired 根据命令行中的唯一标识查询资源，资源不存在时返回错误
+func findRequired(c *Client, r *resource, args identityArgs) (map[string]interface{}, error) {
+	identity, err := args.require(r)
+	if err != nil {
+		return nil, err
+	}
+	item, err := r.find(c, identity)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, errors.New(r.kind + " not found")
+	}
+	return item, nil
+}
@@ -0,0 +1,74 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// newIsolateCommand 隔离或者取消隔离服务实例
+func newIsolateCommand() *cobra.Command {
+	args := identityArgs{}
+	isolate := true
+	cmd := &cobra.Command{
+		Use:   "isolate",
+		Short: "isolate an instance, use --isolate=false to cancel the isolation",
+		RunE: func(c *cobra.Command, _ []string) error {
+			return updateInstance(args, "isolate", isolate)
+		},
+	}
+	args.bind(instanceResource, cmd.Flags())
+	cmd.Flags().BoolVar(&isolate, "isolate", true, "whether the instance is isolated")
+	return cmd
+}
+
+// newWeightCommand 修改服务实例的权重
+func newWeightCommand() *cobra.Command {
+	args := identityArgs{}
+	var weight uint32
+	cmd := &cobra.Command{
+		Use:   "weight",
+		Short: "change the weight of an instance",
+		RunE: func(c *cobra.Command, _ []string) error {
+			if !c.Flags().Changed("weight") {
+				return fmt.Errorf("--weight is required")
+			}
+			return updateInstance(args, "weight", weight)
+		},
+	}
+	args.bind(instanceResource, cmd.Flags())
+	cmd.Flags().Uint32Var(&weight, "weight", 100, "weight of the instance, 0 means no traffic")
+	return cmd
+}
+
+// updateInstance 只修改服务实例的单个属性，其余属性保持不变
+func updateInstance(args identityArgs, key string, value interface{}) error {
+	req, err := args.require(instanceResource)
+	if err != nil {
+		return err
+	}
+	req[key] = value
+	if _, err := newClient().Do(http.MethodPut, instanceResource.updatePath, nil, []interface{}{req}); err != nil {
+		return err
+	}
+	fmt.Printf("%s of the instance %v:%v updated\n", key, req["host"], req["port"])
+	return nil
+}
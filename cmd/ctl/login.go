@@ -0,0 +1,68 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginUser     = ""
+	loginPassword = ""
+
+	loginCmd = &cobra.Command{
+		Use:   "login",
+		Short: "login with the user name and password, and print the token of the user",
+		Long: "login with the user name and password, and print the token of the user, " +
+			"the password is read from stdin when it is not set by the flag",
+		RunE: func(c *cobra.Command, args []string) error {
+			if loginUser == "" {
+				return errors.New("--user is required")
+			}
+			if loginPassword == "" {
+				fmt.Fprint(os.Stderr, "password: ")
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return err
+				}
+				loginPassword = strings.TrimSpace(line)
+			}
+			resp, err := newClient().Do(http.MethodPost, "/core/v1/user/login", nil, map[string]interface{}{
+				"name":     loginUser,
+				"password": loginPassword,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatValue(lookup(resp, "loginResponse.token")))
+			return nil
+		},
+	}
+)
+
+func init() {
+	loginCmd.Flags().StringVarP(&loginUser, "user", "u", "", "name of the user")
+	loginCmd.Flags().StringVarP(&loginPassword, "password", "p", "", "password of the user")
+}
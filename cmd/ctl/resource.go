@@ -0,0 +1,372 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// resource 通过控制台接口管理的一类资源
+type resource struct {
+	// kind 声明式清单中的资源类型
+	kind string
+	// use 子命令名称
+	use     string
+	aliases []string
+	// identity 唯一标识资源的字段，同时作为查询参数和命令行参数
+	identity []string
+	// listPath 列表查询接口，响应中的列表为 listKey
+	listPath string
+	listKey  string
+	// getPath 非空时通过单个查询接口精确查询，响应中的对象为 getKey
+	getPath string
+	getKey  string
+	// createPath 创建接口，createSingle 表示请求体为单个对象而不是数组
+	createPath   string
+	createSingle bool
+	// updatePath 更新接口，为空表示不支持通过命令行更新
+	updatePath   string
+	updateSingle bool
+	// deletePath 删除接口，deleteMethod 为 DELETE 时 deleteKeys 通过查询参数传递
+	deletePath   string
+	deleteMethod string
+	deleteKeys   []string
+	columns      []column
+}
+
+var (
+	namespaceResource = &resource{
+		kind:       "Namespace",
+		use:        "namespace",
+		aliases:    []string{"namespaces", "ns"},
+		identity:   []string{"name"},
+		listPath:   "/naming/v1/namespaces",
+		listKey:    "namespaces",
+		createPath: "/naming/v1/namespaces",
+		updatePath: "/naming/v1/namespaces",
+		deletePath: "/naming/v1/namespaces/delete",
+		deleteKeys: []string{"name"},
+		columns: []column{
+			{"NAME", "name"}, {"COMMENT", "comment"}, {"OWNERS", "owners"},
+			{"SERVICES", "totalServiceCount"}, {"MTIME", "mtime"},
+		},
+	}
+
+	serviceResource = &resource{
+		kind:       "Service",
+		use:        "service",
+		aliases:    []string{"services", "svc"},
+		identity:   []string{"namespace", "name"},
+		listPath:   "/naming/v1/services",
+		listKey:    "services",
+		createPath: "/naming/v1/services",
+		updatePath: "/naming/v1/services",
+		deletePath: "/naming/v1/services/delete",
+		deleteKeys: []string{"namespace", "name"},
+		columns: []column{
+			{"NAMESPACE", "namespace"}, {"NAME", "name"}, {"INSTANCES", "totalInstanceCount"},
+			{"HEALTHY", "healthyInstanceCount"}, {"BUSINESS", "business"}, {"MTIME", "mtime"},
+		},
+	}
+
+	instanceResource = &resource{
+		kind:       "Instance",
+		use:        "instance",
+		aliases:    []string{"instances", "ins"},
+		identity:   []string{"namespace", "service", "host", "port"},
+		listPath:   "/naming/v1/instances",
+		listKey:    "instances",
+		createPath: "/naming/v1/instances",
+		updatePath: "/naming/v1/instances",
+		deletePath: "/naming/v1/instances/delete",
+		deleteKeys: []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"HOST", "host"}, {"PORT", "port"}, {"WEIGHT", "weight"},
+			{"HEALTHY", "healthy"}, {"ISOLATE", "isolate"}, {"MTIME", "mtime"},
+		},
+	}
+
+	routingResource = &resource{
+		kind:       "RoutingRule",
+		use:        "routing",
+		aliases:    []string{"routings"},
+		identity:   []string{"namespace", "name"},
+		listPath:   "/naming/v2/routings",
+		listKey:    "data",
+		createPath: "/naming/v2/routings",
+		updatePath: "/naming/v2/routings",
+		deletePath: "/naming/v2/routings/delete",
+		deleteKeys: []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"NAMESPACE", "namespace"}, {"NAME", "name"}, {"ENABLE", "enable"},
+			{"PRIORITY", "priority"}, {"MTIME", "mtime"},
+		},
+	}
+
+	rateLimitResource = &resource{
+		kind:       "RateLimit",
+		use:        "ratelimit",
+		aliases:    []string{"ratelimits", "rl"},
+		identity:   []string{"namespace", "service", "name"},
+		listPath:   "/naming/v1/ratelimits",
+		listKey:    "rateLimits",
+		createPath: "/naming/v1/ratelimits",
+		updatePath: "/naming/v1/ratelimits",
+		deletePath: "/naming/v1/ratelimits/delete",
+		deleteKeys: []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"NAMESPACE", "namespace"}, {"SERVICE", "service"}, {"NAME", "name"},
+			{"DISABLE", "disable"}, {"MTIME", "mtime"},
+		},
+	}
+
+	circuitBreakerResource = &resource{
+		kind:       "CircuitBreakerRule",
+		use:        "circuitbreaker",
+		aliases:    []string{"circuitbreakers", "cb"},
+		identity:   []string{"namespace", "name"},
+		listPath:   "/naming/v1/circuitbreaker/rules",
+		listKey:    "data",
+		createPath: "/naming/v1/circuitbreaker/rules",
+		updatePath: "/naming/v1/circuitbreaker/rules",
+		deletePath: "/naming/v1/circuitbreaker/rules/delete",
+		deleteKeys: []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"NAMESPACE", "namespace"}, {"NAME", "name"}, {"ENABLE", "enable"},
+			{"LEVEL", "level"}, {"MTIME", "mtime"},
+		},
+	}
+
+	configFileResource = &resource{
+		kind:         "ConfigFile",
+		use:          "config",
+		aliases:      []string{"configs", "configfile", "cf"},
+		identity:     []string{"namespace", "group", "name"},
+		listPath:     "/config/v1/configfiles/search",
+		listKey:      "configFiles",
+		getPath:      "/config/v1/configfiles",
+		getKey:       "configFile",
+		createPath:   "/config/v1/configfiles",
+		createSingle: true,
+		updatePath:   "/config/v1/configfiles",
+		updateSingle: true,
+		deletePath:   "/config/v1/configfiles",
+		deleteMethod: http.MethodDelete,
+		deleteKeys:   []string{"namespace", "group", "name"},
+		columns: []column{
+			{"NAMESPACE", "namespace"}, {"GROUP", "group"}, {"NAME", "name"}, {"FORMAT", "format"},
+			{"STATUS", "status"}, {"MODIFY_TIME", "modifyTime"}, {"RELEASE_TIME", "releaseTime"},
+		},
+	}
+
+	userResource = &resource{
+		kind:         "User",
+		use:          "user",
+		aliases:      []string{"users"},
+		identity:     []string{"name"},
+		listPath:     "/core/v1/users",
+		listKey:      "users",
+		createPath:   "/core/v1/users",
+		updatePath:   "/core/v1/user",
+		updateSingle: true,
+		deletePath:   "/core/v1/users/delete",
+		deleteKeys:   []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"NAME", "name"}, {"SOURCE", "source"}, {"COMMENT", "comment"},
+			{"MTIME", "mtime"},
+		},
+	}
+
+	// strategyResource 鉴权策略的更新接口为增量修改，声明式清单只支持创建
+	strategyResource = &resource{
+		kind:         "AuthStrategy",
+		use:          "strategy",
+		aliases:      []string{"strategies"},
+		identity:     []string{"name"},
+		listPath:     "/core/v1/auth/strategies",
+		listKey:      "authStrategies",
+		createPath:   "/core/v1/auth/strategy",
+		createSingle: true,
+		deletePath:   "/core/v1/auth/strategies/delete",
+		deleteKeys:   []string{"id"},
+		columns: []column{
+			{"ID", "id"}, {"NAME", "name"}, {"OWNER", "owner"}, {"DEFAULT", "defaultStrategy"},
+			{"COMMENT", "comment"}, {"MTIME", "mtime"},
+		},
+	}
+
+	resources = []*resource{
+		namespaceResource, serviceResource, instanceResource, routingResource, rateLimitResource,
+		circuitBreakerResource, configFileResource, userResource, strategyResource,
+	}
+)
+
+// resourceOfKind 根据清单中的资源类型获取资源定义
+func resourceOfKind(kind string) (*resource, error) {
+	for _, r := range resources {
+		if r.kind == kind {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown kind %s", kind)
+}
+
+// list 查询资源列表
+func (r *resource) list(c *Client, query url.Values) ([]map[string]interface{}, error) {
+	resp, err := c.Do(http.MethodGet, r.listPath, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items(r.listKey), nil
+}
+
+// find 根据唯一标识查询资源，资源不存在时返回 nil
+func (r *resource) find(c *Client, identity map[string]interface{}) (map[string]interface{}, error) {
+	query := url.Values{}
+	for _, key := range r.identity {
+		query.Set(key, formatValue(identity[key]))
+	}
+	if r.getPath != "" {
+		resp, err := c.Do(http.MethodGet, r.getPath, query, nil)
+		if err != nil {
+			if resp != nil && resp.Code() == codeNotFoundResource {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return resp.Object(r.getKey), nil
+	}
+
+	query.Set("offset", "0")
+	query.Set("limit", "100")
+	items, err := r.list(c, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if r.matches(item, identity) {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// matches 判断资源是否和唯一标识一致，列表查询可能为模糊匹配
+func (r *resource) matches(item, identity map[string]interface{}) bool {
+	for _, key := range r.identity {
+		if formatValue(item[key]) != formatValue(identity[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// create 创建资源
+func (r *resource) create(c *Client, obj map[string]interface{}) error {
+	_, err := c.Do(http.MethodPost, r.createPath, nil, r.body(obj, r.createSingle))
+	return err
+}
+
+// update 更新资源，会带上已存在资源的 id
+func (r *resource) update(c *Client, existing, obj map[string]interface{}) error {
+	if r.updatePath == "" {
+		return fmt.Errorf("update of %s is not supported, delete and apply it again", r.kind)
+	}
+	req := make(map[string]interface{}, len(obj)+1)
+	if id, ok := existing["id"]; ok {
+		req["id"] = id
+	}
+	for k, v := range obj {
+		req[k] = v
+	}
+	_, err := c.Do(http.MethodPut, r.updatePath, nil, r.body(req, r.updateSingle))
+	return err
+}
+
+// remove 删除资源
+func (r *resource) remove(c *Client, item map[string]interface{}) error {
+	req := make(map[string]interface{}, len(r.deleteKeys))
+	for _, key := range r.deleteKeys {
+		if _, ok := item[key]; !ok {
+			return fmt.Errorf("%s of %s is required for deletion", key, r.kind)
+		}
+		req[key] = item[key]
+	}
+	if r.deleteMethod == http.MethodDelete {
+		query := url.Values{}
+		for k, v := range req {
+			query.Set(k, formatValue(v))
+		}
+		_, err := c.Do(http.MethodDelete, r.deletePath, query, nil)
+		return err
+	}
+	_, err := c.Do(http.MethodPost, r.deletePath, nil, r.body(req, false))
+	return err
+}
+
+func (r *resource) body(obj map[string]interface{}, single bool) interface{} {
+	if single {
+		return obj
+	}
+	return []interface{}{obj}
+}
+
+// identityArgs 命令行中指定的资源唯一标识
+type identityArgs map[string]*string
+
+// bind 为资源的唯一标识注册命令行参数
+func (a identityArgs) bind(r *resource, flags interface {
+	StringVar(p *string, name string, value string, usage string)
+}) {
+	for _, key := range r.identity {
+		value := ""
+		a[key] = &value
+		flags.StringVar(a[key], key, "", key+" of the "+r.use)
+	}
+}
+
+// values 返回命令行中的唯一标识，端口等数值字段转换为数字
+func (a identityArgs) values() map[string]interface{} {
+	ret := make(map[string]interface{}, len(a))
+	for key, value := range a {
+		if *value == "" {
+			continue
+		}
+		if n, err := strconv.ParseUint(*value, 10, 32); err == nil && key == "port" {
+			ret[key] = n
+			continue
+		}
+		ret[key] = *value
+	}
+	return ret
+}
+
+// require 检查唯一标识是否都已指定
+func (a identityArgs) require(r *resource) (map[string]interface{}, error) {
+	values := a.values()
+	for _, key := range r.identity {
+		if _, ok := values[key]; !ok {
+			return nil, errors.New("--" + key + " is required")
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,114 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/polarismesh/polaris/plugin/password"
+)
+
+var (
+	encryptKeyFile   = ""
+	encryptKeyEnv    = ""
+	encryptPublicKey = ""
+	encryptGenKey    = false
+	encryptGenPair   = false
+
+	encryptCmd = &cobra.Command{
+		Use:   "encrypt [plaintext]",
+		Short: "encrypt a password for the config file",
+		Long: "encrypt a password into the ENC(...) format with the AES key, or into the SEALED(...) format " +
+			"with the public key, which can be decrypted by the localParse password plugin, " +
+			"the plaintext is read from stdin when it is not given as an argument",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if encryptGenKey {
+				key, err := password.GenerateKey()
+				if err != nil {
+					return err
+				}
+				fmt.Println(key)
+				return nil
+			}
+			if encryptGenPair {
+				publicKey, privateKey, err := password.GenerateKeyPair()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("public key: %s\nprivate key: %s\n", publicKey, privateKey)
+				return nil
+			}
+
+			plain, err := readPlaintext(args)
+			if err != nil {
+				return err
+			}
+			var cipher string
+			if encryptPublicKey != "" {
+				cipher, err = password.Seal(encryptPublicKey, plain)
+			} else {
+				cipher, err = encryptWithKey(plain)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(cipher)
+			return nil
+		},
+	}
+)
+
+// init 解析命令参数
+func init() {
+	encryptCmd.Flags().StringVar(&encryptKeyFile, "key-file", "", "file of the base64 encoded AES key")
+	encryptCmd.Flags().StringVar(&encryptKeyEnv, "key-env", password.DefaultKeyEnv,
+		"environment variable of the base64 encoded AES key, used when --key-file is not set")
+	encryptCmd.Flags().StringVar(&encryptPublicKey, "public-key", "",
+		"base64 encoded public key, the password is encrypted by sealed box when it is set")
+	encryptCmd.Flags().BoolVar(&encryptGenKey, "gen-key", false, "generate a new AES key")
+	encryptCmd.Flags().BoolVar(&encryptGenPair, "gen-key-pair", false, "generate a new key pair of sealed box")
+}
+
+func encryptWithKey(plain string) (string, error) {
+	key, err := password.LoadKey(encryptKeyFile, encryptKeyEnv)
+	if err != nil {
+		return "", err
+	}
+	if len(key) == 0 {
+		return "", errors.New("AES key is not found, set it by --key-file or the environment variable")
+	}
+	return password.Encrypt(key, plain)
+}
+
+func readPlaintext(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,169 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	boot_config "github.com/polarismesh/polaris/bootstrap/config"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+	"github.com/polarismesh/polaris/store/migrate"
+)
+
+var (
+	migrateSourceConfig = ""
+	migrateTargetConfig = ""
+	migrateOptions      = migrate.Options{}
+	migrateVerify       = true
+	migrateVerifyOnly   = false
+
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate data between stores",
+		Long: "migrate data from the store configured in --source to the store configured in --target, " +
+			"the polaris server should be stopped during migration",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runMigrate()
+		},
+	}
+)
+
+// init 解析命令参数
+func init() {
+	flags := migrateCmd.Flags()
+	flags.StringVar(&migrateSourceConfig, "source", "", "config file path of the source store")
+	flags.StringVar(&migrateTargetConfig, "target", "", "config file path of the target store")
+	flags.BoolVar(&migrateOptions.DryRun, "dry-run", false, "only count the records to migrate")
+	flags.IntVar(&migrateOptions.BatchSize, "batch-size", migrate.DefaultBatchSize, "records written per batch")
+	flags.StringVar(&migrateOptions.CheckpointFile, "checkpoint", "polaris-migrate.checkpoint",
+		"checkpoint file used to resume an interrupted migration, empty to disable")
+	flags.StringSliceVar(&migrateOptions.Resources, "resources", nil,
+		fmt.Sprintf("resources to migrate, default all of %v", migrate.ResourceNames()))
+	flags.BoolVar(&migrateVerify, "verify", true, "compare counts and revisions after migration")
+	flags.BoolVar(&migrateVerifyOnly, "verify-only", false, "skip migration and only run verification")
+}
+
+func runMigrate() error {
+	if migrateSourceConfig == "" || migrateTargetConfig == "" {
+		return errors.New("both --source and --target are required")
+	}
+	sourceConf, err := boot_config.Load(migrateSourceConfig)
+	if err != nil {
+		return err
+	}
+	targetConf, err := boot_config.Load(migrateTargetConfig)
+	if err != nil {
+		return err
+	}
+	// 密码解析等插件以目标存储的配置为准
+	plugin.SetPluginConfig(&targetConf.Plugin)
+
+	source, err := openStore(&sourceConf.Store)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Destroy() }()
+	target, err := openStore(&targetConf.Store)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = target.Destroy() }()
+
+	migrateOptions.Output = os.Stdout
+	migrator, err := migrate.NewMigrator(source, target, migrateOptions)
+	if err != nil {
+		return err
+	}
+
+	if !migrateVerifyOnly {
+		stats, err := migrator.Run()
+		printMigrateStats(stats)
+		if err != nil {
+			return err
+		}
+		if migrateOptions.DryRun {
+			return nil
+		}
+	}
+	if !migrateVerify && !migrateVerifyOnly {
+		return nil
+	}
+	results, err := migrator.Verify()
+	passed := printVerifyResults(results)
+	if err != nil {
+		return err
+	}
+	if !passed {
+		return errors.New("verification failed, target store is not consistent with source store")
+	}
+	fmt.Println("verification passed")
+	return nil
+}
+
+// openStore 根据配置初始化一个存储插件，迁移过程需要同时打开两个存储，因此不使用全局的 store.GetStore
+func openStore(conf *store.Config) (store.Store, error) {
+	s, ok := store.StoreSlots[conf.Name]
+	if !ok {
+		return nil, fmt.Errorf("store `%s` not found", conf.Name)
+	}
+	if err := s.Initialize(conf); err != nil {
+		return nil, fmt.Errorf("initialize store `%s` fail: %w", conf.Name, err)
+	}
+	return s, nil
+}
+
+func printMigrateStats(stats []*migrate.Stat) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RESOURCE\tTOTAL\tRESUMED\tSKIPPED\tWRITTEN")
+	for _, stat := range stats {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", stat.Resource, stat.Total, stat.Resumed, stat.Skipped,
+			stat.Written)
+	}
+	_ = w.Flush()
+}
+
+func printVerifyResults(results []*migrate.VerifyResult) bool {
+	passed := true
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RESOURCE\tSOURCE\tTARGET\tMISSING\tMISMATCH\tRESULT")
+	for _, ret := range results {
+		result := "ok"
+		if !ret.Passed() {
+			result = "failed"
+			passed = false
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", ret.Resource, ret.SourceCount, ret.TargetCount,
+			len(ret.Missing), len(ret.Mismatch), result)
+	}
+	_ = w.Flush()
+	for _, ret := range results {
+		for _, key := range ret.Missing {
+			fmt.Printf("[%s] missing in target: %s\n", ret.Resource, key)
+		}
+		for _, key := range ret.Mismatch {
+			fmt.Printf("[%s] revision mismatch: %s\n", ret.Resource, key)
+		}
+	}
+	return passed
+}
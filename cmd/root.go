@@ -19,6 +19,8 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/polarismesh/polaris/cmd/ctl"
 )
 
 var (
@@ -35,6 +37,11 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(revisionCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(ctl.Command)
 }
 
 // Execute 执行命令行解析
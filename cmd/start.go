@@ -18,19 +18,33 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/polarismesh/polaris/bootstrap"
+	boot_config "github.com/polarismesh/polaris/bootstrap/config"
+	"github.com/polarismesh/polaris/plugin"
+	sqldb "github.com/polarismesh/polaris/store/mysql"
 )
 
 var (
 	configFilePath = ""
+	schemaDryRun   = false
 
 	startCmd = &cobra.Command{
 		Use:   "start",
 		Short: "start running",
 		Long:  "start running",
 		Run: func(c *cobra.Command, args []string) {
+			if schemaDryRun {
+				if err := runSchemaDryRun(); err != nil {
+					fmt.Printf("[ERROR] %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
 			bootstrap.Start(configFilePath)
 		},
 	}
@@ -39,4 +53,20 @@ var (
 // init 解析命令参数
 func init() {
 	startCmd.PersistentFlags().StringVarP(&configFilePath, "config", "c", "conf/polaris-server.yaml", "config file path")
+	startCmd.Flags().BoolVar(&schemaDryRun, "schema-dry-run", false,
+		"print the pending schema delta statements of the mysql store and exit")
+}
+
+// runSchemaDryRun 输出 mysql 存储尚未执行的 schema 变更语句
+func runSchemaDryRun() error {
+	conf, err := boot_config.Load(configFilePath)
+	if err != nil {
+		return err
+	}
+	if conf.Store.Name != sqldb.STORENAME {
+		return fmt.Errorf("schema dry run only supports store %s, current store is %s", sqldb.STORENAME,
+			conf.Store.Name)
+	}
+	plugin.SetPluginConfig(&conf.Plugin)
+	return sqldb.DryRunSchema(&conf.Store, os.Stdout)
 }
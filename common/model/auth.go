@@ -208,6 +208,10 @@ type User struct {
 	Comment     string
 	CreateTime  time.Time
 	ModifyTime  time.Time
+	// PasswordHistory 历史密码的 hash 列表，越靠前越新
+	PasswordHistory []string
+	// PasswordModifyTime 密码最近一次修改的时间
+	PasswordModifyTime time.Time
 }
 
 // UserGroupDetail 用户组详细（带用户列表）
@@ -0,0 +1,45 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// ChangeResource 变更日志记录的资源类型，缓存据此决定需要刷新哪些数据，
+// 客户端随每次上报频繁变化，不记录变更日志
+type ChangeResource string
+
+const (
+	ChangeResourceNamespace      ChangeResource = "namespace"
+	ChangeResourceService        ChangeResource = "service"
+	ChangeResourceInstance       ChangeResource = "instance"
+	ChangeResourceRouting        ChangeResource = "routing"
+	ChangeResourceRateLimit      ChangeResource = "ratelimit"
+	ChangeResourceCircuitBreaker ChangeResource = "circuitbreaker"
+	ChangeResourceFaultDetect    ChangeResource = "faultdetect"
+	ChangeResourceUser           ChangeResource = "user"
+	ChangeResourceAuthStrategy   ChangeResource = "auth_strategy"
+	ChangeResourceConfigFile     ChangeResource = "config_file"
+	ChangeResourceL5             ChangeResource = "l5"
+)
+
+// ChangeLog 存储层的一条数据变更记录，Seq 在同一个存储内单调递增
+type ChangeLog struct {
+	Seq        uint64
+	Resource   ChangeResource
+	CreateTime time.Time
+}
@@ -0,0 +1,89 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// MaxDependencyCallerHosts 每条依赖关系最多保留的主调方地址数量
+	MaxDependencyCallerHosts = 16
+)
+
+// ServiceDependency 服务依赖关系，由主调方发起的服务发现请求推导得出，以主调服务到被调服务为唯一标识。
+// CallerVerified 表示主调服务来自客户端证书等已认证的身份，否则来自请求头中的声明，可能被伪造
+type ServiceDependency struct {
+	CallerNamespace string `json:"callerNamespace"`
+	CallerService   string `json:"callerService"`
+	// CallerHosts 最近发现的主调方地址，最新的在前，最多保留 MaxDependencyCallerHosts 个
+	CallerHosts     []string  `json:"callerHosts"`
+	CallerVerified  bool      `json:"callerVerified"`
+	CalleeNamespace string    `json:"calleeNamespace"`
+	CalleeService   string    `json:"calleeService"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastSeen        time.Time `json:"lastSeen"`
+}
+
+// Key 依赖关系的唯一标识，由主调服务以及被调服务组成
+func (d *ServiceDependency) Key() string {
+	return strings.Join([]string{d.CallerNamespace, d.CallerService, d.CalleeNamespace, d.CalleeService}, "|")
+}
+
+// Merge 合并同一依赖关系的另一条观测记录，保留最早的首次发现时间以及最晚的最近发现时间，
+// 主调方地址按照发现时间合并去重，任意一次观测通过认证即认为主调服务已认证
+func (d *ServiceDependency) Merge(other *ServiceDependency) {
+	if other.LastSeen.Before(d.LastSeen) {
+		d.CallerHosts = MergeDependencyHosts(d.CallerHosts, other.CallerHosts)
+	} else {
+		d.CallerHosts = MergeDependencyHosts(other.CallerHosts, d.CallerHosts)
+	}
+	d.CallerVerified = d.CallerVerified || other.CallerVerified
+	if d.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(d.FirstSeen)) {
+		d.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(d.LastSeen) {
+		d.LastSeen = other.LastSeen
+	}
+}
+
+// MergeDependencyHosts 合并两组主调方地址，recent 在前，去重后最多保留 MaxDependencyCallerHosts 个
+func MergeDependencyHosts(recent, older []string) []string {
+	hosts := make([]string, 0, MaxDependencyCallerHosts)
+	for _, group := range [][]string{recent, older} {
+		for _, host := range group {
+			if len(hosts) >= MaxDependencyCallerHosts {
+				return hosts
+			}
+			if host != "" && !containsString(hosts, host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
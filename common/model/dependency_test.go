@@ -0,0 +1,52 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceDependency_Merge(t *testing.T) {
+	seen := time.Unix(1700000000, 0)
+	dep := &ServiceDependency{CallerHosts: []string{"10.0.0.1"}, FirstSeen: seen, LastSeen: seen}
+	dep.Merge(&ServiceDependency{CallerHosts: []string{"10.0.0.2", "10.0.0.1"}, CallerVerified: true,
+		FirstSeen: seen.Add(time.Second), LastSeen: seen.Add(time.Second)})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.1"}, dep.CallerHosts)
+	assert.True(t, dep.CallerVerified)
+	assert.Equal(t, seen, dep.FirstSeen)
+	assert.Equal(t, seen.Add(time.Second), dep.LastSeen)
+
+	// 较早的观测记录中的地址排在后面，并且认证状态不会被覆盖
+	dep.Merge(&ServiceDependency{CallerHosts: []string{"10.0.0.3"}, FirstSeen: seen, LastSeen: seen})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.1", "10.0.0.3"}, dep.CallerHosts)
+	assert.True(t, dep.CallerVerified)
+}
+
+func TestMergeDependencyHosts(t *testing.T) {
+	var older []string
+	for i := 0; i < MaxDependencyCallerHosts; i++ {
+		older = append(older, fmt.Sprintf("10.0.0.%d", i))
+	}
+	hosts := MergeDependencyHosts([]string{"10.0.1.1", "", "10.0.0.0"}, older)
+	assert.Len(t, hosts, MaxDependencyCallerHosts)
+	assert.Equal(t, []string{"10.0.1.1", "10.0.0.0", "10.0.0.1"}, hosts[:3])
+}
@@ -81,3 +81,78 @@ func (i *InstanceEvent) String() string {
 	return fmt.Sprintf("InstanceEvent(id=%s, namespace=%s, svcId=%s, service=%s, type=%v, instance=%s, healthy=%v)",
 		i.Id, i.Namespace, i.SvcId, i.Service, i.EType, hostPortStr, i.Instance.GetHealthy().GetValue())
 }
+
+// InstanceEventRecord 保存到存储层的实例事件，用于查询服务下实例的变更时间线
+type InstanceEventRecord struct {
+	EventType  InstanceEventType `json:"eventType"`
+	Namespace  string            `json:"namespace"`
+	Service    string            `json:"service"`
+	InstanceID string            `json:"instanceId"`
+	Host       string            `json:"host"`
+	Port       uint32            `json:"port"`
+	Weight     uint32            `json:"weight"`
+	Healthy    bool              `json:"healthy"`
+	Isolate    bool              `json:"isolate"`
+	// Server 产生事件的服务端节点
+	Server     string    `json:"server"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// NewInstanceEventRecord 根据实例事件创建需要保存的记录，事件中的实例为变更后的状态
+func NewInstanceEventRecord(event *InstanceEvent, server string) *InstanceEventRecord {
+	createTime := event.CreateTime
+	if createTime.IsZero() {
+		createTime = time.Now()
+	}
+	return &InstanceEventRecord{
+		EventType:  event.EType,
+		Namespace:  event.Namespace,
+		Service:    event.Service,
+		InstanceID: event.Id,
+		Host:       event.Instance.GetHost().GetValue(),
+		Port:       event.Instance.GetPort().GetValue(),
+		Weight:     event.Instance.GetWeight().GetValue(),
+		Healthy:    event.Instance.GetHealthy().GetValue(),
+		Isolate:    event.Instance.GetIsolate().GetValue(),
+		Server:     server,
+		CreateTime: createTime,
+	}
+}
+
+// InstanceEventFilter 实例事件的查询条件，为空的条件不参与过滤，时间范围为 [StartTime, EndTime)
+type InstanceEventFilter struct {
+	Namespace  string
+	Service    string
+	InstanceID string
+	EventTypes []InstanceEventType
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// Match 判断事件是否满足查询条件
+func (f *InstanceEventFilter) Match(record *InstanceEventRecord) bool {
+	if f.Namespace != "" && f.Namespace != record.Namespace {
+		return false
+	}
+	if f.Service != "" && f.Service != record.Service {
+		return false
+	}
+	if f.InstanceID != "" && f.InstanceID != record.InstanceID {
+		return false
+	}
+	if !f.StartTime.IsZero() && record.CreateTime.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && !record.CreateTime.Before(f.EndTime) {
+		return false
+	}
+	if len(f.EventTypes) == 0 {
+		return true
+	}
+	for _, eventType := range f.EventTypes {
+		if eventType == record.EventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// InstanceHeartbeat 保存到存储层的实例心跳记录，用于没有 Redis 的集群在各个节点之间共享心跳时间
+type InstanceHeartbeat struct {
+	InstanceID string
+	// Server 最近一次接收心跳的服务端节点
+	Server string
+	// LastHeartbeatSec 最近一次心跳的时间，单位秒
+	LastHeartbeatSec int64
+	// Count 心跳上报次数，实例不存在时用于判断是否需要返回实例不存在
+	Count int64
+	// ModifyTime 记录在存储层的修改时间，由存储层写入
+	ModifyTime time.Time
+}
+
+// Newer 判断心跳记录是否比 other 更新，心跳时间相同时以上报次数较大的为准
+func (h *InstanceHeartbeat) Newer(other *InstanceHeartbeat) bool {
+	if other == nil {
+		return true
+	}
+	if h.LastHeartbeatSec != other.LastHeartbeatSec {
+		return h.LastHeartbeatSec > other.LastHeartbeatSec
+	}
+	return h.Count > other.Count
+}
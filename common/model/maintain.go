@@ -17,7 +17,12 @@
 
 package model
 
-import "time"
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
 
 // LeaderElection leader election info
 type LeaderElection struct {
@@ -29,3 +34,75 @@ type LeaderElection struct {
 	ModifyTime time.Time
 	Valid      bool
 }
+
+const (
+	// MaintainJobTriggerCron 按照 cron 表达式定时触发
+	MaintainJobTriggerCron = "cron"
+	// MaintainJobTriggerManual 通过运维接口手动触发
+	MaintainJobTriggerManual = "manual"
+
+	// MaintainJobRunning 执行中
+	MaintainJobRunning = "running"
+	// MaintainJobSuccess 执行成功
+	MaintainJobSuccess = "success"
+	// MaintainJobFailed 执行失败
+	MaintainJobFailed = "failed"
+)
+
+// MaintainJobRun 运维任务的一次执行记录
+type MaintainJobRun struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Trigger  string `json:"trigger"`
+	Operator string `json:"operator"`
+	// Host 执行任务的节点
+	Host   string `json:"host"`
+	Status string `json:"status"`
+	// Message 执行结果的说明，失败时为失败原因
+	Message string `json:"message"`
+	// Affected 本次执行影响的资源，如删除的实例 ID、服务名
+	Affected  []string  `json:"affected"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+const (
+	// WhitelistAllow 放通匹配的地址
+	WhitelistAllow = "allow"
+	// WhitelistDeny 拒绝匹配的地址，拒绝规则优先于放通规则
+	WhitelistDeny = "deny"
+)
+
+// WhitelistRule 通过运维接口在运行时修改的白名单规则
+type WhitelistRule struct {
+	ID string `json:"id"`
+	// API 规则作用的接口路径前缀，HTTP 接口为 url 路径，gRPC 接口为方法全名，为空表示作用于全部接口
+	API string `json:"api"`
+	// CIDR IP 地址或者网段，支持 IPv6
+	CIDR string `json:"cidr"`
+	// Action allow 或者 deny
+	Action     string    `json:"action"`
+	Comment    string    `json:"comment"`
+	Operator   string    `json:"operator"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// ParseIPNet 解析 IP 地址或者网段，单个 IP 地址按照掩码全为 1 的网段处理
+func ParseIPNet(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %s", value)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip %s", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
@@ -43,6 +43,10 @@ const (
 	OUpdateGroup OperationType = "UpdateGroup"
 	// OEnableRateLimit Update enable state
 	OUpdateEnable OperationType = "UpdateEnable"
+	// OLock Lock the resource, e.g. user login lockout
+	OLock OperationType = "Lock"
+	// OUnlock Unlock the resource
+	OUnlock OperationType = "Unlock"
 )
 
 // Resource Operating resources
@@ -65,6 +69,7 @@ const (
 	RConfigFileRelease  Resource = "ConfigFileRelease"
 	RCircuitBreakerRule Resource = "CircuitBreakerRule"
 	RFaultDetectRule    Resource = "FaultDetectRule"
+	RClientAddress      Resource = "ClientAddress"
 )
 
 // RecordEntry Operation records
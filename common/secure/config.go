@@ -18,6 +18,8 @@
 package secure
 
 import (
+	"time"
+
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/polarismesh/polaris/common/log"
@@ -37,6 +39,36 @@ type TLSConfig struct {
 	// InsecureSkipVerify tls 的一个配置
 	// 客户端是否验证证书和服务器主机名
 	InsecureSkipVerify bool `mapstructure:"insecureSkipTlsVerify"`
+
+	// ClientCertAuth 是否强制要求客户端提供由 TrustedCAFile 签发的证书
+	ClientCertAuth bool `mapstructure:"clientCertAuth"`
+	// CRLFile 客户端证书吊销列表，需要由 TrustedCAFile 中的 CA 签发，超过 NextUpdate 后拒绝所有客户端证书
+	CRLFile string `mapstructure:"crlFile"`
+	// AllowedCN 允许访问的客户端证书 CN，为空表示不限制
+	AllowedCN string `mapstructure:"allowedCN"`
+	// AllowedHostname 客户端证书中必须包含的 IP 地址或主机名，为空表示不限制
+	AllowedHostname string `mapstructure:"allowedHostname"`
+	// ReloadInterval 检查证书文件是否变更的间隔，用于证书轮转时的热加载
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+}
+
+// ToTLSInfo 将 tls 配置转换为服务端使用的 TLSInfo
+func (c *TLSConfig) ToTLSInfo() *TLSInfo {
+	if c == nil {
+		return nil
+	}
+	return &TLSInfo{
+		CertFile:           c.CertFile,
+		KeyFile:            c.KeyFile,
+		TrustedCAFile:      c.TrustedCAFile,
+		ClientCertAuth:     c.ClientCertAuth,
+		CRLFile:            c.CRLFile,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		ServerName:         c.ServerName,
+		AllowedCN:          c.AllowedCN,
+		AllowedHostname:    c.AllowedHostname,
+		ReloadInterval:     c.ReloadInterval,
+	}
 }
 
 // ParseTLSConfig 解析 tls 配置
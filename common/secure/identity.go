@@ -0,0 +1,70 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/tls"
+	"net/url"
+	"strings"
+)
+
+// PeerIdentity 客户端证书中可用于映射北极星用户/用户组的身份信息
+type PeerIdentity struct {
+	// CommonName 证书 Subject 中的 CN
+	CommonName string
+	// DNSNames 证书 SAN 中的 DNS 名称
+	DNSNames []string
+	// SpiffeID 证书 URI SAN 中的 SPIFFE ID
+	SpiffeID string
+}
+
+// ParsePeerIdentity 从 tls 连接状态中解析客户端证书的身份信息，客户端未提供证书时返回 nil
+func ParsePeerIdentity(state *tls.ConnectionState) *PeerIdentity {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := state.PeerCertificates[0]
+	identity := &PeerIdentity{
+		CommonName: leaf.Subject.CommonName,
+		DNSNames:   leaf.DNSNames,
+	}
+	for _, uri := range leaf.URIs {
+		if uri.Scheme == "spiffe" {
+			identity.SpiffeID = uri.String()
+			break
+		}
+	}
+	return identity
+}
+
+// Workload 从 SPIFFE ID 中解析客户端的命名空间以及服务，
+// 要求 SPIFFE ID 形如 spiffe://{trust-domain}/ns/{namespace}/sa/{service}，不满足时 ok 为 false
+func (p *PeerIdentity) Workload() (namespace string, service string, ok bool) {
+	if p == nil || p.SpiffeID == "" {
+		return "", "", false
+	}
+	u, err := url.Parse(p.SpiffeID)
+	if err != nil {
+		return "", "", false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) != 4 || segments[0] != "ns" || segments[2] != "sa" || segments[1] == "" || segments[3] == "" {
+		return "", "", false
+	}
+	return segments[1], segments[3], true
+}
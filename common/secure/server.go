@@ -0,0 +1,413 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/log"
+)
+
+const (
+	// defaultReloadInterval 默认的证书文件变更检查间隔
+	defaultReloadInterval = 10 * time.Second
+)
+
+var (
+	// ErrNoClientCert 开启了客户端证书认证，但客户端没有提供证书
+	ErrNoClientCert = errors.New("client certificate is required")
+	// ErrClientCertRevoked 客户端证书已被吊销
+	ErrClientCertRevoked = errors.New("client certificate has been revoked")
+	// ErrServerCertRevoked 服务端证书已被吊销
+	ErrServerCertRevoked = errors.New("server certificate has been revoked")
+	// ErrCRLExpired 吊销列表已经超过 NextUpdate，需要更新吊销列表文件
+	ErrCRLExpired = errors.New("certificate revocation list has expired")
+)
+
+// ServerConfig 根据 tls 配置信息构建服务端使用的 tls.Config
+// 证书、CA 以及吊销列表文件发生变更时，会在后续的握手过程中自动重新加载
+// 配置了 TrustedCAFile 时会请求客户端证书，若 ClientCertAuth 为 true 则客户端必须提供证书
+func (t *TLSInfo) ServerConfig() (*tls.Config, error) {
+	if t.IsEmpty() {
+		return nil, errors.New("tls certFile or keyFile is empty")
+	}
+	if t.ClientCertAuth && t.TrustedCAFile == "" {
+		return nil, errors.New("tls clientCertAuth requires trustedCAFile")
+	}
+	if t.CRLFile != "" && t.TrustedCAFile == "" {
+		return nil, errors.New("tls crlFile requires trustedCAFile")
+	}
+
+	holder, err := newCertHolder(t)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		CipherSuites:   t.CipherSuites,
+		GetCertificate: holder.getCertificate,
+		ClientAuth:     tls.NoClientCert,
+	}
+	if t.TrustedCAFile != "" {
+		// 客户端证书的校验交由 verifyConnection 完成，这样 CA 与吊销列表才能热加载，
+		// 与 VerifyPeerCertificate 不同，VerifyConnection 在会话恢复时同样会执行，吊销的证书无法通过会话票据绕过校验
+		conf.ClientAuth = tls.RequestClientCert
+		if t.ClientCertAuth {
+			conf.ClientAuth = tls.RequireAnyClientCert
+		}
+		conf.VerifyConnection = holder.verifyConnection
+	}
+	return conf, nil
+}
+
+// ClientConfig 根据 tls 配置信息构建客户端使用的 tls.Config，用于服务端节点之间的双向认证
+// 客户端证书文件发生变更时会在后续的握手过程中自动重新加载，TrustedCAFile 用于校验服务端证书
+func (t *TLSInfo) ClientConfig() (*tls.Config, error) {
+	configs, err := t.NewClientConfigs()
+	if err != nil {
+		return nil, err
+	}
+	return configs(t.ServerName), nil
+}
+
+// NewClientConfigs 返回按照服务端主机名生成客户端 tls.Config 的函数，生成的配置共享同一份热加载的证书、CA 以及吊销列表
+// 配置了 ServerName 时总是使用 ServerName 校验服务端证书，否则使用传入的主机名，为空时使用握手时的 SNI
+func (t *TLSInfo) NewClientConfigs() (func(serverName string) *tls.Config, error) {
+	if t.IsEmpty() {
+		return nil, errors.New("tls certFile or keyFile is empty")
+	}
+	holder, err := newCertHolder(t)
+	if err != nil {
+		return nil, err
+	}
+	return func(serverName string) *tls.Config {
+		if t.ServerName != "" {
+			serverName = t.ServerName
+		}
+		conf := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			CipherSuites:       t.CipherSuites,
+			ServerName:         serverName,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return holder.getCertificate(nil)
+			},
+		}
+		if t.TrustedCAFile != "" && !t.InsecureSkipVerify {
+			// RootCAs 只在创建时读取一次，CA 轮换后无法生效，因此关闭默认的校验，
+			// 改为在 verifyServerConnection 中使用当前生效的 CA 以及吊销列表校验服务端证书
+			conf.InsecureSkipVerify = true
+			conf.VerifyConnection = func(state tls.ConnectionState) error {
+				return holder.verifyServerConnection(state, serverName)
+			}
+		}
+		return conf
+	}, nil
+}
+
+// certHolder 持有当前生效的服务端证书、CA 证书池以及吊销列表
+type certHolder struct {
+	info *TLSInfo
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	caPool    *x509.CertPool
+	crl       *revocationList
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+func newCertHolder(info *TLSInfo) (*certHolder, error) {
+	holder := &certHolder{info: info}
+	if err := holder.load(); err != nil {
+		return nil, err
+	}
+	return holder, nil
+}
+
+func (h *certHolder) files() []string {
+	files := make([]string, 0, 4)
+	for _, file := range []string{h.info.CertFile, h.info.KeyFile, h.info.TrustedCAFile, h.info.CRLFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (h *certHolder) reloadInterval() time.Duration {
+	if h.info.ReloadInterval > 0 {
+		return h.info.ReloadInterval
+	}
+	return defaultReloadInterval
+}
+
+// load 加载所有的证书文件，任意一个文件加载失败都不会替换当前生效的数据
+func (h *certHolder) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range h.files() {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = stat.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(h.info.CertFile, h.info.KeyFile)
+	if err != nil {
+		return err
+	}
+	var (
+		caPool  *x509.CertPool
+		caCerts []*x509.Certificate
+	)
+	if h.info.TrustedCAFile != "" {
+		if caPool, caCerts, err = loadCertPool(h.info.TrustedCAFile); err != nil {
+			return err
+		}
+	}
+	var crl *revocationList
+	if h.info.CRLFile != "" {
+		if crl, err = loadRevocationList(h.info.CRLFile, caCerts, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.cert = &cert
+	h.caPool = caPool
+	h.crl = crl
+	h.modTimes = modTimes
+	return nil
+}
+
+// maybeReload 距离上一次检查超过 reloadInterval 时，检查文件的修改时间并按需重新加载
+func (h *certHolder) maybeReload(now time.Time) {
+	h.lock.Lock()
+	if now.Sub(h.lastCheck) < h.reloadInterval() {
+		h.lock.Unlock()
+		return
+	}
+	h.lastCheck = now
+	changed := false
+	for file, modTime := range h.modTimes {
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !stat.ModTime().Equal(modTime) {
+			changed = true
+			break
+		}
+	}
+	h.lock.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := h.load(); err != nil {
+		log.Errorf("[TLS] reload certificate files err: %s", err.Error())
+		return
+	}
+	log.Infof("[TLS] certificate files reloaded, cert: %s", h.info.CertFile)
+}
+
+func (h *certHolder) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	h.maybeReload(time.Now())
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.cert, nil
+}
+
+// verifyConnection 校验客户端证书链、吊销列表以及 CN/主机名 限制
+func (h *certHolder) verifyConnection(state tls.ConnectionState) error {
+	// 会话恢复时不会获取服务端证书，需要在这里检查吊销列表等文件是否变更
+	h.maybeReload(time.Now())
+
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		if h.info.ClientCertAuth {
+			return ErrNoClientCert
+		}
+		return nil
+	}
+
+	h.lock.RLock()
+	caPool, crl := h.caPool, h.crl
+	h.lock.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return err
+	}
+	if crl != nil {
+		if crl.expired(time.Now()) {
+			return ErrCRLExpired
+		}
+		for _, cert := range certs {
+			if _, ok := crl.serials[cert.SerialNumber.String()]; ok {
+				return ErrClientCertRevoked
+			}
+		}
+	}
+	if h.info.AllowedCN != "" && leaf.Subject.CommonName != h.info.AllowedCN {
+		return fmt.Errorf("client certificate CN %q is not allowed", leaf.Subject.CommonName)
+	}
+	if h.info.AllowedHostname != "" {
+		if err := leaf.VerifyHostname(h.info.AllowedHostname); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyServerConnection 客户端校验服务端证书链、主机名以及吊销列表，serverName 为空时使用握手时的 SNI
+func (h *certHolder) verifyServerConnection(state tls.ConnectionState, serverName string) error {
+	h.maybeReload(time.Now())
+
+	if serverName == "" {
+		serverName = state.ServerName
+	}
+	if serverName == "" {
+		return errors.New("tls serverName is required to verify the server certificate")
+	}
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("server certificate is required")
+	}
+
+	h.lock.RLock()
+	caPool, crl := h.caPool, h.crl
+	h.lock.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return err
+	}
+	if crl != nil {
+		if crl.expired(time.Now()) {
+			return ErrCRLExpired
+		}
+		for _, cert := range certs {
+			if _, ok := crl.serials[cert.SerialNumber.String()]; ok {
+				return ErrServerCertRevoked
+			}
+		}
+	}
+	return nil
+}
+
+// loadCertPool 加载 CA 证书，同时返回解析后的证书用于校验吊销列表的签名
+func loadCertPool(file string) (*x509.CertPool, []*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no valid certificate found in %s", file)
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, certs, nil
+}
+
+// revocationList 已加载的吊销列表
+type revocationList struct {
+	serials    map[string]struct{}
+	nextUpdate time.Time
+}
+
+// expired 吊销列表超过 NextUpdate 后不再可信，未设置 NextUpdate 时一直有效
+func (l *revocationList) expired(now time.Time) bool {
+	return !l.nextUpdate.IsZero() && now.After(l.nextUpdate)
+}
+
+// loadRevocationList 加载吊销列表，支持 PEM 以及 DER 格式，吊销列表必须由 CA 签发且没有过期
+func loadRevocationList(file string, caCerts []*x509.Certificate, now time.Time) (*revocationList, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil && block.Type == "X509 CRL" {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+	signed := false
+	for _, ca := range caCerts {
+		if crl.CheckSignatureFrom(ca) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, fmt.Errorf("crl %s is not signed by the trusted CA", file)
+	}
+	list := &revocationList{
+		serials:    make(map[string]struct{}, len(crl.RevokedCertificates)),
+		nextUpdate: crl.NextUpdate,
+	}
+	if list.expired(now) {
+		return nil, fmt.Errorf("crl %s has expired at %s", file, crl.NextUpdate.Format(time.RFC3339))
+	}
+	for _, item := range crl.RevokedCertificates {
+		list.serials[item.SerialNumber.String()] = struct{}{}
+	}
+	return list, nil
+}
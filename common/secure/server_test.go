@@ -0,0 +1,427 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "polaris-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, tmpl *x509.Certificate) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl.SerialNumber = big.NewInt(serial)
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func (ca *testCA) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+func (ca *testCA) crlPEM(t *testing.T, serials ...int64) []byte {
+	return ca.crlPEMWithNextUpdate(t, time.Now().Add(time.Hour), serials...)
+}
+
+func (ca *testCA) crlPEMWithNextUpdate(t *testing.T, nextUpdate time.Time, serials ...int64) []byte {
+	revoked := make([]pkix.RevokedCertificate, 0, len(serials))
+	for _, serial := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now(),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          nextUpdate.Add(-2 * time.Hour),
+		NextUpdate:          nextUpdate,
+		RevokedCertificates: revoked,
+	}, ca.cert, ca.key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	file := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(file, data, 0600))
+	return file
+}
+
+func clientTemplate(cn string) *x509.Certificate {
+	spiffe, _ := url.Parse("spiffe://polaris.io/ns/default/sa/" + cn)
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		DNSNames:    []string{cn + ".polaris.io"},
+		URIs:        []*url.URL{spiffe},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
+
+func newTestTLSInfo(t *testing.T, ca *testCA) *TLSInfo {
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, 100, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "polaris-server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return &TLSInfo{
+		CertFile:       writeFile(t, dir, "server.pem", certPEM),
+		KeyFile:        writeFile(t, dir, "server-key.pem", keyPEM),
+		TrustedCAFile:  writeFile(t, dir, "ca.pem", ca.certPEM()),
+		CRLFile:        writeFile(t, dir, "ca.crl", ca.crlPEM(t, 3)),
+		ClientCertAuth: true,
+	}
+}
+
+func newClientConfig(t *testing.T, ca *testCA, certPEM, keyPEM []byte) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConf := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		assert.NoError(t, err)
+		clientConf.Certificates = []tls.Certificate{cert}
+	}
+	return clientConf
+}
+
+// handshake 使用指定的客户端证书与服务端进行一次 tls 握手
+func handshake(t *testing.T, serverConf *tls.Config, ca *testCA, certPEM, keyPEM []byte) (*PeerIdentity, error) {
+	state, err := handshakeWithClient(t, serverConf, newClientConfig(t, ca, certPEM, keyPEM))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePeerIdentity(state), nil
+}
+
+// handshakeWithClient 使用指定的客户端配置与服务端进行一次 tls 握手，客户端处理完服务端的消息后才返回
+func handshakeWithClient(t *testing.T, serverConf, clientConf *tls.Config) (*tls.ConnectionState, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client, err := tls.Dial("tcp", ln.Addr().String(), clientConf)
+		if err != nil {
+			return
+		}
+		// 读取服务端的握手结果以及会话票据，保证 TLS 1.3 下服务端的拒绝能够被处理
+		_, _ = client.Read(make([]byte, 1))
+		_ = client.Close()
+	}()
+	serverConn, err := ln.Accept()
+	assert.NoError(t, err)
+
+	server := tls.Server(serverConn, serverConf)
+	err = server.Handshake()
+	state := server.ConnectionState()
+	_ = serverConn.Close()
+	<-done
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func TestServerConfig_ClientCertAuth(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	conf, err := info.ServerConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAnyClientCert, conf.ClientAuth)
+	// 会话恢复时同样需要校验吊销列表，因此不能只依赖 VerifyPeerCertificate
+	assert.NotNil(t, conf.VerifyConnection)
+	assert.Nil(t, conf.VerifyPeerCertificate)
+
+	t.Run("valid client cert", func(t *testing.T) {
+		certPEM, keyPEM := ca.issue(t, 2, clientTemplate("order"))
+		identity, err := handshake(t, conf, ca, certPEM, keyPEM)
+		assert.NoError(t, err)
+		assert.Equal(t, "order", identity.CommonName)
+		assert.Equal(t, []string{"order.polaris.io"}, identity.DNSNames)
+		assert.Equal(t, "spiffe://polaris.io/ns/default/sa/order", identity.SpiffeID)
+	})
+
+	t.Run("no client cert", func(t *testing.T) {
+		_, err := handshake(t, conf, ca, nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("revoked client cert", func(t *testing.T) {
+		certPEM, keyPEM := ca.issue(t, 3, clientTemplate("order"))
+		_, err := handshake(t, conf, ca, certPEM, keyPEM)
+		assert.ErrorIs(t, err, ErrClientCertRevoked)
+	})
+
+	t.Run("untrusted client cert", func(t *testing.T) {
+		other := newTestCA(t)
+		certPEM, keyPEM := other.issue(t, 4, clientTemplate("order"))
+		_, err := handshake(t, conf, ca, certPEM, keyPEM)
+		assert.Error(t, err)
+	})
+}
+
+func TestServerConfig_RevokedOnResumption(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	info.ReloadInterval = time.Nanosecond
+	conf, err := info.ServerConfig()
+	assert.NoError(t, err)
+
+	certPEM, keyPEM := ca.issue(t, 8, clientTemplate("order"))
+	clientConf := newClientConfig(t, ca, certPEM, keyPEM)
+	clientConf.ClientSessionCache = tls.NewLRUClientSessionCache(4)
+	_, err = handshakeWithClient(t, conf, clientConf)
+	assert.NoError(t, err)
+	state, err := handshakeWithClient(t, conf, clientConf)
+	assert.NoError(t, err)
+	assert.True(t, state.DidResume)
+
+	// 证书吊销后，客户端使用之前的会话票据恢复会话也需要被拒绝
+	assert.NoError(t, os.WriteFile(info.CRLFile, ca.crlPEM(t, 3, 8), 0600))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(info.CRLFile, future, future))
+	_, err = handshakeWithClient(t, conf, clientConf)
+	assert.ErrorIs(t, err, ErrClientCertRevoked)
+}
+
+func TestServerConfig_AllowedCN(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	info.AllowedCN = "order"
+	conf, err := info.ServerConfig()
+	assert.NoError(t, err)
+
+	certPEM, keyPEM := ca.issue(t, 5, clientTemplate("order"))
+	_, err = handshake(t, conf, ca, certPEM, keyPEM)
+	assert.NoError(t, err)
+
+	certPEM, keyPEM = ca.issue(t, 6, clientTemplate("payment"))
+	_, err = handshake(t, conf, ca, certPEM, keyPEM)
+	assert.Error(t, err)
+}
+
+func TestClientConfig(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	serverConf, err := info.ServerConfig()
+	assert.NoError(t, err)
+
+	// 节点之间使用同一份配置，服务端证书同时作为客户端证书
+	clientInfo := *info
+	clientInfo.ServerName = "127.0.0.1"
+	clientConf, err := clientInfo.ClientConfig()
+	assert.NoError(t, err)
+	_, err = handshakeWithClient(t, serverConf, clientConf)
+	assert.Error(t, err, "server certificate without client auth usage")
+
+	certPEM, keyPEM := ca.issue(t, 9, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "polaris-server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	})
+	dir := t.TempDir()
+	clientInfo.CertFile = writeFile(t, dir, "node.pem", certPEM)
+	clientInfo.KeyFile = writeFile(t, dir, "node-key.pem", keyPEM)
+	clientConf, err = clientInfo.ClientConfig()
+	assert.NoError(t, err)
+	state, err := handshakeWithClient(t, serverConf, clientConf)
+	assert.NoError(t, err)
+	assert.Equal(t, "polaris-server", ParsePeerIdentity(state).CommonName)
+
+	// 服务端证书不由受信 CA 签发
+	other := newTestCA(t)
+	clientInfo.TrustedCAFile = writeFile(t, dir, "other.pem", other.certPEM())
+	clientInfo.CRLFile = ""
+	clientConf, err = clientInfo.ClientConfig()
+	assert.NoError(t, err)
+	_, err = handshakeWithClient(t, serverConf, clientConf)
+	assert.Error(t, err)
+}
+
+func TestClientConfig_RotateCA(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	serverConf, err := info.ServerConfig()
+	assert.NoError(t, err)
+
+	certPEM, keyPEM := ca.issue(t, 10, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "polaris-server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	})
+	dir := t.TempDir()
+	other := newTestCA(t)
+	clientInfo := &TLSInfo{
+		CertFile:       writeFile(t, dir, "node.pem", certPEM),
+		KeyFile:        writeFile(t, dir, "node-key.pem", keyPEM),
+		TrustedCAFile:  writeFile(t, dir, "ca.pem", other.certPEM()),
+		ReloadInterval: time.Millisecond,
+	}
+	configs, err := clientInfo.NewClientConfigs()
+	assert.NoError(t, err)
+	_, err = handshakeWithClient(t, serverConf, configs("127.0.0.1"))
+	assert.Error(t, err)
+
+	// 轮换 CA 文件之后，已经创建的客户端配置使用新的 CA 校验服务端证书
+	assert.NoError(t, os.WriteFile(clientInfo.TrustedCAFile, ca.certPEM(), 0600))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(clientInfo.TrustedCAFile, future, future))
+	time.Sleep(2 * time.Millisecond)
+	clientConf := configs("127.0.0.1")
+	_, err = handshakeWithClient(t, serverConf, clientConf)
+	assert.NoError(t, err)
+
+	// 服务端证书与主机名不匹配
+	_, err = handshakeWithClient(t, serverConf, configs("127.0.0.2"))
+	assert.Error(t, err)
+	// 服务端没有提供证书
+	assert.Error(t, clientConf.VerifyConnection(tls.ConnectionState{}))
+	// 没有指定主机名，并且 IP 地址不会作为 SNI 发送时，无法校验服务端证书
+	_, err = handshakeWithClient(t, serverConf, configs(""))
+	assert.Error(t, err)
+}
+
+func TestServerConfig_Invalid(t *testing.T) {
+	_, err := (&TLSInfo{}).ServerConfig()
+	assert.Error(t, err)
+
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	info.TrustedCAFile = ""
+	_, err = info.ServerConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadRevocationList(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	now := time.Now()
+
+	crl, err := loadRevocationList(writeFile(t, dir, "ok.crl", ca.crlPEM(t, 3)), []*x509.Certificate{ca.cert}, now)
+	assert.NoError(t, err)
+	assert.Contains(t, crl.serials, "3")
+	assert.False(t, crl.expired(now))
+	assert.True(t, crl.expired(now.Add(2*time.Hour)))
+
+	// 非受信 CA 签发的吊销列表
+	other := newTestCA(t)
+	_, err = loadRevocationList(writeFile(t, dir, "other.crl", other.crlPEM(t, 3)), []*x509.Certificate{ca.cert}, now)
+	assert.Error(t, err)
+
+	// 已经超过 NextUpdate 的吊销列表
+	stale := ca.crlPEMWithNextUpdate(t, now.Add(-time.Minute), 3)
+	_, err = loadRevocationList(writeFile(t, dir, "stale.crl", stale), []*x509.Certificate{ca.cert}, now)
+	assert.Error(t, err)
+}
+
+func TestCertHolder_Reload(t *testing.T) {
+	ca := newTestCA(t)
+	info := newTestTLSInfo(t, ca)
+	holder, err := newCertHolder(info)
+	assert.NoError(t, err)
+
+	certPEM, _ := ca.issue(t, 7, clientTemplate("order"))
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	assert.NoError(t, holder.verifyConnection(state))
+
+	// 吊销证书并更新吊销列表文件，再次检查时应当重新加载
+	assert.NoError(t, os.WriteFile(info.CRLFile, ca.crlPEM(t, 3, 7), 0600))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(info.CRLFile, future, future))
+	holder.maybeReload(time.Now().Add(defaultReloadInterval))
+	assert.ErrorIs(t, holder.verifyConnection(state), ErrClientCertRevoked)
+
+	// 文件内容损坏时保留当前生效的数据
+	assert.NoError(t, os.WriteFile(info.CRLFile, []byte("broken"), 0600))
+	future = future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(info.CRLFile, future, future))
+	holder.maybeReload(time.Now().Add(2 * defaultReloadInterval))
+	assert.ErrorIs(t, holder.verifyConnection(state), ErrClientCertRevoked)
+}
+
+func TestPeerIdentity_Workload(t *testing.T) {
+	namespace, service, ok := (&PeerIdentity{SpiffeID: "spiffe://cluster.local/ns/default/sa/order"}).Workload()
+	assert.True(t, ok)
+	assert.Equal(t, "default", namespace)
+	assert.Equal(t, "order", service)
+
+	for _, identity := range []*PeerIdentity{
+		nil,
+		{CommonName: "order"},
+		{SpiffeID: "spiffe://cluster.local/order"},
+		{SpiffeID: "spiffe://cluster.local/ns/default/sa/"},
+		{SpiffeID: "spiffe://cluster.local/ns/default/sa/order/extra"},
+	} {
+		_, _, ok := identity.Workload()
+		assert.False(t, ok)
+	}
+}
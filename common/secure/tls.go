@@ -19,6 +19,7 @@ package secure
 
 import (
 	"crypto/tls"
+	"time"
 )
 
 // TLSInfo tls 配置信息
@@ -36,7 +37,7 @@ type TLSInfo struct {
 	TrustedCAFile string
 	// ClientCertAuth 是否启用客户端证书
 	ClientCertAuth bool
-	// CRLFile 客户端证书吊销列表
+	// CRLFile 客户端证书吊销列表，需要由 TrustedCAFile 中的 CA 签发，超过 NextUpdate 后拒绝所有客户端证书
 	CRLFile string
 
 	// InsecureSkipVerify tls 的一个配置
@@ -62,6 +63,9 @@ type TLSInfo struct {
 	// AllowedHostname 必须与 TLS 匹配的 IP 地址或主机名
 	// 是由客户端提供的证书
 	AllowedHostname string
+
+	// ReloadInterval 检查证书、CA 以及吊销列表文件是否变更的间隔
+	ReloadInterval time.Duration
 }
 
 // IsEmpty 检查 tls 配置信息是否为空 当证书和密钥同时存在时才不为空
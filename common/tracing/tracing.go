@@ -0,0 +1,128 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TracerName polaris 内部埋点使用的 tracer 名称
+	TracerName = "github.com/polarismesh/polaris"
+	// maxStatementLength span 中记录的数据库语句的最大长度
+	maxStatementLength = 1024
+)
+
+// Tracer 获取全局的 tracer，未注册 TracerProvider 时返回的 span 不会产生任何开销以外的效果
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan 以 ctx 中的 span 为父节点创建 span
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServerSpan 创建接入层处理请求的 span
+func StartServerSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// StartStoreSpan 创建访问存储的 span，存储接口不传递请求的上下文，span 作为新的 trace 的根节点按照采样率采样
+func StartStoreSpan(system, operation string, attrs ...attribute.KeyValue) trace.Span {
+	attrs = append(attrs, attribute.String("db.system", system), attribute.String("db.operation", operation))
+	_, span := Tracer().Start(context.Background(), system+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return span
+}
+
+// Statement 数据库语句的属性，过长的语句会被截断，避免批量写入的语句占用过多的导出带宽
+func Statement(query string) attribute.KeyValue {
+	if len(query) > maxStatementLength {
+		query = query[:maxStatementLength] + "..."
+	}
+	return attribute.String("db.statement", query)
+}
+
+// EndSpan 结束 span，err 不为空时记录错误
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ContextWithSpanFrom 将 src 中的 span 传递到 dst 中，用于接入层重新构造上下文的场景
+func ContextWithSpanFrom(dst, src context.Context) context.Context {
+	if src == nil {
+		return dst
+	}
+	span := trace.SpanFromContext(src)
+	if !span.SpanContext().IsValid() {
+		return dst
+	}
+	return trace.ContextWithSpan(dst, span)
+}
+
+// ExtractGRPC 从 gRPC 请求的 metadata 中解析调用方传递的 trace 上下文
+func ExtractGRPC(ctx context.Context, md metadata.MD) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// ExtractHTTP 从 HTTP 请求头中解析调用方传递的 trace 上下文
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// metadataCarrier 适配 gRPC metadata 的 propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+// Get 获取 key 对应的第一个值
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set 设置 key 对应的值
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+// Keys 获取全部的 key
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
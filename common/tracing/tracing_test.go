@@ -0,0 +1,112 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+func TestExtract(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	ctx := ExtractGRPC(context.Background(), metadata.Pairs("traceparent", traceParent))
+	_, span := StartServerSpan(ctx, "/v1.PolarisGRPC/RegisterInstance")
+	EndSpan(span, nil)
+
+	header := http.Header{}
+	header.Set("Traceparent", traceParent)
+	ctx = ExtractHTTP(context.Background(), header)
+	_, span = StartServerSpan(ctx, "POST:/naming/v1/instances")
+	EndSpan(span, errors.New("store layer exception"))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	}
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Len(t, spans[1].Events(), 1)
+}
+
+func TestContextWithSpanFrom(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	src, parent := StartServerSpan(context.Background(), "parent")
+	// 接入层重新构造的上下文继承原有的 span
+	dst := ContextWithSpanFrom(context.Background(), src)
+	_, child := StartSpan(dst, "child")
+	child.End()
+	parent.End()
+
+	assert.Equal(t, context.Background(), ContextWithSpanFrom(context.Background(), context.Background()))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestStartStoreSpan(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	span := StartStoreSpan("mysql", "exec", Statement(strings.Repeat("?", maxStatementLength+1)))
+	EndSpan(span, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "mysql.exec", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.False(t, spans[0].Parent().IsValid())
+	attrs := map[attribute.Key]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[attr.Key] = attr.Value.AsString()
+	}
+	assert.Equal(t, "mysql", attrs["db.system"])
+	assert.Equal(t, "exec", attrs["db.operation"])
+	assert.Len(t, attrs["db.statement"], maxStatementLength+len("..."))
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
@@ -329,6 +330,31 @@ func ParseClientAddress(ctx context.Context) string {
 	return rid
 }
 
+// ParseClientIP 从ctx中获取客户端IP，未直接携带时从客户端地址中解析
+func ParseClientIP(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ip, _ := ctx.Value(StringContext("client-ip")).(string); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(ParseClientAddress(ctx))
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// ParseSourceService 从ctx中获取主调方声明的来源服务
+func ParseSourceService(ctx context.Context) (string, string) {
+	if ctx == nil {
+		return "", ""
+	}
+	service, _ := ctx.Value(ContextSourceServiceKey).(string)
+	namespace, _ := ctx.Value(ContextSourceNamespaceKey).(string)
+	return service, namespace
+}
+
 // ParseAuthToken 从ctx中获取token
 func ParseAuthToken(ctx context.Context) string {
 	if ctx == nil {
@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"context"
 	"testing"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
@@ -57,3 +58,29 @@ func TestCheckResourceName(t *testing.T) {
 		})
 	}
 }
+
+// TestParseClientIP tests the ParseClientIP function
+func TestParseClientIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "client ip", ctx: context.WithValue(context.Background(), StringContext("client-ip"), "10.0.0.1"),
+			want: "10.0.0.1"},
+		{name: "ipv4 address", ctx: context.WithValue(context.Background(), ContextClientAddress, "10.0.0.2:8080"),
+			want: "10.0.0.2"},
+		{name: "ipv6 address", ctx: context.WithValue(context.Background(), ContextClientAddress, "[fd00::1]:8080"),
+			want: "fd00::1"},
+		{name: "invalid address", ctx: context.WithValue(context.Background(), ContextClientAddress, "10.0.0.3"),
+			want: ""},
+		{name: "empty", ctx: context.Background(), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseClientIP(tt.ctx); got != tt.want {
+				t.Errorf("ParseClientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
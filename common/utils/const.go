@@ -44,6 +44,10 @@ const (
 	HeaderOwnerIDKey string = "X-Owner-ID"
 	// HeaderUserRoleKey user role key
 	HeaderUserRoleKey string = "X-Polaris-User-Role"
+	// HeaderSourceServiceKey caller service name key
+	HeaderSourceServiceKey string = "X-Polaris-Source-Service"
+	// HeaderSourceNamespaceKey caller service namespace key
+	HeaderSourceNamespaceKey string = "X-Polaris-Source-Namespace"
 
 	// ContextAuthTokenKey auth token key
 	ContextAuthTokenKey = StringContext(HeaderAuthTokenKey)
@@ -71,6 +75,12 @@ const (
 	ContextIsFromSystem = StringContext("from-system")
 	// ContextOperator operator info
 	ContextOperator = StringContext("operator")
+	// ContextClientCertIdentity client certificate identity
+	ContextClientCertIdentity = StringContext("client-cert-identity")
+	// ContextSourceServiceKey caller service name
+	ContextSourceServiceKey = StringContext(HeaderSourceServiceKey)
+	// ContextSourceNamespaceKey caller service namespace
+	ContextSourceNamespaceKey = StringContext(HeaderSourceNamespaceKey)
 )
 
 const (
@@ -19,6 +19,7 @@ package config
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
@@ -47,6 +48,7 @@ import (
 	_ "github.com/polarismesh/polaris/store/boltdb"
 	_ "github.com/polarismesh/polaris/store/mysql"
 	sqldb "github.com/polarismesh/polaris/store/mysql"
+	"github.com/polarismesh/polaris/store/postgres"
 	testdata "github.com/polarismesh/polaris/test/data"
 )
 
@@ -148,9 +150,9 @@ func (c *ConfigCenterTest) loadBootstrapConfig() error {
 	c.defaultCtx = context.WithValue(c.defaultCtx, utils.StringContext("request-id"), "config-test-request-id")
 	c.defaultCtx = context.WithValue(c.defaultCtx, utils.ContextUserNameKey, "polaris")
 
-	if os.Getenv("STORE_MODE") == "sqldb" {
-		fmt.Printf("run store mode : sqldb\n")
-		confFileName = testdata.Path("config_test_sqldb.yaml")
+	if mode := os.Getenv("STORE_MODE"); mode == "sqldb" || mode == "postgres" {
+		fmt.Printf("run store mode : %s\n", mode)
+		confFileName = testdata.Path("config_test_" + mode + ".yaml")
 		c.defaultCtx = context.WithValue(c.defaultCtx, utils.ContextAuthTokenKey, "nu/0WRA4EqSR1FagrjRj0fZwPXuGlMpX+zCuWu4uMqy8xr1vRjisSbA25aAC3mtU8MeeRsKhQiDAynUR09I=")
 	} else {
 		c.defaultCtx = context.WithValue(c.defaultCtx, utils.ContextAuthTokenKey, "nu/0WRA4EqSR1FagrjRj0fZwPXuGlMpX+zCuWu4uMqy8xr1vRjisSbA25aAC3mtU8MeeRsKhQiDAynUR09I=")
@@ -180,7 +182,7 @@ func (c *ConfigCenterTest) clearTestData() error {
 		time.Sleep(5 * time.Second)
 	}()
 
-	if c.storage.Name() == sqldb.STORENAME {
+	if c.storage.Name() == sqldb.STORENAME || c.storage.Name() == postgres.STORENAME {
 		if err := c.clearTestDataWhenUseRDS(); err != nil {
 			return err
 		}
@@ -238,7 +240,11 @@ func (c *ConfigCenterTest) clearTestDataWhenUseRDS() error {
 		return err
 	}
 
-	tx := proxyTx.GetDelegateTx().(*sqldb.BaseTx)
+	tx := proxyTx.GetDelegateTx().(interface {
+		Exec(query string, args ...interface{}) (sql.Result, error)
+		Commit() error
+		Rollback() error
+	})
 
 	defer tx.Rollback()
 
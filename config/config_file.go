@@ -35,12 +35,16 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	utils2 "github.com/polarismesh/polaris/config/utils"
 )
 
 // CreateConfigFile 创建配置文件
 func (s *Server) CreateConfigFile(ctx context.Context, configFile *apiconfig.ConfigFile) *apiconfig.ConfigResponse {
+	ctx, span := tracing.StartSpan(ctx, "config.CreateConfigFile")
+	defer span.End()
+
 	if rsp := s.prepareCreateConfigFile(ctx, configFile); rsp.Code.Value != api.ExecuteSuccess {
 		return rsp
 	}
@@ -323,6 +327,9 @@ func (s *Server) queryConfigFileWithoutTags(ctx context.Context, namespace, grou
 
 // UpdateConfigFile 更新配置文件
 func (s *Server) UpdateConfigFile(ctx context.Context, configFile *apiconfig.ConfigFile) *apiconfig.ConfigResponse {
+	ctx, span := tracing.StartSpan(ctx, "config.UpdateConfigFile")
+	defer span.End()
+
 	if checkRsp := checkConfigFileParams(configFile, false); checkRsp != nil {
 		return checkRsp
 	}
@@ -387,6 +394,9 @@ func (s *Server) UpdateConfigFile(ctx context.Context, configFile *apiconfig.Con
 // DeleteConfigFile 删除配置文件，删除配置文件同时会通知客户端 Not_Found
 func (s *Server) DeleteConfigFile(
 	ctx context.Context, namespace, group, name, deleteBy string) *apiconfig.ConfigResponse {
+	ctx, span := tracing.StartSpan(ctx, "config.DeleteConfigFile")
+	defer span.End()
+
 	if err := utils2.CheckResourceName(utils.NewStringValue(namespace)); err != nil {
 		return api.NewConfigFileResponse(apimodel.Code_InvalidNamespaceName, nil)
 	}
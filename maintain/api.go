@@ -19,11 +19,16 @@ package maintain
 
 import (
 	"context"
+	"io"
+	"time"
 
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/polarismesh/polaris/cache"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/maintain/job"
+	"github.com/polarismesh/polaris/service/healthcheck"
 )
 
 type ConnReq struct {
@@ -47,6 +52,155 @@ type ConnStatsResp struct {
 	Stats           []*connlimit.HostConnStat
 }
 
+// ServiceCacheView 服务实例的缓存视图，也用于和其他节点对比缓存
+type ServiceCacheView struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	ServiceID string `json:"serviceId"`
+	// Revision 服务下全部实例计算得到的 revision
+	Revision string `json:"revision"`
+	// Instances 实例 ID -> 实例 revision
+	Instances map[string]string `json:"instances"`
+}
+
+// CacheDiff 本节点的服务实例缓存与存储或者其他节点之间的差异
+type CacheDiff struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	// Target 对比的目标，store 或者其他节点的地址
+	Target         string `json:"target"`
+	Consistent     bool   `json:"consistent"`
+	LocalRevision  string `json:"localRevision"`
+	TargetRevision string `json:"targetRevision"`
+	LocalCount     int    `json:"localCount"`
+	TargetCount    int    `json:"targetCount"`
+	// OnlyLocal 只存在于本节点缓存中的实例
+	OnlyLocal []string `json:"onlyLocal"`
+	// OnlyTarget 只存在于目标中的实例
+	OnlyTarget []string `json:"onlyTarget"`
+	// Changed 两边都存在但是 revision 不一致的实例
+	Changed []string `json:"changed"`
+}
+
+// NodeListener 节点上的 apiserver 监听信息
+type NodeListener struct {
+	Protocol string `json:"protocol"`
+	Port     uint32 `json:"port"`
+	// ConnCount 当前连接数，-1 表示该监听没有开启连接限制，无法统计
+	ConnCount int32 `json:"connCount"`
+}
+
+// NodeStatus 节点自身的运行状态
+type NodeStatus struct {
+	Host      string          `json:"host"`
+	Version   string          `json:"version"`
+	Revision  string          `json:"revision"`
+	StartTime time.Time       `json:"startTime"`
+	Uptime    string          `json:"uptime"`
+	Listeners []*NodeListener `json:"listeners"`
+	// Caches 缓存的同步状态
+	Caches []*cache.CacheStatus `json:"caches"`
+	// CheckerLeader 是否为健康检查的 leader 节点
+	CheckerLeader bool `json:"checkerLeader"`
+}
+
+// ClusterNode 集群中的一个节点，基于节点在健康检查服务下自注册的实例
+type ClusterNode struct {
+	Host     string `json:"host"`
+	Version  string `json:"version"`
+	Healthy  bool   `json:"healthy"`
+	Isolated bool   `json:"isolated"`
+	// Listeners 节点自注册的 apiserver 监听
+	Listeners []*NodeListener `json:"listeners"`
+	// CheckerLeader 是否为健康检查的 leader 节点
+	CheckerLeader bool `json:"checkerLeader"`
+	// HashRanges 节点在健康检查一致性 hash 环上负责的区间，以当前节点的视图计算
+	HashRanges []*healthcheck.HashRange `json:"hashRanges"`
+	// HashRatio 节点负责的区间占整个 hash 环的比例
+	HashRatio float64 `json:"hashRatio"`
+	// Status 节点通过运维接口返回的运行状态，获取失败时为空
+	Status *NodeStatus `json:"status,omitempty"`
+	// Error 获取节点运行状态失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigReloadResult 配置文件重新加载的结果
+type ConfigReloadResult struct {
+	// Reloaded 重新加载的配置项，配置文件没有变化时为空
+	Reloaded []string `json:"reloaded"`
+}
+
+// MaintainJobList 当前节点的运维任务以及运行状态，运行时的开关只在当前节点生效
+type MaintainJobList struct {
+	// Paused 全部任务的定时执行是否已经暂停
+	Paused bool             `json:"paused"`
+	Jobs   []*job.JobStatus `json:"jobs"`
+}
+
+// MaintainJobReq 运维任务的操作请求
+type MaintainJobReq struct {
+	Name   string `json:"name"`
+	Enable bool   `json:"enable"`
+}
+
+// MaintainJobPauseReq 暂停或者恢复全部运维任务的请求
+type MaintainJobPauseReq struct {
+	Pause bool `json:"pause"`
+}
+
+// MaintainJobRunsReq 运维任务执行记录的查询请求
+type MaintainJobRunsReq struct {
+	Name   string
+	Offset uint32
+	Limit  uint32
+}
+
+// MaintainJobRunsResp 运维任务的执行记录
+type MaintainJobRunsResp struct {
+	Total uint32                  `json:"total"`
+	Runs  []*model.MaintainJobRun `json:"runs"`
+}
+
+// WhitelistRuleDeleteReq 删除白名单运行时规则的请求
+type WhitelistRuleDeleteReq struct {
+	ID string `json:"id"`
+}
+
+// ServiceDependenciesReq 查询服务依赖关系的请求，条件为空时不参与过滤
+type ServiceDependenciesReq struct {
+	CallerNamespace string
+	CallerService   string
+	CallerHost      string
+	CalleeNamespace string
+	CalleeService   string
+	Offset          uint32
+	Limit           uint32
+}
+
+// ServiceDependenciesResp 服务依赖关系的查询结果
+type ServiceDependenciesResp struct {
+	Total        uint32                     `json:"total"`
+	Dependencies []*model.ServiceDependency `json:"dependencies"`
+}
+
+// InstanceEventsReq 查询实例事件时间线的请求，需要指定服务或者实例，时间范围为 [StartTime, EndTime)
+type InstanceEventsReq struct {
+	Namespace  string
+	Service    string
+	InstanceID string
+	EventTypes []model.InstanceEventType
+	StartTime  time.Time
+	EndTime    time.Time
+	Offset     uint32
+	Limit      uint32
+}
+
+// InstanceEventsResp 实例事件时间线的查询结果，按照事件时间倒序排列
+type InstanceEventsResp struct {
+	Total  uint32                       `json:"total"`
+	Events []*model.InstanceEventRecord `json:"events"`
+}
+
 // MaintainOperateServer Maintain related operation
 type MaintainOperateServer interface {
 	// GetServerConnections Get connection count
@@ -75,4 +229,40 @@ type MaintainOperateServer interface {
 	ReleaseLeaderElection(ctx context.Context, electKey string) error
 	// GetCMDBInfo get cmdb info
 	GetCMDBInfo(ctx context.Context) ([]model.LocationView, error)
+	// GetCacheStatus 获取各个缓存的运行状态
+	GetCacheStatus(ctx context.Context) ([]*cache.CacheStatus, error)
+	// GetServiceCacheView 获取本节点缓存中指定服务的实例视图
+	GetServiceCacheView(ctx context.Context, namespace, service string) (*ServiceCacheView, error)
+	// DiffServiceCache 对比本节点缓存中指定服务的实例与存储，peer 不为空时与集群中 host 为 peer 的节点缓存对比
+	DiffServiceCache(ctx context.Context, namespace, service, peer string) (*CacheDiff, error)
+	// ResyncCache 从存储重新读取指定缓存的全部数据
+	ResyncCache(ctx context.Context, name string) error
+	// GetNodeStatus 获取本节点的运行状态
+	GetNodeStatus(ctx context.Context) (*NodeStatus, error)
+	// ListClusterNodes 获取集群中所有存活的节点以及运行状态
+	ListClusterNodes(ctx context.Context) ([]*ClusterNode, error)
+	// ReloadConfig 重新加载服务端配置文件
+	ReloadConfig(ctx context.Context) (*ConfigReloadResult, error)
+	// ListMaintainJobs 获取当前节点的运维任务以及运行状态
+	ListMaintainJobs(ctx context.Context) (*MaintainJobList, error)
+	// TriggerMaintainJob 在当前节点立即执行一次运维任务
+	TriggerMaintainJob(ctx context.Context, req *MaintainJobReq) (*model.MaintainJobRun, error)
+	// EnableMaintainJob 开启或者关闭运维任务的定时执行
+	EnableMaintainJob(ctx context.Context, req *MaintainJobReq) error
+	// PauseMaintainJobs 暂停或者恢复全部运维任务的定时执行
+	PauseMaintainJobs(ctx context.Context, req *MaintainJobPauseReq) error
+	// GetMaintainJobRuns 查询运维任务的执行记录
+	GetMaintainJobRuns(ctx context.Context, req *MaintainJobRunsReq) (*MaintainJobRunsResp, error)
+	// GetWhitelistRules 查询白名单的运行时规则
+	GetWhitelistRules(ctx context.Context) ([]*model.WhitelistRule, error)
+	// AddWhitelistRule 新增白名单的运行时规则
+	AddWhitelistRule(ctx context.Context, rule *model.WhitelistRule) (*model.WhitelistRule, error)
+	// DeleteWhitelistRule 删除白名单的运行时规则
+	DeleteWhitelistRule(ctx context.Context, req *WhitelistRuleDeleteReq) error
+	// GetServiceDependencies 查询服务依赖关系
+	GetServiceDependencies(ctx context.Context, req *ServiceDependenciesReq) (*ServiceDependenciesResp, error)
+	// GetInstanceEvents 查询服务或者实例的事件时间线
+	GetInstanceEvents(ctx context.Context, req *InstanceEventsReq) (*InstanceEventsResp, error)
+	// BackupStore 以备份文件的格式导出存储中的数据，resources 为空时导出全部资源
+	BackupStore(ctx context.Context, w io.Writer, resources []string) error
 }
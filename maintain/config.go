@@ -22,4 +22,6 @@ import "github.com/polarismesh/polaris/maintain/job"
 // Config maintain configuration
 type Config struct {
 	Jobs []job.JobConfig `yaml:"jobs"`
+	// PeerToken 请求其他节点运维接口时使用的鉴权 token，需要具备运维接口的读权限
+	PeerToken string `yaml:"peerToken"`
 }
@@ -64,16 +64,28 @@ func initialize(_ context.Context, cfg *Config, namingService service.DiscoverSe
 	maintainServer.healthCheckServer = healthCheckServer
 	maintainServer.cacheMgn = cacheMgn
 	maintainServer.storage = storage
+	maintainServer.peerToken = cfg.PeerToken
 
 	maintainJobs := job.NewMaintainJobs(namingService, cacheMgn, storage)
 	if err := maintainJobs.StartMaintianJobs(cfg.Jobs); err != nil {
 		return err
 	}
+	maintainServer.maintainJobs = maintainJobs
 
 	server = newServerAuthAbility(maintainServer, authServer)
 	return nil
 }
 
+// ConfigReloader 重新加载服务端配置文件，返回重新加载的配置项
+type ConfigReloader func() ([]string, error)
+
+var configReloader ConfigReloader
+
+// SetConfigReloader 设置配置文件重新加载的实现，由 bootstrap 在启动完成后注入
+func SetConfigReloader(reloader ConfigReloader) {
+	configReloader = reloader
+}
+
 // GetServer 获取已经初始化好的Server
 func GetServer() (MaintainOperateServer, error) {
 	if !finishInit {
@@ -0,0 +1,127 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// ReleaseHistoryRetentionPolicy 命名空间的配置发布历史保留策略，
+// 发布历史在最新的 KeepCount 条之内或者发布时间在 KeepDuration 之内都会保留，两者都为 0 时不清理
+type ReleaseHistoryRetentionPolicy struct {
+	// Namespace 命名空间，* 表示没有单独配置策略的命名空间
+	Namespace    string        `mapstructure:"namespace"`
+	KeepCount    uint32        `mapstructure:"keepCount"`
+	KeepDuration time.Duration `mapstructure:"keepDuration"`
+}
+
+type CleanConfigFileReleaseHistoryJobConfig struct {
+	BatchSize uint32                           `mapstructure:"batchSize"`
+	Policies  []*ReleaseHistoryRetentionPolicy `mapstructure:"policies"`
+}
+
+type cleanConfigFileReleaseHistoryJob struct {
+	cfg     *CleanConfigFileReleaseHistoryJobConfig
+	storage store.Store
+}
+
+func (job *cleanConfigFileReleaseHistoryJob) init(raw map[string]interface{}) error {
+	cfg := &CleanConfigFileReleaseHistoryJobConfig{}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanConfigFileReleaseHistory] new config decoder err: %v", err)
+		return err
+	}
+	err = decoder.Decode(raw)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanConfigFileReleaseHistory] parse config err: %v", err)
+		return err
+	}
+	if err := checkPolicyNamespaces(cfg.namespaces()); err != nil {
+		log.Errorf("[Maintain][Job][CleanConfigFileReleaseHistory] check config err: %v", err)
+		return err
+	}
+	if _, err := getRetentionStore(job.storage); err != nil {
+		return err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultRetentionBatchSize
+	}
+	job.cfg = cfg
+	return nil
+}
+
+func (cfg *CleanConfigFileReleaseHistoryJobConfig) namespaces() []string {
+	namespaces := make([]string, 0, len(cfg.Policies))
+	for _, policy := range cfg.Policies {
+		namespaces = append(namespaces, policy.Namespace)
+	}
+	return namespaces
+}
+
+func (job *cleanConfigFileReleaseHistoryJob) execute() (*jobResult, error) {
+	retentionStore, err := getRetentionStore(job.storage)
+	if err != nil {
+		return nil, err
+	}
+	result := &jobResult{}
+	var total uint32
+	namespaces := job.cfg.namespaces()
+	for _, policy := range job.cfg.Policies {
+		if policy.KeepCount == 0 && policy.KeepDuration == 0 {
+			continue
+		}
+		// 最新的一条发布历史总是保留
+		keepCount := policy.KeepCount
+		if keepCount == 0 {
+			keepCount = 1
+		}
+		namespace, excludes := policyScope(policy.Namespace, namespaces)
+		before := time.Now().Add(-policy.KeepDuration)
+		count, err := cleanInBatches(job.cfg.BatchSize, func(batchSize uint32) (uint32, error) {
+			return retentionStore.BatchCleanConfigFileReleaseHistories(namespace, excludes, keepCount, before,
+				batchSize)
+		})
+		total += count
+		if count > 0 {
+			result.affected = append(result.affected, fmt.Sprintf("%s:%d", policy.Namespace, count))
+		}
+		log.Infof("[Maintain][Job][CleanConfigFileReleaseHistory] namespace(%s) clean release history count %d",
+			policy.Namespace, count)
+		if err != nil {
+			log.Errorf("[Maintain][Job][CleanConfigFileReleaseHistory] namespace(%s) clean release history, "+
+				"err: %v", policy.Namespace, err)
+			result.message = fmt.Sprintf("clean config file release history count %d", total)
+			return result, err
+		}
+	}
+	result.message = fmt.Sprintf("clean config file release history count %d", total)
+	return result, nil
+}
+
+func (job *cleanConfigFileReleaseHistoryJob) clear() {
+}
@@ -18,6 +18,8 @@
 package job
 
 import (
+	"fmt"
+
 	"github.com/polarismesh/polaris/store"
 )
 
@@ -29,21 +31,24 @@ func (job *cleanDeletedInstancesJob) init(raw map[string]interface{}) error {
 	return nil
 }
 
-func (job *cleanDeletedInstancesJob) execute() {
+func (job *cleanDeletedInstancesJob) execute() (*jobResult, error) {
 	batchSize := uint32(100)
+	total := uint32(0)
 	for {
 		count, err := job.storage.BatchCleanDeletedInstances(batchSize)
 		if err != nil {
 			log.Errorf("[Maintain][Job][CleanDeletedInstances] batch clean deleted instance, err: %v", err)
-			break
+			return &jobResult{message: fmt.Sprintf("clean deleted instance count %d", total)}, err
 		}
 
 		log.Infof("[Maintain][Job][CleanDeletedInstances] clean deleted instance count %d", count)
+		total += count
 
 		if count < batchSize {
 			break
 		}
 	}
+	return &jobResult{message: fmt.Sprintf("clean deleted instance count %d", total)}, nil
 }
 
 func (job *cleanDeletedInstancesJob) clear() {
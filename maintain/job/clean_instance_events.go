@@ -0,0 +1,109 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// CleanInstanceEventsJobConfig 实例事件保留策略，事件时间超过 KeepDuration 或者不在最新的 KeepCount 条之内都会被清理，
+// 两者都为 0 时不清理
+type CleanInstanceEventsJobConfig struct {
+	KeepDuration time.Duration `mapstructure:"keepDuration"`
+	KeepCount    uint32        `mapstructure:"keepCount"`
+	BatchSize    uint32        `mapstructure:"batchSize"`
+}
+
+type cleanInstanceEventsJob struct {
+	cfg     *CleanInstanceEventsJobConfig
+	storage store.Store
+}
+
+func (job *cleanInstanceEventsJob) init(raw map[string]interface{}) error {
+	cfg := &CleanInstanceEventsJobConfig{}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanInstanceEvents] new config decoder err: %v", err)
+		return err
+	}
+	err = decoder.Decode(raw)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanInstanceEvents] parse config err: %v", err)
+		return err
+	}
+	if cfg.KeepDuration < 0 {
+		log.Errorf("[Maintain][Job][CleanInstanceEvents] keepDuration is negative")
+		return errors.New("keepDuration is negative")
+	}
+	if _, err := getInstanceEventStore(job.storage); err != nil {
+		return err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultRetentionBatchSize
+	}
+	job.cfg = cfg
+	return nil
+}
+
+func (job *cleanInstanceEventsJob) execute() (*jobResult, error) {
+	eventStore, err := getInstanceEventStore(job.storage)
+	if err != nil {
+		return nil, err
+	}
+	result := &jobResult{}
+	if job.cfg.KeepDuration == 0 && job.cfg.KeepCount == 0 {
+		result.message = "clean instance events count 0"
+		return result, nil
+	}
+
+	var before time.Time
+	if job.cfg.KeepDuration > 0 {
+		before = time.Now().Add(-job.cfg.KeepDuration)
+	}
+	count, err := cleanInBatches(job.cfg.BatchSize, func(batchSize uint32) (uint32, error) {
+		return eventStore.BatchCleanInstanceEvents(before, job.cfg.KeepCount, batchSize)
+	})
+	result.message = fmt.Sprintf("clean instance events count %d", count)
+	log.Infof("[Maintain][Job][CleanInstanceEvents] clean instance events count %d", count)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanInstanceEvents] clean instance events err: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (job *cleanInstanceEventsJob) clear() {
+}
+
+func getInstanceEventStore(storage store.Store) (store.InstanceEventStore, error) {
+	eventStore, ok := storage.(store.InstanceEventStore)
+	if !ok {
+		return nil, errors.New("store not support instance events")
+	}
+	return eventStore, nil
+}
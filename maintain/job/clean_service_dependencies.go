@@ -0,0 +1,99 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// CleanServiceDependenciesJobConfig 服务依赖关系保留策略，最近发现时间超过 KeepDuration 的依赖关系会被清理
+type CleanServiceDependenciesJobConfig struct {
+	KeepDuration time.Duration `mapstructure:"keepDuration"`
+	BatchSize    uint32        `mapstructure:"batchSize"`
+}
+
+type cleanServiceDependenciesJob struct {
+	cfg     *CleanServiceDependenciesJobConfig
+	storage store.Store
+}
+
+func (job *cleanServiceDependenciesJob) init(raw map[string]interface{}) error {
+	cfg := &CleanServiceDependenciesJobConfig{}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanServiceDependencies] new config decoder err: %v", err)
+		return err
+	}
+	err = decoder.Decode(raw)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanServiceDependencies] parse config err: %v", err)
+		return err
+	}
+	if cfg.KeepDuration <= 0 {
+		log.Errorf("[Maintain][Job][CleanServiceDependencies] keepDuration must be positive")
+		return errors.New("keepDuration must be positive")
+	}
+	if _, err := getDependencyStore(job.storage); err != nil {
+		return err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultRetentionBatchSize
+	}
+	job.cfg = cfg
+	return nil
+}
+
+func (job *cleanServiceDependenciesJob) execute() (*jobResult, error) {
+	dependencyStore, err := getDependencyStore(job.storage)
+	if err != nil {
+		return nil, err
+	}
+	result := &jobResult{}
+	before := time.Now().Add(-job.cfg.KeepDuration)
+	count, err := cleanInBatches(job.cfg.BatchSize, func(batchSize uint32) (uint32, error) {
+		return dependencyStore.BatchCleanServiceDependencies(before, batchSize)
+	})
+	result.message = fmt.Sprintf("clean service dependencies count %d", count)
+	log.Infof("[Maintain][Job][CleanServiceDependencies] clean service dependencies count %d", count)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanServiceDependencies] clean service dependencies err: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (job *cleanServiceDependenciesJob) clear() {
+}
+
+func getDependencyStore(storage store.Store) (store.DependencyStore, error) {
+	dependencyStore, ok := storage.(store.DependencyStore)
+	if !ok {
+		return nil, errors.New("store not support service dependencies")
+	}
+	return dependencyStore, nil
+}
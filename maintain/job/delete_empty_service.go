@@ -18,6 +18,7 @@
 package job
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -64,11 +65,15 @@ func (job *deleteEmptyAutoCreatedServiceJob) init(raw map[string]interface{}) er
 	return nil
 }
 
-func (job *deleteEmptyAutoCreatedServiceJob) execute() {
-	err := job.deleteEmptyAutoCreatedServices()
+func (job *deleteEmptyAutoCreatedServiceJob) execute() (*jobResult, error) {
+	deleted, err := job.deleteEmptyAutoCreatedServices()
 	if err != nil {
 		log.Errorf("[Maintain][Job][DeleteEmptyAutoCreatedService] delete empty autocreated services, err: %v", err)
 	}
+	return &jobResult{
+		message:  fmt.Sprintf("delete empty auto-created services count %d", len(deleted)),
+		affected: deleted,
+	}, err
 }
 
 func (job *deleteEmptyAutoCreatedServiceJob) clear() {
@@ -120,9 +125,11 @@ func (job *deleteEmptyAutoCreatedServiceJob) filterToDeletedServices(services []
 	return toDeleteServices
 }
 
-func (job *deleteEmptyAutoCreatedServiceJob) deleteEmptyAutoCreatedServices() error {
+// deleteEmptyAutoCreatedServices 删除空的自动创建服务，返回删除成功的服务，格式为 namespace/name
+func (job *deleteEmptyAutoCreatedServiceJob) deleteEmptyAutoCreatedServices() ([]string, error) {
 	emptyServices := job.getEmptyAutoCreatedServices()
 
+	var deleted []string
 	deleteBatchSize := 100
 	for i := 0; i < len(emptyServices); i += deleteBatchSize {
 		j := i + deleteBatchSize
@@ -133,18 +140,22 @@ func (job *deleteEmptyAutoCreatedServiceJob) deleteEmptyAutoCreatedServices() er
 		ctx, err := buildContext(job.storage)
 		if err != nil {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] build conetxt, err: %v", err)
-			return err
+			return deleted, err
 		}
 		resp := job.namingServer.DeleteServices(ctx, convertDeleteServiceRequest(emptyServices[i:j]))
 		if api.CalcCode(resp) != 200 {
 			log.Errorf("[Maintain][Job][DeleteEmptyAutoCreatedService] delete services err, code: %d, info: %s",
 				resp.Code.GetValue(), resp.Info.GetValue())
+			continue
+		}
+		for _, svc := range emptyServices[i:j] {
+			deleted = append(deleted, svc.Namespace+"/"+svc.Name)
 		}
 	}
 
 	log.Infof("[Maintain][Job][DeleteEmptyAutoCreatedService] delete empty auto-created services count %d",
-		len(emptyServices))
-	return nil
+		len(deleted))
+	return deleted, nil
 }
 
 func convertDeleteServiceRequest(infos []*model.Service) []*apiservice.Service {
@@ -18,6 +18,7 @@
 package job
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -60,14 +61,20 @@ func (job *deleteUnHealthyInstanceJob) init(raw map[string]interface{}) error {
 	return nil
 }
 
-func (job *deleteUnHealthyInstanceJob) execute() {
+func (job *deleteUnHealthyInstanceJob) execute() (*jobResult, error) {
 	batchSize := uint32(100)
-	var count int = 0
+	var deleted []string
+	result := func() *jobResult {
+		return &jobResult{
+			message:  fmt.Sprintf("delete unhealthy instance count %d", len(deleted)),
+			affected: deleted,
+		}
+	}
 	for {
 		instanceIds, err := job.storage.GetUnHealthyInstances(job.cfg.InstanceDeleteTimeout, batchSize)
 		if err != nil {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] get unhealthy instances, err: %v", err)
-			break
+			return result(), err
 		}
 		if len(instanceIds) == 0 {
 			break
@@ -81,22 +88,21 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 		ctx, err := buildContext(job.storage)
 		if err != nil {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] build conetxt, err: %v", err)
-			return
+			return result(), err
 		}
 		resp := job.namingServer.DeleteInstances(ctx, req)
-		if api.CalcCode(resp) == 200 {
-			log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete instance count %d, list: %v",
-				len(instanceIds), instanceIds)
-		} else {
+		if api.CalcCode(resp) != 200 {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] delete instance list: %v, err: %d %s",
 				instanceIds, resp.Code.GetValue(), resp.Info.GetValue())
-			break
+			return result(), fmt.Errorf("delete instances err: %d %s", resp.Code.GetValue(), resp.Info.GetValue())
 		}
-		count += len(instanceIds)
+		log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete instance count %d, list: %v",
+			len(instanceIds), instanceIds)
+		deleted = append(deleted, instanceIds...)
 	}
 
-	log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete unhealthy instance count %d", count)
-
+	log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete unhealthy instance count %d", len(deleted))
+	return result(), nil
 }
 
 func (job *deleteUnHealthyInstanceJob) clear() {
@@ -19,12 +19,17 @@ package job
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/polarismesh/polaris/cache"
 	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/store"
@@ -32,12 +37,56 @@ import (
 
 var log = commonlog.GetScopeOrDefaultByName(commonlog.DefaultLoggerName)
 
+var (
+	// ErrJobNotConfigured 任务没有在配置文件中配置
+	ErrJobNotConfigured = errors.New("job not configured")
+	// ErrJobRunning 任务正在执行
+	ErrJobRunning = errors.New("job is running")
+	// ErrJobRunsNotSupported 存储插件不支持保存执行记录
+	ErrJobRunsNotSupported = errors.New("store not support maintain job runs")
+	// ErrJobNotLeader 任务由其他节点调度执行
+	ErrJobNotLeader = errors.New("job is scheduled by another node")
+)
+
+const (
+	// maxRunAffected 执行记录中保存的影响资源的最大数量，超出的部分只记录数量
+	maxRunAffected = 1000
+	// keepJobRuns 每个任务保留的执行记录数量，每次执行结束后清理更早的记录
+	keepJobRuns = 100
+)
+
+// JobStatus 运维任务的配置以及运行状态
+type JobStatus struct {
+	Name     string                 `json:"name"`
+	CronSpec string                 `json:"cronSpec"`
+	Enable   bool                   `json:"enable"`
+	Running  bool                   `json:"running"`
+	Leader   bool                   `json:"leader"`
+	Option   map[string]interface{} `json:"option"`
+}
+
+// jobState 配置文件中的任务以及运行时状态，运行时的开关只在当前节点生效，重启后以配置文件为准
+type jobState struct {
+	cfg JobConfig
+	job maintainJob
+	// inited 任务是否已经初始化
+	inited bool
+	// started 任务是否已经参与选主并加入定时调度
+	started bool
+	enabled bool
+	running bool
+}
+
 // MaintainJobs
 type MaintainJobs struct {
-	jobs        map[string]maintainJob
-	startedJobs map[string]maintainJob
-	scheduler   *cron.Cron
-	storage     store.Store
+	jobs      map[string]maintainJob
+	scheduler *cron.Cron
+	storage   store.Store
+
+	lock   sync.Mutex
+	states map[string]*jobState
+	// paused 暂停全部任务的定时执行，不影响手动触发
+	paused bool
 }
 
 // NewMaintainJobs
@@ -51,44 +100,47 @@ func NewMaintainJobs(namingServer service.DiscoverServer, cacheMgn *cache.CacheM
 				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
 			"CleanDeletedInstances": &cleanDeletedInstancesJob{
 				storage: storage},
+			"CleanConfigFileReleaseHistory": &cleanConfigFileReleaseHistoryJob{
+				storage: storage},
+			"PurgeDeletedRecords": &purgeDeletedRecordsJob{
+				cacheMgn: cacheMgn, storage: storage},
+			"CleanInstanceEvents": &cleanInstanceEventsJob{
+				storage: storage},
+			"CleanServiceDependencies": &cleanServiceDependenciesJob{
+				storage: storage},
 		},
-		startedJobs: map[string]maintainJob{},
-		scheduler:   newCron(),
-		storage:     storage,
+		scheduler: newCron(),
+		storage:   storage,
+		states:    map[string]*jobState{},
 	}
 }
 
 // StartMaintainJobs
 func (mj *MaintainJobs) StartMaintianJobs(configs []JobConfig) error {
+	mj.lock.Lock()
+	defer mj.lock.Unlock()
+
 	for _, cfg := range configs {
+		job, ok := mj.jobs[cfg.Name]
 		if !cfg.Enable {
 			log.Infof("[Maintain][Job] job (%s) not enable", cfg.Name)
+			// 未开启的任务同样记录下来，可以在运行时开启
+			if _, exist := mj.states[cfg.Name]; ok && !exist {
+				mj.states[cfg.Name] = &jobState{cfg: cfg, job: job}
+			}
 			continue
 		}
-		job, ok := mj.jobs[cfg.Name]
 		if !ok {
 			return fmt.Errorf("[Maintain][Job] job (%s) not exist", cfg.Name)
 		}
-		_, ok = mj.startedJobs[cfg.Name]
-		if ok {
+		if state, exist := mj.states[cfg.Name]; exist && state.started {
 			return fmt.Errorf("[Maintain][Job] job (%s) duplicated", cfg.Name)
 		}
-		err := job.init(cfg.Option)
-		if err != nil {
-			log.Errorf("[Maintain][Job] job (%s) fail to init, err: %v", cfg.Name, err)
-			return fmt.Errorf("[Maintain][Job] job (%s) fail to init", cfg.Name)
-		}
-		err = mj.storage.StartLeaderElection(store.ElectionKeyMaintainJobPrefix + cfg.Name)
-		if err != nil {
-			log.Errorf("[Maintain][Job][%s] start leader election err: %v", cfg.Name, err)
+		state := &jobState{cfg: cfg, job: job, enabled: true}
+		mj.states[cfg.Name] = state
+		if err := mj.startJob(state); err != nil {
 			return err
 		}
-		_, err = mj.scheduler.AddFunc(cfg.CronSpec, newCronCmd(cfg.Name, job, mj.storage))
-		if err != nil {
-			log.Errorf("[Maintain][Job] job (%s) fail to start, err: %v", cfg.Name, err)
-			return fmt.Errorf("[Maintain][Job] job (%s) fail to start", cfg.Name)
-		}
-		mj.startedJobs[cfg.Name] = job
 	}
 	mj.scheduler.Start()
 	return nil
@@ -98,33 +150,270 @@ func (mj *MaintainJobs) StartMaintianJobs(configs []JobConfig) error {
 func (mj *MaintainJobs) StopMaintainJobs() {
 	ctx := mj.scheduler.Stop()
 	<-ctx.Done()
-	mj.startedJobs = map[string]maintainJob{}
+	mj.lock.Lock()
+	mj.states = map[string]*jobState{}
+	mj.lock.Unlock()
 }
 
-func newCron() *cron.Cron {
-	return cron.New(cron.WithChain(
-		cron.Recover(cron.DefaultLogger)),
-		cron.WithParser(cron.NewParser(
-			cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor)))
+// ListJobs 查询配置的任务以及运行状态
+func (mj *MaintainJobs) ListJobs() []*JobStatus {
+	mj.lock.Lock()
+	defer mj.lock.Unlock()
+
+	jobs := make([]*JobStatus, 0, len(mj.states))
+	for name, state := range mj.states {
+		jobs = append(jobs, &JobStatus{
+			Name:     name,
+			CronSpec: state.cfg.CronSpec,
+			Enable:   state.enabled,
+			Running:  state.running,
+			Leader:   state.started && mj.storage.IsLeader(store.ElectionKeyMaintainJobPrefix+name),
+			Option:   state.cfg.Option,
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
+
+// Paused 全部任务的定时执行是否已经暂停
+func (mj *MaintainJobs) Paused() bool {
+	mj.lock.Lock()
+	defer mj.lock.Unlock()
+	return mj.paused
+}
+
+// PauseJobs 暂停或者恢复全部任务的定时执行
+func (mj *MaintainJobs) PauseJobs(pause bool) {
+	mj.lock.Lock()
+	defer mj.lock.Unlock()
+	mj.paused = pause
+	log.Infof("[Maintain][Job] set jobs paused %v", pause)
 }
 
-func newCronCmd(name string, job maintainJob, storage store.Store) func() {
+// EnableJob 在运行时开启或者关闭任务的定时执行，首次开启配置中未开启的任务时才进行初始化
+func (mj *MaintainJobs) EnableJob(name string, enable bool) error {
+	mj.lock.Lock()
+	defer mj.lock.Unlock()
+
+	state, ok := mj.states[name]
+	if !ok {
+		return ErrJobNotConfigured
+	}
+	if enable && !state.started {
+		if err := mj.startJob(state); err != nil {
+			return err
+		}
+	}
+	state.enabled = enable
+	log.Infof("[Maintain][Job] set job (%s) enable %v", name, enable)
+	return nil
+}
+
+// TriggerJob 在当前节点立即执行一次任务，不受暂停的影响，返回本次执行的记录。
+// 手动触发和定时执行使用同一个选主锁，只有调度该任务的 leader 节点可以触发，避免与定时执行同时进行
+func (mj *MaintainJobs) TriggerJob(ctx context.Context, name string) (*model.MaintainJobRun, error) {
+	mj.lock.Lock()
+	state, ok := mj.states[name]
+	if !ok {
+		mj.lock.Unlock()
+		return nil, ErrJobNotConfigured
+	}
+	if state.running {
+		mj.lock.Unlock()
+		return nil, ErrJobRunning
+	}
+	if err := mj.checkLeader(state); err != nil {
+		mj.lock.Unlock()
+		return nil, err
+	}
+	if err := mj.initJob(state); err != nil {
+		mj.lock.Unlock()
+		return nil, err
+	}
+	state.running = true
+	mj.lock.Unlock()
+
+	run := newJobRun(name, model.MaintainJobTriggerManual, utils.ParseOperator(ctx))
+	mj.addJobRun(run)
+	// 执行过程中会修改执行记录，返回副本给调用方
+	started := *run
+	go func() {
+		defer mj.finishRunning(state)
+		mj.executeJob(state.job, run)
+	}()
+	return &started, nil
+}
+
+// GetJobRuns 查询任务的执行记录，name 为空时查询全部任务
+func (mj *MaintainJobs) GetJobRuns(name string, offset, limit uint32) (uint32, []*model.MaintainJobRun, error) {
+	runStore, ok := mj.storage.(store.MaintainJobStore)
+	if !ok {
+		return 0, nil, ErrJobRunsNotSupported
+	}
+	return runStore.GetMaintainJobRuns(name, offset, limit)
+}
+
+// checkLeader 检查当前节点是否可以执行任务。当前节点参与选主时只有 leader 可以执行，
+// 没有参与选主时，只有集群中没有其他节点调度该任务才可以执行
+func (mj *MaintainJobs) checkLeader(state *jobState) error {
+	key := store.ElectionKeyMaintainJobPrefix + state.cfg.Name
+	if state.started && mj.storage.IsLeader(key) {
+		return nil
+	}
+	elections, err := mj.storage.ListLeaderElections()
+	if err != nil {
+		log.Errorf("[Maintain][Job][%s] list leader elections err: %v", state.cfg.Name, err)
+		return err
+	}
+	leader := ""
+	for _, election := range elections {
+		if election.ElectKey == key {
+			leader = election.Host
+			break
+		}
+	}
+	if state.started || (leader != "" && leader != utils.LocalHost) {
+		return fmt.Errorf("%w, trigger it on the leader %q", ErrJobNotLeader, leader)
+	}
+	return nil
+}
+
+func (mj *MaintainJobs) initJob(state *jobState) error {
+	if state.inited {
+		return nil
+	}
+	if err := state.job.init(state.cfg.Option); err != nil {
+		log.Errorf("[Maintain][Job] job (%s) fail to init, err: %v", state.cfg.Name, err)
+		return fmt.Errorf("[Maintain][Job] job (%s) fail to init", state.cfg.Name)
+	}
+	state.inited = true
+	return nil
+}
+
+func (mj *MaintainJobs) startJob(state *jobState) error {
+	name := state.cfg.Name
+	if err := mj.initJob(state); err != nil {
+		return err
+	}
+	err := mj.storage.StartLeaderElection(store.ElectionKeyMaintainJobPrefix + name)
+	if err != nil {
+		log.Errorf("[Maintain][Job][%s] start leader election err: %v", name, err)
+		return err
+	}
+	_, err = mj.scheduler.AddFunc(state.cfg.CronSpec, mj.newCronCmd(state))
+	if err != nil {
+		log.Errorf("[Maintain][Job] job (%s) fail to start, err: %v", name, err)
+		return fmt.Errorf("[Maintain][Job] job (%s) fail to start", name)
+	}
+	state.started = true
+	return nil
+}
+
+func (mj *MaintainJobs) finishRunning(state *jobState) {
+	mj.lock.Lock()
+	state.running = false
+	mj.lock.Unlock()
+}
+
+func (mj *MaintainJobs) newCronCmd(state *jobState) func() {
+	name := state.cfg.Name
 	return func() {
-		if !storage.IsLeader(store.ElectionKeyMaintainJobPrefix + name) {
+		mj.lock.Lock()
+		enabled, paused, running := state.enabled, mj.paused, state.running
+		if !enabled || paused || running {
+			mj.lock.Unlock()
+			log.Infof("[Maintain][Job][%s] skip, enable: %v, paused: %v, running: %v",
+				name, enabled, paused, running)
+			return
+		}
+		state.running = true
+		mj.lock.Unlock()
+		defer mj.finishRunning(state)
+
+		if !mj.storage.IsLeader(store.ElectionKeyMaintainJobPrefix + name) {
 			log.Infof("[Maintain][Job][%s] I am follower", name)
-			job.clear()
+			state.job.clear()
 			return
 		}
 		log.Infof("[Maintain][Job][%s] I am leader, job start", name)
-		job.execute()
+		run := newJobRun(name, model.MaintainJobTriggerCron, "")
+		mj.addJobRun(run)
+		mj.executeJob(state.job, run)
 		log.Infof("[Maintain][Job][%s] I am leader, job end", name)
+	}
+}
+
+// executeJob 执行任务并保存执行结果
+func (mj *MaintainJobs) executeJob(job maintainJob, run *model.MaintainJobRun) {
+	result, err := job.execute()
+	run.EndTime = time.Now()
+	run.Status = model.MaintainJobSuccess
+	if result != nil {
+		run.Message = result.message
+		run.Affected = result.affected
+	}
+	if err != nil {
+		run.Status = model.MaintainJobFailed
+		run.Message = err.Error()
+	}
+	if len(run.Affected) > maxRunAffected {
+		run.Message = fmt.Sprintf("%s (%d resources affected, only the first %d are recorded)",
+			run.Message, len(run.Affected), maxRunAffected)
+		run.Affected = run.Affected[:maxRunAffected]
+	}
+	runStore, ok := mj.storage.(store.MaintainJobStore)
+	if !ok {
+		return
+	}
+	if err := runStore.UpdateMaintainJobRun(run); err != nil {
+		log.Errorf("[Maintain][Job][%s] update job run err: %v", run.Name, err)
+	}
+	if cleaned, err := runStore.CleanMaintainJobRuns(run.Name, keepJobRuns); err != nil {
+		log.Errorf("[Maintain][Job][%s] clean job runs err: %v", run.Name, err)
+	} else if cleaned > 0 {
+		log.Infof("[Maintain][Job][%s] clean %d job runs", run.Name, cleaned)
+	}
+}
+
+func (mj *MaintainJobs) addJobRun(run *model.MaintainJobRun) {
+	if runStore, ok := mj.storage.(store.MaintainJobStore); ok {
+		if err := runStore.AddMaintainJobRun(run); err != nil {
+			log.Errorf("[Maintain][Job][%s] add job run err: %v", run.Name, err)
+		}
+	}
+}
 
+func newJobRun(name, trigger, operator string) *model.MaintainJobRun {
+	return &model.MaintainJobRun{
+		ID:        utils.NewUUID(),
+		Name:      name,
+		Trigger:   trigger,
+		Operator:  operator,
+		Host:      utils.LocalHost,
+		Status:    model.MaintainJobRunning,
+		StartTime: time.Now(),
 	}
 }
 
+func newCron() *cron.Cron {
+	return cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger)),
+		cron.WithParser(cron.NewParser(
+			cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor)))
+}
+
+// jobResult 任务一次执行的结果
+type jobResult struct {
+	message string
+	// affected 本次执行影响的资源
+	affected []string
+}
+
 type maintainJob interface {
 	init(cfg map[string]interface{}) error
-	execute()
+	execute() (*jobResult, error)
 	clear()
 }
 
@@ -0,0 +1,227 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+type testJob struct {
+	inited   int
+	release  chan struct{}
+	err      error
+	affected []string
+}
+
+func (job *testJob) init(cfg map[string]interface{}) error {
+	job.inited++
+	return nil
+}
+
+func (job *testJob) execute() (*jobResult, error) {
+	<-job.release
+	return &jobResult{message: "done", affected: job.affected}, job.err
+}
+
+func (job *testJob) clear() {
+}
+
+// testJobStore 在 mock store 的基础上保存任务的执行记录
+type testJobStore struct {
+	*storemock.MockStore
+	lock    sync.Mutex
+	runs    map[string]model.MaintainJobRun
+	cleaned int
+}
+
+func (s *testJobStore) AddMaintainJobRun(run *model.MaintainJobRun) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.runs[run.ID] = *run
+	return nil
+}
+
+func (s *testJobStore) UpdateMaintainJobRun(run *model.MaintainJobRun) error {
+	return s.AddMaintainJobRun(run)
+}
+
+func (s *testJobStore) GetMaintainJobRuns(name string, offset, limit uint32) (
+	uint32, []*model.MaintainJobRun, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var runs []*model.MaintainJobRun
+	for i := range s.runs {
+		run := s.runs[i]
+		runs = append(runs, &run)
+	}
+	return uint32(len(runs)), runs, nil
+}
+
+func (s *testJobStore) CleanMaintainJobRuns(name string, keepCount uint32) (uint32, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cleaned++
+	return 0, nil
+}
+
+func (s *testJobStore) getRun(id string) model.MaintainJobRun {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.runs[id]
+}
+
+func newTestMaintainJobs(t *testing.T) (*MaintainJobs, *testJob, *testJobStore) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	storage := &testJobStore{MockStore: storemock.NewMockStore(ctrl), runs: map[string]model.MaintainJobRun{}}
+	job := &testJob{release: make(chan struct{}), affected: []string{"ins-1"}}
+	mj := &MaintainJobs{
+		jobs:      map[string]maintainJob{"TestJob": job},
+		scheduler: newCron(),
+		storage:   storage,
+		states:    map[string]*jobState{},
+	}
+	return mj, job, storage
+}
+
+func TestMaintainJobs_EnableAndPause(t *testing.T) {
+	mj, job, storage := newTestMaintainJobs(t)
+	storage.EXPECT().StartLeaderElection(store.ElectionKeyMaintainJobPrefix + "TestJob").Return(nil).Times(1)
+	storage.EXPECT().IsLeader(store.ElectionKeyMaintainJobPrefix + "TestJob").Return(true).AnyTimes()
+
+	assert.NoError(t, mj.StartMaintianJobs([]JobConfig{
+		{Name: "TestJob", Enable: false, CronSpec: "*/1 * * * *"},
+		{Name: "UnknownJob", Enable: false},
+	}))
+	defer mj.StopMaintainJobs()
+
+	jobs := mj.ListJobs()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "TestJob", jobs[0].Name)
+	assert.False(t, jobs[0].Enable)
+	assert.False(t, jobs[0].Leader)
+	assert.Equal(t, 0, job.inited)
+
+	// 运行时开启未开启的任务，只初始化并加入调度一次
+	assert.NoError(t, mj.EnableJob("TestJob", true))
+	assert.NoError(t, mj.EnableJob("TestJob", false))
+	assert.NoError(t, mj.EnableJob("TestJob", true))
+	assert.Equal(t, 1, job.inited)
+	jobs = mj.ListJobs()
+	assert.True(t, jobs[0].Enable)
+	assert.True(t, jobs[0].Leader)
+
+	assert.Equal(t, ErrJobNotConfigured, mj.EnableJob("UnknownJob", true))
+
+	mj.PauseJobs(true)
+	assert.True(t, mj.Paused())
+	// 暂停后定时执行直接跳过
+	mj.newCronCmd(mj.states["TestJob"])()
+	assert.Len(t, storage.runs, 0)
+	mj.PauseJobs(false)
+	assert.False(t, mj.Paused())
+}
+
+func TestMaintainJobs_TriggerJob(t *testing.T) {
+	mj, job, storage := newTestMaintainJobs(t)
+	storage.EXPECT().ListLeaderElections().Return(nil, nil).AnyTimes()
+	assert.NoError(t, mj.StartMaintianJobs([]JobConfig{{Name: "TestJob", Enable: false}}))
+	defer mj.StopMaintainJobs()
+
+	_, err := mj.TriggerJob(context.Background(), "UnknownJob")
+	assert.Equal(t, ErrJobNotConfigured, err)
+
+	run, err := mj.TriggerJob(context.Background(), "TestJob")
+	assert.NoError(t, err)
+	assert.Equal(t, model.MaintainJobTriggerManual, run.Trigger)
+	assert.Equal(t, model.MaintainJobRunning, storage.getRun(run.ID).Status)
+	assert.True(t, mj.ListJobs()[0].Running)
+
+	// 同一个任务同时只能执行一次
+	_, err = mj.TriggerJob(context.Background(), "TestJob")
+	assert.Equal(t, ErrJobRunning, err)
+
+	job.err = errors.New("mock error")
+	close(job.release)
+	assert.Eventually(t, func() bool {
+		return storage.getRun(run.ID).Status == model.MaintainJobFailed
+	}, time.Second, 10*time.Millisecond)
+	saved := storage.getRun(run.ID)
+	assert.Equal(t, "mock error", saved.Message)
+	assert.Equal(t, []string{"ins-1"}, saved.Affected)
+	assert.False(t, saved.EndTime.IsZero())
+	assert.Eventually(t, func() bool {
+		storage.lock.Lock()
+		defer storage.lock.Unlock()
+		return storage.cleaned == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return !mj.ListJobs()[0].Running
+	}, time.Second, 10*time.Millisecond)
+	total, runs, err := mj.GetJobRuns("TestJob", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), total)
+	assert.Len(t, runs, 1)
+}
+
+func TestMaintainJobs_TriggerJobLeader(t *testing.T) {
+	mj, job, storage := newTestMaintainJobs(t)
+	key := store.ElectionKeyMaintainJobPrefix + "TestJob"
+	storage.EXPECT().ListLeaderElections().Return([]*model.LeaderElection{
+		{ElectKey: key, Host: "10.0.0.2"},
+	}, nil).AnyTimes()
+	assert.NoError(t, mj.StartMaintianJobs([]JobConfig{{Name: "TestJob", Enable: false, CronSpec: "0 0 1 1 *"}}))
+	defer mj.StopMaintainJobs()
+
+	// 其他节点调度该任务时，当前节点不能触发
+	_, err := mj.TriggerJob(context.Background(), "TestJob")
+	assert.ErrorIs(t, err, ErrJobNotLeader)
+	assert.Contains(t, err.Error(), "10.0.0.2")
+
+	// 参与选主但不是 leader 时不能触发，成为 leader 后可以触发
+	leader := false
+	storage.EXPECT().StartLeaderElection(key).Return(nil)
+	storage.EXPECT().IsLeader(key).DoAndReturn(func(string) bool { return leader }).AnyTimes()
+	assert.NoError(t, mj.EnableJob("TestJob", true))
+	_, err = mj.TriggerJob(context.Background(), "TestJob")
+	assert.ErrorIs(t, err, ErrJobNotLeader)
+
+	leader = true
+	job.affected = make([]string, maxRunAffected+1)
+	run, err := mj.TriggerJob(context.Background(), "TestJob")
+	assert.NoError(t, err)
+	close(job.release)
+	assert.Eventually(t, func() bool {
+		return storage.getRun(run.ID).Status == model.MaintainJobSuccess
+	}, time.Second, 10*time.Millisecond)
+	saved := storage.getRun(run.ID)
+	assert.Len(t, saved.Affected, maxRunAffected)
+	assert.Contains(t, saved.Message, "1001 resources affected")
+}
@@ -0,0 +1,142 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/store"
+)
+
+// DeletedRecordsRetentionPolicy 命名空间的软删除数据保留策略，
+// 软删除超过 DeletedTimeout 的服务以及配置文件会被物理删除，为 0 时不清理，
+// 软删除的实例由 CleanDeletedInstances 任务清理
+type DeletedRecordsRetentionPolicy struct {
+	// Namespace 命名空间，* 表示没有单独配置策略的命名空间
+	Namespace      string        `mapstructure:"namespace"`
+	DeletedTimeout time.Duration `mapstructure:"deletedTimeout"`
+}
+
+type PurgeDeletedRecordsJobConfig struct {
+	BatchSize uint32                           `mapstructure:"batchSize"`
+	Policies  []*DeletedRecordsRetentionPolicy `mapstructure:"policies"`
+}
+
+type purgeDeletedRecordsJob struct {
+	cfg      *PurgeDeletedRecordsJobConfig
+	cacheMgn *cache.CacheManager
+	storage  store.Store
+}
+
+func (job *purgeDeletedRecordsJob) init(raw map[string]interface{}) error {
+	cfg := &PurgeDeletedRecordsJobConfig{}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][PurgeDeletedRecords] new config decoder err: %v", err)
+		return err
+	}
+	err = decoder.Decode(raw)
+	if err != nil {
+		log.Errorf("[Maintain][Job][PurgeDeletedRecords] parse config err: %v", err)
+		return err
+	}
+	if err := checkPolicyNamespaces(cfg.namespaces()); err != nil {
+		log.Errorf("[Maintain][Job][PurgeDeletedRecords] check config err: %v", err)
+		return err
+	}
+	if _, err := getRetentionStore(job.storage); err != nil {
+		return err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultRetentionBatchSize
+	}
+	job.cfg = cfg
+	return nil
+}
+
+func (cfg *PurgeDeletedRecordsJobConfig) namespaces() []string {
+	namespaces := make([]string, 0, len(cfg.Policies))
+	for _, policy := range cfg.Policies {
+		namespaces = append(namespaces, policy.Namespace)
+	}
+	return namespaces
+}
+
+func (job *purgeDeletedRecordsJob) execute() (*jobResult, error) {
+	retentionStore, err := getRetentionStore(job.storage)
+	if err != nil {
+		return nil, err
+	}
+	resources := []struct {
+		name  string
+		purge func(namespace string, excludes []string, before time.Time, batchSize uint32) (uint32, error)
+	}{
+		{name: "service", purge: retentionStore.BatchPurgeDeletedServices},
+		{name: "config_file", purge: retentionStore.BatchPurgeDeletedConfigFiles},
+	}
+
+	result := &jobResult{}
+	var total uint32
+	namespaces := job.cfg.namespaces()
+	horizon := job.cacheMgn.RetentionHorizon()
+	for _, policy := range job.cfg.Policies {
+		if policy.DeletedTimeout == 0 {
+			continue
+		}
+		namespace, excludes := policyScope(policy.Namespace, namespaces)
+		deletedTimeout := policy.DeletedTimeout
+		if deletedTimeout < horizon {
+			// 缓存以及快照仍然可能需要读取软删除的记录
+			log.Warnf("[Maintain][Job][PurgeDeletedRecords] namespace(%s) deletedTimeout %s is less than "+
+				"the cache retention horizon %s, use the horizon instead", policy.Namespace, deletedTimeout, horizon)
+			deletedTimeout = horizon
+		}
+		before := time.Now().Add(-deletedTimeout)
+		for _, resource := range resources {
+			count, err := cleanInBatches(job.cfg.BatchSize, func(batchSize uint32) (uint32, error) {
+				return resource.purge(namespace, excludes, before, batchSize)
+			})
+			total += count
+			if count > 0 {
+				result.affected = append(result.affected,
+					fmt.Sprintf("%s/%s:%d", resource.name, policy.Namespace, count))
+			}
+			log.Infof("[Maintain][Job][PurgeDeletedRecords] namespace(%s) purge deleted %s count %d",
+				policy.Namespace, resource.name, count)
+			if err != nil {
+				log.Errorf("[Maintain][Job][PurgeDeletedRecords] namespace(%s) purge deleted %s, err: %v",
+					policy.Namespace, resource.name, err)
+				result.message = fmt.Sprintf("purge deleted records count %d", total)
+				return result, err
+			}
+		}
+	}
+	result.message = fmt.Sprintf("purge deleted records count %d", total)
+	return result, nil
+}
+
+func (job *purgeDeletedRecordsJob) clear() {
+}
@@ -0,0 +1,81 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// defaultPolicyNamespace 默认策略，作用于没有单独配置策略的命名空间
+	defaultPolicyNamespace = "*"
+	// defaultRetentionBatchSize 每批次清理的数据量
+	defaultRetentionBatchSize = 100
+)
+
+// checkPolicyNamespaces 检查命名空间策略的配置，每个命名空间只能配置一个策略
+func checkPolicyNamespaces(namespaces []string) error {
+	exists := map[string]bool{}
+	for _, ns := range namespaces {
+		if ns == "" {
+			return errors.New("policy namespace is empty")
+		}
+		if exists[ns] {
+			return fmt.Errorf("policy namespace (%s) duplicated", ns)
+		}
+		exists[ns] = true
+	}
+	return nil
+}
+
+// policyScope 命名空间策略对应的清理范围，默认策略清理除其他策略命名空间之外的全部命名空间
+func policyScope(namespace string, namespaces []string) (string, []string) {
+	if namespace != defaultPolicyNamespace {
+		return namespace, nil
+	}
+	var excludes []string
+	for _, ns := range namespaces {
+		if ns != defaultPolicyNamespace {
+			excludes = append(excludes, ns)
+		}
+	}
+	return "", excludes
+}
+
+// cleanInBatches 分批清理，直到某一批次清理的数量小于 batchSize
+func cleanInBatches(batchSize uint32, clean func(batchSize uint32) (uint32, error)) (uint32, error) {
+	var total uint32
+	for {
+		count, err := clean(batchSize)
+		total += count
+		if err != nil || count < batchSize {
+			return total, err
+		}
+	}
+}
+
+func getRetentionStore(storage store.Store) (store.RetentionStore, error) {
+	retentionStore, ok := storage.(store.RetentionStore)
+	if !ok {
+		return nil, errors.New("store not support retention")
+	}
+	return retentionStore, nil
+}
@@ -0,0 +1,271 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+// testRetentionStore 记录每次清理的调用，每次调用返回 counts 中的下一个值
+type testRetentionStore struct {
+	*storemock.MockStore
+	calls   []string
+	befores []time.Time
+	counts  []uint32
+	err     error
+}
+
+func (s *testRetentionStore) record(resource, namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	s.calls = append(s.calls, fmt.Sprintf("%s %s %v %d", resource, namespace, excludes, batchSize))
+	s.befores = append(s.befores, before)
+	if len(s.counts) == 0 {
+		return 0, s.err
+	}
+	count := s.counts[0]
+	s.counts = s.counts[1:]
+	return count, nil
+}
+
+func (s *testRetentionStore) BatchCleanConfigFileReleaseHistories(namespace string, excludes []string,
+	keepCount uint32, before time.Time, batchSize uint32) (uint32, error) {
+	return s.record(fmt.Sprintf("history(%d)", keepCount), namespace, excludes, before, batchSize)
+}
+
+func (s *testRetentionStore) BatchPurgeDeletedServices(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	return s.record("service", namespace, excludes, before, batchSize)
+}
+
+func (s *testRetentionStore) BatchPurgeDeletedConfigFiles(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	return s.record("config_file", namespace, excludes, before, batchSize)
+}
+
+func Test_policyScope(t *testing.T) {
+	namespaces := []string{"default", "*", "Polaris"}
+	namespace, excludes := policyScope("default", namespaces)
+	assert.Equal(t, "default", namespace)
+	assert.Nil(t, excludes)
+
+	namespace, excludes = policyScope("*", namespaces)
+	assert.Equal(t, "", namespace)
+	assert.Equal(t, []string{"default", "Polaris"}, excludes)
+
+	assert.Error(t, checkPolicyNamespaces([]string{"default", "default"}))
+	assert.Error(t, checkPolicyNamespaces([]string{""}))
+	assert.NoError(t, checkPolicyNamespaces(namespaces))
+}
+
+func Test_CleanConfigFileReleaseHistoryJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 存储插件不支持清理
+	job := &cleanConfigFileReleaseHistoryJob{storage: storemock.NewMockStore(ctrl)}
+	assert.Error(t, job.init(map[string]interface{}{}))
+
+	storage := &testRetentionStore{MockStore: storemock.NewMockStore(ctrl), counts: []uint32{2, 2, 1, 3}}
+	job = &cleanConfigFileReleaseHistoryJob{storage: storage}
+	assert.Error(t, job.init(map[string]interface{}{
+		"policies": []interface{}{
+			map[string]interface{}{"namespace": "default", "keepCount": 10},
+			map[string]interface{}{"namespace": "default", "keepCount": 20},
+		},
+	}))
+	assert.NoError(t, job.init(map[string]interface{}{
+		"batchSize": 2,
+		"policies": []interface{}{
+			map[string]interface{}{"namespace": "*", "keepDuration": "720h"},
+			map[string]interface{}{"namespace": "default", "keepCount": 10},
+			map[string]interface{}{"namespace": "Polaris"},
+		},
+	}))
+	assert.Equal(t, 720*time.Hour, job.cfg.Policies[0].KeepDuration)
+
+	result, err := job.execute()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"history(1)  [default Polaris] 2",
+		"history(1)  [default Polaris] 2",
+		"history(1)  [default Polaris] 2",
+		"history(10) default [] 2",
+		"history(10) default [] 2",
+	}, storage.calls)
+	assert.Equal(t, "clean config file release history count 8", result.message)
+	assert.Equal(t, []string{"*:5", "default:3"}, result.affected)
+}
+
+func Test_PurgeDeletedRecordsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := &testRetentionStore{MockStore: storemock.NewMockStore(ctrl), counts: []uint32{1}}
+	cacheMgn := &cache.CacheManager{}
+	job := &purgeDeletedRecordsJob{cacheMgn: cacheMgn, storage: storage}
+	assert.NoError(t, job.init(map[string]interface{}{
+		"policies": []interface{}{
+			map[string]interface{}{"namespace": "default", "deletedTimeout": "168h"},
+			map[string]interface{}{"namespace": "*", "deletedTimeout": "1m"},
+		},
+	}))
+	assert.Equal(t, uint32(defaultRetentionBatchSize), job.cfg.BatchSize)
+
+	result, err := job.execute()
+	assert.NoError(t, err)
+	// 软删除的实例由 CleanDeletedInstances 任务清理
+	assert.Equal(t, []string{
+		"service default [] 100",
+		"config_file default [] 100",
+		"service  [default] 100",
+		"config_file  [default] 100",
+	}, storage.calls)
+	assert.Equal(t, []string{"service/default:1"}, result.affected)
+	assert.Equal(t, "purge deleted records count 1", result.message)
+	// 短于缓存保留时长的 deletedTimeout 按照保留时长计算
+	horizon := time.Since(storage.befores[2])
+	assert.True(t, horizon >= cacheMgn.RetentionHorizon() && horizon < 2*cacheMgn.RetentionHorizon())
+	assert.True(t, time.Since(storage.befores[0]) >= 168*time.Hour)
+
+	storage.calls = nil
+	storage.err = errors.New("mock error")
+	result, err = job.execute()
+	assert.Error(t, err)
+	// 清理服务失败后不再继续清理配置文件
+	assert.Equal(t, []string{"service default [] 100"}, storage.calls)
+	assert.Empty(t, result.affected)
+	assert.Equal(t, "purge deleted records count 0", result.message)
+}
+
+// testInstanceEventStore 记录每次清理实例事件的调用
+type testInstanceEventStore struct {
+	*testRetentionStore
+}
+
+func (s *testInstanceEventStore) AddInstanceEvents(events []*model.InstanceEventRecord) error {
+	return nil
+}
+
+func (s *testInstanceEventStore) GetInstanceEvents(filter *model.InstanceEventFilter, offset, limit uint32) (
+	uint32, []*model.InstanceEventRecord, error) {
+	return 0, nil, nil
+}
+
+func (s *testInstanceEventStore) BatchCleanInstanceEvents(before time.Time, keepCount uint32, batchSize uint32) (
+	uint32, error) {
+	return s.record(fmt.Sprintf("event(%d,%v)", keepCount, before.IsZero()), "", nil, before, batchSize)
+}
+
+func Test_CleanInstanceEventsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 存储插件不支持实例事件
+	job := &cleanInstanceEventsJob{storage: storemock.NewMockStore(ctrl)}
+	assert.Error(t, job.init(map[string]interface{}{}))
+
+	storage := &testInstanceEventStore{
+		testRetentionStore: &testRetentionStore{MockStore: storemock.NewMockStore(ctrl), counts: []uint32{2, 1}},
+	}
+	job = &cleanInstanceEventsJob{storage: storage}
+	assert.Error(t, job.init(map[string]interface{}{"keepDuration": "-1h"}))
+
+	// 没有配置保留策略时不清理
+	assert.NoError(t, job.init(map[string]interface{}{}))
+	result, err := job.execute()
+	assert.NoError(t, err)
+	assert.Empty(t, storage.calls)
+	assert.Equal(t, "clean instance events count 0", result.message)
+
+	assert.NoError(t, job.init(map[string]interface{}{"batchSize": 2, "keepCount": 1000}))
+	result, err = job.execute()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"event(1000,true)  [] 2",
+		"event(1000,true)  [] 2",
+	}, storage.calls)
+	assert.Equal(t, "clean instance events count 3", result.message)
+
+	storage.calls = nil
+	storage.err = errors.New("mock error")
+	assert.NoError(t, job.init(map[string]interface{}{"keepDuration": "168h"}))
+	assert.Equal(t, 168*time.Hour, job.cfg.KeepDuration)
+	_, err = job.execute()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"event(0,false)  [] 100"}, storage.calls)
+}
+
+// testDependencyStore 记录每次清理服务依赖关系的调用
+type testDependencyStore struct {
+	*testRetentionStore
+}
+
+func (s *testDependencyStore) UpsertServiceDependencies(deps []*model.ServiceDependency) error {
+	return nil
+}
+
+func (s *testDependencyStore) GetServiceDependencies(filter map[string]string, offset, limit uint32) (
+	uint32, []*model.ServiceDependency, error) {
+	return 0, nil, nil
+}
+
+func (s *testDependencyStore) BatchCleanServiceDependencies(before time.Time, batchSize uint32) (uint32, error) {
+	return s.record(fmt.Sprintf("dependency(%v)", before.IsZero()), "", nil, before, batchSize)
+}
+
+func Test_CleanServiceDependenciesJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 存储插件不支持服务依赖关系
+	job := &cleanServiceDependenciesJob{storage: storemock.NewMockStore(ctrl)}
+	assert.Error(t, job.init(map[string]interface{}{"keepDuration": "168h"}))
+
+	storage := &testDependencyStore{
+		testRetentionStore: &testRetentionStore{MockStore: storemock.NewMockStore(ctrl), counts: []uint32{2, 1}},
+	}
+	job = &cleanServiceDependenciesJob{storage: storage}
+	// 必须配置保留时长
+	assert.Error(t, job.init(map[string]interface{}{}))
+	assert.Error(t, job.init(map[string]interface{}{"keepDuration": "-1h"}))
+
+	assert.NoError(t, job.init(map[string]interface{}{"batchSize": 2, "keepDuration": "168h"}))
+	result, err := job.execute()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"dependency(false)  [] 2",
+		"dependency(false)  [] 2",
+	}, storage.calls)
+	assert.Equal(t, "clean service dependencies count 3", result.message)
+
+	storage.calls = nil
+	storage.err = errors.New("mock error")
+	_, err = job.execute()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"dependency(false)  [] 2"}, storage.calls)
+}
@@ -19,20 +19,36 @@ package maintain
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"go.uber.org/zap"
 
+	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	commonlog "github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/common/version"
+	"github.com/polarismesh/polaris/maintain/job"
 	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service/healthcheck"
+	"github.com/polarismesh/polaris/store"
+	"github.com/polarismesh/polaris/store/migrate"
 )
 
 func (s *Server) GetServerConnections(_ context.Context, req *ConnReq) (*ConnCountResp, error) {
@@ -216,3 +232,518 @@ func (svr *Server) GetCMDBInfo(ctx context.Context) ([]model.LocationView, error
 
 	return ret, nil
 }
+
+func (s *Server) GetCacheStatus(_ context.Context) ([]*cache.CacheStatus, error) {
+	return s.cacheMgn.GetCacheStatus(), nil
+}
+
+func (s *Server) GetServiceCacheView(_ context.Context, namespace, service string) (*ServiceCacheView, error) {
+	if namespace == "" || service == "" {
+		return nil, errors.New("missing param namespace or service")
+	}
+	view := &ServiceCacheView{Namespace: namespace, Service: service, Instances: map[string]string{}}
+	svc := s.cacheMgn.Service().GetServiceByName(service, namespace)
+	if svc == nil {
+		return view, nil
+	}
+	view.ServiceID = svc.ID
+	view.Revision = s.cacheMgn.GetServiceInstanceRevision(svc.ID)
+	for _, item := range s.cacheMgn.Instance().GetInstancesByServiceID(svc.ID) {
+		view.Instances[item.ID()] = item.Revision()
+	}
+	return view, nil
+}
+
+func (s *Server) DiffServiceCache(ctx context.Context, namespace, service, peer string) (*CacheDiff, error) {
+	local, err := s.GetServiceCacheView(ctx, namespace, service)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		target     *ServiceCacheView
+		targetName = "store"
+	)
+	if peer == "" {
+		target, err = s.getServiceStoreView(namespace, service)
+	} else {
+		targetName = peer
+		target, err = s.getPeerServiceCacheView(ctx, peer, namespace, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diffServiceCacheView(local, target, targetName), nil
+}
+
+func (s *Server) ResyncCache(_ context.Context, name string) error {
+	if name == "" {
+		return errors.New("missing param name")
+	}
+	return s.cacheMgn.ResyncCache(name)
+}
+
+const (
+	// cacheDiffPageSize 从存储分页读取服务实例的大小
+	cacheDiffPageSize = 100
+	// peerRequestTimeout 请求其他节点的超时时间
+	peerRequestTimeout = 5 * time.Second
+)
+
+// getServiceStoreView 从存储读取指定服务的实例，按照缓存相同的方式计算 revision
+func (s *Server) getServiceStoreView(namespace, service string) (*ServiceCacheView, error) {
+	view := &ServiceCacheView{Namespace: namespace, Service: service, Instances: map[string]string{}}
+	svc, err := s.storage.GetService(service, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return view, nil
+	}
+	view.ServiceID = svc.ID
+
+	var instances []*model.Instance
+	for offset := uint32(0); ; {
+		filter := map[string]string{"name": service, "namespace": namespace}
+		total, items, err := s.storage.GetExpandInstances(filter, nil, offset, cacheDiffPageSize)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, items...)
+		offset += uint32(len(items))
+		if len(items) == 0 || offset >= total {
+			break
+		}
+	}
+	for _, item := range instances {
+		view.Instances[item.ID()] = item.Revision()
+	}
+	if view.Revision, err = cache.ComputeRevision(svc.Revision, instances); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// getPeerServiceCacheView 通过运维接口获取其他节点缓存中指定服务的实例
+func (s *Server) getPeerServiceCacheView(ctx context.Context,
+	peer, namespace, service string) (*ServiceCacheView, error) {
+	var node *ClusterNode
+	for _, item := range s.listCheckerNodes() {
+		if item.Host == peer {
+			node = item
+			break
+		}
+	}
+	if node == nil {
+		return nil, fmt.Errorf("peer %s is not a member of the cluster", peer)
+	}
+	addr, err := peerHTTPAddress(node)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("namespace", namespace)
+	query.Set("service", service)
+	view := &ServiceCacheView{}
+	if err := s.requestPeer(ctx, addr, "/maintain/v1/cache/service", query, view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// peerHTTPAddress 根据节点自注册的 http 端口得到节点运维接口的地址
+func peerHTTPAddress(node *ClusterNode) (string, error) {
+	for _, item := range node.Listeners {
+		if item.Protocol == "http" {
+			return net.JoinHostPort(node.Host, strconv.Itoa(int(item.Port))), nil
+		}
+	}
+	return "", errors.New("http apiserver not registered")
+}
+
+// requestPeer 请求其他节点的运维 GET 接口，使用 maintain.peerToken 配置的鉴权 token，不透传调用方的 token
+func (s *Server) requestPeer(ctx context.Context, addr, path string, query url.Values, out interface{}) error {
+	reqURL := (&url.URL{Scheme: "http", Host: addr, Path: path, RawQuery: query.Encode()}).String()
+
+	ctx, cancel := context.WithTimeout(ctx, peerRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	if s.peerToken != "" {
+		req.Header.Set(utils.HeaderAuthTokenKey, s.peerToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("peer %s response %d: %s", addr, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// diffServiceCacheView 对比两个服务实例视图
+func diffServiceCacheView(local, target *ServiceCacheView, targetName string) *CacheDiff {
+	diff := &CacheDiff{
+		Namespace:      local.Namespace,
+		Service:        local.Service,
+		Target:         targetName,
+		LocalRevision:  local.Revision,
+		TargetRevision: target.Revision,
+		LocalCount:     len(local.Instances),
+		TargetCount:    len(target.Instances),
+		OnlyLocal:      []string{},
+		OnlyTarget:     []string{},
+		Changed:        []string{},
+	}
+	for id, revision := range local.Instances {
+		targetRevision, ok := target.Instances[id]
+		if !ok {
+			diff.OnlyLocal = append(diff.OnlyLocal, id)
+		} else if targetRevision != revision {
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for id := range target.Instances {
+		if _, ok := local.Instances[id]; !ok {
+			diff.OnlyTarget = append(diff.OnlyTarget, id)
+		}
+	}
+	sort.Strings(diff.OnlyLocal)
+	sort.Strings(diff.OnlyTarget)
+	sort.Strings(diff.Changed)
+	diff.Consistent = local.ServiceID == target.ServiceID && local.Revision == target.Revision &&
+		len(diff.OnlyLocal) == 0 && len(diff.OnlyTarget) == 0 && len(diff.Changed) == 0
+	return diff
+}
+
+func (s *Server) ReloadConfig(_ context.Context) (*ConfigReloadResult, error) {
+	if configReloader == nil {
+		return nil, errors.New("config reload is not supported")
+	}
+	reloaded, err := configReloader()
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigReloadResult{Reloaded: reloaded}, nil
+}
+
+// startTime 进程的启动时间
+var startTime = time.Now()
+
+func (s *Server) GetNodeStatus(_ context.Context) (*NodeStatus, error) {
+	status := &NodeStatus{
+		Host:      utils.LocalHost,
+		Version:   version.Get(),
+		Revision:  version.GetRevision(),
+		StartTime: startTime,
+		Uptime:    time.Since(startTime).Truncate(time.Second).String(),
+		Listeners: []*NodeListener{},
+		Caches:    s.cacheMgn.GetCacheStatus(),
+	}
+	for _, node := range s.listCheckerNodes() {
+		if node.Host == utils.LocalHost {
+			status.Listeners = node.Listeners
+		}
+	}
+	for _, item := range status.Listeners {
+		item.ConnCount = -1
+		if lis := connlimit.GetLimitListener(item.Protocol); lis != nil {
+			item.ConnCount = lis.GetListenerConnCount()
+		}
+	}
+	status.CheckerLeader = s.storage.IsLeader(store.ElectionKeySelfServiceChecker)
+	return status, nil
+}
+
+func (s *Server) ListClusterNodes(ctx context.Context) ([]*ClusterNode, error) {
+	nodes := s.listCheckerNodes()
+	leader := ""
+	elections, err := s.storage.ListLeaderElections()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range elections {
+		if item.ElectKey == store.ElectionKeySelfServiceChecker && item.Valid {
+			leader = item.Host
+		}
+	}
+	ranges := map[string][]*healthcheck.HashRange{}
+	if s.healthCheckServer != nil {
+		ranges = s.healthCheckServer.HashRanges()
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := range nodes {
+		node := nodes[i]
+		node.CheckerLeader = node.Host == leader
+		node.HashRanges = ranges[node.Host]
+		if node.HashRanges == nil {
+			node.HashRanges = []*healthcheck.HashRange{}
+		}
+		node.HashRatio = healthcheck.HashRatio(node.HashRanges)
+		if node.Host == utils.LocalHost {
+			node.Status, _ = s.GetNodeStatus(ctx)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.fillPeerNodeStatus(ctx, node)
+		}()
+	}
+	wg.Wait()
+	return nodes, nil
+}
+
+// listCheckerNodes 根据健康检查服务下的自注册实例，按照 host 聚合出集群的节点
+func (s *Server) listCheckerNodes() []*ClusterNode {
+	if s.healthCheckServer == nil {
+		return []*ClusterNode{}
+	}
+	nodes := map[string]*ClusterNode{}
+	for _, item := range s.healthCheckServer.ListCheckerServer() {
+		node, ok := nodes[item.Host()]
+		if !ok {
+			node = &ClusterNode{
+				Host:      item.Host(),
+				Version:   item.Version(),
+				Healthy:   true,
+				Listeners: []*NodeListener{},
+			}
+			nodes[item.Host()] = node
+		}
+		node.Healthy = node.Healthy && item.Healthy()
+		node.Isolated = node.Isolated || item.Isolate()
+		node.Listeners = append(node.Listeners, &NodeListener{
+			Protocol: item.Protocol(),
+			Port:     item.Port(),
+		})
+	}
+	ret := make([]*ClusterNode, 0, len(nodes))
+	for _, node := range nodes {
+		sort.Slice(node.Listeners, func(i, j int) bool {
+			return node.Listeners[i].Port < node.Listeners[j].Port
+		})
+		ret = append(ret, node)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Host < ret[j].Host
+	})
+	return ret
+}
+
+// fillPeerNodeStatus 通过节点自注册的 http 端口获取节点的运行状态
+func (s *Server) fillPeerNodeStatus(ctx context.Context, node *ClusterNode) {
+	peer, err := peerHTTPAddress(node)
+	if err != nil {
+		node.Error = err.Error()
+		return
+	}
+	status := &NodeStatus{}
+	if err := s.requestPeer(ctx, peer, "/maintain/v1/cluster/node", nil, status); err != nil {
+		log.Warnf("[MAINTAIN] get node(%s) status fail: %s", peer, err.Error())
+		node.Error = err.Error()
+		return
+	}
+	node.Status = status
+}
+
+func (s *Server) ListMaintainJobs(_ context.Context) (*MaintainJobList, error) {
+	if s.maintainJobs == nil {
+		return &MaintainJobList{Jobs: []*job.JobStatus{}}, nil
+	}
+	return &MaintainJobList{
+		Paused: s.maintainJobs.Paused(),
+		Jobs:   s.maintainJobs.ListJobs(),
+	}, nil
+}
+
+func (s *Server) TriggerMaintainJob(ctx context.Context, req *MaintainJobReq) (*model.MaintainJobRun, error) {
+	if s.maintainJobs == nil {
+		return nil, job.ErrJobNotConfigured
+	}
+	run, err := s.maintainJobs.TriggerJob(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("[MAINTAIN] job(%s) triggered by %s, run id %s", req.Name, run.Operator, run.ID)
+	return run, nil
+}
+
+func (s *Server) EnableMaintainJob(ctx context.Context, req *MaintainJobReq) error {
+	if s.maintainJobs == nil {
+		return job.ErrJobNotConfigured
+	}
+	if err := s.maintainJobs.EnableJob(req.Name, req.Enable); err != nil {
+		return err
+	}
+	log.Infof("[MAINTAIN] job(%s) enable %v by %s", req.Name, req.Enable, utils.ParseOperator(ctx))
+	return nil
+}
+
+func (s *Server) PauseMaintainJobs(ctx context.Context, req *MaintainJobPauseReq) error {
+	if s.maintainJobs == nil {
+		return nil
+	}
+	s.maintainJobs.PauseJobs(req.Pause)
+	log.Infof("[MAINTAIN] jobs paused %v by %s", req.Pause, utils.ParseOperator(ctx))
+	return nil
+}
+
+func (s *Server) GetMaintainJobRuns(_ context.Context, req *MaintainJobRunsReq) (*MaintainJobRunsResp, error) {
+	if s.maintainJobs == nil {
+		return nil, job.ErrJobRunsNotSupported
+	}
+	total, runs, err := s.maintainJobs.GetJobRuns(req.Name, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []*model.MaintainJobRun{}
+	}
+	return &MaintainJobRunsResp{Total: total, Runs: runs}, nil
+}
+
+func (s *Server) whitelistStore() (store.WhitelistStore, error) {
+	ws, ok := s.storage.(store.WhitelistStore)
+	if !ok {
+		return nil, errors.New("whitelist rules are not supported by the store")
+	}
+	return ws, nil
+}
+
+// refreshWhitelist 规则修改后立即刷新本节点的白名单，其他节点按照同步周期加载
+func refreshWhitelist() {
+	whitelist, ok := plugin.GetWhitelist().(plugin.DynamicWhitelist)
+	if !ok {
+		return
+	}
+	if err := whitelist.Refresh(); err != nil {
+		log.Errorf("[MAINTAIN] refresh whitelist rules err: %s", err.Error())
+	}
+}
+
+func (s *Server) GetWhitelistRules(_ context.Context) ([]*model.WhitelistRule, error) {
+	ws, err := s.whitelistStore()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ws.GetWhitelistRules()
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []*model.WhitelistRule{}
+	}
+	return rules, nil
+}
+
+func (s *Server) AddWhitelistRule(ctx context.Context, rule *model.WhitelistRule) (*model.WhitelistRule, error) {
+	ws, err := s.whitelistStore()
+	if err != nil {
+		return nil, err
+	}
+	ipNet, err := model.ParseIPNet(rule.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Action == "" {
+		rule.Action = model.WhitelistAllow
+	}
+	if rule.Action != model.WhitelistAllow && rule.Action != model.WhitelistDeny {
+		return nil, fmt.Errorf("invalid action %s, must be allow or deny", rule.Action)
+	}
+	rule.ID = utils.NewUUID()
+	rule.CIDR = ipNet.String()
+	rule.Operator = utils.ParseOperator(ctx)
+	rule.CreateTime = time.Now()
+	if err := ws.AddWhitelistRule(rule); err != nil {
+		return nil, err
+	}
+	log.Infof("[MAINTAIN] whitelist rule(%s) %s %s for api(%s) added by %s",
+		rule.ID, rule.Action, rule.CIDR, rule.API, rule.Operator)
+	refreshWhitelist()
+	return rule, nil
+}
+
+func (s *Server) DeleteWhitelistRule(ctx context.Context, req *WhitelistRuleDeleteReq) error {
+	if req.ID == "" {
+		return errors.New("missing param id")
+	}
+	ws, err := s.whitelistStore()
+	if err != nil {
+		return err
+	}
+	if err := ws.DeleteWhitelistRule(req.ID); err != nil {
+		return err
+	}
+	log.Infof("[MAINTAIN] whitelist rule(%s) deleted by %s", req.ID, utils.ParseOperator(ctx))
+	refreshWhitelist()
+	return nil
+}
+
+func (s *Server) GetServiceDependencies(_ context.Context,
+	req *ServiceDependenciesReq) (*ServiceDependenciesResp, error) {
+	ds, ok := s.storage.(store.DependencyStore)
+	if !ok {
+		return nil, errors.New("service dependencies are not supported by the store")
+	}
+	filter := map[string]string{}
+	for key, value := range map[string]string{
+		"caller_namespace": req.CallerNamespace,
+		"caller_service":   req.CallerService,
+		"caller_host":      req.CallerHost,
+		"callee_namespace": req.CalleeNamespace,
+		"callee_service":   req.CalleeService,
+	} {
+		if value != "" {
+			filter[key] = value
+		}
+	}
+	total, deps, err := ds.GetServiceDependencies(filter, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if deps == nil {
+		deps = []*model.ServiceDependency{}
+	}
+	return &ServiceDependenciesResp{Total: total, Dependencies: deps}, nil
+}
+
+func (s *Server) GetInstanceEvents(_ context.Context, req *InstanceEventsReq) (*InstanceEventsResp, error) {
+	es, ok := s.storage.(store.InstanceEventStore)
+	if !ok {
+		return nil, errors.New("instance events are not supported by the store")
+	}
+	if req.InstanceID == "" && (req.Namespace == "" || req.Service == "") {
+		return nil, errors.New("namespace and service or instanceId is required")
+	}
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && !req.StartTime.Before(req.EndTime) {
+		return nil, errors.New("startTime should be before endTime")
+	}
+	filter := &model.InstanceEventFilter{
+		Namespace:  req.Namespace,
+		Service:    req.Service,
+		InstanceID: req.InstanceID,
+		EventTypes: req.EventTypes,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}
+	total, events, err := es.GetInstanceEvents(filter, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []*model.InstanceEventRecord{}
+	}
+	return &InstanceEventsResp{Total: total, Events: events}, nil
+}
+
+func (s *Server) BackupStore(_ context.Context, w io.Writer, resources []string) error {
+	_, err := migrate.BackupTo(s.storage, w, migrate.BackupOptions{Resources: resources, Output: io.Discard})
+	return err
+}
@@ -19,9 +19,11 @@ package maintain
 
 import (
 	"context"
+	"io"
 
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
@@ -178,3 +180,244 @@ func (svr *serverAuthAbility) GetCMDBInfo(ctx context.Context) ([]model.Location
 
 	return svr.targetServer.GetCMDBInfo(ctx)
 }
+
+func (svr *serverAuthAbility) GetCacheStatus(ctx context.Context) ([]*cache.CacheStatus, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetCacheStatus")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetCacheStatus(ctx)
+}
+
+func (svr *serverAuthAbility) GetServiceCacheView(ctx context.Context,
+	namespace, service string) (*ServiceCacheView, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetServiceCacheView")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetServiceCacheView(ctx, namespace, service)
+}
+
+func (svr *serverAuthAbility) DiffServiceCache(ctx context.Context,
+	namespace, service, peer string) (*CacheDiff, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "DiffServiceCache")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.DiffServiceCache(ctx, namespace, service, peer)
+}
+
+func (svr *serverAuthAbility) ResyncCache(ctx context.Context, name string) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "ResyncCache")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ResyncCache(ctx, name)
+}
+
+func (svr *serverAuthAbility) GetNodeStatus(ctx context.Context) (*NodeStatus, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetNodeStatus")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetNodeStatus(ctx)
+}
+
+func (svr *serverAuthAbility) ListClusterNodes(ctx context.Context) ([]*ClusterNode, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "ListClusterNodes")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ListClusterNodes(ctx)
+}
+
+func (svr *serverAuthAbility) ReloadConfig(ctx context.Context) (*ConfigReloadResult, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "ReloadConfig")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ReloadConfig(ctx)
+}
+
+func (svr *serverAuthAbility) ListMaintainJobs(ctx context.Context) (*MaintainJobList, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "ListMaintainJobs")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ListMaintainJobs(ctx)
+}
+
+func (svr *serverAuthAbility) TriggerMaintainJob(ctx context.Context,
+	req *MaintainJobReq) (*model.MaintainJobRun, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "TriggerMaintainJob")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.TriggerMaintainJob(ctx, req)
+}
+
+func (svr *serverAuthAbility) EnableMaintainJob(ctx context.Context, req *MaintainJobReq) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "EnableMaintainJob")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.EnableMaintainJob(ctx, req)
+}
+
+func (svr *serverAuthAbility) PauseMaintainJobs(ctx context.Context, req *MaintainJobPauseReq) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "PauseMaintainJobs")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.PauseMaintainJobs(ctx, req)
+}
+
+func (svr *serverAuthAbility) GetMaintainJobRuns(ctx context.Context,
+	req *MaintainJobRunsReq) (*MaintainJobRunsResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetMaintainJobRuns")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetMaintainJobRuns(ctx, req)
+}
+
+func (svr *serverAuthAbility) GetWhitelistRules(ctx context.Context) ([]*model.WhitelistRule, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetWhitelistRules")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetWhitelistRules(ctx)
+}
+
+func (svr *serverAuthAbility) AddWhitelistRule(ctx context.Context,
+	rule *model.WhitelistRule) (*model.WhitelistRule, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "AddWhitelistRule")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.AddWhitelistRule(ctx, rule)
+}
+
+func (svr *serverAuthAbility) DeleteWhitelistRule(ctx context.Context, req *WhitelistRuleDeleteReq) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "DeleteWhitelistRule")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.DeleteWhitelistRule(ctx, req)
+}
+
+func (svr *serverAuthAbility) GetServiceDependencies(ctx context.Context,
+	req *ServiceDependenciesReq) (*ServiceDependenciesResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetServiceDependencies")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetServiceDependencies(ctx, req)
+}
+
+func (svr *serverAuthAbility) GetInstanceEvents(ctx context.Context,
+	req *InstanceEventsReq) (*InstanceEventsResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetInstanceEvents")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetInstanceEvents(ctx, req)
+}
+
+func (svr *serverAuthAbility) BackupStore(ctx context.Context, w io.Writer, resources []string) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "BackupStore")
+	_, err := svr.authMgn.CheckConsolePermission(authCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.BackupStore(ctx, w, resources)
+}
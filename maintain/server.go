@@ -21,6 +21,7 @@ import (
 	"sync"
 
 	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/maintain/job"
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/service/healthcheck"
 	"github.com/polarismesh/polaris/store"
@@ -34,4 +35,6 @@ type Server struct {
 	healthCheckServer *healthcheck.Server
 	cacheMgn          *cache.CacheManager
 	storage           store.Store
+	maintainJobs      *job.MaintainJobs
+	peerToken         string
 }
@@ -26,18 +26,25 @@ import (
 	_ "github.com/polarismesh/polaris/apiserver/xdsserverv3"
 	_ "github.com/polarismesh/polaris/auth/defaultauth"
 	_ "github.com/polarismesh/polaris/cache"
+	_ "github.com/polarismesh/polaris/plugin/cmdb/file"
 	_ "github.com/polarismesh/polaris/plugin/cmdb/memory"
 	_ "github.com/polarismesh/polaris/plugin/discoverevent/local"
+	_ "github.com/polarismesh/polaris/plugin/discoverevent/timeline"
+	_ "github.com/polarismesh/polaris/plugin/discoverevent/webhook"
 	_ "github.com/polarismesh/polaris/plugin/discoverstat/discoverlocal"
 	_ "github.com/polarismesh/polaris/plugin/healthchecker/heartbeatmemory"
+	_ "github.com/polarismesh/polaris/plugin/healthchecker/heartbeatp2p"
 	_ "github.com/polarismesh/polaris/plugin/healthchecker/heartbeatredis"
+	_ "github.com/polarismesh/polaris/plugin/healthchecker/heartbeatstore"
 	_ "github.com/polarismesh/polaris/plugin/history/logger"
 	_ "github.com/polarismesh/polaris/plugin/password"
 	_ "github.com/polarismesh/polaris/plugin/ratelimit/lrurate"
 	_ "github.com/polarismesh/polaris/plugin/ratelimit/token"
 	_ "github.com/polarismesh/polaris/plugin/statis/logger"
+	_ "github.com/polarismesh/polaris/plugin/statis/otlp"
 	_ "github.com/polarismesh/polaris/plugin/statis/prometheus"
 	_ "github.com/polarismesh/polaris/plugin/whitelist"
 	_ "github.com/polarismesh/polaris/store/boltdb"
 	_ "github.com/polarismesh/polaris/store/mysql"
+	_ "github.com/polarismesh/polaris/store/postgres"
 )
@@ -0,0 +1,204 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// PluginName 插件名称
+	PluginName = "cmdbFile"
+	// defaultReloadInterval 检查映射文件是否变化的默认间隔
+	defaultReloadInterval = 10 * time.Second
+)
+
+var (
+	log = commonlog.RegisterScope("cmdb", "", 0)
+)
+
+// init 自注册到插件列表
+func init() {
+	plugin.RegisterPlugin(PluginName, &FileCMDB{})
+}
+
+// FileCMDB 从本地文件加载 CIDR 到 region/zone/campus 的映射，文件变化后自动重新加载，
+// 重新加载失败时继续使用上一次加载成功的数据
+type FileCMDB struct {
+	path        string
+	format      string
+	allowNested bool
+	interval    time.Duration
+
+	table   atomic.Value
+	modTime time.Time
+	size    int64
+	cancel  context.CancelFunc
+	wait    sync.WaitGroup
+}
+
+// Name 返回插件名
+func (f *FileCMDB) Name() string {
+	return PluginName
+}
+
+// Initialize 初始化函数，首次加载映射文件失败时返回错误
+func (f *FileCMDB) Initialize(c *plugin.ConfigEntry) error {
+	f.path, _ = c.Option["path"].(string)
+	if f.path == "" {
+		return errors.New("cmdb file path is empty")
+	}
+	format, _ := c.Option["format"].(string)
+	format, err := detectFormat(f.path, format)
+	if err != nil {
+		return err
+	}
+	f.format = format
+	f.allowNested = true
+	if allowNested, ok := c.Option["allowNested"].(bool); ok {
+		f.allowNested = allowNested
+	}
+	f.interval = defaultReloadInterval
+	if interval, _ := c.Option["interval"].(string); interval != "" {
+		if f.interval, err = time.ParseDuration(interval); err != nil {
+			return err
+		}
+		if f.interval <= 0 {
+			return errors.New("cmdb file reload interval should be positive")
+		}
+	}
+
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.wait.Add(1)
+	go func() {
+		defer f.wait.Done()
+		f.watch(ctx)
+	}()
+	return nil
+}
+
+// load 读取并校验映射文件，校验通过后替换当前生效的映射表
+func (f *FileCMDB) load() error {
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	entries, err := parseEntries(data, f.format)
+	if err != nil {
+		return err
+	}
+	table, err := NewTable(entries, f.allowNested)
+	if err != nil {
+		return err
+	}
+	f.table.Store(table)
+	f.modTime = stat.ModTime()
+	f.size = stat.Size()
+	log.Infof("[CMDB][File] load %d cidr entries from %s", table.Size(), f.path)
+	return nil
+}
+
+// watch 定时检查映射文件的修改时间以及大小，发生变化时重新加载
+func (f *FileCMDB) watch(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			stat, err := os.Stat(f.path)
+			if err != nil {
+				log.Errorf("[CMDB][File] stat cmdb file %s err: %s", f.path, err.Error())
+				continue
+			}
+			if stat.ModTime().Equal(f.modTime) && stat.Size() == f.size {
+				continue
+			}
+			if err := f.load(); err != nil {
+				log.Errorf("[CMDB][File] reload cmdb file %s err: %s, keep the previous data", f.path, err.Error())
+				// 记录本次的文件状态，文件再次变化之前不重复加载
+				f.modTime = stat.ModTime()
+				f.size = stat.Size()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Destroy 销毁函数
+func (f *FileCMDB) Destroy() error {
+	if f.cancel != nil {
+		f.cancel()
+		f.wait.Wait()
+	}
+	return nil
+}
+
+func (f *FileCMDB) getTable() *Table {
+	val := f.table.Load()
+	if val == nil {
+		return nil
+	}
+	return val.(*Table)
+}
+
+// GetLocation 实现CMDB插件接口，返回包含 host 的掩码最长的网段对应的地域信息
+func (f *FileCMDB) GetLocation(host string) (*model.Location, error) {
+	table := f.getTable()
+	if table == nil {
+		return nil, nil
+	}
+	return table.Lookup(host), nil
+}
+
+// Range 实现CMDB插件接口，host 为映射文件中的网段
+func (f *FileCMDB) Range(handler func(host string, location *model.Location) (bool, error)) error {
+	table := f.getTable()
+	if table == nil {
+		return nil
+	}
+	return table.Range(handler)
+}
+
+// Size 实现CMDB插件接口
+func (f *FileCMDB) Size() int32 {
+	table := f.getTable()
+	if table == nil {
+		return 0
+	}
+	return int32(table.Size())
+}
@@ -0,0 +1,162 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const testYAML = `
+entries:
+  - cidr: 10.0.0.0/8
+    region: ap-guangzhou
+  - cidr: 10.1.0.0/16
+    region: ap-guangzhou
+    zone: ap-guangzhou-3
+  - cidr: 10.1.2.0/24
+    region: ap-guangzhou
+    zone: ap-guangzhou-3
+    campus: campus-1
+  - cidr: 10.1.2.3
+    region: ap-shanghai
+    zone: ap-shanghai-1
+    campus: campus-2
+  - cidr: fd00::/64
+    region: ap-beijing
+`
+
+func region(loc *model.Location) string {
+	if loc == nil {
+		return ""
+	}
+	return loc.Proto.GetRegion().GetValue() + "/" + loc.Proto.GetZone().GetValue() + "/" +
+		loc.Proto.GetCampus().GetValue()
+}
+
+func TestTableLongestPrefixMatch(t *testing.T) {
+	entries, err := parseEntries([]byte(testYAML), FormatYAML)
+	assert.NoError(t, err)
+	table, err := NewTable(entries, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, table.Size())
+
+	assert.Equal(t, "ap-guangzhou//", region(table.Lookup("10.200.0.1")))
+	assert.Equal(t, "ap-guangzhou/ap-guangzhou-3/", region(table.Lookup("10.1.200.1")))
+	assert.Equal(t, "ap-guangzhou/ap-guangzhou-3/campus-1", region(table.Lookup("10.1.2.4")))
+	assert.Equal(t, "ap-shanghai/ap-shanghai-1/campus-2", region(table.Lookup("10.1.2.3")))
+	assert.Equal(t, "ap-beijing//", region(table.Lookup("fd00::1")))
+	assert.Nil(t, table.Lookup("192.168.0.1"))
+	assert.Nil(t, table.Lookup("fd01::1"))
+	assert.Nil(t, table.Lookup("not-an-ip"))
+
+	var cidrs []string
+	assert.NoError(t, table.Range(func(cidr string, location *model.Location) (bool, error) {
+		cidrs = append(cidrs, cidr)
+		return true, nil
+	}))
+	assert.Equal(t, []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "fd00::/64"}, cidrs)
+
+	// 不允许网段互相包含
+	_, err = NewTable(entries, false)
+	assert.Error(t, err)
+}
+
+func TestTableValidate(t *testing.T) {
+	_, err := NewTable([]*Entry{
+		{CIDR: "10.0.0.0/8", Region: "a"},
+		{CIDR: "10.1.2.3/8", Region: "b"},
+	}, true)
+	assert.EqualError(t, err, "entry 1: cidr 10.1.2.3/8 overlaps with 10.0.0.0/8")
+
+	_, err = NewTable([]*Entry{{CIDR: "10.0.0.0/33", Region: "a"}}, true)
+	assert.Error(t, err)
+	_, err = NewTable([]*Entry{{CIDR: "10.0.0.0/8"}}, true)
+	assert.Error(t, err)
+
+	table, err := NewTable([]*Entry{
+		{CIDR: "10.0.0.0/16", Region: "a"},
+		{CIDR: "10.1.0.0/16", Region: "b"},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "b//", region(table.Lookup("10.1.0.1")))
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "# cmdb\ncidr,region,zone,campus\n10.0.0.0/8, ap-guangzhou, ap-guangzhou-3, campus-1\n\n10.1.0.0/16,a,b,c\n"
+	entries, err := parseEntries([]byte(data), FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Entry{
+		{CIDR: "10.0.0.0/8", Region: "ap-guangzhou", Zone: "ap-guangzhou-3", Campus: "campus-1"},
+		{CIDR: "10.1.0.0/16", Region: "a", Zone: "b", Campus: "c"},
+	}, entries)
+
+	_, err = parseEntries([]byte("10.0.0.0/8,a,b\n"), FormatCSV)
+	assert.Error(t, err)
+
+	format, err := detectFormat("/data/cmdb.CSV", "")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+	format, err = detectFormat("/data/cmdb.yml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatYAML, format)
+	_, err = detectFormat("/data/cmdb.yml", "json")
+	assert.Error(t, err)
+}
+
+func TestFileCMDBReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cmdb.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.0/8,ap-guangzhou,,\n"), 0600))
+
+	f := &FileCMDB{}
+	assert.Error(t, f.Initialize(&plugin.ConfigEntry{Option: map[string]interface{}{}}))
+	assert.NoError(t, f.Initialize(&plugin.ConfigEntry{
+		Name:   PluginName,
+		Option: map[string]interface{}{"path": path, "interval": "10ms"},
+	}))
+	defer func() {
+		_ = f.Destroy()
+	}()
+
+	loc, err := f.GetLocation("10.1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "ap-guangzhou//", region(loc))
+	assert.Equal(t, int32(1), f.Size())
+
+	// 文件变化后重新加载
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.0/8,ap-guangzhou,,\n10.1.0.0/16,ap-shanghai,,\n"), 0600))
+	assert.Eventually(t, func() bool {
+		loc, _ := f.GetLocation("10.1.2.3")
+		return region(loc) == "ap-shanghai//"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 校验失败时继续使用上一次加载成功的数据
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.0/8,a,,\n10.0.0.0/8,b,,\n10.2.0.0/16,c,,\n"), 0600))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), f.Size())
+	loc, err = f.GetLocation("10.2.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ap-guangzhou//", region(loc))
+}
@@ -0,0 +1,268 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package file
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v2"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// FormatYAML YAML 格式的映射文件
+	FormatYAML = "yaml"
+	// FormatCSV CSV 格式的映射文件，每行依次为 cidr,region,zone,campus
+	FormatCSV = "csv"
+)
+
+// Entry 映射文件中的一条记录，cidr 为单个 IP 时按照 /32（IPv6 为 /128）处理
+type Entry struct {
+	CIDR   string `yaml:"cidr"`
+	Region string `yaml:"region"`
+	Zone   string `yaml:"zone"`
+	Campus string `yaml:"campus"`
+}
+
+// fileContent YAML 映射文件的内容
+type fileContent struct {
+	Entries []*Entry `yaml:"entries"`
+}
+
+// detectFormat 未指定格式时根据文件扩展名判断
+func detectFormat(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = FormatCSV
+		default:
+			format = FormatYAML
+		}
+	}
+	switch format {
+	case FormatYAML, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported cmdb file format %s", format)
+	}
+}
+
+// parseEntries 解析映射文件的内容
+func parseEntries(data []byte, format string) ([]*Entry, error) {
+	if format == FormatCSV {
+		return parseCSV(data)
+	}
+	content := &fileContent{}
+	if err := yaml.Unmarshal(data, content); err != nil {
+		return nil, err
+	}
+	return content.Entries, nil
+}
+
+// parseCSV 解析 CSV 格式的内容，# 开头的行为注释，第一列为 cidr 的行视为表头
+func parseCSV(data []byte) ([]*Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []*Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(record[0]), "cidr") {
+			continue
+		}
+		if len(record) != 4 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expect 4 fields cidr,region,zone,campus, got %d", line, len(record))
+		}
+		entries = append(entries, &Entry{
+			CIDR:   strings.TrimSpace(record[0]),
+			Region: strings.TrimSpace(record[1]),
+			Zone:   strings.TrimSpace(record[2]),
+			Campus: strings.TrimSpace(record[3]),
+		})
+	}
+}
+
+// parseCIDR 解析网段，单个 IP 视为只包含自身的网段
+func parseCIDR(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip %s", value)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	if v4 := ipNet.IP.To4(); v4 != nil {
+		ones, _ := ipNet.Mask.Size()
+		ipNet = &net.IPNet{IP: v4, Mask: net.CIDRMask(ones, 32)}
+	}
+	return ipNet, nil
+}
+
+// rangeEntry 一条生效的网段映射
+type rangeEntry struct {
+	ipNet *net.IPNet
+	loc   *model.Location
+}
+
+// prefixGroup 相同掩码长度的网段
+type prefixGroup struct {
+	ones   int
+	bits   int
+	ranges map[string]*rangeEntry
+}
+
+// Table CIDR 到地域信息的映射表，按照最长前缀匹配
+type Table struct {
+	// groups 按照掩码长度从长到短排列
+	groups  []*prefixGroup
+	entries []*rangeEntry
+}
+
+// NewTable 根据映射记录创建映射表，网段重复或者缺少地域信息时返回错误，
+// allowNested 为 false 时网段之间不允许互相包含
+func NewTable(entries []*Entry, allowNested bool) (*Table, error) {
+	t := &Table{}
+	groups := map[string]*prefixGroup{}
+	for i, entry := range entries {
+		ipNet, err := parseCIDR(entry.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		if entry.Region == "" && entry.Zone == "" && entry.Campus == "" {
+			return nil, fmt.Errorf("entry %d: cidr %s has no region, zone or campus", i, entry.CIDR)
+		}
+		ones, bits := ipNet.Mask.Size()
+		groupKey := fmt.Sprintf("%d/%d", ones, bits)
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &prefixGroup{ones: ones, bits: bits, ranges: map[string]*rangeEntry{}}
+			groups[groupKey] = group
+			t.groups = append(t.groups, group)
+		}
+		if exist, ok := group.ranges[ipNet.IP.String()]; ok {
+			return nil, fmt.Errorf("entry %d: cidr %s overlaps with %s", i, entry.CIDR, exist.ipNet.String())
+		}
+		item := &rangeEntry{ipNet: ipNet, loc: newLocation(entry)}
+		group.ranges[ipNet.IP.String()] = item
+		t.entries = append(t.entries, item)
+	}
+	sort.Slice(t.groups, func(i, j int) bool {
+		if t.groups[i].bits != t.groups[j].bits {
+			return t.groups[i].bits < t.groups[j].bits
+		}
+		return t.groups[i].ones > t.groups[j].ones
+	})
+
+	if !allowNested {
+		for _, item := range t.entries {
+			if outer := t.lookup(item.ipNet.IP, item); outer != nil {
+				return nil, fmt.Errorf("cidr %s overlaps with %s", item.ipNet.String(), outer.ipNet.String())
+			}
+		}
+	}
+	return t, nil
+}
+
+func newLocation(entry *Entry) *model.Location {
+	return &model.Location{
+		Proto: &apimodel.Location{
+			Region: wrapperspb.String(entry.Region),
+			Zone:   wrapperspb.String(entry.Zone),
+			Campus: wrapperspb.String(entry.Campus),
+		},
+	}
+}
+
+// Lookup 返回包含 host 的掩码最长的网段对应的地域信息，没有匹配的网段时返回 nil
+func (t *Table) Lookup(host string) *model.Location {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if item := t.lookup(ip, nil); item != nil {
+		return item.loc
+	}
+	return nil
+}
+
+// lookup 按照掩码从长到短查找包含 ip 的网段，跳过 exclude
+func (t *Table) lookup(ip net.IP, exclude *rangeEntry) *rangeEntry {
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		bits = 32
+	}
+	for _, group := range t.groups {
+		if group.bits != bits {
+			continue
+		}
+		item, ok := group.ranges[ip.Mask(net.CIDRMask(group.ones, group.bits)).String()]
+		if ok && item != exclude {
+			return item
+		}
+	}
+	return nil
+}
+
+// Range 按照文件中的顺序遍历全部网段
+func (t *Table) Range(handler func(cidr string, location *model.Location) (bool, error)) error {
+	for _, item := range t.entries {
+		next, err := handler(item.ipNet.String(), item.loc)
+		if err != nil {
+			return err
+		}
+		if !next {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Size 网段的数量
+func (t *Table) Size() int {
+	return len(t.entries)
+}
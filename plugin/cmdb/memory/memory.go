@@ -61,6 +61,10 @@ func (m *Memory) Initialize(c *plugin.ConfigEntry) error {
 	url, _ := c.Option["url"].(string)
 	token, _ := c.Option["token"].(string)
 	interval, _ := c.Option["interval"].(string)
+	token, err := plugin.ParseSecret(token)
+	if err != nil {
+		return err
+	}
 
 	tick, err := time.ParseDuration(interval)
 	if err != nil {
@@ -0,0 +1,234 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// PluginName 插件名称
+	PluginName = "discoverEventTimeline"
+
+	defaultQueueSize     = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = 1
+)
+
+var log = commonlog.RegisterScope(PluginName, "", 0)
+
+// subscribeEvents 默认记录的事件类型，在 discoverEventLocal 的基础上增加实例属性变更事件，便于排查权重等变化
+var subscribeEvents = []model.InstanceEventType{
+	model.EventInstanceOnline,
+	model.EventInstanceOffline,
+	model.EventInstanceTurnHealth,
+	model.EventInstanceTurnUnHealth,
+	model.EventInstanceOpenIsolate,
+	model.EventInstanceCloseIsolate,
+	model.EventInstanceUpdate,
+}
+
+func init() {
+	d := &discoverEventTimeline{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
+
+// Config 插件配置
+type Config struct {
+	// QueueSize 等待写入的事件队列长度，队列满或者存储层持续写入失败时丢弃事件
+	QueueSize int `json:"queueSize"`
+	// BatchSize 单次写入存储层的最大事件数
+	BatchSize int `json:"batchSize"`
+	// FlushInterval 未攒满一批时的写入间隔，单位秒
+	FlushInterval int `json:"flushInterval"`
+	// EventTypes 记录的事件类型，为空时记录实例上下线、健康状态、隔离状态变化以及实例属性变更事件
+	EventTypes []string `json:"eventTypes"`
+}
+
+// parseConfig 解析插件配置
+func parseConfig(option map[string]interface{}) (*Config, error) {
+	config := &Config{
+		QueueSize:     defaultQueueSize,
+		BatchSize:     defaultBatchSize,
+		FlushInterval: defaultFlushInterval,
+	}
+	if len(option) != 0 {
+		contentBytes, err := json.Marshal(option)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(contentBytes, config); err != nil {
+			return nil, err
+		}
+	}
+	if config.QueueSize <= 0 {
+		return nil, errors.New("queueSize is <= 0")
+	}
+	if config.BatchSize <= 0 {
+		return nil, errors.New("batchSize is <= 0")
+	}
+	if config.FlushInterval <= 0 {
+		return nil, errors.New("flushInterval is <= 0")
+	}
+	return config, nil
+}
+
+// discoverEventTimeline 将服务实例事件写入存储层，供控制台按照服务或者实例查询事件时间线
+type discoverEventTimeline struct {
+	conf       *Config
+	eventTypes map[model.InstanceEventType]struct{}
+	eventCh    chan model.InstanceEvent
+	pending    []*model.InstanceEventRecord
+	// saveFailed 上一次写入失败时不再按批次触发写入，等待定时写入重试
+	saveFailed bool
+	save       func(events []*model.InstanceEventRecord) error
+	cancel     context.CancelFunc
+	wait       sync.WaitGroup
+}
+
+// Name 插件名称
+func (t *discoverEventTimeline) Name() string {
+	return PluginName
+}
+
+// Initialize 根据配置文件进行初始化插件 discoverEventTimeline
+func (t *discoverEventTimeline) Initialize(conf *plugin.ConfigEntry) error {
+	config, err := parseConfig(conf.Option)
+	if err != nil {
+		return err
+	}
+
+	t.conf = config
+	t.eventTypes = make(map[model.InstanceEventType]struct{})
+	eventTypes := config.EventTypes
+	if len(eventTypes) == 0 {
+		for _, eventType := range subscribeEvents {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+	}
+	for _, eventType := range eventTypes {
+		t.eventTypes[model.InstanceEventType(eventType)] = struct{}{}
+	}
+	t.eventCh = make(chan model.InstanceEvent, config.QueueSize)
+	if t.save == nil {
+		t.save = saveStoreEvents
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wait.Add(1)
+	go func() {
+		defer t.wait.Done()
+		t.run(ctx)
+	}()
+	return nil
+}
+
+// Destroy 执行插件销毁，队列中剩余的事件会尝试写入一次存储层
+func (t *discoverEventTimeline) Destroy() error {
+	if t.cancel != nil {
+		t.cancel()
+		t.wait.Wait()
+	}
+	return nil
+}
+
+// PublishEvent 发布一个服务事件，队列已满时丢弃
+func (t *discoverEventTimeline) PublishEvent(event model.InstanceEvent) {
+	if _, ok := t.eventTypes[event.EType]; !ok {
+		return
+	}
+	select {
+	case t.eventCh <- event:
+	default:
+		log.Warnf("[DiscoverEvent][Timeline] event queue is full, drop event %s", event.String())
+	}
+}
+
+func (t *discoverEventTimeline) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(t.conf.FlushInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-t.eventCh:
+			t.add(event)
+		case <-ticker.C:
+			t.flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-t.eventCh:
+					t.add(event)
+				default:
+					t.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *discoverEventTimeline) add(event model.InstanceEvent) {
+	t.pending = append(t.pending, model.NewInstanceEventRecord(&event, utils.LocalHost))
+	if len(t.pending) >= t.conf.BatchSize && !t.saveFailed {
+		t.flush()
+	}
+}
+
+// flush 将攒批的事件写入存储层，写入失败时保留数据等待下一次写入，超过队列长度的最早事件会被丢弃
+func (t *discoverEventTimeline) flush() {
+	if len(t.pending) == 0 {
+		return
+	}
+	if err := t.save(t.pending); err != nil {
+		t.saveFailed = true
+		log.Errorf("[DiscoverEvent][Timeline] save %d instance events err: %s", len(t.pending), err.Error())
+		if dropped := len(t.pending) - t.conf.QueueSize; dropped > 0 {
+			log.Warnf("[DiscoverEvent][Timeline] drop %d oldest instance events", dropped)
+			t.pending = append([]*model.InstanceEventRecord{}, t.pending[dropped:]...)
+		}
+		return
+	}
+	t.pending = nil
+	t.saveFailed = false
+}
+
+// saveStoreEvents 将实例事件写入存储层，存储插件不支持时返回错误
+func saveStoreEvents(events []*model.InstanceEventRecord) error {
+	s, err := store.GetStore()
+	if err != nil {
+		return err
+	}
+	es, ok := s.(store.InstanceEventStore)
+	if !ok {
+		return fmt.Errorf("store %s does not support instance events", s.Name())
+	}
+	return es.AddInstanceEvents(events)
+}
@@ -0,0 +1,151 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package timeline
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// recorder 记录写入存储层的事件，fail 为 true 时模拟写入失败
+type recorder struct {
+	lock   sync.Mutex
+	events []*model.InstanceEventRecord
+	calls  int32
+	fail   int32
+}
+
+func (r *recorder) save(events []*model.InstanceEventRecord) error {
+	atomic.AddInt32(&r.calls, 1)
+	if atomic.LoadInt32(&r.fail) == 1 {
+		return errors.New("store unavailable")
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *recorder) saved() []*model.InstanceEventRecord {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*model.InstanceEventRecord{}, r.events...)
+}
+
+func newEvent(id string, eventType model.InstanceEventType, weight uint32) model.InstanceEvent {
+	return model.InstanceEvent{
+		Id:        id,
+		Namespace: "default",
+		Service:   "order",
+		Instance: &apiservice.Instance{
+			Id:      &wrappers.StringValue{Value: id},
+			Host:    &wrappers.StringValue{Value: "10.0.0.1"},
+			Port:    &wrappers.UInt32Value{Value: 8080},
+			Weight:  &wrappers.UInt32Value{Value: weight},
+			Healthy: &wrappers.BoolValue{Value: true},
+		},
+		EType:      eventType,
+		CreateTime: time.Now(),
+	}
+}
+
+func newTimeline(t *testing.T, r *recorder, option map[string]interface{}) *discoverEventTimeline {
+	d := &discoverEventTimeline{save: r.save}
+	assert.NoError(t, d.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option}))
+	return d
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultQueueSize, config.QueueSize)
+	assert.Equal(t, defaultBatchSize, config.BatchSize)
+	assert.Equal(t, defaultFlushInterval, config.FlushInterval)
+
+	_, err = parseConfig(map[string]interface{}{"batchSize": 0})
+	assert.Error(t, err)
+	_, err = parseConfig(map[string]interface{}{"flushInterval": -1})
+	assert.Error(t, err)
+}
+
+func TestTimelineSaveInBatches(t *testing.T) {
+	r := &recorder{}
+	d := newTimeline(t, r, map[string]interface{}{"batchSize": 2, "flushInterval": 60})
+
+	d.PublishEvent(newEvent("ins-1", model.EventInstanceOnline, 100))
+	d.PublishEvent(newEvent("ins-1", model.EventInstanceUpdate, 50))
+	d.PublishEvent(newEvent("ins-1", model.EventDiscoverNone, 50))
+	d.PublishEvent(newEvent("ins-2", model.EventInstanceOffline, 100))
+
+	// 攒满一批后立即写入，未攒满的事件在插件销毁时写入
+	assert.Eventually(t, func() bool { return len(r.saved()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, d.Destroy())
+
+	events := r.saved()
+	assert.Len(t, events, 3)
+	assert.Equal(t, model.EventInstanceOnline, events[0].EventType)
+	assert.Equal(t, model.EventInstanceUpdate, events[1].EventType)
+	assert.Equal(t, uint32(50), events[1].Weight)
+	assert.Equal(t, "ins-2", events[2].InstanceID)
+	assert.Equal(t, "order", events[2].Service)
+	assert.True(t, events[2].Healthy)
+}
+
+func TestTimelineEventTypes(t *testing.T) {
+	r := &recorder{}
+	d := newTimeline(t, r, map[string]interface{}{"eventTypes": []string{"InstanceOffline"}})
+
+	d.PublishEvent(newEvent("ins-1", model.EventInstanceOnline, 100))
+	d.PublishEvent(newEvent("ins-1", model.EventInstanceOffline, 100))
+	assert.NoError(t, d.Destroy())
+
+	events := r.saved()
+	assert.Len(t, events, 1)
+	assert.Equal(t, model.EventInstanceOffline, events[0].EventType)
+}
+
+func TestTimelineKeepEventsOnFailure(t *testing.T) {
+	r := &recorder{fail: 1}
+	d := newTimeline(t, r, map[string]interface{}{"queueSize": 3, "batchSize": 1, "flushInterval": 1})
+
+	for i := 0; i < 5; i++ {
+		d.PublishEvent(newEvent("ins-1", model.EventInstanceUpdate, uint32(i)))
+		assert.Eventually(t, func() bool { return len(d.eventCh) == 0 }, time.Second, time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&r.calls) >= 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// 写入恢复后保留的事件继续写入，超过队列长度的最早事件被丢弃
+	atomic.StoreInt32(&r.fail, 0)
+	assert.Eventually(t, func() bool { return len(r.saved()) > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, d.Destroy())
+
+	events := r.saved()
+	assert.Len(t, events, 3)
+	assert.Equal(t, uint32(2), events[0].Weight)
+	assert.Equal(t, uint32(4), events[2].Weight)
+}
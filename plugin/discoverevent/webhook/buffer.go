@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const bufferFileSuffix = ".json"
+
+// ErrBufferTooLarge 单批数据超过落盘上限
+var ErrBufferTooLarge = errors.New("webhook batch exceeds buffer max size")
+
+// bufferFile 落盘的一批事件，文件名为 <写入时间>-<序号>-<事件数>.json，按文件名排序即为写入顺序
+type bufferFile struct {
+	name  string
+	size  int64
+	count int
+}
+
+// diskBuffer 有界的磁盘缓冲区，推送失败的事件按批落盘，目标恢复后按写入顺序补推
+type diskBuffer struct {
+	lock    sync.Mutex
+	dir     string
+	maxSize int64
+	size    int64
+	seq     uint64
+	files   []*bufferFile
+}
+
+// newDiskBuffer 创建磁盘缓冲区，并加载上次进程退出前未推送完成的数据
+func newDiskBuffer(dir string, maxSize int64) (*diskBuffer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &diskBuffer{
+		dir:     dir,
+		maxSize: maxSize,
+		files:   make([]*bufferFile, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), bufferFileSuffix) {
+			continue
+		}
+		count, ok := parseBufferFileCount(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		b.files = append(b.files, &bufferFile{name: entry.Name(), size: info.Size(), count: count})
+		b.size += info.Size()
+	}
+	sort.Slice(b.files, func(i, j int) bool {
+		return b.files[i].name < b.files[j].name
+	})
+	return b, nil
+}
+
+// Push 写入一批事件，空间不足时淘汰最早写入的数据，返回被淘汰的事件数
+func (b *diskBuffer) Push(data []byte, count int) (int, error) {
+	size := int64(len(data))
+	if size > b.maxSize {
+		return 0, ErrBufferTooLarge
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	evicted := 0
+	for len(b.files) > 0 && b.size+size > b.maxSize {
+		evicted += b.files[0].count
+		if err := b.removeLocked(b.files[0].name); err != nil {
+			return evicted, err
+		}
+	}
+
+	b.seq++
+	name := fmt.Sprintf("%020d-%010d-%d%s", time.Now().UnixNano(), b.seq, count, bufferFileSuffix)
+	tmp := filepath.Join(b.dir, name+".tmp")
+	// 缓冲的事件包含实例的地址以及元数据，和缓存快照一样只允许当前用户读写
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return evicted, err
+	}
+	if err := os.Rename(tmp, filepath.Join(b.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return evicted, err
+	}
+	b.files = append(b.files, &bufferFile{name: name, size: size, count: count})
+	b.size += size
+	return evicted, nil
+}
+
+// Peek 读取最早写入的一批事件
+func (b *diskBuffer) Peek() (*bufferFile, []byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for len(b.files) > 0 {
+		file := b.files[0]
+		data, err := os.ReadFile(filepath.Join(b.dir, file.name))
+		if err == nil {
+			return file, data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		// 文件被外部删除，直接跳过
+		b.files = b.files[1:]
+		b.size -= file.size
+	}
+	return nil, nil, nil
+}
+
+// Remove 删除已经推送成功的数据
+func (b *diskBuffer) Remove(file *bufferFile) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.removeLocked(file.name)
+}
+
+func (b *diskBuffer) removeLocked(name string) error {
+	for i := range b.files {
+		if b.files[i].name != name {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		b.size -= b.files[i].size
+		b.files = append(b.files[:i], b.files[i+1:]...)
+		return nil
+	}
+	return nil
+}
+
+// Len 落盘的批次数
+func (b *diskBuffer) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.files)
+}
+
+// Size 落盘数据的总大小
+func (b *diskBuffer) Size() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.size
+}
+
+func parseBufferFileCount(name string) (int, bool) {
+	items := strings.Split(strings.TrimSuffix(name, bufferFileSuffix), "-")
+	if len(items) != 3 {
+		return 0, false
+	}
+	count, err := strconv.Atoi(items[2])
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
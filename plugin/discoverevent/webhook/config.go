@@ -0,0 +1,223 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	defaultQueueSize     = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5
+	defaultTimeout       = 5
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = 500
+	defaultMaxBackoff    = 30000
+	defaultBufferDir     = "./polaris/webhook"
+	defaultBufferMaxSize = 64
+)
+
+// nameRegex endpoint 名称会作为落盘目录名，只允许文件名安全的字符
+var (
+	nameRegex        = regexp.MustCompile(`^[0-9A-Za-z_.\-]+$`)
+	invalidNameChars = regexp.MustCompile(`[^0-9A-Za-z_.\-]`)
+)
+
+// subscribeEvents 默认推送的事件类型，与 discoverEventLocal 保持一致
+var subscribeEvents = []model.InstanceEventType{
+	model.EventInstanceOnline,
+	model.EventInstanceOffline,
+	model.EventInstanceTurnHealth,
+	model.EventInstanceTurnUnHealth,
+	model.EventInstanceOpenIsolate,
+	model.EventInstanceCloseIsolate,
+}
+
+// Config webhook 插件配置
+type Config struct {
+	// QueueSize 等待分发的事件队列长度，队列满时丢弃事件
+	QueueSize int `json:"queueSize"`
+	// BatchSize 单次推送的最大事件数
+	BatchSize int `json:"batchSize"`
+	// FlushInterval 未攒满一批时的推送间隔，单位秒
+	FlushInterval int `json:"flushInterval"`
+	// Timeout 单次 HTTP 请求超时时间，单位秒
+	Timeout int `json:"timeout"`
+	// MaxRetries 推送失败后的最大重试次数
+	MaxRetries int `json:"maxRetries"`
+	// RetryBackoff 首次重试的退避时间，之后每次翻倍，单位毫秒
+	RetryBackoff int `json:"retryBackoff"`
+	// MaxBackoff 退避时间上限，单位毫秒
+	MaxBackoff int `json:"maxBackoff"`
+	// BufferDir 推送失败的事件落盘目录，每个 endpoint 使用独立的子目录
+	BufferDir string `json:"bufferDir"`
+	// BufferMaxSize 每个 endpoint 落盘数据的大小上限，超过后淘汰最早的数据，单位 MB
+	BufferMaxSize int `json:"bufferMaxSize"`
+	// Endpoints 推送目标
+	Endpoints []*EndpointConfig `json:"endpoints"`
+}
+
+// EndpointConfig 推送目标配置
+type EndpointConfig struct {
+	// Name endpoint 名称，用于监控指标以及落盘目录，不填时根据 URL 生成
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret 签名密钥，配置后请求会携带 HMAC-SHA256 签名，支持密码插件加密后的密文
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers"`
+	// Namespaces 只推送这些命名空间的事件，为空表示不过滤
+	Namespaces []string `json:"namespaces"`
+	// Services 只推送这些服务的事件，为空表示不过滤
+	Services []string `json:"services"`
+	// EventTypes 只推送这些类型的事件，为空时推送实例上下线、健康状态及隔离状态变化事件
+	EventTypes []string `json:"eventTypes"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		QueueSize:     defaultQueueSize,
+		BatchSize:     defaultBatchSize,
+		FlushInterval: defaultFlushInterval,
+		Timeout:       defaultTimeout,
+		MaxRetries:    defaultMaxRetries,
+		RetryBackoff:  defaultRetryBackoff,
+		MaxBackoff:    defaultMaxBackoff,
+		BufferDir:     defaultBufferDir,
+		BufferMaxSize: defaultBufferMaxSize,
+	}
+}
+
+// parseConfig 解析插件配置
+func parseConfig(option map[string]interface{}) (*Config, error) {
+	config := defaultConfig()
+	if len(option) != 0 {
+		contentBytes, err := json.Marshal(option)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(contentBytes, config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate 检查配置是否正确
+func (c *Config) Validate() error {
+	if c.QueueSize <= 0 {
+		return errors.New("queueSize is <= 0")
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("batchSize is <= 0")
+	}
+	if c.FlushInterval <= 0 {
+		return errors.New("flushInterval is <= 0")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("timeout is <= 0")
+	}
+	if c.MaxRetries < 0 {
+		return errors.New("maxRetries is < 0")
+	}
+	if c.RetryBackoff <= 0 || c.MaxBackoff < c.RetryBackoff {
+		return errors.New("retryBackoff must be > 0 and <= maxBackoff")
+	}
+	if c.BufferDir == "" {
+		return errors.New("bufferDir is empty")
+	}
+	if c.BufferMaxSize <= 0 {
+		return errors.New("bufferMaxSize is <= 0")
+	}
+	if len(c.Endpoints) == 0 {
+		return errors.New("endpoints is empty")
+	}
+
+	names := make(map[string]struct{}, len(c.Endpoints))
+	for _, endpoint := range c.Endpoints {
+		if err := endpoint.validate(); err != nil {
+			return err
+		}
+		if _, ok := names[endpoint.Name]; ok {
+			return fmt.Errorf("duplicate endpoint name %s", endpoint.Name)
+		}
+		names[endpoint.Name] = struct{}{}
+	}
+	return nil
+}
+
+// validate 检查 endpoint 配置，并补齐默认的名称
+func (e *EndpointConfig) validate() error {
+	if e == nil {
+		return errors.New("endpoint is nil")
+	}
+	target, err := url.Parse(e.URL)
+	if err != nil {
+		return fmt.Errorf("endpoint url %s is invalid: %w", e.URL, err)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("endpoint url %s is invalid", e.URL)
+	}
+	for _, eventType := range e.EventTypes {
+		if !isSubscribeEvent(model.InstanceEventType(eventType)) {
+			return fmt.Errorf("endpoint %s unsupported event type %s", e.URL, eventType)
+		}
+	}
+	if e.Name == "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(e.URL))
+		e.Name = fmt.Sprintf("%s-%08x", target.Hostname(), h.Sum32())
+		e.Name = invalidNameChars.ReplaceAllString(e.Name, "_")
+	}
+	if !nameRegex.MatchString(e.Name) || e.Name == "." || e.Name == ".." {
+		return fmt.Errorf("endpoint name %s is invalid", e.Name)
+	}
+	if e.Secret, err = plugin.ParseSecret(e.Secret); err != nil {
+		return fmt.Errorf("endpoint %s parse secret: %w", e.Name, err)
+	}
+	return nil
+}
+
+func isSubscribeEvent(eventType model.InstanceEventType) bool {
+	for _, item := range subscribeEvents {
+		if item == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) flushInterval() time.Duration {
+	return time.Duration(c.FlushInterval) * time.Second
+}
+
+func (c *Config) bufferMaxBytes() int64 {
+	return int64(c.BufferMaxSize) * 1024 * 1024
+}
@@ -0,0 +1,295 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// batch 一次推送的数据
+type batch struct {
+	data  []byte
+	count int
+}
+
+// deliverError 推送失败，retryable 为 false 表示接收方拒绝了请求，重试也不会成功
+type deliverError struct {
+	retryable bool
+	err       error
+}
+
+func (e *deliverError) Error() string {
+	return e.err.Error()
+}
+
+// endpoint 单个推送目标，持有独立的发送队列以及磁盘缓冲区
+type endpoint struct {
+	conf       *EndpointConfig
+	filter     *eventFilter
+	client     *http.Client
+	batchSize  int
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	interval   time.Duration
+	buffer     *diskBuffer
+	// pending 尚未攒满一批的事件，只在分发协程中读写
+	pending []*EventMessage
+	batchCh chan *batch
+}
+
+func newEndpoint(conf *Config, endpointConf *EndpointConfig) (*endpoint, error) {
+	buffer, err := newDiskBuffer(filepath.Join(conf.BufferDir, endpointConf.Name), conf.bufferMaxBytes())
+	if err != nil {
+		return nil, err
+	}
+	e := &endpoint{
+		conf:       endpointConf,
+		filter:     newEventFilter(endpointConf),
+		client:     &http.Client{Timeout: time.Duration(conf.Timeout) * time.Second},
+		batchSize:  conf.BatchSize,
+		maxRetries: conf.MaxRetries,
+		backoff:    time.Duration(conf.RetryBackoff) * time.Millisecond,
+		maxBackoff: time.Duration(conf.MaxBackoff) * time.Millisecond,
+		interval:   conf.flushInterval(),
+		buffer:     buffer,
+		pending:    make([]*EventMessage, 0, conf.BatchSize),
+		batchCh:    make(chan *batch, 16),
+	}
+	bufferBytes.WithLabelValues(e.conf.Name).Set(float64(buffer.Size()))
+	return e, nil
+}
+
+// add 添加一个待推送的事件，攒满一批后交给发送协程
+func (e *endpoint) add(event *EventMessage) {
+	if !e.filter.match(event) {
+		return
+	}
+	e.pending = append(e.pending, event)
+	if len(e.pending) >= e.batchSize {
+		e.flush()
+	}
+}
+
+// flush 将未攒满的事件交给发送协程，发送协程繁忙时直接落盘
+func (e *endpoint) flush() {
+	b := e.takePending()
+	if b == nil {
+		return
+	}
+	select {
+	case e.batchCh <- b:
+	default:
+		e.bufferBatch(b)
+	}
+}
+
+// close 进程退出前将尚未推送的事件全部落盘，下次启动后继续推送
+func (e *endpoint) close() {
+	if b := e.takePending(); b != nil {
+		e.bufferBatch(b)
+	}
+	for {
+		select {
+		case b := <-e.batchCh:
+			e.bufferBatch(b)
+		default:
+			return
+		}
+	}
+}
+
+func (e *endpoint) takePending() *batch {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	events := e.pending
+	e.pending = make([]*EventMessage, 0, e.batchSize)
+	data, err := json.Marshal(newPayload(events))
+	if err != nil {
+		log.Errorf("[Webhook] endpoint(%s) marshal events err: %s", e.conf.Name, err.Error())
+		eventsTotal.WithLabelValues(e.conf.Name, resultDropped).Add(float64(len(events)))
+		return nil
+	}
+	return &batch{data: data, count: len(events)}
+}
+
+// run 发送协程，缓冲区有数据时新的批次先落盘，保证事件按照产生的顺序推送
+func (e *endpoint) run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case b := <-e.batchCh:
+			if e.buffer.Len() > 0 {
+				e.bufferBatch(b)
+				e.replay(ctx)
+				continue
+			}
+			e.deliverBatch(ctx, b)
+		case <-ticker.C:
+			e.replay(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *endpoint) deliverBatch(ctx context.Context, b *batch) {
+	err := e.deliver(ctx, b.data, b.count)
+	if err == nil {
+		eventsTotal.WithLabelValues(e.conf.Name, resultDelivered).Add(float64(b.count))
+		return
+	}
+	var deliverErr *deliverError
+	if errors.As(err, &deliverErr) && !deliverErr.retryable {
+		log.Errorf("[Webhook] endpoint(%s) reject %d events: %s", e.conf.Name, b.count, err.Error())
+		eventsTotal.WithLabelValues(e.conf.Name, resultRejected).Add(float64(b.count))
+		return
+	}
+	log.Warnf("[Webhook] endpoint(%s) deliver %d events err: %s, buffer them", e.conf.Name, b.count, err.Error())
+	e.bufferBatch(b)
+}
+
+// replay 按写入顺序补推缓冲区中的数据，遇到可重试的错误时停止，等待下次补推
+func (e *endpoint) replay(ctx context.Context) {
+	defer func() {
+		bufferBytes.WithLabelValues(e.conf.Name).Set(float64(e.buffer.Size()))
+	}()
+
+	for ctx.Err() == nil {
+		file, data, err := e.buffer.Peek()
+		if err != nil {
+			log.Errorf("[Webhook] endpoint(%s) read buffer err: %s", e.conf.Name, err.Error())
+			return
+		}
+		if file == nil {
+			return
+		}
+
+		err = e.deliver(ctx, data, file.count)
+		var deliverErr *deliverError
+		switch {
+		case err == nil:
+			eventsTotal.WithLabelValues(e.conf.Name, resultDelivered).Add(float64(file.count))
+		case errors.As(err, &deliverErr) && !deliverErr.retryable:
+			log.Errorf("[Webhook] endpoint(%s) reject %d buffered events: %s", e.conf.Name, file.count, err.Error())
+			eventsTotal.WithLabelValues(e.conf.Name, resultRejected).Add(float64(file.count))
+		default:
+			log.Warnf("[Webhook] endpoint(%s) replay buffered events err: %s", e.conf.Name, err.Error())
+			return
+		}
+		if err := e.buffer.Remove(file); err != nil {
+			log.Errorf("[Webhook] endpoint(%s) remove buffer err: %s", e.conf.Name, err.Error())
+			return
+		}
+	}
+}
+
+// bufferBatch 落盘等待补推
+func (e *endpoint) bufferBatch(b *batch) {
+	evicted, err := e.buffer.Push(b.data, b.count)
+	if evicted > 0 {
+		log.Warnf("[Webhook] endpoint(%s) buffer is full, drop %d oldest events", e.conf.Name, evicted)
+		eventsTotal.WithLabelValues(e.conf.Name, resultDropped).Add(float64(evicted))
+	}
+	if err != nil {
+		log.Errorf("[Webhook] endpoint(%s) buffer %d events err: %s", e.conf.Name, b.count, err.Error())
+		eventsTotal.WithLabelValues(e.conf.Name, resultDropped).Add(float64(b.count))
+		return
+	}
+	eventsTotal.WithLabelValues(e.conf.Name, resultBuffered).Add(float64(b.count))
+	bufferBytes.WithLabelValues(e.conf.Name).Set(float64(e.buffer.Size()))
+}
+
+// deliver 推送一批事件，失败时按指数退避重试
+func (e *endpoint) deliver(ctx context.Context, data []byte, count int) error {
+	for attempt := 0; ; attempt++ {
+		err := e.send(ctx, data, count)
+		if err == nil {
+			return nil
+		}
+		var deliverErr *deliverError
+		if (errors.As(err, &deliverErr) && !deliverErr.retryable) || attempt >= e.maxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(e.retryBackoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// retryBackoff 第 attempt 次重试前的等待时间，在退避时间的 [1/2, 1] 区间内随机
+func (e *endpoint) retryBackoff(attempt int) time.Duration {
+	backoff := e.maxBackoff
+	if attempt < 32 && e.backoff<<attempt < e.maxBackoff {
+		backoff = e.backoff << attempt
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func (e *endpoint) send(ctx context.Context, data []byte, count int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.conf.URL, bytes.NewReader(data))
+	if err != nil {
+		return &deliverError{retryable: false, err: err}
+	}
+	for key, value := range e.conf.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventCount, strconv.Itoa(count))
+	if e.conf.Secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(HeaderSignature, Sign(e.conf.Secret, timestamp, data))
+	}
+
+	start := time.Now()
+	rsp, err := e.client.Do(req)
+	requestDuration.WithLabelValues(e.conf.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		requestsTotal.WithLabelValues(e.conf.Name, "error").Inc()
+		return &deliverError{retryable: true, err: err}
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(rsp.Body, 4096))
+
+	requestsTotal.WithLabelValues(e.conf.Name, strconv.Itoa(rsp.StatusCode)).Inc()
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+	// 限流以及服务端错误可以重试，其余的 4xx 说明请求本身有问题
+	retryable := rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500
+	return &deliverError{retryable: retryable, err: fmt.Errorf("unexpected status code %d", rsp.StatusCode)}
+}
@@ -0,0 +1,158 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// HeaderSignature 请求体签名，格式为 sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+	HeaderSignature = "X-Polaris-Signature"
+	// HeaderTimestamp 签名使用的时间戳，单位秒
+	HeaderTimestamp = "X-Polaris-Timestamp"
+	// HeaderEventCount 本次推送的事件数
+	HeaderEventCount = "X-Polaris-Event-Count"
+)
+
+// Payload webhook 推送的请求体
+type Payload struct {
+	Server string          `json:"server"`
+	Events []*EventMessage `json:"events"`
+}
+
+// EventMessage 推送的实例事件
+type EventMessage struct {
+	ID         string                  `json:"id"`
+	Type       model.InstanceEventType `json:"type"`
+	Namespace  string                  `json:"namespace"`
+	Service    string                  `json:"service"`
+	Instance   *InstanceMessage        `json:"instance"`
+	Metadata   map[string]string       `json:"metadata,omitempty"`
+	CreateTime time.Time               `json:"createTime"`
+}
+
+// InstanceMessage 事件对应的实例信息
+type InstanceMessage struct {
+	ID       string            `json:"id"`
+	Host     string            `json:"host"`
+	Port     uint32            `json:"port"`
+	Protocol string            `json:"protocol,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Weight   uint32            `json:"weight"`
+	Healthy  bool              `json:"healthy"`
+	Isolate  bool              `json:"isolate"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func newEventMessage(event model.InstanceEvent) *EventMessage {
+	ins := event.Instance
+	return &EventMessage{
+		ID:        event.Id,
+		Type:      event.EType,
+		Namespace: event.Namespace,
+		Service:   event.Service,
+		Instance: &InstanceMessage{
+			ID:       ins.GetId().GetValue(),
+			Host:     ins.GetHost().GetValue(),
+			Port:     ins.GetPort().GetValue(),
+			Protocol: ins.GetProtocol().GetValue(),
+			Version:  ins.GetVersion().GetValue(),
+			Weight:   ins.GetWeight().GetValue(),
+			Healthy:  ins.GetHealthy().GetValue(),
+			Isolate:  ins.GetIsolate().GetValue(),
+			Metadata: ins.GetMetadata(),
+		},
+		Metadata:   event.MetaData,
+		CreateTime: event.CreateTime,
+	}
+}
+
+func newPayload(events []*EventMessage) *Payload {
+	return &Payload{
+		Server: utils.LocalHost,
+		Events: events,
+	}
+}
+
+// Sign 计算请求体签名，接收方可以使用相同的方式校验请求
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// eventFilter 按命名空间、服务以及事件类型过滤事件
+type eventFilter struct {
+	namespaces map[string]struct{}
+	services   map[string]struct{}
+	eventTypes map[model.InstanceEventType]struct{}
+}
+
+func newEventFilter(conf *EndpointConfig) *eventFilter {
+	f := &eventFilter{
+		namespaces: toSet(conf.Namespaces),
+		services:   toSet(conf.Services),
+		eventTypes: make(map[model.InstanceEventType]struct{}),
+	}
+	eventTypes := conf.EventTypes
+	if len(eventTypes) == 0 {
+		for _, eventType := range subscribeEvents {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+	}
+	for _, eventType := range eventTypes {
+		f.eventTypes[model.InstanceEventType(eventType)] = struct{}{}
+	}
+	return f
+}
+
+// match 判断事件是否需要推送
+func (f *eventFilter) match(event *EventMessage) bool {
+	if _, ok := f.eventTypes[event.Type]; !ok {
+		return false
+	}
+	if len(f.namespaces) != 0 {
+		if _, ok := f.namespaces[event.Namespace]; !ok {
+			return false
+		}
+	}
+	if len(f.services) != 0 {
+		if _, ok := f.services[event.Service]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func toSet(values []string) map[string]struct{} {
+	ret := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		ret[value] = struct{}{}
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	labelEndpoint = "endpoint"
+	labelResult   = "result"
+	labelCode     = "code"
+
+	// resultDelivered 推送成功
+	resultDelivered = "delivered"
+	// resultBuffered 推送失败或者发送队列已满，落盘等待补推
+	resultBuffered = "buffered"
+	// resultRejected 接收方返回了不可重试的错误，事件被丢弃
+	resultRejected = "rejected"
+	// resultDropped 事件队列已满或者落盘数据被淘汰，事件被丢弃
+	resultDropped = "dropped"
+)
+
+var (
+	eventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "discover_event_webhook_events_total",
+		Help: "number of discover events handled by the webhook plugin",
+		ConstLabels: map[string]string{
+			metrics.LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelEndpoint, labelResult})
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "discover_event_webhook_requests_total",
+		Help: "number of webhook requests and the response code",
+		ConstLabels: map[string]string{
+			metrics.LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelEndpoint, labelCode})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "discover_event_webhook_request_duration_seconds",
+		Help: "webhook request duration",
+		ConstLabels: map[string]string{
+			metrics.LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelEndpoint})
+
+	bufferBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "discover_event_webhook_buffer_bytes",
+		Help: "size of the webhook events buffered on disk",
+		ConstLabels: map[string]string{
+			metrics.LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelEndpoint})
+)
+
+// registerMetrics 注册推送指标，插件重复初始化时忽略已注册的错误
+func registerMetrics() error {
+	for _, collector := range []prometheus.Collector{eventsTotal, requestsTotal, requestDuration, bufferBytes} {
+		if err := metrics.GetRegistry().Register(collector); err != nil {
+			var registered prometheus.AlreadyRegisteredError
+			if !errors.As(err, &registered) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// PluginName 插件名称
+	PluginName = "discoverEventWebhook"
+	// allEndpoints 事件进入分发队列前被丢弃时使用的 endpoint 标签
+	allEndpoints = "*"
+)
+
+var log = commonlog.RegisterScope(PluginName, "", 0)
+
+func init() {
+	d := &discoverEventWebhook{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
+
+// discoverEventWebhook 将服务实例事件批量推送到配置的 webhook 地址
+type discoverEventWebhook struct {
+	conf      *Config
+	eventCh   chan model.InstanceEvent
+	endpoints []*endpoint
+	cancel    context.CancelFunc
+	wait      sync.WaitGroup
+}
+
+// Name 插件名称
+func (w *discoverEventWebhook) Name() string {
+	return PluginName
+}
+
+// Initialize 根据配置文件进行初始化插件 discoverEventWebhook
+func (w *discoverEventWebhook) Initialize(conf *plugin.ConfigEntry) error {
+	config, err := parseConfig(conf.Option)
+	if err != nil {
+		return err
+	}
+	if err := registerMetrics(); err != nil {
+		return err
+	}
+
+	w.conf = config
+	w.eventCh = make(chan model.InstanceEvent, config.QueueSize)
+	w.endpoints = make([]*endpoint, 0, len(config.Endpoints))
+	for _, endpointConf := range config.Endpoints {
+		e, err := newEndpoint(config, endpointConf)
+		if err != nil {
+			return err
+		}
+		w.endpoints = append(w.endpoints, e)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wait.Add(len(w.endpoints) + 1)
+	for i := range w.endpoints {
+		go func(e *endpoint) {
+			defer w.wait.Done()
+			e.run(ctx)
+		}(w.endpoints[i])
+	}
+	go func() {
+		defer w.wait.Done()
+		w.dispatch(ctx)
+	}()
+	return nil
+}
+
+// Destroy 执行插件销毁，尚未推送的事件会落盘，下次启动后继续推送
+func (w *discoverEventWebhook) Destroy() error {
+	if w.cancel != nil {
+		w.cancel()
+		w.wait.Wait()
+	}
+	return nil
+}
+
+// PublishEvent 发布一个服务事件，队列已满时丢弃
+func (w *discoverEventWebhook) PublishEvent(event model.InstanceEvent) {
+	select {
+	case w.eventCh <- event:
+	default:
+		eventsTotal.WithLabelValues(allEndpoints, resultDropped).Inc()
+	}
+}
+
+// dispatch 按 endpoint 的过滤条件分发事件，并定时将未攒满一批的事件推送出去
+func (w *discoverEventWebhook) dispatch(ctx context.Context) {
+	ticker := time.NewTicker(w.conf.flushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-w.eventCh:
+			w.route(event)
+		case <-ticker.C:
+			for _, e := range w.endpoints {
+				e.flush()
+			}
+		case <-ctx.Done():
+			w.close()
+			return
+		}
+	}
+}
+
+func (w *discoverEventWebhook) route(event model.InstanceEvent) {
+	if !isSubscribeEvent(event.EType) {
+		return
+	}
+	if event.CreateTime.IsZero() {
+		event.CreateTime = time.Now()
+	}
+	message := newEventMessage(event)
+	for _, e := range w.endpoints {
+		e.add(message)
+	}
+}
+
+// close 将队列中剩余的事件分发完毕后全部落盘
+func (w *discoverEventWebhook) close() {
+	for {
+		select {
+		case event := <-w.eventCh:
+			w.route(event)
+		default:
+			for _, e := range w.endpoints {
+				e.close()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,296 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// receiver 记录收到的 webhook 请求，statusFn 控制每次请求的返回码
+type receiver struct {
+	lock     sync.Mutex
+	secret   string
+	events   []*EventMessage
+	requests int32
+	statusFn func(n int32) int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	n := atomic.AddInt32(&r.requests, 1)
+	if code := r.statusFn(n); code != http.StatusOK {
+		w.WriteHeader(code)
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	if r.secret != "" {
+		timestamp, _ := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+		if Sign(r.secret, timestamp, body) != req.Header.Get(HeaderSignature) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	payload := &Payload{}
+	if err := json.Unmarshal(body, payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if strconv.Itoa(len(payload.Events)) != req.Header.Get(HeaderEventCount) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.lock.Lock()
+	r.events = append(r.events, payload.Events...)
+	r.lock.Unlock()
+}
+
+func (r *receiver) received() []*EventMessage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*EventMessage{}, r.events...)
+}
+
+func newTestEvent(id, namespace, service string, eventType model.InstanceEventType) model.InstanceEvent {
+	return model.InstanceEvent{
+		Id:        id,
+		Namespace: namespace,
+		Service:   service,
+		EType:     eventType,
+		Instance: &apiservice.Instance{
+			Id:   &wrappers.StringValue{Value: id},
+			Host: &wrappers.StringValue{Value: "127.0.0.1"},
+			Port: &wrappers.UInt32Value{Value: 8080},
+		},
+	}
+}
+
+func newTestPlugin(t *testing.T, option map[string]interface{}) *discoverEventWebhook {
+	option["bufferDir"] = t.TempDir()
+	option["flushInterval"] = 1
+	w := &discoverEventWebhook{}
+	assert.NoError(t, w.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option}))
+	t.Cleanup(func() {
+		_ = w.Destroy()
+	})
+	return w
+}
+
+func Test_discoverEventWebhook_Filter(t *testing.T) {
+	all := &receiver{secret: "polaris", statusFn: func(int32) int { return http.StatusOK }}
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+	filtered := &receiver{statusFn: func(int32) int { return http.StatusOK }}
+	filteredServer := httptest.NewServer(filtered)
+	defer filteredServer.Close()
+
+	w := newTestPlugin(t, map[string]interface{}{
+		"batchSize": 2,
+		"endpoints": []map[string]interface{}{
+			{"url": allServer.URL, "secret": "polaris"},
+			{
+				"url":        filteredServer.URL,
+				"namespaces": []string{"Test"},
+				"services":   []string{"svc-a"},
+				"eventTypes": []string{string(model.EventInstanceOffline)},
+			},
+		},
+	})
+
+	w.PublishEvent(newTestEvent("1", "Test", "svc-a", model.EventInstanceOnline))
+	w.PublishEvent(newTestEvent("2", "Test", "svc-a", model.EventInstanceOffline))
+	w.PublishEvent(newTestEvent("3", "Test", "svc-b", model.EventInstanceOffline))
+	w.PublishEvent(newTestEvent("4", "Other", "svc-a", model.EventInstanceOffline))
+	w.PublishEvent(newTestEvent("5", "Test", "svc-a", model.EventInstanceSendHeartbeat))
+
+	assert.Eventually(t, func() bool {
+		return len(all.received()) == 4 && len(filtered.received()) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	ids := make([]string, 0, 4)
+	for _, event := range all.received() {
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+	event := filtered.received()[0]
+	assert.Equal(t, "2", event.ID)
+	assert.Equal(t, model.EventInstanceOffline, event.Type)
+	assert.Equal(t, "127.0.0.1", event.Instance.Host)
+	assert.Equal(t, uint32(8080), event.Instance.Port)
+	assert.False(t, event.CreateTime.IsZero())
+}
+
+func Test_discoverEventWebhook_RetryAndReplay(t *testing.T) {
+	// 前 3 次请求失败，事件落盘后按顺序补推
+	r := &receiver{statusFn: func(n int32) int {
+		if n <= 3 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	w := newTestPlugin(t, map[string]interface{}{
+		"batchSize":    1,
+		"maxRetries":   1,
+		"retryBackoff": 10,
+		"maxBackoff":   20,
+		"endpoints":    []map[string]interface{}{{"name": "test", "url": server.URL}},
+	})
+
+	for i := 1; i <= 3; i++ {
+		w.PublishEvent(newTestEvent(strconv.Itoa(i), "Test", "svc", model.EventInstanceOnline))
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(r.received()) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+	for i, event := range r.received() {
+		assert.Equal(t, strconv.Itoa(i+1), event.ID)
+	}
+	assert.Eventually(t, func() bool {
+		return w.endpoints[0].buffer.Len() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_discoverEventWebhook_Rejected(t *testing.T) {
+	r := &receiver{statusFn: func(int32) int { return http.StatusBadRequest }}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	w := newTestPlugin(t, map[string]interface{}{
+		"batchSize": 1,
+		"endpoints": []map[string]interface{}{{"name": "test", "url": server.URL}},
+	})
+	w.PublishEvent(newTestEvent("1", "Test", "svc", model.EventInstanceOnline))
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&r.requests) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// 不可重试的错误既不重试也不落盘
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.requests))
+	assert.Equal(t, 0, w.endpoints[0].buffer.Len())
+}
+
+func Test_discoverEventWebhook_DestroyBuffer(t *testing.T) {
+	dir := t.TempDir()
+	w := &discoverEventWebhook{}
+	assert.NoError(t, w.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: map[string]interface{}{
+		"bufferDir":     dir,
+		"flushInterval": 60,
+		"endpoints":     []map[string]interface{}{{"name": "test", "url": "http://127.0.0.1:1"}},
+	}}))
+	w.PublishEvent(newTestEvent("1", "Test", "svc", model.EventInstanceOnline))
+	w.PublishEvent(newTestEvent("2", "Test", "svc", model.EventInstanceOffline))
+	assert.NoError(t, w.Destroy())
+
+	// 未推送的事件在退出时落盘，重启后可以继续推送
+	buffer, err := newDiskBuffer(dir+"/test", 1024*1024)
+	assert.NoError(t, err)
+	file, data, err := buffer.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, file.count)
+	payload := &Payload{}
+	assert.NoError(t, json.Unmarshal(data, payload))
+	assert.Len(t, payload.Events, 2)
+}
+
+func Test_diskBuffer(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := newDiskBuffer(dir, 10)
+	assert.NoError(t, err)
+
+	_, err = buffer.Push([]byte("01234567890"), 1)
+	assert.ErrorIs(t, err, ErrBufferTooLarge)
+
+	evicted, err := buffer.Push([]byte("aaaa"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, evicted)
+	file, _, err := buffer.Peek()
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, file.name))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	evicted, err = buffer.Push([]byte("bbbb"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, evicted)
+	// 空间不足时淘汰最早的数据
+	evicted, err = buffer.Push([]byte("cccc"), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, evicted)
+	assert.Equal(t, 2, buffer.Len())
+	assert.Equal(t, int64(8), buffer.Size())
+
+	reload, err := newDiskBuffer(dir, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reload.Len())
+	for _, expect := range []string{"bbbb", "cccc"} {
+		file, data, err := reload.Peek()
+		assert.NoError(t, err)
+		assert.Equal(t, expect, string(data))
+		assert.NoError(t, reload.Remove(file))
+	}
+	file, _, err = reload.Peek()
+	assert.NoError(t, err)
+	assert.Nil(t, file)
+	assert.Equal(t, int64(0), reload.Size())
+}
+
+func Test_parseConfig(t *testing.T) {
+	_, err := parseConfig(map[string]interface{}{})
+	assert.Error(t, err)
+
+	_, err = parseConfig(map[string]interface{}{
+		"endpoints": []map[string]interface{}{{"url": "127.0.0.1:8080"}},
+	})
+	assert.Error(t, err)
+
+	_, err = parseConfig(map[string]interface{}{
+		"endpoints": []map[string]interface{}{{"url": "http://127.0.0.1", "eventTypes": []string{"Unknown"}}},
+	})
+	assert.Error(t, err)
+
+	_, err = parseConfig(map[string]interface{}{
+		"endpoints": []map[string]interface{}{{"name": "a/b", "url": "http://127.0.0.1"}},
+	})
+	assert.Error(t, err)
+
+	conf, err := parseConfig(map[string]interface{}{
+		"endpoints": []map[string]interface{}{{"url": "http://[::1]:8080/hook"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultBatchSize, conf.BatchSize)
+	assert.Regexp(t, nameRegex, conf.Endpoints[0].Name)
+}
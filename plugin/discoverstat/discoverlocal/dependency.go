@@ -0,0 +1,92 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package discoverlocal
+
+import (
+	"fmt"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// DependencyCollector 根据主调方的服务发现调用汇总服务依赖关系，并定期批量写入存储层
+type DependencyCollector struct {
+	deps map[string]*model.ServiceDependency
+	save func(deps []*model.ServiceDependency) error
+}
+
+func newDependencyCollector(save func(deps []*model.ServiceDependency) error) *DependencyCollector {
+	return &DependencyCollector{
+		deps: make(map[string]*model.ServiceDependency),
+		save: save,
+	}
+}
+
+// add 汇总一次服务发现调用，无法识别主调服务的调用直接忽略
+func (d *DependencyCollector) add(dc *DiscoverCall) {
+	caller := dc.caller
+	if caller == nil || caller.Service == "" {
+		return
+	}
+
+	dep := &model.ServiceDependency{
+		CallerNamespace: caller.Namespace,
+		CallerService:   caller.Service,
+		CallerHosts:     model.MergeDependencyHosts([]string{caller.Host}, nil),
+		CallerVerified:  caller.Verified,
+		CalleeNamespace: dc.namespace,
+		CalleeService:   dc.service,
+		FirstSeen:       dc.time,
+		LastSeen:        dc.time,
+	}
+	if saved, ok := d.deps[dep.Key()]; ok {
+		saved.Merge(dep)
+		return
+	}
+	d.deps[dep.Key()] = dep
+}
+
+// flush 将汇总的依赖关系写入存储层，写入失败时保留数据等待下一次写入
+func (d *DependencyCollector) flush() {
+	if len(d.deps) == 0 {
+		return
+	}
+
+	deps := make([]*model.ServiceDependency, 0, len(d.deps))
+	for _, dep := range d.deps {
+		deps = append(deps, dep)
+	}
+	if err := d.save(deps); err != nil {
+		log.Errorf("[DiscoverStatis] save %d service dependencies err: %s", len(deps), err.Error())
+		return
+	}
+	d.deps = make(map[string]*model.ServiceDependency)
+}
+
+// saveStoreDependencies 将依赖关系写入存储层，存储插件不支持时返回错误
+func saveStoreDependencies(deps []*model.ServiceDependency) error {
+	s, err := store.GetStore()
+	if err != nil {
+		return err
+	}
+	ds, ok := s.(store.DependencyStore)
+	if !ok {
+		return fmt.Errorf("store %s does not support service dependencies", s.Name())
+	}
+	return ds.UpsertServiceDependencies(deps)
+}
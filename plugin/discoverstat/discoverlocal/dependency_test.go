@@ -0,0 +1,74 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package discoverlocal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func TestDependencyCollector(t *testing.T) {
+	var (
+		saved   []*model.ServiceDependency
+		saveErr error
+	)
+	collector := newDependencyCollector(func(deps []*model.ServiceDependency) error {
+		if saveErr != nil {
+			return saveErr
+		}
+		saved = append(saved, deps...)
+		return nil
+	})
+
+	start := time.Unix(1700000000, 0)
+	caller := &plugin.DiscoverCaller{Host: "10.0.0.1", Service: "order", Namespace: "default"}
+	collector.add(&DiscoverCall{service: "payment", namespace: "default", time: start.Add(time.Second),
+		caller: caller})
+	// 同一主调服务的不同实例归并为一条依赖关系
+	collector.add(&DiscoverCall{service: "payment", namespace: "default", time: start,
+		caller: &plugin.DiscoverCaller{Host: "10.0.0.2", Service: "order", Namespace: "default", Verified: true}})
+	// 无法识别主调服务的调用不会产生依赖关系
+	collector.add(&DiscoverCall{service: "payment", namespace: "default", time: start})
+	collector.add(&DiscoverCall{service: "payment", namespace: "default", time: start,
+		caller: &plugin.DiscoverCaller{Host: "10.0.0.3"}})
+
+	saveErr = errors.New("store unavailable")
+	collector.flush()
+	assert.Empty(t, saved)
+	assert.Len(t, collector.deps, 1)
+
+	saveErr = nil
+	collector.flush()
+	assert.Empty(t, collector.deps)
+	assert.Len(t, saved, 1)
+	assert.Equal(t, "order", saved[0].CallerService)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, saved[0].CallerHosts)
+	assert.True(t, saved[0].CallerVerified)
+	assert.Equal(t, "payment", saved[0].CalleeService)
+	assert.Equal(t, start, saved[0].FirstSeen)
+	assert.Equal(t, start.Add(time.Second), saved[0].LastSeen)
+
+	collector.flush()
+	assert.Len(t, saved, 1)
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/plugin"
 )
 
 // DiscoverCall 服务发现统计
@@ -29,6 +30,7 @@ type DiscoverCall struct {
 	service   string
 	namespace string
 	time      time.Time
+	caller    *plugin.DiscoverCaller
 }
 
 // Service 服务
@@ -27,6 +27,9 @@ import (
 
 const (
 	PluginName = "discoverLocal"
+
+	// defaultFlushInterval 服务依赖关系写入存储层的默认周期
+	defaultFlushInterval = 30 * time.Second
 )
 
 var log = commonLog.RegisterScope(PluginName, "", 0)
@@ -43,6 +46,11 @@ type DiscoverStatisWorker struct {
 
 	dcc chan *DiscoverCall
 	dcs *DiscoverCallStatis
+
+	// flushInterval 服务依赖关系写入存储层的周期
+	flushInterval time.Duration
+	// dependency 服务依赖关系汇总，未开启时为 nil
+	dependency *DependencyCollector
 }
 
 // Name 获取插件名称
@@ -62,6 +70,16 @@ func (d *DiscoverStatisWorker) Initialize(conf *plugin.ConfigEntry) error {
 		statis: make(map[Service]time.Time),
 	}
 
+	// 开启后根据主调方的服务发现调用记录服务依赖关系
+	if enable, _ := conf.Option["dependency"].(bool); enable {
+		flushInterval, _ := conf.Option["flushInterval"].(int)
+		d.flushInterval = time.Duration(flushInterval) * time.Second
+		if d.flushInterval <= 0 {
+			d.flushInterval = defaultFlushInterval
+		}
+		d.dependency = newDependencyCollector(saveStoreDependencies)
+	}
+
 	go d.Run()
 
 	return nil
@@ -87,17 +105,47 @@ func (d *DiscoverStatisWorker) AddDiscoverCall(service, namespace string, tt tim
 	return nil
 }
 
+// AddDependencyCall 上报携带主调方信息的请求
+func (d *DiscoverStatisWorker) AddDependencyCall(caller *plugin.DiscoverCaller, service, namespace string,
+	tt time.Time) error {
+	select {
+	case d.dcc <- &DiscoverCall{
+		service:   service,
+		namespace: namespace,
+		time:      tt,
+		caller:    caller,
+	}:
+	default:
+		log.Errorf("[DiscoverStatis] service: %s, namespace: %s is not captured", service, namespace)
+		return fmt.Errorf("[DiscoverStatis] service: %s, namespace: %s is not captured", service, namespace)
+	}
+	return nil
+}
+
 // Run 运行服务发现统计插件
 func (d *DiscoverStatisWorker) Run() {
 	ticker := time.NewTicker(d.interval)
 	defer ticker.Stop()
 
+	// 未开启服务依赖关系时 flushC 为 nil，不会触发写入
+	var flushC <-chan time.Time
+	if d.dependency != nil {
+		flushTicker := time.NewTicker(d.flushInterval)
+		defer flushTicker.Stop()
+		flushC = flushTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			d.dcs.log()
+		case <-flushC:
+			d.dependency.flush()
 		case dc := <-d.dcc:
 			d.dcs.add(dc)
+			if d.dependency != nil {
+				d.dependency.add(dc)
+			}
 		}
 	}
 }
@@ -35,6 +35,24 @@ type DiscoverStatis interface {
 	AddDiscoverCall(service, namespace string, tt time.Time) error
 }
 
+// DiscoverCaller 发起服务发现请求的主调方
+type DiscoverCaller struct {
+	// Host 主调方地址
+	Host string
+	// Service 主调方的来源服务
+	Service string
+	// Namespace 主调方的来源服务所在的命名空间
+	Namespace string
+	// Verified 来源服务是否来自客户端证书等已认证的身份，否则来自请求头中的声明
+	Verified bool
+}
+
+// DependencyStatis 记录主调方信息的服务发现统计插件，用于推导服务之间的依赖关系
+type DependencyStatis interface {
+	// AddDependencyCall 记录主调方对服务的发现调用
+	AddDependencyCall(caller *DiscoverCaller, service, namespace string, tt time.Time) error
+}
+
 // GetDiscoverStatis Get service discovery statistical plug -in
 func GetDiscoverStatis() DiscoverStatis {
 	c := &config.DiscoverStatis
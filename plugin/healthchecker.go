@@ -101,6 +101,13 @@ type HealthChecker interface {
 	Delete(id string) error
 }
 
+// PeerAwareHealthChecker 健康检查插件可选实现的接口，插件需要感知实例由哪一个健康检查节点负责时实现，
+// 健康检查节点的一致性哈希环发生变化时，健康检查服务会通知插件最新的节点列表以及实例的归属节点
+type PeerAwareHealthChecker interface {
+	// OnPeersChanged localHost 为当前节点，peers 为所有健康检查节点，owner 根据实例ID返回负责的节点
+	OnPeersChanged(localHost string, peers []string, owner func(instanceId string) string)
+}
+
 // GetHealthChecker get the health checker by name
 func GetHealthChecker(name string, cfg *ConfigEntry) HealthChecker {
 	plugin, exist := pluginSet[name]
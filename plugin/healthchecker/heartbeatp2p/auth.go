@@ -0,0 +1,114 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatp2p
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// peerTokenKey 节点间请求携带共享访问凭据的 metadata key
+const peerTokenKey = "x-polaris-peer-token"
+
+// newPeerCredentials 根据配置生成节点间心跳转发服务的服务端认证选项，以及按照节点主机名生成客户端认证选项的函数，
+// 配置了 tls 时节点之间进行双向 TLS 认证，配置了 token 时校验请求携带的共享访问凭据
+func newPeerCredentials(config *Config) ([]grpc.ServerOption, func(serverName string) []grpc.DialOption, error) {
+	var (
+		serverOpts  []grpc.ServerOption
+		clientConfs func(serverName string) *tls.Config
+	)
+	if config.TLS != nil {
+		info := config.TLS.ToTLSInfo()
+		info.ClientCertAuth = true
+		serverConf, err := info.ServerConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		if clientConfs, err = info.NewClientConfigs(); err != nil {
+			return nil, nil, err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(serverConf)))
+	}
+	var auth *tokenAuth
+	if config.Token != "" {
+		auth = &tokenAuth{token: config.Token, requireTLS: config.TLS != nil}
+		serverOpts = append(serverOpts, grpc.UnaryInterceptor(auth.unaryInterceptor),
+			grpc.StreamInterceptor(auth.streamInterceptor))
+	}
+	dialOpts := func(serverName string) []grpc.DialOption {
+		opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		if clientConfs != nil {
+			opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(clientConfs(serverName)))}
+		}
+		if auth != nil {
+			opts = append(opts, grpc.WithPerRPCCredentials(auth))
+		}
+		return opts
+	}
+	return serverOpts, dialOpts, nil
+}
+
+// tokenAuth 节点间共享访问凭据的校验以及携带
+type tokenAuth struct {
+	token      string
+	requireTLS bool
+}
+
+// GetRequestMetadata 客户端请求携带访问凭据
+func (a *tokenAuth) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return map[string]string{peerTokenKey: a.token}, nil
+}
+
+// RequireTransportSecurity 只有配置了 tls 时才要求加密传输
+func (a *tokenAuth) RequireTransportSecurity() bool {
+	return a.requireTLS
+}
+
+func (a *tokenAuth) verify(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, token := range md.Get(peerTokenKey) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid peer token")
+}
+
+func (a *tokenAuth) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.verify(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *tokenAuth) streamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	if err := a.verify(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,562 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/grpc"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/secure"
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// PluginName plugin name
+	PluginName = "heartbeatP2P"
+)
+
+var (
+	log = commonlog.GetScopeOrDefaultByName(commonlog.HealthcheckLoggerName)
+
+	// ErrPeerBusy 归属节点的转发队列已满，心跳被丢弃
+	ErrPeerBusy = errors.New("heartbeat forward queue of the owner peer is full")
+)
+
+const (
+	// handoverRetryTimes 移交心跳记录失败后的最大重试次数，重试耗尽后记录保留在本节点，等待下一次归属关系变化
+	handoverRetryTimes = 3
+	// handoverRetryInterval 移交失败后重试的间隔
+	handoverRetryInterval = time.Second
+)
+
+// Config 插件配置
+type Config struct {
+	// ListenIP 节点间心跳转发服务的监听地址，默认为本节点的地址
+	ListenIP string `mapstructure:"listenIP"`
+	// ListenPort 节点间心跳转发服务的监听端口，集群内所有节点需要保持一致
+	ListenPort uint32 `mapstructure:"listenPort"`
+	// QueueSize 每个节点等待转发的心跳队列长度，队列满时丢弃心跳
+	QueueSize int `mapstructure:"queueSize"`
+	// BatchSize 单次转发的最大心跳数
+	BatchSize int `mapstructure:"batchSize"`
+	// FlushInterval 未攒满一批时的转发间隔
+	FlushInterval time.Duration `mapstructure:"flushInterval"`
+	// RequestTimeout 向归属节点查询以及删除心跳记录的超时时间
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+	// TLS 节点间双向 TLS 认证，节点证书需要同时用于服务端以及客户端认证
+	TLS *secure.TLSConfig `mapstructure:"tls"`
+	// Token 集群内共享的访问凭据，TLS 与 Token 至少需要配置一个
+	Token string `mapstructure:"token"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		ListenPort:     8097,
+		QueueSize:      10240,
+		BatchSize:      128,
+		FlushInterval:  50 * time.Millisecond,
+		RequestTimeout: time.Second,
+	}
+}
+
+// parseConfig 解析插件配置
+func parseConfig(option map[string]interface{}) (*Config, error) {
+	config := defaultConfig()
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(option); err != nil {
+		return nil, err
+	}
+	if config.QueueSize <= 0 {
+		return nil, errors.New("queueSize is <= 0")
+	}
+	if config.BatchSize <= 0 {
+		return nil, errors.New("batchSize is <= 0")
+	}
+	if config.FlushInterval <= 0 {
+		return nil, errors.New("flushInterval is <= 0")
+	}
+	if config.RequestTimeout <= 0 {
+		return nil, errors.New("requestTimeout is <= 0")
+	}
+	if config.TLS != nil {
+		if config.TLS.CertFile == "" || config.TLS.KeyFile == "" || config.TLS.TrustedCAFile == "" {
+			return nil, errors.New("tls requires certFile, keyFile and trustedCAFile")
+		}
+	} else if config.Token == "" {
+		return nil, errors.New("peer service requires tls or token")
+	}
+	if config.ListenIP == "" {
+		config.ListenIP = utils.LocalHost
+	}
+	return config, nil
+}
+
+// heartbeatRecord 归属节点在内存中保存的心跳记录
+type heartbeatRecord struct {
+	Server     string
+	CurTimeSec int64
+	Count      int64
+}
+
+// newer 判断心跳记录是否比 other 更新，心跳时间相同时以上报次数较大的为准
+func (h *heartbeatRecord) newer(other *heartbeatRecord) bool {
+	if other == nil {
+		return true
+	}
+	if h.CurTimeSec != other.CurTimeSec {
+		return h.CurTimeSec > other.CurTimeSec
+	}
+	return h.Count > other.Count
+}
+
+// P2PHealthChecker 节点间转发心跳的健康检查插件，不依赖 Redis，
+// 实例的心跳只保存在一致性哈希环上负责该实例的节点内存中，其他节点接收到心跳后转发到归属节点
+type P2PHealthChecker struct {
+	config *Config
+	server *grpc.Server
+	cancel context.CancelFunc
+	ctx    context.Context
+	// peerAddr 根据节点地址获取节点间心跳转发服务的地址
+	peerAddr func(host string) string
+	// dialOpts 根据节点主机名生成连接其他节点时使用的认证选项
+	dialOpts func(serverName string) []grpc.DialOption
+
+	recordLock sync.RWMutex
+	records    map[string]*heartbeatRecord
+	// handoverLock 保证同一时间只有一个移交任务
+	handoverLock sync.Mutex
+
+	peerLock  sync.RWMutex
+	localHost string
+	owner     func(instanceId string) string
+	peers     map[string]*peer
+
+	suspendTimeSec int64
+	// changeTimeSec 最近一次归属关系变化的时间
+	changeTimeSec int64
+}
+
+// Name return plugin name
+func (r *P2PHealthChecker) Name() string {
+	return PluginName
+}
+
+// Initialize initialize plugin
+func (r *P2PHealthChecker) Initialize(c *plugin.ConfigEntry) error {
+	config, err := parseConfig(c.Option)
+	if err != nil {
+		return fmt.Errorf("fail to parse %s config entry, err is %v", PluginName, err)
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(config.ListenIP, strconv.Itoa(int(config.ListenPort))))
+	if err != nil {
+		return fmt.Errorf("fail to listen %s:%d, err is %v", config.ListenIP, config.ListenPort, err)
+	}
+	if err := r.start(config, listener); err != nil {
+		_ = listener.Close()
+		return err
+	}
+	return nil
+}
+
+func (r *P2PHealthChecker) start(config *Config, listener net.Listener) error {
+	serverOpts, dialOpts, err := newPeerCredentials(config)
+	if err != nil {
+		return err
+	}
+	r.config = config
+	r.dialOpts = dialOpts
+	r.records = make(map[string]*heartbeatRecord)
+	r.peers = make(map[string]*peer)
+	if r.peerAddr == nil {
+		r.peerAddr = func(host string) string {
+			return net.JoinHostPort(host, strconv.Itoa(int(config.ListenPort)))
+		}
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.server = grpc.NewServer(serverOpts...)
+	RegisterHeartbeatPeerServer(r.server, &peerServer{checker: r})
+	go func() {
+		log.Infof("[Health Check][P2PCheck]heartbeat peer server listen on %s", listener.Addr())
+		if err := r.server.Serve(listener); err != nil {
+			log.Errorf("[Health Check][P2PCheck]heartbeat peer server stopped, err is %v", err)
+		}
+	}()
+	return nil
+}
+
+// Destroy plugin destruction
+func (r *P2PHealthChecker) Destroy() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	if r.server != nil {
+		r.server.Stop()
+	}
+	r.peerLock.Lock()
+	defer r.peerLock.Unlock()
+	for host, p := range r.peers {
+		p.close()
+		delete(r.peers, host)
+	}
+	return nil
+}
+
+// Type for health check plugin, only one same type plugin is allowed
+func (r *P2PHealthChecker) Type() plugin.HealthCheckType {
+	return plugin.HealthCheckerHeartbeat
+}
+
+// OnPeersChanged 一致性哈希环发生变化，建立到新节点的连接，并把不再由本节点负责的心跳记录移交给新的归属节点
+func (r *P2PHealthChecker) OnPeersChanged(localHost string, peers []string, owner func(instanceId string) string) {
+	r.peerLock.Lock()
+	r.localHost = localHost
+	r.owner = owner
+	current := make(map[string]struct{}, len(peers))
+	for _, host := range peers {
+		if host == localHost {
+			continue
+		}
+		current[host] = struct{}{}
+		if _, ok := r.peers[host]; !ok {
+			addr := r.peerAddr(host)
+			serverName, _, _ := net.SplitHostPort(addr)
+			p, err := newPeer(r.ctx, host, addr, r.config, r.dialOpts(serverName))
+			if err != nil {
+				log.Errorf("[Health Check][P2PCheck]fail to connect peer %s, err is %v", host, err)
+				continue
+			}
+			r.peers[host] = p
+		}
+	}
+	for host, p := range r.peers {
+		if _, ok := current[host]; !ok {
+			p.close()
+			delete(r.peers, host)
+		}
+	}
+	r.peerLock.Unlock()
+
+	changeTimeSec := commontime.CurrentMillisecond() / 1000
+	atomic.StoreInt64(&r.changeTimeSec, changeTimeSec)
+	log.Infof("[Health Check][P2PCheck]peers changed to %v, local is %s, time %d", peers, localHost, changeTimeSec)
+	go r.handover()
+}
+
+// handover 把不再由本节点负责的心跳记录分批移交给新的归属节点，归属节点确认收到后才从本节点删除，
+// 移交失败的记录保留在本节点并重试，在此期间查询这些实例时仍然可以使用本节点的记录
+func (r *P2PHealthChecker) handover() {
+	r.handoverLock.Lock()
+	defer r.handoverLock.Unlock()
+	for i := 0; ; i++ {
+		moved, pending := r.handoverOnce()
+		if moved > 0 {
+			log.Infof("[Health Check][P2PCheck]hand over %d heartbeat records to other peers", moved)
+		}
+		if pending == 0 {
+			return
+		}
+		if i >= handoverRetryTimes {
+			log.Errorf("[Health Check][P2PCheck]fail to hand over %d heartbeat records, keep them locally", pending)
+			return
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(handoverRetryInterval):
+		}
+	}
+}
+
+// handoverOnce 执行一轮移交，返回移交成功的记录数以及仍然需要移交的记录数
+func (r *P2PHealthChecker) handoverOnce() (int, int) {
+	groups := make(map[*peer]map[string]*heartbeatRecord)
+	r.recordLock.RLock()
+	for id, record := range r.records {
+		p := r.remotePeer(id)
+		if p == nil {
+			continue
+		}
+		if _, ok := groups[p]; !ok {
+			groups[p] = make(map[string]*heartbeatRecord)
+		}
+		groups[p][id] = record
+	}
+	r.recordLock.RUnlock()
+
+	var moved, pending int
+	for p, records := range groups {
+		batch := make([]*HeartbeatRecord, 0, r.config.BatchSize)
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			defer func() {
+				batch = make([]*HeartbeatRecord, 0, r.config.BatchSize)
+			}()
+			ctx, cancel := context.WithTimeout(r.ctx, r.config.RequestTimeout)
+			defer cancel()
+			if err := p.handover(ctx, batch); err != nil {
+				log.Errorf("[Health Check][P2PCheck]fail to hand over %d records to %s, err is %v",
+					len(batch), p.host, err)
+				pending += len(batch)
+				return
+			}
+			n := r.removeHandedOver(batch, records)
+			moved += n
+			pending += len(batch) - n
+		}
+		for id, record := range records {
+			batch = append(batch, toProtoRecord(id, record))
+			if len(batch) >= r.config.BatchSize {
+				flush()
+			}
+		}
+		flush()
+	}
+	return moved, pending
+}
+
+// removeHandedOver 删除已经移交成功的记录，移交期间本节点又收到更新的记录时保留，等待下一轮移交
+func (r *P2PHealthChecker) removeHandedOver(batch []*HeartbeatRecord, snapshot map[string]*heartbeatRecord) int {
+	r.recordLock.Lock()
+	defer r.recordLock.Unlock()
+	var count int
+	for _, item := range batch {
+		id := item.GetInstanceId()
+		if r.records[id] == snapshot[id] {
+			delete(r.records, id)
+			count++
+		}
+	}
+	return count
+}
+
+// remotePeer 获取实例的归属节点，实例由本节点负责时返回 nil
+func (r *P2PHealthChecker) remotePeer(instanceId string) *peer {
+	r.peerLock.RLock()
+	defer r.peerLock.RUnlock()
+	if r.owner == nil {
+		return nil
+	}
+	host := r.owner(instanceId)
+	if host == "" || host == r.localHost {
+		return nil
+	}
+	return r.peers[host]
+}
+
+// merge 保存更新的心跳记录
+func (r *P2PHealthChecker) merge(instanceId string, record *heartbeatRecord) {
+	r.recordLock.Lock()
+	defer r.recordLock.Unlock()
+	if record.newer(r.records[instanceId]) {
+		r.records[instanceId] = record
+	}
+}
+
+func (r *P2PHealthChecker) load(instanceId string) (*heartbeatRecord, bool) {
+	r.recordLock.RLock()
+	defer r.recordLock.RUnlock()
+	record, ok := r.records[instanceId]
+	return record, ok
+}
+
+func (r *P2PHealthChecker) remove(instanceId string) {
+	r.recordLock.Lock()
+	defer r.recordLock.Unlock()
+	delete(r.records, instanceId)
+}
+
+// Report process heartbeat info report
+func (r *P2PHealthChecker) Report(request *plugin.ReportRequest) error {
+	record := &heartbeatRecord{
+		Server:     request.LocalHost,
+		CurTimeSec: request.CurTimeSec,
+		Count:      request.Count,
+	}
+	if p := r.remotePeer(request.InstanceId); p != nil {
+		if !p.forward(toProtoRecord(request.InstanceId, record)) {
+			log.Warnf("[Health Check][P2PCheck]addr:%s:%d, id:%s, forward to %s dropped",
+				request.Host, request.Port, request.InstanceId, p.host)
+			return ErrPeerBusy
+		}
+		return nil
+	}
+	r.merge(request.InstanceId, record)
+	log.Debugf("[Health Check][P2PCheck]add hb record, instanceId %s, record %+v", request.InstanceId, record)
+	return nil
+}
+
+// Query queries the heartbeat time
+func (r *P2PHealthChecker) Query(request *plugin.QueryRequest) (*plugin.QueryResponse, error) {
+	record, ok := r.load(request.InstanceId)
+	if p := r.remotePeer(request.InstanceId); p != nil {
+		ctx, cancel := context.WithTimeout(r.ctx, r.config.RequestTimeout)
+		defer cancel()
+		resp, err := p.client.Query(ctx, &QueryHeartbeatRequest{InstanceId: request.InstanceId})
+		if err != nil {
+			log.Errorf("[Health Check][P2PCheck]addr:%s:%d, id:%s, query from %s err:%v",
+				request.Host, request.Port, request.InstanceId, p.host, err)
+			// 记录还没有移交给归属节点时，使用本节点的记录
+			if !ok {
+				return nil, err
+			}
+		} else if resp.GetExists() {
+			if remote := fromProtoRecord(resp.GetRecord()); !ok || remote.newer(record) {
+				record, ok = remote, true
+			}
+		}
+	}
+	if !ok {
+		return &plugin.QueryResponse{
+			LastHeartbeatSec: 0,
+		}, nil
+	}
+	log.Debugf("[Health Check][P2PCheck]query hb record, instanceId %s, record %+v", request.InstanceId, record)
+	return &plugin.QueryResponse{
+		Server:           record.Server,
+		Exists:           true,
+		LastHeartbeatSec: record.CurTimeSec,
+		Count:            record.Count,
+	}, nil
+}
+
+func (r *P2PHealthChecker) skipCheck(instanceId string, expireDurationSec int64) bool {
+	suspendTimeSec := r.SuspendTimeSec()
+	localCurTimeSec := commontime.CurrentMillisecond() / 1000
+	if suspendTimeSec > 0 && localCurTimeSec >= suspendTimeSec && localCurTimeSec-suspendTimeSec < expireDurationSec {
+		log.Infof("[Health Check][P2PCheck]health check suspended, "+
+			"suspendTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, id %s",
+			suspendTimeSec, localCurTimeSec, expireDurationSec, instanceId)
+		return true
+	}
+	changeTimeSec := atomic.LoadInt64(&r.changeTimeSec)
+	// 归属关系变化后，新的归属节点可能还没有收到移交的心跳记录，不做变更
+	if changeTimeSec > 0 && localCurTimeSec >= changeTimeSec && localCurTimeSec-changeTimeSec < expireDurationSec {
+		log.Infof("[Health Check][P2PCheck]health check on peers changed, "+
+			"changeTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, id %s",
+			changeTimeSec, localCurTimeSec, expireDurationSec, instanceId)
+		return true
+	}
+	return false
+}
+
+// Check Report process the instance check
+func (r *P2PHealthChecker) Check(request *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+	queryResp, err := r.Query(&request.QueryRequest)
+	if err != nil {
+		return nil, err
+	}
+	lastHeartbeatTime := queryResp.LastHeartbeatSec
+	checkResp := &plugin.CheckResponse{
+		LastHeartbeatTimeSec: lastHeartbeatTime,
+	}
+	curTimeSec := request.CurTimeSec()
+	log.Debugf("[Health Check][P2PCheck]check hb record, cur is %d, last is %d", curTimeSec, lastHeartbeatTime)
+	if r.skipCheck(request.InstanceId, int64(request.ExpireDurationSec)) {
+		checkResp.StayUnchanged = true
+		return checkResp, nil
+	}
+	if curTimeSec > lastHeartbeatTime {
+		if curTimeSec-lastHeartbeatTime >= int64(request.ExpireDurationSec) {
+			// 心跳超时
+			checkResp.Healthy = false
+
+			if request.Healthy {
+				log.Infof("[Health Check][P2PCheck]health check expired, "+
+					"last hb timestamp is %d, curTimeSec is %d, expireDurationSec is %d, instanceId %s",
+					lastHeartbeatTime, curTimeSec, request.ExpireDurationSec, request.InstanceId)
+			} else {
+				checkResp.StayUnchanged = true
+			}
+			return checkResp, nil
+		}
+	}
+	checkResp.Healthy = true
+	if !request.Healthy {
+		log.Infof("[Health Check][P2PCheck]health check resumed, "+
+			"last hb timestamp is %d, curTimeSec is %d, expireDurationSec is %d instanceId %s",
+			lastHeartbeatTime, curTimeSec, request.ExpireDurationSec, request.InstanceId)
+	} else {
+		checkResp.StayUnchanged = true
+	}
+
+	return checkResp, nil
+}
+
+// AddToCheck add the instances to check procedure
+func (r *P2PHealthChecker) AddToCheck(request *plugin.AddCheckRequest) error {
+	return nil
+}
+
+// RemoveFromCheck removes the instances from check procedure
+func (r *P2PHealthChecker) RemoveFromCheck(request *plugin.AddCheckRequest) error {
+	return nil
+}
+
+// Delete delete the id
+func (r *P2PHealthChecker) Delete(id string) error {
+	r.remove(id)
+	p := r.remotePeer(id)
+	if p == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.RequestTimeout)
+	defer cancel()
+	if _, err := p.client.Delete(ctx, &DeleteHeartbeatRequest{InstanceId: id}); err != nil {
+		log.Errorf("[Health Check][P2PCheck]id:%s, delete from %s err:%v", id, p.host, err)
+		return err
+	}
+	return nil
+}
+
+// Suspend checker for an entire expired interval
+func (r *P2PHealthChecker) Suspend() {
+	curTimeMilli := commontime.CurrentMillisecond() / 1000
+	log.Infof("[Health Check][P2PCheck] suspend checker, start time %d", curTimeMilli)
+	atomic.StoreInt64(&r.suspendTimeSec, curTimeMilli)
+}
+
+// SuspendTimeSec get suspend time in seconds
+func (r *P2PHealthChecker) SuspendTimeSec() int64 {
+	return atomic.LoadInt64(&r.suspendTimeSec)
+}
+
+func init() {
+	d := &P2PHealthChecker{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
@@ -0,0 +1,263 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatp2p
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	hostA = "10.0.0.1"
+	hostB = "10.0.0.2"
+)
+
+// newTestCheckers 创建两个通过本地端口互相转发心跳的节点
+func newTestCheckers(t *testing.T) (*P2PHealthChecker, *P2PHealthChecker) {
+	return newTestCheckersWithTokens(t, "secret", "secret")
+}
+
+// newTestCheckersWithTokens 创建两个节点，节点 A 以及 B 分别使用指定的共享访问凭据
+func newTestCheckersWithTokens(t *testing.T, tokenA, tokenB string) (*P2PHealthChecker, *P2PHealthChecker) {
+	tokens := map[string]string{hostA: tokenA, hostB: tokenB}
+	addrs := make(map[string]string)
+	checkers := make(map[string]*P2PHealthChecker)
+	for _, host := range []string{hostA, hostB} {
+		config, err := parseConfig(map[string]interface{}{"flushInterval": "10ms", "token": tokens[host]})
+		assert.NoError(t, err)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		addrs[host] = listener.Addr().String()
+		checker := &P2PHealthChecker{
+			peerAddr: func(host string) string {
+				return addrs[host]
+			},
+		}
+		assert.NoError(t, checker.start(config, listener))
+		t.Cleanup(func() {
+			_ = checker.Destroy()
+		})
+		checkers[host] = checker
+	}
+	return checkers[hostA], checkers[hostB]
+}
+
+// ownerByPrefix 以 b- 开头的实例由节点 B 负责，其他实例由节点 A 负责
+func ownerByPrefix(instanceId string) string {
+	if strings.HasPrefix(instanceId, "b-") {
+		return hostB
+	}
+	return hostA
+}
+
+func reportRequest(id string, curTimeSec int64, count int64) *plugin.ReportRequest {
+	return &plugin.ReportRequest{
+		QueryRequest: plugin.QueryRequest{InstanceId: id},
+		LocalHost:    "127.0.0.1",
+		CurTimeSec:   curTimeSec,
+		Count:        count,
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	// 节点间心跳转发服务必须开启认证
+	_, err := parseConfig(nil)
+	assert.Error(t, err)
+
+	config, err := parseConfig(map[string]interface{}{"token": "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(8097), config.ListenPort)
+	assert.Equal(t, utils.LocalHost, config.ListenIP)
+	assert.Equal(t, 50*time.Millisecond, config.FlushInterval)
+
+	config, err = parseConfig(map[string]interface{}{
+		"token":          "secret",
+		"listenPort":     8098,
+		"batchSize":      "32",
+		"requestTimeout": "3s",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(8098), config.ListenPort)
+	assert.Equal(t, 32, config.BatchSize)
+	assert.Equal(t, 3*time.Second, config.RequestTimeout)
+
+	_, err = parseConfig(map[string]interface{}{"token": "secret", "queueSize": 0})
+	assert.Error(t, err)
+	_, err = parseConfig(map[string]interface{}{
+		"tls": map[interface{}]interface{}{"certFile": "node.pem", "keyFile": "node-key.pem"},
+	})
+	assert.Error(t, err)
+}
+
+func TestP2PHealthChecker_HandoverFail(t *testing.T) {
+	checkerA, checkerB := newTestCheckers(t)
+	assert.NoError(t, checkerA.Report(reportRequest("b-1", 100, 1)))
+
+	// 节点 B 不可用时，移交失败的记录保留在节点 A，查询时仍然使用节点 A 的记录
+	_ = checkerB.Destroy()
+	checkerA.OnPeersChanged(hostA, []string{hostA, hostB}, ownerByPrefix)
+	checkerA.handover()
+	record, ok := checkerA.load("b-1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), record.CurTimeSec)
+	resp, err := checkerA.Query(&plugin.QueryRequest{InstanceId: "b-1"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Exists)
+	assert.Equal(t, int64(100), resp.LastHeartbeatSec)
+}
+
+func TestP2PHealthChecker_InvalidToken(t *testing.T) {
+	checkerA, checkerB := newTestCheckersWithTokens(t, "secret", "other")
+	checkerA.OnPeersChanged(hostA, []string{hostA, hostB}, ownerByPrefix)
+	checkerB.OnPeersChanged(hostB, []string{hostA, hostB}, ownerByPrefix)
+
+	assert.NoError(t, checkerB.Report(reportRequest("b-1", 100, 1)))
+	_, err := checkerA.Query(&plugin.QueryRequest{InstanceId: "b-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestP2PHealthChecker_Forward(t *testing.T) {
+	checkerA, checkerB := newTestCheckers(t)
+	checkerA.OnPeersChanged(hostA, []string{hostA, hostB}, ownerByPrefix)
+	checkerB.OnPeersChanged(hostB, []string{hostA, hostB}, ownerByPrefix)
+
+	// 归属于本节点的心跳保存在本地，其他节点的心跳转发到归属节点
+	assert.NoError(t, checkerA.Report(reportRequest("a-1", 100, 1)))
+	assert.NoError(t, checkerA.Report(reportRequest("b-1", 100, 1)))
+	assert.NoError(t, checkerA.Report(reportRequest("b-1", 101, 2)))
+	_, ok := checkerA.load("a-1")
+	assert.True(t, ok)
+	_, ok = checkerA.load("b-1")
+	assert.False(t, ok)
+	assert.Eventually(t, func() bool {
+		record, ok := checkerB.load("b-1")
+		return ok && record.CurTimeSec == 101
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// 非归属节点从归属节点查询心跳记录
+	resp, err := checkerA.Query(&plugin.QueryRequest{InstanceId: "b-1"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Exists)
+	assert.Equal(t, int64(101), resp.LastHeartbeatSec)
+	assert.Equal(t, int64(2), resp.Count)
+	resp, err = checkerB.Query(&plugin.QueryRequest{InstanceId: "a-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), resp.LastHeartbeatSec)
+	resp, err = checkerA.Query(&plugin.QueryRequest{InstanceId: "b-2"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Exists)
+
+	// 非归属节点删除时同时删除归属节点上的心跳记录
+	assert.NoError(t, checkerA.Delete("b-1"))
+	_, ok = checkerB.load("b-1")
+	assert.False(t, ok)
+}
+
+func TestP2PHealthChecker_Handover(t *testing.T) {
+	checkerA, checkerB := newTestCheckers(t)
+	assert.NoError(t, checkerA.Report(reportRequest("a-1", 100, 1)))
+	assert.NoError(t, checkerA.Report(reportRequest("b-1", 100, 1)))
+
+	// 节点 B 加入后，原先保存在节点 A 的 b-1 移交到节点 B
+	checkerB.OnPeersChanged(hostB, []string{hostA, hostB}, ownerByPrefix)
+	checkerA.OnPeersChanged(hostA, []string{hostA, hostB}, ownerByPrefix)
+	assert.Eventually(t, func() bool {
+		record, ok := checkerB.load("b-1")
+		return ok && record.CurTimeSec == 100
+	}, 3*time.Second, 10*time.Millisecond)
+	// 节点 B 确认收到后才从节点 A 删除
+	assert.Eventually(t, func() bool {
+		_, ok := checkerA.load("b-1")
+		return !ok
+	}, 3*time.Second, 10*time.Millisecond)
+	_, ok := checkerA.load("a-1")
+	assert.True(t, ok)
+
+	// 归属关系变化后的一个过期周期内不做状态变更
+	checkResp, err := checkerA.Check(&plugin.CheckRequest{
+		QueryRequest:      plugin.QueryRequest{InstanceId: "b-1", Healthy: true},
+		ExpireDurationSec: 15,
+		CurTimeSec:        func() int64 { return 200 },
+	})
+	assert.NoError(t, err)
+	assert.True(t, checkResp.StayUnchanged)
+	assert.Equal(t, int64(100), checkResp.LastHeartbeatTimeSec)
+
+	// 节点 B 下线后，所有实例都由节点 A 负责，心跳不再转发
+	checkerA.OnPeersChanged(hostA, []string{hostA}, func(string) string { return hostA })
+	assert.NoError(t, checkerA.Report(reportRequest("b-1", 110, 2)))
+	record, ok := checkerA.load("b-1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(110), record.CurTimeSec)
+	checkerA.peerLock.RLock()
+	assert.Empty(t, checkerA.peers)
+	checkerA.peerLock.RUnlock()
+}
+
+func TestP2PHealthChecker_Check(t *testing.T) {
+	checker, _ := newTestCheckers(t)
+	now := time.Now().Unix()
+	assert.NoError(t, checker.Report(reportRequest("ins-1", now, 1)))
+	// 过期的心跳不会覆盖较新的心跳
+	assert.NoError(t, checker.Report(reportRequest("ins-1", now-1, 2)))
+
+	checkRequest := &plugin.CheckRequest{
+		QueryRequest:      plugin.QueryRequest{InstanceId: "ins-1", Healthy: true},
+		ExpireDurationSec: 15,
+		CurTimeSec:        func() int64 { return now },
+	}
+	resp, err := checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+	assert.Equal(t, now, resp.LastHeartbeatTimeSec)
+
+	// 心跳超时
+	checkRequest.CurTimeSec = func() int64 { return now + 20 }
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	// 心跳恢复
+	checkRequest.Healthy = false
+	assert.NoError(t, checker.Report(reportRequest("ins-1", now+20, 3)))
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	// 暂停期间不做状态变更
+	checker.Suspend()
+	assert.Equal(t, commontime.CurrentMillisecond()/1000, checker.SuspendTimeSec())
+	checkRequest.CurTimeSec = func() int64 { return now + 60 }
+	checkRequest.Healthy = true
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.StayUnchanged)
+}
@@ -0,0 +1,218 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: heartbeat.proto
+
+package heartbeatp2p
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// HeartbeatPeerClient is the client API for HeartbeatPeer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HeartbeatPeerClient interface {
+	// 持续转发心跳记录到实例的归属节点
+	Forward(ctx context.Context, opts ...grpc.CallOption) (HeartbeatPeer_ForwardClient, error)
+	// 查询归属节点上实例的心跳记录
+	Query(ctx context.Context, in *QueryHeartbeatRequest, opts ...grpc.CallOption) (*QueryHeartbeatResponse, error)
+	// 删除归属节点上实例的心跳记录
+	Delete(ctx context.Context, in *DeleteHeartbeatRequest, opts ...grpc.CallOption) (*DeleteHeartbeatResponse, error)
+}
+
+type heartbeatPeerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHeartbeatPeerClient(cc grpc.ClientConnInterface) HeartbeatPeerClient {
+	return &heartbeatPeerClient{cc}
+}
+
+func (c *heartbeatPeerClient) Forward(ctx context.Context, opts ...grpc.CallOption) (HeartbeatPeer_ForwardClient, error) {
+	stream, err := c.cc.NewStream(ctx, &HeartbeatPeer_ServiceDesc.Streams[0], "/heartbeatp2p.HeartbeatPeer/Forward", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &heartbeatPeerForwardClient{stream}
+	return x, nil
+}
+
+type HeartbeatPeer_ForwardClient interface {
+	Send(*HeartbeatBatch) error
+	CloseAndRecv() (*ForwardResponse, error)
+	grpc.ClientStream
+}
+
+type heartbeatPeerForwardClient struct {
+	grpc.ClientStream
+}
+
+func (x *heartbeatPeerForwardClient) Send(m *HeartbeatBatch) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *heartbeatPeerForwardClient) CloseAndRecv() (*ForwardResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ForwardResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *heartbeatPeerClient) Query(ctx context.Context, in *QueryHeartbeatRequest, opts ...grpc.CallOption) (*QueryHeartbeatResponse, error) {
+	out := new(QueryHeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/heartbeatp2p.HeartbeatPeer/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *heartbeatPeerClient) Delete(ctx context.Context, in *DeleteHeartbeatRequest, opts ...grpc.CallOption) (*DeleteHeartbeatResponse, error) {
+	out := new(DeleteHeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/heartbeatp2p.HeartbeatPeer/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HeartbeatPeerServer is the server API for HeartbeatPeer service.
+// All implementations must embed UnimplementedHeartbeatPeerServer
+// for forward compatibility
+type HeartbeatPeerServer interface {
+	// 持续转发心跳记录到实例的归属节点
+	Forward(HeartbeatPeer_ForwardServer) error
+	// 查询归属节点上实例的心跳记录
+	Query(context.Context, *QueryHeartbeatRequest) (*QueryHeartbeatResponse, error)
+	// 删除归属节点上实例的心跳记录
+	Delete(context.Context, *DeleteHeartbeatRequest) (*DeleteHeartbeatResponse, error)
+	mustEmbedUnimplementedHeartbeatPeerServer()
+}
+
+// UnimplementedHeartbeatPeerServer must be embedded to have forward compatible implementations.
+type UnimplementedHeartbeatPeerServer struct {
+}
+
+func (UnimplementedHeartbeatPeerServer) Forward(HeartbeatPeer_ForwardServer) error {
+	return status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedHeartbeatPeerServer) Query(context.Context, *QueryHeartbeatRequest) (*QueryHeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedHeartbeatPeerServer) Delete(context.Context, *DeleteHeartbeatRequest) (*DeleteHeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedHeartbeatPeerServer) mustEmbedUnimplementedHeartbeatPeerServer() {}
+
+// UnsafeHeartbeatPeerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HeartbeatPeerServer will
+// result in compilation errors.
+type UnsafeHeartbeatPeerServer interface {
+	mustEmbedUnimplementedHeartbeatPeerServer()
+}
+
+func RegisterHeartbeatPeerServer(s grpc.ServiceRegistrar, srv HeartbeatPeerServer) {
+	s.RegisterService(&HeartbeatPeer_ServiceDesc, srv)
+}
+
+func _HeartbeatPeer_Forward_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(HeartbeatPeerServer).Forward(&heartbeatPeerForwardServer{stream})
+}
+
+type HeartbeatPeer_ForwardServer interface {
+	SendAndClose(*ForwardResponse) error
+	Recv() (*HeartbeatBatch, error)
+	grpc.ServerStream
+}
+
+type heartbeatPeerForwardServer struct {
+	grpc.ServerStream
+}
+
+func (x *heartbeatPeerForwardServer) SendAndClose(m *ForwardResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *heartbeatPeerForwardServer) Recv() (*HeartbeatBatch, error) {
+	m := new(HeartbeatBatch)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _HeartbeatPeer_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryHeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeartbeatPeerServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/heartbeatp2p.HeartbeatPeer/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeartbeatPeerServer).Query(ctx, req.(*QueryHeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HeartbeatPeer_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteHeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeartbeatPeerServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/heartbeatp2p.HeartbeatPeer/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeartbeatPeerServer).Delete(ctx, req.(*DeleteHeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HeartbeatPeer_ServiceDesc is the grpc.ServiceDesc for HeartbeatPeer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HeartbeatPeer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "heartbeatp2p.HeartbeatPeer",
+	HandlerType: (*HeartbeatPeerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _HeartbeatPeer_Query_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _HeartbeatPeer_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Forward",
+			Handler:       _HeartbeatPeer_Forward_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "heartbeat.proto",
+}
@@ -0,0 +1,203 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatp2p
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// peer 其他健康检查节点，心跳通过 Forward 流批量转发到该节点
+type peer struct {
+	host   string
+	conn   *grpc.ClientConn
+	client HeartbeatPeerClient
+	queue  chan *HeartbeatRecord
+	cancel context.CancelFunc
+
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func newPeer(ctx context.Context, host, addr string, config *Config, dialOpts []grpc.DialOption) (*peer, error) {
+	conn, err := grpc.Dial(addr, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	p := &peer{
+		host:          host,
+		conn:          conn,
+		client:        NewHeartbeatPeerClient(conn),
+		queue:         make(chan *HeartbeatRecord, config.QueueSize),
+		batchSize:     config.BatchSize,
+		flushInterval: config.FlushInterval,
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+	go p.sendLoop(ctx)
+	return p, nil
+}
+
+// forward 心跳记录放入转发队列，队列满时返回 false
+func (p *peer) forward(record *HeartbeatRecord) bool {
+	select {
+	case p.queue <- record:
+		return true
+	default:
+		return false
+	}
+}
+
+// handover 通过单独的 Forward 流同步发送一批心跳记录，收到归属节点的响应后才算移交成功
+func (p *peer) handover(ctx context.Context, records []*HeartbeatRecord) error {
+	stream, err := p.client.Forward(ctx)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&HeartbeatBatch{Records: records}); err != nil {
+		if err == io.EOF {
+			_, err = stream.CloseAndRecv()
+		}
+		return err
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+func (p *peer) close() {
+	p.cancel()
+	_ = p.conn.Close()
+}
+
+// sendLoop 从队列中获取心跳记录，攒满一批或者到达转发间隔时通过 Forward 流发送，
+// 发送失败时重新建立 Forward 流重试一次，仍然失败时丢弃这一批心跳，等待实例下一次上报
+func (p *peer) sendLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	var (
+		stream HeartbeatPeer_ForwardClient
+		batch  = make([]*HeartbeatRecord, 0, p.batchSize)
+	)
+	send := func() error {
+		if stream == nil {
+			var err error
+			if stream, err = p.client.Forward(ctx); err != nil {
+				return err
+			}
+		}
+		if err := stream.Send(&HeartbeatBatch{Records: batch}); err != nil {
+			if err == io.EOF {
+				_, err = stream.CloseAndRecv()
+			}
+			stream = nil
+			return err
+		}
+		return nil
+	}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		defer func() {
+			batch = make([]*HeartbeatRecord, 0, p.batchSize)
+		}()
+		// 对端重启等原因会导致已经建立的流失效，重新建立流后重试一次
+		if err := send(); err != nil {
+			if err = send(); err != nil {
+				log.Errorf("[Health Check][P2PCheck]fail to forward %d records to %s, drop them, err is %v",
+					len(batch), p.host, err)
+			}
+		}
+	}
+	for {
+		select {
+		case record := <-p.queue:
+			batch = append(batch, record)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			if stream != nil {
+				_, _ = stream.CloseAndRecv()
+			}
+			return
+		}
+	}
+}
+
+// peerServer 接收其他节点转发的心跳，转发来的心跳直接保存在本节点，不会再次转发
+type peerServer struct {
+	UnimplementedHeartbeatPeerServer
+	checker *P2PHealthChecker
+}
+
+// Forward 接收其他节点转发或者移交的心跳记录
+func (s *peerServer) Forward(stream HeartbeatPeer_ForwardServer) error {
+	for {
+		batch, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&ForwardResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		for _, record := range batch.GetRecords() {
+			s.checker.merge(record.GetInstanceId(), fromProtoRecord(record))
+		}
+	}
+}
+
+// Query 查询本节点的心跳记录
+func (s *peerServer) Query(_ context.Context, req *QueryHeartbeatRequest) (*QueryHeartbeatResponse, error) {
+	record, ok := s.checker.load(req.GetInstanceId())
+	if !ok {
+		return &QueryHeartbeatResponse{}, nil
+	}
+	return &QueryHeartbeatResponse{
+		Exists: true,
+		Record: toProtoRecord(req.GetInstanceId(), record),
+	}, nil
+}
+
+// Delete 删除本节点的心跳记录
+func (s *peerServer) Delete(_ context.Context, req *DeleteHeartbeatRequest) (*DeleteHeartbeatResponse, error) {
+	s.checker.remove(req.GetInstanceId())
+	return &DeleteHeartbeatResponse{}, nil
+}
+
+func toProtoRecord(instanceId string, record *heartbeatRecord) *HeartbeatRecord {
+	return &HeartbeatRecord{
+		InstanceId: instanceId,
+		Server:     record.Server,
+		CurTimeSec: record.CurTimeSec,
+		Count:      record.Count,
+	}
+}
+
+func fromProtoRecord(record *HeartbeatRecord) *heartbeatRecord {
+	return &heartbeatRecord{
+		Server:     record.GetServer(),
+		CurTimeSec: record.GetCurTimeSec(),
+		Count:      record.GetCount(),
+	}
+}
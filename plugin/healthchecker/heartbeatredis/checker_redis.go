@@ -73,6 +73,13 @@ func (r *RedisHealthChecker) Initialize(c *plugin.ConfigEntry) error {
 	if err = json.Unmarshal(redisBytes, &config); err != nil {
 		return fmt.Errorf("fail to unmarshal %s config entry, err is %v", PluginName, err)
 	}
+	if config.KvPasswd, err = plugin.ParseSecret(config.KvPasswd); err != nil {
+		return fmt.Errorf("fail to parse %s redis password, err is %v", PluginName, err)
+	}
+	if config.SentinelConfig.SentinelPassword, err = plugin.ParseSecret(
+		config.SentinelConfig.SentinelPassword); err != nil {
+		return fmt.Errorf("fail to parse %s sentinel password, err is %v", PluginName, err)
+	}
 	r.statis = plugin.GetStatis()
 	var ctx context.Context
 	ctx, r.cancel = context.WithCancel(context.Background())
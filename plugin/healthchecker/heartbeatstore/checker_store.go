@@ -0,0 +1,364 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service/batch"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// PluginName plugin name
+	PluginName = "heartbeatStore"
+
+	defaultSyncInterval = time.Second
+	// syncOverlap 增量同步时向前多拉取的时间，避免遗漏同一时刻提交较晚的心跳记录
+	syncOverlap = time.Second
+)
+
+var (
+	log = commonlog.GetScopeOrDefaultByName(commonlog.HealthcheckLoggerName)
+
+	// ErrSyncFailed 从存储层同步心跳记录失败，此时本地的心跳记录可能已经过期
+	ErrSyncFailed = errors.New("sync heartbeat records from store failed")
+)
+
+// Config 插件配置
+type Config struct {
+	// SyncInterval 从存储层增量同步其他节点心跳记录的间隔
+	SyncInterval time.Duration `mapstructure:"syncInterval"`
+	// Batch 心跳记录批量写入存储层的配置，关闭时每次心跳都会直接写入存储层
+	Batch *batch.CtrlConfig `mapstructure:"batch"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		SyncInterval: defaultSyncInterval,
+		Batch: &batch.CtrlConfig{
+			Open:          true,
+			QueueSize:     10240,
+			WaitTime:      "100ms",
+			MaxBatchCount: 128,
+			Concurrency:   16,
+		},
+	}
+}
+
+// parseConfig 解析插件配置
+func parseConfig(option map[string]interface{}) (*Config, error) {
+	config := defaultConfig()
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(option); err != nil {
+		return nil, err
+	}
+	if config.SyncInterval <= 0 {
+		return nil, errors.New("syncInterval is <= 0")
+	}
+	return config, nil
+}
+
+// StoreHealthChecker 通过存储层共享心跳记录的健康检查插件，适用于没有 Redis 的集群，
+// 心跳先写入本地，再批量写入存储层，其他节点的心跳记录通过定时增量同步获取
+type StoreHealthChecker struct {
+	config    *Config
+	storage   store.InstanceHeartbeatStore
+	batchCtrl *batch.HeartbeatRecordCtrl
+	cancel    context.CancelFunc
+
+	lock    sync.RWMutex
+	records map[string]*model.InstanceHeartbeat
+	// lastMtime 已同步的心跳记录的最大修改时间，只在同步协程中读写
+	lastMtime time.Time
+
+	syncFailed     int32
+	recoverTimeSec int64
+	suspendTimeSec int64
+}
+
+// Name return plugin name
+func (r *StoreHealthChecker) Name() string {
+	return PluginName
+}
+
+// Initialize initialize plugin
+func (r *StoreHealthChecker) Initialize(c *plugin.ConfigEntry) error {
+	config, err := parseConfig(c.Option)
+	if err != nil {
+		return fmt.Errorf("fail to parse %s config entry, err is %v", PluginName, err)
+	}
+	s, err := store.GetStore()
+	if err != nil {
+		return err
+	}
+	storage, ok := s.(store.InstanceHeartbeatStore)
+	if !ok {
+		return fmt.Errorf("store %s does not support instance heartbeat records", s.Name())
+	}
+	return r.start(storage, config)
+}
+
+func (r *StoreHealthChecker) start(storage store.InstanceHeartbeatStore, config *Config) error {
+	batchCtrl, err := batch.NewBatchHeartbeatRecordCtrl(storage, config.Batch)
+	if err != nil {
+		return err
+	}
+	r.config = config
+	r.storage = storage
+	r.batchCtrl = batchCtrl
+	r.records = make(map[string]*model.InstanceHeartbeat)
+	if err := r.sync(); err != nil {
+		return fmt.Errorf("fail to load heartbeat records from store, err is %v", err)
+	}
+
+	var ctx context.Context
+	ctx, r.cancel = context.WithCancel(context.Background())
+	if r.batchCtrl != nil {
+		r.batchCtrl.Start(ctx)
+	}
+	go r.syncLoop(ctx)
+	return nil
+}
+
+// Destroy plugin destruction
+func (r *StoreHealthChecker) Destroy() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return nil
+}
+
+// Type for health check plugin, only one same type plugin is allowed
+func (r *StoreHealthChecker) Type() plugin.HealthCheckType {
+	return plugin.HealthCheckerHeartbeat
+}
+
+func (r *StoreHealthChecker) syncLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.sync(); err != nil {
+				if atomic.CompareAndSwapInt32(&r.syncFailed, 0, 1) {
+					log.Errorf("[Health Check][StoreCheck]fail to sync heartbeat records, err is %v", err)
+				}
+				continue
+			}
+			if atomic.CompareAndSwapInt32(&r.syncFailed, 1, 0) {
+				recoverTimeSec := commontime.CurrentMillisecond() / 1000
+				log.Infof("[Health Check][StoreCheck]sync heartbeat records recovered, time %d", recoverTimeSec)
+				atomic.StoreInt64(&r.recoverTimeSec, recoverTimeSec)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync 从存储层增量同步心跳记录
+func (r *StoreHealthChecker) sync() error {
+	since := r.lastMtime
+	if !since.IsZero() {
+		since = since.Add(-syncOverlap)
+	}
+	heartbeats, err := r.storage.GetMoreInstanceHeartbeats(since)
+	if err != nil {
+		return err
+	}
+	for _, heartbeat := range heartbeats {
+		r.merge(heartbeat)
+		if heartbeat.ModifyTime.After(r.lastMtime) {
+			r.lastMtime = heartbeat.ModifyTime
+		}
+	}
+	return nil
+}
+
+// merge 保存更新的心跳记录
+func (r *StoreHealthChecker) merge(heartbeat *model.InstanceHeartbeat) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if heartbeat.Newer(r.records[heartbeat.InstanceID]) {
+		r.records[heartbeat.InstanceID] = heartbeat
+	}
+}
+
+// Report process heartbeat info report
+func (r *StoreHealthChecker) Report(request *plugin.ReportRequest) error {
+	heartbeat := &model.InstanceHeartbeat{
+		InstanceID:       request.InstanceId,
+		Server:           request.LocalHost,
+		LastHeartbeatSec: request.CurTimeSec,
+		Count:            request.Count,
+	}
+	r.merge(heartbeat)
+	log.Debugf("[Health Check][StoreCheck]add hb record, instanceId %s, record %+v", request.InstanceId, heartbeat)
+	if r.batchCtrl != nil {
+		r.batchCtrl.AsyncUpdateHeartbeat(heartbeat, false)
+		return nil
+	}
+	if err := r.storage.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{heartbeat}); err != nil {
+		log.Errorf("[Health Check][StoreCheck]addr:%s:%d, id:%s, update heartbeat err:%s",
+			request.Host, request.Port, request.InstanceId, err)
+		return err
+	}
+	return nil
+}
+
+// Query queries the heartbeat time
+func (r *StoreHealthChecker) Query(request *plugin.QueryRequest) (*plugin.QueryResponse, error) {
+	if atomic.LoadInt32(&r.syncFailed) == 1 {
+		return nil, ErrSyncFailed
+	}
+	r.lock.RLock()
+	heartbeat, ok := r.records[request.InstanceId]
+	r.lock.RUnlock()
+	if !ok {
+		return &plugin.QueryResponse{
+			LastHeartbeatSec: 0,
+		}, nil
+	}
+	log.Debugf("[Health Check][StoreCheck]query hb record, instanceId %s, record %+v", request.InstanceId, heartbeat)
+	return &plugin.QueryResponse{
+		Server:           heartbeat.Server,
+		Exists:           true,
+		LastHeartbeatSec: heartbeat.LastHeartbeatSec,
+		Count:            heartbeat.Count,
+	}, nil
+}
+
+func (r *StoreHealthChecker) skipCheck(instanceId string, expireDurationSec int64) bool {
+	suspendTimeSec := r.SuspendTimeSec()
+	localCurTimeSec := commontime.CurrentMillisecond() / 1000
+	if suspendTimeSec > 0 && localCurTimeSec >= suspendTimeSec && localCurTimeSec-suspendTimeSec < expireDurationSec {
+		log.Infof("[Health Check][StoreCheck]health check store suspended, "+
+			"suspendTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, id %s",
+			suspendTimeSec, localCurTimeSec, expireDurationSec, instanceId)
+		return true
+	}
+	recoverTimeSec := atomic.LoadInt64(&r.recoverTimeSec)
+	// 存储层恢复期，其他节点的心跳记录可能还没有写入，不做变更
+	if recoverTimeSec > 0 && localCurTimeSec >= recoverTimeSec && localCurTimeSec-recoverTimeSec < expireDurationSec {
+		log.Infof("[Health Check][StoreCheck]health check store on recover, "+
+			"recoverTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, id %s",
+			recoverTimeSec, localCurTimeSec, expireDurationSec, instanceId)
+		return true
+	}
+	return false
+}
+
+// Check Report process the instance check
+func (r *StoreHealthChecker) Check(request *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+	queryResp, err := r.Query(&request.QueryRequest)
+	if err != nil {
+		return nil, err
+	}
+	lastHeartbeatTime := queryResp.LastHeartbeatSec
+	checkResp := &plugin.CheckResponse{
+		LastHeartbeatTimeSec: lastHeartbeatTime,
+	}
+	curTimeSec := request.CurTimeSec()
+	log.Debugf("[Health Check][StoreCheck]check hb record, cur is %d, last is %d", curTimeSec, lastHeartbeatTime)
+	if r.skipCheck(request.InstanceId, int64(request.ExpireDurationSec)) {
+		checkResp.StayUnchanged = true
+		return checkResp, nil
+	}
+	if curTimeSec > lastHeartbeatTime {
+		if curTimeSec-lastHeartbeatTime >= int64(request.ExpireDurationSec) {
+			// 心跳超时
+			checkResp.Healthy = false
+
+			if request.Healthy {
+				log.Infof("[Health Check][StoreCheck]health check expired, "+
+					"last hb timestamp is %d, curTimeSec is %d, expireDurationSec is %d, instanceId %s",
+					lastHeartbeatTime, curTimeSec, request.ExpireDurationSec, request.InstanceId)
+			} else {
+				checkResp.StayUnchanged = true
+			}
+			return checkResp, nil
+		}
+	}
+	checkResp.Healthy = true
+	if !request.Healthy {
+		log.Infof("[Health Check][StoreCheck]health check resumed, "+
+			"last hb timestamp is %d, curTimeSec is %d, expireDurationSec is %d instanceId %s",
+			lastHeartbeatTime, curTimeSec, request.ExpireDurationSec, request.InstanceId)
+	} else {
+		checkResp.StayUnchanged = true
+	}
+
+	return checkResp, nil
+}
+
+// AddToCheck add the instances to check procedure
+func (r *StoreHealthChecker) AddToCheck(request *plugin.AddCheckRequest) error {
+	return nil
+}
+
+// RemoveFromCheck removes the instances from check procedure
+func (r *StoreHealthChecker) RemoveFromCheck(request *plugin.AddCheckRequest) error {
+	return nil
+}
+
+// Delete delete the id
+func (r *StoreHealthChecker) Delete(id string) error {
+	r.lock.Lock()
+	delete(r.records, id)
+	r.lock.Unlock()
+	if err := r.storage.DeleteInstanceHeartbeat(id); err != nil {
+		log.Errorf("[Health Check][StoreCheck]id:%s, delete heartbeat err:%s", id, err)
+		return err
+	}
+	return nil
+}
+
+// Suspend checker for an entire expired interval
+func (r *StoreHealthChecker) Suspend() {
+	curTimeMilli := commontime.CurrentMillisecond() / 1000
+	log.Infof("[Health Check][StoreCheck] suspend checker, start time %d", curTimeMilli)
+	atomic.StoreInt64(&r.suspendTimeSec, curTimeMilli)
+}
+
+// SuspendTimeSec get suspend time in seconds
+func (r *StoreHealthChecker) SuspendTimeSec() int64 {
+	return atomic.LoadInt64(&r.suspendTimeSec)
+}
+
+func init() {
+	d := &StoreHealthChecker{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
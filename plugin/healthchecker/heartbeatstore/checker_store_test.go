@@ -0,0 +1,289 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package heartbeatstore
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// fakeHeartbeatStore 模拟多个节点共享的存储层
+type fakeHeartbeatStore struct {
+	mutex   sync.Mutex
+	records map[string]*model.InstanceHeartbeat
+	updates int
+	err     error
+}
+
+func newFakeHeartbeatStore() *fakeHeartbeatStore {
+	return &fakeHeartbeatStore{records: make(map[string]*model.InstanceHeartbeat)}
+}
+
+func (s *fakeHeartbeatStore) BatchUpdateInstanceHeartbeats(heartbeats []*model.InstanceHeartbeat) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.updates++
+	for _, heartbeat := range heartbeats {
+		if heartbeat.Newer(s.records[heartbeat.InstanceID]) {
+			record := *heartbeat
+			record.ModifyTime = time.Now()
+			s.records[heartbeat.InstanceID] = &record
+		}
+	}
+	return nil
+}
+
+func (s *fakeHeartbeatStore) GetMoreInstanceHeartbeats(mtime time.Time) ([]*model.InstanceHeartbeat, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	var heartbeats []*model.InstanceHeartbeat
+	for _, record := range s.records {
+		if mtime.IsZero() || !record.ModifyTime.Before(mtime) {
+			heartbeat := *record
+			heartbeats = append(heartbeats, &heartbeat)
+		}
+	}
+	return heartbeats, nil
+}
+
+func (s *fakeHeartbeatStore) DeleteInstanceHeartbeat(instanceID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, instanceID)
+	return nil
+}
+
+func (s *fakeHeartbeatStore) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func (s *fakeHeartbeatStore) get(id string) *model.InstanceHeartbeat {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.records[id]
+}
+
+func newTestChecker(t *testing.T, storage *fakeHeartbeatStore, option map[string]interface{}) *StoreHealthChecker {
+	config, err := parseConfig(option)
+	assert.NoError(t, err)
+	checker := &StoreHealthChecker{}
+	assert.NoError(t, checker.start(storage, config))
+	t.Cleanup(func() {
+		_ = checker.Destroy()
+	})
+	return checker
+}
+
+func reportRequest(id string, curTimeSec int64, count int64) *plugin.ReportRequest {
+	return &plugin.ReportRequest{
+		QueryRequest: plugin.QueryRequest{InstanceId: id},
+		LocalHost:    "127.0.0.1",
+		CurTimeSec:   curTimeSec,
+		Count:        count,
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSyncInterval, config.SyncInterval)
+	assert.True(t, config.Batch.Open)
+	assert.Equal(t, "100ms", config.Batch.WaitTime)
+
+	config, err = parseConfig(map[string]interface{}{
+		"syncInterval": "3s",
+		"batch": map[string]interface{}{
+			"maxBatchCount": 64,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, config.SyncInterval)
+	assert.Equal(t, 64, config.Batch.MaxBatchCount)
+	assert.Equal(t, 10240, config.Batch.QueueSize)
+
+	_, err = parseConfig(map[string]interface{}{"syncInterval": "0s"})
+	assert.Error(t, err)
+}
+
+func TestStoreHealthChecker_ReportAndQuery(t *testing.T) {
+	storage := newFakeHeartbeatStore()
+	checker := newTestChecker(t, storage, map[string]interface{}{
+		"batch": map[string]interface{}{"open": false},
+	})
+
+	assert.NoError(t, checker.Report(reportRequest("ins-1", 100, 1)))
+	// 过期的心跳不会覆盖较新的心跳
+	assert.NoError(t, checker.Report(reportRequest("ins-1", 99, 2)))
+	resp, err := checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Exists)
+	assert.Equal(t, "127.0.0.1", resp.Server)
+	assert.Equal(t, int64(100), resp.LastHeartbeatSec)
+	assert.Equal(t, int64(1), resp.Count)
+	assert.Equal(t, int64(100), storage.get("ins-1").LastHeartbeatSec)
+
+	resp, err = checker.Query(&plugin.QueryRequest{InstanceId: "ins-2"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Exists)
+	assert.Equal(t, int64(0), resp.LastHeartbeatSec)
+
+	storage.setErr(errors.New("mock error"))
+	assert.Error(t, checker.Report(reportRequest("ins-1", 101, 3)))
+	storage.setErr(nil)
+
+	assert.NoError(t, checker.Delete("ins-1"))
+	assert.Nil(t, storage.get("ins-1"))
+	resp, err = checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Exists)
+}
+
+func TestStoreHealthChecker_BatchReport(t *testing.T) {
+	storage := newFakeHeartbeatStore()
+	checker := newTestChecker(t, storage, map[string]interface{}{
+		"batch": map[string]interface{}{"waitTime": "10ms"},
+	})
+
+	for i := int64(1); i <= 10; i++ {
+		assert.NoError(t, checker.Report(reportRequest("ins-1", 100+i, i)))
+	}
+	// 本地的心跳记录立即可见，存储层的心跳记录合并后批量写入
+	resp, err := checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(110), resp.LastHeartbeatSec)
+	assert.Eventually(t, func() bool {
+		record := storage.get("ins-1")
+		return record != nil && record.LastHeartbeatSec == 110
+	}, time.Second, 10*time.Millisecond)
+	storage.mutex.Lock()
+	assert.Less(t, storage.updates, 10)
+	storage.mutex.Unlock()
+}
+
+func TestStoreHealthChecker_Sync(t *testing.T) {
+	storage := newFakeHeartbeatStore()
+	assert.NoError(t, storage.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.2", LastHeartbeatSec: 100, Count: 1},
+	}))
+	checker := newTestChecker(t, storage, map[string]interface{}{"syncInterval": "10ms"})
+
+	// 启动时全量加载其他节点的心跳记录
+	resp, err := checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.2", resp.Server)
+
+	// 其他节点写入的心跳记录通过增量同步获取
+	assert.NoError(t, storage.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.3", LastHeartbeatSec: 105, Count: 2},
+	}))
+	assert.Eventually(t, func() bool {
+		resp, err := checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+		return err == nil && resp.LastHeartbeatSec == 105
+	}, time.Second, 10*time.Millisecond)
+
+	// 同步失败时无法确认心跳是否过期，查询返回错误
+	storage.setErr(errors.New("mock error"))
+	assert.Eventually(t, func() bool {
+		_, err := checker.Query(&plugin.QueryRequest{InstanceId: "ins-1"})
+		return errors.Is(err, ErrSyncFailed)
+	}, time.Second, 10*time.Millisecond)
+	_, err = checker.Check(&plugin.CheckRequest{
+		QueryRequest:      plugin.QueryRequest{InstanceId: "ins-1", Healthy: true},
+		ExpireDurationSec: 15,
+		CurTimeSec:        func() int64 { return 200 },
+	})
+	assert.Error(t, err)
+
+	// 同步恢复后的一个过期周期内不做状态变更
+	storage.setErr(nil)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&checker.recoverTimeSec) > 0
+	}, time.Second, 10*time.Millisecond)
+	checkResp, err := checker.Check(&plugin.CheckRequest{
+		QueryRequest:      plugin.QueryRequest{InstanceId: "ins-1", Healthy: true},
+		ExpireDurationSec: 15,
+		CurTimeSec:        func() int64 { return 200 },
+	})
+	assert.NoError(t, err)
+	assert.True(t, checkResp.StayUnchanged)
+}
+
+func TestStoreHealthChecker_Check(t *testing.T) {
+	storage := newFakeHeartbeatStore()
+	checker := newTestChecker(t, storage, map[string]interface{}{
+		"batch": map[string]interface{}{"open": false},
+	})
+	now := time.Now().Unix()
+	assert.NoError(t, checker.Report(reportRequest("ins-1", now, 1)))
+
+	checkRequest := &plugin.CheckRequest{
+		QueryRequest:      plugin.QueryRequest{InstanceId: "ins-1", Healthy: true},
+		ExpireDurationSec: 15,
+		CurTimeSec:        func() int64 { return now },
+	}
+	resp, err := checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+
+	// 心跳超时
+	checkRequest.CurTimeSec = func() int64 { return now + 20 }
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	checkRequest.Healthy = false
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+
+	// 心跳恢复
+	assert.NoError(t, checker.Report(reportRequest("ins-1", now+20, 2)))
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	// 暂停期间不做状态变更
+	checker.Suspend()
+	assert.Equal(t, commontime.CurrentMillisecond()/1000, checker.SuspendTimeSec())
+	checkRequest.CurTimeSec = func() int64 { return now + 60 }
+	checkRequest.Healthy = true
+	resp, err = checker.Check(checkRequest)
+	assert.NoError(t, err)
+	assert.True(t, resp.StayUnchanged)
+}
@@ -49,3 +49,15 @@ func GetParsePassword() ParsePassword {
 
 	return plugin.(ParsePassword)
 }
+
+// ParseSecret 使用密码解析插件解析配置中的密码等敏感信息，未配置插件时原样返回
+func ParseSecret(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	p := GetParsePassword()
+	if p == nil {
+		return value, nil
+	}
+	return p.ParsePassword(value)
+}
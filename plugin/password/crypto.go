@@ -0,0 +1,188 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package password
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// EncryptPrefix AES-GCM 密文的前缀，格式为 ENC(base64(nonce + ciphertext))
+	EncryptPrefix = "ENC("
+	// SealedPrefix sealed box 密文的前缀，格式为 SEALED(base64(sealed box))
+	SealedPrefix = "SEALED("
+	// SecretPrefix 密钥文件的引用前缀，格式为 SECRET(文件名)
+	SecretPrefix = "SECRET("
+	// DefaultKeyEnv 默认保存 AES 密钥的环境变量
+	DefaultKeyEnv = "POLARIS_SECRET_KEY"
+	// DefaultPrivateKeyEnv 默认保存 sealed box 私钥的环境变量
+	DefaultPrivateKeyEnv = "POLARIS_SECRET_PRIVATE_KEY"
+
+	cipherSuffix = ")"
+)
+
+// GenerateKey 生成 base64 编码的 AES-256 密钥
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// GenerateKeyPair 生成 base64 编码的 sealed box 公钥和私钥
+func GenerateKeyPair() (string, string, error) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(publicKey[:]), base64.StdEncoding.EncodeToString(privateKey[:]), nil
+}
+
+// LoadKey 读取 base64 编码的密钥，优先读取文件，文件为空时读取环境变量，都未配置时返回 nil
+func LoadKey(file, env string) ([]byte, error) {
+	value := ""
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		value = string(data)
+	} else if env != "" {
+		value = os.Getenv(env)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("key is not encoded by base64: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypt 使用 AES-GCM 加密明文，返回 ENC(...) 格式的密文
+func Encrypt(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return EncryptPrefix + base64.StdEncoding.EncodeToString(data) + cipherSuffix, nil
+}
+
+// Seal 使用 base64 编码的公钥通过 sealed box 加密明文，返回 SEALED(...) 格式的密文
+func Seal(publicKey string, plain string) (string, error) {
+	key, err := decodeKey32(publicKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := box.SealAnonymous(nil, []byte(plain), key, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return SealedPrefix + base64.StdEncoding.EncodeToString(data) + cipherSuffix, nil
+}
+
+// decrypt 解密 AES-GCM 密文，data 为 base64 编码的 nonce 和密文
+func decrypt(key []byte, data string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// open 使用私钥解密 sealed box 密文
+func open(privateKey []byte, data string) (string, error) {
+	if len(privateKey) != 32 {
+		return "", errors.New("private key must be 32 bytes")
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return "", err
+	}
+	var pub, priv [32]byte
+	copy(pub[:], publicKey)
+	copy(priv[:], privateKey)
+	plain, ok := box.OpenAnonymous(nil, raw, &pub, &priv)
+	if !ok {
+		return "", errors.New("fail to open the sealed box")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func decodeKey32(value string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("key is not encoded by base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	key := &[32]byte{}
+	copy(key[:], raw)
+	return key, nil
+}
+
+// unwrap 去掉密文的前缀和后缀，不是该格式时返回 false
+func unwrap(value, prefix string) (string, bool) {
+	if !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, cipherSuffix) {
+		return "", false
+	}
+	return value[len(prefix) : len(value)-len(cipherSuffix)], true
+}
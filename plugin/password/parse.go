@@ -17,7 +17,13 @@
 
 package password
 
-import "github.com/polarismesh/polaris/plugin"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/polarismesh/polaris/plugin"
+)
 
 const (
 	PluginName = "localParse"
@@ -28,8 +34,23 @@ func init() {
 	plugin.RegisterPlugin(PluginName, &Password{})
 }
 
-// Password 密码插件
-type Password struct{}
+// Option 密码插件的配置，密钥都为 base64 编码，文件优先于环境变量
+type Option struct {
+	// KeyFile 保存 AES 密钥的文件，用于解密 ENC(...) 格式的密文
+	KeyFile string `json:"keyFile"`
+	// KeyEnv 保存 AES 密钥的环境变量，默认为 POLARIS_SECRET_KEY
+	KeyEnv string `json:"keyEnv"`
+	// PrivateKeyFile 保存 sealed box 私钥的文件，用于解密 SEALED(...) 格式的密文
+	PrivateKeyFile string `json:"privateKeyFile"`
+	// PrivateKeyEnv 保存 sealed box 私钥的环境变量，默认为 POLARIS_SECRET_PRIVATE_KEY
+	PrivateKeyEnv string `json:"privateKeyEnv"`
+}
+
+// Password 密码插件，解密本地密钥加密的密文，不是密文格式的配置按照明文处理
+type Password struct {
+	key        []byte
+	privateKey []byte
+}
 
 // Name 返回插件名字
 func (p *Password) Name() string {
@@ -43,10 +64,48 @@ func (p *Password) Destroy() error {
 
 // Initialize 插件初始化
 func (p *Password) Initialize(c *plugin.ConfigEntry) error {
+	option := &Option{}
+	if len(c.Option) > 0 {
+		data, err := json.Marshal(c.Option)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, option); err != nil {
+			return fmt.Errorf("fail to unmarshal %s config entry, err is %v", PluginName, err)
+		}
+	}
+	if option.KeyEnv == "" {
+		option.KeyEnv = DefaultKeyEnv
+	}
+	if option.PrivateKeyEnv == "" {
+		option.PrivateKeyEnv = DefaultPrivateKeyEnv
+	}
+
+	key, err := LoadKey(option.KeyFile, option.KeyEnv)
+	if err != nil {
+		return fmt.Errorf("fail to load the key of %s: %w", PluginName, err)
+	}
+	privateKey, err := LoadKey(option.PrivateKeyFile, option.PrivateKeyEnv)
+	if err != nil {
+		return fmt.Errorf("fail to load the private key of %s: %w", PluginName, err)
+	}
+	p.key, p.privateKey = key, privateKey
 	return nil
 }
 
 // ParsePassword 解析密码
 func (p *Password) ParsePassword(cipher string) (string, error) {
-	return "", nil
+	if data, ok := unwrap(cipher, EncryptPrefix); ok {
+		if len(p.key) == 0 {
+			return "", errors.New("key of the encrypted password is not configured")
+		}
+		return decrypt(p.key, data)
+	}
+	if data, ok := unwrap(cipher, SealedPrefix); ok {
+		if len(p.privateKey) == 0 {
+			return "", errors.New("private key of the sealed password is not configured")
+		}
+		return open(p.privateKey, data)
+	}
+	return cipher, nil
 }
@@ -0,0 +1,95 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func TestPasswordEncrypt(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "secret.key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(key+"\n"), 0600))
+	publicKey, privateKey, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	t.Setenv(DefaultPrivateKeyEnv, privateKey)
+
+	p := &Password{}
+	assert.NoError(t, p.Initialize(&plugin.ConfigEntry{Option: map[string]interface{}{"keyFile": keyFile}}))
+
+	raw, err := LoadKey(keyFile, "")
+	assert.NoError(t, err)
+	cipher, err := Encrypt(raw, "polaris")
+	assert.NoError(t, err)
+	plain, err := p.ParsePassword(cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, "polaris", plain)
+
+	sealed, err := Seal(publicKey, "sealed-polaris")
+	assert.NoError(t, err)
+	plain, err = p.ParsePassword(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "sealed-polaris", plain)
+
+	// 不是密文格式的配置按照明文处理
+	plain, err = p.ParsePassword("plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", plain)
+
+	// 密文被篡改
+	_, err = p.ParsePassword(cipher[:len(cipher)-3] + "AA)")
+	assert.Error(t, err)
+
+	// 未配置密钥
+	empty := &Password{}
+	assert.NoError(t, empty.Initialize(&plugin.ConfigEntry{Option: map[string]interface{}{
+		"keyEnv": "POLARIS_TEST_NOT_EXIST_KEY", "privateKeyEnv": "POLARIS_TEST_NOT_EXIST_KEY",
+	}}))
+	_, err = empty.ParsePassword(cipher)
+	assert.Error(t, err)
+	_, err = empty.ParsePassword(sealed)
+	assert.Error(t, err)
+}
+
+func TestSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mysql-password"), []byte("polaris\n"), 0600))
+
+	s := &SecretFile{}
+	assert.NoError(t, s.Initialize(&plugin.ConfigEntry{Option: map[string]interface{}{"dir": dir}}))
+
+	plain, err := s.ParsePassword("SECRET(mysql-password)")
+	assert.NoError(t, err)
+	assert.Equal(t, "polaris", plain)
+
+	plain, err = s.ParsePassword("plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", plain)
+
+	_, err = s.ParsePassword("SECRET(not-exist)")
+	assert.Error(t, err)
+	_, err = s.ParsePassword("SECRET(../mysql-password)")
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package password
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// SecretFilePluginName 从挂载目录中读取密码的插件
+	SecretFilePluginName = "secretFile"
+	// DefaultSecretDir 默认的密钥挂载目录
+	DefaultSecretDir = "/etc/polaris/secrets"
+)
+
+func init() {
+	plugin.RegisterPlugin(SecretFilePluginName, &SecretFile{})
+}
+
+// SecretFile 密码插件，SECRET(文件名) 格式的配置从挂载目录的文件中读取，
+// 适用于 kubernetes secret 等以文件形式挂载的密钥，其余配置按照明文处理
+type SecretFile struct {
+	dir string
+}
+
+// Name 返回插件名字
+func (s *SecretFile) Name() string {
+	return SecretFilePluginName
+}
+
+// Destroy 销毁插件
+func (s *SecretFile) Destroy() error {
+	return nil
+}
+
+// Initialize 插件初始化
+func (s *SecretFile) Initialize(c *plugin.ConfigEntry) error {
+	s.dir, _ = c.Option["dir"].(string)
+	if s.dir == "" {
+		s.dir = DefaultSecretDir
+	}
+	return nil
+}
+
+// ParsePassword 解析密码
+func (s *SecretFile) ParsePassword(cipher string) (string, error) {
+	name, ok := unwrap(cipher, SecretPrefix)
+	if !ok {
+		return cipher, nil
+	}
+	// 只允许读取挂载目录下的文件
+	if name == "" || name != filepath.Base(name) || name == ".." {
+		return "", fmt.Errorf("invalid secret file name %s", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
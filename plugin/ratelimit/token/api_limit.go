@@ -21,19 +21,23 @@ import (
 	"errors"
 	"sync"
 
-	"golang.org/x/time/rate"
+	"github.com/polarismesh/polaris/plugin"
 )
 
 // apiRatelimit 接口限流类
 type apiRatelimit struct {
-	rules  map[string]*BucketRatelimit // 存储规则
-	apis   sync.Map                    // 存储api -> apiLimiter
-	config *APILimitConfig
+	rules   map[string]*BucketRatelimit // 存储规则
+	apis    sync.Map                    // 存储api -> apiLimiter
+	config  *APILimitConfig
+	buckets bucketFactory
 }
 
-// newAPIRatelimit 新建一个接口限流类
-func newAPIRatelimit(config *APILimitConfig) (*apiRatelimit, error) {
-	art := &apiRatelimit{}
+// newAPIRatelimit 新建一个接口限流类，buckets 为空时使用单机令牌桶
+func newAPIRatelimit(config *APILimitConfig, buckets bucketFactory) (*apiRatelimit, error) {
+	if buckets == nil {
+		buckets = localBucketFactory{}
+	}
+	art := &apiRatelimit{buckets: buckets}
 	if err := art.initialize(config); err != nil {
 		return nil, err
 	}
@@ -108,7 +112,7 @@ func (art *apiRatelimit) parseApis(apis []*APILimitInfo) error {
 
 // createLimiter 创建一个私有limiter
 func (art *apiRatelimit) createLimiter(name string, limit *BucketRatelimit) *apiLimiter {
-	limiter := newAPILimiter(name, limit.Open, limit.Rate, limit.Bucket)
+	limiter := newAPILimiter(name, limit.Open, limit.Rate, limit.Bucket, art.buckets)
 	art.apis.Store(name, limiter)
 	return limiter
 }
@@ -144,36 +148,36 @@ func (art *apiRatelimit) allow(name string) bool {
 	return limiter.Allow()
 }
 
-// 封装rate.Limiter
+// 封装令牌桶
 // 每个API接口对应一个apiLimiter
 type apiLimiter struct {
-	open          bool   // 该接口是否开启限流
-	name          string // 接口名
-	*rate.Limiter        // 令牌桶对象
+	open   bool   // 该接口是否开启限流
+	name   string // 接口名
+	bucket        // 令牌桶对象
 }
 
 // newAPILimiter 新建一个apiLimiter
-func newAPILimiter(name string, open bool, r int, b int) *apiLimiter {
+func newAPILimiter(name string, open bool, r int, b int, buckets bucketFactory) *apiLimiter {
 	limiter := &apiLimiter{
-		open:    false,
-		name:    name,
-		Limiter: nil,
+		open:   false,
+		name:   name,
+		bucket: nil,
 	}
 	if !open {
 		return limiter
 	}
 
 	limiter.open = true
-	limiter.Limiter = rate.NewLimiter(rate.Limit(r), b)
+	limiter.bucket = buckets.newBucket(plugin.RatelimitStr[plugin.APIRatelimit]+":"+name, r, b)
 	return limiter
 }
 
-// Allow 继承rate.Limiter.Allow函数
+// Allow 继承令牌桶的Allow函数
 func (a *apiLimiter) Allow() bool {
 	// 当前接口不开启限流
 	if !a.open {
 		return true
 	}
 
-	return a.Limiter.Allow()
+	return a.bucket.Allow()
 }
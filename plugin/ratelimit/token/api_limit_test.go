@@ -26,7 +26,7 @@ import (
 
 // newAPIRateLimitCheck 校验新建apiRate
 func newAPIRateLimitCheck(t *testing.T, config *APILimitConfig) *apiRatelimit {
-	apiLimit, err := newAPIRatelimit(config)
+	apiLimit, err := newAPIRatelimit(config, nil)
 	if err != nil {
 		t.Fatalf("error: %s", err.Error())
 	}
@@ -102,7 +102,7 @@ func TestAPILimitConfig(t *testing.T) {
 	})
 	Convey("rules为空，报错", t, func() {
 		config := &APILimitConfig{Open: true}
-		limiter, err := newAPIRatelimit(config)
+		limiter, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		So(limiter, ShouldBeNil)
 	})
@@ -115,7 +115,7 @@ func TestAPILimitConfig(t *testing.T) {
 			},
 			Apis: []*APILimitInfo{},
 		}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 	})
 }
@@ -131,14 +131,14 @@ func TestAPILimitConfigRule(t *testing.T) {
 			{Name: "",
 				Limit: &BucketRatelimit{Open: true, Bucket: 0, Rate: 5}},
 		}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 	})
 	Convey("rules内部参数，limit不能为空", t, func() {
 		config.Rules = []*RateLimitRule{
 			{Name: "rule-1", Limit: nil},
 		}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 	})
 	Convey("rules内部参数，open为false，bucket和rate可以是任意值", t, func() {
@@ -146,7 +146,7 @@ func TestAPILimitConfigRule(t *testing.T) {
 			{Name: "",
 				Limit: &BucketRatelimit{Open: false}},
 		}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 	})
 	Convey("rules内部参数，open的规则，bucket和rate必须大于0", t, func() {
@@ -154,13 +154,13 @@ func TestAPILimitConfigRule(t *testing.T) {
 			{Name: "rule-1",
 				Limit: &BucketRatelimit{Open: true, Bucket: 0, Rate: 5}},
 		}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 
 		config.Rules[0].Limit.Bucket = 10
 		config.Rules[0].Limit.Rate = 0
-		_, err = newAPIRatelimit(config)
+		_, err = newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 	})
@@ -176,18 +176,18 @@ func TestAPILimitConfigApi(t *testing.T) {
 		},
 	}
 	Convey("apis内部参数，apis为空，返回错误", t, func() {
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 	})
 	Convey("apis内部参数，部分参数为空，返回错误", t, func() {
 		config.Apis = []*APILimitInfo{{Name: "", Rule: ""}}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 
 		config.Apis[0].Name = "123"
-		_, err = newAPIRatelimit(config)
+		_, err = newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 
@@ -196,7 +196,7 @@ func TestAPILimitConfigApi(t *testing.T) {
 	})
 	Convey("api内部参数，rule不存在，返回错误", t, func() {
 		config.Apis = []*APILimitInfo{{Name: "aaa", Rule: "bbb"}}
-		_, err := newAPIRatelimit(config)
+		_, err := newAPIRatelimit(config, nil)
 		So(err, ShouldNotBeNil)
 		t.Logf("%s", err.Error())
 
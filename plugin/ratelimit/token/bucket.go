@@ -0,0 +1,45 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// bucket 令牌桶
+type bucket interface {
+	// Allow 获取一个令牌，获取成功返回true
+	Allow() bool
+}
+
+// bucketFactory 令牌桶的创建方式，key 为令牌桶在全部节点之间的唯一标识
+type bucketFactory interface {
+	newBucket(key string, rate int, burst int) bucket
+	close() error
+}
+
+// localBucketFactory 单机令牌桶，每个节点独立计算令牌
+type localBucketFactory struct{}
+
+func (localBucketFactory) newBucket(_ string, r int, b int) bucket {
+	return rate.NewLimiter(rate.Limit(r), b)
+}
+
+func (localBucketFactory) close() error {
+	return nil
+}
@@ -19,6 +19,7 @@ package token
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -36,6 +37,33 @@ type Config struct {
 
 	// 基于实例的限流配置
 	InstanceLimitConf *ResourceLimitConfig `mapstructure:"instance-limit"`
+
+	// 分布式限流配置，开启后令牌桶的状态通过redis在所有节点之间共享
+	DistributedConf *DistributedConfig `mapstructure:"distributed"`
+}
+
+// DistributedConfig 分布式限流配置
+type DistributedConfig struct {
+	// 是否开启分布式限流，不开启时每个节点独立限流
+	Open bool `mapstructure:"open"`
+
+	// 每次从redis预取到本地的令牌数，不超过令牌桶大小
+	Prefetch int `mapstructure:"prefetch"`
+
+	// 预取令牌的有效期，过期未使用的令牌在下一次预取时归还到redis
+	PrefetchTTL time.Duration `mapstructure:"prefetch-ttl"`
+
+	// 单次访问redis的超时时间
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// redis不可用时退化为单机限流，经过该间隔后重新尝试访问redis
+	RetryInterval time.Duration `mapstructure:"retry-interval"`
+
+	// redis中令牌桶key的前缀
+	KeyPrefix string `mapstructure:"key-prefix"`
+
+	// redis连接配置，与heartbeatRedis插件的配置相同
+	Redis map[string]interface{} `mapstructure:"redis"`
 }
 
 // BucketRatelimit 针对令牌桶的具体配置
@@ -102,7 +130,14 @@ func decodeConfig(data map[string]interface{}) (*Config, error) {
 		return nil, fmt.Errorf("plugin(%s) option is empty", PluginName)
 	}
 	var config Config
-	if err := mapstructure.Decode(data, &config); err != nil {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     &config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(data); err != nil {
 		log.Errorf("[Plugin][%s] decode config err: %s", PluginName, err.Error())
 		return nil, err
 	}
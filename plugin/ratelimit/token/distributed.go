@@ -0,0 +1,260 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/time/rate"
+
+	"github.com/polarismesh/polaris/common/redispool"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	defaultPrefetch      = 10
+	defaultPrefetchTTL   = time.Second
+	defaultRedisTimeout  = 100 * time.Millisecond
+	defaultRetryInterval = 5 * time.Second
+	defaultKeyPrefix     = "polaris_ratelimit:"
+)
+
+// acquireScript 按照令牌桶算法从redis中获取令牌，令牌不足时返回实际获取到的数量
+// KEYS[1] 令牌桶的key；ARGV 依次为每秒生成的令牌数、令牌桶大小、申请的令牌数、当前时间（毫秒）、归还的令牌数
+// 令牌桶的时间只会向前推进，节点之间的时钟误差不会导致令牌被重复发放；
+// 本地预取的令牌过期未使用时在下一次申请时归还，归还后令牌数不超过令牌桶大小
+var acquireScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local requested = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local returned = tonumber(ARGV[5])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+	ts = now
+end
+tokens = math.min(burst, tokens + returned)
+local granted = math.min(requested, math.floor(tokens))
+tokens = tokens - granted
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return granted
+`)
+
+// redisBucketFactory 分布式令牌桶，令牌桶的状态保存在redis中，redis不可用时退化为单机令牌桶
+type redisBucketFactory struct {
+	config *DistributedConfig
+	client redis.UniversalClient
+	// downUntil redis不可用时，在该时间之前不再访问redis，单位纳秒
+	downUntil int64
+	down      uint32
+}
+
+// newRedisBucketFactory 解析分布式限流配置并创建redis客户端
+func newRedisBucketFactory(config *DistributedConfig) (*redisBucketFactory, error) {
+	if config.Prefetch == 0 {
+		config.Prefetch = defaultPrefetch
+	}
+	if config.PrefetchTTL == 0 {
+		config.PrefetchTTL = defaultPrefetchTTL
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultRedisTimeout
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = defaultKeyPrefix
+	}
+	if config.Prefetch < 0 || config.PrefetchTTL < 0 || config.Timeout < 0 || config.RetryInterval < 0 {
+		return nil, errors.New("distributed ratelimit prefetch, prefetch-ttl, timeout or retry-interval invalid")
+	}
+	if len(config.Redis) == 0 {
+		return nil, errors.New("distributed ratelimit redis config is empty")
+	}
+
+	redisBytes, err := json.Marshal(config.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal distributed ratelimit redis config, err is %v", err)
+	}
+	var redisConfig redispool.Config
+	if err = json.Unmarshal(redisBytes, &redisConfig); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal distributed ratelimit redis config, err is %v", err)
+	}
+	if redisConfig.KvPasswd, err = plugin.ParseSecret(redisConfig.KvPasswd); err != nil {
+		return nil, fmt.Errorf("fail to parse distributed ratelimit redis password, err is %v", err)
+	}
+	if redisConfig.SentinelConfig.SentinelPassword, err = plugin.ParseSecret(
+		redisConfig.SentinelConfig.SentinelPassword); err != nil {
+		return nil, fmt.Errorf("fail to parse distributed ratelimit sentinel password, err is %v", err)
+	}
+
+	log.Infof("[Plugin][%s] distributed ratelimit open, prefetch %d", PluginName, config.Prefetch)
+	return &redisBucketFactory{
+		config: config,
+		client: redispool.NewRedisClient(&redisConfig),
+	}, nil
+}
+
+func (f *redisBucketFactory) newBucket(key string, r int, b int) bucket {
+	// 脚本中使用 rate 计算过期时间，为 0 时除零，redis会拒绝 PEXPIRE 导致一直退化为单机限流
+	if r < 1 {
+		r = 1
+	}
+	prefetch := f.config.Prefetch
+	if prefetch > b {
+		prefetch = b
+	}
+	return &redisBucket{
+		factory:  f,
+		key:      f.config.KeyPrefix + key,
+		rate:     r,
+		burst:    b,
+		prefetch: prefetch,
+		local:    rate.NewLimiter(rate.Limit(r), b),
+	}
+}
+
+func (f *redisBucketFactory) close() error {
+	return f.client.Close()
+}
+
+// available redis是否可用，不可用期间直接使用单机令牌桶
+func (f *redisBucketFactory) available(now time.Time) bool {
+	return now.UnixNano() >= atomic.LoadInt64(&f.downUntil)
+}
+
+// markDown 访问redis失败，在重试间隔内退化为单机限流
+func (f *redisBucketFactory) markDown(now time.Time, err error) {
+	atomic.StoreInt64(&f.downUntil, now.Add(f.config.RetryInterval).UnixNano())
+	if atomic.CompareAndSwapUint32(&f.down, 0, 1) {
+		log.Errorf("[Plugin][%s] distributed ratelimit redis unavailable, fallback to local ratelimit: %s",
+			PluginName, err.Error())
+	}
+}
+
+// markUp 访问redis成功，恢复分布式限流
+func (f *redisBucketFactory) markUp() {
+	if atomic.CompareAndSwapUint32(&f.down, 1, 0) {
+		log.Infof("[Plugin][%s] distributed ratelimit redis recovered", PluginName)
+	}
+}
+
+// acquire 归还过期未使用的令牌并从redis中申请令牌，返回实际获取到的令牌数
+func (f *redisBucketFactory) acquire(key string, r, b, requested, returned int, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.Timeout)
+	defer cancel()
+	return acquireScript.Run(ctx, f.client, []string{key}, r, b, requested, now.UnixMilli(), returned).Int()
+}
+
+// redisBucket 分布式令牌桶，每次从redis中预取一批令牌到本地，减少访问redis的次数
+type redisBucket struct {
+	factory  *redisBucketFactory
+	key      string
+	rate     int
+	burst    int
+	prefetch int
+	// local redis不可用时使用的单机令牌桶
+	local *rate.Limiter
+
+	mu       sync.Mutex
+	tokens   int
+	expireAt time.Time
+	// refilling 正在进行的预取，同一时间只有一个请求访问redis，其他请求等待预取的结果
+	refilling *refill
+}
+
+// refill 一次从redis中预取令牌的结果，done 关闭之后 granted 以及 err 可以读取
+type refill struct {
+	done    chan struct{}
+	granted int
+	err     error
+}
+
+// Allow 优先使用本地预取的令牌，用完后再从redis中预取，访问redis期间不持有令牌桶的锁
+func (rb *redisBucket) Allow() bool {
+	for {
+		now := time.Now()
+		if !rb.factory.available(now) {
+			return rb.local.AllowN(now, 1)
+		}
+
+		rb.mu.Lock()
+		if rb.tokens > 0 && now.Before(rb.expireAt) {
+			rb.tokens--
+			rb.mu.Unlock()
+			return true
+		}
+		if r := rb.refilling; r != nil {
+			rb.mu.Unlock()
+			<-r.done
+			if r.err == nil && r.granted <= 0 {
+				return false
+			}
+			// 预取失败时按照redis不可用处理，预取成功时重新竞争预取到的令牌
+			continue
+		}
+		r := &refill{done: make(chan struct{})}
+		rb.refilling = r
+		returned := rb.tokens
+		rb.tokens = 0
+		rb.mu.Unlock()
+
+		return rb.doRefill(r, returned, now)
+	}
+}
+
+// doRefill 从redis中预取令牌，并归还过期未使用的令牌，预取成功时当前请求占用其中一个令牌
+func (rb *redisBucket) doRefill(r *refill, returned int, now time.Time) bool {
+	r.granted, r.err = rb.factory.acquire(rb.key, rb.rate, rb.burst, rb.prefetch, returned, now)
+	// 先更新redis的可用状态再唤醒等待的请求，预取失败时等待的请求直接使用单机令牌桶
+	if r.err != nil {
+		rb.factory.markDown(now, r.err)
+	} else {
+		rb.factory.markUp()
+	}
+
+	rb.mu.Lock()
+	rb.refilling = nil
+	if r.err == nil && r.granted > 0 {
+		rb.tokens = r.granted - 1
+		rb.expireAt = now.Add(rb.factory.config.PrefetchTTL)
+	}
+	rb.mu.Unlock()
+	close(r.done)
+
+	if r.err != nil {
+		return rb.local.AllowN(now, 1)
+	}
+	return r.granted > 0
+}
@@ -0,0 +1,228 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// newTestRedisBucketFactory 创建连接到 miniredis 的分布式令牌桶
+func newTestRedisBucketFactory(t *testing.T, addr string, prefetch int) *redisBucketFactory {
+	f, err := newRedisBucketFactory(&DistributedConfig{
+		Open:          true,
+		Prefetch:      prefetch,
+		RetryInterval: 50 * time.Millisecond,
+		Redis:         map[string]interface{}{"kvAddr": addr},
+	})
+	if err != nil {
+		t.Fatalf("error: %s", err.Error())
+	}
+	t.Cleanup(func() {
+		_ = f.close()
+	})
+	return f
+}
+
+// countAllowed 交替在多个令牌桶上获取令牌，返回获取成功的次数
+func countAllowed(times int, buckets ...bucket) int {
+	cnt := 0
+	for i := 0; i < times; i++ {
+		for _, b := range buckets {
+			if b.Allow() {
+				cnt++
+			}
+		}
+	}
+	return cnt
+}
+
+// TestRedisBucket_Shared 测试多个节点共享令牌桶
+func TestRedisBucket_Shared(t *testing.T) {
+	s := miniredis.RunT(t)
+	node1 := newTestRedisBucketFactory(t, s.Addr(), 3)
+	node2 := newTestRedisBucketFactory(t, s.Addr(), 3)
+
+	Convey("多个节点共享同一个令牌桶，总的通过数不超过令牌桶大小", t, func() {
+		b1 := node1.newBucket("ip-limit:1.1.1.1", 1, 10)
+		b2 := node2.newBucket("ip-limit:1.1.1.1", 1, 10)
+		cnt := countAllowed(20, b1, b2)
+		So(cnt, ShouldBeBetweenOrEqual, 10, 11)
+		So(s.Exists(defaultKeyPrefix+"ip-limit:1.1.1.1"), ShouldBeTrue)
+	})
+	Convey("不同的key使用不同的令牌桶", t, func() {
+		b := node1.newBucket("ip-limit:2.2.2.2", 1, 5)
+		So(countAllowed(10, b), ShouldBeBetweenOrEqual, 5, 6)
+	})
+	Convey("速率为0时按照每秒1个令牌计算，仍然使用redis限流", t, func() {
+		b := node1.newBucket("ip-limit:3.3.3.3", 0, 2).(*redisBucket)
+		So(b.rate, ShouldEqual, 1)
+		So(b.Allow(), ShouldBeTrue)
+		So(atomic.LoadUint32(&node1.down), ShouldEqual, 0)
+		So(s.TTL(defaultKeyPrefix+"ip-limit:3.3.3.3"), ShouldBeGreaterThan, 0)
+	})
+	Convey("预取的令牌数不超过令牌桶大小", t, func() {
+		b := node1.newBucket("api-limit:api-1", 1, 2).(*redisBucket)
+		So(b.prefetch, ShouldEqual, 2)
+		So(b.Allow(), ShouldBeTrue)
+		So(b.tokens, ShouldEqual, 1)
+	})
+}
+
+// TestRedisBucket_Prefetch 测试预取令牌减少访问redis的次数
+func TestRedisBucket_Prefetch(t *testing.T) {
+	s := miniredis.RunT(t)
+	f := newTestRedisBucketFactory(t, s.Addr(), 10)
+
+	Convey("使用本地预取的令牌时不访问redis", t, func() {
+		b := f.newBucket("ip-limit:1.1.1.1", 100, 100)
+		So(b.Allow(), ShouldBeTrue)
+		count := s.CommandCount()
+		So(countAllowed(9, b), ShouldEqual, 9)
+		So(s.CommandCount(), ShouldEqual, count)
+		So(b.Allow(), ShouldBeTrue)
+		So(s.CommandCount(), ShouldBeGreaterThan, count)
+	})
+	Convey("预取的令牌过期后归还", t, func() {
+		f.config.PrefetchTTL = 10 * time.Millisecond
+		b := f.newBucket("ip-limit:2.2.2.2", 1, 10).(*redisBucket)
+		So(b.Allow(), ShouldBeTrue)
+		So(b.tokens, ShouldEqual, 9)
+		time.Sleep(20 * time.Millisecond)
+		// 令牌桶中剩余的令牌已经全部预取，过期未使用的令牌归还后重新获取
+		So(b.Allow(), ShouldBeTrue)
+		So(b.tokens, ShouldEqual, 8)
+		So(countAllowed(10, b), ShouldEqual, 8)
+	})
+}
+
+// TestRedisBucket_Concurrent 测试并发获取令牌时只有一个请求访问redis，通过数不超过令牌桶大小
+func TestRedisBucket_Concurrent(t *testing.T) {
+	s := miniredis.RunT(t)
+	f := newTestRedisBucketFactory(t, s.Addr(), 3)
+
+	Convey("并发获取令牌，总的通过数不超过令牌桶大小", t, func() {
+		b := f.newBucket("ip-limit:1.1.1.1", 1, 10)
+		var (
+			wg  sync.WaitGroup
+			cnt int32
+		)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if b.Allow() {
+					atomic.AddInt32(&cnt, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		So(cnt, ShouldBeBetweenOrEqual, 10, 11)
+		So(b.(*redisBucket).refilling, ShouldBeNil)
+	})
+}
+
+// TestRedisBucket_Fallback 测试redis不可用时退化为单机限流
+func TestRedisBucket_Fallback(t *testing.T) {
+	s := miniredis.RunT(t)
+	f := newTestRedisBucketFactory(t, s.Addr(), 1)
+	b := f.newBucket("ip-limit:1.1.1.1", 1, 5)
+
+	Convey("redis不可用时使用单机令牌桶", t, func() {
+		f.config.RetryInterval = time.Minute
+		s.Close()
+		So(countAllowed(10, b), ShouldBeBetweenOrEqual, 5, 6)
+		So(f.available(time.Now()), ShouldBeFalse)
+	})
+	Convey("redis恢复后重新使用分布式令牌桶", t, func() {
+		So(s.Restart(), ShouldBeNil)
+		// 重试间隔之后重新访问redis
+		f.config.RetryInterval = 10 * time.Millisecond
+		f.markDown(time.Now(), errors.New("mock error"))
+		time.Sleep(20 * time.Millisecond)
+		So(b.Allow(), ShouldBeTrue)
+		So(f.available(time.Now()), ShouldBeTrue)
+		So(s.Exists(defaultKeyPrefix+"ip-limit:1.1.1.1"), ShouldBeTrue)
+	})
+}
+
+// TestTokenBucket_Distributed 测试插件开启分布式限流
+func TestTokenBucket_Distributed(t *testing.T) {
+	s := miniredis.RunT(t)
+	Convey("分布式限流配置无效，返回失败", t, func() {
+		configEntry := &plugin.ConfigEntry{Name: PluginName, Option: baseConfigOption()}
+		configEntry.Option["distributed"] = map[string]interface{}{"open": true}
+		tb := &tokenBucket{}
+		So(tb.Initialize(configEntry), ShouldNotBeNil)
+
+		configEntry.Option["distributed"] = map[string]interface{}{
+			"open":     true,
+			"prefetch": -1,
+			"redis":    map[string]interface{}{"kvAddr": s.Addr()},
+		}
+		So(tb.Initialize(configEntry), ShouldNotBeNil)
+	})
+	Convey("开启分布式限流，IP以及接口限流共享令牌桶", t, func() {
+		option := baseConfigOption()
+		option["distributed"] = map[string]interface{}{
+			"open":           true,
+			"prefetch":       2,
+			"retry-interval": "1s",
+			"key-prefix":     "test:",
+			"redis":          map[string]interface{}{"kvAddr": s.Addr()},
+		}
+		node1, node2 := &tokenBucket{}, &tokenBucket{}
+		So(node1.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option}), ShouldBeNil)
+		So(node2.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option}), ShouldBeNil)
+		defer func() {
+			_ = node1.Destroy()
+			_ = node2.Destroy()
+		}()
+		So(node1.buckets.(*redisBucketFactory).config.RetryInterval, ShouldEqual, time.Second)
+
+		cnt := 0
+		for i := 0; i < 20; i++ {
+			for _, tb := range []*tokenBucket{node1, node2} {
+				if tb.Allow(plugin.IPRatelimit, "1.1.1.1") {
+					cnt++
+				}
+			}
+		}
+		So(cnt, ShouldBeBetweenOrEqual, 10, 12)
+		So(s.Exists("test:ip-limit:1.1.1.1"), ShouldBeTrue)
+
+		cnt = 0
+		for i := 0; i < 10; i++ {
+			for _, tb := range []*tokenBucket{node1, node2} {
+				if tb.Allow(plugin.APIRatelimit, "api-1") {
+					cnt++
+				}
+			}
+		}
+		So(cnt, ShouldBeBetweenOrEqual, 5, 6)
+		So(s.Exists("test:api-limit:api-1"), ShouldBeTrue)
+	})
+}
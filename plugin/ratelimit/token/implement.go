@@ -29,40 +29,75 @@ func (tb *tokenBucket) initialize(c *plugin.ConfigEntry) error {
 		return err
 	}
 
-	tb.config = config
-	tb.limiters = make(map[plugin.RatelimitType]limiter)
+	// 分布式限流
+	var buckets bucketFactory = localBucketFactory{}
+	if config.DistributedConf != nil && config.DistributedConf.Open {
+		if buckets, err = newRedisBucketFactory(config.DistributedConf); err != nil {
+			log.Errorf("[Plugin][%s] initialize distributed ratelimit err: %s", PluginName, err.Error())
+			return err
+		}
+	}
+
+	limiters := make(map[plugin.RatelimitType]limiter)
 
 	// IP限流
-	irt, err := newResourceRatelimit(plugin.IPRatelimit, config.IPLimitConf)
+	irt, err := newResourceRatelimit(plugin.IPRatelimit, config.IPLimitConf, buckets)
 	if err != nil {
+		_ = buckets.close()
 		return err
 	}
-	tb.limiters[plugin.IPRatelimit] = irt
+	limiters[plugin.IPRatelimit] = irt
 
 	// 接口限流
-	art, err := newAPIRatelimit(config.APILimitConf)
+	art, err := newAPIRatelimit(config.APILimitConf, buckets)
 	if err != nil {
+		_ = buckets.close()
 		return err
 	}
-	tb.limiters[plugin.APIRatelimit] = art
+	limiters[plugin.APIRatelimit] = art
 
 	// 操作实例限流
-	instance, err := newResourceRatelimit(plugin.InstanceRatelimit, config.InstanceLimitConf)
+	instance, err := newResourceRatelimit(plugin.InstanceRatelimit, config.InstanceLimitConf, buckets)
 	if err != nil {
+		_ = buckets.close()
 		return err
 	}
-	tb.limiters[plugin.InstanceRatelimit] = instance
+	limiters[plugin.InstanceRatelimit] = instance
 
+	// 重新加载时整体替换限流器，令牌桶的状态会被重置
+	tb.mu.Lock()
+	previous := tb.buckets
+	tb.config = config
+	tb.limiters = limiters
+	tb.buckets = buckets
+	tb.mu.Unlock()
+	if previous != nil {
+		_ = previous.close()
+	}
 	return nil
 }
 
+// destroy 释放分布式限流的redis连接
+func (tb *tokenBucket) destroy() error {
+	tb.mu.Lock()
+	buckets := tb.buckets
+	tb.buckets = nil
+	tb.mu.Unlock()
+	if buckets == nil {
+		return nil
+	}
+	return buckets.close()
+}
+
 // allow 插件的限流实现函数
 func (tb *tokenBucket) allow(typ plugin.RatelimitType, key string) bool {
 	// key为空，则不作限制
 	if key == "" {
 		return true
 	}
+	tb.mu.RLock()
 	l, ok := tb.limiters[typ]
+	tb.mu.RUnlock()
 	if !ok {
 		return true
 	}
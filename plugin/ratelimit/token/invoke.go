@@ -18,13 +18,17 @@
 package token
 
 import (
+	"sync"
+
 	"github.com/polarismesh/polaris/plugin"
 )
 
 // tokenBucket 实现Plugin接口
 type tokenBucket struct {
+	mu       sync.RWMutex
 	config   *Config
 	limiters map[plugin.RatelimitType]limiter
+	buckets  bucketFactory
 }
 
 // Name 实现Plugin接口，Name方法
@@ -37,9 +41,14 @@ func (tb *tokenBucket) Initialize(c *plugin.ConfigEntry) error {
 	return tb.initialize(c)
 }
 
+// Reload 实现Reloadable接口，使用新的配置重建限流器
+func (tb *tokenBucket) Reload(c *plugin.ConfigEntry) error {
+	return tb.initialize(c)
+}
+
 // Destroy 实现Plugin接口，Destroy方法
 func (tb *tokenBucket) Destroy() error {
-	return nil
+	return tb.destroy()
 }
 
 // Allow 限流接口实现
@@ -126,3 +126,30 @@ func TestTokenBucket_Allow(t *testing.T) {
 		So(tb.Allow(plugin.RatelimitType(100), "123"), ShouldEqual, true)
 	})
 }
+
+// TestTokenBucket_Reload 测试重新加载配置
+func TestTokenBucket_Reload(t *testing.T) {
+	configEntry := &plugin.ConfigEntry{Name: PluginName}
+	configEntry.Option = baseConfigOption()
+	tb := &tokenBucket{}
+	if err := tb.Initialize(configEntry); err != nil {
+		t.Fatalf("error: %s", err.Error())
+	}
+	Convey("无效配置，保持原有的限流器", t, func() {
+		origin := tb.limiters[plugin.IPRatelimit]
+		So(tb.Reload(&plugin.ConfigEntry{Name: PluginName}), ShouldNotBeNil)
+		So(tb.limiters[plugin.IPRatelimit], ShouldEqual, origin)
+	})
+	Convey("修改IP限流的令牌桶大小，新配置生效", t, func() {
+		option := baseConfigOption()
+		option["ip-limit"].(*ResourceLimitConfig).Global = &BucketRatelimit{true, 3, 1}
+		So(tb.Reload(&plugin.ConfigEntry{Name: PluginName, Option: option}), ShouldBeNil)
+		cnt := 0
+		for i := 0; i < 10; i++ {
+			if ok := tb.Allow(plugin.IPRatelimit, "1.2.3.4"); ok {
+				cnt++
+			}
+		}
+		So(cnt, ShouldEqual, 3)
+	})
+}
@@ -21,7 +21,6 @@ import (
 	"fmt"
 
 	lru "github.com/hashicorp/golang-lru"
-	"golang.org/x/time/rate"
 
 	"github.com/polarismesh/polaris/plugin"
 )
@@ -32,11 +31,16 @@ type resourceRatelimit struct {
 	resources *lru.Cache
 	whiteList map[string]bool
 	config    *ResourceLimitConfig
+	buckets   bucketFactory
 }
 
-// 新建资源限制器
-func newResourceRatelimit(typ plugin.RatelimitType, config *ResourceLimitConfig) (*resourceRatelimit, error) {
-	r := &resourceRatelimit{typStr: plugin.RatelimitStr[typ]}
+// 新建资源限制器，buckets 为空时使用单机令牌桶
+func newResourceRatelimit(typ plugin.RatelimitType, config *ResourceLimitConfig,
+	buckets bucketFactory) (*resourceRatelimit, error) {
+	if buckets == nil {
+		buckets = localBucketFactory{}
+	}
+	r := &resourceRatelimit{typStr: plugin.RatelimitStr[typ], buckets: buckets}
 	if err := r.initialize(config); err != nil {
 		return nil, err
 	}
@@ -102,7 +106,7 @@ func (r *resourceRatelimit) allow(key string) bool {
 	value, ok := r.resources.Get(key)
 	if !ok {
 		r.resources.ContainsOrAdd(key,
-			rate.NewLimiter(rate.Limit(r.config.Global.Rate), r.config.Global.Bucket))
+			r.buckets.newBucket(r.typStr+":"+key, r.config.Global.Rate, r.config.Global.Bucket))
 		// 上面已经加了value，这里正常情况会有value
 		value, ok = r.resources.Get(key)
 		if !ok {
@@ -113,5 +117,5 @@ func (r *resourceRatelimit) allow(key string) bool {
 		}
 	}
 
-	return value.(*rate.Limiter).Allow()
+	return value.(bucket).Allow()
 }
@@ -31,14 +31,14 @@ import (
 func TestNewResourceRatelimit(t *testing.T) {
 	Convey("测试新建一个资源限制器", t, func() {
 		Convey("config为空", func() {
-			limiter, err := newResourceRatelimit(plugin.InstanceRatelimit, nil)
+			limiter, err := newResourceRatelimit(plugin.InstanceRatelimit, nil, nil)
 			So(limiter, ShouldNotBeNil)
 			So(err, ShouldBeNil)
 		})
 		Convey("不开启限制器", func() {
 			limiter, err := newResourceRatelimit(plugin.InstanceRatelimit, &ResourceLimitConfig{
 				Open: false,
-			})
+			}, nil)
 			So(limiter, ShouldNotBeNil)
 			So(err, ShouldBeNil)
 			So(limiter.allow("11111"), ShouldBeTrue)
@@ -46,7 +46,7 @@ func TestNewResourceRatelimit(t *testing.T) {
 		Convey("开启了限制器，global为空", func() {
 			limiter, err := newResourceRatelimit(plugin.InstanceRatelimit, &ResourceLimitConfig{
 				Open: true,
-			})
+			}, nil)
 			So(limiter, ShouldBeNil)
 			So(err, ShouldNotBeNil)
 			t.Logf("%s", err.Error())
@@ -55,14 +55,14 @@ func TestNewResourceRatelimit(t *testing.T) {
 			limiter, err := newResourceRatelimit(plugin.InstanceRatelimit, &ResourceLimitConfig{
 				Open:   true,
 				Global: &BucketRatelimit{},
-			})
+			}, nil)
 			So(limiter, ShouldBeNil)
 			So(err, ShouldNotBeNil)
 
 			limiter, err = newResourceRatelimit(plugin.InstanceRatelimit, &ResourceLimitConfig{
 				Open:   true,
 				Global: &BucketRatelimit{true, 10, 10},
-			})
+			}, nil)
 			So(limiter, ShouldBeNil)
 			So(err, ShouldNotBeNil)
 
@@ -70,7 +70,7 @@ func TestNewResourceRatelimit(t *testing.T) {
 				Open:                   true,
 				Global:                 &BucketRatelimit{true, 10, 10},
 				MaxResourceCacheAmount: -1,
-			})
+			}, nil)
 			So(limiter, ShouldBeNil)
 			So(err, ShouldNotBeNil)
 		})
@@ -79,7 +79,7 @@ func TestNewResourceRatelimit(t *testing.T) {
 				Open:                   true,
 				Global:                 &BucketRatelimit{true, 10, 5},
 				MaxResourceCacheAmount: 10,
-			})
+			}, nil)
 			So(limiter, ShouldNotBeNil)
 			So(err, ShouldBeNil)
 		})
@@ -89,7 +89,7 @@ func TestNewResourceRatelimit(t *testing.T) {
 				Global:                 &BucketRatelimit{true, 10, 5},
 				MaxResourceCacheAmount: 10,
 				WhiteList:              []string{"1", "2", "3"},
-			})
+			}, nil)
 			So(limiter, ShouldNotBeNil)
 			So(err, ShouldBeNil)
 			So(len(limiter.whiteList), ShouldEqual, 3)
@@ -105,7 +105,7 @@ func TestResourceAllow(t *testing.T) {
 				Open:                   true,
 				Global:                 &BucketRatelimit{true, 5, 5},
 				MaxResourceCacheAmount: 2,
-			})
+			}, nil)
 			So(err, ShouldBeNil)
 			cnt := 0
 			for i := 0; i <= limiter.config.Global.Rate*2; i++ {
@@ -128,7 +128,7 @@ func TestResourceAllow(t *testing.T) {
 				Open:                   true,
 				Global:                 &BucketRatelimit{true, 5, 5},
 				MaxResourceCacheAmount: 2,
-			})
+			}, nil)
 			So(err, ShouldBeNil)
 			cnt := 0
 			for i := 0; i < limiter.config.Global.Rate*20; i++ {
@@ -145,7 +145,7 @@ func TestResourceAllow(t *testing.T) {
 				Global:                 &BucketRatelimit{true, 5, 5},
 				MaxResourceCacheAmount: 1024,
 				WhiteList:              []string{"1000", "1001", "1002"},
-			})
+			}, nil)
 			So(err, ShouldBeNil)
 
 			cnt := 0
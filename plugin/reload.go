@@ -0,0 +1,83 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package plugin
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Reloadable 支持运行时重新加载配置的插件
+type Reloadable interface {
+	// Reload 使用新的配置重新加载插件，失败时插件需要保持原有的配置
+	Reload(c *ConfigEntry) error
+}
+
+type reloadEntry struct {
+	key  string
+	cur  *ConfigEntry
+	next *ConfigEntry
+}
+
+// ReloadPluginConfig 对比新的插件配置，重新加载配置发生变化的插件，返回重新加载的插件配置项。
+// 插件名称发生变化、插件执行链配置发生变化或者插件不支持重新加载时返回错误，此时不会重新加载任何插件；
+// 插件自身重新加载失败时，之前已经重新加载成功的插件不会回滚
+func ReloadPluginConfig(c *Config) ([]string, error) {
+	if !reflect.DeepEqual(config.History, c.History) {
+		return nil, fmt.Errorf("plugin.history can not be reloaded")
+	}
+	if !reflect.DeepEqual(config.Statis, c.Statis) {
+		return nil, fmt.Errorf("plugin.statis can not be reloaded")
+	}
+	if !reflect.DeepEqual(config.DiscoverEvent, c.DiscoverEvent) {
+		return nil, fmt.Errorf("plugin.discoverEvent can not be reloaded")
+	}
+
+	entries := []*reloadEntry{
+		{key: "cmdb", cur: &config.CMDB, next: &c.CMDB},
+		{key: "ratelimit", cur: &config.RateLimit, next: &c.RateLimit},
+		{key: "discoverStatis", cur: &config.DiscoverStatis, next: &c.DiscoverStatis},
+		{key: "parsePassword", cur: &config.ParsePassword, next: &c.ParsePassword},
+		{key: "whitelist", cur: &config.Whitelist, next: &c.Whitelist},
+		{key: "meshResourceValidate", cur: &config.MeshResourceValidate, next: &c.MeshResourceValidate},
+	}
+	changed := make([]*reloadEntry, 0, len(entries))
+	for _, entry := range entries {
+		if reflect.DeepEqual(entry.cur, entry.next) {
+			continue
+		}
+		if entry.cur.Name != entry.next.Name {
+			return nil, fmt.Errorf("plugin.%s.name can not be reloaded", entry.key)
+		}
+		if _, ok := pluginSet[entry.next.Name].(Reloadable); !ok {
+			return nil, fmt.Errorf("plugin.%s(%s) does not support reload", entry.key, entry.next.Name)
+		}
+		changed = append(changed, entry)
+	}
+
+	keys := make([]string, 0, len(changed))
+	for _, entry := range changed {
+		if err := pluginSet[entry.next.Name].(Reloadable).Reload(entry.next); err != nil {
+			return keys, fmt.Errorf("reload plugin.%s(%s) err: %w", entry.key, entry.next.Name, err)
+		}
+		*entry.cur = *entry.next
+		keys = append(keys, "plugin."+entry.key)
+		log.Infof("[Plugin] reload plugin %s(%s) success", entry.key, entry.next.Name)
+	}
+	return keys, nil
+}
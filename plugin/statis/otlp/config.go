@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultEndpoint    = "127.0.0.1:4317"
+	defaultInterval    = 15 * time.Second
+	defaultTimeout     = 10 * time.Second
+	defaultSampleRatio = 1.0
+)
+
+// Config OTLP 导出插件的配置
+type Config struct {
+	// Endpoint OTLP gRPC 接收端地址
+	Endpoint string
+	// Insecure 是否使用明文连接
+	Insecure bool
+	// Headers 导出时携带的请求头，用于接收端鉴权
+	Headers map[string]string
+	// Interval 指标导出周期
+	Interval time.Duration
+	// Timeout 单次导出的超时时间
+	Timeout time.Duration
+	// Trace 是否开启链路追踪
+	Trace bool
+	// SampleRatio 链路追踪的采样比例，调用方已经采样的请求总是会被记录
+	SampleRatio float64
+}
+
+// parseConfig 解析插件配置
+func parseConfig(option map[string]interface{}) (*Config, error) {
+	conf := &Config{
+		Endpoint:    defaultEndpoint,
+		Insecure:    true,
+		Headers:     map[string]string{},
+		Interval:    defaultInterval,
+		Timeout:     defaultTimeout,
+		SampleRatio: defaultSampleRatio,
+	}
+	if endpoint, _ := option["endpoint"].(string); endpoint != "" {
+		conf.Endpoint = endpoint
+	}
+	if insecure, ok := option["insecure"].(bool); ok {
+		conf.Insecure = insecure
+	}
+	switch headers := option["headers"].(type) {
+	case map[string]interface{}:
+		for key, value := range headers {
+			conf.Headers[key], _ = value.(string)
+		}
+	case map[interface{}]interface{}:
+		for key, value := range headers {
+			name, _ := key.(string)
+			conf.Headers[name], _ = value.(string)
+		}
+	}
+	if interval, _ := option["interval"].(int); interval > 0 {
+		conf.Interval = time.Duration(interval) * time.Second
+	}
+	if timeout, _ := option["timeout"].(int); timeout > 0 {
+		conf.Timeout = time.Duration(timeout) * time.Second
+	}
+	conf.Trace, _ = option["trace"].(bool)
+	switch ratio := option["sampleRatio"].(type) {
+	case float64:
+		conf.SampleRatio = ratio
+	case int:
+		conf.SampleRatio = float64(ratio)
+	}
+	if conf.SampleRatio < 0 || conf.SampleRatio > 1 {
+		return nil, errors.New("sampleRatio must be between 0 and 1")
+	}
+	return conf, nil
+}
@@ -0,0 +1,129 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
+)
+
+var (
+	// clientGauges 客户端数量指标
+	clientGauges = []string{
+		"client_total",
+	}
+	// namingGauges 服务以及实例数量指标，每次上报包含全部命名空间以及服务的取值
+	namingGauges = []string{
+		"service_count",
+		"service_online_count",
+		"service_abnormal_count",
+		"service_offline_count",
+		"instance_count",
+		"instance_online_count",
+		"instance_abnormal_count",
+		"instance_isolate_count",
+	}
+	// configGauges 配置中心的资源数量指标，每次上报包含全部命名空间以及配置分组的取值
+	configGauges = []string{
+		"config_group_count",
+		"config_file_count",
+		"config_release_file_count",
+	}
+	// gaugeNames 服务发现以及配置中心的资源数量指标，和 prometheus 插件的指标名称保持一致
+	gaugeNames = append(append(append([]string{}, clientGauges...), namingGauges...), configGauges...)
+)
+
+// gaugePoint 指标在一组标签下的最新取值
+type gaugePoint struct {
+	attrs []attribute.KeyValue
+	value int64
+}
+
+// gaugeValues 保存资源数量指标的最新取值，在导出周期内由 SDK 回调读取
+type gaugeValues struct {
+	lock   sync.RWMutex
+	values map[string]map[attribute.Distinct]gaugePoint
+}
+
+func newGaugeValues() *gaugeValues {
+	values := make(map[string]map[attribute.Distinct]gaugePoint, len(gaugeNames))
+	for _, name := range gaugeNames {
+		values[name] = map[attribute.Distinct]gaugePoint{}
+	}
+	return &gaugeValues{values: values}
+}
+
+// replace 使用一次上报的全量取值替换 names 对应指标的全部取值，本次上报中不再出现的标签组合被删除，
+// 避免已经删除的服务、命名空间以及配置分组的指标被一直导出
+func (g *gaugeValues) replace(names []string, batch gaugeBatch) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, name := range names {
+		if _, ok := g.values[name]; !ok {
+			continue
+		}
+		points := batch[name]
+		if points == nil {
+			points = map[attribute.Distinct]gaugePoint{}
+		}
+		g.values[name] = points
+	}
+}
+
+// gaugeBatch 一次上报的指标取值
+type gaugeBatch map[string]map[attribute.Distinct]gaugePoint
+
+// set 记录指标在一组标签下的取值
+func (b gaugeBatch) set(name string, value int64, attrs []attribute.KeyValue) {
+	set := attribute.NewSet(attrs...)
+	points, ok := b[name]
+	if !ok {
+		points = map[attribute.Distinct]gaugePoint{}
+		b[name] = points
+	}
+	points[set.Equivalent()] = gaugePoint{attrs: set.ToSlice(), value: value}
+}
+
+// register 注册异步指标，导出时读取最新取值
+func (g *gaugeValues) register(meter metric.Meter) error {
+	gauges := make(map[string]asyncint64.Gauge, len(gaugeNames))
+	insts := make([]instrument.Asynchronous, 0, len(gaugeNames))
+	for _, name := range gaugeNames {
+		gauge, err := meter.AsyncInt64().Gauge(name)
+		if err != nil {
+			return err
+		}
+		gauges[name] = gauge
+		insts = append(insts, gauge)
+	}
+
+	return meter.RegisterCallback(insts, func(ctx context.Context) {
+		g.lock.RLock()
+		defer g.lock.RUnlock()
+		for name, points := range g.values {
+			for _, point := range points {
+				gauges[name].Observe(ctx, point.value, point.attrs...)
+			}
+		}
+	})
+}
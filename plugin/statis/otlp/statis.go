@@ -0,0 +1,253 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	commonLog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/tracing"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/common/version"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	PluginName = "otlp"
+
+	// metricCallTotal 接口调用次数
+	metricCallTotal = "rq_total"
+	// metricCallDuration 接口调用耗时，单位为毫秒
+	metricCallDuration = "rq_duration"
+	// labelCallType 调用类型
+	labelCallType = "call_type"
+)
+
+var log = commonLog.RegisterScope(PluginName, "", 0)
+
+func init() {
+	s := &StatisWorker{}
+	plugin.RegisterPlugin(s.Name(), s)
+}
+
+// StatisWorker 通过 OTLP 协议导出指标以及链路数据的统计插件
+type StatisWorker struct {
+	conf           *Config
+	meterProvider  *sdkmetric.MeterProvider
+	tracerProvider *sdktrace.TracerProvider
+	callTotal      syncint64.Counter
+	callDuration   syncfloat64.Histogram
+	gauges         *gaugeValues
+}
+
+// Name 获取统计插件名称
+func (s *StatisWorker) Name() string {
+	return PluginName
+}
+
+// Initialize 初始化统计插件
+func (s *StatisWorker) Initialize(conf *plugin.ConfigEntry) error {
+	cfg, err := parseConfig(conf.Option)
+	if err != nil {
+		return err
+	}
+	s.conf = cfg
+	s.gauges = newGaugeValues()
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "polaris-server"),
+		attribute.String("service.version", version.Get()),
+		attribute.String(metrics.LabelServerNode, utils.LocalHost),
+	)
+	if err := s.initMetrics(res); err != nil {
+		return err
+	}
+	if cfg.Trace {
+		if err := s.initTrace(res); err != nil {
+			_ = s.meterProvider.Shutdown(context.Background())
+			return err
+		}
+	}
+	log.Infof("[Statis][OTLP] export to %s, interval %s, trace %v", cfg.Endpoint, cfg.Interval, cfg.Trace)
+	return nil
+}
+
+func (s *StatisWorker) initMetrics(res *resource.Resource) error {
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(s.conf.Endpoint),
+		otlpmetricgrpc.WithHeaders(s.conf.Headers),
+		otlpmetricgrpc.WithTimeout(s.conf.Timeout),
+	}
+	if s.conf.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	exporter, err := otlpmetricgrpc.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+	s.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(s.conf.Interval), sdkmetric.WithTimeout(s.conf.Timeout))),
+	)
+
+	meter := s.meterProvider.Meter(tracing.TracerName)
+	if s.callTotal, err = meter.SyncInt64().Counter(metricCallTotal,
+		instrument.WithDescription("total number of interface calls")); err != nil {
+		return err
+	}
+	if s.callDuration, err = meter.SyncFloat64().Histogram(metricCallDuration,
+		instrument.WithDescription("time consumed per interface call"),
+		instrument.WithUnit(unit.Milliseconds)); err != nil {
+		return err
+	}
+	return s.gauges.register(meter)
+}
+
+func (s *StatisWorker) initTrace(res *resource.Resource) error {
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(s.conf.Endpoint),
+		otlptracegrpc.WithHeaders(s.conf.Headers),
+		otlptracegrpc.WithTimeout(s.conf.Timeout),
+	}
+	if s.conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+	s.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.conf.SampleRatio))),
+	)
+	// 注册为全局的 TracerProvider，内部埋点通过 tracing 包获取 tracer
+	otel.SetTracerProvider(s.tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// Destroy 销毁统计插件，导出尚未发送的数据
+func (s *StatisWorker) Destroy() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.conf.Timeout)
+	defer cancel()
+
+	var err error
+	if s.tracerProvider != nil {
+		err = s.tracerProvider.Shutdown(ctx)
+	}
+	if s.meterProvider != nil {
+		if mErr := s.meterProvider.Shutdown(ctx); mErr != nil {
+			err = mErr
+		}
+	}
+	return err
+}
+
+// ReportCallMetrics report call metrics info
+func (s *StatisWorker) ReportCallMetrics(metric metrics.CallMetric) {
+	attrs := buildAttributes(metric.GetLabels())
+	attrs = append(attrs, attribute.String(labelCallType, string(metric.Type)))
+
+	times := int64(metric.Times)
+	if times <= 0 {
+		times = 1
+	}
+	ctx := context.Background()
+	s.callTotal.Add(ctx, times, attrs...)
+	if metric.Duration > 0 {
+		s.callDuration.Record(ctx, float64(metric.Duration.Microseconds())/1e3, attrs...)
+	}
+}
+
+// ReportDiscoveryMetrics report discovery metrics
+// 服务以及实例的指标每次上报全量的取值，不包含客户端指标的上报（包括空的上报）替换全部的服务以及实例指标
+func (s *StatisWorker) ReportDiscoveryMetrics(metric ...metrics.DiscoveryMetric) {
+	batch := gaugeBatch{}
+	hasClient, hasNaming := false, false
+	for i := range metric {
+		m := metric[i]
+		attrs := buildAttributes(m.Labels)
+		switch m.Type {
+		case metrics.ServiceMetrics:
+			hasNaming = true
+			batch.set("service_count", m.Total, attrs)
+			batch.set("service_online_count", m.Online, attrs)
+			batch.set("service_abnormal_count", m.Abnormal, attrs)
+			batch.set("service_offline_count", m.Offline, attrs)
+		case metrics.InstanceMetrics:
+			hasNaming = true
+			batch.set("instance_count", m.Total, attrs)
+			batch.set("instance_online_count", m.Online, attrs)
+			batch.set("instance_abnormal_count", m.Abnormal, attrs)
+			batch.set("instance_isolate_count", m.Isolate, attrs)
+		case metrics.ClientMetrics:
+			hasClient = true
+			batch.set("client_total", m.Total, attrs)
+		}
+	}
+	if hasClient {
+		s.gauges.replace(clientGauges, batch)
+	}
+	if hasNaming || !hasClient {
+		s.gauges.replace(namingGauges, batch)
+	}
+}
+
+// ReportConfigMetrics report config_center metrics
+// 配置中心的指标每次上报全量的取值，替换全部的配置中心指标
+func (s *StatisWorker) ReportConfigMetrics(metric ...metrics.ConfigMetrics) {
+	batch := gaugeBatch{}
+	for i := range metric {
+		m := metric[i]
+		attrs := buildAttributes(m.Labels)
+		switch m.Type {
+		case metrics.ConfigGroupMetric:
+			batch.set("config_group_count", m.Total, attrs)
+		case metrics.FileMetric:
+			batch.set("config_file_count", m.Total, attrs)
+		case metrics.ReleaseFileMetric:
+			batch.set("config_release_file_count", m.Total, attrs)
+		}
+	}
+	s.gauges.replace(configGauges, batch)
+}
+
+func buildAttributes(labels map[string]string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(labels)+1)
+	for key, value := range labels {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+	return attrs
+}
@@ -0,0 +1,211 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/tracing"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// receiver 进程内的 OTLP 接收端，记录收到的指标以及 span 名称
+type receiver struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+
+	lock    sync.Mutex
+	metrics map[string]int
+	spans   []string
+}
+
+func (r *receiver) Export(_ context.Context,
+	req *collectormetrics.ExportMetricsServiceRequest) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, rm := range req.GetResourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				points := len(m.GetSum().GetDataPoints()) + len(m.GetGauge().GetDataPoints()) +
+					len(m.GetHistogram().GetDataPoints())
+				r.metrics[m.GetName()] += points
+			}
+		}
+	}
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+type traceReceiver struct {
+	collectortrace.UnimplementedTraceServiceServer
+	r *receiver
+}
+
+func (t *traceReceiver) Export(_ context.Context,
+	req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	t.r.lock.Lock()
+	defer t.r.lock.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				t.r.spans = append(t.r.spans, span.GetName())
+			}
+		}
+	}
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func startReceiver(t *testing.T) (*receiver, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	r := &receiver{metrics: map[string]int{}}
+	server := grpc.NewServer()
+	collectormetrics.RegisterMetricsServiceServer(server, r)
+	collectortrace.RegisterTraceServiceServer(server, &traceReceiver{r: r})
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	t.Cleanup(server.Stop)
+	return r, ln.Addr().String()
+}
+
+func TestStatisWorker_Export(t *testing.T) {
+	r, endpoint := startReceiver(t)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	s := &StatisWorker{}
+	err := s.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"endpoint": endpoint,
+			"interval": 60,
+			"trace":    true,
+		},
+	})
+	assert.NoError(t, err)
+
+	s.ReportCallMetrics(metrics.CallMetric{
+		Type:     metrics.ServerCallMetric,
+		API:      "/v1.PolarisGRPC/RegisterInstance",
+		Protocol: "gRPC",
+		Code:     200000,
+		Duration: 5 * time.Millisecond,
+	})
+	s.ReportDiscoveryMetrics(metrics.DiscoveryMetric{
+		Type:   metrics.InstanceMetrics,
+		Total:  3,
+		Online: 2,
+		Labels: map[string]string{metrics.LabelNamespace: "default", metrics.LabelService: "order"},
+	}, metrics.DiscoveryMetric{
+		Type:  metrics.ClientMetrics,
+		Total: 1,
+	})
+	s.ReportConfigMetrics(metrics.ConfigMetrics{
+		Type:   metrics.FileMetric,
+		Total:  4,
+		Labels: map[string]string{metrics.LabelNamespace: "default", metrics.LabelGroup: "app"},
+	})
+
+	ctx, parent := tracing.StartServerSpan(context.Background(), "/v1.PolarisGRPC/RegisterInstance")
+	_, child := tracing.StartSpan(ctx, "naming.CreateInstance")
+	tracing.EndSpan(child, nil)
+	parent.End()
+
+	// 销毁插件时导出剩余的数据
+	assert.NoError(t, s.Destroy())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	assert.Equal(t, 1, r.metrics[metricCallTotal])
+	assert.Equal(t, 1, r.metrics[metricCallDuration])
+	assert.Equal(t, 1, r.metrics["instance_count"])
+	assert.Equal(t, 1, r.metrics["instance_online_count"])
+	assert.Equal(t, 1, r.metrics["client_total"])
+	assert.Equal(t, 1, r.metrics["config_file_count"])
+	assert.Equal(t, 0, r.metrics["service_count"])
+	assert.ElementsMatch(t, []string{"/v1.PolarisGRPC/RegisterInstance", "naming.CreateInstance"}, r.spans)
+}
+
+func TestStatisWorker_RemoveDeletedGauges(t *testing.T) {
+	s := &StatisWorker{gauges: newGaugeValues()}
+	instanceMetric := func(service string) metrics.DiscoveryMetric {
+		return metrics.DiscoveryMetric{
+			Type:   metrics.InstanceMetrics,
+			Total:  1,
+			Labels: map[string]string{metrics.LabelNamespace: "default", metrics.LabelService: service},
+		}
+	}
+	s.ReportDiscoveryMetrics(instanceMetric("order"), instanceMetric("user"))
+	s.ReportDiscoveryMetrics(metrics.DiscoveryMetric{Type: metrics.ClientMetrics, Total: 1})
+	s.ReportConfigMetrics(metrics.ConfigMetrics{
+		Type:   metrics.FileMetric,
+		Total:  4,
+		Labels: map[string]string{metrics.LabelNamespace: "default", metrics.LabelGroup: "app"},
+	})
+	assert.Len(t, s.gauges.values["instance_count"], 2)
+	assert.Len(t, s.gauges.values["client_total"], 1)
+	assert.Len(t, s.gauges.values["config_file_count"], 1)
+
+	// 服务删除之后不再上报，对应的指标被删除，客户端指标不受影响
+	s.ReportDiscoveryMetrics(instanceMetric("order"))
+	assert.Len(t, s.gauges.values["instance_count"], 1)
+	assert.Len(t, s.gauges.values["client_total"], 1)
+	s.ReportDiscoveryMetrics()
+	assert.Len(t, s.gauges.values["instance_count"], 0)
+	assert.Len(t, s.gauges.values["client_total"], 1)
+
+	s.ReportConfigMetrics()
+	assert.Len(t, s.gauges.values["config_file_count"], 0)
+}
+
+func TestParseConfig(t *testing.T) {
+	conf, err := parseConfig(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultEndpoint, conf.Endpoint)
+	assert.True(t, conf.Insecure)
+	assert.False(t, conf.Trace)
+	assert.Equal(t, defaultInterval, conf.Interval)
+
+	conf, err = parseConfig(map[string]interface{}{
+		"endpoint":    "collector:4317",
+		"insecure":    false,
+		"headers":     map[interface{}]interface{}{"authorization": "Bearer token"},
+		"interval":    30,
+		"sampleRatio": 0.1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "collector:4317", conf.Endpoint)
+	assert.False(t, conf.Insecure)
+	assert.Equal(t, "Bearer token", conf.Headers["authorization"])
+	assert.Equal(t, 30*time.Second, conf.Interval)
+	assert.Equal(t, 0.1, conf.SampleRatio)
+
+	_, err = parseConfig(map[string]interface{}{"sampleRatio": 2})
+	assert.Error(t, err)
+}
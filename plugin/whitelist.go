@@ -33,6 +33,21 @@ type Whitelist interface {
 	Contain(entry interface{}) bool
 }
 
+// WhitelistEntry 白名单校验的访问信息，Contain 也支持直接传入 IP 字符串，此时只匹配作用于全部接口的规则
+type WhitelistEntry struct {
+	IP string
+	// API 访问的接口，HTTP 接口为 url 路径，gRPC 接口为方法全名
+	API string
+}
+
+// DynamicWhitelist 支持运行时规则的白名单插件
+type DynamicWhitelist interface {
+	Whitelist
+
+	// Refresh 立即从存储层重新加载运行时规则
+	Refresh() error
+}
+
 // GetWhitelist Get the whitelist plug -in
 func GetWhitelist() Whitelist {
 	c := &config.Whitelist
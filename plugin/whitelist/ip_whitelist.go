@@ -18,19 +18,69 @@
 package whitelist
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
 )
 
-const PluginName = "whitelist"
+const (
+	PluginName = "whitelist"
+
+	defaultSyncInterval = 10 * time.Second
+)
 
 func init() {
 	plugin.RegisterPlugin(PluginName, &ipWhitelist{})
 }
 
+// ipWhitelist IP 白名单，支持 IP、CIDR 网段以及 IPv6。
+// 配置文件中的 ip 作用于全部接口，apis 按照接口路径前缀单独配置，匹配最长的前缀，
+// 匹配到前缀时只使用该前缀的白名单。dynamic 开启后从存储层定期加载运行时规则，
+// 运行时的 allow 规则在配置文件的基础上放通匹配前缀的接口，deny 规则优先于任何放通规则
 type ipWhitelist struct {
-	ips map[string]bool
+	mu sync.RWMutex
+	// ips、nets 作用于全部接口的 IP 地址和网段
+	ips  map[string]bool
+	nets []*net.IPNet
+	// apis 按照接口路径前缀配置的白名单
+	apis []*apiRule
+	// rules 从存储层加载的运行时规则
+	rules []*dynamicRule
+
+	syncInterval time.Duration
+	cancel       context.CancelFunc
+	loadRules    func() ([]*model.WhitelistRule, error)
+}
+
+// apiRule 作用于指定接口路径前缀的白名单
+type apiRule struct {
+	prefix string
+	ips    map[string]bool
+	nets   []*net.IPNet
+}
+
+// dynamicRule 运行时规则
+type dynamicRule struct {
+	api   string
+	ipNet *net.IPNet
+	deny  bool
+}
+
+// whitelistConfig 解析后的插件配置
+type whitelistConfig struct {
+	ips          map[string]bool
+	nets         []*net.IPNet
+	apis         []*apiRule
+	dynamic      bool
+	syncInterval time.Duration
 }
 
 // Name 插件名称
@@ -41,23 +91,274 @@ func (i *ipWhitelist) Name() string {
 // Initialize 初始化IP白名单插件
 func (i *ipWhitelist) Initialize(conf *plugin.ConfigEntry) error {
 	i.ips = make(map[string]bool)
-	ips, ok := conf.Option["ip"].([]interface{})
-	if !ok {
-		return errors.New("whitelist plugin initialize error")
+	c, err := parseConfig(conf)
+	if err != nil {
+		return err
 	}
-	for _, ip := range ips {
-		i.ips[ip.(string)] = true
+	i.apply(c)
+	return nil
+}
+
+// Reload 重新加载IP白名单，配置无效时保持原有的白名单
+func (i *ipWhitelist) Reload(conf *plugin.ConfigEntry) error {
+	c, err := parseConfig(conf)
+	if err != nil {
+		return err
 	}
+	i.apply(c)
 	return nil
 }
 
+// apply 使用新的配置，按需开启或者关闭运行时规则的同步
+func (i *ipWhitelist) apply(c *whitelistConfig) {
+	i.mu.Lock()
+	i.ips, i.nets, i.apis = c.ips, c.nets, c.apis
+	cancel := i.cancel
+	restart := c.dynamic && (cancel == nil || i.syncInterval != c.syncInterval)
+	if !c.dynamic || restart {
+		i.cancel = nil
+	}
+	if !c.dynamic {
+		i.rules = nil
+	}
+	i.syncInterval = c.syncInterval
+	i.mu.Unlock()
+
+	if cancel != nil && (!c.dynamic || restart) {
+		cancel()
+	}
+	if restart {
+		ctx, cancel := context.WithCancel(context.Background())
+		i.mu.Lock()
+		i.cancel = cancel
+		if i.loadRules == nil {
+			i.loadRules = loadStoreRules
+		}
+		i.mu.Unlock()
+		go i.syncRules(ctx, c.syncInterval)
+	}
+}
+
+func parseConfig(conf *plugin.ConfigEntry) (*whitelistConfig, error) {
+	c := &whitelistConfig{ips: map[string]bool{}, syncInterval: defaultSyncInterval}
+	raw, exist := conf.Option["ip"]
+	apis, hasAPIs := conf.Option["apis"].([]interface{})
+	if exist || !hasAPIs {
+		ips, ok := raw.([]interface{})
+		if !ok {
+			return nil, errors.New("whitelist plugin initialize error")
+		}
+		var err error
+		if c.ips, c.nets, err = parseIPs(ips); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range apis {
+		entry, ok := toStringMap(item)
+		if !ok {
+			return nil, errors.New("whitelist plugin apis must be a list of prefix and ip")
+		}
+		prefix, _ := entry["prefix"].(string)
+		ips, _ := entry["ip"].([]interface{})
+		if prefix == "" {
+			return nil, errors.New("whitelist plugin api prefix is empty")
+		}
+		rule := &apiRule{prefix: prefix}
+		var err error
+		if rule.ips, rule.nets, err = parseIPs(ips); err != nil {
+			return nil, err
+		}
+		c.apis = append(c.apis, rule)
+	}
+
+	c.dynamic, _ = conf.Option["dynamic"].(bool)
+	if interval, ok := conf.Option["syncInterval"].(string); ok && interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("whitelist plugin invalid syncInterval %s", interval)
+		}
+		c.syncInterval = d
+	}
+	return c, nil
+}
+
+// parseIPs 解析 IP 列表，单个 IP 地址精确匹配，网段按照 CIDR 匹配
+func parseIPs(values []interface{}) (map[string]bool, []*net.IPNet, error) {
+	ips := make(map[string]bool, len(values))
+	var nets []*net.IPNet
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("whitelist plugin invalid ip %v", value)
+		}
+		if !strings.Contains(str, "/") {
+			ip := net.ParseIP(strings.TrimSpace(str))
+			if ip == nil {
+				return nil, nil, fmt.Errorf("whitelist plugin invalid ip %s", str)
+			}
+			ips[ip.String()] = true
+			continue
+		}
+		ipNet, err := model.ParseIPNet(str)
+		if err != nil {
+			return nil, nil, fmt.Errorf("whitelist plugin %s", err.Error())
+		}
+		nets = append(nets, ipNet)
+	}
+	return ips, nets, nil
+}
+
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			ret[fmt.Sprintf("%v", key)] = item
+		}
+		return ret, true
+	default:
+		return nil, false
+	}
+}
+
 // Destroy 销毁插件
 func (i *ipWhitelist) Destroy() error {
+	i.mu.Lock()
+	cancel := i.cancel
+	i.cancel = nil
+	i.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
 	return nil
 }
 
-// Contain 白名单是否包含IP
+// Contain 白名单是否包含IP，entry 为 IP 字符串或者 plugin.WhitelistEntry
 func (i *ipWhitelist) Contain(entry interface{}) bool {
-	ip, _ := entry.(string)
-	return i.ips[ip]
+	var addr, api string
+	switch v := entry.(type) {
+	case string:
+		addr = v
+	case plugin.WhitelistEntry:
+		addr, api = v.IP, v.API
+	case *plugin.WhitelistEntry:
+		addr, api = v.IP, v.API
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, rule := range i.rules {
+		if rule.deny && strings.HasPrefix(api, rule.api) && rule.ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	scope := i.matchScope(api)
+	if scope == "" {
+		if matchIP(i.ips, i.nets, ip) {
+			return true
+		}
+	}
+	for _, rule := range i.apis {
+		if rule.prefix == scope && matchIP(rule.ips, rule.nets, ip) {
+			return true
+		}
+	}
+	for _, rule := range i.rules {
+		if !rule.deny && strings.HasPrefix(api, rule.api) && rule.ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchScope 返回配置文件中匹配接口的最长路径前缀，没有匹配时返回空，表示使用作用于全部接口的白名单
+func (i *ipWhitelist) matchScope(api string) string {
+	scope := ""
+	for _, rule := range i.apis {
+		if strings.HasPrefix(api, rule.prefix) && len(rule.prefix) > len(scope) {
+			scope = rule.prefix
+		}
+	}
+	return scope
+}
+
+func matchIP(ips map[string]bool, nets []*net.IPNet, ip net.IP) bool {
+	if ips[ip.String()] {
+		return true
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh 立即从存储层重新加载运行时规则，未开启运行时规则时不做处理
+func (i *ipWhitelist) Refresh() error {
+	i.mu.RLock()
+	load := i.loadRules
+	enabled := i.cancel != nil
+	i.mu.RUnlock()
+	if !enabled || load == nil {
+		return nil
+	}
+
+	rules, err := load()
+	if err != nil {
+		return err
+	}
+	ret := make([]*dynamicRule, 0, len(rules))
+	for _, rule := range rules {
+		ipNet, err := model.ParseIPNet(rule.CIDR)
+		if err != nil {
+			log.Errorf("[Plugin][%s] skip invalid rule(%s): %s", PluginName, rule.ID, err.Error())
+			continue
+		}
+		ret = append(ret, &dynamicRule{api: rule.API, ipNet: ipNet, deny: rule.Action == model.WhitelistDeny})
+	}
+
+	i.mu.Lock()
+	i.rules = ret
+	i.mu.Unlock()
+	return nil
+}
+
+func (i *ipWhitelist) syncRules(ctx context.Context, interval time.Duration) {
+	if err := i.Refresh(); err != nil {
+		log.Errorf("[Plugin][%s] load rules err: %s", PluginName, err.Error())
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.Refresh(); err != nil {
+				log.Errorf("[Plugin][%s] load rules err: %s", PluginName, err.Error())
+			}
+		}
+	}
+}
+
+// loadStoreRules 从存储层加载运行时规则，存储插件不支持时返回错误
+func loadStoreRules() ([]*model.WhitelistRule, error) {
+	s, err := store.GetStore()
+	if err != nil {
+		return nil, err
+	}
+	ws, ok := s.(store.WhitelistStore)
+	if !ok {
+		return nil, fmt.Errorf("store %s does not support whitelist rules", s.Name())
+	}
+	return ws.GetWhitelistRules()
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/plugin"
 )
 
@@ -152,3 +153,111 @@ func Test_ipWhitelist_Contain(t *testing.T) {
 		})
 	}
 }
+
+func Test_ipWhitelist_Reload(t *testing.T) {
+	i := &ipWhitelist{}
+	err := i.Initialize(&plugin.ConfigEntry{
+		Name:   "whitelist",
+		Option: map[string]interface{}{"ip": []interface{}{"127.0.0.1"}},
+	})
+	assert.NoError(t, err)
+
+	err = i.Reload(&plugin.ConfigEntry{
+		Name:   "whitelist",
+		Option: map[string]interface{}{"ip": "192.168.0.1"},
+	})
+	assert.Error(t, err)
+	assert.True(t, i.Contain("127.0.0.1"))
+
+	err = i.Reload(&plugin.ConfigEntry{
+		Name:   "whitelist",
+		Option: map[string]interface{}{"ip": []interface{}{"192.168.0.1"}},
+	})
+	assert.NoError(t, err)
+	assert.False(t, i.Contain("127.0.0.1"))
+	assert.True(t, i.Contain("192.168.0.1"))
+}
+
+func Test_ipWhitelist_CIDRAndAPIs(t *testing.T) {
+	i := &ipWhitelist{}
+	err := i.Initialize(&plugin.ConfigEntry{
+		Name: "whitelist",
+		Option: map[string]interface{}{
+			"ip": []interface{}{"127.0.0.1", "192.168.0.0/16", "::1", "fd00::/8"},
+			"apis": []interface{}{
+				map[interface{}]interface{}{"prefix": "/maintain/v1", "ip": []interface{}{"10.1.0.0/16"}},
+				map[interface{}]interface{}{"prefix": "/maintain/v1/jobs", "ip": []interface{}{"10.2.0.1"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, i.Contain("192.168.10.1"))
+	assert.True(t, i.Contain("::1"))
+	assert.True(t, i.Contain("fd00::1"))
+	assert.False(t, i.Contain("10.1.0.1"))
+	assert.False(t, i.Contain("fe80::1"))
+	assert.False(t, i.Contain("invalid"))
+
+	naming := plugin.WhitelistEntry{IP: "192.168.10.1", API: "/naming/v1/services"}
+	assert.True(t, i.Contain(naming))
+	// 匹配到接口前缀时只使用该前缀的白名单
+	assert.False(t, i.Contain(plugin.WhitelistEntry{IP: "192.168.10.1", API: "/maintain/v1/log/outputlevel"}))
+	assert.True(t, i.Contain(&plugin.WhitelistEntry{IP: "10.1.2.3", API: "/maintain/v1/log/outputlevel"}))
+	// 匹配最长的前缀
+	assert.False(t, i.Contain(plugin.WhitelistEntry{IP: "10.1.2.3", API: "/maintain/v1/jobs/trigger"}))
+	assert.True(t, i.Contain(plugin.WhitelistEntry{IP: "10.2.0.1", API: "/maintain/v1/jobs/trigger"}))
+
+	err = i.Reload(&plugin.ConfigEntry{
+		Name:   "whitelist",
+		Option: map[string]interface{}{"ip": []interface{}{"10.0.0.0/33"}},
+	})
+	assert.Error(t, err)
+	assert.True(t, i.Contain("192.168.10.1"))
+}
+
+func Test_ipWhitelist_Dynamic(t *testing.T) {
+	rules := []*model.WhitelistRule{
+		{ID: "1", API: "/maintain/v1", CIDR: "172.16.0.0/12", Action: model.WhitelistAllow},
+		{ID: "2", CIDR: "127.0.0.2", Action: model.WhitelistDeny},
+		{ID: "3", CIDR: "invalid", Action: model.WhitelistAllow},
+	}
+	i := &ipWhitelist{loadRules: func() ([]*model.WhitelistRule, error) {
+		return rules, nil
+	}}
+	err := i.Initialize(&plugin.ConfigEntry{
+		Name: "whitelist",
+		Option: map[string]interface{}{
+			"ip":           []interface{}{"127.0.0.0/8"},
+			"dynamic":      true,
+			"syncInterval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+	defer func() { _ = i.Destroy() }()
+	assert.NoError(t, i.Refresh())
+
+	assert.True(t, i.Contain("127.0.0.1"))
+	// deny 规则优先
+	assert.False(t, i.Contain("127.0.0.2"))
+	assert.False(t, i.Contain(plugin.WhitelistEntry{IP: "127.0.0.2", API: "/maintain/v1/jobs"}))
+	// allow 规则只放通匹配前缀的接口
+	assert.True(t, i.Contain(plugin.WhitelistEntry{IP: "172.16.1.1", API: "/maintain/v1/jobs"}))
+	assert.False(t, i.Contain(plugin.WhitelistEntry{IP: "172.16.1.1", API: "/naming/v1/services"}))
+
+	rules = rules[:1]
+	assert.NoError(t, i.Refresh())
+	assert.True(t, i.Contain("127.0.0.2"))
+
+	// 关闭运行时规则后不再生效
+	assert.NoError(t, i.Reload(&plugin.ConfigEntry{
+		Name:   "whitelist",
+		Option: map[string]interface{}{"ip": []interface{}{"127.0.0.0/8"}},
+	}))
+	assert.False(t, i.Contain(plugin.WhitelistEntry{IP: "172.16.1.1", API: "/maintain/v1/jobs"}))
+
+	// 未开启运行时规则时不会加载
+	loadErr := errors.New("load error")
+	i.loadRules = func() ([]*model.WhitelistRule, error) { return nil, loadErr }
+	assert.NoError(t, i.Refresh())
+}
@@ -23,8 +23,10 @@ import (
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/store"
 )
 
@@ -32,9 +34,9 @@ import (
 type ClientCtrl struct {
 	config          *CtrlConfig
 	storage         store.Store
-	storeThreadCh   []chan []*ClientFuture      // store协程，负责写操作
-	clientHandler   func([]*ClientFuture) error // store协程里面调用的instance处理函数，可以是注册和反注册
-	idleStoreThread chan int                    // 空闲的store协程，记录每一个空闲id
+	storeThreadCh   []chan []*ClientFuture                       // store协程，负责写操作
+	clientHandler   func(context.Context, []*ClientFuture) error // store协程里面调用的instance处理函数，可以是注册和反注册
+	idleStoreThread chan int                                     // 空闲的store协程，记录每一个空闲id
 	waitDuration    time.Duration
 	queue           chan *ClientFuture // 请求接受协程
 	label           string
@@ -164,7 +166,11 @@ func (ctrl *ClientCtrl) storeWorker(ctx context.Context, index int) {
 	for {
 		select {
 		case futures := <-ctrl.storeThreadCh[index]:
-			if err := ctrl.clientHandler(futures); err != nil {
+			flushCtx, span := tracing.StartSpan(context.Background(), "batch.client."+ctrl.label,
+				attribute.Int("batch.size", len(futures)))
+			err := ctrl.clientHandler(flushCtx, futures)
+			tracing.EndSpan(span, err)
+			if err != nil {
 				// 所有的错误都在instanceHandler函数里面进行答复和处理，这里只需记录一条日志
 				log.Errorf("[Batch][Client] %s clients err: %s", ctrl.label, err.Error())
 			}
@@ -181,7 +187,7 @@ func (ctrl *ClientCtrl) storeWorker(ctx context.Context, index int) {
 // 判断实例是否存在，也可以提前判断，减少batch复杂度
 // 提前通过token判断，再进入batch操作
 // batch操作，只是写操作
-func (ctrl *ClientCtrl) registerHandler(futures []*ClientFuture) error {
+func (ctrl *ClientCtrl) registerHandler(ctx context.Context, futures []*ClientFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -193,7 +199,10 @@ func (ctrl *ClientCtrl) registerHandler(futures []*ClientFuture) error {
 	for _, entry := range futures {
 		clients = append(clients, model.NewClient(entry.request))
 	}
-	if err := ctrl.storage.BatchAddClients(clients); err != nil {
+	_, span := tracing.StartSpan(ctx, "store.BatchAddClients")
+	err := ctrl.storage.BatchAddClients(clients)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		SendClientReply(futures, StoreCode2APICode(err), err)
 		return err
 	}
@@ -206,7 +215,7 @@ func (ctrl *ClientCtrl) registerHandler(futures []*ClientFuture) error {
 // 判断实例是否存在，也可以提前判断，减少batch复杂度
 // 提前通过token判断，再进入batch操作
 // batch操作，只是写操作
-func (ctrl *ClientCtrl) deregisterHandler(futures []*ClientFuture) error {
+func (ctrl *ClientCtrl) deregisterHandler(ctx context.Context, futures []*ClientFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -219,7 +228,10 @@ func (ctrl *ClientCtrl) deregisterHandler(futures []*ClientFuture) error {
 		id := entry.request.GetId().GetValue()
 		clients = append(clients, id)
 	}
-	if err := ctrl.storage.BatchDeleteClients(clients); err != nil {
+	_, span := tracing.StartSpan(ctx, "store.BatchDeleteClients")
+	err := ctrl.storage.BatchDeleteClients(clients)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		SendClientReply(futures, StoreCode2APICode(err), err)
 		return err
 	}
@@ -0,0 +1,207 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/tracing"
+	"github.com/polarismesh/polaris/store"
+)
+
+// HeartbeatRecordCtrl 批量写入实例心跳记录的类，同一批次内同一个实例的多次心跳只会写入最新的一次
+type HeartbeatRecordCtrl struct {
+	config  *CtrlConfig
+	storage store.InstanceHeartbeatStore
+
+	// store协程，负责写操作
+	storeThreadCh []chan []*HeartbeatRecordFuture
+
+	// 空闲的store协程，记录每一个空闲id
+	idleStoreThread chan int
+	waitDuration    time.Duration
+
+	// 请求接受协程
+	queue chan *HeartbeatRecordFuture
+	label string
+}
+
+// NewBatchHeartbeatRecordCtrl 实例心跳记录的批量写入对象
+func NewBatchHeartbeatRecordCtrl(storage store.InstanceHeartbeatStore, config *CtrlConfig) (
+	*HeartbeatRecordCtrl, error) {
+	if config == nil || !config.Open {
+		return nil, nil
+	}
+	if !checkCtrlConfig(config) {
+		log.Errorf("[Batch] batch heartbeat record config is invalid: %+v", config)
+		return nil, errors.New("batch heartbeat record config is invalid")
+	}
+	duration, err := time.ParseDuration(config.WaitTime)
+	if err != nil {
+		log.Errorf("[Batch] parse waitTime(%s) err: %s", config.WaitTime, err.Error())
+		return nil, err
+	}
+	if duration == 0 {
+		log.Infof("[Batch] waitTime(%s) is 0, use default %v", config.WaitTime, defaultWaitTime)
+		duration = defaultWaitTime
+	}
+
+	log.Info("[Batch] open batch heartbeat record")
+	ctrl := &HeartbeatRecordCtrl{
+		config:          config,
+		storage:         storage,
+		storeThreadCh:   make([]chan []*HeartbeatRecordFuture, 0, config.Concurrency),
+		idleStoreThread: make(chan int, config.Concurrency),
+		queue:           make(chan *HeartbeatRecordFuture, config.QueueSize),
+		waitDuration:    duration,
+		label:           "heartbeatRecord",
+	}
+	return ctrl, nil
+}
+
+// Start 开始启动批量写入心跳记录的相关协程
+func (ctrl *HeartbeatRecordCtrl) Start(ctx context.Context) {
+	log.Infof("[Batch] Start batch heartbeat record, config: %+v", ctrl.config)
+
+	// 初始化并且启动多个store协程，并发对数据库写
+	for i := 0; i < ctrl.config.Concurrency; i++ {
+		ctrl.storeThreadCh = append(ctrl.storeThreadCh, make(chan []*HeartbeatRecordFuture))
+	}
+	for i := 0; i < ctrl.config.Concurrency; i++ {
+		go ctrl.storeWorker(ctx, i)
+	}
+
+	// 进入主循环
+	ctrl.mainLoop(ctx)
+}
+
+// AsyncUpdateHeartbeat 异步写入心跳记录，needWait 为 true 时需要调用 Wait 获取写入结果
+func (ctrl *HeartbeatRecordCtrl) AsyncUpdateHeartbeat(record *model.InstanceHeartbeat,
+	needWait bool) *HeartbeatRecordFuture {
+	future := &HeartbeatRecordFuture{
+		record:   record,
+		needWait: needWait,
+	}
+	if needWait {
+		future.result = make(chan error, 1)
+	}
+
+	ctrl.queue <- future
+	return future
+}
+
+// mainLoop 心跳记录的主协程
+// 从队列中获取心跳记录，当达到ctrl.config.MaxBatchCount，
+// 或当到了一个超时时间ctrl.waitDuration，则发起一个写请求
+// 写请求发送到store协程，规则：从空闲的管道idleStoreThread中挑选一个
+func (ctrl *HeartbeatRecordCtrl) mainLoop(ctx context.Context) {
+	futures := make([]*HeartbeatRecordFuture, 0, ctrl.config.MaxBatchCount)
+	idx := 0
+	triggerConsume := func(data []*HeartbeatRecordFuture) {
+		if idx == 0 {
+			return
+		}
+		idleIdx := <-ctrl.idleStoreThread
+		ctrl.storeThreadCh[idleIdx] <- data
+		futures = make([]*HeartbeatRecordFuture, 0, ctrl.config.MaxBatchCount)
+		idx = 0
+	}
+	go func() {
+		ticker := time.NewTicker(ctrl.waitDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case future := <-ctrl.queue:
+				futures = append(futures, future)
+				idx++
+				if idx == ctrl.config.MaxBatchCount {
+					triggerConsume(futures[0:idx])
+				}
+			case <-ticker.C:
+				triggerConsume(futures[0:idx])
+			case <-ctx.Done():
+				log.Infof("[Batch] %s main loop exited", ctrl.label)
+				return
+			}
+		}
+	}()
+}
+
+// storeWorker store写协程的主循环
+// 从chan中获取数据，直接写数据库
+// 每次写完，设置协程为空闲
+func (ctrl *HeartbeatRecordCtrl) storeWorker(ctx context.Context, index int) {
+	log.Infof("[Batch] %s worker(%d) running in main loop", ctrl.label, index)
+	// store协程启动，先把自己注册到idle中
+	ctrl.idleStoreThread <- index
+	for {
+		select {
+		case futures := <-ctrl.storeThreadCh[index]:
+			flushCtx, span := tracing.StartSpan(context.Background(), "batch.instance."+ctrl.label,
+				attribute.Int("batch.size", len(futures)))
+			err := ctrl.heartbeatRecordHandler(flushCtx, futures)
+			tracing.EndSpan(span, err)
+			if err != nil {
+				log.Errorf("[Batch] %s instances err: %s", ctrl.label, err.Error())
+			}
+			ctrl.idleStoreThread <- index
+		case <-ctx.Done():
+			log.Infof("[Batch] %s worker(%d) exited", ctrl.label, index)
+			return
+		}
+	}
+}
+
+// heartbeatRecordHandler 心跳记录写入处理函数，同一个实例只保留最新的心跳记录
+func (ctrl *HeartbeatRecordCtrl) heartbeatRecordHandler(ctx context.Context,
+	futures []*HeartbeatRecordFuture) error {
+	if len(futures) == 0 {
+		return nil
+	}
+	latest := make(map[string]*model.InstanceHeartbeat, len(futures))
+	for _, future := range futures {
+		record := future.record
+		if exist, ok := latest[record.InstanceID]; ok && !record.Newer(exist) {
+			continue
+		}
+		latest[record.InstanceID] = record
+	}
+	// 按照实例ID排序写入，避免并发写入时出现死锁
+	records := make([]*model.InstanceHeartbeat, 0, len(latest))
+	for _, record := range latest {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].InstanceID < records[j].InstanceID
+	})
+	log.Debugf("[Batch] start batch heartbeat record count: %d, coalesced: %d", len(futures), len(records))
+
+	_, span := tracing.StartSpan(ctx, "store.BatchUpdateInstanceHeartbeats")
+	err := ctrl.storage.BatchUpdateInstanceHeartbeats(records)
+	tracing.EndSpan(span, err)
+	for _, future := range futures {
+		future.Reply(err)
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package batch
+
+import (
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// HeartbeatRecordFuture 写入心跳记录的异步结构体
+type HeartbeatRecordFuture struct {
+	// 心跳记录
+	record *model.InstanceHeartbeat
+	// 这个 future 是否会被外部调用 Wait 接口
+	needWait bool
+	// 执行成功/失败的应答chan
+	result chan error
+}
+
+// Reply future的应答
+func (future *HeartbeatRecordFuture) Reply(result error) {
+	if !future.needWait {
+		return
+	}
+	select {
+	case future.result <- result:
+	default:
+		log.Warnf("[Batch] heartbeat record(%s) future is not captured", future.record.InstanceID)
+	}
+}
+
+// Wait 外部调用者，需要调用Wait等待执行结果
+func (future *HeartbeatRecordFuture) Wait() error {
+	if !future.needWait {
+		return nil
+	}
+	return <-future.result
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+type fakeHeartbeatStore struct {
+	mutex   sync.Mutex
+	batches [][]*model.InstanceHeartbeat
+	err     error
+}
+
+func (s *fakeHeartbeatStore) BatchUpdateInstanceHeartbeats(heartbeats []*model.InstanceHeartbeat) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.batches = append(s.batches, heartbeats)
+	return s.err
+}
+
+func (s *fakeHeartbeatStore) GetMoreInstanceHeartbeats(mtime time.Time) ([]*model.InstanceHeartbeat, error) {
+	return nil, nil
+}
+
+func (s *fakeHeartbeatStore) DeleteInstanceHeartbeat(instanceID string) error {
+	return nil
+}
+
+func TestNewBatchHeartbeatRecordCtrl(t *testing.T) {
+	ctrl, err := NewBatchHeartbeatRecordCtrl(&fakeHeartbeatStore{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ctrl)
+
+	_, err = NewBatchHeartbeatRecordCtrl(&fakeHeartbeatStore{}, &CtrlConfig{Open: true, WaitTime: "32ms"})
+	assert.Error(t, err)
+
+	_, err = NewBatchHeartbeatRecordCtrl(&fakeHeartbeatStore{}, &CtrlConfig{
+		Open: true, QueueSize: 16, WaitTime: "abc", MaxBatchCount: 4, Concurrency: 1,
+	})
+	assert.Error(t, err)
+}
+
+func TestHeartbeatRecordCtrl_Coalesce(t *testing.T) {
+	storage := &fakeHeartbeatStore{}
+	ctrl, err := NewBatchHeartbeatRecordCtrl(storage, &CtrlConfig{
+		Open:          true,
+		QueueSize:     16,
+		WaitTime:      "1m",
+		MaxBatchCount: 4,
+		Concurrency:   1,
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl.Start(ctx)
+
+	// 同一个实例的多次心跳在同一批次内只写入最新的一次
+	records := []*model.InstanceHeartbeat{
+		{InstanceID: "ins-2", Server: "127.0.0.1", LastHeartbeatSec: 100, Count: 1},
+		{InstanceID: "ins-1", Server: "127.0.0.1", LastHeartbeatSec: 101, Count: 2},
+		{InstanceID: "ins-1", Server: "127.0.0.2", LastHeartbeatSec: 102, Count: 3},
+		{InstanceID: "ins-1", Server: "127.0.0.1", LastHeartbeatSec: 100, Count: 1},
+	}
+	futures := make([]*HeartbeatRecordFuture, 0, len(records))
+	for _, record := range records {
+		futures = append(futures, ctrl.AsyncUpdateHeartbeat(record, true))
+	}
+	for _, future := range futures {
+		assert.NoError(t, future.Wait())
+	}
+
+	storage.mutex.Lock()
+	assert.Len(t, storage.batches, 1)
+	batch := storage.batches[0]
+	storage.mutex.Unlock()
+	assert.Len(t, batch, 2)
+	assert.Equal(t, "ins-1", batch[0].InstanceID)
+	assert.Equal(t, "127.0.0.2", batch[0].Server)
+	assert.Equal(t, int64(102), batch[0].LastHeartbeatSec)
+	assert.Equal(t, "ins-2", batch[1].InstanceID)
+
+	// 写入失败时所有等待的 future 都会收到错误
+	storage.mutex.Lock()
+	storage.err = errors.New("mock error")
+	storage.mutex.Unlock()
+	futures = futures[:0]
+	for i := 0; i < 4; i++ {
+		futures = append(futures, ctrl.AsyncUpdateHeartbeat(&model.InstanceHeartbeat{
+			InstanceID: "ins-3", LastHeartbeatSec: int64(200 + i),
+		}, true))
+	}
+	for _, future := range futures {
+		assert.Error(t, future.Wait())
+	}
+	assert.NoError(t, ctrl.AsyncUpdateHeartbeat(&model.InstanceHeartbeat{InstanceID: "ins-4"}, false).Wait())
+}
@@ -25,12 +25,14 @@ import (
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	instancecommon "github.com/polarismesh/polaris/common/service"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
 )
@@ -56,7 +58,7 @@ type InstanceCtrl struct {
 	storeThreadCh []chan []*InstanceFuture
 
 	// store协程里面调用的instance处理函数，可以是注册和反注册
-	instanceHandler func([]*InstanceFuture) error
+	instanceHandler func(context.Context, []*InstanceFuture) error
 
 	// 空闲的store协程，记录每一个空闲id
 	idleStoreThread chan int
@@ -241,7 +243,12 @@ func (ctrl *InstanceCtrl) storeWorker(ctx context.Context, index int) {
 	for {
 		select {
 		case futures := <-ctrl.storeThreadCh[index]:
-			if err := ctrl.instanceHandler(futures); err != nil {
+			// 每次批量写入作为一条独立的 trace
+			flushCtx, span := tracing.StartSpan(context.Background(), "batch.instance."+ctrl.label,
+				attribute.Int("batch.size", len(futures)))
+			err := ctrl.instanceHandler(flushCtx, futures)
+			tracing.EndSpan(span, err)
+			if err != nil {
 				// 所有的错误都在instanceHandler函数里面进行答复和处理，这里只需记录一条日志
 				log.Errorf("[Batch] %s instances err: %s", ctrl.label, err.Error())
 			}
@@ -258,7 +265,7 @@ func (ctrl *InstanceCtrl) storeWorker(ctx context.Context, index int) {
 // 判断实例是否存在，也可以提前判断，减少batch复杂度
 // 提前通过token判断，再进入batch操作
 // batch操作，只是写操作
-func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) registerHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		log.Warn("[Batch] futures is empty")
 		return nil
@@ -287,7 +294,7 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 	}
 
 	// 统一判断实例是否存在，存在则需要更新部分数据
-	if err := ctrl.batchRestoreInstanceIsolate(remains); err != nil {
+	if err := ctrl.batchRestoreInstanceIsolate(ctx, remains); err != nil {
 		log.Errorf("[Batch] batch check instances existed err: %s", err.Error())
 	}
 
@@ -329,7 +336,10 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 	for _, entry := range remains {
 		instances = append(instances, entry.instance)
 	}
-	if err := ctrl.storage.BatchAddInstances(instances); err != nil {
+	_, span := tracing.StartSpan(ctx, "store.BatchAddInstances")
+	err = ctrl.storage.BatchAddInstances(instances)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		sendReply(remains, apimodel.Code(StoreCode2APICode(err)), err)
 		return err
 	}
@@ -339,7 +349,7 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 }
 
 // heartbeatHandler 心跳状态变更处理函数
-func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) heartbeatHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -367,7 +377,9 @@ func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
 		for id := range values {
 			idValues = append(idValues, id)
 		}
+		_, span := tracing.StartSpan(ctx, "store.BatchSetInstanceHealthStatus")
 		err := ctrl.storage.BatchSetInstanceHealthStatus(idValues, model.StatusBoolToInt(healthy), utils.NewUUID())
+		tracing.EndSpan(span, err)
 		if err != nil {
 			log.Errorf("[Batch] batch healthy check instances err: %s", err.Error())
 			sendReply(futures, apimodel.Code_StoreLayerException, err)
@@ -386,7 +398,7 @@ func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
 //   - 对于不存在的token，返回notFoundResource
 //   - 对于token校验失败的，返回校验失败
 //   - 调用批量接口删除实例
-func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) deregisterHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -406,7 +418,9 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 	}
 
 	// 统一鉴权与判断是否存在
+	_, span := tracing.StartSpan(ctx, "store.GetInstancesBrief")
 	instances, err := ctrl.storage.GetInstancesBrief(ids)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		log.Errorf("[Batch] get instances service token err: %s", err.Error())
 		sendReply(remains, apimodel.Code_StoreLayerException, err)
@@ -434,7 +448,10 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 	for _, entry := range remains {
 		args = append(args, entry.request.GetId().GetValue())
 	}
-	if err := ctrl.storage.BatchDeleteInstances(args); err != nil {
+	_, span = tracing.StartSpan(ctx, "store.BatchDeleteInstances")
+	err = ctrl.storage.BatchDeleteInstances(args)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		log.Errorf("[Batch] batch delete instances err: %s", err.Error())
 		sendReply(remains, apimodel.Code_StoreLayerException, err)
 		return err
@@ -445,7 +462,8 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 }
 
 // batchRestoreInstanceIsolate 批量恢复实例的隔离状态，以请求为准，请求如果不存在，就以数据库为准
-func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(futures map[string]*InstanceFuture) error {
+func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(ctx context.Context,
+	futures map[string]*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -457,7 +475,10 @@ func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(futures map[string]*Instan
 	}
 	var id2Isolate map[string]bool
 	var err error
-	if id2Isolate, err = ctrl.storage.BatchGetInstanceIsolate(ids); err != nil {
+	_, span := tracing.StartSpan(ctx, "store.BatchGetInstanceIsolate")
+	id2Isolate, err = ctrl.storage.BatchGetInstanceIsolate(ids)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		log.Errorf("[Batch] check instances existed storage err: %s", err.Error())
 		sendReply(futures, apimodel.Code_StoreLayerException, err)
 		return err
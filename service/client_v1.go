@@ -31,6 +31,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -48,6 +49,9 @@ func (s *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instanc
 
 // ReportClient 客户端上报信息
 func (s *Server) ReportClient(ctx context.Context, req *apiservice.Client) *apiservice.Response {
+	ctx, span := tracing.StartSpan(ctx, "naming.ReportClient")
+	defer span.End()
+
 	if s.caches == nil {
 		return api.NewResponse(apimodel.Code_ClientAPINotOpen)
 	}
@@ -188,6 +192,9 @@ func (s *Server) GetServiceWithCache(ctx context.Context, req *apiservice.Servic
 
 // ServiceInstancesCache 根据服务名查询服务实例列表
 func (s *Server) ServiceInstancesCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.ServiceInstancesCache")
+	defer span.End()
+
 	if req == nil {
 		return api.NewDiscoverInstanceResponse(apimodel.Code_EmptyRequest, req)
 	}
@@ -213,7 +220,7 @@ func (s *Server) ServiceInstancesCache(ctx context.Context, req *apiservice.Serv
 		log.Errorf("[Server][Service][Instance] not found name(%s) namespace(%s) service", serviceName, namespaceName)
 		return api.NewDiscoverInstanceResponse(apimodel.Code_NotFoundResource, req)
 	}
-	s.RecordDiscoverStatis(service.Name, service.Namespace)
+	s.RecordDiscoverCall(ctx, service.Name, service.Namespace)
 	// 获取revision，如果revision一致，则不返回内容，直接返回一个状态码
 	revision := s.caches.GetServiceInstanceRevision(service.ID)
 	if revision == "" {
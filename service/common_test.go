@@ -56,6 +56,7 @@ import (
 	"github.com/polarismesh/polaris/store/boltdb"
 	_ "github.com/polarismesh/polaris/store/boltdb"
 	sqldb "github.com/polarismesh/polaris/store/mysql"
+	"github.com/polarismesh/polaris/store/postgres"
 	testsuit "github.com/polarismesh/polaris/test/suit"
 )
 
@@ -92,14 +93,14 @@ func respNotFound(resp api.ResponseMessage) bool {
 }
 
 func (d *DiscoverTestSuit) cleanReportClient() {
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 			defer rollbackDbTx(dbTx)
 
 			if _, err := dbTx.Exec("delete from client"); err != nil {
@@ -133,13 +134,26 @@ func (d *DiscoverTestSuit) cleanReportClient() {
 	}
 }
 
-func rollbackDbTx(dbTx *sqldb.BaseTx) {
+// sqlTx mysql 以及 postgres 存储的事务对象，清理测试数据时统一使用
+type sqlTx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Commit() error
+	Rollback() error
+}
+
+// isSQLStore 判断当前是否为关系型数据库存储
+func isSQLStore(name string) bool {
+	return name == sqldb.STORENAME || name == postgres.STORENAME
+}
+
+func rollbackDbTx(dbTx sqlTx) {
 	if err := dbTx.Rollback(); err != nil {
 		log.Errorf("fail to rollback db tx, err %v", err)
 	}
 }
 
-func commitDbTx(dbTx *sqldb.BaseTx) {
+func commitDbTx(dbTx sqlTx) {
 	if err := dbTx.Commit(); err != nil {
 		log.Errorf("fail to commit db tx, err %v", err)
 	}
@@ -165,7 +179,7 @@ func (d *DiscoverTestSuit) cleanNamespace(name string) {
 
 	log.Infof("clean namespace: %s", name)
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		str := "delete from namespace where name = ?"
 		func() {
 			tx, err := d.Storage.StartTx()
@@ -173,7 +187,7 @@ func (d *DiscoverTestSuit) cleanNamespace(name string) {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 			defer rollbackDbTx(dbTx)
 
 			if _, err := dbTx.Exec(str, name); err != nil {
@@ -205,14 +219,14 @@ func (d *DiscoverTestSuit) cleanNamespace(name string) {
 // 从数据库彻底删除全部服务
 func (d *DiscoverTestSuit) cleanAllService() {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -258,14 +272,14 @@ func (d *DiscoverTestSuit) cleanAllService() {
 // 从数据库彻底删除服务
 func (d *DiscoverTestSuit) cleanService(name, namespace string) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -336,14 +350,14 @@ func (d *DiscoverTestSuit) cleanServiceName(name string, namespace string) {
 // clean services
 func (d *DiscoverTestSuit) cleanServices(services []*apiservice.Service) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -403,14 +417,14 @@ func (d *DiscoverTestSuit) cleanInstance(instanceID string) {
 	}
 	log.Infof("clean instance: %s", instanceID)
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -914,14 +928,14 @@ func (d *DiscoverTestSuit) updateCommonRoutingConfig(t *testing.T, req *apitraff
 // 彻底删除一个路由配置
 func (d *DiscoverTestSuit) cleanCommonRoutingConfig(service string, namespace string) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -979,14 +993,14 @@ func (d *DiscoverTestSuit) cleanCommonRoutingConfig(service string, namespace st
 }
 
 func (d *DiscoverTestSuit) truncateCommonRoutingConfigV2() {
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 			defer rollbackDbTx(dbTx)
 
 			str := "delete from routing_config_v2"
@@ -1022,14 +1036,14 @@ func (d *DiscoverTestSuit) truncateCommonRoutingConfigV2() {
 // 彻底删除一个路由配置
 func (d *DiscoverTestSuit) cleanCommonRoutingConfigV2(rules []*apitraffic.RouteRule) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 			defer rollbackDbTx(dbTx)
 
 			str := "delete from routing_config_v2 where id in (%s)"
@@ -1302,14 +1316,14 @@ func (d *DiscoverTestSuit) updateRateLimit(t *testing.T, rateLimit *apitraffic.R
 // 彻底删除限流规则
 func (d *DiscoverTestSuit) cleanRateLimit(id string) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -1343,19 +1357,19 @@ func (d *DiscoverTestSuit) cleanRateLimit(id string) {
 // 彻底删除限流规则版本号
 func (d *DiscoverTestSuit) cleanRateLimitRevision(service, namespace string) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
-			str := "delete from ratelimit_revision using ratelimit_revision, service " +
-				"where service_id = service.id and name = ? and namespace = ?"
+			str := "delete from ratelimit_revision " +
+				"where service_id in (select id from service where name = ? and namespace = ?)"
 			if _, err := dbTx.Exec(str, service, namespace); err != nil {
 				panic(err)
 			}
@@ -1706,14 +1720,14 @@ func buildCircuitBreakerKey(id, version string) string {
 func (d *DiscoverTestSuit) cleanCircuitBreaker(id, version string) {
 	log.Infof("clean circuit breaker, id: %s, version: %s", id, version)
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
@@ -1748,19 +1762,20 @@ func (d *DiscoverTestSuit) cleanCircuitBreaker(id, version string) {
 // 彻底删除熔断规则发布记录
 func (d *DiscoverTestSuit) cleanCircuitBreakerRelation(name, namespace, ruleID, ruleVersion string) {
 
-	if d.Storage.Name() == sqldb.STORENAME {
+	if isSQLStore(d.Storage.Name()) {
 		func() {
 			tx, err := d.Storage.StartTx()
 			if err != nil {
 				panic(err)
 			}
 
-			dbTx := tx.GetDelegateTx().(*sqldb.BaseTx)
+			dbTx := tx.GetDelegateTx().(sqlTx)
 
 			defer rollbackDbTx(dbTx)
 
-			str := "delete from circuitbreaker_rule_relation using circuitbreaker_rule_relation, service " +
-				"where service_id = service.id and name = ? and namespace = ? and rule_id = ? and rule_version = ?"
+			str := "delete from circuitbreaker_rule_relation " +
+				"where service_id in (select id from service where name = ? and namespace = ?) " +
+				"and rule_id = ? and rule_version = ?"
 			if _, err := dbTx.Exec(str, name, namespace, ruleID, ruleVersion); err != nil {
 				panic(err)
 			}
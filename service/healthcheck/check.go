@@ -20,6 +20,7 @@ package healthcheck
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -126,6 +127,13 @@ func newCheckScheduler(ctx context.Context, slotNum int, minCheckInterval time.D
 	return scheduler
 }
 
+// updateIntervals 更新检查间隔，只影响之后加入时间轮的检查任务
+func (c *CheckScheduler) updateIntervals(minCheckInterval, maxCheckInterval, clientCheckTtl time.Duration) {
+	atomic.StoreInt64(&c.minCheckIntervalSec, int64(minCheckInterval.Seconds()))
+	atomic.StoreInt64(&c.maxCheckIntervalSec, int64(maxCheckInterval.Seconds()))
+	atomic.StoreInt64(&c.clientCheckTtlSec, int64(clientCheckTtl.Seconds()))
+}
+
 func (c *CheckScheduler) doCheckInstances(ctx context.Context) {
 	c.timeWheel.Start()
 	log.Infof("[Health Check][Check]timeWheel has been started")
@@ -268,9 +276,9 @@ func (c *CheckScheduler) putClientIfAbsent(clientWithChecker *ClientWithChecker)
 			host:              client.Proto().GetHost().GetValue(),
 			port:              0,
 			id:                clientId,
-			expireDurationSec: uint32(expireTtlCount * c.clientCheckTtlSec),
+			expireDurationSec: uint32(expireTtlCount * atomic.LoadInt64(&c.clientCheckTtlSec)),
 			checker:           clientWithChecker.checker,
-			ttlDurationSec:    uint32(c.clientCheckTtlSec),
+			ttlDurationSec:    uint32(atomic.LoadInt64(&c.clientCheckTtlSec)),
 		},
 		lastCheckTimeSec: 0,
 	}
@@ -337,8 +345,9 @@ func (c *CheckScheduler) addHealthyCallback(instance *itemValue, lastHeartbeatTi
 			nextDelaySec = int64(delaySec) - timePassed
 		}
 	}
-	if nextDelaySec > 0 && nextDelaySec < c.minCheckIntervalSec {
-		nextDelaySec = c.minCheckIntervalSec
+	if minCheckIntervalSec := atomic.LoadInt64(&c.minCheckIntervalSec); nextDelaySec > 0 &&
+		nextDelaySec < minCheckIntervalSec {
+		nextDelaySec = minCheckIntervalSec
 	}
 	if nextDelaySec > 0 {
 		delaySec = uint32(nextDelaySec)
@@ -354,8 +363,9 @@ func (c *CheckScheduler) addHealthyCallback(instance *itemValue, lastHeartbeatTi
 
 func (c *CheckScheduler) addUnHealthyCallback(instance *itemValue) {
 	delaySec := instance.expireDurationSec
-	if c.maxCheckIntervalSec > 0 && int64(delaySec) > c.maxCheckIntervalSec {
-		delaySec = uint32(c.maxCheckIntervalSec)
+	if maxCheckIntervalSec := atomic.LoadInt64(&c.maxCheckIntervalSec); maxCheckIntervalSec > 0 &&
+		int64(delaySec) > maxCheckIntervalSec {
+		delaySec = uint32(maxCheckIntervalSec)
 	}
 	host := instance.host
 	port := instance.port
@@ -26,6 +26,7 @@ import (
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
 )
 
 const (
@@ -141,10 +142,41 @@ func (d *Dispatcher) reloadSelfContinuum() bool {
 		d.noAvailableServers = false
 	}
 	d.selfServiceBuckets = nextBuckets
-	d.continuum = New(d.selfServiceBuckets)
+	continuum := New(d.selfServiceBuckets)
+	d.mutex.Lock()
+	d.continuum = continuum
+	d.mutex.Unlock()
+	d.notifyPeersChanged(continuum)
 	return true
 }
 
+// notifyPeersChanged 通知需要感知实例归属节点的健康检查插件
+func (d *Dispatcher) notifyPeersChanged(continuum *Continuum) {
+	peers := make([]string, 0, len(d.selfServiceBuckets))
+	for bucket := range d.selfServiceBuckets {
+		peers = append(peers, bucket.Host)
+	}
+	owner := func(instanceId string) string {
+		if continuum == nil {
+			return ""
+		}
+		return continuum.Hash(hashString(instanceId))
+	}
+	for _, checker := range d.svr.checkers {
+		if peerAware, ok := checker.(plugin.PeerAwareHealthChecker); ok {
+			peerAware.OnPeersChanged(d.svr.localHost, peers, owner)
+		}
+	}
+}
+
+// HashRanges 当前节点视图下，各个健康检查节点在 hash 环上负责的区间
+func (d *Dispatcher) HashRanges() map[string][]*HashRange {
+	d.mutex.Lock()
+	continuum := d.continuum
+	d.mutex.Unlock()
+	return continuum.Ranges()
+}
+
 func (d *Dispatcher) reloadManagedClients() {
 	nextClients := make(map[string]*ClientWithChecker)
 
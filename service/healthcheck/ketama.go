@@ -119,3 +119,58 @@ func (c *Continuum) Hash(h uint) string {
 
 	return c.ring[i].bucket.Host
 }
+
+// maxHashPoint hash 环上的最大值，hash 值取 sha1 摘要的前 4 个字节
+const maxHashPoint = uint(1)<<32 - 1
+
+// HashRange hash 环上的一段闭区间
+type HashRange struct {
+	Start uint `json:"start"`
+	End   uint `json:"end"`
+}
+
+// Ranges 计算每个节点在 hash 环上负责的区间，和 Hash 的查找规则保持一致，相邻的区间会合并
+func (c *Continuum) Ranges() map[string][]*HashRange {
+	ret := map[string][]*HashRange{}
+	if c == nil || len(c.ring) == 0 {
+		return ret
+	}
+	var (
+		last     *HashRange
+		lastHost string
+	)
+	add := func(host string, start, end uint) {
+		if last != nil && lastHost == host && last.End+1 == start {
+			last.End = end
+			return
+		}
+		last = &HashRange{Start: start, End: end}
+		lastHost = host
+		ret[host] = append(ret[host], last)
+	}
+
+	head := c.ring[0]
+	add(head.bucket.Host, 0, head.point)
+	for i := 1; i < len(c.ring); i++ {
+		prev, cur := c.ring[i-1].point, c.ring[i].point
+		// 重复的点位由排在前面的节点负责
+		if cur == prev {
+			continue
+		}
+		add(c.ring[i].bucket.Host, prev+1, cur)
+	}
+	// 大于最后一个点位的 hash 值回到环的起点
+	if tail := c.ring[len(c.ring)-1].point; tail < maxHashPoint {
+		add(head.bucket.Host, tail+1, maxHashPoint)
+	}
+	return ret
+}
+
+// HashRatio 计算区间占整个 hash 环的比例
+func HashRatio(ranges []*HashRange) float64 {
+	var total uint
+	for _, item := range ranges {
+		total += item.End - item.Start + 1
+	}
+	return float64(total) / float64(maxHashPoint+1)
+}
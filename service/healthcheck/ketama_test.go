@@ -0,0 +1,65 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinuum_Ranges(t *testing.T) {
+	buckets := map[Bucket]bool{}
+	for i := 0; i < 3; i++ {
+		buckets[Bucket{Host: fmt.Sprintf("127.0.0.%d", i+1), Weight: weight}] = true
+	}
+	c := New(buckets)
+	ranges := c.Ranges()
+	assert.Len(t, ranges, 3)
+
+	var ratio float64
+	for _, items := range ranges {
+		ratio += HashRatio(items)
+	}
+	assert.InDelta(t, 1, ratio, 1e-9)
+
+	owner := func(h uint) string {
+		for host, items := range ranges {
+			for _, item := range items {
+				if h >= item.Start && h <= item.End {
+					return host
+				}
+			}
+		}
+		return ""
+	}
+	for i := 0; i < 1000; i++ {
+		h := hashString(fmt.Sprintf("instance-%d", i))
+		assert.Equal(t, c.Hash(h), owner(h))
+	}
+	for _, h := range []uint{0, maxHashPoint} {
+		assert.Equal(t, c.Hash(h), owner(h))
+	}
+}
+
+func TestContinuum_RangesEmpty(t *testing.T) {
+	var c *Continuum
+	assert.Empty(t, c.Ranges())
+	assert.Empty(t, New(map[Bucket]bool{}).Ranges())
+}
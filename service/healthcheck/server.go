@@ -189,6 +189,9 @@ func (s *Server) CacheProvider() (*CacheProvider, error) {
 
 // ListCheckerServer get checker server instance list
 func (s *Server) ListCheckerServer() []*model.Instance {
+	if s.cacheProvider == nil {
+		return nil
+	}
 	ret := make([]*model.Instance, 0, s.cacheProvider.selfServiceInstances.Count())
 	s.cacheProvider.selfServiceInstances.Range(func(instanceId string, value ItemWithChecker) {
 		ret = append(ret, value.GetInstance())
@@ -197,6 +200,26 @@ func (s *Server) ListCheckerServer() []*model.Instance {
 	return ret
 }
 
+// Reload 重新加载健康检查配置，只处理检查间隔相关的配置项，其他配置项的变更需要由调用方提前拒绝
+func (s *Server) Reload(conf *Config) {
+	if s.checkScheduler == nil {
+		return
+	}
+	next := *conf
+	next.SetDefault()
+	s.checkScheduler.updateIntervals(next.MinCheckInterval, next.MaxCheckInterval, next.ClientCheckTtl)
+	log.Infof("[Health Check] reload check interval, min: %s, max: %s, client ttl: %s",
+		next.MinCheckInterval, next.MaxCheckInterval, next.ClientCheckTtl)
+}
+
+// HashRanges 获取各个健康检查节点在一致性 hash 环上负责的区间，未开启健康检查时返回空
+func (s *Server) HashRanges() map[string][]*HashRange {
+	if s.dispatcher == nil {
+		return map[string][]*HashRange{}
+	}
+	return s.dispatcher.HashRanges()
+}
+
 // RecordHistory server对外提供history插件的简单封装
 func (s *Server) RecordHistory(entry *model.RecordEntry) {
 	// 如果插件没有初始化，那么不记录history
@@ -35,6 +35,7 @@ import (
 	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	instancecommon "github.com/polarismesh/polaris/common/service"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -75,6 +76,9 @@ var (
 
 // CreateInstances 批量创建服务实例
 func (s *Server) CreateInstances(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.CreateInstances")
+	defer span.End()
+
 	if checkError := checkBatchInstance(reqs); checkError != nil {
 		return checkError
 	}
@@ -222,6 +226,9 @@ func (s *Server) serialCreateInstance(
 
 // DeleteInstances 批量删除服务实例
 func (s *Server) DeleteInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.DeleteInstances")
+	defer span.End()
+
 	if checkError := checkBatchInstance(req); checkError != nil {
 		return checkError
 	}
@@ -408,6 +415,9 @@ func (s *Server) DeleteInstanceByHost(ctx context.Context, req *apiservice.Insta
 
 // UpdateInstances 批量修改服务实例
 func (s *Server) UpdateInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.UpdateInstances")
+	defer span.End()
+
 	if checkError := checkBatchInstance(req); checkError != nil {
 		return checkError
 	}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/polarismesh/polaris/cache"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/secure"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/namespace"
 	"github.com/polarismesh/polaris/plugin"
@@ -107,6 +108,30 @@ func (s *Server) RecordDiscoverStatis(service, discoverNamespace string) {
 	_ = s.discoverStatis.AddDiscoverCall(service, discoverNamespace, time.Now())
 }
 
+// RecordDiscoverCall 记录主调方的服务发现调用，插件支持时用于推导服务之间的依赖关系
+func (s *Server) RecordDiscoverCall(ctx context.Context, service, discoverNamespace string) {
+	if s.discoverStatis == nil {
+		return
+	}
+	dependencyStatis, ok := s.discoverStatis.(plugin.DependencyStatis)
+	if !ok {
+		s.RecordDiscoverStatis(service, discoverNamespace)
+		return
+	}
+
+	caller := &plugin.DiscoverCaller{
+		Host: utils.ParseClientIP(ctx),
+	}
+	// 优先使用客户端证书中已认证的身份，请求头中声明的来源服务无法校验，只在没有认证身份时使用
+	identity, _ := ctx.Value(utils.ContextClientCertIdentity).(*secure.PeerIdentity)
+	if namespace, service, ok := identity.Workload(); ok {
+		caller.Namespace, caller.Service, caller.Verified = namespace, service, true
+	} else {
+		caller.Service, caller.Namespace = utils.ParseSourceService(ctx)
+	}
+	_ = dependencyStatis.AddDependencyCall(caller, service, discoverNamespace, time.Now())
+}
+
 // GetServiceInstanceRevision 获取服务实例的revision
 func (s *Server) GetServiceInstanceRevision(serviceID string, instances []*model.Instance) (string, error) {
 	if revision := s.caches.GetServiceInstanceRevision(serviceID); revision != "" {
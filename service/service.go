@@ -33,6 +33,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/service/batch"
 )
@@ -72,6 +73,9 @@ var (
 
 // CreateServices 批量创建服务
 func (s *Server) CreateServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.CreateServices")
+	defer span.End()
+
 	if checkError := checkBatchService(req); checkError != nil {
 		return checkError
 	}
@@ -166,6 +170,9 @@ func (s *Server) CreateService(ctx context.Context, req *apiservice.Service) *ap
 
 // DeleteServices 批量删除服务
 func (s *Server) DeleteServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.DeleteServices")
+	defer span.End()
+
 	if checkError := checkBatchService(req); checkError != nil {
 		return checkError
 	}
@@ -227,6 +234,9 @@ func (s *Server) DeleteService(ctx context.Context, req *apiservice.Service) *ap
 
 // UpdateServices 批量修改服务
 func (s *Server) UpdateServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
+	ctx, span := tracing.StartSpan(ctx, "naming.UpdateServices")
+	defer span.End()
+
 	if checkError := checkBatchService(req); checkError != nil {
 		return checkError
 	}
@@ -115,3 +115,21 @@ type ToolStore interface {
 	// GetUnixSecond Get the current time
 	GetUnixSecond(maxWait time.Duration) (int64, error)
 }
+
+// ChangeLogStore 数据变更日志的存储接口，缓存通过追踪变更日志按需刷新
+type ChangeLogStore interface {
+	// ChangeLogEnabled 是否开启了变更日志
+	ChangeLogEnabled() bool
+	// GetLatestChangeSeq 获取当前最新的变更序号
+	GetLatestChangeSeq() (uint64, error)
+	// GetMoreChangeLogs 获取序号大于 seq 的变更记录，按照序号升序返回，最多返回 limit 条
+	GetMoreChangeLogs(seq uint64, limit int) ([]*model.ChangeLog, error)
+	// CleanChangeLogs 清理创建时间早于 retention 之前的变更记录，返回清理的条数
+	CleanChangeLogs(retention time.Duration) (uint64, error)
+}
+
+// SnapshotStore 支持一致性快照读取的存储，备份时全部数据从同一个快照中读取
+type SnapshotStore interface {
+	// Snapshot 返回只读的存储视图，视图内的读取都基于同一个快照，使用完毕后需要调用 release 释放快照
+	Snapshot() (view Store, release func() error, err error)
+}
@@ -41,14 +41,14 @@ func DoTransactionIfNeed(sTx store.Tx, handler BoltHandler, handle transactionFu
 	tx := sTx.GetDelegateTx().(*bolt.Tx)
 	defer func() {
 		if autoManageTx {
-			_ = tx.Rollback()
+			_ = rollbackTx(tx)
 		}
 	}()
 
 	ret, err := handle(tx)
 
 	if autoManageTx && err == nil {
-		if err := tx.Commit(); err != nil {
+		if err := commitTx(tx); err != nil {
 			log.Error("do tx commit", zap.Error(err))
 			return nil, err
 		}
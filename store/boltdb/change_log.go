@@ -0,0 +1,189 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblChangeLog = "change_log"
+)
+
+// changeLogTables 需要记录变更日志的数据表以及对应的资源类型，客户端随每次上报频繁更新，不记录变更。
+// 实例的心跳写入 instance_heartbeat 表，实例表只在注册、注销、隔离以及健康状态等发生变化时才会更新
+var changeLogTables = map[string]model.ChangeResource{
+	tblNameNamespace:      model.ChangeResourceNamespace,
+	tblNameService:        model.ChangeResourceService,
+	tblNameInstance:       model.ChangeResourceInstance,
+	tblNameRouting:        model.ChangeResourceRouting,
+	tblNameRoutingV2:      model.ChangeResourceRouting,
+	tblRateLimitConfig:    model.ChangeResourceRateLimit,
+	tblCircuitBreakerRule: model.ChangeResourceCircuitBreaker,
+	tblFaultDetectRule:    model.ChangeResourceFaultDetect,
+	tblUser:               model.ChangeResourceUser,
+	tblGroup:              model.ChangeResourceUser,
+	tblStrategy:           model.ChangeResourceAuthStrategy,
+	tblConfigFileRelease:  model.ChangeResourceConfigFile,
+	tblNameL5:             model.ChangeResourceL5,
+}
+
+// changeLogDBs 开启了变更日志的数据库，*bolt.DB -> struct{}
+var changeLogDBs sync.Map
+
+func enableChangeLog(db *bolt.DB) {
+	changeLogDBs.Store(db, struct{}{})
+}
+
+func disableChangeLog(db *bolt.DB) {
+	changeLogDBs.Delete(db)
+}
+
+func isChangeLogEnabled(db *bolt.DB) bool {
+	_, ok := changeLogDBs.Load(db)
+	return ok
+}
+
+// appendChangeLog 在写事务内追加一条变更记录，未开启变更日志或者数据表无需记录时直接忽略
+func appendChangeLog(tx *bolt.Tx, typ string) error {
+	resource, ok := changeLogTables[typ]
+	if !ok || !isChangeLogEnabled(tx.DB()) {
+		return nil
+	}
+	bucket, err := tx.CreateBucketIfNotExists([]byte(tblChangeLog))
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(encodeChangeSeq(seq), encodeChangeLog(time.Now(), resource))
+}
+
+func encodeChangeSeq(seq uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, seq)
+	return buf
+}
+
+// encodeChangeLog 变更记录的格式为 8 字节的创建时间（纳秒）加上资源类型
+func encodeChangeLog(ctime time.Time, resource model.ChangeResource) []byte {
+	buf := make([]byte, 8+len(resource))
+	binary.BigEndian.PutUint64(buf, uint64(ctime.UnixNano()))
+	copy(buf[8:], resource)
+	return buf
+}
+
+func decodeChangeLog(key, value []byte) *model.ChangeLog {
+	if len(key) != 8 || len(value) < 8 {
+		return nil
+	}
+	return &model.ChangeLog{
+		Seq:        binary.BigEndian.Uint64(key),
+		Resource:   model.ChangeResource(value[8:]),
+		CreateTime: time.Unix(0, int64(binary.BigEndian.Uint64(value[:8]))),
+	}
+}
+
+// localDB 获取 handler 对应的本地数据库，变更日志只记录在本地，不参与 raft 复制
+func localDB(handler BoltHandler) *bolt.DB {
+	switch h := handler.(type) {
+	case *boltHandler:
+		return h.db
+	case *raftHandler:
+		return h.db
+	}
+	return nil
+}
+
+// changeLogStore 变更日志的存储实现
+type changeLogStore struct {
+	db *bolt.DB
+}
+
+// ChangeLogEnabled 是否开启了变更日志
+func (c *changeLogStore) ChangeLogEnabled() bool {
+	return c.db != nil && isChangeLogEnabled(c.db)
+}
+
+// GetLatestChangeSeq 获取当前最新的变更序号
+func (c *changeLogStore) GetLatestChangeSeq() (uint64, error) {
+	var seq uint64
+	err := c.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(tblChangeLog)); bucket != nil {
+			seq = bucket.Sequence()
+		}
+		return nil
+	})
+	return seq, err
+}
+
+// GetMoreChangeLogs 获取序号大于 seq 的变更记录
+func (c *changeLogStore) GetMoreChangeLogs(seq uint64, limit int) ([]*model.ChangeLog, error) {
+	var logs []*model.ChangeLog
+	err := c.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(tblChangeLog))
+		if bucket == nil {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(encodeChangeSeq(seq + 1)); k != nil && len(logs) < limit; k, v = cursor.Next() {
+			if item := decodeChangeLog(k, v); item != nil {
+				logs = append(logs, item)
+			}
+		}
+		return nil
+	})
+	return logs, err
+}
+
+// CleanChangeLogs 清理创建时间早于 retention 之前的变更记录
+func (c *changeLogStore) CleanChangeLogs(retention time.Duration) (uint64, error) {
+	var count uint64
+	deadline := time.Now().Add(-retention)
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(tblChangeLog))
+		if bucket == nil {
+			return nil
+		}
+		var keys [][]byte
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			item := decodeChangeLog(k, v)
+			if item != nil && !item.CreateTime.Before(deadline) {
+				break
+			}
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
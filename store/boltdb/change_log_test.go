@@ -0,0 +1,98 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestChangeLogStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "change_log.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file, ChangeLog: true})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &changeLogStore{db: localDB(handler)}
+	assert.True(t, s.ChangeLogEnabled())
+
+	assert.NoError(t, handler.SaveValue(tblNameNamespace, "ns", &model.Namespace{Name: "ns"}))
+	assert.NoError(t, handler.UpdateValue(tblNameNamespace, "ns", map[string]interface{}{"Comment": "test"}))
+	assert.NoError(t, handler.DeleteValues(tblNameService, []string{"svc"}))
+	// 不需要记录变更的数据表
+	assert.NoError(t, handler.SaveValue(tblRateLimitRevision, "rev", &model.RateLimitRevision{}))
+	assert.NoError(t, handler.DeleteValues(tblClient, []string{"client"}))
+
+	seq, err := s.GetLatestChangeSeq()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), seq)
+
+	logs, err := s.GetMoreChangeLogs(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, model.ChangeResourceNamespace, logs[0].Resource)
+	assert.Equal(t, model.ChangeResourceNamespace, logs[1].Resource)
+	assert.Equal(t, model.ChangeResourceService, logs[2].Resource)
+	assert.Equal(t, uint64(3), logs[2].Seq)
+
+	logs, err = s.GetMoreChangeLogs(1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, uint64(2), logs[0].Seq)
+
+	count, err := s.CleanChangeLogs(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+	count, err = s.CleanChangeLogs(0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+
+	// 清理之后序号继续递增
+	assert.NoError(t, handler.SaveValue(tblNameService, "svc", &model.Service{ID: "svc"}))
+	logs, err = s.GetMoreChangeLogs(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, uint64(4), logs[0].Seq)
+	assert.Equal(t, model.ChangeResourceService, logs[0].Resource)
+}
+
+func TestChangeLogStore_Disabled(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "change_log.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+	}()
+
+	s := &changeLogStore{db: localDB(handler)}
+	assert.False(t, s.ChangeLogEnabled())
+
+	assert.NoError(t, handler.SaveValue(tblNameNamespace, "ns", &model.Namespace{Name: "ns"}))
+	seq, err := s.GetLatestChangeSeq()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), seq)
+}
@@ -94,7 +94,7 @@ func (fg *configFileGroupStore) createConfigFileGroup(
 	}
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	fg.id++
@@ -117,7 +117,7 @@ func (fg *configFileGroupStore) createConfigFileGroup(
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[ConfigFileGroup] do tx commit", zap.Error(err))
 		return nil, err
 	}
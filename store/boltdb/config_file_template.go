@@ -75,7 +75,7 @@ func (cf *configFileTemplateStore) GetConfigFileTemplate(name string) (*model.Co
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	values := make(map[string]interface{})
@@ -83,7 +83,7 @@ func (cf *configFileTemplateStore) GetConfigFileTemplate(name string) (*model.Co
 		return nil, err
 	}
 
-	err = tx.Commit()
+	err = commitTx(tx)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +111,7 @@ func (cf *configFileTemplateStore) CreateConfigFileTemplate(
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	cf.id++
@@ -132,7 +132,7 @@ func (cf *configFileTemplateStore) CreateConfigFileTemplate(
 		return nil, err
 	}
 
-	if err = tx.Commit(); err != nil {
+	if err = commitTx(tx); err != nil {
 		log.Error("[ConfigFileTemplate] commit error", zap.Error(err))
 		return nil, err
 	}
@@ -93,6 +93,27 @@ type boltStore struct {
 	// maintain store
 	*maintainStore
 
+	// 变更日志
+	*changeLogStore
+
+	// 运维任务执行记录
+	*maintainJobStore
+
+	// 历史数据清理
+	*retentionStore
+
+	// 白名单运行时规则
+	*whitelistStore
+
+	// 服务依赖关系
+	*dependencyStore
+
+	// 实例事件
+	*instanceEventStore
+
+	// 实例心跳记录
+	*instanceHeartbeatStore
+
 	handler BoltHandler
 	start   bool
 }
@@ -113,18 +134,23 @@ func (m *boltStore) Initialize(c *store.Config) error {
 	if err != nil {
 		return err
 	}
+	return m.initialize(handler)
+}
+
+// initialize 使用指定的 BoltHandler 初始化各个模块的存储以及初始数据
+func (m *boltStore) initialize(handler BoltHandler) error {
 	m.handler = handler
-	if err = m.newStore(); err != nil {
+	if err := m.newStore(); err != nil {
 		_ = handler.Close()
 		return err
 	}
 
-	if err = m.initAuthStoreData(); err != nil {
+	if err := m.initAuthStoreData(); err != nil {
 		_ = handler.Close()
 		return err
 	}
 
-	if err = m.initNamingStoreData(); err != nil {
+	if err := m.initNamingStoreData(); err != nil {
 		_ = handler.Close()
 		return err
 	}
@@ -279,6 +305,7 @@ func (m *boltStore) newStore() error {
 		return err
 	}
 	m.clientStore = &clientStore{handler: m.handler}
+	m.changeLogStore = &changeLogStore{db: localDB(m.handler)}
 
 	if err := m.newDiscoverModuleStore(); err != nil {
 		return err
@@ -364,6 +391,18 @@ func (m *boltStore) newConfigModuleStore() error {
 func (m *boltStore) newMaintainModuleStore() error {
 	m.maintainStore = &maintainStore{handler: m.handler, leMap: make(map[string]bool)}
 
+	m.maintainJobStore = &maintainJobStore{handler: m.handler}
+
+	m.retentionStore = &retentionStore{handler: m.handler}
+
+	m.whitelistStore = &whitelistStore{handler: m.handler}
+
+	m.dependencyStore = &dependencyStore{handler: m.handler}
+
+	m.instanceEventStore = &instanceEventStore{handler: m.handler}
+
+	m.instanceHeartbeatStore = &instanceHeartbeatStore{handler: m.handler}
+
 	return nil
 }
 
@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblServiceDependency = "service_dependency"
+
+	dependencyFieldLastSeen = "LastSeen"
+)
+
+// serviceDependencyObject 服务依赖关系的存储对象
+type serviceDependencyObject struct {
+	CallerNamespace string
+	CallerService   string
+	// CallerHosts 以逗号分隔的主调方地址
+	CallerHosts     string
+	CallerVerified  bool
+	CalleeNamespace string
+	CalleeService   string
+	FirstSeen       time.Time
+	LastSeen        time.Time
+}
+
+func (o *serviceDependencyObject) toModel() *model.ServiceDependency {
+	hosts := []string{}
+	if o.CallerHosts != "" {
+		hosts = strings.Split(o.CallerHosts, ",")
+	}
+	return &model.ServiceDependency{
+		CallerNamespace: o.CallerNamespace,
+		CallerService:   o.CallerService,
+		CallerHosts:     hosts,
+		CallerVerified:  o.CallerVerified,
+		CalleeNamespace: o.CalleeNamespace,
+		CalleeService:   o.CalleeService,
+		FirstSeen:       o.FirstSeen,
+		LastSeen:        o.LastSeen,
+	}
+}
+
+// match 判断依赖关系是否满足查询条件，caller_host 匹配最近发现的主调方地址中的任意一个
+func (o *serviceDependencyObject) match(filter map[string]string) bool {
+	fields := map[string]string{
+		"caller_namespace": o.CallerNamespace,
+		"caller_service":   o.CallerService,
+		"callee_namespace": o.CalleeNamespace,
+		"callee_service":   o.CalleeService,
+	}
+	for key, value := range filter {
+		if field, ok := fields[key]; ok && field != value {
+			return false
+		}
+	}
+	if host, ok := filter["caller_host"]; ok {
+		for _, callerHost := range strings.Split(o.CallerHosts, ",") {
+			if callerHost == host {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// dependencyStore 服务依赖关系的存储实现
+type dependencyStore struct {
+	handler BoltHandler
+}
+
+// UpsertServiceDependencies 批量保存依赖关系，已存在的记录更新主调方地址、认证状态以及最近发现时间
+func (d *dependencyStore) UpsertServiceDependencies(deps []*model.ServiceDependency) error {
+	if len(deps) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		keys = append(keys, dep.Key())
+	}
+	values, err := d.handler.LoadValues(tblServiceDependency, keys, &serviceDependencyObject{})
+	if err != nil {
+		log.Errorf("[Store][boltdb] load service dependencies err: %s", err.Error())
+		return err
+	}
+
+	for _, dep := range deps {
+		saved := dep
+		if value, ok := values[dep.Key()]; ok {
+			saved = value.(*serviceDependencyObject).toModel()
+			saved.Merge(dep)
+		}
+		err := d.handler.SaveValue(tblServiceDependency, dep.Key(), &serviceDependencyObject{
+			CallerNamespace: saved.CallerNamespace,
+			CallerService:   saved.CallerService,
+			CallerHosts:     strings.Join(saved.CallerHosts, ","),
+			CallerVerified:  saved.CallerVerified,
+			CalleeNamespace: saved.CalleeNamespace,
+			CalleeService:   saved.CalleeService,
+			FirstSeen:       saved.FirstSeen,
+			LastSeen:        saved.LastSeen,
+		})
+		if err != nil {
+			log.Errorf("[Store][boltdb] save service dependency(%s) err: %s", dep.Key(), err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// GetServiceDependencies 查询依赖关系，按照最近发现时间倒序返回
+func (d *dependencyStore) GetServiceDependencies(filter map[string]string, offset, limit uint32) (
+	uint32, []*model.ServiceDependency, error) {
+	values, err := d.handler.LoadValuesAll(tblServiceDependency, &serviceDependencyObject{})
+	if err != nil {
+		log.Errorf("[Store][boltdb] get service dependencies err: %s", err.Error())
+		return 0, nil, err
+	}
+
+	deps := make([]*model.ServiceDependency, 0, len(values))
+	for _, value := range values {
+		obj := value.(*serviceDependencyObject)
+		if obj.match(filter) {
+			deps = append(deps, obj.toModel())
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].LastSeen.Equal(deps[j].LastSeen) {
+			return deps[i].Key() < deps[j].Key()
+		}
+		return deps[i].LastSeen.After(deps[j].LastSeen)
+	})
+
+	total := uint32(len(deps))
+	if offset >= total {
+		return total, []*model.ServiceDependency{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return total, deps[offset:end], nil
+}
+
+// BatchCleanServiceDependencies 清理最近发现时间早于 before 的依赖关系
+func (d *dependencyStore) BatchCleanServiceDependencies(before time.Time, batchSize uint32) (uint32, error) {
+	values, err := d.handler.LoadValuesByFilter(tblServiceDependency, []string{dependencyFieldLastSeen},
+		&serviceDependencyObject{}, func(m map[string]interface{}) bool {
+			lastSeen, _ := m[dependencyFieldLastSeen].(time.Time)
+			return lastSeen.Before(before)
+		})
+	if err != nil {
+		log.Errorf("[Store][boltdb] load expired service dependencies err: %s", err.Error())
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if uint32(len(keys)) >= batchSize {
+			break
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if err := d.handler.DeleteValues(tblServiceDependency, keys); err != nil {
+		log.Errorf("[Store][boltdb] clean service dependencies err: %s", err.Error())
+		return 0, err
+	}
+	return uint32(len(keys)), nil
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestDependencyStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dependency.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &dependencyStore{handler: handler}
+	seen := time.Unix(1700000000, 0)
+	assert.NoError(t, s.UpsertServiceDependencies([]*model.ServiceDependency{
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.1"},
+			CallerVerified:  true,
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen,
+		},
+		{
+			CallerNamespace: "default",
+			CallerService:   "user",
+			CallerHosts:     []string{"10.0.0.2"},
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen.Add(time.Second),
+		},
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.1"},
+			CalleeNamespace: "default",
+			CalleeService:   "stock",
+			FirstSeen:       seen,
+			LastSeen:        seen,
+		},
+	}))
+
+	// 再次上报时保留首次发现时间以及认证状态，合并主调方地址
+	assert.NoError(t, s.UpsertServiceDependencies([]*model.ServiceDependency{
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.3"},
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen.Add(time.Minute),
+			LastSeen:        seen.Add(time.Minute),
+		},
+	}))
+
+	total, deps, err := s.GetServiceDependencies(map[string]string{
+		"callee_namespace": "default",
+		"callee_service":   "payment",
+	}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, "order", deps[0].CallerService)
+	assert.Equal(t, []string{"10.0.0.3", "10.0.0.1"}, deps[0].CallerHosts)
+	assert.True(t, deps[0].CallerVerified)
+	assert.True(t, seen.Equal(deps[0].FirstSeen))
+	assert.True(t, seen.Add(time.Minute).Equal(deps[0].LastSeen))
+	assert.Equal(t, "user", deps[1].CallerService)
+
+	total, deps, err = s.GetServiceDependencies(map[string]string{
+		"caller_namespace": "default",
+		"caller_service":   "order",
+	}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Len(t, deps, 1)
+	assert.Equal(t, "stock", deps[0].CalleeService)
+
+	total, deps, err = s.GetServiceDependencies(map[string]string{"caller_host": "10.0.0.2"}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), total)
+	assert.Equal(t, "user", deps[0].CallerService)
+
+	// 清理最近发现时间早于 before 的依赖关系
+	count, err := s.BatchCleanServiceDependencies(seen.Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), count)
+	total, _, err = s.GetServiceDependencies(map[string]string{}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+}
@@ -86,7 +86,7 @@ func (gs *groupStore) AddGroup(group *model.UserGroupDetail) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	if err := gs.cleanInValidGroup(tx, group.Name, group.Owner); err != nil {
@@ -122,7 +122,7 @@ func (gs *groupStore) addGroup(tx *bolt.Tx, group *model.UserGroupDetail) error
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Group] add usergroup tx commit", zap.Error(err),
 			zap.String("name", group.Name), zap.String("owner", group.Owner))
 		return err
@@ -149,7 +149,7 @@ func (gs *groupStore) updateGroup(group *model.ModifyUserGroup) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	values := make(map[string]interface{})
@@ -184,7 +184,7 @@ func (gs *groupStore) updateGroup(group *model.ModifyUserGroup) error {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Group] update usergroup tx commit",
 			zap.Error(err), zap.String("id", ret.ID))
 		return err
@@ -221,7 +221,7 @@ func (gs *groupStore) deleteGroup(group *model.UserGroupDetail) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	properties := make(map[string]interface{})
@@ -239,7 +239,7 @@ func (gs *groupStore) deleteGroup(group *model.UserGroupDetail) error {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Group] delete usergroupr tx commit",
 			zap.Error(err), zap.String("id", group.ID))
 		return err
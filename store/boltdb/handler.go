@@ -25,13 +25,19 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/golang/protobuf/proto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/store"
 )
 
 const (
 	DeleteFlagValue    byte   = 1
 	DataValidFieldName string = "Valid"
+
+	// dbSystem 链路数据中的数据库类型
+	dbSystem = "boltdb"
 )
 
 var (
@@ -84,11 +90,14 @@ type BoltHandler interface {
 type BoltConfig struct {
 	// FileName boltdb store file
 	FileName string
+	// ChangeLog record change log of the data objects for cache refreshing
+	ChangeLog bool
 }
 
 const (
-	confPath    = "path"
-	defaultPath = "./polaris.bolt"
+	confPath      = "path"
+	confChangeLog = "changeLog"
+	defaultPath   = "./polaris.bolt"
 )
 
 // Parse parse yaml config
@@ -98,6 +107,7 @@ func (c *BoltConfig) Parse(opt map[string]interface{}) {
 	} else {
 		c.FileName = defaultPath
 	}
+	c.ChangeLog, _ = opt[confChangeLog].(bool)
 }
 
 const (
@@ -110,6 +120,9 @@ func NewBoltHandler(config *BoltConfig) (BoltHandler, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.ChangeLog {
+		enableChangeLog(db)
+	}
 	return &boltHandler{db: db}, nil
 }
 
@@ -125,7 +138,7 @@ func openBoltDB(path string) (*bolt.DB, error) {
 
 // SaveValue insert data object, each data object should be identified by unique key
 func (b *boltHandler) SaveValue(typ string, key string, value interface{}) error {
-	return b.db.Update(func(tx *bolt.Tx) error {
+	return b.update("SaveValue", typ, func(tx *bolt.Tx) error {
 		return saveValue(tx, typ, key, value)
 	})
 }
@@ -138,6 +151,9 @@ func (b *boltHandler) SaveValue(typ string, key string, value interface{}) error
 //	@param value record value
 //	@return error if save failed, return error
 func saveValue(tx *bolt.Tx, typ string, key string, value interface{}) error {
+	if err := markObjectsDirty(tx, typ, key); err != nil {
+		return err
+	}
 	var typBucket *bolt.Bucket
 	var err error
 	typBucket, err = tx.CreateBucketIfNotExists([]byte(typ))
@@ -181,13 +197,14 @@ func (b *boltHandler) LoadValues(typ string, keys []string, typObject interface{
 	if len(keys) == 0 {
 		return values, nil
 	}
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view("LoadValues", typ, func(tx *bolt.Tx) error {
 		return loadValues(tx, typ, keys, typObject, values)
 	})
 	return values, err
 }
 
 func loadValues(tx *bolt.Tx, typ string, keys []string, typObject interface{}, values map[string]interface{}) error {
+	markObjectsRead(tx, typ, keys...)
 	for _, key := range keys {
 		bucket := getBucket(tx, typ, key)
 		if bucket == nil {
@@ -206,7 +223,7 @@ func loadValues(tx *bolt.Tx, typ string, keys []string, typObject interface{}, v
 func (b *boltHandler) LoadValuesByFilter(typ string, fields []string,
 	typObject interface{}, filter func(map[string]interface{}) bool) (map[string]interface{}, error) {
 	values := make(map[string]interface{})
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view("LoadValuesByFilter", typ, func(tx *bolt.Tx) error {
 		return loadValuesByFilter(tx, typ, fields, typObject, filter, values)
 	})
 	return values, err
@@ -214,6 +231,7 @@ func (b *boltHandler) LoadValuesByFilter(typ string, fields []string,
 
 func loadValuesByFilter(tx *bolt.Tx, typ string, fields []string, typObject interface{},
 	filter func(map[string]interface{}) bool, values map[string]interface{}) error {
+	markObjectsRead(tx, typ)
 	typeBucket := tx.Bucket([]byte(typ))
 	if typeBucket == nil {
 		return nil
@@ -350,7 +368,7 @@ func (b *boltHandler) IterateFields(typ string, field string, typObject interfac
 	if filter == nil {
 		return nil
 	}
-	return b.db.View(func(tx *bolt.Tx) error {
+	return b.view("IterateFields", typ, func(tx *bolt.Tx) error {
 		typeBucket := tx.Bucket([]byte(typ))
 		if typeBucket == nil {
 			return nil
@@ -382,6 +400,7 @@ func (b *boltHandler) IterateFields(typ string, field string, typObject interfac
 // Close boltdb
 func (b *boltHandler) Close() error {
 	if b.db != nil {
+		disableChangeLog(b.db)
 		return b.db.Close()
 	}
 	return nil
@@ -392,12 +411,15 @@ func (b *boltHandler) DeleteValues(typ string, keys []string) error {
 	if len(keys) == 0 {
 		return nil
 	}
-	return b.db.Update(func(tx *bolt.Tx) error {
+	return b.update("DeleteValues", typ, func(tx *bolt.Tx) error {
 		return deleteValues(tx, typ, keys)
 	})
 }
 
 func deleteValues(tx *bolt.Tx, typ string, keys []string) error {
+	if err := markObjectsDirty(tx, typ, keys...); err != nil {
+		return err
+	}
 	typeBucket := tx.Bucket([]byte(typ))
 	if typeBucket == nil {
 		return nil
@@ -465,7 +487,7 @@ func getKeys(bucket *bolt.Bucket) ([]string, error) {
 // CountValues count all data objects
 func (b *boltHandler) CountValues(typ string) (int, error) {
 	var count int
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view("CountValues", typ, func(tx *bolt.Tx) error {
 		typeBucket := tx.Bucket([]byte(typ))
 		if typeBucket == nil {
 			return nil
@@ -498,12 +520,15 @@ func (b *boltHandler) CountValues(typ string) (int, error) {
 
 // UpdateValue update properties of data object
 func (b *boltHandler) UpdateValue(typ string, key string, properties map[string]interface{}) error {
-	return b.db.Update(func(tx *bolt.Tx) error {
+	return b.update("UpdateValue", typ, func(tx *bolt.Tx) error {
 		return updateValue(tx, typ, key, properties)
 	})
 }
 
 func updateValue(tx *bolt.Tx, typ string, key string, properties map[string]interface{}) error {
+	if err := markObjectsDirty(tx, typ, key); err != nil {
+		return err
+	}
 	var err error
 	typeBucket := tx.Bucket([]byte(typ))
 	if typeBucket == nil {
@@ -559,7 +584,7 @@ func updateValue(tx *bolt.Tx, typ string, key string, properties map[string]inte
 // LoadValuesAll load all saved data objects, return value is 'key->object' map
 func (b *boltHandler) LoadValuesAll(typ string, typObject interface{}) (map[string]interface{}, error) {
 	values := make(map[string]interface{})
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view("LoadValuesAll", typ, func(tx *bolt.Tx) error {
 		typeBucket := tx.Bucket([]byte(typ))
 		if typeBucket == nil {
 			return nil
@@ -592,9 +617,33 @@ func (b *boltHandler) LoadValuesAll(typ string, typObject interface{}) (map[stri
 // Execute execute scripts directly
 func (b *boltHandler) Execute(writable bool, process func(tx *bolt.Tx) error) error {
 	if writable {
-		return b.db.Update(process)
+		return b.update("Execute", "", process)
+	}
+	return b.view("Execute", "", process)
+}
+
+// view 在只读事务中执行 process，并记录访问 boltdb 的 span
+func (b *boltHandler) view(op, typ string, process func(tx *bolt.Tx) error) error {
+	span := startSpan(op, typ)
+	err := b.db.View(process)
+	tracing.EndSpan(span, err)
+	return err
+}
+
+// update 在写事务中执行 process，并记录访问 boltdb 的 span
+func (b *boltHandler) update(op, typ string, process func(tx *bolt.Tx) error) error {
+	span := startSpan(op, typ)
+	err := b.db.Update(process)
+	tracing.EndSpan(span, err)
+	return err
+}
+
+// startSpan 创建访问 boltdb 的 span，typ 为操作的数据对象类型
+func startSpan(op, typ string) trace.Span {
+	if typ == "" {
+		return tracing.StartStoreSpan(dbSystem, op)
 	}
-	return b.db.View(process)
+	return tracing.StartStoreSpan(dbSystem, op, attribute.String("db.bolt.bucket", typ))
 }
 
 // StartTx start a new tx
@@ -0,0 +1,172 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblInstanceEvent = "instance_event"
+)
+
+// instanceEventSeq 同一纳秒内写入多个事件时用于区分主键
+var instanceEventSeq uint32
+
+// instanceEventObject 实例事件的存储对象
+type instanceEventObject struct {
+	EventType  string
+	Namespace  string
+	Service    string
+	InstanceID string
+	Host       string
+	Port       uint32
+	Weight     uint32
+	Healthy    bool
+	Isolate    bool
+	Server     string
+	CreateTime time.Time
+}
+
+func (o *instanceEventObject) toModel() *model.InstanceEventRecord {
+	return &model.InstanceEventRecord{
+		EventType:  model.InstanceEventType(o.EventType),
+		Namespace:  o.Namespace,
+		Service:    o.Service,
+		InstanceID: o.InstanceID,
+		Host:       o.Host,
+		Port:       o.Port,
+		Weight:     o.Weight,
+		Healthy:    o.Healthy,
+		Isolate:    o.Isolate,
+		Server:     o.Server,
+		CreateTime: o.CreateTime,
+	}
+}
+
+// instanceEventKey 主键以事件时间开头，按主键排序即为事件的先后顺序
+func instanceEventKey(createTime time.Time) string {
+	return fmt.Sprintf("%020d-%010d", createTime.UnixNano(), atomic.AddUint32(&instanceEventSeq, 1))
+}
+
+// instanceEventStore 实例事件的存储实现
+type instanceEventStore struct {
+	handler BoltHandler
+}
+
+// AddInstanceEvents 批量保存实例事件
+func (e *instanceEventStore) AddInstanceEvents(events []*model.InstanceEventRecord) error {
+	for _, event := range events {
+		err := e.handler.SaveValue(tblInstanceEvent, instanceEventKey(event.CreateTime), &instanceEventObject{
+			EventType:  string(event.EventType),
+			Namespace:  event.Namespace,
+			Service:    event.Service,
+			InstanceID: event.InstanceID,
+			Host:       event.Host,
+			Port:       event.Port,
+			Weight:     event.Weight,
+			Healthy:    event.Healthy,
+			Isolate:    event.Isolate,
+			Server:     event.Server,
+			CreateTime: event.CreateTime,
+		})
+		if err != nil {
+			log.Errorf("[Store][boltdb] save instance event err: %s", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInstanceEvents 查询实例事件，按照事件时间倒序返回
+func (e *instanceEventStore) GetInstanceEvents(filter *model.InstanceEventFilter, offset, limit uint32) (
+	uint32, []*model.InstanceEventRecord, error) {
+	keys, values, err := e.loadSorted()
+	if err != nil {
+		log.Errorf("[Store][boltdb] get instance events err: %s", err.Error())
+		return 0, nil, err
+	}
+
+	events := make([]*model.InstanceEventRecord, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		event := values[keys[i]].(*instanceEventObject).toModel()
+		if filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+
+	total := uint32(len(events))
+	if offset >= total {
+		return total, []*model.InstanceEventRecord{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return total, events[offset:end], nil
+}
+
+// BatchCleanInstanceEvents 清理过期的实例事件以及超过保留数量的实例事件
+func (e *instanceEventStore) BatchCleanInstanceEvents(before time.Time, keepCount uint32, batchSize uint32) (
+	uint32, error) {
+	keys, values, err := e.loadSorted()
+	if err != nil {
+		log.Errorf("[Store][boltdb] load instance events err: %s", err.Error())
+		return 0, err
+	}
+
+	// 主键按照事件时间升序，从最早的事件开始清理
+	var cleanKeys []string
+	for i, key := range keys {
+		if uint32(len(cleanKeys)) >= batchSize {
+			break
+		}
+		expired := !before.IsZero() && values[key].(*instanceEventObject).CreateTime.Before(before)
+		exceeded := keepCount > 0 && uint32(len(keys)-i) > keepCount
+		if !expired && !exceeded {
+			break
+		}
+		cleanKeys = append(cleanKeys, key)
+	}
+	if len(cleanKeys) == 0 {
+		return 0, nil
+	}
+	if err := e.handler.DeleteValues(tblInstanceEvent, cleanKeys); err != nil {
+		log.Errorf("[Store][boltdb] clean instance events err: %s", err.Error())
+		return 0, err
+	}
+	return uint32(len(cleanKeys)), nil
+}
+
+func (e *instanceEventStore) loadSorted() ([]string, map[string]interface{}, error) {
+	values, err := e.handler.LoadValuesAll(tblInstanceEvent, &instanceEventObject{})
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, values, nil
+}
@@ -0,0 +1,91 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestInstanceEventStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "instance_event.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &instanceEventStore{handler: handler}
+	base := time.Unix(1700000000, 0)
+	events := []*model.InstanceEventRecord{
+		{EventType: model.EventInstanceOnline, Namespace: "default", Service: "order", InstanceID: "ins-1",
+			Host: "10.0.0.1", Port: 8080, Weight: 100, Healthy: true, CreateTime: base},
+		{EventType: model.EventInstanceTurnUnHealth, Namespace: "default", Service: "order", InstanceID: "ins-1",
+			Host: "10.0.0.1", Port: 8080, Weight: 100, CreateTime: base.Add(time.Second)},
+		{EventType: model.EventInstanceOnline, Namespace: "default", Service: "order", InstanceID: "ins-2",
+			Host: "10.0.0.2", Port: 8080, Weight: 100, Healthy: true, CreateTime: base.Add(2 * time.Second)},
+		{EventType: model.EventInstanceOnline, Namespace: "default", Service: "payment", InstanceID: "ins-3",
+			Host: "10.0.0.3", Port: 8080, Weight: 100, Healthy: true, CreateTime: base.Add(3 * time.Second)},
+	}
+	assert.NoError(t, s.AddInstanceEvents(events))
+
+	total, ret, err := s.GetInstanceEvents(&model.InstanceEventFilter{Namespace: "default", Service: "order"}, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), total)
+	assert.Len(t, ret, 2)
+	assert.Equal(t, "ins-2", ret[0].InstanceID)
+	assert.Equal(t, model.EventInstanceTurnUnHealth, ret[1].EventType)
+	assert.True(t, ret[1].CreateTime.Equal(base.Add(time.Second)))
+
+	total, ret, err = s.GetInstanceEvents(&model.InstanceEventFilter{
+		InstanceID: "ins-1",
+		EventTypes: []model.InstanceEventType{model.EventInstanceOnline},
+	}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), total)
+	assert.True(t, ret[0].Healthy)
+
+	total, ret, err = s.GetInstanceEvents(&model.InstanceEventFilter{Namespace: "default", Service: "order"}, 5, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), total)
+	assert.Empty(t, ret)
+
+	// 先按时间清理最早的一条，再按数量只保留最新的两条
+	cleaned, err := s.BatchCleanInstanceEvents(base.Add(time.Second), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), cleaned)
+	cleaned, err = s.BatchCleanInstanceEvents(time.Time{}, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), cleaned)
+	cleaned, err = s.BatchCleanInstanceEvents(time.Time{}, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), cleaned)
+
+	total, ret, err = s.GetInstanceEvents(&model.InstanceEventFilter{}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, "ins-3", ret[0].InstanceID)
+	assert.Equal(t, "ins-2", ret[1].InstanceID)
+}
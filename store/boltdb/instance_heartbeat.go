@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblInstanceHeartbeat = "instance_heartbeat"
+)
+
+// instanceHeartbeatObject 实例心跳记录的存储对象
+type instanceHeartbeatObject struct {
+	InstanceID       string
+	Server           string
+	LastHeartbeatSec int64
+	Count            int64
+	ModifyTime       time.Time
+}
+
+func (o *instanceHeartbeatObject) toModel() *model.InstanceHeartbeat {
+	return &model.InstanceHeartbeat{
+		InstanceID:       o.InstanceID,
+		Server:           o.Server,
+		LastHeartbeatSec: o.LastHeartbeatSec,
+		Count:            o.Count,
+		ModifyTime:       o.ModifyTime,
+	}
+}
+
+// instanceHeartbeatStore 实例心跳记录的存储实现
+type instanceHeartbeatStore struct {
+	handler BoltHandler
+}
+
+// BatchUpdateInstanceHeartbeats 批量写入实例心跳记录，已存在的记录只有在心跳更新时才会被覆盖
+func (h *instanceHeartbeatStore) BatchUpdateInstanceHeartbeats(heartbeats []*model.InstanceHeartbeat) error {
+	keys := make([]string, 0, len(heartbeats))
+	for _, heartbeat := range heartbeats {
+		keys = append(keys, heartbeat.InstanceID)
+	}
+	err := h.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := make(map[string]interface{}, len(keys))
+		if err := loadValues(tx, tblInstanceHeartbeat, keys, &instanceHeartbeatObject{}, values); err != nil {
+			return err
+		}
+		mtime := time.Now()
+		for _, heartbeat := range heartbeats {
+			if value, ok := values[heartbeat.InstanceID]; ok {
+				if !heartbeat.Newer(value.(*instanceHeartbeatObject).toModel()) {
+					continue
+				}
+			}
+			obj := &instanceHeartbeatObject{
+				InstanceID:       heartbeat.InstanceID,
+				Server:           heartbeat.Server,
+				LastHeartbeatSec: heartbeat.LastHeartbeatSec,
+				Count:            heartbeat.Count,
+				ModifyTime:       mtime,
+			}
+			if err := saveValue(tx, tblInstanceHeartbeat, heartbeat.InstanceID, obj); err != nil {
+				return err
+			}
+			values[heartbeat.InstanceID] = obj
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("[Store][boltdb] update %d instance heartbeats err: %s", len(heartbeats), err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetMoreInstanceHeartbeats 获取修改时间不早于 mtime 的心跳记录
+func (h *instanceHeartbeatStore) GetMoreInstanceHeartbeats(mtime time.Time) ([]*model.InstanceHeartbeat, error) {
+	values, err := h.handler.LoadValuesAll(tblInstanceHeartbeat, &instanceHeartbeatObject{})
+	if err != nil {
+		log.Errorf("[Store][boltdb] get more instance heartbeats err: %s", err.Error())
+		return nil, err
+	}
+	heartbeats := make([]*model.InstanceHeartbeat, 0, len(values))
+	for _, value := range values {
+		obj := value.(*instanceHeartbeatObject)
+		if !mtime.IsZero() && obj.ModifyTime.Before(mtime) {
+			continue
+		}
+		heartbeats = append(heartbeats, obj.toModel())
+	}
+	return heartbeats, nil
+}
+
+// DeleteInstanceHeartbeat 删除实例的心跳记录
+func (h *instanceHeartbeatStore) DeleteInstanceHeartbeat(instanceID string) error {
+	if err := h.handler.DeleteValues(tblInstanceHeartbeat, []string{instanceID}); err != nil {
+		log.Errorf("[Store][boltdb] delete instance(%s) heartbeat err: %s", instanceID, err.Error())
+		return err
+	}
+	return nil
+}
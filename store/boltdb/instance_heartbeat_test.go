@@ -0,0 +1,77 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestInstanceHeartbeatStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "instance_heartbeat.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &instanceHeartbeatStore{handler: handler}
+	assert.NoError(t, s.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.1", LastHeartbeatSec: 100, Count: 1},
+		{InstanceID: "ins-2", Server: "127.0.0.1", LastHeartbeatSec: 100, Count: 1},
+	}))
+	since := time.Now()
+
+	// 较旧的心跳不会覆盖已有的记录
+	assert.NoError(t, s.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.2", LastHeartbeatSec: 99, Count: 5},
+		{InstanceID: "ins-2", Server: "127.0.0.2", LastHeartbeatSec: 101, Count: 2},
+	}))
+
+	heartbeats, err := s.GetMoreInstanceHeartbeats(time.Time{})
+	assert.NoError(t, err)
+	records := make(map[string]*model.InstanceHeartbeat)
+	for _, heartbeat := range heartbeats {
+		records[heartbeat.InstanceID] = heartbeat
+	}
+	assert.Len(t, records, 2)
+	assert.Equal(t, "127.0.0.1", records["ins-1"].Server)
+	assert.Equal(t, int64(100), records["ins-1"].LastHeartbeatSec)
+	assert.Equal(t, int64(1), records["ins-1"].Count)
+	assert.Equal(t, "127.0.0.2", records["ins-2"].Server)
+	assert.Equal(t, int64(101), records["ins-2"].LastHeartbeatSec)
+	assert.Equal(t, int64(2), records["ins-2"].Count)
+
+	heartbeats, err = s.GetMoreInstanceHeartbeats(since)
+	assert.NoError(t, err)
+	assert.Len(t, heartbeats, 1)
+	assert.Equal(t, "ins-2", heartbeats[0].InstanceID)
+
+	assert.NoError(t, s.DeleteInstanceHeartbeat("ins-1"))
+	heartbeats, err = s.GetMoreInstanceHeartbeats(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, heartbeats, 1)
+	assert.Equal(t, "ins-2", heartbeats[0].InstanceID)
+}
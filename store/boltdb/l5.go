@@ -72,6 +72,9 @@ const (
 
 func updateL5SidTable(rowBucket *bolt.Bucket, mid uint64, iid uint64, rnum uint64) error {
 	var err error
+	if err = markObjectsDirty(rowBucket.Tx(), tblNameL5, rowSidKey); err != nil {
+		return err
+	}
 	if err = rowBucket.Put([]byte(colModuleId), encodeUintBuffer(mid, typeUint32)); err != nil {
 		return err
 	}
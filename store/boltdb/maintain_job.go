@@ -0,0 +1,186 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblMaintainJobRun = "maintain_job_run"
+
+	MaintainJobRunFieldName     = "Name"
+	MaintainJobRunFieldStatus   = "Status"
+	MaintainJobRunFieldMessage  = "Message"
+	MaintainJobRunFieldAffected = "Affected"
+	MaintainJobRunFieldEndTime  = "EndTime"
+)
+
+// maintainJobRunObject 运维任务执行记录的存储对象，codec 不支持切片，影响的资源以 json 字符串保存
+type maintainJobRunObject struct {
+	ID        string
+	Name      string
+	Trigger   string
+	Operator  string
+	Host      string
+	Status    string
+	Message   string
+	Affected  string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// maintainJobStore 运维任务执行记录的存储实现
+type maintainJobStore struct {
+	handler BoltHandler
+}
+
+// AddMaintainJobRun 新增一条执行记录
+func (m *maintainJobStore) AddMaintainJobRun(run *model.MaintainJobRun) error {
+	affected, err := json.Marshal(run.Affected)
+	if err != nil {
+		return err
+	}
+	return m.handler.SaveValue(tblMaintainJobRun, run.ID, &maintainJobRunObject{
+		ID:        run.ID,
+		Name:      run.Name,
+		Trigger:   run.Trigger,
+		Operator:  run.Operator,
+		Host:      run.Host,
+		Status:    run.Status,
+		Message:   run.Message,
+		Affected:  string(affected),
+		StartTime: run.StartTime,
+		EndTime:   run.EndTime,
+	})
+}
+
+// UpdateMaintainJobRun 更新执行记录的状态、结果以及结束时间
+func (m *maintainJobStore) UpdateMaintainJobRun(run *model.MaintainJobRun) error {
+	affected, err := json.Marshal(run.Affected)
+	if err != nil {
+		return err
+	}
+	return m.handler.UpdateValue(tblMaintainJobRun, run.ID, map[string]interface{}{
+		MaintainJobRunFieldStatus:   run.Status,
+		MaintainJobRunFieldMessage:  run.Message,
+		MaintainJobRunFieldAffected: string(affected),
+		MaintainJobRunFieldEndTime:  run.EndTime,
+	})
+}
+
+// GetMaintainJobRuns 查询执行记录，name 为空时查询全部任务，按照开始时间倒序返回
+func (m *maintainJobStore) GetMaintainJobRuns(name string, offset, limit uint32) (
+	uint32, []*model.MaintainJobRun, error) {
+	values, err := m.handler.LoadValuesByFilter(tblMaintainJobRun, []string{MaintainJobRunFieldName},
+		&maintainJobRunObject{}, func(m map[string]interface{}) bool {
+			return name == "" || m[MaintainJobRunFieldName].(string) == name
+		})
+	if err != nil {
+		log.Errorf("[Store][boltdb] get maintain job runs err: %s", err.Error())
+		return 0, nil, err
+	}
+
+	runs := make([]*model.MaintainJobRun, 0, len(values))
+	for _, value := range values {
+		run, err := convertToModelMaintainJobRun(value.(*maintainJobRunObject))
+		if err != nil {
+			log.Errorf("[Store][boltdb] convert maintain job run err: %s", err.Error())
+			return 0, nil, err
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].StartTime.Equal(runs[j].StartTime) {
+			return runs[i].ID < runs[j].ID
+		}
+		return runs[i].StartTime.After(runs[j].StartTime)
+	})
+
+	total := uint32(len(runs))
+	if offset >= total {
+		return total, nil, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return total, runs[offset:end], nil
+}
+
+// CleanMaintainJobRuns 清理任务的历史执行记录，只保留开始时间最新的 keepCount 条，返回清理的数量
+func (m *maintainJobStore) CleanMaintainJobRuns(name string, keepCount uint32) (uint32, error) {
+	if keepCount == 0 {
+		return 0, nil
+	}
+	values, err := m.handler.LoadValuesByFilter(tblMaintainJobRun, []string{MaintainJobRunFieldName},
+		&maintainJobRunObject{}, func(m map[string]interface{}) bool {
+			return m[MaintainJobRunFieldName].(string) == name
+		})
+	if err != nil {
+		log.Errorf("[Store][boltdb] load maintain job runs(%s) err: %s", name, err.Error())
+		return 0, err
+	}
+	if uint32(len(values)) <= keepCount {
+		return 0, nil
+	}
+
+	runs := make([]*maintainJobRunObject, 0, len(values))
+	for _, value := range values {
+		runs = append(runs, value.(*maintainJobRunObject))
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].StartTime.Equal(runs[j].StartTime) {
+			return runs[i].ID < runs[j].ID
+		}
+		return runs[i].StartTime.After(runs[j].StartTime)
+	})
+	keys := make([]string, 0, len(runs)-int(keepCount))
+	for _, run := range runs[keepCount:] {
+		keys = append(keys, run.ID)
+	}
+	if err := m.handler.DeleteValues(tblMaintainJobRun, keys); err != nil {
+		log.Errorf("[Store][boltdb] clean maintain job runs(%s) err: %s", name, err.Error())
+		return 0, err
+	}
+	return uint32(len(keys)), nil
+}
+
+func convertToModelMaintainJobRun(obj *maintainJobRunObject) (*model.MaintainJobRun, error) {
+	run := &model.MaintainJobRun{
+		ID:        obj.ID,
+		Name:      obj.Name,
+		Trigger:   obj.Trigger,
+		Operator:  obj.Operator,
+		Host:      obj.Host,
+		Status:    obj.Status,
+		Message:   obj.Message,
+		StartTime: obj.StartTime,
+		EndTime:   obj.EndTime,
+	}
+	if obj.Affected != "" {
+		if err := json.Unmarshal([]byte(obj.Affected), &run.Affected); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
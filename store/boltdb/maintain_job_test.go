@@ -0,0 +1,98 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestMaintainJobStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintain_job.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &maintainJobStore{handler: handler}
+	start := time.Now()
+	for i, name := range []string{"DeleteUnHealthyInstance", "DeleteEmptyAutoCreatedService",
+		"DeleteUnHealthyInstance"} {
+		assert.NoError(t, s.AddMaintainJobRun(&model.MaintainJobRun{
+			ID:        name + string(rune('0'+i)),
+			Name:      name,
+			Trigger:   model.MaintainJobTriggerCron,
+			Host:      "127.0.0.1",
+			Status:    model.MaintainJobRunning,
+			StartTime: start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	assert.NoError(t, s.UpdateMaintainJobRun(&model.MaintainJobRun{
+		ID:       "DeleteUnHealthyInstance0",
+		Status:   model.MaintainJobSuccess,
+		Message:  "delete 2 instances",
+		Affected: []string{"ins-1", "ins-2"},
+		EndTime:  start.Add(time.Second),
+	}))
+
+	total, runs, err := s.GetMaintainJobRuns("DeleteUnHealthyInstance", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Len(t, runs, 2)
+	// 按照开始时间倒序
+	assert.Equal(t, "DeleteUnHealthyInstance2", runs[0].ID)
+	assert.Equal(t, model.MaintainJobRunning, runs[0].Status)
+	assert.Equal(t, "DeleteUnHealthyInstance0", runs[1].ID)
+	assert.Equal(t, model.MaintainJobSuccess, runs[1].Status)
+	assert.Equal(t, "delete 2 instances", runs[1].Message)
+	assert.Equal(t, []string{"ins-1", "ins-2"}, runs[1].Affected)
+	assert.False(t, runs[1].EndTime.IsZero())
+
+	total, runs, err = s.GetMaintainJobRuns("", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), total)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, "DeleteEmptyAutoCreatedService1", runs[0].ID)
+
+	total, runs, err = s.GetMaintainJobRuns("", 5, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), total)
+	assert.Len(t, runs, 0)
+
+	// 只保留每个任务最新的执行记录，不影响其他任务
+	cleaned, err := s.CleanMaintainJobRuns("DeleteUnHealthyInstance", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), cleaned)
+	cleaned, err = s.CleanMaintainJobRuns("DeleteUnHealthyInstance", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), cleaned)
+	total, runs, err = s.GetMaintainJobRuns("", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, "DeleteUnHealthyInstance2", runs[0].ID)
+	assert.Equal(t, "DeleteEmptyAutoCreatedService1", runs[1].ID)
+}
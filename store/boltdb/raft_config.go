@@ -0,0 +1,153 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/common/secure"
+)
+
+const (
+	confRaft = "raft"
+
+	defaultRaftDataDir           = "./polaris-raft"
+	defaultRaftApplyTimeout      = 10 * time.Second
+	defaultRaftLeaderWaitTimeout = time.Minute
+	defaultRaftSnapshotInterval  = 2 * time.Minute
+	defaultRaftSnapshotThreshold = 8192
+	defaultRaftSnapshotRetain    = 2
+)
+
+// RaftConfig raft 复制相关的配置
+type RaftConfig struct {
+	// NodeID 当前节点的 ID，必须出现在 Peers 中
+	NodeID string `mapstructure:"nodeId"`
+	// BindAddress raft 监听地址，为空时使用 Peers 中当前节点的地址
+	BindAddress string `mapstructure:"bindAddress"`
+	// DataDir raft 日志以及快照的存放目录
+	DataDir string `mapstructure:"dataDir"`
+	// Peers 集群中的全部节点，所有节点的配置必须一致
+	Peers []*RaftPeer `mapstructure:"peers"`
+	// ApplyTimeout 单次写操作等待复制完成的超时时间
+	ApplyTimeout time.Duration `mapstructure:"applyTimeout"`
+	// LeaderWaitTimeout 启动时等待集群选出 leader 的超时时间
+	LeaderWaitTimeout time.Duration `mapstructure:"leaderWaitTimeout"`
+	// SnapshotInterval 检查是否需要生成快照的间隔
+	SnapshotInterval time.Duration `mapstructure:"snapshotInterval"`
+	// SnapshotThreshold 距离上一次快照新增多少条日志后生成快照
+	SnapshotThreshold uint64 `mapstructure:"snapshotThreshold"`
+	// SnapshotRetain 保留的快照数量
+	SnapshotRetain int `mapstructure:"snapshotRetain"`
+	// TLS 节点之间的双向 TLS 认证，节点证书需要同时用于服务端以及客户端认证
+	TLS *secure.TLSConfig `mapstructure:"tls"`
+	// Token 集群内共享的访问凭据，TLS 与 Token 至少需要配置一个
+	Token string `mapstructure:"token"`
+}
+
+// RaftPeer raft 集群节点
+type RaftPeer struct {
+	// ID 节点 ID
+	ID string `mapstructure:"id"`
+	// Address 节点之间通信的地址，host:port
+	Address string `mapstructure:"address"`
+}
+
+// ParseRaftConfig 解析 raft 配置
+func ParseRaftConfig(opt map[string]interface{}) (*RaftConfig, error) {
+	conf := &RaftConfig{
+		DataDir:           defaultRaftDataDir,
+		ApplyTimeout:      defaultRaftApplyTimeout,
+		LeaderWaitTimeout: defaultRaftLeaderWaitTimeout,
+		SnapshotInterval:  defaultRaftSnapshotInterval,
+		SnapshotThreshold: defaultRaftSnapshotThreshold,
+		SnapshotRetain:    defaultRaftSnapshotRetain,
+	}
+	raw, ok := opt[confRaft]
+	if !ok {
+		return nil, errors.New("[Store][Raft] raft config is required")
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     conf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	if err := conf.Verify(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Verify 检查 raft 配置是否合法
+func (c *RaftConfig) Verify() error {
+	if c.NodeID == "" {
+		return errors.New("[Store][Raft] nodeId is required")
+	}
+	if len(c.Peers) == 0 {
+		return errors.New("[Store][Raft] peers is required")
+	}
+	ids := make(map[string]struct{}, len(c.Peers))
+	for _, peer := range c.Peers {
+		if peer.ID == "" || peer.Address == "" {
+			return errors.New("[Store][Raft] peer id and address is required")
+		}
+		if _, ok := ids[peer.ID]; ok {
+			return fmt.Errorf("[Store][Raft] duplicate peer id %s", peer.ID)
+		}
+		ids[peer.ID] = struct{}{}
+	}
+	if c.LocalPeer() == nil {
+		return fmt.Errorf("[Store][Raft] nodeId %s not found in peers", c.NodeID)
+	}
+	if c.ApplyTimeout <= 0 || c.LeaderWaitTimeout <= 0 || c.SnapshotInterval <= 0 {
+		return errors.New("[Store][Raft] applyTimeout, leaderWaitTimeout and snapshotInterval must be positive")
+	}
+	if c.SnapshotRetain <= 0 {
+		return errors.New("[Store][Raft] snapshotRetain must be positive")
+	}
+	if c.TLS != nil {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" || c.TLS.TrustedCAFile == "" {
+			return errors.New("[Store][Raft] tls requires certFile, keyFile and trustedCAFile")
+		}
+	} else if c.Token == "" {
+		return errors.New("[Store][Raft] tls or token is required")
+	}
+	if len(c.Token) > raftMaxTokenLen {
+		return fmt.Errorf("[Store][Raft] token is longer than %d", raftMaxTokenLen)
+	}
+	return nil
+}
+
+// LocalPeer 返回当前节点
+func (c *RaftConfig) LocalPeer() *RaftPeer {
+	for _, peer := range c.Peers {
+		if peer.ID == c.NodeID {
+			return peer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+const (
+	// raftMetaBucket 记录状态机已经应用的日志索引
+	raftMetaBucket = "__raft_meta__"
+	// raftObjectIndexBucket 记录每个数据对象以及每张表最后一次被修改时的日志索引，用于写冲突检测
+	raftObjectIndexBucket = "__raft_object_index__"
+	// raftAppliedIndexKey 已应用日志索引的 key
+	raftAppliedIndexKey = "appliedIndex"
+)
+
+var (
+	// ErrRaftWriteConflict 写操作基于的数据已经被其他写操作修改
+	ErrRaftWriteConflict = errors.New("raft write conflict, data has been modified concurrently")
+)
+
+// raftCommand 一次写事务复制到集群的内容
+// 写事务在本地执行后不会直接提交，而是记录下被修改数据对象的最终内容，由状态机在每个节点上整体覆盖
+type raftCommand struct {
+	// BaseIndex 执行写事务时本地状态机已应用的日志索引
+	BaseIndex uint64 `json:"baseIndex"`
+	// Objects 被修改的数据对象
+	Objects []*raftObject `json:"objects"`
+	// Reads 写事务读取过的数据对象，同样不允许在 BaseIndex 之后被修改，
+	// 否则两个节点基于过期数据的检查（例如服务名唯一）会各自通过，以不同的 key 写入后产生重复的数据
+	Reads []*raftRead `json:"reads,omitempty"`
+}
+
+// raftRead 写事务读取过的数据对象，Key 为空表示遍历了 typ 表下的全部数据对象
+type raftRead struct {
+	Typ string `json:"typ"`
+	Key string `json:"key,omitempty"`
+}
+
+func (r *raftRead) indexKey() []byte {
+	if r.Key == "" {
+		return tableIndexKey(r.Typ)
+	}
+	return []byte(r.Typ + "\x00" + r.Key)
+}
+
+// raftObject 被修改的数据对象，对应 typ 表下 key 对应的 bucket
+type raftObject struct {
+	Typ string `json:"typ"`
+	Key string `json:"key"`
+	// Data 数据对象修改后的内容，为空表示数据对象已被删除
+	Data *raftBucket `json:"data,omitempty"`
+}
+
+// raftBucket bucket 的全部内容
+type raftBucket struct {
+	Values  map[string][]byte      `json:"values,omitempty"`
+	Buckets map[string]*raftBucket `json:"buckets,omitempty"`
+}
+
+func (o *raftObject) indexKey() []byte {
+	return []byte(o.Typ + "\x00" + o.Key)
+}
+
+// tableIndexKey 表级别的修改索引，表名中不包含 \x00，不会与数据对象的索引冲突
+func tableIndexKey(typ string) []byte {
+	return []byte(typ)
+}
+
+// writeSet 写事务内被修改的数据对象，按照修改顺序记录
+type writeSet struct {
+	seen    map[string]struct{}
+	objects [][2]string
+}
+
+func newWriteSet() *writeSet {
+	return &writeSet{seen: make(map[string]struct{})}
+}
+
+func (w *writeSet) add(typ string, keys ...string) {
+	for _, key := range keys {
+		id := typ + "\x00" + key
+		if _, ok := w.seen[id]; ok {
+			continue
+		}
+		w.seen[id] = struct{}{}
+		w.objects = append(w.objects, [2]string{typ, key})
+	}
+}
+
+// readSet 写事务内读取过的数据对象
+type readSet struct {
+	seen  map[string]struct{}
+	reads []*raftRead
+}
+
+func newReadSet() *readSet {
+	return &readSet{seen: make(map[string]struct{})}
+}
+
+// add 记录读取的数据对象，keys 为空表示遍历了整张表
+func (r *readSet) add(typ string, keys ...string) {
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+	for _, key := range keys {
+		id := typ + "\x00" + key
+		if _, ok := r.seen[id]; ok {
+			continue
+		}
+		r.seen[id] = struct{}{}
+		r.reads = append(r.reads, &raftRead{Typ: typ, Key: key})
+	}
+}
+
+// buildRaftCommand 根据写事务内被修改以及读取的数据对象生成复制命令
+func buildRaftCommand(tx *bolt.Tx, ws *writeSet, rs *readSet) *raftCommand {
+	cmd := &raftCommand{
+		BaseIndex: loadAppliedIndex(tx),
+		Objects:   make([]*raftObject, 0, len(ws.objects)),
+		Reads:     rs.reads,
+	}
+	for _, item := range ws.objects {
+		obj := &raftObject{Typ: item[0], Key: item[1]}
+		if bucket := getBucket(tx, obj.Typ, obj.Key); bucket != nil {
+			obj.Data = dumpBucket(bucket)
+		}
+		cmd.Objects = append(cmd.Objects, obj)
+	}
+	return cmd
+}
+
+func dumpBucket(bucket *bolt.Bucket) *raftBucket {
+	data := &raftBucket{}
+	_ = bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			if data.Buckets == nil {
+				data.Buckets = make(map[string]*raftBucket)
+			}
+			data.Buckets[string(k)] = dumpBucket(bucket.Bucket(k))
+			return nil
+		}
+		if data.Values == nil {
+			data.Values = make(map[string][]byte)
+		}
+		data.Values[string(k)] = append([]byte(nil), v...)
+		return nil
+	})
+	return data
+}
+
+func writeBucket(bucket *bolt.Bucket, data *raftBucket) error {
+	for k, v := range data.Values {
+		if err := bucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	for k, sub := range data.Buckets {
+		child, err := bucket.CreateBucket([]byte(k))
+		if err != nil {
+			return err
+		}
+		if err := writeBucket(child, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyRaftObject(tx *bolt.Tx, obj *raftObject) error {
+	if err := appendChangeLog(tx, obj.Typ); err != nil {
+		return err
+	}
+	typBucket, err := tx.CreateBucketIfNotExists([]byte(obj.Typ))
+	if err != nil {
+		return err
+	}
+	keyBuf := []byte(obj.Key)
+	if typBucket.Bucket(keyBuf) != nil {
+		if err := typBucket.DeleteBucket(keyBuf); err != nil {
+			return err
+		}
+	}
+	if obj.Data == nil {
+		return nil
+	}
+	bucket, err := typBucket.CreateBucket(keyBuf)
+	if err != nil {
+		return err
+	}
+	return writeBucket(bucket, obj.Data)
+}
+
+// applyRaftCommand 应用复制命令，若命令修改或者读取的数据对象在 BaseIndex 之后被修改过则整体放弃
+func applyRaftCommand(tx *bolt.Tx, index uint64, cmd *raftCommand) (bool, error) {
+	indexBucket, err := tx.CreateBucketIfNotExists([]byte(raftObjectIndexBucket))
+	if err != nil {
+		return false, err
+	}
+	for _, obj := range cmd.Objects {
+		if decodeRaftIndex(indexBucket.Get(obj.indexKey())) > cmd.BaseIndex {
+			return false, nil
+		}
+	}
+	for _, read := range cmd.Reads {
+		if decodeRaftIndex(indexBucket.Get(read.indexKey())) > cmd.BaseIndex {
+			return false, nil
+		}
+	}
+	for _, obj := range cmd.Objects {
+		if err := applyRaftObject(tx, obj); err != nil {
+			return false, err
+		}
+		if err := indexBucket.Put(obj.indexKey(), encodeRaftIndex(index)); err != nil {
+			return false, err
+		}
+		if err := indexBucket.Put(tableIndexKey(obj.Typ), encodeRaftIndex(index)); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func loadAppliedIndex(tx *bolt.Tx) uint64 {
+	meta := tx.Bucket([]byte(raftMetaBucket))
+	if meta == nil {
+		return 0
+	}
+	return decodeRaftIndex(meta.Get([]byte(raftAppliedIndexKey)))
+}
+
+func encodeRaftIndex(index uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index)
+	return buf
+}
+
+func decodeRaftIndex(buf []byte) uint64 {
+	if len(buf) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(buf)
+}
+
+// raftFSM 基于 boltdb 的 raft 状态机
+type raftFSM struct {
+	db *bolt.DB
+	// appliedIndex 已应用的日志索引，用于转发写操作后等待本地状态追上
+	appliedIndex uint64
+}
+
+func newRaftFSM(db *bolt.DB) (*raftFSM, error) {
+	fsm := &raftFSM{db: db}
+	err := db.View(func(tx *bolt.Tx) error {
+		fsm.appliedIndex = loadAppliedIndex(tx)
+		return nil
+	})
+	return fsm, err
+}
+
+// AppliedIndex 返回状态机已应用的日志索引
+func (f *raftFSM) AppliedIndex() uint64 {
+	return atomic.LoadUint64(&f.appliedIndex)
+}
+
+// Apply 应用日志，返回 nil 或者 ErrRaftWriteConflict
+// 已应用日志索引与数据在同一个事务中持久化，节点重启后 raft 重放的旧日志会被直接跳过
+func (f *raftFSM) Apply(l *raft.Log) interface{} {
+	var (
+		result  error
+		skipped bool
+	)
+	err := f.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists([]byte(raftMetaBucket))
+		if err != nil {
+			return err
+		}
+		if decodeRaftIndex(meta.Get([]byte(raftAppliedIndexKey))) >= l.Index {
+			skipped = true
+			return nil
+		}
+		cmd := &raftCommand{}
+		if err := json.Unmarshal(l.Data, cmd); err != nil {
+			log.Error("[Store][Raft] decode raft command", zap.Uint64("index", l.Index), zap.Error(err))
+			result = err
+		} else {
+			applied, err := applyRaftCommand(tx, l.Index, cmd)
+			if err != nil {
+				return err
+			}
+			if !applied {
+				result = ErrRaftWriteConflict
+			}
+		}
+		return meta.Put([]byte(raftAppliedIndexKey), encodeRaftIndex(l.Index))
+	})
+	if err != nil {
+		log.Error("[Store][Raft] apply raft log", zap.Uint64("index", l.Index), zap.Error(err))
+		return err
+	}
+	if !skipped {
+		atomic.StoreUint64(&f.appliedIndex, l.Index)
+	}
+	return result
+}
+
+// Snapshot 基于 boltdb 的只读事务生成快照，不会阻塞后续日志的应用
+func (f *raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+	tx, err := f.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &raftSnapshot{tx: tx}, nil
+}
+
+// Restore 使用快照中的数据整体替换当前的数据
+func (f *raftFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	tmpFile := f.db.Path() + ".restore"
+	if err := writeSnapshotFile(tmpFile, rc); err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+
+	src, err := bolt.Open(tmpFile, 0600, &bolt.Options{Timeout: defaultTimeoutForFileLock, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var appliedIndex uint64
+	err = f.db.Update(func(tx *bolt.Tx) error {
+		names := make([][]byte, 0)
+		if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return src.View(func(srcTx *bolt.Tx) error {
+			appliedIndex = loadAppliedIndex(srcTx)
+			return srcTx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+				dst, err := tx.CreateBucket(append([]byte(nil), name...))
+				if err != nil {
+					return err
+				}
+				return copyBucket(dst, bucket)
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&f.appliedIndex, appliedIndex)
+	log.Info("[Store][Raft] restore from snapshot", zap.Uint64("applied-index", appliedIndex))
+	return nil
+}
+
+func writeSnapshotFile(file string, r io.Reader) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func copyBucket(dst, src *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		key := append([]byte(nil), k...)
+		if v == nil {
+			child, err := dst.CreateBucket(key)
+			if err != nil {
+				return err
+			}
+			return copyBucket(child, src.Bucket(k))
+		}
+		return dst.Put(key, append([]byte(nil), v...))
+	})
+}
+
+// raftSnapshot 持有 boltdb 只读事务的快照
+type raftSnapshot struct {
+	tx *bolt.Tx
+}
+
+// Persist 将快照写入 raft 的快照存储
+func (s *raftSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := s.tx.WriteTo(sink); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release 释放快照持有的只读事务
+func (s *raftSnapshot) Release() {
+	_ = s.tx.Rollback()
+}
@@ -0,0 +1,460 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/tracing"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// maxRaftConflictRetry 写冲突时的最大重试次数
+	maxRaftConflictRetry = 3
+	// raftPollInterval 等待 leader 选举以及本地状态追赶时的轮询间隔
+	raftPollInterval = 20 * time.Millisecond
+	raftMaxPool      = 3
+)
+
+// raftHandler 基于 raft 复制的 BoltHandler，读操作直接访问本地 boltdb，写操作复制到集群后生效
+type raftHandler struct {
+	*boltHandler
+
+	conf      *RaftConfig
+	fsm       *raftFSM
+	raft      *raft.Raft
+	mux       *raftMux
+	logStore  *raftboltdb.BoltStore
+	transport *raft.NetworkTransport
+}
+
+// NewRaftHandler 创建基于 raft 复制的 BoltHandler，并启动 raft 节点
+func NewRaftHandler(boltConf *BoltConfig, conf *RaftConfig) (BoltHandler, error) {
+	db, err := openBoltDB(boltConf.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if boltConf.ChangeLog {
+		enableChangeLog(db)
+	}
+	h := &raftHandler{boltHandler: &boltHandler{db: db}, conf: conf}
+	if err := h.start(); err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *raftHandler) start() error {
+	var err error
+	if err = os.MkdirAll(h.conf.DataDir, 0750); err != nil {
+		return err
+	}
+	if h.fsm, err = newRaftFSM(h.db); err != nil {
+		return err
+	}
+
+	logOutput := &raftLogWriter{}
+	h.logStore, err = raftboltdb.NewBoltStore(filepath.Join(h.conf.DataDir, "raft.db"))
+	if err != nil {
+		return err
+	}
+	snapshots, err := raft.NewFileSnapshotStore(h.conf.DataDir, h.conf.SnapshotRetain, logOutput)
+	if err != nil {
+		return err
+	}
+	if err = h.restoreIfBehind(snapshots); err != nil {
+		return err
+	}
+
+	local := h.conf.LocalPeer()
+	bindAddress := h.conf.BindAddress
+	if bindAddress == "" {
+		bindAddress = local.Address
+	}
+	security, err := newRaftSecurity(h.conf)
+	if err != nil {
+		return err
+	}
+	if h.mux, err = newRaftMux(bindAddress, local.Address, security); err != nil {
+		return err
+	}
+	if err = h.mux.rpcServer.RegisterName(raftForwardService, &RaftForward{handler: h}); err != nil {
+		return err
+	}
+	h.transport = raft.NewNetworkTransport(&raftStreamLayer{mux: h.mux}, raftMaxPool, h.conf.ApplyTimeout, logOutput)
+
+	raftConf := raft.DefaultConfig()
+	raftConf.LocalID = raft.ServerID(h.conf.NodeID)
+	raftConf.LogOutput = logOutput
+	raftConf.SnapshotInterval = h.conf.SnapshotInterval
+	raftConf.SnapshotThreshold = h.conf.SnapshotThreshold
+	// 状态机的数据本身就持久化在 boltdb 中，启动时无需再从快照恢复
+	raftConf.NoSnapshotRestoreOnStart = true
+
+	hasState, err := raft.HasExistingState(h.logStore, h.logStore, snapshots)
+	if err != nil {
+		return err
+	}
+	if !hasState {
+		servers := make([]raft.Server, 0, len(h.conf.Peers))
+		for _, peer := range h.conf.Peers {
+			servers = append(servers, raft.Server{
+				ID:      raft.ServerID(peer.ID),
+				Address: raft.ServerAddress(peer.Address),
+			})
+		}
+		if err = raft.BootstrapCluster(raftConf, h.logStore, h.logStore, snapshots, h.transport,
+			raft.Configuration{Servers: servers}); err != nil {
+			return err
+		}
+	}
+
+	h.raft, err = raft.NewRaft(raftConf, h.fsm, h.logStore, h.logStore, snapshots, h.transport)
+	return err
+}
+
+// restoreIfBehind boltdb 中的数据落后于最新的快照时（例如数据文件被删除），先使用快照恢复数据
+func (h *raftHandler) restoreIfBehind(snapshots raft.SnapshotStore) error {
+	metas, err := snapshots.List()
+	if err != nil || len(metas) == 0 {
+		return err
+	}
+	latest := metas[0]
+	if h.fsm.AppliedIndex() >= latest.Index {
+		return nil
+	}
+	log.Info("[Store][Raft] local data is behind snapshot, restore it",
+		zap.Uint64("applied-index", h.fsm.AppliedIndex()), zap.Uint64("snapshot-index", latest.Index))
+	_, reader, err := snapshots.Open(latest.ID)
+	if err != nil {
+		return err
+	}
+	return h.fsm.Restore(reader)
+}
+
+// IsLeader 当前节点是否为 raft leader
+func (h *raftHandler) IsLeader() bool {
+	return h.raft.State() == raft.Leader
+}
+
+// LeaderAddress 返回当前 leader 的地址
+func (h *raftHandler) LeaderAddress() string {
+	return string(h.raft.Leader())
+}
+
+// waitLeader 等待集群选出 leader
+func (h *raftHandler) waitLeader(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if leader := h.LeaderAddress(); leader != "" {
+			return leader, nil
+		}
+		if time.Now().After(deadline) {
+			return "", ErrRaftNoLeader
+		}
+		time.Sleep(raftPollInterval)
+	}
+}
+
+// SaveValue insert data object, each data object should be identified by unique key
+func (h *raftHandler) SaveValue(typ string, key string, value interface{}) error {
+	return h.write("SaveValue", typ, func(tx *bolt.Tx) error {
+		return saveValue(tx, typ, key, value)
+	})
+}
+
+// DeleteValues delete data object by unique key
+func (h *raftHandler) DeleteValues(typ string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return h.write("DeleteValues", typ, func(tx *bolt.Tx) error {
+		return deleteValues(tx, typ, keys)
+	})
+}
+
+// UpdateValue update properties of data object
+func (h *raftHandler) UpdateValue(typ string, key string, properties map[string]interface{}) error {
+	return h.write("UpdateValue", typ, func(tx *bolt.Tx) error {
+		return updateValue(tx, typ, key, properties)
+	})
+}
+
+// Execute execute scripts directly
+func (h *raftHandler) Execute(writable bool, process func(tx *bolt.Tx) error) error {
+	if !writable {
+		return h.boltHandler.Execute(false, process)
+	}
+	return h.write("Execute", "", process)
+}
+
+// StartTx start a new tx
+func (h *raftHandler) StartTx() (store.Tx, error) {
+	rtx, err := h.begin()
+	if err != nil {
+		return nil, err
+	}
+	return NewBoltTx(rtx.tx), nil
+}
+
+// Close boltdb
+func (h *raftHandler) Close() error {
+	if h.raft != nil {
+		if err := h.raft.Shutdown().Error(); err != nil {
+			log.Error("[Store][Raft] shutdown raft", zap.Error(err))
+		}
+	}
+	if h.transport != nil {
+		_ = h.transport.Close()
+	} else if h.mux != nil {
+		_ = h.mux.close()
+	}
+	if h.logStore != nil {
+		_ = h.logStore.Close()
+	}
+	return h.boltHandler.Close()
+}
+
+// write 在本地写事务中执行 process，然后将修改复制到集群，发生写冲突时重新执行
+func (h *raftHandler) write(op, typ string, process func(tx *bolt.Tx) error) (err error) {
+	span := startSpan(op, typ)
+	defer func() { tracing.EndSpan(span, err) }()
+	for i := 0; i < maxRaftConflictRetry; i++ {
+		var rtx *raftTx
+		if rtx, err = h.begin(); err != nil {
+			return err
+		}
+		if err = process(rtx.tx); err != nil {
+			_ = rtx.rollback()
+			return err
+		}
+		if err = rtx.commit(); !errors.Is(err, ErrRaftWriteConflict) {
+			return err
+		}
+		log.Warn("[Store][Raft] write conflict, retry", zap.Int("retry", i+1))
+	}
+	return err
+}
+
+func (h *raftHandler) begin() (*raftTx, error) {
+	tx, err := h.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	rtx := &raftTx{handler: h, tx: tx, ws: newWriteSet(), rs: newReadSet()}
+	txInterceptors.Store(tx, rtx)
+	return rtx, nil
+}
+
+// replicate 将复制命令提交到集群，follower 节点会转发给 leader，并等待本地状态机追上
+func (h *raftHandler) replicate(cmd *raftCommand) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	if h.IsLeader() {
+		_, err = h.applyLocal(data)
+		return err
+	}
+	// 发生写冲突时同样需要等待本地追上 leader，重试时才能基于最新的数据执行
+	index, err := h.forward(data)
+	if err != nil && !errors.Is(err, ErrRaftWriteConflict) {
+		return err
+	}
+	if waitErr := h.waitApplied(index); waitErr != nil {
+		return waitErr
+	}
+	return err
+}
+
+// barrier 等待本地状态机追上 leader 当前已提交的日志
+func (h *raftHandler) barrier() error {
+	if h.IsLeader() {
+		return h.raft.Barrier(h.conf.ApplyTimeout).Error()
+	}
+	data, err := json.Marshal(&raftCommand{})
+	if err != nil {
+		return err
+	}
+	index, err := h.forward(data)
+	if err != nil {
+		return err
+	}
+	return h.waitApplied(index)
+}
+
+// applyLocal 在 leader 上提交日志，等待本地状态机应用完成
+func (h *raftHandler) applyLocal(data []byte) (uint64, error) {
+	if !h.IsLeader() {
+		return 0, ErrRaftNotLeader
+	}
+	future := h.raft.Apply(data, h.conf.ApplyTimeout)
+	if err := future.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return 0, ErrRaftNotLeader
+		}
+		return 0, err
+	}
+	if resp, ok := future.Response().(error); ok && resp != nil {
+		return future.Index(), resp
+	}
+	return future.Index(), nil
+}
+
+// forward 将写操作转发给 leader，leader 切换时会重新查找 leader 进行转发
+func (h *raftHandler) forward(data []byte) (uint64, error) {
+	deadline := time.Now().Add(h.conf.ApplyTimeout)
+	for {
+		index, err := h.forwardOnce(data, time.Until(deadline))
+		if err == nil || !isRaftNotLeader(err) || time.Now().After(deadline) {
+			return index, err
+		}
+		time.Sleep(raftPollInterval)
+		if h.IsLeader() {
+			return h.applyLocal(data)
+		}
+	}
+}
+
+func (h *raftHandler) forwardOnce(data []byte, timeout time.Duration) (uint64, error) {
+	leader, err := h.waitLeader(timeout)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := h.mux.security.dial(leader, forwardConnType, timeout)
+	if err != nil {
+		return 0, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	client := rpc.NewClient(conn)
+	defer client.Close()
+
+	resp := &RaftForwardResponse{}
+	if err := client.Call(raftForwardService+".Apply", &RaftForwardRequest{Command: data}, resp); err != nil {
+		return 0, err
+	}
+	if resp.Conflict {
+		return resp.Index, ErrRaftWriteConflict
+	}
+	return resp.Index, nil
+}
+
+func isRaftNotLeader(err error) bool {
+	if errors.Is(err, ErrRaftNotLeader) || errors.Is(err, ErrRaftNoLeader) {
+		return true
+	}
+	var serverErr rpc.ServerError
+	return errors.As(err, &serverErr) && string(serverErr) == ErrRaftNotLeader.Error()
+}
+
+// waitApplied 等待本地状态机应用到指定的日志索引，保证写操作返回后在本节点可读
+func (h *raftHandler) waitApplied(index uint64) error {
+	deadline := time.Now().Add(h.conf.ApplyTimeout)
+	for h.fsm.AppliedIndex() < index {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("[Store][Raft] wait log %d applied timeout", index)
+		}
+		time.Sleep(raftPollInterval)
+	}
+	return nil
+}
+
+// raftTx raft 存储下的写事务，提交时不会直接提交到本地，而是通过 raft 复制后由状态机应用
+type raftTx struct {
+	handler *raftHandler
+	tx      *bolt.Tx
+	ws      *writeSet
+	rs      *readSet
+	once    sync.Once
+}
+
+func (t *raftTx) markDirty(typ string, keys ...string) {
+	t.ws.add(typ, keys...)
+}
+
+func (t *raftTx) markRead(typ string, keys ...string) {
+	t.rs.add(typ, keys...)
+}
+
+// commit 收集事务内的修改后回滚本地事务，再将修改复制到集群
+func (t *raftTx) commit() error {
+	var cmd *raftCommand
+	closed := true
+	t.once.Do(func() {
+		closed = false
+		cmd = buildRaftCommand(t.tx, t.ws, t.rs)
+		t.close()
+	})
+	if closed {
+		return bolt.ErrTxClosed
+	}
+	if len(cmd.Objects) == 0 {
+		return nil
+	}
+	return t.handler.replicate(cmd)
+}
+
+func (t *raftTx) rollback() error {
+	closed := true
+	t.once.Do(func() {
+		closed = false
+		t.close()
+	})
+	if closed {
+		return bolt.ErrTxClosed
+	}
+	return nil
+}
+
+func (t *raftTx) close() {
+	txInterceptors.Delete(t.tx)
+	_ = t.tx.Rollback()
+}
+
+// raftLogWriter 将 raft 的日志输出到存储层日志
+type raftLogWriter struct{}
+
+func (w *raftLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	switch {
+	case strings.Contains(msg, "[ERROR]"):
+		log.Error(msg)
+	case strings.Contains(msg, "[WARN]"):
+		log.Warn(msg)
+	case strings.Contains(msg, "[DEBUG]") || strings.Contains(msg, "[TRACE]"):
+		log.Debug(msg)
+	default:
+		log.Info(msg)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,182 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// RaftStoreName 基于 raft 复制的 boltdb 存储名称
+	RaftStoreName = "raftStore"
+)
+
+// raftStore 基于 raft 复制的 boltdb 存储，复用 boltStore 的全部数据操作，写操作通过 raft 复制到集群的每个节点
+// 选主直接使用 raft 的 leader 身份，而不是依赖数据库中的选主记录
+type raftStore struct {
+	*boltStore
+
+	raftHandler *raftHandler
+	leMutex     sync.Mutex
+	leKeys      map[string]bool
+	stopCh      chan struct{}
+}
+
+// Name store name
+func (r *raftStore) Name() string {
+	return RaftStoreName
+}
+
+// Initialize init store
+func (r *raftStore) Initialize(c *store.Config) error {
+	if r.start {
+		return nil
+	}
+	boltConfig := &BoltConfig{}
+	boltConfig.Parse(c.Option)
+	raftConfig, err := ParseRaftConfig(c.Option)
+	if err != nil {
+		return err
+	}
+	handler, err := NewRaftHandler(boltConfig, raftConfig)
+	if err != nil {
+		return err
+	}
+	r.raftHandler = handler.(*raftHandler)
+	// 初始化数据之前需要先追上 leader 的数据，避免基于过期的数据写入初始数据
+	if _, err := r.raftHandler.waitLeader(raftConfig.LeaderWaitTimeout); err != nil {
+		_ = handler.Close()
+		return err
+	}
+	if err := r.raftHandler.barrier(); err != nil {
+		_ = handler.Close()
+		return err
+	}
+
+	r.leKeys = make(map[string]bool)
+	r.stopCh = make(chan struct{})
+	go r.watchLeadership()
+	return r.boltStore.initialize(handler)
+}
+
+// Destroy store
+func (r *raftStore) Destroy() error {
+	if r.stopCh != nil {
+		close(r.stopCh)
+		r.stopCh = nil
+	}
+	return r.boltStore.Destroy()
+}
+
+// watchLeadership raft leader 身份变化时通知所有参与选主的 key
+func (r *raftStore) watchLeadership() {
+	leaderCh := r.raftHandler.raft.LeaderCh()
+	stopCh := r.stopCh
+	for {
+		select {
+		case <-stopCh:
+			return
+		case isLeader := <-leaderCh:
+			log.Infof("[Store][Raft] leadership changed, leader: %v", isLeader)
+			r.leMutex.Lock()
+			for key, started := range r.leKeys {
+				if started {
+					eventhub.Publish(eventhub.LeaderChangeEventTopic, store.LeaderChangeEvent{Key: key, Leader: isLeader})
+				}
+			}
+			r.leMutex.Unlock()
+		}
+	}
+}
+
+// StartLeaderElection 参与选主，raft leader 即为所有 key 的 leader
+func (r *raftStore) StartLeaderElection(key string) error {
+	r.leMutex.Lock()
+	defer r.leMutex.Unlock()
+
+	if started := r.leKeys[key]; started {
+		return nil
+	}
+	r.leKeys[key] = true
+	if r.raftHandler.IsLeader() {
+		eventhub.Publish(eventhub.LeaderChangeEventTopic, store.LeaderChangeEvent{Key: key, Leader: true})
+	}
+	return nil
+}
+
+// IsLeader 当前节点是否为 key 的 leader
+func (r *raftStore) IsLeader(key string) bool {
+	r.leMutex.Lock()
+	defer r.leMutex.Unlock()
+
+	return r.leKeys[key] && r.raftHandler.IsLeader()
+}
+
+// ListLeaderElections 列出所有参与选主的 key 以及当前的 leader
+func (r *raftStore) ListLeaderElections() ([]*model.LeaderElection, error) {
+	r.leMutex.Lock()
+	defer r.leMutex.Unlock()
+
+	host := r.raftHandler.LeaderAddress()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	now := time.Now()
+	out := make([]*model.LeaderElection, 0, len(r.leKeys))
+	for key, started := range r.leKeys {
+		out = append(out, &model.LeaderElection{
+			ElectKey:   key,
+			Host:       host,
+			Mtime:      now.Unix(),
+			CreateTime: time.Unix(0, 0),
+			ModifyTime: time.Unix(now.Unix(), 0),
+			Valid:      started,
+		})
+	}
+	return out, nil
+}
+
+// ReleaseLeaderElection 退出选主，raft leader 身份由整个节点共享，这里只停止对 key 的 leader 通知
+func (r *raftStore) ReleaseLeaderElection(key string) error {
+	r.leMutex.Lock()
+	defer r.leMutex.Unlock()
+
+	started, ok := r.leKeys[key]
+	if !ok {
+		return fmt.Errorf("LeaderElection(%s) not started", key)
+	}
+	if started {
+		r.leKeys[key] = false
+		if r.raftHandler.IsLeader() {
+			eventhub.Publish(eventhub.LeaderChangeEventTopic, store.LeaderChangeEvent{Key: key, Leader: false})
+		}
+	}
+	return nil
+}
+
+func init() {
+	s := &raftStore{boltStore: &boltStore{}}
+	_ = store.RegisterStore(s)
+}
@@ -0,0 +1,357 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/secure"
+)
+
+type memSnapshotSink struct {
+	bytes.Buffer
+}
+
+func (s *memSnapshotSink) ID() string {
+	return "mem"
+}
+
+func (s *memSnapshotSink) Cancel() error {
+	return nil
+}
+
+func (s *memSnapshotSink) Close() error {
+	return nil
+}
+
+func newTestRaftFSM(t *testing.T) *raftFSM {
+	db, err := openBoltDB(filepath.Join(t.TempDir(), "fsm.bolt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	fsm, err := newRaftFSM(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsm
+}
+
+// buildTestRaftLog 在 fsm 的本地事务中执行 process，生成对应的 raft 日志
+func buildTestRaftLog(t *testing.T, fsm *raftFSM, index uint64, process func(tx *bolt.Tx) error) *raft.Log {
+	tx, err := fsm.db.Begin(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rtx := &raftTx{tx: tx, ws: newWriteSet(), rs: newReadSet()}
+	txInterceptors.Store(tx, rtx)
+	defer rtx.close()
+
+	if err := process(tx); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(buildRaftCommand(tx, rtx.ws, rtx.rs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &raft.Log{Index: index, Data: data}
+}
+
+func testNamespace(name string, comment string) *model.Namespace {
+	return &model.Namespace{
+		Name:       name,
+		Comment:    comment,
+		Token:      "token-" + name,
+		Owner:      "polaris",
+		Valid:      true,
+		CreateTime: time.Now(),
+		ModifyTime: time.Now(),
+	}
+}
+
+func loadTestNamespace(t *testing.T, handler BoltHandler, name string) *model.Namespace {
+	values, err := handler.LoadValues(tblNameNamespace, []string{name}, &model.Namespace{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, ok := values[name]; ok {
+		return val.(*model.Namespace)
+	}
+	return nil
+}
+
+func TestRaftFSM_Apply(t *testing.T) {
+	fsm := newTestRaftFSM(t)
+	handler := &boltHandler{db: fsm.db}
+
+	// 写事务在本地回滚，只有日志被应用后数据才可见
+	entry := buildTestRaftLog(t, fsm, 1, func(tx *bolt.Tx) error {
+		return saveValue(tx, tblNameNamespace, "ns1", testNamespace("ns1", "v1"))
+	})
+	assert.Nil(t, loadTestNamespace(t, handler, "ns1"))
+	assert.Nil(t, fsm.Apply(entry))
+	assert.Equal(t, uint64(1), fsm.AppliedIndex())
+	ns := loadTestNamespace(t, handler, "ns1")
+	assert.NotNil(t, ns)
+	assert.Equal(t, "v1", ns.Comment)
+
+	t.Run("写冲突", func(t *testing.T) {
+		first := buildTestRaftLog(t, fsm, 2, func(tx *bolt.Tx) error {
+			return updateValue(tx, tblNameNamespace, "ns1", map[string]interface{}{"Comment": "v2"})
+		})
+		second := buildTestRaftLog(t, fsm, 3, func(tx *bolt.Tx) error {
+			return updateValue(tx, tblNameNamespace, "ns1", map[string]interface{}{"Comment": "v3"})
+		})
+		assert.Nil(t, fsm.Apply(first))
+		assert.Equal(t, ErrRaftWriteConflict, fsm.Apply(second))
+		assert.Equal(t, uint64(3), fsm.AppliedIndex())
+		assert.Equal(t, "v2", loadTestNamespace(t, handler, "ns1").Comment)
+	})
+
+	t.Run("重放已应用的日志", func(t *testing.T) {
+		replay := buildTestRaftLog(t, fsm, 2, func(tx *bolt.Tx) error {
+			return updateValue(tx, tblNameNamespace, "ns1", map[string]interface{}{"Comment": "v4"})
+		})
+		assert.Nil(t, fsm.Apply(replay))
+		assert.Equal(t, uint64(3), fsm.AppliedIndex())
+		assert.Equal(t, "v2", loadTestNamespace(t, handler, "ns1").Comment)
+	})
+
+	t.Run("删除数据", func(t *testing.T) {
+		entry := buildTestRaftLog(t, fsm, 4, func(tx *bolt.Tx) error {
+			return deleteValues(tx, tblNameNamespace, []string{"ns1"})
+		})
+		assert.Nil(t, fsm.Apply(entry))
+		assert.Nil(t, loadTestNamespace(t, handler, "ns1"))
+	})
+
+	// 两个事务都遍历整张表确认不存在同名数据，然后以不同的 key 写入，后应用的日志需要被拒绝
+	t.Run("读表冲突", func(t *testing.T) {
+		createIfAbsent := func(key string) func(tx *bolt.Tx) error {
+			return func(tx *bolt.Tx) error {
+				values := make(map[string]interface{})
+				err := loadValuesByFilter(tx, tblNameNamespace, []string{"Comment"}, &model.Namespace{},
+					func(m map[string]interface{}) bool {
+						return m["Comment"] == "same"
+					}, values)
+				if err != nil || len(values) > 0 {
+					return err
+				}
+				return saveValue(tx, tblNameNamespace, key, testNamespace(key, "same"))
+			}
+		}
+		first := buildTestRaftLog(t, fsm, 5, createIfAbsent("ns-a"))
+		second := buildTestRaftLog(t, fsm, 6, createIfAbsent("ns-b"))
+		assert.Nil(t, fsm.Apply(first))
+		assert.Equal(t, ErrRaftWriteConflict, fsm.Apply(second))
+		assert.NotNil(t, loadTestNamespace(t, handler, "ns-a"))
+		assert.Nil(t, loadTestNamespace(t, handler, "ns-b"))
+	})
+
+	t.Run("读数据冲突", func(t *testing.T) {
+		read := buildTestRaftLog(t, fsm, 8, func(tx *bolt.Tx) error {
+			values := make(map[string]interface{})
+			if err := loadValues(tx, tblNameNamespace, []string{"ns-a"}, &model.Namespace{}, values); err != nil {
+				return err
+			}
+			return saveValue(tx, tblNameNamespace, "ns-c", testNamespace("ns-c", "v1"))
+		})
+		update := buildTestRaftLog(t, fsm, 7, func(tx *bolt.Tx) error {
+			return updateValue(tx, tblNameNamespace, "ns-a", map[string]interface{}{"Comment": "v2"})
+		})
+		assert.Nil(t, fsm.Apply(update))
+		assert.Equal(t, ErrRaftWriteConflict, fsm.Apply(read))
+		assert.Nil(t, loadTestNamespace(t, handler, "ns-c"))
+	})
+}
+
+func TestRaftFSM_SnapshotRestore(t *testing.T) {
+	src := newTestRaftFSM(t)
+	for i := 1; i <= 3; i++ {
+		name := fmt.Sprintf("ns%d", i)
+		entry := buildTestRaftLog(t, src, uint64(i), func(tx *bolt.Tx) error {
+			return saveValue(tx, tblNameNamespace, name, testNamespace(name, name))
+		})
+		assert.Nil(t, src.Apply(entry))
+	}
+
+	snapshot, err := src.Snapshot()
+	assert.Nil(t, err)
+	sink := &memSnapshotSink{}
+	assert.Nil(t, snapshot.Persist(sink))
+	snapshot.Release()
+
+	dst := newTestRaftFSM(t)
+	stale := buildTestRaftLog(t, dst, 1, func(tx *bolt.Tx) error {
+		return saveValue(tx, tblNameNamespace, "stale", testNamespace("stale", "stale"))
+	})
+	assert.Nil(t, dst.Apply(stale))
+
+	assert.Nil(t, dst.Restore(ioutil.NopCloser(bytes.NewReader(sink.Bytes()))))
+	assert.Equal(t, uint64(3), dst.AppliedIndex())
+	handler := &boltHandler{db: dst.db}
+	assert.Nil(t, loadTestNamespace(t, handler, "stale"))
+	for i := 1; i <= 3; i++ {
+		name := fmt.Sprintf("ns%d", i)
+		ns := loadTestNamespace(t, handler, name)
+		assert.NotNil(t, ns)
+		assert.Equal(t, name, ns.Comment)
+	}
+}
+
+func freeRaftAddress(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRaftMux_Token(t *testing.T) {
+	addr := freeRaftAddress(t)
+	mux, err := newRaftMux(addr, addr, &raftSecurity{token: []byte("secret")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mux.close()
+
+	accepted := func() bool {
+		select {
+		case conn := <-mux.raftConns:
+			_ = conn.Close()
+			return true
+		case <-time.After(500 * time.Millisecond):
+			return false
+		}
+	}
+
+	// 访问凭据错误的连接被拒绝
+	conn, err := (&raftSecurity{token: []byte("other")}).dial(addr, raftConnType, time.Second)
+	assert.Nil(t, err)
+	defer conn.Close()
+	assert.False(t, accepted())
+
+	conn, err = (&raftSecurity{token: []byte("secret")}).dial(addr, raftConnType, time.Second)
+	assert.Nil(t, err)
+	defer conn.Close()
+	assert.True(t, accepted())
+}
+
+func TestRaftConfig_Verify(t *testing.T) {
+	conf := &RaftConfig{
+		NodeID:            "node1",
+		Peers:             []*RaftPeer{{ID: "node1", Address: "127.0.0.1:8300"}},
+		ApplyTimeout:      time.Second,
+		LeaderWaitTimeout: time.Second,
+		SnapshotInterval:  time.Second,
+		SnapshotRetain:    1,
+	}
+	// 节点之间必须开启认证
+	assert.NotNil(t, conf.Verify())
+	conf.Token = "secret"
+	assert.Nil(t, conf.Verify())
+	conf.TLS = &secure.TLSConfig{CertFile: "node.pem", KeyFile: "node-key.pem"}
+	assert.NotNil(t, conf.Verify())
+}
+
+func TestRaftHandler_Cluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip raft cluster test in short mode")
+	}
+	peers := make([]*RaftPeer, 0, 3)
+	for i := 0; i < 3; i++ {
+		peers = append(peers, &RaftPeer{ID: fmt.Sprintf("node%d", i), Address: freeRaftAddress(t)})
+	}
+	handlers := make([]*raftHandler, 0, len(peers))
+	for _, peer := range peers {
+		dir := t.TempDir()
+		handler, err := NewRaftHandler(&BoltConfig{FileName: filepath.Join(dir, "polaris.bolt")}, &RaftConfig{
+			NodeID:            peer.ID,
+			DataDir:           filepath.Join(dir, "raft"),
+			Peers:             peers,
+			ApplyTimeout:      5 * time.Second,
+			LeaderWaitTimeout: 10 * time.Second,
+			SnapshotInterval:  time.Minute,
+			SnapshotThreshold: 1024,
+			SnapshotRetain:    1,
+			Token:             "secret",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handlers = append(handlers, handler.(*raftHandler))
+	}
+	defer func() {
+		for _, handler := range handlers {
+			_ = handler.Close()
+		}
+	}()
+
+	var leader, follower *raftHandler
+	for _, handler := range handlers {
+		if _, err := handler.waitLeader(10 * time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, handler := range handlers {
+		if handler.IsLeader() {
+			leader = handler
+		} else if follower == nil {
+			follower = handler
+		}
+	}
+	assert.NotNil(t, leader)
+	assert.NotNil(t, follower)
+
+	// follower 上的写操作转发给 leader，返回后在 follower 本地可读
+	assert.Nil(t, follower.SaveValue(tblNameNamespace, "ns1", testNamespace("ns1", "v1")))
+	assert.Equal(t, "v1", loadTestNamespace(t, follower, "ns1").Comment)
+
+	assert.Nil(t, leader.UpdateValue(tblNameNamespace, "ns1", map[string]interface{}{"Comment": "v2"}))
+	tx, err := follower.StartTx()
+	assert.Nil(t, err)
+	assert.Nil(t, saveValue(tx.GetDelegateTx().(*bolt.Tx), tblNameNamespace, "ns2", testNamespace("ns2", "v1")))
+	assert.Nil(t, tx.Commit())
+
+	for _, handler := range handlers {
+		assert.Nil(t, handler.barrier())
+		assert.Equal(t, "v2", loadTestNamespace(t, handler, "ns1").Comment)
+		assert.NotNil(t, loadTestNamespace(t, handler, "ns2"))
+	}
+
+	assert.Nil(t, follower.DeleteValues(tblNameNamespace, []string{"ns2"}))
+	for _, handler := range handlers {
+		assert.Nil(t, handler.barrier())
+		assert.Nil(t, loadTestNamespace(t, handler, "ns2"))
+	}
+}
@@ -0,0 +1,271 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+const (
+	// raftConnType raft 节点之间复制日志的连接
+	raftConnType byte = 1
+	// forwardConnType follower 向 leader 转发写操作的连接
+	forwardConnType byte = 2
+
+	raftConnReadTimeout = 10 * time.Second
+	raftForwardService  = "RaftForward"
+	// raftMaxTokenLen 连接头中访问凭据的最大长度
+	raftMaxTokenLen = 1024
+)
+
+var (
+	// ErrRaftNotLeader 当前节点不是 leader
+	ErrRaftNotLeader = errors.New("raft node is not leader")
+	// ErrRaftNoLeader 集群当前没有 leader
+	ErrRaftNoLeader = errors.New("raft cluster has no leader")
+)
+
+// raftSecurity 节点之间连接的认证，配置了 tls 时进行双向 TLS 认证，配置了 token 时校验连接头中的共享访问凭据
+type raftSecurity struct {
+	serverConf *tls.Config
+	// clientConfs 根据节点主机名生成客户端 tls 配置，所有配置共享热加载的证书以及 CA
+	clientConfs func(serverName string) *tls.Config
+	token       []byte
+}
+
+func newRaftSecurity(conf *RaftConfig) (*raftSecurity, error) {
+	security := &raftSecurity{token: []byte(conf.Token)}
+	if conf.TLS == nil {
+		return security, nil
+	}
+	info := conf.TLS.ToTLSInfo()
+	info.ClientCertAuth = true
+	var err error
+	if security.serverConf, err = info.ServerConfig(); err != nil {
+		return nil, err
+	}
+	if security.clientConfs, err = info.NewClientConfigs(); err != nil {
+		return nil, err
+	}
+	return security, nil
+}
+
+// dial 建立指定类型的连接，连接建立后首先发送连接头：1 字节的连接类型、2 字节的访问凭据长度以及访问凭据
+func (s *raftSecurity) dial(address string, connType byte, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if s.clientConfs != nil {
+		host, _, _ := net.SplitHostPort(address)
+		tlsConn := tls.Client(conn, s.clientConfs(host))
+		if err := tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	header := make([]byte, 3, 3+len(s.token))
+	header[0] = connType
+	binary.BigEndian.PutUint16(header[1:], uint16(len(s.token)))
+	header = append(header, s.token...)
+	if _, err := conn.Write(header); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// accept 读取并校验连接头，返回连接类型
+func (s *raftSecurity) accept(conn net.Conn) (byte, error) {
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, err
+	}
+	size := binary.BigEndian.Uint16(header[1:])
+	if size > raftMaxTokenLen {
+		return 0, errors.New("raft connection token too long")
+	}
+	token := make([]byte, size)
+	if _, err := io.ReadFull(conn, token); err != nil {
+		return 0, err
+	}
+	if len(s.token) > 0 && subtle.ConstantTimeCompare(token, s.token) != 1 {
+		return 0, errors.New("invalid raft connection token")
+	}
+	return header[0], nil
+}
+
+// raftMux 在同一个端口上同时提供 raft 日志复制以及写操作转发，连接头中的第一个字节标识连接类型
+type raftMux struct {
+	listener  net.Listener
+	advertise net.Addr
+	security  *raftSecurity
+	raftConns chan net.Conn
+	rpcServer *rpc.Server
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func newRaftMux(bindAddress string, advertise string, security *raftSecurity) (*raftMux, error) {
+	addr, err := net.ResolveTCPAddr("tcp", advertise)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", bindAddress)
+	if err != nil {
+		return nil, err
+	}
+	if security.serverConf != nil {
+		listener = tls.NewListener(listener, security.serverConf)
+	}
+	mux := &raftMux{
+		listener:  listener,
+		advertise: addr,
+		security:  security,
+		raftConns: make(chan net.Conn, 16),
+		rpcServer: rpc.NewServer(),
+		closeCh:   make(chan struct{}),
+	}
+	go mux.serve()
+	return mux, nil
+}
+
+func (m *raftMux) serve() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			select {
+			case <-m.closeCh:
+				return
+			default:
+			}
+			log.Error("[Store][Raft] accept connection", zap.Error(err))
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go m.dispatch(conn)
+	}
+}
+
+func (m *raftMux) dispatch(conn net.Conn) {
+	_ = conn.SetReadDeadline(time.Now().Add(raftConnReadTimeout))
+	connType, err := m.security.accept(conn)
+	if err != nil {
+		log.Warn("[Store][Raft] reject connection", zap.String("remote", conn.RemoteAddr().String()), zap.Error(err))
+		_ = conn.Close()
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	switch connType {
+	case raftConnType:
+		select {
+		case m.raftConns <- conn:
+		case <-m.closeCh:
+			_ = conn.Close()
+		}
+	case forwardConnType:
+		m.rpcServer.ServeConn(conn)
+	default:
+		log.Warn("[Store][Raft] unknown connection type", zap.Uint8("type", connType),
+			zap.String("remote", conn.RemoteAddr().String()))
+		_ = conn.Close()
+	}
+}
+
+func (m *raftMux) close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.closeCh)
+		err = m.listener.Close()
+	})
+	return err
+}
+
+// raftStreamLayer 实现 raft.StreamLayer，只处理 raft 类型的连接
+type raftStreamLayer struct {
+	mux *raftMux
+}
+
+// Accept 等待 raft 类型的连接
+func (s *raftStreamLayer) Accept() (net.Conn, error) {
+	select {
+	case conn := <-s.mux.raftConns:
+		return conn, nil
+	case <-s.mux.closeCh:
+		return nil, errors.New("raft stream layer closed")
+	}
+}
+
+// Close 关闭监听
+func (s *raftStreamLayer) Close() error {
+	return s.mux.close()
+}
+
+// Addr 返回其他节点访问当前节点的地址
+func (s *raftStreamLayer) Addr() net.Addr {
+	return s.mux.advertise
+}
+
+// Dial 建立 raft 类型的连接
+func (s *raftStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return s.mux.security.dial(string(address), raftConnType, timeout)
+}
+
+// RaftForwardRequest follower 转发给 leader 的写操作
+type RaftForwardRequest struct {
+	Command []byte
+}
+
+// RaftForwardResponse leader 处理转发写操作的结果
+type RaftForwardResponse struct {
+	// Index 写操作对应的日志索引
+	Index uint64
+	// Conflict 是否发生写冲突
+	Conflict bool
+}
+
+// RaftForward leader 上处理转发写操作的 rpc 服务
+type RaftForward struct {
+	handler *raftHandler
+}
+
+// Apply 在 leader 上提交转发过来的写操作
+func (f *RaftForward) Apply(req *RaftForwardRequest, resp *RaftForwardResponse) error {
+	index, err := f.handler.applyLocal(req.Command)
+	resp.Index = index
+	if errors.Is(err, ErrRaftWriteConflict) {
+		resp.Conflict = true
+		return nil
+	}
+	return err
+}
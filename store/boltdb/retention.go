@@ -0,0 +1,175 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// retentionStore 历史数据清理的存储实现
+type retentionStore struct {
+	handler BoltHandler
+}
+
+// matchNamespace namespace 不为空时只匹配该命名空间，为空时匹配除 excludes 之外的全部命名空间
+func matchNamespace(saveNs, namespace string, excludes []string) bool {
+	if namespace != "" {
+		return saveNs == namespace
+	}
+	for _, item := range excludes {
+		if saveNs == item {
+			return false
+		}
+	}
+	return true
+}
+
+// BatchCleanConfigFileReleaseHistories 清理配置文件的发布历史，
+// 每个配置文件保留最新的 keepCount 条以及创建时间不早于 before 的记录
+func (r *retentionStore) BatchCleanConfigFileReleaseHistories(namespace string, excludes []string,
+	keepCount uint32, before time.Time, batchSize uint32) (uint32, error) {
+	type history struct {
+		key        string
+		id         uint64
+		createTime time.Time
+	}
+	files := map[string][]*history{}
+	fields := []string{FileHistoryFieldNamespace, FileHistoryFieldGroup, FileHistoryFieldFileName,
+		FileHistoryFieldId, FileHistoryFieldCreateTime}
+	err := r.scan(tblConfigFileReleaseHistory, fields, &model.ConfigFileReleaseHistory{},
+		func(key string, m map[string]interface{}) {
+			saveNs, _ := m[FileHistoryFieldNamespace].(string)
+			if !matchNamespace(saveNs, namespace, excludes) {
+				return
+			}
+			group, _ := m[FileHistoryFieldGroup].(string)
+			fileName, _ := m[FileHistoryFieldFileName].(string)
+			id, _ := m[FileHistoryFieldId].(uint64)
+			ctime, _ := m[FileHistoryFieldCreateTime].(time.Time)
+			file := saveNs + "@" + group + "@" + fileName
+			files[file] = append(files[file], &history{key: key, id: id, createTime: ctime})
+		})
+	if err != nil {
+		log.Errorf("[Store][boltdb] scan config file release histories err: %s", err.Error())
+		return 0, err
+	}
+
+	var keys []string
+	for _, histories := range files {
+		sort.Slice(histories, func(i, j int) bool {
+			return histories[i].id > histories[j].id
+		})
+		for i := int(keepCount); i < len(histories); i++ {
+			if histories[i].createTime.Before(before) {
+				keys = append(keys, histories[i].key)
+			}
+		}
+	}
+	return r.deleteInBatches(tblConfigFileReleaseHistory, keys, batchSize)
+}
+
+// BatchPurgeDeletedServices 物理删除修改时间早于 before 的软删除服务
+func (r *retentionStore) BatchPurgeDeletedServices(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	fields := []string{SvcFieldValid, SvcFieldNamespace, SvcFieldModifyTime}
+	return r.purgeDeleted(tblNameService, fields, &model.Service{}, batchSize,
+		func(m map[string]interface{}) bool {
+			valid, _ := m[SvcFieldValid].(bool)
+			saveNs, _ := m[SvcFieldNamespace].(string)
+			mtime, _ := m[SvcFieldModifyTime].(time.Time)
+			return !valid && mtime.Before(before) && matchNamespace(saveNs, namespace, excludes)
+		})
+}
+
+// BatchPurgeDeletedConfigFiles 物理删除修改时间早于 before 的软删除配置文件
+func (r *retentionStore) BatchPurgeDeletedConfigFiles(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	fields := []string{FileFieldValid, FileFieldNamespace, FileFieldModifyTime}
+	return r.purgeDeleted(tblConfigFile, fields, &model.ConfigFile{}, batchSize,
+		func(m map[string]interface{}) bool {
+			valid, _ := m[FileFieldValid].(bool)
+			saveNs, _ := m[FileFieldNamespace].(string)
+			mtime, _ := m[FileFieldModifyTime].(time.Time)
+			return !valid && mtime.Before(before) && matchNamespace(saveNs, namespace, excludes)
+		})
+}
+
+func (r *retentionStore) purgeDeleted(typ string, fields []string, typObject interface{}, batchSize uint32,
+	filter func(map[string]interface{}) bool) (uint32, error) {
+	var keys []string
+	err := r.scan(typ, fields, typObject, func(key string, m map[string]interface{}) {
+		if filter(m) {
+			keys = append(keys, key)
+		}
+	})
+	if err != nil {
+		log.Errorf("[Store][boltdb] scan deleted %s err: %s", typ, err.Error())
+		return 0, err
+	}
+	return r.deleteInBatches(typ, keys, batchSize)
+}
+
+// scan 使用游标遍历一次 typ 的全部数据，只读取 fields 中的字段
+func (r *retentionStore) scan(typ string, fields []string, typObject interface{},
+	process func(key string, m map[string]interface{})) error {
+	return r.handler.Execute(false, func(tx *bolt.Tx) error {
+		typeBucket := tx.Bucket([]byte(typ))
+		if typeBucket == nil {
+			return nil
+		}
+		cursor := typeBucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			bucket := typeBucket.Bucket(k)
+			if bucket == nil {
+				continue
+			}
+			key := string(k)
+			_, err := matchObject(bucket, fields, typObject, func(m map[string]interface{}) bool {
+				process(key, m)
+				return false
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteInBatches 每个事务最多删除 batchSize 条数据，避免单个写事务过大
+func (r *retentionStore) deleteInBatches(typ string, keys []string, batchSize uint32) (uint32, error) {
+	var count uint32
+	for len(keys) > 0 {
+		size := len(keys)
+		if batchSize > 0 && size > int(batchSize) {
+			size = int(batchSize)
+		}
+		if err := r.handler.DeleteValues(typ, keys[:size]); err != nil {
+			log.Errorf("[Store][boltdb] purge %s err: %s", typ, err.Error())
+			return count, err
+		}
+		count += uint32(size)
+		keys = keys[size:]
+	}
+	return count, nil
+}
@@ -0,0 +1,130 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_retentionStore_CleanConfigFileReleaseHistories(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblConfigFileReleaseHistory, func(t *testing.T, handler BoltHandler) {
+		now := time.Now()
+		id := uint64(0)
+		save := func(namespace, fileName string, age time.Duration) {
+			id++
+			assert.NoError(t, handler.SaveValue(tblConfigFileReleaseHistory, strconv.FormatUint(id, 10),
+				&model.ConfigFileReleaseHistory{
+					Id:         id,
+					Namespace:  namespace,
+					Group:      "group",
+					FileName:   fileName,
+					CreateTime: now.Add(-age),
+					ModifyTime: now.Add(-age),
+					Valid:      true,
+				}))
+		}
+		// 每个文件 5 条发布历史，越早发布的越旧
+		for _, ns := range []string{"default", "Polaris"} {
+			for _, file := range []string{"a.yaml", "b.yaml"} {
+				for i := 5; i > 0; i-- {
+					save(ns, file, time.Duration(i)*24*time.Hour)
+				}
+			}
+		}
+
+		s := &retentionStore{handler: handler}
+		// default 命名空间每个文件保留 2 条，其余 3 条都早于 before
+		count, err := s.BatchCleanConfigFileReleaseHistories("default", nil, 2, now, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(6), count)
+
+		// 其他命名空间保留 1 条以及 2.5 天之内的记录，遍历一次后分两个事务清理
+		before := now.Add(-60 * time.Hour)
+		count, err = s.BatchCleanConfigFileReleaseHistories("", []string{"default"}, 1, before, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(6), count)
+		count, err = s.BatchCleanConfigFileReleaseHistories("", []string{"default"}, 1, before, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(0), count)
+
+		values, err := handler.LoadValuesAll(tblConfigFileReleaseHistory, &model.ConfigFileReleaseHistory{})
+		assert.NoError(t, err)
+		assert.Len(t, values, 8)
+		for _, value := range values {
+			history := value.(*model.ConfigFileReleaseHistory)
+			if history.Namespace == "Polaris" {
+				assert.False(t, history.CreateTime.Before(before))
+			}
+		}
+	})
+}
+
+func Test_retentionStore_PurgeDeleted(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblNameService, func(t *testing.T, handler BoltHandler) {
+		now := time.Now()
+		for i, item := range []struct {
+			namespace string
+			valid     bool
+			age       time.Duration
+		}{
+			{namespace: "default", valid: false, age: 48 * time.Hour},
+			{namespace: "default", valid: false, age: time.Hour},
+			{namespace: "default", valid: true, age: 48 * time.Hour},
+			{namespace: "Polaris", valid: false, age: 48 * time.Hour},
+		} {
+			key := strconv.Itoa(i)
+			assert.NoError(t, handler.SaveValue(tblNameService, key, &model.Service{
+				ID: key, Name: key, Namespace: item.namespace, ModifyTime: now.Add(-item.age),
+			}))
+			assert.NoError(t, handler.SaveValue(tblConfigFile, key, &model.ConfigFile{
+				Name: key, Namespace: item.namespace, ModifyTime: now.Add(-item.age),
+			}))
+			if item.valid {
+				continue
+			}
+			// 软删除
+			properties := map[string]interface{}{"Valid": false, "ModifyTime": now.Add(-item.age)}
+			assert.NoError(t, handler.UpdateValue(tblNameService, key, properties))
+			assert.NoError(t, handler.UpdateValue(tblConfigFile, key, properties))
+		}
+
+		s := &retentionStore{handler: handler}
+		before := now.Add(-24 * time.Hour)
+		count, err := s.BatchPurgeDeletedServices("", []string{"Polaris"}, before, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), count)
+		count, err = s.BatchPurgeDeletedConfigFiles("Polaris", nil, before, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), count)
+
+		services, err := handler.LoadValuesAll(tblNameService, &model.Service{})
+		assert.NoError(t, err)
+		assert.Len(t, services, 3)
+		assert.NotContains(t, services, "0")
+		files, err := handler.LoadValuesAll(tblConfigFile, &model.ConfigFile{})
+		assert.NoError(t, err)
+		assert.Len(t, files, 3)
+		assert.NotContains(t, files, "3")
+	})
+}
@@ -249,7 +249,7 @@ func (r *routingStoreV2) GetRoutingConfigV2WithID(id string) (*model.RouterConfi
 
 	boldTx := tx.GetDelegateTx().(*bolt.Tx)
 	defer func() {
-		_ = boldTx.Rollback()
+		_ = rollbackTx(boldTx)
 	}()
 
 	return r.getRoutingConfigV2WithIDTx(boldTx, id)
@@ -20,11 +20,13 @@ package boltdb
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/boltdb/bolt"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"go.uber.org/zap"
 
@@ -64,19 +66,47 @@ const (
 
 // AddService save a service
 func (ss *serviceStore) AddService(s *model.Service) error {
-
-	// 删除之前同名的服务
-	if err := ss.cleanInValidService(s.Name, s.Namespace); err != nil {
-		return err
-	}
-
 	initService(s)
 
 	if s.ID == "" || s.Name == "" || s.Namespace == "" {
 		return store.NewStatusError(store.EmptyParamsErr, "add Service missing some params")
 	}
 
-	err := ss.handler.SaveValue(tblNameService, s.ID, s)
+	// 同名检查与写入放在同一个写事务中，raft 存储下其他节点并发创建同名服务时，
+	// 读取的服务表已被修改，复制命令会因冲突被拒绝并重新执行
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		fields := []string{SvcFieldName, SvcFieldNamespace, SvcFieldValid}
+		olds := make(map[string]interface{})
+		err := loadValuesByFilter(tx, tblNameService, fields, &model.Service{},
+			func(m map[string]interface{}) bool {
+				svcName, _ := m[SvcFieldName].(string)
+				svcNs, _ := m[SvcFieldNamespace].(string)
+				return svcName == s.Name && svcNs == s.Namespace
+			}, olds)
+		if err != nil {
+			return err
+		}
+
+		// 删除之前同名的无效服务
+		invalidIDs := make([]string, 0, len(olds))
+		for id, v := range olds {
+			if id == s.ID {
+				continue
+			}
+			if v.(*model.Service).Valid {
+				return store.NewStatusError(store.DuplicateEntryErr,
+					fmt.Sprintf("service %s in namespace %s already exists", s.Name, s.Namespace))
+			}
+			invalidIDs = append(invalidIDs, id)
+		}
+		if len(invalidIDs) > 0 {
+			if err := deleteValues(tx, tblNameService, invalidIDs); err != nil {
+				log.Errorf("[Store][boltdb] delete invalid service error, %+v", err)
+				return err
+			}
+		}
+		return saveValue(tx, tblNameService, s.ID, s)
+	})
 
 	return store.Error(err)
 }
@@ -898,25 +928,6 @@ func (ss *serviceStore) getServices(serviceFilters, serviceMetas map[string]stri
 	return uint32(totalCount), getRealServicesList(ret, offset, limit), nil
 }
 
-func (ss *serviceStore) cleanInValidService(name, namespace string) error {
-	old, err := ss.getServiceByNameAndNsIgnoreValid(name, namespace)
-
-	if err != nil {
-		return err
-	}
-
-	if old == nil {
-		return nil
-	}
-
-	if err := ss.handler.DeleteValues(tblNameService, []string{old.ID}); err != nil {
-		log.Errorf("[Store][boltdb] delete invalid service error, %+v", err)
-		return err
-	}
-
-	return nil
-}
-
 func (ss *serviceStore) GetServiceByNameAndNamespace(name string, namespace string) ([]*model.Service, error) {
 	return ss.getServiceByNameAndNsCommon(name, namespace, true)
 }
@@ -27,6 +27,7 @@ import (
 
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
 )
 
 const (
@@ -485,6 +486,32 @@ func TestServiceStore_DeleteServiceAlias(t *testing.T) {
 	})
 }
 
+func TestServiceStore_AddServiceDuplicate(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblNameService, func(t *testing.T, handler BoltHandler) {
+		sStore := &serviceStore{handler: handler}
+
+		oldID := utils.NewUUID()
+		err := sStore.AddService(&model.Service{ID: oldID, Name: "dup-svc", Namespace: "dup-ns"})
+		assert.Nil(t, err)
+
+		// 同名的有效服务已经存在，不允许以不同的 ID 再次创建
+		err = sStore.AddService(&model.Service{ID: utils.NewUUID(), Name: "dup-svc", Namespace: "dup-ns"})
+		assert.NotNil(t, err)
+		assert.Equal(t, store.DuplicateEntryErr, store.Code(err))
+
+		// 同名服务被删除后可以重新创建，之前无效的服务会被清理
+		assert.Nil(t, sStore.DeleteService(oldID, "dup-svc", "dup-ns"))
+		newID := utils.NewUUID()
+		err = sStore.AddService(&model.Service{ID: newID, Name: "dup-svc", Namespace: "dup-ns"})
+		assert.Nil(t, err)
+
+		svc, err := sStore.getServiceByNameAndNsIgnoreValid("dup-svc", "dup-ns")
+		assert.Nil(t, err)
+		assert.Equal(t, newID, svc.ID)
+		assert.True(t, svc.Valid)
+	})
+}
+
 func TestServiceStore_DeleteService(t *testing.T) {
 	handler, err := NewBoltHandler(&BoltConfig{FileName: "./table.bolt"})
 	if err != nil {
@@ -0,0 +1,82 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// Snapshot 通过只读事务的 Tx.WriteTo 将一致性快照写入临时文件，再从快照文件读取数据。
+// 运行中的 server 持有数据文件的锁，其他进程无法打开，因此备份需要在 server 进程内执行
+func (m *boltStore) Snapshot() (store.Store, func() error, error) {
+	db := localDB(m.handler)
+	if db == nil {
+		return nil, nil, errors.New("store is not initialized")
+	}
+	dir, err := os.MkdirTemp("", "polaris-bolt-snapshot")
+	if err != nil {
+		return nil, nil, err
+	}
+	file := filepath.Join(dir, "snapshot.bolt")
+	if err := writeSnapshot(db, file); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	view := &boltStore{handler: handler, start: true}
+	if err := view.newStore(); err != nil {
+		_ = handler.Close()
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	release := func() error {
+		err := handler.Close()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return view, release, nil
+}
+
+func writeSnapshot(db *bolt.DB, file string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(f)
+		return err
+	}); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
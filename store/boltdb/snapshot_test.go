@@ -0,0 +1,48 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestWriteSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewBoltHandler(&BoltConfig{FileName: filepath.Join(dir, "origin.bolt")})
+	assert.NoError(t, err)
+	defer func() { _ = handler.Close() }()
+	assert.NoError(t, handler.SaveValue(tblNameNamespace, "ns1", &model.Namespace{Name: "ns1"}))
+
+	// 数据文件被当前进程持有时，通过只读事务导出快照
+	file := filepath.Join(dir, "snapshot.bolt")
+	assert.NoError(t, writeSnapshot(localDB(handler), file))
+	assert.NoError(t, handler.SaveValue(tblNameNamespace, "ns2", &model.Namespace{Name: "ns2"}))
+
+	snapshot, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() { _ = snapshot.Close() }()
+	values, err := snapshot.LoadValues(tblNameNamespace, []string{"ns1", "ns2"}, &model.Namespace{})
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Contains(t, values, "ns1")
+}
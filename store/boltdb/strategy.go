@@ -98,7 +98,7 @@ func (ss *strategyStore) AddStrategy(strategy *model.StrategyDetail) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	return ss.addStrategy(tx, strategy)
@@ -117,7 +117,7 @@ func (ss *strategyStore) addStrategy(tx *bolt.Tx, strategy *model.StrategyDetail
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Strategy] clean invalid auth_strategy tx commit", zap.Error(err),
 			zap.String("name", strategy.Name), zap.String("owner", strategy.Owner))
 		return err
@@ -140,7 +140,7 @@ func (ss *strategyStore) UpdateStrategy(strategy *model.ModifyStrategyDetail) er
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	ret, err := loadStrategyById(tx, strategy.ID)
@@ -176,7 +176,7 @@ func (ss *strategyStore) updateStrategy(tx *bolt.Tx, modify *model.ModifyStrateg
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Strategy] update auth_strategy tx commit", zap.Error(err),
 			zap.String("id", saveVal.ID))
 		return err
@@ -271,7 +271,7 @@ func (ss *strategyStore) operateStrategyResources(remove bool, resources []model
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	resMap := buildResMap(resources)
@@ -293,7 +293,7 @@ func (ss *strategyStore) operateStrategyResources(remove bool, resources []model
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][Strategy] update auth_strategy resource tx commit",
 			zap.Error(err), zap.Bool("remove", remove))
 		return err
@@ -357,7 +357,7 @@ func (ss *strategyStore) GetStrategyDetail(id string) (*model.StrategyDetail, er
 	}
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	return ss.getStrategyDetail(tx, id)
@@ -18,6 +18,8 @@
 package boltdb
 
 import (
+	"sync"
+
 	"github.com/boltdb/bolt"
 
 	"github.com/polarismesh/polaris/store"
@@ -34,13 +36,65 @@ func NewBoltTx(delegateTx *bolt.Tx) store.Tx {
 }
 
 func (t *Tx) Commit() error {
-	return t.delegateTx.Commit()
+	return commitTx(t.delegateTx)
 }
 
 func (t *Tx) Rollback() error {
-	return t.delegateTx.Rollback()
+	return rollbackTx(t.delegateTx)
 }
 
 func (t *Tx) GetDelegateTx() interface{} {
 	return t.delegateTx
 }
+
+// txInterceptor 拦截写事务的提交与回滚，raft 存储借此在提交时把事务内的修改复制到整个集群
+type txInterceptor interface {
+	// markDirty 记录事务内被修改的数据对象
+	markDirty(typ string, keys ...string)
+	// markRead 记录事务内读取的数据对象，keys 为空表示遍历了整张表
+	markRead(typ string, keys ...string)
+	// commit 提交事务
+	commit() error
+	// rollback 回滚事务
+	rollback() error
+}
+
+// txInterceptors 写事务与拦截器的映射，*bolt.Tx -> txInterceptor
+var txInterceptors sync.Map
+
+// commitTx 提交写事务，事务注册了拦截器时交由拦截器完成提交
+func commitTx(tx *bolt.Tx) error {
+	if interceptor, ok := txInterceptors.Load(tx); ok {
+		return interceptor.(txInterceptor).commit()
+	}
+	return tx.Commit()
+}
+
+// rollbackTx 回滚写事务，事务注册了拦截器时交由拦截器完成回滚
+func rollbackTx(tx *bolt.Tx) error {
+	if interceptor, ok := txInterceptors.Load(tx); ok {
+		return interceptor.(txInterceptor).rollback()
+	}
+	return tx.Rollback()
+}
+
+// markObjectsDirty 记录写事务内被修改的数据对象，注册了拦截器的事务交由拦截器记录，
+// 否则在开启了变更日志时追加一条变更记录，raft 存储会在各个节点应用复制命令时记录变更日志
+func markObjectsDirty(tx *bolt.Tx, typ string, keys ...string) error {
+	if interceptor, ok := txInterceptors.Load(tx); ok {
+		interceptor.(txInterceptor).markDirty(typ, keys...)
+		return nil
+	}
+	return appendChangeLog(tx, typ)
+}
+
+// markObjectsRead 记录写事务内读取的数据对象，keys 为空表示遍历了整张表，
+// raft 存储在应用复制命令时会检查这些数据对象是否已经被其他写操作修改
+func markObjectsRead(tx *bolt.Tx, typ string, keys ...string) {
+	if !tx.Writable() {
+		return
+	}
+	if interceptor, ok := txInterceptors.Load(tx); ok {
+		interceptor.(txInterceptor).markRead(typ, keys...)
+	}
+}
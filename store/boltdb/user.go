@@ -63,6 +63,13 @@ const (
 	UserFieldMobile string = "Mobile"
 	// UserFieldEmail 用户邮箱信息
 	UserFieldEmail string = "Email"
+	// UserFieldPasswordHistory 用户历史密码信息
+	UserFieldPasswordHistory string = "PasswordHistory"
+	// UserFieldPasswordModifyTime 用户密码修改时间
+	UserFieldPasswordModifyTime string = "PasswordModifyTime"
+
+	// 历史密码 hash 之间的分隔符，bcrypt 的结果中不会出现该字符
+	passwordHistorySeparator = ","
 )
 
 var (
@@ -96,7 +103,7 @@ func (us *userStore) addUser(user *model.User) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	owner := user.Owner
@@ -116,7 +123,7 @@ func (us *userStore) addUser(user *model.User) error {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][User] save user tx commit fail", zap.Error(err),
 			zap.String("name", user.Name))
 		return err
@@ -146,6 +153,8 @@ func (us *userStore) UpdateUser(user *model.User) error {
 	properties[UserFieldEmail] = user.Email
 	properties[UserFieldMobile] = user.Mobile
 	properties[UserFieldPassword] = user.Password
+	properties[UserFieldPasswordHistory] = strings.Join(user.PasswordHistory, passwordHistorySeparator)
+	properties[UserFieldPasswordModifyTime] = user.PasswordModifyTime
 	properties[UserFieldModifyTime] = time.Now()
 
 	err := us.handler.UpdateValue(tblUser, user.ID, properties)
@@ -174,7 +183,7 @@ func (us *userStore) deleteUser(user *model.User) error {
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	properties := make(map[string]interface{})
@@ -195,7 +204,7 @@ func (us *userStore) deleteUser(user *model.User) error {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := commitTx(tx); err != nil {
 		log.Error("[Store][User] delete user tx commit", zap.Error(err), zap.String("id", user.ID))
 		return err
 	}
@@ -214,7 +223,7 @@ func (us *userStore) GetUser(id string) (*model.User, error) {
 	}
 	tx := proxy.GetDelegateTx().(*bolt.Tx)
 	defer func() {
-		_ = tx.Rollback()
+		_ = rollbackTx(tx)
 	}()
 
 	return us.getUser(tx, id)
@@ -560,10 +569,22 @@ func converToUserStore(user *model.User) *userForStore {
 		ModifyTime:  user.ModifyTime,
 		Email:       user.Email,
 		Mobile:      user.Mobile,
+
+		PasswordHistory:    strings.Join(user.PasswordHistory, passwordHistorySeparator),
+		PasswordModifyTime: user.PasswordModifyTime,
 	}
 }
 
 func converToUserModel(user *userForStore) *model.User {
+	var history []string
+	if user.PasswordHistory != "" {
+		history = strings.Split(user.PasswordHistory, passwordHistorySeparator)
+	}
+	// 兼容老数据，没有记录密码修改时间的用户以创建时间为准
+	pwdModifyTime := user.PasswordModifyTime
+	if pwdModifyTime.Unix() <= 0 {
+		pwdModifyTime = user.CreateTime
+	}
 	return &model.User{
 		ID:          user.ID,
 		Name:        user.Name,
@@ -579,6 +600,9 @@ func converToUserModel(user *userForStore) *model.User {
 		ModifyTime:  user.ModifyTime,
 		Email:       user.Email,
 		Mobile:      user.Mobile,
+
+		PasswordHistory:    history,
+		PasswordModifyTime: pwdModifyTime,
 	}
 }
 
@@ -588,6 +612,9 @@ func initUser(user *model.User) {
 		user.Valid = true
 		user.CreateTime = tn
 		user.ModifyTime = tn
+		if user.PasswordModifyTime.IsZero() {
+			user.PasswordModifyTime = tn
+		}
 	}
 }
 
@@ -606,4 +633,7 @@ type userForStore struct {
 	Comment     string
 	CreateTime  time.Time
 	ModifyTime  time.Time
+
+	PasswordHistory    string
+	PasswordModifyTime time.Time
 }
@@ -73,6 +73,8 @@ func Test_userStore_AddUser(t *testing.T) {
 		users[0].ModifyTime = tn
 		ret.CreateTime = tn
 		ret.ModifyTime = tn
+		users[0].PasswordModifyTime = tn
+		ret.PasswordModifyTime = tn
 
 		if !assert.Equal(t, users[0], ret) {
 			t.FailNow()
@@ -107,6 +109,8 @@ func Test_userStore_UpdateUser(t *testing.T) {
 		users[0].ModifyTime = tn
 		ret.CreateTime = tn
 		ret.ModifyTime = tn
+		users[0].PasswordModifyTime = tn
+		ret.PasswordModifyTime = tn
 
 		if !assert.Equal(t, users[0], ret) {
 			t.FailNow()
@@ -169,6 +173,8 @@ func Test_userStore_GetUserByName(t *testing.T) {
 		users[0].ModifyTime = tn
 		ret.CreateTime = tn
 		ret.ModifyTime = tn
+		users[0].PasswordModifyTime = tn
+		ret.PasswordModifyTime = tn
 
 		if !assert.Equal(t, users[0], ret) {
 			t.FailNow()
@@ -0,0 +1,93 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sort"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	tblWhitelistRule = "whitelist_rule"
+)
+
+// whitelistRuleObject 白名单运行时规则的存储对象
+type whitelistRuleObject struct {
+	ID         string
+	API        string
+	CIDR       string
+	Action     string
+	Comment    string
+	Operator   string
+	CreateTime time.Time
+}
+
+// whitelistStore 白名单运行时规则的存储实现
+type whitelistStore struct {
+	handler BoltHandler
+}
+
+// AddWhitelistRule 新增一条规则
+func (w *whitelistStore) AddWhitelistRule(rule *model.WhitelistRule) error {
+	return w.handler.SaveValue(tblWhitelistRule, rule.ID, &whitelistRuleObject{
+		ID:         rule.ID,
+		API:        rule.API,
+		CIDR:       rule.CIDR,
+		Action:     rule.Action,
+		Comment:    rule.Comment,
+		Operator:   rule.Operator,
+		CreateTime: rule.CreateTime,
+	})
+}
+
+// DeleteWhitelistRule 删除一条规则
+func (w *whitelistStore) DeleteWhitelistRule(id string) error {
+	return w.handler.DeleteValues(tblWhitelistRule, []string{id})
+}
+
+// GetWhitelistRules 查询全部规则，按照创建时间排序
+func (w *whitelistStore) GetWhitelistRules() ([]*model.WhitelistRule, error) {
+	values, err := w.handler.LoadValuesAll(tblWhitelistRule, &whitelistRuleObject{})
+	if err != nil {
+		log.Errorf("[Store][boltdb] get whitelist rules err: %s", err.Error())
+		return nil, err
+	}
+
+	rules := make([]*model.WhitelistRule, 0, len(values))
+	for _, value := range values {
+		obj := value.(*whitelistRuleObject)
+		rules = append(rules, &model.WhitelistRule{
+			ID:         obj.ID,
+			API:        obj.API,
+			CIDR:       obj.CIDR,
+			Action:     obj.Action,
+			Comment:    obj.Comment,
+			Operator:   obj.Operator,
+			CreateTime: obj.CreateTime,
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].CreateTime.Equal(rules[j].CreateTime) {
+			return rules[i].ID < rules[j].ID
+		}
+		return rules[i].CreateTime.Before(rules[j].CreateTime)
+	})
+	return rules, nil
+}
@@ -0,0 +1,67 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestWhitelistStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "whitelist.bolt")
+	handler, err := NewBoltHandler(&BoltConfig{FileName: file})
+	assert.NoError(t, err)
+	defer func() {
+		_ = handler.Close()
+		_ = os.Remove(file)
+	}()
+
+	s := &whitelistStore{handler: handler}
+	start := time.Now()
+	for i, cidr := range []string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"} {
+		assert.NoError(t, s.AddWhitelistRule(&model.WhitelistRule{
+			ID:         "rule-" + string(rune('0'+i)),
+			API:        "/maintain/v1",
+			CIDR:       cidr,
+			Action:     model.WhitelistAllow,
+			Operator:   "polaris",
+			CreateTime: start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	rules, err := s.GetWhitelistRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules, 3)
+	assert.Equal(t, "rule-0", rules[0].ID)
+	assert.Equal(t, "10.0.0.0/8", rules[0].CIDR)
+	assert.Equal(t, "/maintain/v1", rules[0].API)
+	assert.Equal(t, model.WhitelistAllow, rules[0].Action)
+	assert.Equal(t, "fd00::/8", rules[2].CIDR)
+
+	assert.NoError(t, s.DeleteWhitelistRule("rule-1"))
+	rules, err = s.GetWhitelistRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "rule-2", rules[1].ID)
+}
@@ -332,6 +332,41 @@ type ClientStore interface {
 	GetMoreClients(mtime time.Time, firstUpdate bool) (map[string]*model.Client, error)
 }
 
+// DependencyStore 服务依赖关系的存储接口
+type DependencyStore interface {
+	// UpsertServiceDependencies 批量保存依赖关系，已存在的记录更新主调方地址、认证状态以及最近发现时间
+	UpsertServiceDependencies(deps []*model.ServiceDependency) error
+	// GetServiceDependencies 查询依赖关系，按照最近发现时间倒序返回，
+	// filter 支持 caller_namespace、caller_service、caller_host、callee_namespace、callee_service，
+	// 其中 caller_host 匹配最近发现的主调方地址中的任意一个
+	GetServiceDependencies(filter map[string]string, offset, limit uint32) (uint32, []*model.ServiceDependency, error)
+	// BatchCleanServiceDependencies 清理最近发现时间早于 before 的依赖关系，
+	// 每次调用最多清理 batchSize 条数据，返回实际清理的数量
+	BatchCleanServiceDependencies(before time.Time, batchSize uint32) (uint32, error)
+}
+
+// InstanceEventStore 实例事件的存储接口，用于查询服务下实例的变更时间线
+type InstanceEventStore interface {
+	// AddInstanceEvents 批量保存实例事件
+	AddInstanceEvents(events []*model.InstanceEventRecord) error
+	// GetInstanceEvents 查询实例事件，按照事件时间倒序返回
+	GetInstanceEvents(filter *model.InstanceEventFilter, offset, limit uint32) (
+		uint32, []*model.InstanceEventRecord, error)
+	// BatchCleanInstanceEvents 清理事件时间早于 before 的事件以及最新的 keepCount 条之外的事件，
+	// before 为零值时不按时间清理，keepCount 为 0 时不按数量清理，每次调用最多清理 batchSize 条数据，返回实际清理的数量
+	BatchCleanInstanceEvents(before time.Time, keepCount uint32, batchSize uint32) (uint32, error)
+}
+
+// InstanceHeartbeatStore 实例心跳记录的存储接口，用于没有 Redis 的集群通过存储层共享心跳时间
+type InstanceHeartbeatStore interface {
+	// BatchUpdateInstanceHeartbeats 批量写入实例心跳记录，已存在的记录只有在心跳更新时才会被覆盖
+	BatchUpdateInstanceHeartbeats(heartbeats []*model.InstanceHeartbeat) error
+	// GetMoreInstanceHeartbeats 获取修改时间不早于 mtime 的心跳记录，mtime 为零值时返回全部记录
+	GetMoreInstanceHeartbeats(mtime time.Time) ([]*model.InstanceHeartbeat, error)
+	// DeleteInstanceHeartbeat 删除实例的心跳记录
+	DeleteInstanceHeartbeat(instanceID string) error
+}
+
 // RoutingConfigStoreV2 路由配置表的存储接口
 type RoutingConfigStoreV2 interface {
 	// EnableRouting 设置路由规则是否启用
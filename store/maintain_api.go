@@ -48,6 +48,44 @@ type MaintainStore interface {
 	GetUnHealthyInstances(timeout time.Duration, limit uint32) ([]string, error)
 }
 
+// MaintainJobStore 运维任务执行记录的存储接口
+type MaintainJobStore interface {
+	// AddMaintainJobRun 新增一条执行记录
+	AddMaintainJobRun(run *model.MaintainJobRun) error
+	// UpdateMaintainJobRun 更新执行记录的状态、结果以及结束时间
+	UpdateMaintainJobRun(run *model.MaintainJobRun) error
+	// GetMaintainJobRuns 查询执行记录，name 为空时查询全部任务，按照开始时间倒序返回
+	GetMaintainJobRuns(name string, offset, limit uint32) (uint32, []*model.MaintainJobRun, error)
+	// CleanMaintainJobRuns 清理任务的历史执行记录，只保留开始时间最新的 keepCount 条，返回清理的数量
+	CleanMaintainJobRuns(name string, keepCount uint32) (uint32, error)
+}
+
+// RetentionStore 历史数据清理的存储接口，
+// namespace 不为空时只清理该命名空间的数据，为空时清理除 excludes 之外全部命名空间的数据，
+// 每个事务最多清理 batchSize 条数据，返回实际清理的数量
+type RetentionStore interface {
+	// BatchCleanConfigFileReleaseHistories 清理配置文件的发布历史，
+	// 每个配置文件保留最新的 keepCount 条以及创建时间不早于 before 的记录
+	BatchCleanConfigFileReleaseHistories(namespace string, excludes []string, keepCount uint32,
+		before time.Time, batchSize uint32) (uint32, error)
+	// BatchPurgeDeletedServices 物理删除修改时间早于 before 的软删除服务
+	BatchPurgeDeletedServices(namespace string, excludes []string, before time.Time,
+		batchSize uint32) (uint32, error)
+	// BatchPurgeDeletedConfigFiles 物理删除修改时间早于 before 的软删除配置文件
+	BatchPurgeDeletedConfigFiles(namespace string, excludes []string, before time.Time,
+		batchSize uint32) (uint32, error)
+}
+
+// WhitelistStore 白名单运行时规则的存储接口
+type WhitelistStore interface {
+	// AddWhitelistRule 新增一条规则
+	AddWhitelistRule(rule *model.WhitelistRule) error
+	// DeleteWhitelistRule 删除一条规则
+	DeleteWhitelistRule(id string) error
+	// GetWhitelistRules 查询全部规则，按照创建时间排序
+	GetWhitelistRules() ([]*model.WhitelistRule, error)
+}
+
 // LeaderChangeEvent
 type LeaderChangeEvent struct {
 	Key    string
@@ -0,0 +1,478 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// ArchiveVersion 当前备份文件的格式版本
+	ArchiveVersion = 1
+
+	lineHeader = "header"
+	lineRecord = "record"
+	lineFooter = "footer"
+
+	// maxLineSize 备份文件中单行数据的最大长度
+	maxLineSize = 64 * 1024 * 1024
+)
+
+// archiveLine 备份文件中的一行，文件由 header、若干 record 以及 footer 组成，每行一个 json 对象
+type archiveLine struct {
+	Type string `json:"type"`
+
+	// header 字段
+	Version    int       `json:"version,omitempty"`
+	Store      string    `json:"store,omitempty"`
+	CreateTime time.Time `json:"createTime,omitempty"`
+	Resources  []string  `json:"resources,omitempty"`
+
+	// record 字段
+	Resource  string          `json:"resource,omitempty"`
+	Key       string          `json:"key,omitempty"`
+	Namespace string          `json:"namespace,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+
+	// record 以及 footer 字段，record 为 data 的摘要，footer 为全部 record 摘要的摘要
+	Checksum string `json:"checksum,omitempty"`
+	Count    int    `json:"count,omitempty"`
+}
+
+// BackupOptions 备份参数
+type BackupOptions struct {
+	// Resources 需要备份的资源，为空时备份全部资源
+	Resources []string
+	// Output 备份过程的输出
+	Output io.Writer
+}
+
+// RestoreOptions 恢复参数
+type RestoreOptions struct {
+	// Namespace 只恢复该命名空间下的数据，为空时恢复全部数据
+	Namespace string
+	// Resources 需要恢复的资源，为空时恢复备份文件中的全部资源
+	Resources []string
+	// BatchSize 每批写入的数据条数
+	BatchSize int
+	// SkipExisting 目标存储中已经存在备份中的数据时跳过这些数据，未开启时恢复失败且不写入任何数据
+	SkipExisting bool
+	// Output 恢复过程的输出
+	Output io.Writer
+}
+
+// Backup 将存储中的全部数据导出到备份文件，文件名以 .gz 结尾时使用 gzip 压缩
+func Backup(s store.Store, path string, opts BackupOptions) ([]*Stat, error) {
+	// 先写入临时文件，全部导出成功后再重命名，避免留下不完整的备份文件
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+	}()
+
+	var w io.Writer = file
+	var zw *gzip.Writer
+	if isGzip(path) {
+		zw = gzip.NewWriter(file)
+		w = zw
+	}
+	bw := bufio.NewWriter(w)
+	stats, err := BackupTo(s, bw, opts)
+	if err != nil {
+		return stats, err
+	}
+	if err := bw.Flush(); err != nil {
+		return stats, err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return stats, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return stats, err
+	}
+	if err := file.Close(); err != nil {
+		return stats, err
+	}
+	return stats, os.Rename(tmp, path)
+}
+
+// BackupTo 将存储中的全部数据以备份文件的格式写入 w。
+// 存储支持一致性快照时，全部数据从同一个快照中读取，避免备份过程中的写入导致数据之间不一致
+func BackupTo(s store.Store, w io.Writer, opts BackupOptions) ([]*Stat, error) {
+	selected, err := selectResources(opts.Resources)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if ss, ok := s.(store.SnapshotStore); ok {
+		view, release, err := ss.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("create snapshot of %s fail: %w", s.Name(), err)
+		}
+		defer func() { _ = release() }()
+		s = view
+	} else {
+		_, _ = fmt.Fprintf(opts.Output, "store %s does not support snapshot, the backup may be inconsistent "+
+			"if the data is modified during the backup\n", s.Name())
+	}
+
+	aw := &archiveWriter{w: w, digest: sha256.New()}
+	names := make([]string, 0, len(selected))
+	for i := range selected {
+		names = append(names, selected[i].name)
+	}
+	if err := aw.writeLine(&archiveLine{
+		Type:       lineHeader,
+		Version:    ArchiveVersion,
+		Store:      s.Name(),
+		CreateTime: time.Now(),
+		Resources:  names,
+	}); err != nil {
+		return nil, err
+	}
+
+	stats := make([]*Stat, 0, len(selected))
+	// 只关联了服务 ID 的数据，通过服务确定其所属的命名空间
+	serviceNamespaces := map[string]string{}
+	for _, res := range selected {
+		items, err := res.list(s)
+		if err != nil {
+			return stats, fmt.Errorf("list %s fail: %w", res.name, err)
+		}
+		for _, it := range items {
+			namespace := it.namespace
+			if res.name == "service" || res.name == "service_alias" {
+				serviceNamespaces[it.key] = namespace
+			}
+			if namespace == "" && it.serviceID != "" {
+				namespace = serviceNamespaces[it.serviceID]
+			}
+			if err := aw.writeRecord(res, it, namespace); err != nil {
+				return stats, fmt.Errorf("write %s %s fail: %w", res.name, it.key, err)
+			}
+		}
+		stats = append(stats, &Stat{Resource: res.name, Total: len(items), Written: len(items)})
+		_, _ = fmt.Fprintf(opts.Output, "[%s] %d records exported\n", res.name, len(items))
+	}
+
+	if err := aw.writeLine(&archiveLine{
+		Type:     lineFooter,
+		Count:    aw.count,
+		Checksum: hex.EncodeToString(aw.digest.Sum(nil)),
+	}); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// Restore 将备份文件中的数据导入到存储中，导入前会先校验整个备份文件的完整性。
+// 恢复的目标应当是一个新初始化的存储，除了存储初始化时创建的内置数据之外，备份中的数据已经存在时恢复失败，
+// 开启 SkipExisting 时跳过已经存在的数据，已经存在的数据不会被覆盖
+func Restore(s store.Store, path string, opts RestoreOptions) ([]*Stat, error) {
+	selected, err := selectResources(opts.Resources)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	wanted := make(map[string]*resource, len(selected))
+	for i := range selected {
+		wanted[selected[i].name] = selected[i]
+	}
+
+	// 校验整个备份文件的同时检查目标存储中已经存在的数据，存在冲突时不写入任何数据
+	conflicts := map[string]int{}
+	if _, err := readArchive(path, func(line *archiveLine) error {
+		res := wanted[line.Resource]
+		if res == nil || res.exist == nil || (opts.Namespace != "" && line.Namespace != opts.Namespace) {
+			return nil
+		}
+		it, err := decodeItem(res, line)
+		if err != nil {
+			return err
+		}
+		exist, err := res.exist(s, it)
+		if err != nil {
+			return err
+		}
+		if exist && (res.builtin == nil || !res.builtin(it)) {
+			conflicts[res.name]++
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && !opts.SkipExisting {
+		return nil, fmt.Errorf("target store is not empty, records already exist: %s, "+
+			"enable skip existing to keep the existing records and restore the others", formatConflicts(conflicts))
+	}
+
+	var (
+		stats   = make([]*Stat, 0, len(selected))
+		current *Stat
+		res     *resource
+		batch   = make([]*item, 0, opts.BatchSize)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := res.write(s, batch); err != nil {
+			return fmt.Errorf("restore %s fail: %w", res.name, err)
+		}
+		current.Written += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	header, err := readArchive(path, func(line *archiveLine) error {
+		if res == nil || line.Resource != res.name {
+			if err := flush(); err != nil {
+				return err
+			}
+			if current != nil {
+				_, _ = fmt.Fprintf(opts.Output, "[%s] %d records restored\n", current.Resource, current.Written)
+			}
+			res, current = wanted[line.Resource], nil
+			if res == nil {
+				return nil
+			}
+			current = &Stat{Resource: res.name}
+			stats = append(stats, current)
+		}
+		if res == nil {
+			return nil
+		}
+		// 按照命名空间恢复时，只恢复属于该命名空间的数据，全局数据不做恢复
+		if opts.Namespace != "" && line.Namespace != opts.Namespace {
+			return nil
+		}
+		current.Total++
+		it, err := decodeItem(res, line)
+		if err != nil {
+			return err
+		}
+		if res.exist != nil {
+			exist, err := res.exist(s, it)
+			if err != nil {
+				return err
+			}
+			if exist {
+				current.Skipped++
+				return nil
+			}
+		}
+		batch = append(batch, it)
+		if len(batch) >= opts.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+	if current != nil {
+		_, _ = fmt.Fprintf(opts.Output, "[%s] %d records restored\n", current.Resource, current.Written)
+	}
+	_, _ = fmt.Fprintf(opts.Output, "restore from backup of %s created at %s\n", header.Store,
+		header.CreateTime.Format(time.RFC3339))
+	return stats, nil
+}
+
+func decodeItem(res *resource, line *archiveLine) (*item, error) {
+	value, err := res.decode(line.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s %s fail: %w", line.Resource, line.Key, err)
+	}
+	return &item{key: line.Key, value: value, namespace: line.Namespace}, nil
+}
+
+// formatConflicts 按照资源顺序输出已经存在的数据条数
+func formatConflicts(conflicts map[string]int) string {
+	parts := make([]string, 0, len(conflicts))
+	for i := range resources {
+		if count, ok := conflicts[resources[i].name]; ok {
+			parts = append(parts, fmt.Sprintf("%d %s", count, resources[i].name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// archiveWriter 写入备份文件并计算摘要
+type archiveWriter struct {
+	w      io.Writer
+	digest hash.Hash
+	count  int
+}
+
+func (aw *archiveWriter) writeRecord(res *resource, it *item, namespace string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if res.encode != nil {
+		data, err = res.encode(it.value)
+	} else {
+		data, err = json.Marshal(it.value)
+	}
+	if err != nil {
+		return err
+	}
+	checksum := sha256Hex(data)
+	if err := aw.writeLine(&archiveLine{
+		Type:      lineRecord,
+		Resource:  res.name,
+		Key:       it.key,
+		Namespace: namespace,
+		Data:      data,
+		Checksum:  checksum,
+	}); err != nil {
+		return err
+	}
+	_, _ = aw.digest.Write([]byte(checksum))
+	aw.count++
+	return nil
+}
+
+func (aw *archiveWriter) writeLine(line *archiveLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, err := aw.w.Write(data); err != nil {
+		return err
+	}
+	_, err = aw.w.Write([]byte{'\n'})
+	return err
+}
+
+// readArchive 顺序读取备份文件中的 record 并校验摘要，返回备份文件的 header
+func readArchive(path string, handle func(line *archiveLine) error) (*archiveLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var r io.Reader = file
+	if isGzip(path) {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var (
+		header *archiveLine
+		footer *archiveLine
+		digest = sha256.New()
+		count  int
+		lineNo int
+	)
+	for scanner.Scan() {
+		lineNo++
+		line := &archiveLine{}
+		if err := json.Unmarshal(scanner.Bytes(), line); err != nil {
+			return nil, fmt.Errorf("invalid archive line %d: %w", lineNo, err)
+		}
+		if footer != nil {
+			return nil, fmt.Errorf("invalid archive line %d: data after footer", lineNo)
+		}
+		switch line.Type {
+		case lineHeader:
+			if header != nil {
+				return nil, fmt.Errorf("invalid archive line %d: duplicate header", lineNo)
+			}
+			if line.Version > ArchiveVersion {
+				return nil, fmt.Errorf("archive version %d is newer than supported version %d",
+					line.Version, ArchiveVersion)
+			}
+			header = line
+		case lineRecord:
+			if header == nil {
+				return nil, fmt.Errorf("invalid archive line %d: missing header", lineNo)
+			}
+			if sha256Hex(line.Data) != line.Checksum {
+				return nil, fmt.Errorf("invalid archive line %d: checksum mismatch of %s %s",
+					lineNo, line.Resource, line.Key)
+			}
+			_, _ = digest.Write([]byte(line.Checksum))
+			count++
+			if err := handle(line); err != nil {
+				return nil, err
+			}
+		case lineFooter:
+			footer = line
+		default:
+			return nil, fmt.Errorf("invalid archive line %d: unknown type %s", lineNo, line.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("invalid archive: missing header")
+	}
+	if footer == nil {
+		return nil, errors.New("invalid archive: missing footer, the archive may be truncated")
+	}
+	if footer.Count != count || footer.Checksum != hex.EncodeToString(digest.Sum(nil)) {
+		return nil, fmt.Errorf("invalid archive: expect %d records, got %d, or checksum mismatch", footer.Count, count)
+	}
+	return header, nil
+}
+
+func isGzip(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
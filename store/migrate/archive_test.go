@@ -0,0 +1,159 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+func prepareArchive(t *testing.T, name string) string {
+	source := prepareSource()
+	source.namespaces["test"] = &model.Namespace{Name: "test", Valid: true}
+	source.services["svc-4"] = &model.Service{ID: "svc-4", Name: "svc-4", Namespace: "test", Revision: "svc-4",
+		Valid: true}
+
+	path := filepath.Join(t.TempDir(), name)
+	stats, err := Backup(source, path, BackupOptions{
+		Resources: []string{"namespace", "service", "service_alias"},
+		Output:    io.Discard,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(stats))
+	assert.Equal(t, 2, stats[0].Total)
+	assert.Equal(t, 4, stats[1].Total)
+	assert.Equal(t, 1, stats[2].Total)
+	return path
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	for _, name := range []string{"polaris.backup", "polaris.backup.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := prepareArchive(t, name)
+
+			target := newMemStore("target")
+			target.namespaces["default"] = &model.Namespace{Name: "default", Valid: true}
+			stats, err := Restore(target, path, RestoreOptions{Output: io.Discard})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, len(stats))
+			assert.Equal(t, 1, stats[0].Skipped)
+			assert.Equal(t, 1, stats[0].Written)
+			assert.Equal(t, 2, len(target.namespaces))
+			assert.Equal(t, 5, len(target.services))
+			assert.Equal(t, "svc-1", target.services["alias-1"].Reference)
+		})
+	}
+}
+
+func TestRestore_Namespace(t *testing.T) {
+	path := prepareArchive(t, "polaris.backup")
+
+	target := newMemStore("target")
+	_, err := Restore(target, path, RestoreOptions{Namespace: "test", Output: io.Discard})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(target.namespaces))
+	assert.NotNil(t, target.namespaces["test"])
+	assert.Equal(t, 1, len(target.services))
+	assert.NotNil(t, target.services["svc-4"])
+}
+
+func TestRestore_Corrupted(t *testing.T) {
+	path := prepareArchive(t, "polaris.backup")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// 修改数据内容后校验失败
+	corrupted := filepath.Join(t.TempDir(), "corrupted")
+	content := strings.Replace(string(data), `"Name":"svc-2"`, `"Name":"svc-x"`, 1)
+	assert.NoError(t, os.WriteFile(corrupted, []byte(content), 0600))
+	target := newMemStore("target")
+	_, err = Restore(target, corrupted, RestoreOptions{Output: io.Discard})
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(target.namespaces))
+
+	// 文件被截断时校验失败
+	truncated := filepath.Join(t.TempDir(), "truncated")
+	content = strings.Join(lines[:len(lines)-2], "\n")
+	assert.NoError(t, os.WriteFile(truncated, []byte(content), 0600))
+	_, err = Restore(target, truncated, RestoreOptions{Output: io.Discard})
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(target.namespaces))
+}
+
+// snapshotMemStore 支持一致性快照的内存存储，快照为创建时数据的拷贝
+type snapshotMemStore struct {
+	*memStore
+	released int
+}
+
+func (m *snapshotMemStore) Snapshot() (store.Store, func() error, error) {
+	view := newMemStore(m.name)
+	for name, ns := range m.namespaces {
+		view.namespaces[name] = ns
+	}
+	for id, svc := range m.services {
+		view.services[id] = svc
+	}
+	return view, func() error {
+		m.released++
+		return nil
+	}, nil
+}
+
+func TestBackup_Snapshot(t *testing.T) {
+	source := &snapshotMemStore{memStore: prepareSource()}
+	path := filepath.Join(t.TempDir(), "polaris.backup")
+	stats, err := Backup(source, path, BackupOptions{
+		Resources: []string{"namespace", "service"},
+		Output:    io.Discard,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, source.released)
+	assert.Equal(t, 1, stats[0].Total)
+	assert.Equal(t, 3, stats[1].Total)
+}
+
+func TestRestore_NotEmpty(t *testing.T) {
+	path := prepareArchive(t, "polaris.backup")
+
+	// 目标存储中已经存在备份中的非内置数据时，恢复失败且不写入任何数据
+	target := newMemStore("target")
+	target.namespaces["test"] = &model.Namespace{Name: "test", Valid: true}
+	_, err := Restore(target, path, RestoreOptions{Output: io.Discard})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 namespace")
+	assert.Equal(t, 1, len(target.namespaces))
+	assert.Equal(t, 0, len(target.services))
+
+	// 开启跳过已经存在的数据后，只恢复不存在的数据
+	stats, err := Restore(target, path, RestoreOptions{SkipExisting: true, Output: io.Discard})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[0].Skipped)
+	assert.Equal(t, 1, stats[0].Written)
+	assert.Equal(t, 2, len(target.namespaces))
+	assert.Equal(t, 5, len(target.services))
+}
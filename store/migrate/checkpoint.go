@@ -0,0 +1,88 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// checkpoint 迁移进度，记录每类资源最后一批写入成功的数据 key，用于断点续传
+type checkpoint struct {
+	path     string
+	Source   string            `json:"source"`
+	Target   string            `json:"target"`
+	Progress map[string]string `json:"progress"`
+}
+
+// loadCheckpoint 加载迁移进度，文件不存在时返回一个空的进度
+func loadCheckpoint(path, source, target string) (*checkpoint, error) {
+	cp := &checkpoint{
+		path:     path,
+		Source:   source,
+		Target:   target,
+		Progress: map[string]string{},
+	}
+	if path == "" {
+		return cp, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := &checkpoint{}
+	if err := json.Unmarshal(data, saved); err != nil {
+		return nil, fmt.Errorf("parse checkpoint file %s fail: %w", path, err)
+	}
+	if saved.Source != source || saved.Target != target {
+		return nil, fmt.Errorf("checkpoint file %s belongs to migration %s -> %s, not %s -> %s",
+			path, saved.Source, saved.Target, source, target)
+	}
+	if saved.Progress != nil {
+		cp.Progress = saved.Progress
+	}
+	return cp, nil
+}
+
+// done 判断数据是否在之前的迁移中已经写入
+func (c *checkpoint) done(resource, key string) bool {
+	last, ok := c.Progress[resource]
+	return ok && key <= last
+}
+
+// save 更新资源的迁移位点并持久化，先写临时文件再重命名，避免进程退出时文件损坏
+func (c *checkpoint) save(resource, key string) error {
+	c.Progress[resource] = key
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
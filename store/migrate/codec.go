@@ -0,0 +1,102 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"encoding/json"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// instanceRecord 实例在备份文件中的结构，Proto 使用 protobuf 的 json 格式编码
+type instanceRecord struct {
+	Proto             json.RawMessage `json:"proto"`
+	ServiceID         string          `json:"serviceId"`
+	ServicePlatformID string          `json:"servicePlatformId"`
+	Valid             bool            `json:"valid"`
+	ModifyTime        time.Time       `json:"modifyTime"`
+}
+
+// jsonDecoder 创建一个 json 解码函数
+func jsonDecoder(newValue func() interface{}) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		v := newValue()
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func encodeInstance(v interface{}) ([]byte, error) {
+	ins := v.(*model.Instance)
+	proto, err := protojson.Marshal(ins.Proto)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&instanceRecord{
+		Proto:             proto,
+		ServiceID:         ins.ServiceID,
+		ServicePlatformID: ins.ServicePlatformID,
+		Valid:             ins.Valid,
+		ModifyTime:        ins.ModifyTime,
+	})
+}
+
+func decodeInstance(data []byte) (interface{}, error) {
+	record := &instanceRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	proto := &apiservice.Instance{}
+	if err := protojson.Unmarshal(record.Proto, proto); err != nil {
+		return nil, err
+	}
+	return &model.Instance{
+		Proto:             proto,
+		ServiceID:         record.ServiceID,
+		ServicePlatformID: record.ServicePlatformID,
+		Valid:             record.Valid,
+		ModifyTime:        record.ModifyTime,
+	}, nil
+}
+
+// encodeRateLimit 规则内容已经保存在 Rule 字段中，Proto 由 Rule 解析得到，无需备份
+func encodeRateLimit(v interface{}) ([]byte, error) {
+	rule := *v.(*model.RateLimit)
+	rule.Proto = nil
+	return json.Marshal(&rule)
+}
+
+// encodeCircuitBreakerRule 规则内容已经保存在 Rule 字段中，Proto 由 Rule 解析得到，无需备份
+func encodeCircuitBreakerRule(v interface{}) ([]byte, error) {
+	rule := *v.(*model.CircuitBreakerRule)
+	rule.Proto = nil
+	return json.Marshal(&rule)
+}
+
+// encodeFaultDetectRule 规则内容已经保存在 Rule 字段中，Proto 由 Rule 解析得到，无需备份
+func encodeFaultDetectRule(v interface{}) ([]byte, error) {
+	rule := *v.(*model.FaultDetectRule)
+	rule.Proto = nil
+	return json.Marshal(&rule)
+}
@@ -0,0 +1,252 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// DefaultBatchSize 默认每批写入的数据条数
+	DefaultBatchSize = 100
+)
+
+// Options 迁移参数
+type Options struct {
+	// DryRun 只统计需要迁移的数据，不写入目标存储
+	DryRun bool
+	// BatchSize 每批写入的数据条数，每批写入成功后更新断点
+	BatchSize int
+	// CheckpointFile 断点文件路径，为空时不支持断点续传
+	CheckpointFile string
+	// Resources 需要迁移的资源，为空时迁移全部资源
+	Resources []string
+	// Output 迁移过程的输出
+	Output io.Writer
+}
+
+// Stat 单类资源的迁移统计
+type Stat struct {
+	Resource string
+	// Total 源存储中的数据总数
+	Total int
+	// Resumed 断点之前已经迁移的数据
+	Resumed int
+	// Skipped 目标存储中已经存在的数据
+	Skipped int
+	// Written 本次写入的数据，dry-run 时表示需要写入的数据
+	Written int
+}
+
+// VerifyResult 单类资源的校验结果
+type VerifyResult struct {
+	Resource    string
+	SourceCount int
+	TargetCount int
+	// Missing 目标存储中缺失的数据
+	Missing []string
+	// Mismatch 版本不一致的数据
+	Mismatch []string
+}
+
+// Passed 是否校验通过
+func (r *VerifyResult) Passed() bool {
+	return r.TargetCount >= r.SourceCount && len(r.Missing) == 0 && len(r.Mismatch) == 0
+}
+
+// Migrator 在两个存储之间迁移数据
+type Migrator struct {
+	source    store.Store
+	target    store.Store
+	opts      Options
+	resources []*resource
+}
+
+// NewMigrator 创建迁移对象
+func NewMigrator(source, target store.Store, opts Options) (*Migrator, error) {
+	if source == nil || target == nil {
+		return nil, errors.New("source and target store must not be empty")
+	}
+	if source.Name() == target.Name() {
+		return nil, fmt.Errorf("source and target store are the same plugin %s", source.Name())
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	selected, err := selectResources(opts.Resources)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		source:    source,
+		target:    target,
+		opts:      opts,
+		resources: selected,
+	}, nil
+}
+
+// selectResources 按照迁移顺序筛选出需要迁移的资源
+func selectResources(names []string) ([]*resource, error) {
+	if len(names) == 0 {
+		return resources, nil
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+	selected := make([]*resource, 0, len(names))
+	for i := range resources {
+		if _, ok := wanted[resources[i].name]; ok {
+			selected = append(selected, resources[i])
+			delete(wanted, resources[i].name)
+		}
+	}
+	if len(wanted) != 0 {
+		unknown := make([]string, 0, len(wanted))
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown resources %v, supported resources are %v", unknown, ResourceNames())
+	}
+	return selected, nil
+}
+
+// Run 执行迁移
+func (m *Migrator) Run() ([]*Stat, error) {
+	cp, err := loadCheckpoint(m.opts.CheckpointFile, m.source.Name(), m.target.Name())
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*Stat, 0, len(m.resources))
+	for _, res := range m.resources {
+		stat, err := m.migrate(res, cp)
+		if stat != nil {
+			stats = append(stats, stat)
+		}
+		if err != nil {
+			return stats, fmt.Errorf("migrate %s fail: %w", res.name, err)
+		}
+	}
+	return stats, nil
+}
+
+func (m *Migrator) migrate(res *resource, cp *checkpoint) (*Stat, error) {
+	items, err := res.list(m.source)
+	if err != nil {
+		return nil, err
+	}
+	stat := &Stat{Resource: res.name, Total: len(items)}
+	m.printf("[%s] %d records in %s\n", res.name, len(items), m.source.Name())
+
+	batch := make([]*item, 0, m.opts.BatchSize)
+	for i := 0; i < len(items); i += m.opts.BatchSize {
+		end := i + m.opts.BatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batch = batch[:0]
+		for _, it := range items[i:end] {
+			if cp.done(res.name, it.key) {
+				stat.Resumed++
+				continue
+			}
+			if res.exist != nil {
+				exist, err := res.exist(m.target, it)
+				if err != nil {
+					return stat, err
+				}
+				if exist {
+					stat.Skipped++
+					continue
+				}
+			}
+			batch = append(batch, it)
+		}
+		stat.Written += len(batch)
+		if m.opts.DryRun {
+			continue
+		}
+		if len(batch) != 0 {
+			if err := res.write(m.target, batch); err != nil {
+				return stat, err
+			}
+		}
+		if err := cp.save(res.name, items[end-1].key); err != nil {
+			return stat, err
+		}
+		m.printf("[%s] %d/%d records processed\n", res.name, end, len(items))
+	}
+	return stat, nil
+}
+
+// Verify 比对源存储与目标存储中各类资源的数量以及版本
+func (m *Migrator) Verify() ([]*VerifyResult, error) {
+	results := make([]*VerifyResult, 0, len(m.resources))
+	for _, res := range m.resources {
+		sourceItems, err := res.list(m.source)
+		if err != nil {
+			return results, fmt.Errorf("list %s from %s fail: %w", res.name, m.source.Name(), err)
+		}
+		targetItems, err := res.list(m.target)
+		if err != nil {
+			return results, fmt.Errorf("list %s from %s fail: %w", res.name, m.target.Name(), err)
+		}
+		results = append(results, compareItems(res, sourceItems, targetItems))
+	}
+	return results, nil
+}
+
+func compareItems(res *resource, sourceItems, targetItems []*item) *VerifyResult {
+	ret := &VerifyResult{
+		Resource:    res.name,
+		SourceCount: len(sourceItems),
+		TargetCount: len(targetItems),
+	}
+	if res.countOnly {
+		return ret
+	}
+	revisions := make(map[string]string, len(targetItems))
+	for _, it := range targetItems {
+		revisions[it.key] = it.revision
+	}
+	for _, it := range sourceItems {
+		revision, ok := revisions[it.key]
+		if !ok {
+			ret.Missing = append(ret.Missing, it.key)
+			continue
+		}
+		if it.revision != "" && it.revision != revision {
+			ret.Mismatch = append(ret.Mismatch, it.key)
+		}
+	}
+	return ret
+}
+
+func (m *Migrator) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(m.opts.Output, format, args...)
+}
@@ -0,0 +1,265 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// memStore 仅实现命名空间以及服务相关接口的内存存储
+type memStore struct {
+	store.Store
+	name       string
+	namespaces map[string]*model.Namespace
+	services   map[string]*model.Service
+	histories  []*model.ConfigFileReleaseHistory
+	// failService 写入该服务时返回错误
+	failService string
+	// failHistory 写入该名称的发布历史时返回错误
+	failHistory string
+}
+
+func newMemStore(name string) *memStore {
+	return &memStore{
+		name:       name,
+		namespaces: map[string]*model.Namespace{},
+		services:   map[string]*model.Service{},
+	}
+}
+
+func (m *memStore) Name() string {
+	return m.name
+}
+
+func (m *memStore) GetMoreNamespaces(mtime time.Time) ([]*model.Namespace, error) {
+	ret := make([]*model.Namespace, 0, len(m.namespaces))
+	for _, ns := range m.namespaces {
+		ret = append(ret, ns)
+	}
+	return ret, nil
+}
+
+func (m *memStore) GetNamespace(name string) (*model.Namespace, error) {
+	return m.namespaces[name], nil
+}
+
+func (m *memStore) AddNamespace(namespace *model.Namespace) error {
+	m.namespaces[namespace.Name] = namespace
+	return nil
+}
+
+func (m *memStore) GetMoreServices(mtime time.Time, firstUpdate, disableBusiness, needMeta bool) (
+	map[string]*model.Service, error) {
+	return m.services, nil
+}
+
+func (m *memStore) GetServiceByID(id string) (*model.Service, error) {
+	return m.services[id], nil
+}
+
+func (m *memStore) AddService(service *model.Service) error {
+	if service.ID == m.failService {
+		return errors.New("mock add service error")
+	}
+	m.services[service.ID] = service
+	return nil
+}
+
+func (m *memStore) QueryConfigFileReleaseHistories(namespace, group, fileName string, offset, limit uint32,
+	endId uint64) (uint32, []*model.ConfigFileReleaseHistory, error) {
+	var ret []*model.ConfigFileReleaseHistory
+	for _, history := range m.histories {
+		if (namespace == "" || history.Namespace == namespace) && (group == "" || history.Group == group) &&
+			(fileName == "" || history.FileName == fileName) {
+			ret = append(ret, history)
+		}
+	}
+	total := uint32(len(ret))
+	if offset >= total {
+		return total, nil, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return total, ret[offset:end], nil
+}
+
+func (m *memStore) CreateConfigFileReleaseHistory(tx store.Tx, history *model.ConfigFileReleaseHistory) error {
+	if history.Name == m.failHistory {
+		return errors.New("mock create config file release history error")
+	}
+	// 目标存储重新生成 ID
+	saved := *history
+	saved.Id = uint64(len(m.histories) + 1)
+	m.histories = append(m.histories, &saved)
+	return nil
+}
+
+func prepareSource() *memStore {
+	source := newMemStore("source")
+	source.namespaces["default"] = &model.Namespace{Name: "default", Valid: true}
+	source.namespaces["deleted"] = &model.Namespace{Name: "deleted", Valid: false}
+	for _, id := range []string{"svc-1", "svc-2", "svc-3"} {
+		source.services[id] = &model.Service{ID: id, Name: id, Namespace: "default", Revision: id, Valid: true}
+	}
+	source.services["alias-1"] = &model.Service{ID: "alias-1", Name: "alias-1", Namespace: "default",
+		Reference: "svc-1", Revision: "alias-1", Valid: true}
+	return source
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	source := prepareSource()
+	target := newMemStore("target")
+	m, err := NewMigrator(source, target, Options{
+		DryRun:    true,
+		Resources: []string{"service", "namespace"},
+		Output:    io.Discard,
+	})
+	assert.NoError(t, err)
+
+	stats, err := m.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(stats))
+	assert.Equal(t, "namespace", stats[0].Resource)
+	assert.Equal(t, 1, stats[0].Written)
+	assert.Equal(t, "service", stats[1].Resource)
+	assert.Equal(t, 3, stats[1].Written)
+	assert.Equal(t, 0, len(target.namespaces))
+	assert.Equal(t, 0, len(target.services))
+}
+
+func TestMigrator_RunAndVerify(t *testing.T) {
+	source := prepareSource()
+	target := newMemStore("target")
+	target.namespaces["default"] = &model.Namespace{Name: "default", Valid: true}
+	m, err := NewMigrator(source, target, Options{
+		Resources: []string{"namespace", "service", "service_alias"},
+		Output:    io.Discard,
+	})
+	assert.NoError(t, err)
+
+	stats, err := m.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[0].Skipped)
+	assert.Equal(t, 3, stats[1].Written)
+	assert.Equal(t, 1, stats[2].Written)
+	assert.Equal(t, 4, len(target.services))
+
+	results, err := m.Verify()
+	assert.NoError(t, err)
+	for _, ret := range results {
+		assert.True(t, ret.Passed(), ret.Resource)
+	}
+
+	changed := *target.services["svc-2"]
+	changed.Revision = "changed"
+	target.services["svc-2"] = &changed
+	delete(target.services, "svc-3")
+	results, err = m.Verify()
+	assert.NoError(t, err)
+	assert.False(t, results[1].Passed())
+	assert.Equal(t, []string{"svc-3"}, results[1].Missing)
+	assert.Equal(t, []string{"svc-2"}, results[1].Mismatch)
+}
+
+func TestMigrator_Resume(t *testing.T) {
+	source := prepareSource()
+	target := newMemStore("target")
+	target.failService = "svc-2"
+	opts := Options{
+		BatchSize:      1,
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoint"),
+		Resources:      []string{"service"},
+		Output:         io.Discard,
+	}
+	m, err := NewMigrator(source, target, opts)
+	assert.NoError(t, err)
+	_, err = m.Run()
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(target.services))
+
+	// 删除目标存储中已写入的数据，验证断点之前的数据不会重新写入
+	target.services = map[string]*model.Service{}
+	target.failService = ""
+	m, err = NewMigrator(source, target, opts)
+	assert.NoError(t, err)
+	stats, err := m.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[0].Resumed)
+	assert.Equal(t, 2, stats[0].Written)
+	assert.Equal(t, 2, len(target.services))
+
+	// 断点文件不能用于其他的迁移任务
+	m, err = NewMigrator(source, newMemStore("other"), opts)
+	assert.NoError(t, err)
+	_, err = m.Run()
+	assert.Error(t, err)
+}
+
+func TestMigrator_ResumeConfigFileReleaseHistory(t *testing.T) {
+	source := newMemStore("source")
+	for i, name := range []string{"a-001", "a-002", "a-003"} {
+		source.histories = append(source.histories, &model.ConfigFileReleaseHistory{
+			Id: uint64(i + 10), Name: name, Namespace: "default", Group: "group", FileName: "a", Md5: name,
+		})
+	}
+	target := newMemStore("target")
+	target.failHistory = "a-002"
+	opts := Options{
+		BatchSize:      2,
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoint"),
+		Resources:      []string{"config_file_release_history"},
+		Output:         io.Discard,
+	}
+	m, err := NewMigrator(source, target, opts)
+	assert.NoError(t, err)
+	_, err = m.Run()
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(target.histories))
+
+	// 写入一半的批次没有记录断点，续传时跳过已经写入的发布历史
+	target.failHistory = ""
+	m, err = NewMigrator(source, target, opts)
+	assert.NoError(t, err)
+	stats, err := m.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[0].Skipped)
+	assert.Equal(t, 2, stats[0].Written)
+	assert.Equal(t, 3, len(target.histories))
+	for i, history := range target.histories {
+		assert.Equal(t, source.histories[i].Name, history.Name)
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	_, err := NewMigrator(newMemStore("same"), newMemStore("same"), Options{})
+	assert.Error(t, err)
+
+	_, err = NewMigrator(newMemStore("source"), newMemStore("target"), Options{Resources: []string{"unknown"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,793 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// listPageSize 分页读取数据时每页的大小
+	listPageSize = 100
+)
+
+var (
+	// zeroTime 读取全量数据时使用的起始时间
+	zeroTime = time.Unix(0, 0)
+	// builtinNamespaces 存储初始化时创建的命名空间
+	builtinNamespaces = map[string]struct{}{
+		"default": {},
+		"Polaris": {},
+	}
+)
+
+// item 待迁移的一条数据
+type item struct {
+	// key 数据在存储中的唯一标识，同时作为断点续传的位点
+	key string
+	// revision 数据的版本，用于迁移后的一致性校验，为空时不做版本比对
+	revision string
+	// value 数据对象
+	value interface{}
+	// namespace 数据所属的命名空间，为空时表示全局数据
+	namespace string
+	// serviceID 数据所属的服务，用于确定只关联了服务的数据所属的命名空间
+	serviceID string
+}
+
+// resource 描述一类需要迁移的数据，资源之间按照依赖关系排序
+type resource struct {
+	// name 资源名称
+	name string
+	// list 从存储中读取全部有效数据
+	list func(s store.Store) ([]*item, error)
+	// exist 判断数据在目标存储中是否已经存在，为空时仅依赖断点续传保证不重复写入
+	exist func(s store.Store, it *item) (bool, error)
+	// builtin 数据是否为存储初始化时创建的内置数据，恢复到新初始化的存储时这些数据已经存在，不视为冲突
+	builtin func(it *item) bool
+	// write 将一批数据写入目标存储
+	write func(s store.Store, items []*item) error
+	// countOnly 数据在目标存储中会重新生成 ID，校验时只比对数量
+	countOnly bool
+	// encode 将数据编码为备份文件中的内容，为空时直接使用 json 编码
+	encode func(v interface{}) ([]byte, error)
+	// decode 将备份文件中的内容解码为数据对象
+	decode func(data []byte) (interface{}, error)
+}
+
+// configFileWithTags 配置文件以及其关联的标签
+type configFileWithTags struct {
+	File *model.ConfigFile      `json:"file"`
+	Tags []*model.ConfigFileTag `json:"tags"`
+}
+
+// resources 全部支持迁移的资源，写入顺序需要满足资源之间的依赖关系
+var resources = []*resource{
+	{
+		name: "namespace",
+		list: listNamespaces,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetNamespace(it.key)
+			return ret != nil, err
+		},
+		builtin: func(it *item) bool {
+			_, ok := builtinNamespaces[it.key]
+			return ok
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddNamespace(v.(*model.Namespace))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.Namespace{} }),
+	},
+	{
+		name: "service",
+		list: func(s store.Store) ([]*item, error) {
+			return listServices(s, false)
+		},
+		exist:   existService,
+		builtin: builtinService,
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddService(v.(*model.Service))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.Service{} }),
+	},
+	{
+		name: "service_alias",
+		list: func(s store.Store) ([]*item, error) {
+			return listServices(s, true)
+		},
+		exist: existService,
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddService(v.(*model.Service))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.Service{} }),
+	},
+	{
+		name: "instance",
+		list: listInstances,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetInstance(it.key)
+			return ret != nil, err
+		},
+		write: func(s store.Store, items []*item) error {
+			instances := make([]*model.Instance, 0, len(items))
+			for i := range items {
+				instances = append(instances, items[i].value.(*model.Instance))
+			}
+			return s.BatchAddInstances(instances)
+		},
+		encode: encodeInstance,
+		decode: decodeInstance,
+	},
+	{
+		name: "routing_config",
+		list: listRoutingConfigs,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetRoutingConfigWithID(it.key)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateRoutingConfig(v.(*model.RoutingConfig))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.RoutingConfig{} }),
+	},
+	{
+		name: "router_config_v2",
+		list: listRouterConfigs,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetRoutingConfigV2WithID(it.key)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateRoutingConfigV2(v.(*model.RouterConfig))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.RouterConfig{} }),
+	},
+	{
+		name: "ratelimit_config",
+		list: listRateLimits,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetRateLimitWithID(it.key)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateRateLimit(v.(*model.RateLimit))
+		}),
+		encode: encodeRateLimit,
+		decode: jsonDecoder(func() interface{} { return &model.RateLimit{} }),
+	},
+	{
+		name: "circuitbreaker_rule",
+		list: listCircuitBreakerRules,
+		exist: func(s store.Store, it *item) (bool, error) {
+			return s.HasCircuitBreakerRule(it.key)
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateCircuitBreakerRule(v.(*model.CircuitBreakerRule))
+		}),
+		encode: encodeCircuitBreakerRule,
+		decode: jsonDecoder(func() interface{} { return &model.CircuitBreakerRule{} }),
+	},
+	{
+		name: "fault_detect_rule",
+		list: listFaultDetectRules,
+		exist: func(s store.Store, it *item) (bool, error) {
+			return s.HasFaultDetectRule(it.key)
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateFaultDetectRule(v.(*model.FaultDetectRule))
+		}),
+		encode: encodeFaultDetectRule,
+		decode: jsonDecoder(func() interface{} { return &model.FaultDetectRule{} }),
+	},
+	{
+		name: "user",
+		list: listUsers,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetUser(it.key)
+			return ret != nil, err
+		},
+		// 主账户在存储初始化时创建
+		builtin: func(it *item) bool {
+			return it.value.(*model.User).Type == model.OwnerUserRole
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddUser(v.(*model.User))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.User{} }),
+	},
+	{
+		name: "user_group",
+		list: listGroups,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetGroup(it.key)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddGroup(v.(*model.UserGroupDetail))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.UserGroupDetail{} }),
+	},
+	{
+		name: "auth_strategy",
+		list: func(s store.Store) ([]*item, error) {
+			return listStrategies(s, false)
+		},
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetStrategyDetail(it.key)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.AddStrategy(v.(*model.StrategyDetail))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.StrategyDetail{} }),
+	},
+	{
+		// 默认策略在创建用户、用户组时由存储层自动生成，ID 与源存储不同，因此按照 principal 合并资源
+		name: "default_auth_strategy",
+		list: func(s store.Store) ([]*item, error) {
+			return listStrategies(s, true)
+		},
+		exist: existDefaultStrategy,
+		// 默认策略合并到目标存储中已有的默认策略
+		builtin: func(it *item) bool { return true },
+		write:   writeEach(mergeDefaultStrategy),
+		decode:  jsonDecoder(func() interface{} { return &model.StrategyDetail{} }),
+	},
+	{
+		name: "config_file_group",
+		list: listConfigFileGroups,
+		exist: func(s store.Store, it *item) (bool, error) {
+			group := it.value.(*model.ConfigFileGroup)
+			ret, err := s.GetConfigFileGroup(group.Namespace, group.Name)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			_, err := s.CreateConfigFileGroup(v.(*model.ConfigFileGroup))
+			return err
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.ConfigFileGroup{} }),
+	},
+	{
+		name: "config_file",
+		list: listConfigFiles,
+		exist: func(s store.Store, it *item) (bool, error) {
+			file := it.value.(*configFileWithTags).File
+			ret, err := s.GetConfigFile(nil, file.Namespace, file.Group, file.Name)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			data := v.(*configFileWithTags)
+			if _, err := s.CreateConfigFile(nil, data.File); err != nil {
+				return err
+			}
+			for i := range data.Tags {
+				if err := s.CreateConfigFileTag(nil, data.Tags[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		decode: jsonDecoder(func() interface{} { return &configFileWithTags{} }),
+	},
+	{
+		name: "config_file_release",
+		list: listConfigFileReleases,
+		exist: func(s store.Store, it *item) (bool, error) {
+			release := it.value.(*model.ConfigFileRelease)
+			ret, err := s.GetConfigFileReleaseWithAllFlag(nil, release.Namespace, release.Group, release.FileName)
+			return ret != nil, err
+		},
+		write: writeEach(func(s store.Store, v interface{}) error {
+			_, err := s.CreateConfigFileRelease(nil, v.(*model.ConfigFileRelease))
+			return err
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.ConfigFileRelease{} }),
+	},
+	{
+		name:      "config_file_release_history",
+		list:      listConfigFileReleaseHistories,
+		countOnly: true,
+		exist:     existConfigFileReleaseHistory,
+		write: writeEach(func(s store.Store, v interface{}) error {
+			return s.CreateConfigFileReleaseHistory(nil, v.(*model.ConfigFileReleaseHistory))
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.ConfigFileReleaseHistory{} }),
+	},
+	{
+		name: "config_file_template",
+		list: listConfigFileTemplates,
+		exist: func(s store.Store, it *item) (bool, error) {
+			ret, err := s.GetConfigFileTemplate(it.key)
+			return ret != nil, err
+		},
+		// 配置模板在数据库初始化脚本中内置
+		builtin: func(it *item) bool { return true },
+		write: writeEach(func(s store.Store, v interface{}) error {
+			_, err := s.CreateConfigFileTemplate(v.(*model.ConfigFileTemplate))
+			return err
+		}),
+		decode: jsonDecoder(func() interface{} { return &model.ConfigFileTemplate{} }),
+	},
+}
+
+// ResourceNames 返回全部支持迁移的资源名称，按照迁移顺序排列
+func ResourceNames() []string {
+	names := make([]string, 0, len(resources))
+	for i := range resources {
+		names = append(names, resources[i].name)
+	}
+	return names
+}
+
+// writeEach 逐条写入数据
+func writeEach(handle func(s store.Store, v interface{}) error) func(s store.Store, items []*item) error {
+	return func(s store.Store, items []*item) error {
+		for i := range items {
+			if err := handle(s, items[i].value); err != nil {
+				return fmt.Errorf("write %s fail: %w", items[i].key, err)
+			}
+		}
+		return nil
+	}
+}
+
+// sortItems 按照 key 排序，保证断点续传时的位点有序
+func sortItems(items []*item) []*item {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].key < items[j].key
+	})
+	return items
+}
+
+func listNamespaces(s store.Store) ([]*item, error) {
+	namespaces, err := s.GetMoreNamespaces(zeroTime)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(namespaces))
+	for i := range namespaces {
+		if !namespaces[i].Valid {
+			continue
+		}
+		items = append(items, &item{key: namespaces[i].Name, value: namespaces[i], namespace: namespaces[i].Name})
+	}
+	return sortItems(items), nil
+}
+
+func listServices(s store.Store, alias bool) ([]*item, error) {
+	services, err := s.GetMoreServices(zeroTime, true, false, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(services))
+	for _, svc := range services {
+		if !svc.Valid || (svc.Reference != "") != alias {
+			continue
+		}
+		items = append(items, &item{key: svc.ID, revision: svc.Revision, value: svc, namespace: svc.Namespace})
+	}
+	return sortItems(items), nil
+}
+
+func existService(s store.Store, it *item) (bool, error) {
+	ret, err := s.GetServiceByID(it.key)
+	return ret != nil, err
+}
+
+// builtinService 内置命名空间 Polaris 下的服务在存储初始化时创建
+func builtinService(it *item) bool {
+	return it.namespace == "Polaris"
+}
+
+func listInstances(s store.Store) ([]*item, error) {
+	instances, err := s.GetMoreInstances(zeroTime, true, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(instances))
+	for _, ins := range instances {
+		if !ins.Valid || ins.Proto == nil {
+			continue
+		}
+		items = append(items, &item{
+			key:       ins.ID(),
+			revision:  ins.Revision(),
+			value:     ins,
+			namespace: ins.Proto.GetNamespace().GetValue(),
+			serviceID: ins.ServiceID,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listRoutingConfigs(s store.Store) ([]*item, error) {
+	rules, err := s.GetRoutingConfigsForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Valid {
+			continue
+		}
+		// 路由规则的 ID 即为服务 ID
+		items = append(items, &item{
+			key:       rules[i].ID,
+			revision:  rules[i].Revision,
+			value:     rules[i],
+			serviceID: rules[i].ID,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listRouterConfigs(s store.Store) ([]*item, error) {
+	rules, err := s.GetRoutingConfigsV2ForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Valid {
+			continue
+		}
+		items = append(items, &item{
+			key:       rules[i].ID,
+			revision:  rules[i].Revision,
+			value:     rules[i],
+			namespace: rules[i].Namespace,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listRateLimits(s store.Store) ([]*item, error) {
+	rules, _, err := s.GetRateLimitsForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Valid {
+			continue
+		}
+		items = append(items, &item{
+			key:       rules[i].ID,
+			revision:  rules[i].Revision,
+			value:     rules[i],
+			serviceID: rules[i].ServiceID,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listCircuitBreakerRules(s store.Store) ([]*item, error) {
+	rules, err := s.GetCircuitBreakerRulesForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Valid {
+			continue
+		}
+		items = append(items, &item{
+			key:       rules[i].ID,
+			revision:  rules[i].Revision,
+			value:     rules[i],
+			namespace: rules[i].Namespace,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listFaultDetectRules(s store.Store) ([]*item, error) {
+	rules, err := s.GetFaultDetectRulesForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Valid {
+			continue
+		}
+		items = append(items, &item{
+			key:       rules[i].ID,
+			revision:  rules[i].Revision,
+			value:     rules[i],
+			namespace: rules[i].Namespace,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listUsers(s store.Store) ([]*item, error) {
+	users, err := s.GetUsersForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(users))
+	for i := range users {
+		if !users[i].Valid {
+			continue
+		}
+		items = append(items, &item{key: users[i].ID, value: users[i]})
+	}
+	return sortItems(items), nil
+}
+
+func listGroups(s store.Store) ([]*item, error) {
+	groups, err := s.GetGroupsForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(groups))
+	for i := range groups {
+		if groups[i].UserGroup == nil || !groups[i].Valid {
+			continue
+		}
+		items = append(items, &item{key: groups[i].ID, value: groups[i]})
+	}
+	return sortItems(items), nil
+}
+
+func listStrategies(s store.Store, defaultStrategy bool) ([]*item, error) {
+	strategies, err := s.GetStrategyDetailsForCache(zeroTime, true)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(strategies))
+	for i := range strategies {
+		strategy := strategies[i]
+		if !strategy.Valid || strategy.Default != defaultStrategy {
+			continue
+		}
+		key := strategy.ID
+		if defaultStrategy {
+			if len(strategy.Principals) == 0 {
+				continue
+			}
+			key = principalKey(strategy.Principals[0])
+		}
+		items = append(items, &item{key: key, revision: strategyFingerprint(strategy), value: strategy})
+	}
+	return sortItems(items), nil
+}
+
+// principalKey 默认策略按照其唯一的 principal 进行标识
+func principalKey(principal model.Principal) string {
+	return fmt.Sprintf("%d/%s", principal.PrincipalRole, principal.PrincipalID)
+}
+
+// strategyFingerprint 策略的 revision 在不同存储中不一致，这里根据策略内容计算指纹用于校验
+func strategyFingerprint(strategy *model.StrategyDetail) string {
+	parts := make([]string, 0, len(strategy.Principals)+len(strategy.Resources)+1)
+	parts = append(parts, strategy.Action)
+	if !strategy.Default {
+		for i := range strategy.Principals {
+			parts = append(parts, "p:"+principalKey(strategy.Principals[i]))
+		}
+	}
+	for i := range strategy.Resources {
+		parts = append(parts, fmt.Sprintf("r:%d/%s", strategy.Resources[i].ResType, strategy.Resources[i].ResID))
+	}
+	sort.Strings(parts[1:])
+	return hashString(strings.Join(parts, ","))
+}
+
+func existDefaultStrategy(s store.Store, it *item) (bool, error) {
+	source := it.value.(*model.StrategyDetail)
+	principal := source.Principals[0]
+	target, err := s.GetDefaultStrategyDetailByPrincipal(principal.PrincipalID, principal.PrincipalRole)
+	if err != nil || target == nil {
+		return false, err
+	}
+	exists := make(map[string]struct{}, len(target.Resources))
+	for i := range target.Resources {
+		exists[fmt.Sprintf("%d/%s", target.Resources[i].ResType, target.Resources[i].ResID)] = struct{}{}
+	}
+	for i := range source.Resources {
+		if _, ok := exists[fmt.Sprintf("%d/%s", source.Resources[i].ResType, source.Resources[i].ResID)]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// mergeDefaultStrategy 将源存储中默认策略的资源合并到目标存储中对应 principal 的默认策略
+func mergeDefaultStrategy(s store.Store, v interface{}) error {
+	source := v.(*model.StrategyDetail)
+	principal := source.Principals[0]
+	target, err := s.GetDefaultStrategyDetailByPrincipal(principal.PrincipalID, principal.PrincipalRole)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return fmt.Errorf("default strategy of principal %s not found", principalKey(principal))
+	}
+	if len(source.Resources) == 0 {
+		return nil
+	}
+	resources := make([]model.StrategyResource, 0, len(source.Resources))
+	for i := range source.Resources {
+		resources = append(resources, model.StrategyResource{
+			StrategyID: target.ID,
+			ResType:    source.Resources[i].ResType,
+			ResID:      source.Resources[i].ResID,
+		})
+	}
+	return s.UpdateStrategy(&model.ModifyStrategyDetail{
+		ID:           target.ID,
+		Name:         target.Name,
+		Action:       target.Action,
+		Comment:      target.Comment,
+		AddResources: resources,
+		ModifyTime:   time.Now(),
+	})
+}
+
+func listConfigFileGroups(s store.Store) ([]*item, error) {
+	items := make([]*item, 0, listPageSize)
+	for offset := uint32(0); ; offset += listPageSize {
+		_, groups, err := s.QueryConfigFileGroups("", "", offset, listPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range groups {
+			key := fmt.Sprintf("%s/%s", groups[i].Namespace, groups[i].Name)
+			items = append(items, &item{key: key, value: groups[i], namespace: groups[i].Namespace})
+		}
+		if len(groups) < listPageSize {
+			break
+		}
+	}
+	return sortItems(items), nil
+}
+
+func listConfigFiles(s store.Store) ([]*item, error) {
+	items := make([]*item, 0, listPageSize)
+	for offset := uint32(0); ; offset += listPageSize {
+		_, files, err := s.QueryConfigFiles("", "", "", offset, listPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range files {
+			file := files[i]
+			tags, err := s.QueryTagByConfigFile(file.Namespace, file.Group, file.Name)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, &item{
+				key:       fmt.Sprintf("%s/%s/%s", file.Namespace, file.Group, file.Name),
+				revision:  configFileFingerprint(file, tags),
+				value:     &configFileWithTags{File: file, Tags: tags},
+				namespace: file.Namespace,
+			})
+		}
+		if len(files) < listPageSize {
+			break
+		}
+	}
+	return sortItems(items), nil
+}
+
+// configFileFingerprint 根据配置内容以及标签计算指纹用于校验
+func configFileFingerprint(file *model.ConfigFile, tags []*model.ConfigFileTag) string {
+	parts := make([]string, 0, len(tags))
+	for i := range tags {
+		parts = append(parts, tags[i].Key+"="+tags[i].Value)
+	}
+	sort.Strings(parts)
+	return hashString(file.Content + "\n" + strings.Join(parts, ","))
+}
+
+func listConfigFileReleases(s store.Store) ([]*item, error) {
+	releases, err := s.FindConfigFileReleaseByModifyTimeAfter(zeroTime)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(releases))
+	for i := range releases {
+		release := releases[i]
+		// 已删除的发布记录只用于通知客户端，无需迁移
+		if release.Flag != 0 {
+			continue
+		}
+		items = append(items, &item{
+			key:       fmt.Sprintf("%s/%s/%s", release.Namespace, release.Group, release.FileName),
+			revision:  fmt.Sprintf("%d@%s", release.Version, release.Md5),
+			value:     release,
+			namespace: release.Namespace,
+		})
+	}
+	return sortItems(items), nil
+}
+
+func listConfigFileReleaseHistories(s store.Store) ([]*item, error) {
+	items := make([]*item, 0, listPageSize)
+	for offset := uint32(0); ; offset += listPageSize {
+		_, histories, err := s.QueryConfigFileReleaseHistories("", "", "", offset, listPageSize, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := range histories {
+			// 使用定长的 ID 作为 key，按照发布的先后顺序写入
+			items = append(items, &item{
+				key:       fmt.Sprintf("%020d", histories[i].Id),
+				value:     histories[i],
+				namespace: histories[i].Namespace,
+			})
+		}
+		if len(histories) < listPageSize {
+			break
+		}
+	}
+	return sortItems(items), nil
+}
+
+// existConfigFileReleaseHistory 发布历史在目标存储中会重新生成 ID 以及时间，
+// 按照同一配置文件下发布名称、内容以及发布人等信息都相同的记录判断是否已经写入
+func existConfigFileReleaseHistory(s store.Store, it *item) (bool, error) {
+	history := it.value.(*model.ConfigFileReleaseHistory)
+	for offset := uint32(0); ; offset += listPageSize {
+		_, histories, err := s.QueryConfigFileReleaseHistories(history.Namespace, history.Group, history.FileName,
+			offset, listPageSize, 0)
+		if err != nil {
+			return false, err
+		}
+		for _, saved := range histories {
+			if sameReleaseHistory(saved, history) {
+				return true, nil
+			}
+		}
+		if len(histories) < listPageSize {
+			return false, nil
+		}
+	}
+}
+
+func sameReleaseHistory(a, b *model.ConfigFileReleaseHistory) bool {
+	return a.Name == b.Name && a.Md5 == b.Md5 && a.Format == b.Format && a.Tags == b.Tags && a.Type == b.Type &&
+		a.Status == b.Status && a.Comment == b.Comment && a.CreateBy == b.CreateBy && a.ModifyBy == b.ModifyBy
+}
+
+func listConfigFileTemplates(s store.Store) ([]*item, error) {
+	templates, err := s.QueryAllConfigFileTemplates()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*item, 0, len(templates))
+	for i := range templates {
+		items = append(items, &item{key: templates[i].Name, value: templates[i]})
+	}
+	return sortItems(items), nil
+}
+
+func hashString(s string) string {
+	h := md5.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
@@ -24,12 +24,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/polarismesh/polaris/common/tracing"
 	"github.com/polarismesh/polaris/plugin"
 )
 
 // db抛出的异常，需要重试的字符串组
 var errMsg = []string{"Deadlock", "bad connection", "invalid connection"}
 
+// dbSystem 链路数据中的数据库类型
+const dbSystem = "mysql"
+
 // BaseDB 对sql.DB的封装
 type BaseDB struct {
 	*sql.DB
@@ -51,6 +55,11 @@ type dbConfig struct {
 	txIsolationLevel int
 }
 
+// dsn 数据库连接串，密码需要已经经过解析
+func (c *dbConfig) dsn() string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s", c.dbUser, c.dbPwd, c.dbAddr, c.dbName)
+}
+
 // NewBaseDB 新建一个BaseDB
 func NewBaseDB(cfg *dbConfig, parsePwd plugin.ParsePassword) (*BaseDB, error) {
 	baseDb := &BaseDB{cfg: cfg, parsePwd: parsePwd}
@@ -80,9 +89,7 @@ func (b *BaseDB) openDatabase() error {
 		c.dbPwd = pwd
 	}
 
-	dns := fmt.Sprintf("%s:%s@tcp(%s)/%s", c.dbUser, c.dbPwd, c.dbAddr, c.dbName)
-
-	db, err := sql.Open(c.dbType, dns)
+	db, err := sql.Open(c.dbType, c.dsn())
 	if err != nil {
 		log.Errorf("[Store][database] sql open err: %s", err.Error())
 		return err
@@ -112,10 +119,12 @@ func (b *BaseDB) openDatabase() error {
 func (b *BaseDB) Exec(query string, args ...interface{}) (sql.Result, error) {
 	var result sql.Result
 	var err error
+	span := tracing.StartStoreSpan(dbSystem, "exec", tracing.Statement(query))
 	Retry("exec "+query, func() error {
 		result, err = b.DB.Exec(query, args...)
 		return err
 	})
+	tracing.EndSpan(span, err)
 
 	return result, err
 }
@@ -124,14 +133,24 @@ func (b *BaseDB) Exec(query string, args ...interface{}) (sql.Result, error) {
 func (b *BaseDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	var rows *sql.Rows
 	var err error
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
 	Retry("query "+query, func() error {
 		rows, err = b.DB.Query(query, args...)
 		return err
 	})
+	tracing.EndSpan(span, err)
 
 	return rows, err
 }
 
+// QueryRow 重写db.QueryRow函数
+func (b *BaseDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	row := b.DB.QueryRow(query, args...)
+	tracing.EndSpan(span, row.Err())
+	return row
+}
+
 // Begin 重写db.Begin
 func (b *BaseDB) Begin() (*BaseTx, error) {
 	var tx *sql.Tx
@@ -153,6 +172,38 @@ type BaseTx struct {
 	*sql.Tx
 }
 
+// Exec 重写tx.Exec函数
+func (t *BaseTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	span := tracing.StartStoreSpan(dbSystem, "exec", tracing.Statement(query))
+	result, err := t.Tx.Exec(query, args...)
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+// Query 重写tx.Query函数
+func (t *BaseTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	rows, err := t.Tx.Query(query, args...)
+	tracing.EndSpan(span, err)
+	return rows, err
+}
+
+// QueryRow 重写tx.QueryRow函数
+func (t *BaseTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	row := t.Tx.QueryRow(query, args...)
+	tracing.EndSpan(span, row.Err())
+	return row
+}
+
+// Commit 重写tx.Commit函数
+func (t *BaseTx) Commit() error {
+	span := tracing.StartStoreSpan(dbSystem, "commit")
+	err := t.Tx.Commit()
+	tracing.EndSpan(span, err)
+	return err
+}
+
 // Retry 重试主函数
 // 最多重试20次，每次等待5ms*重试次数
 func Retry(label string, handle func() error) {
@@ -0,0 +1,224 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	optionChangeLog = "changeLog"
+
+	changeLogTriggerPrefix = "polaris_change_log_"
+
+	// errTriggerExists 其他节点已经创建了同名触发器
+	errTriggerExists = 1359
+)
+
+// changeLogTable 需要记录变更日志的数据表以及对应的资源类型，
+// changedColumns 不为空时只有这些字段发生变化的更新才记录变更
+type changeLogTable struct {
+	table          string
+	resource       model.ChangeResource
+	changedColumns []string
+}
+
+// changeLogTables 缓存都是基于这些表的 mtime 增量拉取，关联表的修改会同时更新这些表的 mtime。
+// 实例表只记录注册、注销、隔离、权重以及健康状态的变化，其他属性的修改都会更新 revision，
+// 只更新 mtime 的写入不记录变更；client 表随每次上报更新，不创建触发器，对应的缓存继续定时轮询
+var changeLogTables = []changeLogTable{
+	{table: "namespace", resource: model.ChangeResourceNamespace},
+	{table: "service", resource: model.ChangeResourceService},
+	{table: "instance", resource: model.ChangeResourceInstance,
+		changedColumns: []string{"flag", "revision", "isolate", "weight", "health_status"}},
+	{table: "routing_config", resource: model.ChangeResourceRouting},
+	{table: "routing_config_v2", resource: model.ChangeResourceRouting},
+	{table: "ratelimit_config", resource: model.ChangeResourceRateLimit},
+	{table: "circuitbreaker_rule_v2", resource: model.ChangeResourceCircuitBreaker},
+	{table: "fault_detect_rule", resource: model.ChangeResourceFaultDetect},
+	{table: "user", resource: model.ChangeResourceUser},
+	{table: "user_group", resource: model.ChangeResourceUser},
+	{table: "auth_strategy", resource: model.ChangeResourceAuthStrategy},
+	{table: "config_file_release", resource: model.ChangeResourceConfigFile},
+}
+
+var changeLogEvents = []string{"insert", "update", "delete"}
+
+// filtered 该事件的触发器是否只在部分字段变化时记录变更
+func (t changeLogTable) filtered(event string) bool {
+	return event == "update" && len(t.changedColumns) > 0
+}
+
+// triggerName 按字段过滤的触发器使用单独的名称，旧版本无条件记录的同名触发器会被删除
+func (t changeLogTable) triggerName(event string) string {
+	name := fmt.Sprintf("%s%s_%s", changeLogTriggerPrefix, t.table, event)
+	if t.filtered(event) {
+		name += "_changed"
+	}
+	return name
+}
+
+func (t changeLogTable) triggerSQL(event string) string {
+	body := fmt.Sprintf("INSERT INTO change_log (resource) VALUES ('%s')", t.resource)
+	if t.filtered(event) {
+		conds := make([]string, 0, len(t.changedColumns))
+		for _, column := range t.changedColumns {
+			conds = append(conds, fmt.Sprintf("NOT (NEW.`%s` <=> OLD.`%s`)", column, column))
+		}
+		body = "IF " + strings.Join(conds, " OR ") + " THEN " + body + "; END IF"
+	}
+	return fmt.Sprintf("CREATE TRIGGER `%s` AFTER %s ON `%s` FOR EACH ROW %s", t.triggerName(event), event,
+		t.table, body)
+}
+
+// ensureChangeLogTriggers 创建缺失的变更日志触发器，并删除不再需要的旧触发器，
+// 写入数据的同时由数据库追加变更记录，这样集群内的所有节点以及直接修改数据库的工具都会记录变更
+func ensureChangeLogTriggers(db *BaseDB) error {
+	rows, err := db.Query("select trigger_name from information_schema.triggers where trigger_schema = database()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	exists := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		exists[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	expected := map[string]bool{}
+	for _, item := range changeLogTables {
+		for _, event := range changeLogEvents {
+			name := item.triggerName(event)
+			expected[name] = true
+			if exists[name] {
+				continue
+			}
+			_, err := db.Exec(item.triggerSQL(event))
+			var mysqlErr *mysql.MySQLError
+			if err != nil && !(errors.As(err, &mysqlErr) && mysqlErr.Number == errTriggerExists) {
+				return err
+			}
+			log.Infof("[Store][database] create change log trigger %s", name)
+		}
+	}
+	for name := range exists {
+		if !strings.HasPrefix(name, changeLogTriggerPrefix) || expected[name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS `%s`", name)); err != nil {
+			return err
+		}
+		log.Infof("[Store][database] drop change log trigger %s", name)
+	}
+	return nil
+}
+
+// setupChangeLog 按照配置开启变更日志，创建触发器失败时只记录日志，缓存会继续使用定时轮询
+func setupChangeLog(db *BaseDB, option map[string]interface{}) bool {
+	if enable, _ := option[optionChangeLog].(bool); !enable {
+		return false
+	}
+	if err := ensureChangeLogTriggers(db); err != nil {
+		log.Errorf("[Store][database] create change log triggers err: %s, please check the trigger privilege",
+			err.Error())
+		return false
+	}
+	return true
+}
+
+// changeLogStore 变更日志的存储实现
+type changeLogStore struct {
+	master  *BaseDB
+	slave   *BaseDB
+	enabled bool
+}
+
+// ChangeLogEnabled 是否开启了变更日志
+func (c *changeLogStore) ChangeLogEnabled() bool {
+	return c.enabled
+}
+
+// GetLatestChangeSeq 获取当前最新的变更序号
+func (c *changeLogStore) GetLatestChangeSeq() (uint64, error) {
+	var seq uint64
+	err := c.slave.QueryRow("select IFNULL(max(seq), 0) from change_log").Scan(&seq)
+	if err != nil {
+		log.Errorf("[Store][database] get latest change seq err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	return seq, nil
+}
+
+// GetMoreChangeLogs 获取序号大于 seq 的变更记录，和缓存数据一样从只读库读取，保证读到变更时数据已经可见
+func (c *changeLogStore) GetMoreChangeLogs(seq uint64, limit int) ([]*model.ChangeLog, error) {
+	rows, err := c.slave.Query("select seq, resource, UNIX_TIMESTAMP(ctime) from change_log "+
+		"where seq > ? order by seq limit ?", seq, limit)
+	if err != nil {
+		log.Errorf("[Store][database] get more change logs err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var logs []*model.ChangeLog
+	for rows.Next() {
+		var (
+			item  = &model.ChangeLog{}
+			ctime int64
+		)
+		if err := rows.Scan(&item.Seq, &item.Resource, &ctime); err != nil {
+			log.Errorf("[Store][database] fetch change log rows err: %s", err.Error())
+			return nil, store.Error(err)
+		}
+		item.CreateTime = time.Unix(ctime, 0)
+		logs = append(logs, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch change log rows next err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	return logs, nil
+}
+
+// CleanChangeLogs 清理创建时间早于 retention 之前的变更记录
+func (c *changeLogStore) CleanChangeLogs(retention time.Duration) (uint64, error) {
+	result, err := c.master.Exec("delete from change_log where ctime < "+
+		"FROM_UNIXTIME(UNIX_TIMESTAMP(SYSDATE()) - ?)", int64(retention.Seconds()))
+	if err != nil {
+		log.Errorf("[Store][database] clean change logs err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	return uint64(count), nil
+}
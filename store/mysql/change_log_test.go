@@ -0,0 +1,82 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_ensureChangeLogTriggers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"trigger_name"})
+	for _, item := range changeLogTables {
+		for _, event := range changeLogEvents {
+			if item.table == "service" && event == "update" {
+				continue
+			}
+			if item.table == "user" && event == "delete" {
+				continue
+			}
+			rows.AddRow(item.triggerName(event))
+		}
+	}
+	// 旧版本创建的无条件记录的实例表触发器需要删除，用户自己的触发器保持不变
+	rows.AddRow("polaris_change_log_instance_update")
+	rows.AddRow("custom_instance_update")
+	mock.ExpectQuery("select trigger_name from information_schema.triggers where trigger_schema = database()").
+		WillReturnRows(rows)
+	mock.ExpectExec(changeLogTable{table: "service", resource: model.ChangeResourceService}.triggerSQL("update")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// 其他节点已经创建了同名触发器
+	mock.ExpectExec(changeLogTable{table: "user", resource: model.ChangeResourceUser}.triggerSQL("delete")).
+		WillReturnError(&mysql.MySQLError{Number: errTriggerExists, Message: "Trigger already exists"})
+	mock.ExpectExec("DROP TRIGGER IF EXISTS `polaris_change_log_instance_update`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, ensureChangeLogTriggers(&BaseDB{DB: db}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_changeLogTriggerSQL(t *testing.T) {
+	service := changeLogTable{table: "service", resource: model.ChangeResourceService}
+	assert.Equal(t, "CREATE TRIGGER `polaris_change_log_service_insert` AFTER insert ON `service` "+
+		"FOR EACH ROW INSERT INTO change_log (resource) VALUES ('service')",
+		service.triggerSQL("insert"))
+
+	// 只更新 mtime 的实例写入不记录变更
+	instance := changeLogTable{table: "instance", resource: model.ChangeResourceInstance,
+		changedColumns: []string{"flag", "health_status"}}
+	assert.Equal(t, "CREATE TRIGGER `polaris_change_log_instance_update_changed` AFTER update ON `instance` "+
+		"FOR EACH ROW IF NOT (NEW.`flag` <=> OLD.`flag`) OR NOT (NEW.`health_status` <=> OLD.`health_status`) "+
+		"THEN INSERT INTO change_log (resource) VALUES ('instance'); END IF",
+		instance.triggerSQL("update"))
+	assert.Equal(t, "CREATE TRIGGER `polaris_change_log_instance_delete` AFTER delete ON `instance` "+
+		"FOR EACH ROW INSERT INTO change_log (resource) VALUES ('instance')",
+		instance.triggerSQL("delete"))
+}
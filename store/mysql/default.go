@@ -74,6 +74,27 @@ type stableStore struct {
 	// maintain store
 	*maintainStore
 
+	// 变更日志
+	*changeLogStore
+
+	// 运维任务执行记录
+	*maintainJobStore
+
+	// 白名单运行时规则
+	*whitelistStore
+
+	// 服务依赖关系
+	*dependencyStore
+
+	// 实例事件
+	*instanceEventStore
+
+	// 实例心跳记录
+	*instanceHeartbeatStore
+
+	// 历史数据清理
+	*retentionStore
+
 	// 主数据库，可以进行读写
 	master *BaseDB
 	// 对主数据库的事务操作，可读写
@@ -104,6 +125,11 @@ func (s *stableStore) Initialize(conf *store.Config) error {
 	}
 	s.master = master
 
+	if err := migrateSchema(master, conf.Option); err != nil {
+		log.Errorf("[Store][database] migrate schema err: %s", err.Error())
+		return err
+	}
+
 	masterTx, err := NewBaseDB(masterConfig, plugin.GetParsePassword())
 	if err != nil {
 		return err
@@ -127,6 +153,7 @@ func (s *stableStore) Initialize(conf *store.Config) error {
 
 	s.start = true
 	s.newStore()
+	s.changeLogStore.enabled = setupChangeLog(s.master, conf.Option)
 	return nil
 }
 
@@ -285,6 +312,20 @@ func (s *stableStore) newStore() {
 	s.routingConfigStoreV2 = &routingConfigStoreV2{master: s.master, slave: s.slave}
 
 	s.maintainStore = newMaintainStore(s.master)
+
+	s.changeLogStore = &changeLogStore{master: s.master, slave: s.slave}
+
+	s.maintainJobStore = &maintainJobStore{master: s.master}
+
+	s.whitelistStore = &whitelistStore{master: s.master}
+
+	s.dependencyStore = &dependencyStore{master: s.master}
+
+	s.instanceEventStore = &instanceEventStore{master: s.master}
+
+	s.instanceHeartbeatStore = &instanceHeartbeatStore{master: s.master}
+
+	s.retentionStore = &retentionStore{master: s.master}
 }
 
 func buildEtimeStr(enable bool) string {
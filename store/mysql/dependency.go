@@ -0,0 +1,158 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// dependencyUpsertBatch 单条 SQL 批量写入依赖关系的最大数量
+	dependencyUpsertBatch = 100
+)
+
+// dependencyFilterConds 依赖关系支持的查询条件，按照固定顺序拼接 where 语句
+var dependencyFilterConds = []struct {
+	key  string
+	cond string
+}{
+	{key: "callee_namespace", cond: "callee_namespace = ?"},
+	{key: "callee_service", cond: "callee_service = ?"},
+	{key: "caller_namespace", cond: "caller_namespace = ?"},
+	{key: "caller_service", cond: "caller_service = ?"},
+	{key: "caller_host", cond: "find_in_set(?, caller_hosts) > 0"},
+}
+
+// dependencyStore 服务依赖关系的存储实现
+type dependencyStore struct {
+	master *BaseDB
+}
+
+// UpsertServiceDependencies 批量保存依赖关系，已存在的记录更新主调方地址、认证状态以及最近发现时间
+func (d *dependencyStore) UpsertServiceDependencies(deps []*model.ServiceDependency) error {
+	for begin := 0; begin < len(deps); begin += dependencyUpsertBatch {
+		end := begin + dependencyUpsertBatch
+		if end > len(deps) {
+			end = len(deps)
+		}
+		if err := d.batchUpsert(deps[begin:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dependencyStore) batchUpsert(deps []*model.ServiceDependency) error {
+	values := make([]string, 0, len(deps))
+	args := make([]interface{}, 0, len(deps)*8)
+	for _, dep := range deps {
+		values = append(values, "(?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?), FROM_UNIXTIME(?))")
+		args = append(args, dep.CallerNamespace, dep.CallerService, strings.Join(dep.CallerHosts, ","),
+			dep.CallerVerified, dep.CalleeNamespace, dep.CalleeService, dep.FirstSeen.Unix(), dep.LastSeen.Unix())
+	}
+	// caller_hosts 需要与更新前的 last_seen 比较，必须在 last_seen 之前更新
+	str := "insert into service_dependency (caller_namespace, caller_service, caller_hosts, caller_verified, " +
+		"callee_namespace, callee_service, first_seen, last_seen) values " + strings.Join(values, ", ") +
+		" on duplicate key update " +
+		"caller_hosts = if(values(last_seen) >= last_seen, values(caller_hosts), caller_hosts), " +
+		"caller_verified = greatest(caller_verified, values(caller_verified)), " +
+		"first_seen = least(first_seen, values(first_seen)), last_seen = greatest(last_seen, values(last_seen))"
+	if _, err := d.master.Exec(str, args...); err != nil {
+		log.Errorf("[Store][database] upsert %d service dependencies err: %s", len(deps), err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetServiceDependencies 查询依赖关系，按照最近发现时间倒序返回
+func (d *dependencyStore) GetServiceDependencies(filter map[string]string, offset, limit uint32) (
+	uint32, []*model.ServiceDependency, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	for _, item := range dependencyFilterConds {
+		if value, ok := filter[item.key]; ok {
+			conds = append(conds, item.cond)
+			args = append(args, value)
+		}
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " where " + strings.Join(conds, " and ")
+	}
+
+	var total uint32
+	if err := d.master.QueryRow("select count(*) from service_dependency"+where, args...).Scan(&total); err != nil {
+		log.Errorf("[Store][database] count service dependencies err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	args = append(args, offset, limit)
+	rows, err := d.master.Query("select caller_namespace, caller_service, caller_hosts, caller_verified, "+
+		"callee_namespace, callee_service, UNIX_TIMESTAMP(first_seen), UNIX_TIMESTAMP(last_seen) "+
+		"from service_dependency"+where+" order by last_seen desc limit ?, ?", args...)
+	if err != nil {
+		log.Errorf("[Store][database] get service dependencies err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var deps []*model.ServiceDependency
+	for rows.Next() {
+		var (
+			dep                 = &model.ServiceDependency{}
+			hosts               string
+			firstSeen, lastSeen int64
+		)
+		err := rows.Scan(&dep.CallerNamespace, &dep.CallerService, &hosts, &dep.CallerVerified,
+			&dep.CalleeNamespace, &dep.CalleeService, &firstSeen, &lastSeen)
+		if err != nil {
+			log.Errorf("[Store][database] fetch service dependency rows err: %s", err.Error())
+			return 0, nil, store.Error(err)
+		}
+		dep.CallerHosts = []string{}
+		if hosts != "" {
+			dep.CallerHosts = strings.Split(hosts, ",")
+		}
+		dep.FirstSeen = time.Unix(firstSeen, 0)
+		dep.LastSeen = time.Unix(lastSeen, 0)
+		deps = append(deps, dep)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch service dependency rows next err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	return total, deps, nil
+}
+
+// BatchCleanServiceDependencies 清理最近发现时间早于 before 的依赖关系
+func (d *dependencyStore) BatchCleanServiceDependencies(before time.Time, batchSize uint32) (uint32, error) {
+	result, err := d.master.Exec("delete from service_dependency where last_seen < FROM_UNIXTIME(?) limit ?",
+		unixSeconds(before), batchSize)
+	if err != nil {
+		log.Errorf("[Store][database] clean service dependencies before %s err: %s", before, err.Error())
+		return 0, store.Error(err)
+	}
+	affected, _ := result.RowsAffected()
+	return uint32(affected), nil
+}
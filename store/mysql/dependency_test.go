@@ -0,0 +1,108 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_dependencyStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &dependencyStore{master: &BaseDB{DB: db}}
+	seen := time.Unix(1700000000, 0)
+
+	mock.ExpectExec("insert into service_dependency (caller_namespace, caller_service, caller_hosts, "+
+		"caller_verified, callee_namespace, callee_service, first_seen, last_seen) values "+
+		"(?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?), FROM_UNIXTIME(?)), "+
+		"(?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?), FROM_UNIXTIME(?)) on duplicate key update "+
+		"caller_hosts = if(values(last_seen) >= last_seen, values(caller_hosts), caller_hosts), "+
+		"caller_verified = greatest(caller_verified, values(caller_verified)), "+
+		"first_seen = least(first_seen, values(first_seen)), last_seen = greatest(last_seen, values(last_seen))").
+		WithArgs("default", "order", "10.0.0.1,10.0.0.2", true, "default", "payment", seen.Unix(), seen.Unix(),
+			"default", "user", "10.0.0.3", false, "default", "payment", seen.Unix(), seen.Unix()+10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, s.UpsertServiceDependencies([]*model.ServiceDependency{
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.1", "10.0.0.2"},
+			CallerVerified:  true,
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen,
+		},
+		{
+			CallerNamespace: "default",
+			CallerService:   "user",
+			CallerHosts:     []string{"10.0.0.3"},
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen.Add(10 * time.Second),
+		},
+	}))
+
+	mock.ExpectQuery("select count(*) from service_dependency where callee_namespace = ? and callee_service = ? "+
+		"and find_in_set(?, caller_hosts) > 0").
+		WithArgs("default", "payment", "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("select caller_namespace, caller_service, caller_hosts, caller_verified, "+
+		"callee_namespace, callee_service, UNIX_TIMESTAMP(first_seen), UNIX_TIMESTAMP(last_seen) "+
+		"from service_dependency where callee_namespace = ? and callee_service = ? "+
+		"and find_in_set(?, caller_hosts) > 0 order by last_seen desc limit ?, ?").
+		WithArgs("default", "payment", "10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"caller_namespace", "caller_service", "caller_hosts",
+			"caller_verified", "callee_namespace", "callee_service", "first_seen", "last_seen"}).
+			AddRow("default", "user", "", false, "default", "payment", seen.Unix(), seen.Unix()+10).
+			AddRow("default", "order", "10.0.0.1,10.0.0.2", true, "default", "payment", seen.Unix(), seen.Unix()))
+	total, deps, err := s.GetServiceDependencies(map[string]string{
+		"callee_service":   "payment",
+		"callee_namespace": "default",
+		"caller_host":      "10.0.0.1",
+		"unknown":          "ignored",
+	}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Len(t, deps, 2)
+	assert.Equal(t, []string{}, deps[0].CallerHosts)
+	assert.Equal(t, seen.Add(10*time.Second), deps[0].LastSeen)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, deps[1].CallerHosts)
+	assert.True(t, deps[1].CallerVerified)
+
+	before := time.Unix(1700000000, 0)
+	mock.ExpectExec("delete from service_dependency where last_seen < FROM_UNIXTIME(?) limit ?").
+		WithArgs(float64(before.Unix()), 100).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	count, err := s.BatchCleanServiceDependencies(before, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,198 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// instanceEventInsertBatch 单条 SQL 批量写入实例事件的最大数量
+	instanceEventInsertBatch = 100
+)
+
+// instanceEventStore 实例事件的存储实现
+type instanceEventStore struct {
+	master *BaseDB
+}
+
+// AddInstanceEvents 批量保存实例事件
+func (e *instanceEventStore) AddInstanceEvents(events []*model.InstanceEventRecord) error {
+	for begin := 0; begin < len(events); begin += instanceEventInsertBatch {
+		end := begin + instanceEventInsertBatch
+		if end > len(events) {
+			end = len(events)
+		}
+		if err := e.batchInsert(events[begin:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *instanceEventStore) batchInsert(events []*model.InstanceEventRecord) error {
+	values := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*11)
+	for _, event := range events {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))")
+		args = append(args, string(event.EventType), event.Namespace, event.Service, event.InstanceID,
+			event.Host, event.Port, event.Weight, boolToInt(event.Healthy), boolToInt(event.Isolate),
+			event.Server, unixSeconds(event.CreateTime))
+	}
+	str := "insert into instance_event (event_type, namespace, service, instance_id, host, port, weight, " +
+		"healthy, isolate, server, ctime) values " + strings.Join(values, ", ")
+	if _, err := e.master.Exec(str, args...); err != nil {
+		log.Errorf("[Store][database] add %d instance events err: %s", len(events), err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetInstanceEvents 查询实例事件，按照事件时间倒序返回
+func (e *instanceEventStore) GetInstanceEvents(filter *model.InstanceEventFilter, offset, limit uint32) (
+	uint32, []*model.InstanceEventRecord, error) {
+	where, args := instanceEventWhere(filter)
+
+	var total uint32
+	if err := e.master.QueryRow("select count(*) from instance_event"+where, args...).Scan(&total); err != nil {
+		log.Errorf("[Store][database] count instance events err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	args = append(args, offset, limit)
+	rows, err := e.master.Query("select event_type, namespace, service, instance_id, host, port, weight, "+
+		"healthy, isolate, server, UNIX_TIMESTAMP(ctime) from instance_event"+where+
+		" order by ctime desc, id desc limit ?, ?", args...)
+	if err != nil {
+		log.Errorf("[Store][database] get instance events err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var events []*model.InstanceEventRecord
+	for rows.Next() {
+		var (
+			event            = &model.InstanceEventRecord{}
+			eventType        string
+			healthy, isolate int
+			ctime            float64
+		)
+		err := rows.Scan(&eventType, &event.Namespace, &event.Service, &event.InstanceID, &event.Host,
+			&event.Port, &event.Weight, &healthy, &isolate, &event.Server, &ctime)
+		if err != nil {
+			log.Errorf("[Store][database] fetch instance event rows err: %s", err.Error())
+			return 0, nil, store.Error(err)
+		}
+		event.EventType = model.InstanceEventType(eventType)
+		event.Healthy = healthy == 1
+		event.Isolate = isolate == 1
+		event.CreateTime = time.UnixMilli(int64(math.Round(ctime * 1000)))
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch instance event rows next err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	return total, events, nil
+}
+
+// BatchCleanInstanceEvents 清理过期的实例事件以及超过保留数量的实例事件
+func (e *instanceEventStore) BatchCleanInstanceEvents(before time.Time, keepCount uint32, batchSize uint32) (
+	uint32, error) {
+	var count uint32
+	if !before.IsZero() {
+		result, err := e.master.Exec("delete from instance_event where ctime < FROM_UNIXTIME(?) limit ?",
+			unixSeconds(before), batchSize)
+		if err != nil {
+			log.Errorf("[Store][database] clean instance events before %s err: %s", before, err.Error())
+			return 0, store.Error(err)
+		}
+		affected, _ := result.RowsAffected()
+		count += uint32(affected)
+	}
+	if keepCount == 0 || count >= batchSize {
+		return count, nil
+	}
+
+	// 找到需要保留的最早一条事件之前的 ID，之前的事件全部清理
+	var maxID uint64
+	err := e.master.QueryRow("select id from instance_event order by id desc limit ?, 1", keepCount).Scan(&maxID)
+	if err == sql.ErrNoRows {
+		return count, nil
+	}
+	if err != nil {
+		log.Errorf("[Store][database] get instance event keep boundary err: %s", err.Error())
+		return count, store.Error(err)
+	}
+	result, err := e.master.Exec("delete from instance_event where id <= ? limit ?", maxID, batchSize-count)
+	if err != nil {
+		log.Errorf("[Store][database] clean instance events exceed %d err: %s", keepCount, err.Error())
+		return count, store.Error(err)
+	}
+	affected, _ := result.RowsAffected()
+	return count + uint32(affected), nil
+}
+
+// instanceEventWhere 根据查询条件拼接 where 语句
+func instanceEventWhere(filter *model.InstanceEventFilter) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.Namespace != "" {
+		conds = append(conds, "namespace = ?")
+		args = append(args, filter.Namespace)
+	}
+	if filter.Service != "" {
+		conds = append(conds, "service = ?")
+		args = append(args, filter.Service)
+	}
+	if filter.InstanceID != "" {
+		conds = append(conds, "instance_id = ?")
+		args = append(args, filter.InstanceID)
+	}
+	if len(filter.EventTypes) != 0 {
+		conds = append(conds, "event_type in ("+PlaceholdersN(len(filter.EventTypes))+")")
+		for _, eventType := range filter.EventTypes {
+			args = append(args, string(eventType))
+		}
+	}
+	if !filter.StartTime.IsZero() {
+		conds = append(conds, "ctime >= FROM_UNIXTIME(?)")
+		args = append(args, unixSeconds(filter.StartTime))
+	}
+	if !filter.EndTime.IsZero() {
+		conds = append(conds, "ctime < FROM_UNIXTIME(?)")
+		args = append(args, unixSeconds(filter.EndTime))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " where " + strings.Join(conds, " and "), args
+}
+
+// unixSeconds 精确到毫秒的时间戳，单位秒
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
@@ -0,0 +1,129 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_instanceEventStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &instanceEventStore{master: &BaseDB{DB: db}}
+	ctime := time.UnixMilli(1700000000123)
+
+	mock.ExpectExec("insert into instance_event (event_type, namespace, service, instance_id, host, port, "+
+		"weight, healthy, isolate, server, ctime) values "+
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?)), (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))").
+		WithArgs("InstanceOnline", "default", "order", "ins-1", "10.0.0.1", 8080, 100, 1, 0, "127.0.0.1",
+			1700000000.123,
+			"InstanceOpenIsolate", "default", "order", "ins-1", "10.0.0.1", 8080, 100, 1, 1, "127.0.0.1",
+			1700000001.123).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	assert.NoError(t, s.AddInstanceEvents([]*model.InstanceEventRecord{
+		{
+			EventType:  model.EventInstanceOnline,
+			Namespace:  "default",
+			Service:    "order",
+			InstanceID: "ins-1",
+			Host:       "10.0.0.1",
+			Port:       8080,
+			Weight:     100,
+			Healthy:    true,
+			Server:     "127.0.0.1",
+			CreateTime: ctime,
+		},
+		{
+			EventType:  model.EventInstanceOpenIsolate,
+			Namespace:  "default",
+			Service:    "order",
+			InstanceID: "ins-1",
+			Host:       "10.0.0.1",
+			Port:       8080,
+			Weight:     100,
+			Healthy:    true,
+			Isolate:    true,
+			Server:     "127.0.0.1",
+			CreateTime: ctime.Add(time.Second),
+		},
+	}))
+
+	where := " where namespace = ? and service = ? and event_type in (?,?) and ctime >= FROM_UNIXTIME(?)"
+	mock.ExpectQuery("select count(*) from instance_event"+where).
+		WithArgs("default", "order", "InstanceOnline", "InstanceOpenIsolate", 1700000000.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("select event_type, namespace, service, instance_id, host, port, weight, healthy, "+
+		"isolate, server, UNIX_TIMESTAMP(ctime) from instance_event"+where+
+		" order by ctime desc, id desc limit ?, ?").
+		WithArgs("default", "order", "InstanceOnline", "InstanceOpenIsolate", 1700000000.0, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"event_type", "namespace", "service", "instance_id", "host",
+			"port", "weight", "healthy", "isolate", "server", "ctime"}).
+			AddRow("InstanceOpenIsolate", "default", "order", "ins-1", "10.0.0.1", 8080, 100, 1, 1,
+				"127.0.0.1", "1700000001.123").
+			AddRow("InstanceOnline", "default", "order", "ins-1", "10.0.0.1", 8080, 100, 1, 0,
+				"127.0.0.1", "1700000000.123"))
+	total, events, err := s.GetInstanceEvents(&model.InstanceEventFilter{
+		Namespace:  "default",
+		Service:    "order",
+		EventTypes: []model.InstanceEventType{model.EventInstanceOnline, model.EventInstanceOpenIsolate},
+		StartTime:  time.Unix(1700000000, 0),
+	}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Len(t, events, 2)
+	assert.Equal(t, model.EventInstanceOpenIsolate, events[0].EventType)
+	assert.True(t, events[0].Isolate)
+	assert.True(t, ctime.Add(time.Second).Equal(events[0].CreateTime))
+	assert.False(t, events[1].Isolate)
+	assert.True(t, ctime.Equal(events[1].CreateTime))
+
+	// 按时间清理的数量不足一批时继续按数量清理
+	before := time.Unix(1700000000, 0)
+	mock.ExpectExec("delete from instance_event where ctime < FROM_UNIXTIME(?) limit ?").
+		WithArgs(1700000000.0, 100).
+		WillReturnResult(sqlmock.NewResult(0, 40))
+	mock.ExpectQuery("select id from instance_event order by id desc limit ?, 1").
+		WithArgs(1000).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(500))
+	mock.ExpectExec("delete from instance_event where id <= ? limit ?").
+		WithArgs(500, 60).
+		WillReturnResult(sqlmock.NewResult(0, 60))
+	count, err := s.BatchCleanInstanceEvents(before, 1000, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(100), count)
+
+	// 事件数量没有超过保留数量
+	mock.ExpectQuery("select id from instance_event order by id desc limit ?, 1").
+		WithArgs(1000).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	count, err = s.BatchCleanInstanceEvents(time.Time{}, 1000, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,86 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_instanceHeartbeatStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &instanceHeartbeatStore{master: &BaseDB{DB: db}}
+
+	mock.ExpectExec("insert into instance_heartbeat (instance_id, server, last_heartbeat, count, mtime) values "+
+		"(?, ?, ?, ?, sysdate(3)), (?, ?, ?, ?, sysdate(3)) on duplicate key update "+
+		"server = if("+heartbeatNewerCond+", values(server), server), "+
+		"count = if("+heartbeatNewerCond+", values(count), count), "+
+		"last_heartbeat = greatest(last_heartbeat, values(last_heartbeat)), mtime = sysdate(3)").
+		WithArgs("ins-1", "127.0.0.1", 1700000000, 1, "ins-2", "127.0.0.2", 1700000001, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, s.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.1", LastHeartbeatSec: 1700000000, Count: 1},
+		{InstanceID: "ins-2", Server: "127.0.0.2", LastHeartbeatSec: 1700000001, Count: 3},
+	}))
+
+	columns := []string{"instance_id", "server", "last_heartbeat", "count", "mtime"}
+	mock.ExpectQuery("select instance_id, server, last_heartbeat, count, UNIX_TIMESTAMP(mtime) " +
+		"from instance_heartbeat").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("ins-1", "127.0.0.1", 1700000000, 1, "1700000000.123").
+			AddRow("ins-2", "127.0.0.2", 1700000001, 3, "1700000001.456"))
+	heartbeats, err := s.GetMoreInstanceHeartbeats(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, heartbeats, 2)
+	assert.Equal(t, "ins-2", heartbeats[1].InstanceID)
+	assert.Equal(t, "127.0.0.2", heartbeats[1].Server)
+	assert.Equal(t, int64(1700000001), heartbeats[1].LastHeartbeatSec)
+	assert.Equal(t, int64(3), heartbeats[1].Count)
+	assert.True(t, time.UnixMilli(1700000001456).Equal(heartbeats[1].ModifyTime))
+
+	mock.ExpectQuery("select instance_id, server, last_heartbeat, count, UNIX_TIMESTAMP(mtime) " +
+		"from instance_heartbeat where mtime >= FROM_UNIXTIME(?)").
+		WithArgs(1700000001.0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("ins-2", "127.0.0.2", 1700000001, 3, "1700000001.456"))
+	heartbeats, err = s.GetMoreInstanceHeartbeats(time.Unix(1700000001, 0))
+	assert.NoError(t, err)
+	assert.Len(t, heartbeats, 1)
+
+	mock.ExpectExec("delete from instance_heartbeat where instance_id = ?").
+		WithArgs("ins-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.DeleteInstanceHeartbeat("ins-1"))
+
+	mock.ExpectExec("delete from instance_heartbeat where instance_id = ?").
+		WithArgs("ins-2").
+		WillReturnError(errors.New("mock error"))
+	assert.Error(t, s.DeleteInstanceHeartbeat("ins-2"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,160 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// maintainJobStore 运维任务执行记录的存储实现
+type maintainJobStore struct {
+	master *BaseDB
+}
+
+// AddMaintainJobRun 新增一条执行记录
+func (m *maintainJobStore) AddMaintainJobRun(run *model.MaintainJobRun) error {
+	affected, err := json.Marshal(run.Affected)
+	if err != nil {
+		return store.Error(err)
+	}
+	_, err = m.master.Exec("insert into maintain_job_run (id, name, trigger_type, operator, host, status, "+
+		"message, affected, start_time) values (?, ?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))",
+		run.ID, run.Name, run.Trigger, run.Operator, run.Host, run.Status, run.Message, string(affected),
+		run.StartTime.Unix())
+	if err != nil {
+		log.Errorf("[Store][database] add maintain job run(%s) err: %s", run.Name, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// UpdateMaintainJobRun 更新执行记录的状态、结果以及结束时间
+func (m *maintainJobStore) UpdateMaintainJobRun(run *model.MaintainJobRun) error {
+	affected, err := json.Marshal(run.Affected)
+	if err != nil {
+		return store.Error(err)
+	}
+	_, err = m.master.Exec("update maintain_job_run set status = ?, message = ?, affected = ?, "+
+		"end_time = FROM_UNIXTIME(?) where id = ?",
+		run.Status, run.Message, string(affected), run.EndTime.Unix(), run.ID)
+	if err != nil {
+		log.Errorf("[Store][database] update maintain job run(%s) err: %s", run.ID, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetMaintainJobRuns 查询执行记录，name 为空时查询全部任务，按照开始时间倒序返回
+func (m *maintainJobStore) GetMaintainJobRuns(name string, offset, limit uint32) (
+	uint32, []*model.MaintainJobRun, error) {
+	where := ""
+	var args []interface{}
+	if name != "" {
+		where = " where name = ?"
+		args = append(args, name)
+	}
+
+	var total uint32
+	if err := m.master.QueryRow("select count(*) from maintain_job_run"+where, args...).Scan(&total); err != nil {
+		log.Errorf("[Store][database] count maintain job runs err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	args = append(args, offset, limit)
+	rows, err := m.master.Query("select id, name, trigger_type, operator, host, status, IFNULL(message, ''), "+
+		"IFNULL(affected, ''), UNIX_TIMESTAMP(start_time), IFNULL(UNIX_TIMESTAMP(end_time), 0) "+
+		"from maintain_job_run"+where+" order by start_time desc, id limit ?, ?", args...)
+	if err != nil {
+		log.Errorf("[Store][database] get maintain job runs err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	runs, err := fetchMaintainJobRuns(rows)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	return total, runs, nil
+}
+
+// CleanMaintainJobRuns 清理任务的历史执行记录，只保留开始时间最新的 keepCount 条，返回清理的数量
+func (m *maintainJobStore) CleanMaintainJobRuns(name string, keepCount uint32) (uint32, error) {
+	if keepCount == 0 {
+		return 0, nil
+	}
+	// 保留的最后一条记录的开始时间，开始时间相同的记录全部保留
+	var boundary int64
+	err := m.master.QueryRow("select UNIX_TIMESTAMP(start_time) from maintain_job_run where name = ? "+
+		"order by start_time desc, id limit ?, 1", name, keepCount-1).Scan(&boundary)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		log.Errorf("[Store][database] get maintain job run(%s) retention boundary err: %s", name, err.Error())
+		return 0, store.Error(err)
+	}
+	result, err := m.master.Exec("delete from maintain_job_run where name = ? and start_time < FROM_UNIXTIME(?)",
+		name, boundary)
+	if err != nil {
+		log.Errorf("[Store][database] clean maintain job runs(%s) err: %s", name, err.Error())
+		return 0, store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	return uint32(rows), nil
+}
+
+func fetchMaintainJobRuns(rows *sql.Rows) ([]*model.MaintainJobRun, error) {
+	defer rows.Close()
+
+	var runs []*model.MaintainJobRun
+	for rows.Next() {
+		var (
+			run                = &model.MaintainJobRun{}
+			affected           string
+			startTime, endTime int64
+		)
+		err := rows.Scan(&run.ID, &run.Name, &run.Trigger, &run.Operator, &run.Host, &run.Status,
+			&run.Message, &affected, &startTime, &endTime)
+		if err != nil {
+			log.Errorf("[Store][database] fetch maintain job run rows err: %s", err.Error())
+			return nil, err
+		}
+		if affected != "" {
+			if err := json.Unmarshal([]byte(affected), &run.Affected); err != nil {
+				log.Errorf("[Store][database] unmarshal maintain job run(%s) affected err: %s", run.ID, err.Error())
+				return nil, err
+			}
+		}
+		run.StartTime = time.Unix(startTime, 0)
+		if endTime > 0 {
+			run.EndTime = time.Unix(endTime, 0)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch maintain job run rows next err: %s", err.Error())
+		return nil, err
+	}
+	return runs, nil
+}
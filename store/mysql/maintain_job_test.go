@@ -0,0 +1,104 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_maintainJobStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &maintainJobStore{master: &BaseDB{DB: db}}
+	start := time.Unix(1700000000, 0)
+	run := &model.MaintainJobRun{
+		ID:        "run-1",
+		Name:      "DeleteUnHealthyInstance",
+		Trigger:   model.MaintainJobTriggerManual,
+		Operator:  "polaris",
+		Host:      "127.0.0.1",
+		Status:    model.MaintainJobRunning,
+		StartTime: start,
+	}
+
+	mock.ExpectExec("insert into maintain_job_run (id, name, trigger_type, operator, host, status, "+
+		"message, affected, start_time) values (?, ?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))").
+		WithArgs("run-1", "DeleteUnHealthyInstance", "manual", "polaris", "127.0.0.1", "running", "", "null",
+			start.Unix()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	assert.NoError(t, s.AddMaintainJobRun(run))
+
+	run.Status = model.MaintainJobSuccess
+	run.Affected = []string{"ins-1", "ins-2"}
+	run.EndTime = start.Add(time.Second)
+	mock.ExpectExec("update maintain_job_run set status = ?, message = ?, affected = ?, "+
+		"end_time = FROM_UNIXTIME(?) where id = ?").
+		WithArgs("success", "", `["ins-1","ins-2"]`, start.Unix()+1, "run-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.UpdateMaintainJobRun(run))
+
+	mock.ExpectQuery("select count(*) from maintain_job_run where name = ?").
+		WithArgs("DeleteUnHealthyInstance").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("select id, name, trigger_type, operator, host, status, IFNULL(message, ''), "+
+		"IFNULL(affected, ''), UNIX_TIMESTAMP(start_time), IFNULL(UNIX_TIMESTAMP(end_time), 0) "+
+		"from maintain_job_run where name = ? order by start_time desc, id limit ?, ?").
+		WithArgs("DeleteUnHealthyInstance", 0, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "trigger_type", "operator", "host", "status",
+			"message", "affected", "start_time", "end_time"}).
+			AddRow("run-2", "DeleteUnHealthyInstance", "cron", "", "127.0.0.1", "running", "", "", start.Unix()+60, 0))
+	total, runs, err := s.GetMaintainJobRuns("DeleteUnHealthyInstance", 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, "run-2", runs[0].ID)
+	assert.Equal(t, model.MaintainJobTriggerCron, runs[0].Trigger)
+	assert.Nil(t, runs[0].Affected)
+	assert.True(t, runs[0].EndTime.IsZero())
+
+	mock.ExpectQuery("select UNIX_TIMESTAMP(start_time) from maintain_job_run where name = ? "+
+		"order by start_time desc, id limit ?, 1").
+		WithArgs("DeleteUnHealthyInstance", 99).
+		WillReturnRows(sqlmock.NewRows([]string{"start_time"}).AddRow(start.Unix()))
+	mock.ExpectExec("delete from maintain_job_run where name = ? and start_time < FROM_UNIXTIME(?)").
+		WithArgs("DeleteUnHealthyInstance", start.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	cleaned, err := s.CleanMaintainJobRuns("DeleteUnHealthyInstance", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), cleaned)
+
+	// 记录数不超过保留数量时不需要清理
+	mock.ExpectQuery("select UNIX_TIMESTAMP(start_time) from maintain_job_run where name = ? "+
+		"order by start_time desc, id limit ?, 1").
+		WithArgs("DeleteUnHealthyInstance", 99).
+		WillReturnRows(sqlmock.NewRows([]string{"start_time"}))
+	cleaned, err = s.CleanMaintainJobRuns("DeleteUnHealthyInstance", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), cleaned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// retentionStore 历史数据清理的存储实现
+type retentionStore struct {
+	master *BaseDB
+}
+
+// namespaceCondition 生成命名空间的过滤条件，namespace 为空时过滤掉 excludes 中的命名空间
+func namespaceCondition(column, namespace string, excludes []string) (string, []interface{}) {
+	if namespace != "" {
+		return " and " + column + " = ?", []interface{}{namespace}
+	}
+	if len(excludes) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, 0, len(excludes))
+	for _, item := range excludes {
+		args = append(args, item)
+	}
+	return " and " + column + " not in (" + PlaceholdersN(len(excludes)) + ")", args
+}
+
+// BatchCleanConfigFileReleaseHistories 清理配置文件的发布历史，
+// 每个配置文件保留最新的 keepCount 条以及创建时间不早于 before 的记录
+func (r *retentionStore) BatchCleanConfigFileReleaseHistories(namespace string, excludes []string,
+	keepCount uint32, before time.Time, batchSize uint32) (uint32, error) {
+	cond, args := namespaceCondition("h.namespace", namespace, excludes)
+	// 同一张表不能在删除语句的子查询中使用，先查询出需要删除的记录
+	querySql := "select h.id from config_file_release_history h where h.create_time < FROM_UNIXTIME(?)" + cond +
+		" and (select count(*) from config_file_release_history n where n.namespace = h.namespace and " +
+		"n.`group` = h.`group` and n.file_name = h.file_name and n.id > h.id) >= ? limit ?"
+	args = append([]interface{}{before.Unix()}, args...)
+	args = append(args, keepCount, batchSize)
+	ids, err := r.queryIds(querySql, args...)
+	if err != nil {
+		log.Errorf("[Store][database] get expired config file release histories err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	return r.deleteByIds("delete from config_file_release_history where id in ", ids)
+}
+
+// BatchPurgeDeletedServices 物理删除修改时间早于 before 的软删除服务
+func (r *retentionStore) BatchPurgeDeletedServices(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	cond, args := namespaceCondition("namespace", namespace, excludes)
+	args = append([]interface{}{before.Unix()}, args...)
+	args = append(args, batchSize)
+	return r.execDelete("delete from service where flag = 1 and mtime < FROM_UNIXTIME(?)"+cond+" limit ?", args...)
+}
+
+// BatchPurgeDeletedConfigFiles 物理删除修改时间早于 before 的软删除配置文件
+func (r *retentionStore) BatchPurgeDeletedConfigFiles(namespace string, excludes []string, before time.Time,
+	batchSize uint32) (uint32, error) {
+	cond, args := namespaceCondition("namespace", namespace, excludes)
+	args = append([]interface{}{before.Unix()}, args...)
+	args = append(args, batchSize)
+	return r.execDelete("delete from config_file where flag = 1 and modify_time < FROM_UNIXTIME(?)"+cond+
+		" limit ?", args...)
+}
+
+func (r *retentionStore) queryIds(querySql string, args ...interface{}) ([]interface{}, error) {
+	rows, err := r.master.Query(querySql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []interface{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *retentionStore) deleteByIds(deleteSql string, ids []interface{}) (uint32, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.execDelete(deleteSql+"("+PlaceholdersN(len(ids))+")", ids...)
+}
+
+func (r *retentionStore) execDelete(deleteSql string, args ...interface{}) (uint32, error) {
+	result, err := r.master.Exec(deleteSql, args...)
+	if err != nil {
+		log.Errorf("[Store][database] purge expired data err: %s, sql: %s", err.Error(), deleteSql)
+		return 0, store.Error(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	return uint32(count), nil
+}
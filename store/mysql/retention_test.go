@@ -0,0 +1,81 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_namespaceCondition(t *testing.T) {
+	cond, args := namespaceCondition("namespace", "default", []string{"Polaris"})
+	assert.Equal(t, " and namespace = ?", cond)
+	assert.Equal(t, []interface{}{"default"}, args)
+
+	cond, args = namespaceCondition("namespace", "", []string{"default", "Polaris"})
+	assert.Equal(t, " and namespace not in (?,?)", cond)
+	assert.Equal(t, []interface{}{"default", "Polaris"}, args)
+
+	cond, args = namespaceCondition("namespace", "", nil)
+	assert.Equal(t, "", cond)
+	assert.Nil(t, args)
+}
+
+func Test_retentionStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &retentionStore{master: &BaseDB{DB: db}}
+	before := time.Unix(1700000000, 0)
+
+	mock.ExpectQuery("select h.id from config_file_release_history h where h.create_time < FROM_UNIXTIME(?)"+
+		" and h.namespace = ? and (select count(*) from config_file_release_history n where "+
+		"n.namespace = h.namespace and n.`group` = h.`group` and n.file_name = h.file_name and n.id > h.id) >= ? "+
+		"limit ?").
+		WithArgs(before.Unix(), "default", 10, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+	mock.ExpectExec("delete from config_file_release_history where id in (?,?)").
+		WithArgs("1", "2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	count, err := s.BatchCleanConfigFileReleaseHistories("default", nil, 10, before, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), count)
+
+	mock.ExpectExec("delete from service where flag = 1 and mtime < FROM_UNIXTIME(?) limit ?").
+		WithArgs(before.Unix(), 100).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	count, err = s.BatchPurgeDeletedServices("", nil, before, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), count)
+
+	mock.ExpectExec("delete from config_file where flag = 1 and modify_time < FROM_UNIXTIME(?) "+
+		"and namespace = ? limit ?").
+		WithArgs(before.Unix(), "default", 100).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	count, err = s.BatchPurgeDeletedConfigFiles("default", nil, before, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,434 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+//go:embed scripts/delta/*.sql
+var deltaScripts embed.FS
+
+const (
+	// deltaScriptDir 增量变更脚本所在目录
+	deltaScriptDir = "scripts/delta"
+	// schemaLockName 执行 schema 变更时使用的锁，保证同一时刻只有一个 server 执行变更
+	schemaLockName = "polaris_schema_migration"
+	// schemaLockTimeout 等待 schema 变更锁的超时时间，单位秒
+	schemaLockTimeout = 300
+	// optionAutoMigrateSchema 是否在启动时自动执行 schema 变更的配置项，默认开启
+	optionAutoMigrateSchema = "autoMigrateSchema"
+)
+
+// deltaFilePattern 增量变更脚本的文件名格式，如 v1_14_0-v1_15_0.sql
+var deltaFilePattern = regexp.MustCompile(`^v(\d+)_(\d+)_(\d+)-v(\d+)_(\d+)_(\d+)\.sql$`)
+
+// deltaProbes 各个增量变更引入的表或者字段，用于识别尚未记录 schema 版本的已有数据库执行过哪些变更，
+// 新增增量变更脚本时需要同步在这里增加对应的探测语句。
+// 每个功能的变更使用单独的脚本，脚本内的语句需要可以重复执行，部分执行失败后重启即可继续
+var deltaProbes = map[string]string{
+	"1.7.0":  tableProbe("user"),
+	"1.8.0":  tableProbe("client"),
+	"1.11.0": tableProbe("config_file_template"),
+	"1.12.0": tableProbe("routing_config_v2"),
+	"1.14.0": tableProbe("leader_election"),
+	"1.15.0": columnProbe("user", "password_history"),
+	"1.16.0": tableProbe("change_log"),
+	"1.16.1": tableProbe("maintain_job_run"),
+	"1.16.2": tableProbe("whitelist_rule"),
+	"1.16.3": tableProbe("service_dependency"),
+	"1.16.4": tableProbe("instance_event"),
+	"1.16.5": tableProbe("instance_heartbeat"),
+}
+
+func tableProbe(table string) string {
+	return "select count(*) from information_schema.tables where table_schema = database() and table_name = '" +
+		table + "'"
+}
+
+func columnProbe(table, column string) string {
+	return "select count(*) from information_schema.columns where table_schema = database() and table_name = '" +
+		table + "' and column_name = '" + column + "'"
+}
+
+// schemaDelta 一个增量变更脚本
+type schemaDelta struct {
+	from       schemaVersion
+	to         schemaVersion
+	script     string
+	statements []string
+}
+
+// schemaVersion 数据库 schema 的版本，与引入该变更的 server 版本一致，同一个 server 版本的多个变更依次递增修订号
+type schemaVersion [3]int
+
+// String 输出 1.15.0 格式的版本
+func (v schemaVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// less 比较版本大小
+func (v schemaVersion) less(o schemaVersion) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+// parseSchemaVersion 解析 1.15.0 格式的版本
+func parseSchemaVersion(s string) (schemaVersion, error) {
+	var v schemaVersion
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) != len(v) {
+		return v, fmt.Errorf("invalid schema version %s", s)
+	}
+	for i := range parts {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return v, fmt.Errorf("invalid schema version %s", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// loadSchemaDeltas 加载内置的增量变更脚本，按照目标版本排序
+func loadSchemaDeltas() ([]*schemaDelta, error) {
+	entries, err := deltaScripts.ReadDir(deltaScriptDir)
+	if err != nil {
+		return nil, err
+	}
+	deltas := make([]*schemaDelta, 0, len(entries))
+	for _, entry := range entries {
+		match := deltaFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid delta script name %s", entry.Name())
+		}
+		delta := &schemaDelta{script: entry.Name()}
+		for i := 0; i < 3; i++ {
+			delta.from[i], _ = strconv.Atoi(match[i+1])
+			delta.to[i], _ = strconv.Atoi(match[i+4])
+		}
+		content, err := deltaScripts.ReadFile(path.Join(deltaScriptDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		delta.statements = filterDeltaStatements(splitStatements(string(content)))
+		deltas = append(deltas, delta)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].to.less(deltas[j].to)
+	})
+	return deltas, nil
+}
+
+// splitStatements 将 sql 脚本拆分为单条语句，忽略注释以及引号内的分号
+func splitStatements(content string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
+	)
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if quote != 0 {
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(content) {
+				i++
+				current.WriteByte(content[i])
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(content[i:], "--"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+// filterDeltaStatements 过滤掉切换、创建数据库的语句，变更始终在配置的数据库上执行
+func filterDeltaStatements(statements []string) []string {
+	ret := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		fields := strings.Fields(strings.ToUpper(stmt))
+		if fields[0] == "USE" || (len(fields) > 1 && fields[0] == "CREATE" && fields[1] == "DATABASE") {
+			continue
+		}
+		ret = append(ret, stmt)
+	}
+	return ret
+}
+
+// schemaMigrator 管理 mysql 存储的 schema 版本
+type schemaMigrator struct {
+	db     *BaseDB
+	deltas []*schemaDelta
+}
+
+func newSchemaMigrator(db *BaseDB) (*schemaMigrator, error) {
+	deltas, err := loadSchemaDeltas()
+	if err != nil {
+		return nil, err
+	}
+	return &schemaMigrator{db: db, deltas: deltas}, nil
+}
+
+// latest 当前程序支持的最新 schema 版本
+func (m *schemaMigrator) latest() schemaVersion {
+	return m.deltas[len(m.deltas)-1].to
+}
+
+// queryer 执行查询的对象，可以是 *sql.DB 或者 *sql.Conn
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// pending 返回尚未执行的变更，以及通过探测识别出已经执行但是没有记录版本的变更。
+// 数据库的 schema 版本高于当前程序支持的版本时返回错误
+func (m *schemaMigrator) pending(ctx context.Context, q queryer) ([]*schemaDelta, []*schemaDelta, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, tableProbe("schema_version")).Scan(&count); err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		return m.detect(ctx, q)
+	}
+
+	rows, err := q.QueryContext(ctx, "select version from schema_version")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	applied := map[schemaVersion]struct{}{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, nil, err
+		}
+		v, err := parseSchemaVersion(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m.latest().less(v) {
+			return nil, nil, fmt.Errorf("database schema version %s is newer than %s supported by this server, "+
+				"please upgrade polaris-server", v, m.latest())
+		}
+		applied[v] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var pending []*schemaDelta
+	for _, delta := range m.deltas {
+		if _, ok := applied[delta.to]; !ok {
+			pending = append(pending, delta)
+		}
+	}
+	return pending, nil, nil
+}
+
+// detect 没有 schema_version 表时，通过探测变更引入的表以及字段识别已经执行过的变更
+func (m *schemaMigrator) detect(ctx context.Context, q queryer) ([]*schemaDelta, []*schemaDelta, error) {
+	var pending, applied []*schemaDelta
+	for _, delta := range m.deltas {
+		probe, ok := deltaProbes[delta.to.String()]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing probe of delta script %s", delta.script)
+		}
+		var count int
+		if err := q.QueryRowContext(ctx, probe).Scan(&count); err != nil {
+			return nil, nil, err
+		}
+		if count > 0 {
+			applied = append(applied, delta)
+		} else {
+			pending = append(pending, delta)
+		}
+	}
+	return pending, applied, nil
+}
+
+// upgrade 持有 schema 变更锁，依次执行尚未执行的变更
+func (m *schemaMigrator) upgrade(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// GET_LOCK 为会话级别的锁，需要在同一个连接上加锁以及释放
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "select get_lock(?, ?)", schemaLockName,
+		schemaLockTimeout).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.New("acquire schema migration lock timeout")
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "select release_lock(?)", schemaLockName)
+	}()
+
+	pending, detected, err := m.pending(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, createSchemaVersionTable); err != nil {
+		return err
+	}
+	for _, delta := range detected {
+		log.Infof("[Store][database] schema %s already applied, record it", delta.to)
+		if err := recordSchemaVersion(ctx, conn, delta); err != nil {
+			return err
+		}
+	}
+	for _, delta := range pending {
+		log.Infof("[Store][database] apply schema delta %s", delta.script)
+		for _, stmt := range delta.statements {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				log.Errorf("[Store][database] apply schema delta %s fail, statement: %s, err: %s",
+					delta.script, stmt, err.Error())
+				return store.Error(err)
+			}
+		}
+		if err := recordSchemaVersion(ctx, conn, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSchemaVersionTable 记录已执行的 schema 变更
+const createSchemaVersionTable = "CREATE TABLE IF NOT EXISTS `schema_version` (" +
+	"`version` VARCHAR(32) NOT NULL comment 'schema version after the delta script applied', " +
+	"`script` VARCHAR(128) NOT NULL comment 'delta script name', " +
+	"`ctime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time', " +
+	"PRIMARY KEY (`version`)) ENGINE = InnoDB"
+
+func recordSchemaVersion(ctx context.Context, conn *sql.Conn, delta *schemaDelta) error {
+	_, err := conn.ExecContext(ctx, "insert ignore into schema_version(version, script) values(?, ?)",
+		delta.to.String(), delta.script)
+	return err
+}
+
+// migrateSchema 启动时检查数据库的 schema 版本，开启自动变更时执行尚未执行的变更
+func migrateSchema(db *BaseDB, option map[string]interface{}) error {
+	m, err := newSchemaMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*schemaLockTimeout*time.Second)
+	defer cancel()
+
+	if auto, ok := option[optionAutoMigrateSchema].(bool); !ok || auto {
+		return m.upgrade(ctx)
+	}
+	pending, _, err := m.pending(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, delta := range pending {
+		log.Warnf("[Store][database] schema delta %s is not applied, please apply it manually", delta.script)
+	}
+	return nil
+}
+
+// DryRunSchema 输出数据库尚未执行的 schema 变更语句，不做任何修改
+func DryRunSchema(conf *store.Config, w io.Writer) error {
+	masterConfig, _, err := parseDatabaseConf(conf.Option)
+	if err != nil {
+		return err
+	}
+	db, err := NewBaseDB(masterConfig, plugin.GetParsePassword())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	m, err := newSchemaMigrator(db)
+	if err != nil {
+		return err
+	}
+	pending, detected, err := m.pending(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	for _, delta := range detected {
+		_, _ = fmt.Fprintf(w, "-- %s already applied, will be recorded in schema_version\n", delta.script)
+	}
+	if len(pending) == 0 {
+		_, _ = fmt.Fprintf(w, "-- schema is up to date, version %s\n", m.latest())
+		return nil
+	}
+	for _, delta := range pending {
+		_, _ = fmt.Fprintf(w, "-- %s: %s -> %s\n", delta.script, delta.from, delta.to)
+		for _, stmt := range delta.statements {
+			_, _ = fmt.Fprintf(w, "%s;\n\n", stmt)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSchemaDeltas(t *testing.T) {
+	deltas, err := loadSchemaDeltas()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, deltas)
+
+	for i, delta := range deltas {
+		// 每个增量变更都需要能够通过探测识别是否已经执行
+		_, ok := deltaProbes[delta.to.String()]
+		assert.True(t, ok, "missing probe of %s", delta.script)
+		assert.NotEmpty(t, delta.statements, delta.script)
+		if i > 0 {
+			assert.Equal(t, deltas[i-1].to, delta.from, delta.script)
+		}
+		for _, stmt := range delta.statements {
+			assert.False(t, strings.HasPrefix(strings.ToUpper(stmt), "USE"), stmt)
+			assert.False(t, strings.Contains(stmt, "--"), stmt)
+		}
+	}
+
+	// 字段只在不存在时添加，脚本可以重复执行
+	v15 := deltas[len(deltas)-7]
+	assert.Equal(t, "1.15.0", v15.to.String())
+	assert.Equal(t, 8, len(v15.statements))
+	assert.True(t, strings.HasPrefix(v15.statements[0], "SET @ddl = IF("))
+	assert.Contains(t, v15.statements[0], "ALTER TABLE `user` ADD COLUMN `password_history`")
+	assert.Equal(t, "PREPARE stmt FROM @ddl", v15.statements[1])
+	assert.Contains(t, v15.statements[4], "ALTER TABLE `user` ADD COLUMN `password_mtime`")
+
+	// 1.16 的各个功能使用单独的脚本，每个脚本只创建一张表
+	tables := []string{"change_log", "maintain_job_run", "whitelist_rule", "service_dependency",
+		"instance_event", "instance_heartbeat"}
+	for i, table := range tables {
+		delta := deltas[len(deltas)-len(tables)+i]
+		assert.Equal(t, schemaVersion{1, 16, i}, delta.to, delta.script)
+		assert.Equal(t, 1, len(delta.statements), delta.script)
+		assert.True(t, strings.HasPrefix(delta.statements[0], "CREATE TABLE IF NOT EXISTS `"+table+"`"),
+			delta.script)
+		assert.Equal(t, tableProbe(table), deltaProbes[delta.to.String()])
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `/*
+ * license; header
+ */
+-- comment; with semicolon
+USE ` + "`polaris_server`" + `;
+INSERT INTO t (a, b) VALUES ('x;y', "it\"s;"); -- tail comment
+CREATE
+    DATABASE IF NOT EXISTS ` + "`polaris_server`" + `;
+ALTER TABLE ` + "`a;b`" + ` ADD COLUMN c int`
+
+	statements := splitStatements(content)
+	assert.Equal(t, []string{
+		"USE `polaris_server`",
+		`INSERT INTO t (a, b) VALUES ('x;y', "it\"s;")`,
+		"CREATE\n    DATABASE IF NOT EXISTS `polaris_server`",
+		"ALTER TABLE `a;b` ADD COLUMN c int",
+	}, statements)
+
+	assert.Equal(t, []string{
+		`INSERT INTO t (a, b) VALUES ('x;y', "it\"s;")`,
+		"ALTER TABLE `a;b` ADD COLUMN c int",
+	}, filterDeltaStatements(statements))
+}
+
+func TestSchemaVersion(t *testing.T) {
+	v, err := parseSchemaVersion("1.15.0")
+	assert.NoError(t, err)
+	assert.Equal(t, schemaVersion{1, 15, 0}, v)
+	assert.Equal(t, "1.15.0", v.String())
+
+	_, err = parseSchemaVersion("1.15")
+	assert.Error(t, err)
+
+	assert.True(t, schemaVersion{1, 9, 0}.less(schemaVersion{1, 15, 0}))
+	assert.False(t, schemaVersion{1, 15, 0}.less(schemaVersion{1, 15, 0}))
+	assert.False(t, schemaVersion{2, 0, 0}.less(schemaVersion{1, 15, 0}))
+}
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.15.0
+-- MySQL does not support ADD COLUMN IF NOT EXISTS, only add the columns which do not exist
+SET @ddl = IF((SELECT count(*) FROM information_schema.columns
+                WHERE table_schema = database() AND table_name = 'user' AND column_name = 'password_history') > 0,
+               "SELECT 1",
+               "ALTER TABLE `user` ADD COLUMN `password_history` VARCHAR(1024) NOT NULL DEFAULT '' comment 'Hashes of the previous passwords, newest first'");
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+SET @ddl = IF((SELECT count(*) FROM information_schema.columns
+                WHERE table_schema = database() AND table_name = 'user' AND column_name = 'password_mtime') > 0,
+               "SELECT 1",
+               "ALTER TABLE `user` ADD COLUMN `password_mtime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Last time the password was changed'");
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
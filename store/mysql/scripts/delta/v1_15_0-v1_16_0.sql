@@ -0,0 +1,30 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.0
+CREATE TABLE IF NOT EXISTS `change_log`
+(
+    `seq`      BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT comment 'Change sequence',
+    `resource` VARCHAR(64)         NOT NULL comment 'Changed resource type',
+    `ctime`    timestamp           NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time',
+    PRIMARY KEY (`seq`),
+    KEY `ctime` (`ctime`)
+) ENGINE = InnoDB;
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.1
+CREATE TABLE IF NOT EXISTS `maintain_job_run`
+(
+    `id`           VARCHAR(128)  NOT NULL comment 'Unique ID',
+    `name`         VARCHAR(128)  NOT NULL comment 'Maintain job name',
+    `trigger_type` VARCHAR(32)   NOT NULL comment 'How the run is triggered, cron or manual',
+    `operator`     VARCHAR(128)  NOT NULL DEFAULT '' comment 'Operator of the manual trigger',
+    `host`         VARCHAR(128)  NOT NULL comment 'Host which executes the job',
+    `status`       VARCHAR(32)   NOT NULL comment 'Run status, running, success or failed',
+    `message`      TEXT comment 'Run result or failure reason',
+    `affected`     MEDIUMTEXT comment 'Affected resources, json array',
+    `start_time`   timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Start time',
+    `end_time`     timestamp     NULL DEFAULT NULL comment 'End time',
+    PRIMARY KEY (`id`),
+    KEY `name_start_time` (`name`, `start_time`)
+) ENGINE = InnoDB;
@@ -0,0 +1,33 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.2
+CREATE TABLE IF NOT EXISTS `whitelist_rule`
+(
+    `id`       VARCHAR(128) NOT NULL comment 'Unique ID',
+    `api`      VARCHAR(256) NOT NULL DEFAULT '' comment 'API path prefix, empty means all APIs',
+    `cidr`     VARCHAR(64)  NOT NULL comment 'IP address or CIDR range',
+    `action`   VARCHAR(16)  NOT NULL comment 'allow or deny',
+    `comment`  VARCHAR(1024) NOT NULL DEFAULT '' comment 'Description',
+    `operator` VARCHAR(128) NOT NULL DEFAULT '' comment 'Operator',
+    `ctime`    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB;
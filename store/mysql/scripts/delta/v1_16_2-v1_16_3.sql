@@ -0,0 +1,36 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.3
+CREATE TABLE IF NOT EXISTS `service_dependency`
+(
+    `caller_namespace` VARCHAR(64)   NOT NULL comment 'Caller namespace',
+    `caller_service`   VARCHAR(128)  NOT NULL comment 'Caller service',
+    `caller_hosts`     VARCHAR(1024) NOT NULL DEFAULT '' comment 'Recently seen caller hosts, separated by comma',
+    `caller_verified`  TINYINT(1)    NOT NULL DEFAULT 0 comment 'Whether the caller service is authenticated',
+    `callee_namespace` VARCHAR(64)   NOT NULL comment 'Callee namespace',
+    `callee_service`   VARCHAR(128)  NOT NULL comment 'Callee service',
+    `first_seen`       timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'First discover time',
+    `last_seen`        timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Last discover time',
+    PRIMARY KEY (`callee_namespace`, `callee_service`, `caller_namespace`, `caller_service`),
+    KEY `caller` (`caller_namespace`, `caller_service`),
+    KEY `last_seen` (`last_seen`)
+) ENGINE = InnoDB;
@@ -0,0 +1,41 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.4
+CREATE TABLE IF NOT EXISTS `instance_event`
+(
+    `id`          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT comment 'Auto increment ID',
+    `event_type`  VARCHAR(64)     NOT NULL comment 'Instance event type',
+    `namespace`   VARCHAR(64)     NOT NULL comment 'Namespace',
+    `service`     VARCHAR(128)    NOT NULL comment 'Service name',
+    `instance_id` VARCHAR(128)    NOT NULL comment 'Instance ID',
+    `host`        VARCHAR(128)    NOT NULL comment 'Instance host',
+    `port`        INT(11)         NOT NULL comment 'Instance port',
+    `weight`      INT(11)         NOT NULL DEFAULT 0 comment 'Instance weight after the event',
+    `healthy`     TINYINT(4)      NOT NULL DEFAULT 0 comment 'Instance health status after the event',
+    `isolate`     TINYINT(4)      NOT NULL DEFAULT 0 comment 'Instance isolate status after the event',
+    `server`      VARCHAR(128)    NOT NULL DEFAULT '' comment 'Server which produces the event',
+    `ctime`       timestamp(3)    NOT NULL DEFAULT CURRENT_TIMESTAMP(3) comment 'Event time',
+    PRIMARY KEY (`id`),
+    KEY `service_ctime` (`namespace`, `service`, `ctime`),
+    KEY `instance_ctime` (`instance_id`, `ctime`),
+    KEY `ctime` (`ctime`)
+) ENGINE = InnoDB;
@@ -0,0 +1,32 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- v1.16.5
+CREATE TABLE IF NOT EXISTS `instance_heartbeat`
+(
+    `instance_id`    VARCHAR(128) NOT NULL comment 'Instance ID',
+    `server`         VARCHAR(128) NOT NULL DEFAULT '' comment 'Server which receives the last heartbeat',
+    `last_heartbeat` BIGINT       NOT NULL DEFAULT 0 comment 'Last heartbeat time in seconds',
+    `count`          BIGINT       NOT NULL DEFAULT 0 comment 'Heartbeat report count',
+    `mtime`          timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) comment 'Last modify time',
+    PRIMARY KEY (`instance_id`),
+    KEY `mtime` (`mtime`)
+) ENGINE = InnoDB;
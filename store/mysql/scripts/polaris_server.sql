@@ -698,6 +698,8 @@ CREATE TABLE `user`
     `source`       VARCHAR(32)  NOT NULL comment 'Account source',
     `mobile`       VARCHAR(12)  NOT NULL DEFAULT '' comment 'Account mobile phone number',
     `email`        VARCHAR(64)  NOT NULL DEFAULT '' comment 'Account mailbox',
+    `password_history` VARCHAR(1024) NOT NULL DEFAULT '' comment 'Hashes of the previous passwords, newest first',
+    `password_mtime` timestamp  NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Last time the password was changed',
     `token`        VARCHAR(255) NOT NULL comment 'The token information owned by the account can be used for SDK access authentication',
     `token_enable` tinyint(4)   NOT NULL DEFAULT 1,
     `user_type`    int          NOT NULL DEFAULT 20 comment 'Account type, 0 is the admin super account, 20 is the primary account, 50 for the child account',
@@ -989,4 +991,111 @@ CREATE TABLE `fault_detect_rule`
     PRIMARY KEY (`id`),
     KEY `name` (`name`),
     KEY `mtime` (`mtime`)
-) engine = innodb;
\ No newline at end of file
+) engine = innodb;
+
+-- Change log of the data tables, the caches refresh on demand by tailing it
+CREATE TABLE `change_log`
+(
+    `seq`      BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT comment 'Change sequence',
+    `resource` VARCHAR(64)         NOT NULL comment 'Changed resource type',
+    `ctime`    timestamp           NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time',
+    PRIMARY KEY (`seq`),
+    KEY `ctime` (`ctime`)
+) ENGINE = InnoDB;
+
+CREATE TABLE `maintain_job_run`
+(
+    `id`           VARCHAR(128)  NOT NULL comment 'Unique ID',
+    `name`         VARCHAR(128)  NOT NULL comment 'Maintain job name',
+    `trigger_type` VARCHAR(32)   NOT NULL comment 'How the run is triggered, cron or manual',
+    `operator`     VARCHAR(128)  NOT NULL DEFAULT '' comment 'Operator of the manual trigger',
+    `host`         VARCHAR(128)  NOT NULL comment 'Host which executes the job',
+    `status`       VARCHAR(32)   NOT NULL comment 'Run status, running, success or failed',
+    `message`      TEXT comment 'Run result or failure reason',
+    `affected`     MEDIUMTEXT comment 'Affected resources, json array',
+    `start_time`   timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Start time',
+    `end_time`     timestamp     NULL DEFAULT NULL comment 'End time',
+    PRIMARY KEY (`id`),
+    KEY `name_start_time` (`name`, `start_time`)
+) ENGINE = InnoDB;
+
+CREATE TABLE `whitelist_rule`
+(
+    `id`       VARCHAR(128) NOT NULL comment 'Unique ID',
+    `api`      VARCHAR(256) NOT NULL DEFAULT '' comment 'API path prefix, empty means all APIs',
+    `cidr`     VARCHAR(64)  NOT NULL comment 'IP address or CIDR range',
+    `action`   VARCHAR(16)  NOT NULL comment 'allow or deny',
+    `comment`  VARCHAR(1024) NOT NULL DEFAULT '' comment 'Description',
+    `operator` VARCHAR(128) NOT NULL DEFAULT '' comment 'Operator',
+    `ctime`    timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB;
+
+CREATE TABLE `service_dependency`
+(
+    `caller_namespace` VARCHAR(64)   NOT NULL comment 'Caller namespace',
+    `caller_service`   VARCHAR(128)  NOT NULL comment 'Caller service',
+    `caller_hosts`     VARCHAR(1024) NOT NULL DEFAULT '' comment 'Recently seen caller hosts, separated by comma',
+    `caller_verified`  TINYINT(1)    NOT NULL DEFAULT 0 comment 'Whether the caller service is authenticated',
+    `callee_namespace` VARCHAR(64)   NOT NULL comment 'Callee namespace',
+    `callee_service`   VARCHAR(128)  NOT NULL comment 'Callee service',
+    `first_seen`       timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'First discover time',
+    `last_seen`        timestamp     NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Last discover time',
+    PRIMARY KEY (`callee_namespace`, `callee_service`, `caller_namespace`, `caller_service`),
+    KEY `caller` (`caller_namespace`, `caller_service`),
+    KEY `last_seen` (`last_seen`)
+) ENGINE = InnoDB;
+
+CREATE TABLE `instance_event`
+(
+    `id`          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT comment 'Auto increment ID',
+    `event_type`  VARCHAR(64)     NOT NULL comment 'Instance event type',
+    `namespace`   VARCHAR(64)     NOT NULL comment 'Namespace',
+    `service`     VARCHAR(128)    NOT NULL comment 'Service name',
+    `instance_id` VARCHAR(128)    NOT NULL comment 'Instance ID',
+    `host`        VARCHAR(128)    NOT NULL comment 'Instance host',
+    `port`        INT(11)         NOT NULL comment 'Instance port',
+    `weight`      INT(11)         NOT NULL DEFAULT 0 comment 'Instance weight after the event',
+    `healthy`     TINYINT(4)      NOT NULL DEFAULT 0 comment 'Instance health status after the event',
+    `isolate`     TINYINT(4)      NOT NULL DEFAULT 0 comment 'Instance isolate status after the event',
+    `server`      VARCHAR(128)    NOT NULL DEFAULT '' comment 'Server which produces the event',
+    `ctime`       timestamp(3)    NOT NULL DEFAULT CURRENT_TIMESTAMP(3) comment 'Event time',
+    PRIMARY KEY (`id`),
+    KEY `service_ctime` (`namespace`, `service`, `ctime`),
+    KEY `instance_ctime` (`instance_id`, `ctime`),
+    KEY `ctime` (`ctime`)
+) ENGINE = InnoDB;
+
+CREATE TABLE `instance_heartbeat`
+(
+    `instance_id`    VARCHAR(128) NOT NULL comment 'Instance ID',
+    `server`         VARCHAR(128) NOT NULL DEFAULT '' comment 'Server which receives the last heartbeat',
+    `last_heartbeat` BIGINT       NOT NULL DEFAULT 0 comment 'Last heartbeat time in seconds',
+    `count`          BIGINT       NOT NULL DEFAULT 0 comment 'Heartbeat report count',
+    `mtime`          timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) comment 'Last modify time',
+    PRIMARY KEY (`instance_id`),
+    KEY `mtime` (`mtime`)
+) ENGINE = InnoDB;
+
+-- Applied schema delta scripts, the server applies pending delta scripts automatically on startup
+CREATE TABLE `schema_version`
+(
+    `version` VARCHAR(32)  NOT NULL comment 'schema version after the delta script applied',
+    `script`  VARCHAR(128) NOT NULL comment 'delta script name',
+    `ctime`   timestamp    NOT NULL DEFAULT CURRENT_TIMESTAMP comment 'Create time',
+    PRIMARY KEY (`version`)
+) ENGINE = InnoDB;
+
+INSERT INTO `schema_version` (`version`, `script`)
+VALUES ('1.7.0', 'v1_6_0-v1_7_0.sql'),
+       ('1.8.0', 'v1_7_0-v1_8_0.sql'),
+       ('1.11.0', 'v1_8_0-v1_11_0.sql'),
+       ('1.12.0', 'v1_11_0-v1_12_0.sql'),
+       ('1.14.0', 'v1_12_0-v1_14_0.sql'),
+       ('1.15.0', 'v1_14_0-v1_15_0.sql'),
+       ('1.16.0', 'v1_15_0-v1_16_0.sql'),
+       ('1.16.1', 'v1_16_0-v1_16_1.sql'),
+       ('1.16.2', 'v1_16_1-v1_16_2.sql'),
+       ('1.16.3', 'v1_16_2-v1_16_3.sql'),
+       ('1.16.4', 'v1_16_3-v1_16_4.sql'),
+       ('1.16.5', 'v1_16_4-v1_16_5.sql');
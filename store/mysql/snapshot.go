@@ -0,0 +1,241 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/polarismesh/polaris/store"
+)
+
+// snapshotStatements 在一个新的连接上开启只读的一致性快照事务
+var snapshotStatements = []string{
+	"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+	"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+}
+
+// Snapshot 返回在同一个 REPEATABLE READ 一致性快照事务内读取数据的存储视图，备份时使用
+func (s *stableStore) Snapshot() (store.Store, func() error, error) {
+	if s.master == nil {
+		return nil, nil, errors.New("store is not initialized")
+	}
+	db, release, err := openSnapshotDB(s.master.cfg)
+	if err != nil {
+		log.Errorf("[Store][database] open snapshot err: %s", err.Error())
+		return nil, nil, store.Error(err)
+	}
+	base := &BaseDB{DB: db, cfg: s.master.cfg}
+	view := &stableStore{master: base, masterTx: base, slave: base, start: true}
+	view.newStore()
+	return view, release, nil
+}
+
+// openSnapshotDB 打开一个只包含一个物理连接的 sql.DB，所有查询都在该连接的快照事务内执行
+func openSnapshotDB(cfg *dbConfig) (*sql.DB, func() error, error) {
+	// 开启参数插值，带参数的查询不需要在连接上预处理语句
+	conn, err := mysql.MySQLDriver{}.Open(cfg.dsn() + "?interpolateParams=true")
+	if err != nil {
+		return nil, nil, err
+	}
+	return newSnapshotDB(conn)
+}
+
+// newSnapshotDB 在 conn 上开启快照事务，释放时提交事务并关闭 conn
+func newSnapshotDB(conn driver.Conn) (*sql.DB, func() error, error) {
+	session := &snapshotSession{conn: conn}
+	for _, stmt := range snapshotStatements {
+		if _, err := session.exec(context.Background(), stmt, nil); err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+	}
+	db := sql.OpenDB(session)
+	release := func() error {
+		_ = db.Close()
+		_, err := session.exec(context.Background(), "COMMIT", nil)
+		_ = conn.Close()
+		return err
+	}
+	return db, release, nil
+}
+
+// snapshotSession 持有快照事务所在的物理连接，database/sql 的每个连接都映射到该物理连接上。
+// 查询结果在持有锁时全部读取到内存中，因此读取结果的同时执行其他查询不会阻塞
+type snapshotSession struct {
+	mu   sync.Mutex
+	conn driver.Conn
+}
+
+// Connect 实现 driver.Connector
+func (s *snapshotSession) Connect(context.Context) (driver.Conn, error) {
+	return &snapshotConn{session: s}, nil
+}
+
+// Driver 实现 driver.Connector
+func (s *snapshotSession) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+func (s *snapshotSession) exec(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (s *snapshotSession) query(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows, err := s.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ret := &bufferedRows{columns: rows.Columns()}
+	for {
+		values := make([]driver.Value, len(ret.columns))
+		if err := rows.Next(values); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// 驱动会复用读取缓冲区，这里需要拷贝一份
+		for i := range values {
+			if data, ok := values[i].([]byte); ok {
+				values[i] = append([]byte(nil), data...)
+			}
+		}
+		ret.values = append(ret.values, values)
+	}
+	return ret, nil
+}
+
+// snapshotConn database/sql 看到的连接，事务的开启以及提交都是空操作，读取始终在快照事务内
+type snapshotConn struct {
+	session *snapshotSession
+}
+
+// Prepare 实现 driver.Conn
+func (c *snapshotConn) Prepare(query string) (driver.Stmt, error) {
+	return &snapshotStmt{conn: c, query: query}, nil
+}
+
+// Close 实现 driver.Conn，物理连接在释放快照时关闭
+func (c *snapshotConn) Close() error {
+	return nil
+}
+
+// Begin 实现 driver.Conn
+func (c *snapshotConn) Begin() (driver.Tx, error) {
+	return snapshotTx{}, nil
+}
+
+// BeginTx 实现 driver.ConnBeginTx
+func (c *snapshotConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return snapshotTx{}, nil
+}
+
+// QueryContext 实现 driver.QueryerContext
+func (c *snapshotConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	return c.session.query(ctx, query, args)
+}
+
+// CheckNamedValue 实现 driver.NamedValueChecker，参数的转换规则与物理连接保持一致
+func (c *snapshotConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.session.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ExecContext 实现 driver.ExecerContext，快照事务是只读事务，写入语句会被数据库拒绝
+func (c *snapshotConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	return c.session.exec(ctx, query, args)
+}
+
+type snapshotStmt struct {
+	conn  *snapshotConn
+	query string
+}
+
+func (s *snapshotStmt) Close() error {
+	return nil
+}
+
+func (s *snapshotStmt) NumInput() int {
+	return -1
+}
+
+func (s *snapshotStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *snapshotStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	ret := make([]driver.NamedValue, 0, len(args))
+	for i := range args {
+		ret = append(ret, driver.NamedValue{Ordinal: i + 1, Value: args[i]})
+	}
+	return ret
+}
+
+type snapshotTx struct{}
+
+func (snapshotTx) Commit() error {
+	return nil
+}
+
+func (snapshotTx) Rollback() error {
+	return nil
+}
+
+// bufferedRows 已经全部读取到内存中的查询结果
+type bufferedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *bufferedRows) Columns() []string {
+	return r.columns
+}
+
+func (r *bufferedRows) Close() error {
+	r.values = nil
+	return nil
+}
+
+func (r *bufferedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSnapshotConn 记录执行过的语句，查询返回两行数据
+type fakeSnapshotConn struct {
+	statements []string
+	// querying 上一次查询的结果还没有读取完
+	querying bool
+}
+
+func (c *fakeSnapshotConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *fakeSnapshotConn) Close() error {
+	c.statements = append(c.statements, "CLOSE")
+	return nil
+}
+
+func (c *fakeSnapshotConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("begin not supported")
+}
+
+func (c *fakeSnapshotConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	c.statements = append(c.statements, query)
+	return driver.ResultNoRows, nil
+}
+
+func (c *fakeSnapshotConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	if c.querying {
+		return nil, errors.New("commands out of sync")
+	}
+	c.statements = append(c.statements, query)
+	c.querying = true
+	return &fakeSnapshotRows{conn: c, values: [][]byte{[]byte("a"), []byte("b")}}, nil
+}
+
+type fakeSnapshotRows struct {
+	conn   *fakeSnapshotConn
+	values [][]byte
+	buf    []byte
+}
+
+func (r *fakeSnapshotRows) Columns() []string {
+	return []string{"name"}
+}
+
+func (r *fakeSnapshotRows) Close() error {
+	r.conn.querying = false
+	return nil
+}
+
+func (r *fakeSnapshotRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	// 模拟驱动复用读取缓冲区
+	r.buf = append(r.buf[:0], r.values[0]...)
+	dest[0] = r.buf
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestSnapshotDB(t *testing.T) {
+	conn := &fakeSnapshotConn{}
+	db, release, err := newSnapshotDB(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshotStatements, conn.statements)
+
+	// 事务的开启以及提交都是空操作，读取结果的同时可以执行其他查询
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	rows, err := tx.Query("select name from t1 where id = ?", 1)
+	assert.NoError(t, err)
+	var names []string
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+
+		var inner string
+		assert.NoError(t, db.QueryRow("select name from t2").Scan(&inner))
+		assert.Equal(t, "a", inner)
+	}
+	assert.NoError(t, rows.Err())
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	assert.NoError(t, release())
+	assert.Equal(t, []string{"COMMIT", "CLOSE"}, conn.statements[len(conn.statements)-2:])
+}
@@ -48,6 +48,11 @@ var (
 	}
 )
 
+const (
+	// 历史密码 hash 之间的分隔符，bcrypt 的结果中不会出现该字符
+	passwordHistorySeparator = ","
+)
+
 type userStore struct {
 	master *BaseDB
 	slave  *BaseDB
@@ -83,7 +88,8 @@ func (u *userStore) addUser(user *model.User) error {
 
 	addSql := "INSERT INTO user(`id`, `name`, `password`, `owner`, `source`, `token`, " +
 		" `comment`, `flag`, `user_type`, " +
-		" `ctime`, `mtime`, `mobile`, `email`) VALUES (?,?,?,?,?,?,?,?,?,sysdate(),sysdate(),?,?)"
+		" `ctime`, `mtime`, `mobile`, `email`, `password_history`, `password_mtime`) " +
+		" VALUES (?,?,?,?,?,?,?,?,?,sysdate(),sysdate(),?,?,?,sysdate())"
 
 	_, err = tx.Exec(addSql, []interface{}{
 		user.ID,
@@ -97,6 +103,7 @@ func (u *userStore) addUser(user *model.User) error {
 		user.Type,
 		user.Mobile,
 		user.Email,
+		strings.Join(user.PasswordHistory, passwordHistorySeparator),
 	}...)
 
 	if err != nil {
@@ -149,8 +156,10 @@ func (u *userStore) updateUser(user *model.User) error {
 	}
 
 	modifySql := "UPDATE user SET password = ?, token = ?, comment = ?, token_enable = ?, mobile = ?, email = ?, " +
+		" password_history = ?, password_mtime = IF(? > 0, FROM_UNIXTIME(?), password_mtime), " +
 		" mtime = sysdate() WHERE id = ? AND flag = 0"
 
+	pwdModifyTime := timeToTimestamp(user.PasswordModifyTime)
 	_, err = tx.Exec(modifySql, []interface{}{
 		user.Password,
 		user.Token,
@@ -158,6 +167,9 @@ func (u *userStore) updateUser(user *model.User) error {
 		tokenEnable,
 		user.Mobile,
 		user.Email,
+		strings.Join(user.PasswordHistory, passwordHistorySeparator),
+		pwdModifyTime,
+		pwdModifyTime,
 		user.ID,
 	}...)
 
@@ -245,10 +257,14 @@ func (u *userStore) GetSubCount(user *model.User) (uint32, error) {
 
 // GetUser get user by user id
 func (u *userStore) GetUser(id string) (*model.User, error) {
-	var tokenEnable, userType int
+	var (
+		tokenEnable, userType int
+		pwdHistory            string
+		pwdMtime              int64
+	)
 	getSql := `
 		 SELECT u.id, u.name, u.password, u.owner, u.comment, u.source, u.token, u.token_enable, 
-		 	u.user_type, u.mobile, u.email
+		 	u.user_type, u.mobile, u.email, u.password_history, UNIX_TIMESTAMP(u.password_mtime)
 		 FROM user u
 		 WHERE u.flag = 0 AND u.id = ? 
 	  `
@@ -258,7 +274,7 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 	)
 
 	if err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Owner, &user.Comment, &user.Source,
-		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email); err != nil {
+		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email, &pwdHistory, &pwdMtime); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -269,6 +285,8 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 
 	user.TokenEnable = tokenEnable == 1
 	user.Type = model.UserRoleType(userType)
+	user.PasswordHistory = splitPasswordHistory(pwdHistory)
+	user.PasswordModifyTime = time.Unix(pwdMtime, 0)
 	return user, nil
 }
 
@@ -276,7 +294,7 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 	getSql := `
 		 SELECT u.id, u.name, u.password, u.owner, u.comment, u.source, u.token, u.token_enable, 
-		 	u.user_type, u.mobile, u.email
+		 	u.user_type, u.mobile, u.email, u.password_history, UNIX_TIMESTAMP(u.password_mtime)
 		 FROM user u
 		 WHERE u.flag = 0
 			  AND u.name = ?
@@ -287,10 +305,12 @@ func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 		row                   = u.master.QueryRow(getSql, name, ownerId)
 		user                  = new(model.User)
 		tokenEnable, userType int
+		pwdHistory            string
+		pwdMtime              int64
 	)
 
 	if err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Owner, &user.Comment, &user.Source,
-		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email); err != nil {
+		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email, &pwdHistory, &pwdMtime); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -301,6 +321,8 @@ func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 
 	user.TokenEnable = tokenEnable == 1
 	user.Type = model.UserRoleType(userType)
+	user.PasswordHistory = splitPasswordHistory(pwdHistory)
+	user.PasswordModifyTime = time.Unix(pwdMtime, 0)
 	return user, nil
 }
 
@@ -314,6 +336,7 @@ func (u *userStore) GetUserByIds(ids []string) ([]*model.User, error) {
 	  SELECT u.id, u.name, u.password, u.owner, u.comment, u.source
 		  , u.token, u.token_enable, u.user_type, UNIX_TIMESTAMP(u.ctime)
 		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+		  , u.password_history, UNIX_TIMESTAMP(u.password_mtime)
 	  FROM user u
 	  WHERE u.flag = 0 
 		  AND u.id IN ( 
@@ -372,6 +395,7 @@ func (u *userStore) listUsers(filters map[string]string, offset uint32, limit ui
 	  SELECT id, name, password, owner, comment, source
 		  , token, token_enable, user_type, UNIX_TIMESTAMP(ctime)
 		  , UNIX_TIMESTAMP(mtime), flag, mobile, email
+		  , password_history, UNIX_TIMESTAMP(password_mtime)
 	  FROM user
 	  WHERE flag = 0 
 	  `
@@ -438,6 +462,7 @@ func (u *userStore) listGroupUsers(filters map[string]string, offset uint32, lim
 		  SELECT u.id, name, password, owner, u.comment, source
 			  , token, token_enable, user_type, UNIX_TIMESTAMP(u.ctime)
 			  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+		  , u.password_history, UNIX_TIMESTAMP(u.password_mtime)
 		  FROM user_group_relation ug
 			  LEFT JOIN user u ON ug.user_id = u.id AND u.flag = 0
 		  WHERE 1=1 
@@ -498,6 +523,7 @@ func (u *userStore) GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*mode
 	  SELECT u.id, u.name, u.password, u.owner, u.comment, u.source
 		  , u.token, u.token_enable, user_type, UNIX_TIMESTAMP(u.ctime)
 		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+		  , u.password_history, UNIX_TIMESTAMP(u.password_mtime)
 	  FROM user u 
 	  `
 
@@ -579,12 +605,13 @@ func createDefaultStrategy(tx *BaseTx, role model.PrincipalType, id, name, owner
 
 func fetchRown2User(rows *sql.Rows) (*model.User, error) {
 	var (
-		ctime, mtime                int64
+		ctime, mtime, pwdMtime      int64
 		flag, tokenEnable, userType int
+		pwdHistory                  string
 		user                        = new(model.User)
 		err                         = rows.Scan(&user.ID, &user.Name, &user.Password, &user.Owner,
 			&user.Comment, &user.Source, &user.Token, &tokenEnable, &userType, &ctime, &mtime,
-			&flag, &user.Mobile, &user.Email)
+			&flag, &user.Mobile, &user.Email, &pwdHistory, &pwdMtime)
 	)
 
 	if err != nil {
@@ -596,10 +623,20 @@ func fetchRown2User(rows *sql.Rows) (*model.User, error) {
 	user.CreateTime = time.Unix(ctime, 0)
 	user.ModifyTime = time.Unix(mtime, 0)
 	user.Type = model.UserRoleType(userType)
+	user.PasswordHistory = splitPasswordHistory(pwdHistory)
+	user.PasswordModifyTime = time.Unix(pwdMtime, 0)
 
 	return user, nil
 }
 
+// splitPasswordHistory 将存储的历史密码字段拆分为列表
+func splitPasswordHistory(val string) []string {
+	if val == "" {
+		return nil
+	}
+	return strings.Split(val, passwordHistorySeparator)
+}
+
 func (u *userStore) cleanInValidUser(name, owner string) error {
 	log.Infof("[Store][User] clean user, name=(%s), owner=(%s)", name, owner)
 	str := "delete from user where name = ? and owner = ? and flag = 1"
@@ -0,0 +1,71 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_whitelistStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &whitelistStore{master: &BaseDB{DB: db}}
+	ctime := time.Unix(1700000000, 0)
+
+	mock.ExpectExec("insert into whitelist_rule (id, api, cidr, action, comment, operator, ctime) "+
+		"values (?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))").
+		WithArgs("rule-1", "/maintain/v1", "10.0.0.0/8", "allow", "ops subnet", "polaris", ctime.Unix()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	assert.NoError(t, s.AddWhitelistRule(&model.WhitelistRule{
+		ID:         "rule-1",
+		API:        "/maintain/v1",
+		CIDR:       "10.0.0.0/8",
+		Action:     model.WhitelistAllow,
+		Comment:    "ops subnet",
+		Operator:   "polaris",
+		CreateTime: ctime,
+	}))
+
+	mock.ExpectQuery("select id, api, cidr, action, comment, operator, UNIX_TIMESTAMP(ctime) " +
+		"from whitelist_rule order by ctime, id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "api", "cidr", "action", "comment", "operator", "ctime"}).
+			AddRow("rule-1", "/maintain/v1", "10.0.0.0/8", "allow", "ops subnet", "polaris", ctime.Unix()).
+			AddRow("rule-2", "", "fd00::/8", "deny", "", "polaris", ctime.Unix()+1))
+	rules, err := s.GetWhitelistRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "10.0.0.0/8", rules[0].CIDR)
+	assert.Equal(t, ctime, rules[0].CreateTime)
+	assert.Equal(t, model.WhitelistDeny, rules[1].Action)
+
+	mock.ExpectExec("delete from whitelist_rule where id = ?").WithArgs("rule-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.DeleteWhitelistRule("rule-1"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,331 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/polarismesh/polaris/common/tracing"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// driverName lib/pq 注册的驱动名称
+	driverName = "postgres"
+	// defaultSSLMode 默认不使用 SSL 连接
+	defaultSSLMode = "disable"
+	// dbSystem 链路数据中的数据库类型
+	dbSystem = "postgresql"
+)
+
+// db抛出的异常，需要重试的字符串组
+var errMsg = []string{"deadlock detected", "could not serialize access", "bad connection", "invalid connection"}
+
+// BaseDB 对sql.DB的封装
+// 存储层的 SQL 语句统一使用 ? 作为占位符，执行前转换为 postgres 的 $n 占位符
+type BaseDB struct {
+	*sql.DB
+	cfg            *dbConfig
+	isolationLevel sql.IsolationLevel
+	parsePwd       plugin.ParsePassword
+}
+
+// dbConfig store的配置
+type dbConfig struct {
+	dbUser           string
+	dbPwd            string
+	dbAddr           string
+	dbName           string
+	sslMode          string
+	maxOpenConns     int
+	maxIdleConns     int
+	connMaxLifetime  int
+	txIsolationLevel int
+}
+
+// NewBaseDB 新建一个BaseDB
+func NewBaseDB(cfg *dbConfig, parsePwd plugin.ParsePassword) (*BaseDB, error) {
+	baseDb := &BaseDB{cfg: cfg, parsePwd: parsePwd}
+	if cfg.txIsolationLevel > 0 {
+		baseDb.isolationLevel = sql.IsolationLevel(cfg.txIsolationLevel)
+		log.Infof("[Store][database] use isolation level: %s", baseDb.isolationLevel.String())
+	}
+
+	if err := baseDb.openDatabase(); err != nil {
+		return nil, err
+	}
+
+	return baseDb, nil
+}
+
+// openDatabase 与数据库进行连接
+func (b *BaseDB) openDatabase() error {
+	c := b.cfg
+
+	// 使用密码解析插件
+	if b.parsePwd != nil {
+		pwd, err := b.parsePwd.ParsePassword(c.dbPwd)
+		if err != nil {
+			log.Errorf("[Store][database][ParsePwdPlugin] parse password err: %s", err.Error())
+			return err
+		}
+		c.dbPwd = pwd
+	}
+
+	db, err := sql.Open(driverName, buildDSN(c))
+	if err != nil {
+		log.Errorf("[Store][database] sql open err: %s", err.Error())
+		return err
+	}
+	if pingErr := db.Ping(); pingErr != nil {
+		log.Errorf("[Store][database] database ping err: %s", pingErr.Error())
+		return pingErr
+	}
+	if c.maxOpenConns > 0 {
+		log.Infof("[Store][database] db set max open conns: %d", c.maxOpenConns)
+		db.SetMaxOpenConns(c.maxOpenConns)
+	}
+	if c.maxIdleConns > 0 {
+		log.Infof("[Store][database] db set max idle conns: %d", c.maxIdleConns)
+		db.SetMaxIdleConns(c.maxIdleConns)
+	}
+	if c.connMaxLifetime > 0 {
+		log.Infof("[Store][database] db set conn max life time: %d", c.connMaxLifetime)
+		db.SetConnMaxLifetime(time.Second * time.Duration(c.connMaxLifetime))
+	}
+
+	b.DB = db
+	return nil
+}
+
+// buildDSN 生成 lib/pq 的连接串，dbAddr 未指定端口时使用 5432
+func buildDSN(c *dbConfig) string {
+	host := c.dbAddr
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "5432")
+	}
+	sslMode := c.sslMode
+	if sslMode == "" {
+		sslMode = defaultSSLMode
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.dbUser, c.dbPwd),
+		Host:     host,
+		Path:     "/" + c.dbName,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// Exec 重写db.Exec函数 提供重试功能
+func (b *BaseDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	var err error
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "exec", tracing.Statement(query))
+	Retry("exec "+query, func() error {
+		result, err = b.DB.Exec(query, args...)
+		return err
+	})
+	tracing.EndSpan(span, err)
+
+	return result, err
+}
+
+// Query 重写db.Query函数
+func (b *BaseDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	var err error
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	Retry("query "+query, func() error {
+		rows, err = b.DB.Query(query, args...)
+		return err
+	})
+	tracing.EndSpan(span, err)
+
+	return rows, err
+}
+
+// QueryRow 重写db.QueryRow函数
+func (b *BaseDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	row := b.DB.QueryRow(query, args...)
+	tracing.EndSpan(span, row.Err())
+	return row
+}
+
+// Begin 重写db.Begin
+func (b *BaseDB) Begin() (*BaseTx, error) {
+	var tx *sql.Tx
+	var err error
+	var option *sql.TxOptions
+	if b.isolationLevel > 0 {
+		option = &sql.TxOptions{Isolation: sql.IsolationLevel(b.isolationLevel)}
+	}
+	Retry("begin", func() error {
+		tx, err = b.DB.BeginTx(context.Background(), option)
+		return err
+	})
+
+	return &BaseTx{Tx: tx}, err
+}
+
+// BaseTx 对sql.Tx的封装
+type BaseTx struct {
+	*sql.Tx
+}
+
+// Exec 重写tx.Exec函数
+func (t *BaseTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "exec", tracing.Statement(query))
+	result, err := t.Tx.Exec(query, args...)
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+// Query 重写tx.Query函数
+func (t *BaseTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	rows, err := t.Tx.Query(query, args...)
+	tracing.EndSpan(span, err)
+	return rows, err
+}
+
+// QueryRow 重写tx.QueryRow函数
+func (t *BaseTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	query, args = rebind(query, args)
+	span := tracing.StartStoreSpan(dbSystem, "query", tracing.Statement(query))
+	row := t.Tx.QueryRow(query, args...)
+	tracing.EndSpan(span, row.Err())
+	return row
+}
+
+// Commit 重写tx.Commit函数
+func (t *BaseTx) Commit() error {
+	span := tracing.StartStoreSpan(dbSystem, "commit")
+	err := t.Tx.Commit()
+	tracing.EndSpan(span, err)
+	return err
+}
+
+// rebind 将 ? 占位符转换为 $1...$n，字符串常量以及带引号的标识符中的 ? 保持不变
+// 表中的布尔语义字段与 MySQL 一致使用 smallint 存储，bool 类型的参数同时转换为 1/0
+func rebind(query string, args []interface{}) (string, []interface{}) {
+	if strings.IndexByte(query, '?') >= 0 {
+		var (
+			builder = strings.Builder{}
+			index   = 0
+			quote   byte
+		)
+		builder.Grow(len(query) + 8)
+		for i := 0; i < len(query); i++ {
+			c := query[i]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '?':
+				index++
+				builder.WriteString(fmt.Sprintf("$%d", index))
+				continue
+			}
+			builder.WriteByte(c)
+		}
+		query = builder.String()
+	}
+	for i := range args {
+		if _, ok := args[i].(bool); !ok {
+			continue
+		}
+		converted := make([]interface{}, len(args))
+		for j := range args {
+			if v, ok := args[j].(bool); ok {
+				converted[j] = boolToInt(v)
+			} else {
+				converted[j] = args[j]
+			}
+		}
+		return query, converted
+	}
+	return query, args
+}
+
+// Retry 重试主函数
+// 最多重试20次，每次等待5ms*重试次数
+func Retry(label string, handle func() error) {
+	var err error
+	maxTryTimes := 20
+	for i := 1; i <= maxTryTimes; i++ {
+		err = handle()
+		if err == nil {
+			return
+		}
+
+		repeated := false // 是否重试
+		for _, msg := range errMsg {
+			if strings.Contains(err.Error(), msg) {
+				log.Warnf("[Store][database][%s] get error msg: %s. Repeated doing(%d)", label, err.Error(), i)
+				time.Sleep(time.Millisecond * 5 * time.Duration(i))
+				repeated = true
+				break
+			}
+		}
+		if !repeated {
+			return
+		}
+	}
+}
+
+// RetryTransaction 事务重试
+func RetryTransaction(label string, handle func() error) error {
+	var err error
+	Retry(label, func() error {
+		err = handle()
+		return err
+	})
+	return err
+}
+
+func (b *BaseDB) processWithTransaction(label string, handle func(*BaseTx) error) error {
+	tx, err := b.Begin()
+	if err != nil {
+		log.Errorf("[Store][database] %s begin tx err: %s", label, err.Error())
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	return handle(tx)
+}
@@ -0,0 +1,44 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rebind(t *testing.T) {
+	t.Run("占位符按顺序转换", func(t *testing.T) {
+		query, args := rebind("select id from service where name = ? and namespace = ?", []interface{}{"a", "b"})
+		assert.Equal(t, "select id from service where name = $1 and namespace = $2", query)
+		assert.Equal(t, []interface{}{"a", "b"}, args)
+	})
+
+	t.Run("引号内的问号不转换", func(t *testing.T) {
+		query, _ := rebind("select '?', \"a?\" from t where id = ?", []interface{}{"1"})
+		assert.Equal(t, "select '?', \"a?\" from t where id = $1", query)
+	})
+
+	t.Run("bool参数转换为整数", func(t *testing.T) {
+		origin := []interface{}{"1", true, false}
+		_, args := rebind("update t set name = ?, enable = ?, flag = ?", origin)
+		assert.Equal(t, []interface{}{"1", 1, 0}, args)
+		assert.Equal(t, true, origin[1])
+	})
+}
@@ -0,0 +1,180 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// dependencyUpsertBatch 单条 SQL 批量写入依赖关系的最大数量
+	dependencyUpsertBatch = 100
+)
+
+// dependencyFilterConds 依赖关系支持的查询条件，按照固定顺序拼接 where 语句
+var dependencyFilterConds = []struct {
+	key  string
+	cond string
+}{
+	{key: "callee_namespace", cond: "callee_namespace = ?"},
+	{key: "callee_service", cond: "callee_service = ?"},
+	{key: "caller_namespace", cond: "caller_namespace = ?"},
+	{key: "caller_service", cond: "caller_service = ?"},
+	{key: "caller_host", cond: "? = ANY(string_to_array(caller_hosts, ','))"},
+}
+
+// dependencyStore 服务依赖关系的存储实现
+type dependencyStore struct {
+	master *BaseDB
+}
+
+// UpsertServiceDependencies 批量保存依赖关系，已存在的记录更新主调方地址、认证状态以及最近发现时间
+func (d *dependencyStore) UpsertServiceDependencies(deps []*model.ServiceDependency) error {
+	deps = mergeDependencies(deps)
+	for begin := 0; begin < len(deps); begin += dependencyUpsertBatch {
+		end := begin + dependencyUpsertBatch
+		if end > len(deps) {
+			end = len(deps)
+		}
+		if err := d.batchUpsert(deps[begin:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeDependencies 合并同一依赖关系的多条记录，postgres 不允许同一条语句多次更新同一行
+func mergeDependencies(deps []*model.ServiceDependency) []*model.ServiceDependency {
+	index := make(map[string]*model.ServiceDependency, len(deps))
+	ret := make([]*model.ServiceDependency, 0, len(deps))
+	for _, dep := range deps {
+		if exist, ok := index[dep.Key()]; ok {
+			exist.Merge(dep)
+			continue
+		}
+		merged := *dep
+		index[dep.Key()] = &merged
+		ret = append(ret, &merged)
+	}
+	return ret
+}
+
+func (d *dependencyStore) batchUpsert(deps []*model.ServiceDependency) error {
+	values := make([]string, 0, len(deps))
+	args := make([]interface{}, 0, len(deps)*8)
+	for _, dep := range deps {
+		values = append(values, "(?, ?, ?, ?, ?, ?, TO_TIMESTAMP(?), TO_TIMESTAMP(?))")
+		args = append(args, dep.CallerNamespace, dep.CallerService, strings.Join(dep.CallerHosts, ","),
+			dep.CallerVerified, dep.CalleeNamespace, dep.CalleeService, dep.FirstSeen.Unix(), dep.LastSeen.Unix())
+	}
+	// 更新语句中引用的都是更新前的字段值，caller_hosts 与更新前的 last_seen 比较
+	str := "insert into service_dependency (caller_namespace, caller_service, caller_hosts, caller_verified, " +
+		"callee_namespace, callee_service, first_seen, last_seen) values " + strings.Join(values, ", ") +
+		" on conflict (callee_namespace, callee_service, caller_namespace, caller_service) do update set " +
+		"caller_hosts = case when excluded.last_seen >= service_dependency.last_seen " +
+		"then excluded.caller_hosts else service_dependency.caller_hosts end, " +
+		"caller_verified = greatest(service_dependency.caller_verified, excluded.caller_verified), " +
+		"first_seen = least(service_dependency.first_seen, excluded.first_seen), " +
+		"last_seen = greatest(service_dependency.last_seen, excluded.last_seen)"
+	if _, err := d.master.Exec(str, args...); err != nil {
+		log.Errorf("[Store][database] upsert %d service dependencies err: %s", len(deps), err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetServiceDependencies 查询依赖关系，按照最近发现时间倒序返回
+func (d *dependencyStore) GetServiceDependencies(filter map[string]string, offset, limit uint32) (
+	uint32, []*model.ServiceDependency, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	for _, item := range dependencyFilterConds {
+		if value, ok := filter[item.key]; ok {
+			conds = append(conds, item.cond)
+			args = append(args, value)
+		}
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " where " + strings.Join(conds, " and ")
+	}
+
+	var total uint32
+	if err := d.master.QueryRow("select count(*) from service_dependency"+where, args...).Scan(&total); err != nil {
+		log.Errorf("[Store][database] count service dependencies err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	args = append(args, offset, limit)
+	rows, err := d.master.Query("select caller_namespace, caller_service, caller_hosts, caller_verified, "+
+		"callee_namespace, callee_service, EXTRACT(EPOCH FROM first_seen)::bigint, "+
+		"EXTRACT(EPOCH FROM last_seen)::bigint from service_dependency"+where+
+		" order by last_seen desc offset ? limit ?", args...)
+	if err != nil {
+		log.Errorf("[Store][database] get service dependencies err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var deps []*model.ServiceDependency
+	for rows.Next() {
+		var (
+			dep                 = &model.ServiceDependency{}
+			hosts               string
+			verified            int
+			firstSeen, lastSeen int64
+		)
+		err := rows.Scan(&dep.CallerNamespace, &dep.CallerService, &hosts, &verified,
+			&dep.CalleeNamespace, &dep.CalleeService, &firstSeen, &lastSeen)
+		if err != nil {
+			log.Errorf("[Store][database] fetch service dependency rows err: %s", err.Error())
+			return 0, nil, store.Error(err)
+		}
+		dep.CallerHosts = []string{}
+		if hosts != "" {
+			dep.CallerHosts = strings.Split(hosts, ",")
+		}
+		dep.CallerVerified = verified == 1
+		dep.FirstSeen = time.Unix(firstSeen, 0)
+		dep.LastSeen = time.Unix(lastSeen, 0)
+		deps = append(deps, dep)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch service dependency rows next err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	return total, deps, nil
+}
+
+// BatchCleanServiceDependencies 清理最近发现时间早于 before 的依赖关系
+func (d *dependencyStore) BatchCleanServiceDependencies(before time.Time, batchSize uint32) (uint32, error) {
+	result, err := d.master.Exec("delete from service_dependency where ctid in (select ctid from "+
+		"service_dependency where last_seen < TO_TIMESTAMP(?) limit ?)", unixSeconds(before), batchSize)
+	if err != nil {
+		log.Errorf("[Store][database] clean service dependencies before %s err: %s", before, err.Error())
+		return 0, store.Error(err)
+	}
+	affected, _ := result.RowsAffected()
+	return uint32(affected), nil
+}
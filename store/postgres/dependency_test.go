@@ -0,0 +1,127 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_dependencyStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &dependencyStore{master: &BaseDB{DB: db}}
+	seen := time.Unix(1700000000, 0)
+
+	// 同一依赖关系的多条记录合并为一条写入
+	mock.ExpectExec("insert into service_dependency (caller_namespace, caller_service, caller_hosts, "+
+		"caller_verified, callee_namespace, callee_service, first_seen, last_seen) values "+
+		"($1, $2, $3, $4, $5, $6, TO_TIMESTAMP($7), TO_TIMESTAMP($8)), "+
+		"($9, $10, $11, $12, $13, $14, TO_TIMESTAMP($15), TO_TIMESTAMP($16)) "+
+		"on conflict (callee_namespace, callee_service, caller_namespace, caller_service) do update set "+
+		"caller_hosts = case when excluded.last_seen >= service_dependency.last_seen "+
+		"then excluded.caller_hosts else service_dependency.caller_hosts end, "+
+		"caller_verified = greatest(service_dependency.caller_verified, excluded.caller_verified), "+
+		"first_seen = least(service_dependency.first_seen, excluded.first_seen), "+
+		"last_seen = greatest(service_dependency.last_seen, excluded.last_seen)").
+		WithArgs("default", "order", "10.0.0.2,10.0.0.1", 1, "default", "payment", seen.Unix(), seen.Unix()+5,
+			"default", "user", "10.0.0.3", 0, "default", "payment", seen.Unix(), seen.Unix()+10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	deps := []*model.ServiceDependency{
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.1"},
+			CallerVerified:  true,
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen,
+		},
+		{
+			CallerNamespace: "default",
+			CallerService:   "user",
+			CallerHosts:     []string{"10.0.0.3"},
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen,
+			LastSeen:        seen.Add(10 * time.Second),
+		},
+		{
+			CallerNamespace: "default",
+			CallerService:   "order",
+			CallerHosts:     []string{"10.0.0.2"},
+			CalleeNamespace: "default",
+			CalleeService:   "payment",
+			FirstSeen:       seen.Add(5 * time.Second),
+			LastSeen:        seen.Add(5 * time.Second),
+		},
+	}
+	assert.NoError(t, s.UpsertServiceDependencies(deps))
+	// 合并时不修改调用方传入的记录
+	assert.Equal(t, []string{"10.0.0.1"}, deps[0].CallerHosts)
+	assert.Equal(t, seen, deps[0].LastSeen)
+
+	mock.ExpectQuery("select count(*) from service_dependency where callee_namespace = $1 and "+
+		"callee_service = $2 and $3 = ANY(string_to_array(caller_hosts, ','))").
+		WithArgs("default", "payment", "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("select caller_namespace, caller_service, caller_hosts, caller_verified, "+
+		"callee_namespace, callee_service, EXTRACT(EPOCH FROM first_seen)::bigint, "+
+		"EXTRACT(EPOCH FROM last_seen)::bigint from service_dependency where callee_namespace = $1 and "+
+		"callee_service = $2 and $3 = ANY(string_to_array(caller_hosts, ',')) "+
+		"order by last_seen desc offset $4 limit $5").
+		WithArgs("default", "payment", "10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"caller_namespace", "caller_service", "caller_hosts",
+			"caller_verified", "callee_namespace", "callee_service", "first_seen", "last_seen"}).
+			AddRow("default", "user", "", 0, "default", "payment", seen.Unix(), seen.Unix()+10).
+			AddRow("default", "order", "10.0.0.1,10.0.0.2", 1, "default", "payment", seen.Unix(), seen.Unix()))
+	total, ret, err := s.GetServiceDependencies(map[string]string{
+		"callee_service":   "payment",
+		"callee_namespace": "default",
+		"caller_host":      "10.0.0.1",
+		"unknown":          "ignored",
+	}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+	assert.Len(t, ret, 2)
+	assert.Equal(t, []string{}, ret[0].CallerHosts)
+	assert.False(t, ret[0].CallerVerified)
+	assert.Equal(t, seen.Add(10*time.Second), ret[0].LastSeen)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, ret[1].CallerHosts)
+	assert.True(t, ret[1].CallerVerified)
+
+	mock.ExpectExec("delete from service_dependency where ctid in (select ctid from service_dependency "+
+		"where last_seen < TO_TIMESTAMP($1) limit $2)").
+		WithArgs(float64(seen.Unix()), 100).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	count, err := s.BatchCleanServiceDependencies(seen, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,144 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// instanceHeartbeatUpdateBatch 单条 SQL 批量写入心跳记录的最大数量
+	instanceHeartbeatUpdateBatch = 100
+	// heartbeatNewerCond 写入的心跳记录比已有记录更新的判断条件
+	heartbeatNewerCond = "excluded.last_heartbeat > instance_heartbeat.last_heartbeat or " +
+		"(excluded.last_heartbeat = instance_heartbeat.last_heartbeat and " +
+		"excluded.count >= instance_heartbeat.count)"
+)
+
+// instanceHeartbeatStore 实例心跳记录的存储实现
+type instanceHeartbeatStore struct {
+	master *BaseDB
+}
+
+// BatchUpdateInstanceHeartbeats 批量写入实例心跳记录
+func (h *instanceHeartbeatStore) BatchUpdateInstanceHeartbeats(heartbeats []*model.InstanceHeartbeat) error {
+	heartbeats = latestHeartbeats(heartbeats)
+	for begin := 0; begin < len(heartbeats); begin += instanceHeartbeatUpdateBatch {
+		end := begin + instanceHeartbeatUpdateBatch
+		if end > len(heartbeats) {
+			end = len(heartbeats)
+		}
+		if err := h.batchUpsert(heartbeats[begin:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestHeartbeats 同一实例只保留最新的心跳记录，postgres 不允许同一条语句多次更新同一行
+func latestHeartbeats(heartbeats []*model.InstanceHeartbeat) []*model.InstanceHeartbeat {
+	index := make(map[string]int, len(heartbeats))
+	ret := make([]*model.InstanceHeartbeat, 0, len(heartbeats))
+	for _, heartbeat := range heartbeats {
+		i, ok := index[heartbeat.InstanceID]
+		if !ok {
+			index[heartbeat.InstanceID] = len(ret)
+			ret = append(ret, heartbeat)
+			continue
+		}
+		exist := ret[i]
+		if heartbeat.LastHeartbeatSec > exist.LastHeartbeatSec ||
+			(heartbeat.LastHeartbeatSec == exist.LastHeartbeatSec && heartbeat.Count >= exist.Count) {
+			ret[i] = heartbeat
+		}
+	}
+	return ret
+}
+
+func (h *instanceHeartbeatStore) batchUpsert(heartbeats []*model.InstanceHeartbeat) error {
+	values := make([]string, 0, len(heartbeats))
+	args := make([]interface{}, 0, len(heartbeats)*4)
+	for _, heartbeat := range heartbeats {
+		values = append(values, "(?, ?, ?, ?, clock_timestamp())")
+		args = append(args, heartbeat.InstanceID, heartbeat.Server, heartbeat.LastHeartbeatSec, heartbeat.Count)
+	}
+	// 更新语句中引用的都是更新前的字段值，判断条件使用的是旧的心跳时间
+	str := "insert into instance_heartbeat (instance_id, server, last_heartbeat, count, mtime) values " +
+		strings.Join(values, ", ") + " on conflict (instance_id) do update set " +
+		"server = case when " + heartbeatNewerCond + " then excluded.server else instance_heartbeat.server end, " +
+		"count = case when " + heartbeatNewerCond + " then excluded.count else instance_heartbeat.count end, " +
+		"last_heartbeat = greatest(instance_heartbeat.last_heartbeat, excluded.last_heartbeat), " +
+		"mtime = clock_timestamp()"
+	if _, err := h.master.Exec(str, args...); err != nil {
+		log.Errorf("[Store][database] update %d instance heartbeats err: %s", len(heartbeats), err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetMoreInstanceHeartbeats 获取修改时间不早于 mtime 的心跳记录
+func (h *instanceHeartbeatStore) GetMoreInstanceHeartbeats(mtime time.Time) ([]*model.InstanceHeartbeat, error) {
+	str := "select instance_id, server, last_heartbeat, count, EXTRACT(EPOCH FROM mtime)::float8 " +
+		"from instance_heartbeat"
+	var args []interface{}
+	if !mtime.IsZero() {
+		str += " where mtime >= TO_TIMESTAMP(?)"
+		args = append(args, unixSeconds(mtime))
+	}
+	rows, err := h.master.Query(str, args...)
+	if err != nil {
+		log.Errorf("[Store][database] get more instance heartbeats err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var heartbeats []*model.InstanceHeartbeat
+	for rows.Next() {
+		var (
+			heartbeat = &model.InstanceHeartbeat{}
+			mtime     float64
+		)
+		err := rows.Scan(&heartbeat.InstanceID, &heartbeat.Server, &heartbeat.LastHeartbeatSec,
+			&heartbeat.Count, &mtime)
+		if err != nil {
+			log.Errorf("[Store][database] fetch instance heartbeat rows err: %s", err.Error())
+			return nil, store.Error(err)
+		}
+		heartbeat.ModifyTime = time.UnixMilli(int64(math.Round(mtime * 1000)))
+		heartbeats = append(heartbeats, heartbeat)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch instance heartbeat rows next err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	return heartbeats, nil
+}
+
+// DeleteInstanceHeartbeat 删除实例的心跳记录
+func (h *instanceHeartbeatStore) DeleteInstanceHeartbeat(instanceID string) error {
+	if _, err := h.master.Exec("delete from instance_heartbeat where instance_id = ?", instanceID); err != nil {
+		log.Errorf("[Store][database] delete instance(%s) heartbeat err: %s", instanceID, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
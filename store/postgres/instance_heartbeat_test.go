@@ -0,0 +1,73 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_instanceHeartbeatStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &instanceHeartbeatStore{master: &BaseDB{DB: db}}
+
+	// 同一实例只写入最新的心跳记录
+	mock.ExpectExec("insert into instance_heartbeat (instance_id, server, last_heartbeat, count, mtime) values "+
+		"($1, $2, $3, $4, clock_timestamp()), ($5, $6, $7, $8, clock_timestamp()) "+
+		"on conflict (instance_id) do update set "+
+		"server = case when "+heartbeatNewerCond+" then excluded.server else instance_heartbeat.server end, "+
+		"count = case when "+heartbeatNewerCond+" then excluded.count else instance_heartbeat.count end, "+
+		"last_heartbeat = greatest(instance_heartbeat.last_heartbeat, excluded.last_heartbeat), "+
+		"mtime = clock_timestamp()").
+		WithArgs("ins-1", "127.0.0.3", 1700000002, 1, "ins-2", "127.0.0.2", 1700000001, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, s.BatchUpdateInstanceHeartbeats([]*model.InstanceHeartbeat{
+		{InstanceID: "ins-1", Server: "127.0.0.1", LastHeartbeatSec: 1700000000, Count: 1},
+		{InstanceID: "ins-2", Server: "127.0.0.2", LastHeartbeatSec: 1700000001, Count: 3},
+		{InstanceID: "ins-1", Server: "127.0.0.3", LastHeartbeatSec: 1700000002, Count: 1},
+		{InstanceID: "ins-2", Server: "127.0.0.4", LastHeartbeatSec: 1700000001, Count: 2},
+	}))
+
+	columns := []string{"instance_id", "server", "last_heartbeat", "count", "mtime"}
+	mock.ExpectQuery("select instance_id, server, last_heartbeat, count, EXTRACT(EPOCH FROM mtime)::float8 " +
+		"from instance_heartbeat where mtime >= TO_TIMESTAMP($1)").
+		WithArgs(1700000001.0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("ins-2", "127.0.0.2", 1700000001, 3, "1700000001.456"))
+	heartbeats, err := s.GetMoreInstanceHeartbeats(time.Unix(1700000001, 0))
+	assert.NoError(t, err)
+	assert.Len(t, heartbeats, 1)
+	assert.Equal(t, int64(3), heartbeats[0].Count)
+	assert.True(t, time.UnixMilli(1700000001456).Equal(heartbeats[0].ModifyTime))
+
+	mock.ExpectExec("delete from instance_heartbeat where instance_id = $1").
+		WithArgs("ins-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.DeleteInstanceHeartbeat("ins-1"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
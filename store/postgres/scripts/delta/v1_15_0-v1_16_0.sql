@@ -0,0 +1,39 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.0
+CREATE TABLE IF NOT EXISTS change_log
+(
+    seq      bigserial                   NOT NULL,
+    resource varchar(64)                 NOT NULL,
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (seq)
+);
+
+CREATE INDEX IF NOT EXISTS change_log_ctime ON change_log (ctime);
+
+-- Change log triggers are created by the server when changeLog is enabled, the argument is the resource type
+CREATE OR REPLACE FUNCTION polaris_change_log() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO change_log (resource) VALUES (TG_ARGV[0]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.1
+CREATE TABLE IF NOT EXISTS maintain_job_run
+(
+    id           varchar(128)                NOT NULL,
+    name         varchar(128)                NOT NULL,
+    trigger_type varchar(32)                 NOT NULL,
+    operator     varchar(128)                NOT NULL DEFAULT '',
+    host         varchar(128)                NOT NULL,
+    status       varchar(32)                 NOT NULL,
+    message      text,
+    affected     text,
+    start_time   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time     timestamp(0) with time zone NULL DEFAULT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS maintain_job_run_name_start_time ON maintain_job_run (name, start_time);
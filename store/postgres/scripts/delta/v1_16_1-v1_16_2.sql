@@ -0,0 +1,32 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.2
+CREATE TABLE IF NOT EXISTS whitelist_rule
+(
+    id       varchar(128)                NOT NULL,
+    api      varchar(256)                NOT NULL DEFAULT '',
+    cidr     varchar(64)                 NOT NULL,
+    action   varchar(16)                 NOT NULL,
+    comment  varchar(1024)               NOT NULL DEFAULT '',
+    operator varchar(128)                NOT NULL DEFAULT '',
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
@@ -0,0 +1,36 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.3
+CREATE TABLE IF NOT EXISTS service_dependency
+(
+    caller_namespace varchar(64)                 NOT NULL,
+    caller_service   varchar(128)                NOT NULL,
+    caller_hosts     varchar(1024)               NOT NULL DEFAULT '',
+    caller_verified  smallint                    NOT NULL DEFAULT 0,
+    callee_namespace varchar(64)                 NOT NULL,
+    callee_service   varchar(128)                NOT NULL,
+    first_seen       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (callee_namespace, callee_service, caller_namespace, caller_service)
+);
+
+CREATE INDEX IF NOT EXISTS service_dependency_caller ON service_dependency (caller_namespace, caller_service);
+CREATE INDEX IF NOT EXISTS service_dependency_last_seen ON service_dependency (last_seen);
@@ -0,0 +1,41 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.4
+CREATE TABLE IF NOT EXISTS instance_event
+(
+    id          bigserial                   NOT NULL,
+    event_type  varchar(64)                 NOT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    service     varchar(128)                NOT NULL,
+    instance_id varchar(128)                NOT NULL,
+    host        varchar(128)                NOT NULL,
+    port        integer                     NOT NULL,
+    weight      integer                     NOT NULL DEFAULT 0,
+    healthy     smallint                    NOT NULL DEFAULT 0,
+    isolate     smallint                    NOT NULL DEFAULT 0,
+    server      varchar(128)                NOT NULL DEFAULT '',
+    ctime       timestamp(3) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS instance_event_service_ctime ON instance_event (namespace, service, ctime);
+CREATE INDEX IF NOT EXISTS instance_event_instance_ctime ON instance_event (instance_id, ctime);
+CREATE INDEX IF NOT EXISTS instance_event_ctime ON instance_event (ctime);
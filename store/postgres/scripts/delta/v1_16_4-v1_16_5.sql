@@ -0,0 +1,32 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: polaris_server
+--
+
+-- v1.16.5
+CREATE TABLE IF NOT EXISTS instance_heartbeat
+(
+    instance_id    varchar(128)                NOT NULL,
+    server         varchar(128)                NOT NULL DEFAULT '',
+    last_heartbeat bigint                      NOT NULL DEFAULT 0,
+    count          bigint                      NOT NULL DEFAULT 0,
+    mtime          timestamp(3) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (instance_id)
+);
+
+CREATE INDEX IF NOT EXISTS instance_heartbeat_mtime ON instance_heartbeat (mtime);
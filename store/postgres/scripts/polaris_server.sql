@@ -0,0 +1,1210 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+CREATE OR REPLACE FUNCTION update_modify_time_column() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD AND NEW.modify_time = OLD.modify_time THEN
+        NEW.modify_time = clock_timestamp();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_mtime_column() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD AND NEW.mtime = OLD.mtime THEN
+        NEW.mtime = clock_timestamp();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+
+SET TIME ZONE 'UTC';
+
+--
+-- Database: polaris_server
+--
+
+-- --------------------------------------------------------
+--
+-- Table structure business
+--
+CREATE TABLE business
+(
+    id    varchar(32)                 NOT NULL,
+    name  varchar(64)                 NOT NULL,
+    token varchar(64)                 NOT NULL,
+    owner varchar(1024)               NOT NULL,
+    flag  smallint                    NOT NULL DEFAULT 0,
+    ctime timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER business_update_mtime BEFORE UPDATE ON business
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure instance
+--
+CREATE TABLE instance
+(
+    id                  varchar(128)                NOT NULL,
+    service_id          varchar(32)                 NOT NULL,
+    vpc_id              varchar(64)                 DEFAULT NULL,
+    host                varchar(128)                NOT NULL,
+    port                integer                     NOT NULL,
+    protocol            varchar(32)                 DEFAULT NULL,
+    version             varchar(32)                 DEFAULT NULL,
+    health_status       smallint                    NOT NULL DEFAULT 1,
+    isolate             smallint                    NOT NULL DEFAULT 0,
+    weight              smallint                    NOT NULL DEFAULT 100,
+    enable_health_check smallint                    NOT NULL DEFAULT 0,
+    logic_set           varchar(128)                DEFAULT NULL,
+    cmdb_region         varchar(128)                DEFAULT NULL,
+    cmdb_zone           varchar(128)                DEFAULT NULL,
+    cmdb_idc            varchar(128)                DEFAULT NULL,
+    priority            smallint                    NOT NULL DEFAULT 0,
+    revision            varchar(32)                 NOT NULL,
+    flag                smallint                    NOT NULL DEFAULT 0,
+    ctime               timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime               timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX instance_service_id ON instance (service_id);
+CREATE INDEX instance_mtime ON instance (mtime);
+CREATE INDEX instance_host ON instance (host);
+
+CREATE TRIGGER instance_update_mtime BEFORE UPDATE ON instance
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure health_check
+--
+CREATE TABLE health_check
+(
+    id   varchar(128) NOT NULL,
+    type smallint     NOT NULL DEFAULT 0,
+    ttl  integer      NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT health_check_ibfk_1 FOREIGN KEY (id) REFERENCES instance (id) ON DELETE CASCADE
+);
+
+-- --------------------------------------------------------
+--
+-- Table structure instance_metadata
+--
+CREATE TABLE instance_metadata
+(
+    id     varchar(128)                NOT NULL,
+    mkey   varchar(128)                NOT NULL,
+    mvalue varchar(4096)               NOT NULL,
+    ctime  timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime  timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, mkey),
+    CONSTRAINT instance_metadata_ibfk_1 FOREIGN KEY (id) REFERENCES instance (id) ON DELETE CASCADE
+);
+
+CREATE INDEX instance_metadata_mkey ON instance_metadata (mkey);
+
+CREATE TRIGGER instance_metadata_update_mtime BEFORE UPDATE ON instance_metadata
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure namespace
+--
+CREATE TABLE namespace
+(
+    name    varchar(64)                 NOT NULL,
+    comment varchar(1024)               DEFAULT NULL,
+    token   varchar(64)                 NOT NULL,
+    owner   varchar(1024)               NOT NULL,
+    flag    smallint                    NOT NULL DEFAULT 0,
+    ctime   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name)
+);
+
+CREATE TRIGGER namespace_update_mtime BEFORE UPDATE ON namespace
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+--
+-- Data in the conveyor namespace
+--
+INSERT INTO namespace (name,
+                       comment,
+                       token,
+                       owner,
+                       flag,
+                       ctime,
+                       mtime)
+VALUES ('Polaris',
+        'Polaris-server',
+        '2d1bfe5d12e04d54b8ee69e62494c7fd',
+        'polaris',
+        0,
+        '2019-09-06 07:55:07',
+        '2019-09-06 07:55:07'),
+       ('default',
+        'Default Environment',
+        'e2e473081d3d4306b52264e49f7ce227',
+        'polaris',
+        0,
+        '2021-07-27 19:37:37',
+        '2021-07-27 19:37:37');
+
+-- --------------------------------------------------------
+--
+-- Table structure routing_config
+--
+CREATE TABLE routing_config
+(
+    id         varchar(32)                 NOT NULL,
+    in_bounds  text,
+    out_bounds text,
+    revision   varchar(40)                 NOT NULL,
+    flag       smallint                    NOT NULL DEFAULT 0,
+    ctime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX routing_config_mtime ON routing_config (mtime);
+
+CREATE TRIGGER routing_config_update_mtime BEFORE UPDATE ON routing_config
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure ratelimit_config
+--
+CREATE TABLE ratelimit_config
+(
+    id         varchar(32)                 NOT NULL,
+    name       varchar(64)                 NOT NULL,
+    disable    smallint                    NOT NULL DEFAULT 0,
+    service_id varchar(32)                 NOT NULL,
+    method     varchar(512)                NOT NULL,
+    labels     text                        NOT NULL,
+    priority   smallint                    NOT NULL DEFAULT 0,
+    rule       text                        NOT NULL,
+    revision   varchar(32)                 NOT NULL,
+    flag       smallint                    NOT NULL DEFAULT 0,
+    ctime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    etime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX ratelimit_config_mtime ON ratelimit_config (mtime);
+CREATE INDEX ratelimit_config_service_id ON ratelimit_config (service_id);
+
+CREATE TRIGGER ratelimit_config_update_mtime BEFORE UPDATE ON ratelimit_config
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure ratelimit_revision
+--
+CREATE TABLE ratelimit_revision
+(
+    service_id    varchar(32)                 NOT NULL,
+    last_revision varchar(40)                 NOT NULL,
+    mtime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service_id)
+);
+
+CREATE INDEX ratelimit_revision_service_id ON ratelimit_revision (service_id);
+CREATE INDEX ratelimit_revision_mtime ON ratelimit_revision (mtime);
+
+CREATE TRIGGER ratelimit_revision_update_mtime BEFORE UPDATE ON ratelimit_revision
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure service
+--
+CREATE TABLE service
+(
+    id           varchar(32)                 NOT NULL,
+    name         varchar(128)                NOT NULL,
+    namespace    varchar(64)                 NOT NULL,
+    ports        text                        DEFAULT NULL,
+    business     varchar(64)                 DEFAULT NULL,
+    department   varchar(1024)               DEFAULT NULL,
+    cmdb_mod1    varchar(1024)               DEFAULT NULL,
+    cmdb_mod2    varchar(1024)               DEFAULT NULL,
+    cmdb_mod3    varchar(1024)               DEFAULT NULL,
+    comment      varchar(1024)               DEFAULT NULL,
+    token        varchar(2048)               NOT NULL,
+    revision     varchar(32)                 NOT NULL,
+    owner        varchar(1024)               NOT NULL,
+    flag         smallint                    NOT NULL DEFAULT 0,
+    reference    varchar(32)                 DEFAULT NULL,
+    refer_filter varchar(1024)               DEFAULT NULL,
+    platform_id  varchar(32)                 DEFAULT '',
+    ctime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT service_name UNIQUE (name, namespace)
+);
+
+CREATE INDEX service_namespace ON service (namespace);
+CREATE INDEX service_mtime ON service (mtime);
+CREATE INDEX service_reference ON service (reference);
+CREATE INDEX service_platform_id ON service (platform_id);
+
+CREATE TRIGGER service_update_mtime BEFORE UPDATE ON service
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Data in the conveyor service
+--
+INSERT INTO service (id,
+                     name,
+                     namespace,
+                     comment,
+                     business,
+                     token,
+                     revision,
+                     owner,
+                     flag,
+                     ctime,
+                     mtime)
+VALUES ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        'polaris.checker',
+        'Polaris',
+        'polaris checker service',
+        'polaris',
+        '7d19c46de327408d8709ee7392b7700b',
+        '301b1e9f0bbd47a6b697e26e99dfe012',
+        'polaris',
+        0,
+        '2021-09-06 07:55:07',
+        '2021-09-06 07:55:09');
+
+-- --------------------------------------------------------
+--
+-- Table structure service_metadata
+--
+CREATE TABLE service_metadata
+(
+    id     varchar(32)                 NOT NULL,
+    mkey   varchar(128)                NOT NULL,
+    mvalue varchar(4096)               NOT NULL,
+    ctime  timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime  timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, mkey),
+    CONSTRAINT service_metadata_ibfk_1 FOREIGN KEY (id) REFERENCES service (id) ON DELETE CASCADE
+);
+
+CREATE INDEX service_metadata_mkey ON service_metadata (mkey);
+
+CREATE TRIGGER service_metadata_update_mtime BEFORE UPDATE ON service_metadata
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure owner_service_map Quickly query all services under an Owner
+--
+CREATE TABLE owner_service_map
+(
+    id        varchar(32)  NOT NULL,
+    owner     varchar(32)  NOT NULL,
+    service   varchar(128) NOT NULL,
+    namespace varchar(64)  NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX owner_service_map_owner ON owner_service_map (owner);
+CREATE INDEX owner_service_map_name ON owner_service_map (service, namespace);
+
+-- --------------------------------------------------------
+--
+-- Table structure circuitbreaker_rule
+--
+CREATE TABLE circuitbreaker_rule
+(
+    id         varchar(97)                 NOT NULL,
+    version    varchar(32)                 NOT NULL DEFAULT 'master',
+    name       varchar(128)                NOT NULL,
+    namespace  varchar(64)                 NOT NULL,
+    business   varchar(64)                 DEFAULT NULL,
+    department varchar(1024)               DEFAULT NULL,
+    comment    varchar(1024)               DEFAULT NULL,
+    inbounds   text                        NOT NULL,
+    outbounds  text                        NOT NULL,
+    token      varchar(32)                 NOT NULL,
+    owner      varchar(1024)               NOT NULL,
+    revision   varchar(32)                 NOT NULL,
+    flag       smallint                    NOT NULL DEFAULT 0,
+    ctime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime      timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, version),
+    CONSTRAINT circuitbreaker_rule_name UNIQUE (name, namespace, version)
+);
+
+CREATE INDEX circuitbreaker_rule_mtime ON circuitbreaker_rule (mtime);
+
+CREATE TRIGGER circuitbreaker_rule_update_mtime BEFORE UPDATE ON circuitbreaker_rule
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure circuitbreaker_rule_relation
+--
+CREATE TABLE circuitbreaker_rule_relation
+(
+    service_id   varchar(32)                 NOT NULL,
+    rule_id      varchar(97)                 NOT NULL,
+    rule_version varchar(32)                 NOT NULL,
+    flag         smallint                    NOT NULL DEFAULT 0,
+    ctime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service_id),
+    CONSTRAINT circuitbreaker_rule_relation_ibfk_1 FOREIGN KEY (service_id) REFERENCES service (id) ON DELETE CASCADE
+);
+
+CREATE INDEX circuitbreaker_rule_relation_mtime ON circuitbreaker_rule_relation (mtime);
+CREATE INDEX circuitbreaker_rule_relation_rule_id ON circuitbreaker_rule_relation (rule_id);
+
+CREATE TRIGGER circuitbreaker_rule_relation_update_mtime BEFORE UPDATE ON circuitbreaker_rule_relation
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure t_ip_config
+--
+CREATE TABLE t_ip_config
+(
+    fip     bigint                      NOT NULL,
+    fareaid bigint                      NOT NULL,
+    fcityid bigint                      NOT NULL,
+    fidcid  bigint                      NOT NULL,
+    fflag   smallint                    DEFAULT 0,
+    fstamp  timestamp(0) with time zone NOT NULL,
+    fflow   bigint                      NOT NULL,
+    PRIMARY KEY (fip)
+);
+
+CREATE INDEX t_ip_config_idx_fflow ON t_ip_config (fflow);
+
+-- --------------------------------------------------------
+--
+-- Table structure t_policy
+--
+CREATE TABLE t_policy
+(
+    fmodid bigint                      NOT NULL,
+    fdiv   bigint                      NOT NULL,
+    fmod   bigint                      NOT NULL,
+    fflag  smallint                    DEFAULT 0,
+    fstamp timestamp(0) with time zone NOT NULL,
+    fflow  bigint                      NOT NULL,
+    PRIMARY KEY (fmodid)
+);
+
+-- --------------------------------------------------------
+--
+-- Table structure t_route
+--
+CREATE TABLE t_route
+(
+    fip    bigint                      NOT NULL,
+    fmodid bigint                      NOT NULL,
+    fcmdid bigint                      NOT NULL,
+    fsetid varchar(32)                 NOT NULL,
+    fflag  smallint                    DEFAULT 0,
+    fstamp timestamp(0) with time zone NOT NULL,
+    fflow  bigint                      NOT NULL,
+    PRIMARY KEY (fip, fmodid, fcmdid)
+);
+
+CREATE INDEX t_route_fflow ON t_route (fflow);
+CREATE INDEX t_route_idx1 ON t_route (fmodid, fcmdid, fsetid);
+
+-- --------------------------------------------------------
+--
+-- Table structure t_section
+--
+CREATE TABLE t_section
+(
+    fmodid bigint                      NOT NULL,
+    ffrom  bigint                      NOT NULL,
+    fto    bigint                      NOT NULL,
+    fxid   bigint                      NOT NULL,
+    fflag  smallint                    DEFAULT 0,
+    fstamp timestamp(0) with time zone NOT NULL,
+    fflow  bigint                      NOT NULL,
+    PRIMARY KEY (fmodid, ffrom, fto)
+);
+
+-- --------------------------------------------------------
+--
+-- Table structure start_lock
+--
+CREATE TABLE start_lock
+(
+    lock_id  integer                     NOT NULL,
+    lock_key varchar(32)                 NOT NULL,
+    server   varchar(32)                 NOT NULL,
+    mtime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (lock_id, lock_key)
+);
+
+CREATE TRIGGER start_lock_update_mtime BEFORE UPDATE ON start_lock
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+--
+-- Data in the conveyor start_lock
+--
+INSERT INTO start_lock (lock_id, lock_key, server, mtime)
+VALUES (1, 'sz', 'aaa', '2019-12-05 08:35:49');
+
+-- --------------------------------------------------------
+--
+-- Table structure cl5_module
+--
+CREATE TABLE cl5_module
+(
+    module_id    integer                     NOT NULL,
+    interface_id integer                     NOT NULL,
+    range_num    integer                     NOT NULL,
+    mtime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (module_id)
+);
+
+CREATE TRIGGER cl5_module_update_mtime BEFORE UPDATE ON cl5_module
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+--
+-- Data in the conveyor cl5_module
+--
+insert into cl5_module(module_id, interface_id, range_num)
+values (3000001, 1, 0);
+
+-- --------------------------------------------------------
+--
+-- Table structure mesh
+--
+CREATE TABLE mesh
+(
+    id            varchar(32)                 NOT NULL,
+    name          varchar(128)                NOT NULL,
+    department    varchar(1024)               DEFAULT NULL,
+    business      varchar(128)                NOT NULL,
+    managed       smallint                    NOT NULL,
+    istio_version varchar(64),
+    data_cluster  varchar(1024),
+    revision      varchar(32)                 NOT NULL,
+    comment       varchar(1024)               DEFAULT NULL,
+    token         varchar(32)                 NOT NULL,
+    owner         varchar(1024)               NOT NULL,
+    flag          smallint                    NOT NULL DEFAULT 0,
+    ctime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX mesh_name ON mesh (name);
+CREATE INDEX mesh_mtime ON mesh (mtime);
+
+CREATE TRIGGER mesh_update_mtime BEFORE UPDATE ON mesh
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure mesh_service
+--
+CREATE TABLE mesh_service
+(
+    id             varchar(32)                 NOT NULL,
+    mesh_id        varchar(32)                 NOT NULL,
+    service_id     varchar(32)                 NOT NULL,
+    namespace      varchar(64)                 NOT NULL,
+    service        varchar(128)                NOT NULL,
+    mesh_namespace varchar(64)                 NOT NULL,
+    mesh_service   varchar(128)                NOT NULL,
+    location       varchar(16)                 NOT NULL,
+    export_to      varchar(1024)               NOT NULL,
+    revision       varchar(32)                 NOT NULL,
+    flag           smallint                    NOT NULL DEFAULT 0,
+    ctime          timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime          timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT mesh_service_relation UNIQUE (mesh_id, mesh_namespace, mesh_service)
+);
+
+CREATE INDEX mesh_service_namespace ON mesh_service (namespace);
+CREATE INDEX mesh_service_service ON mesh_service (service);
+CREATE INDEX mesh_service_location ON mesh_service (location);
+CREATE INDEX mesh_service_export_to ON mesh_service (export_to);
+CREATE INDEX mesh_service_mtime ON mesh_service (mtime);
+CREATE INDEX mesh_service_flag ON mesh_service (flag);
+
+CREATE TRIGGER mesh_service_update_mtime BEFORE UPDATE ON mesh_service
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure mesh_service_revision
+--
+CREATE TABLE mesh_service_revision
+(
+    mesh_id  varchar(32)                 NOT NULL,
+    revision varchar(32)                 NOT NULL,
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (mesh_id)
+);
+
+CREATE INDEX mesh_service_revision_mtime ON mesh_service_revision (mtime);
+
+CREATE TRIGGER mesh_service_revision_update_mtime BEFORE UPDATE ON mesh_service_revision
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure mesh_resource
+--
+CREATE TABLE mesh_resource
+(
+    id             varchar(32)                 NOT NULL,
+    mesh_id        varchar(32)                 NOT NULL,
+    name           varchar(64)                 NOT NULL,
+    mesh_namespace varchar(64)                 NOT NULL,
+    type_url       varchar(96)                 NOT NULL,
+    revision       varchar(32)                 NOT NULL,
+    body           text,
+    flag           smallint                    NOT NULL DEFAULT 0,
+    ctime          timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime          timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT mesh_resource_name UNIQUE (mesh_id, name, mesh_namespace, type_url)
+);
+
+CREATE INDEX mesh_resource_mtime ON mesh_resource (mtime);
+
+CREATE TRIGGER mesh_resource_update_mtime BEFORE UPDATE ON mesh_resource
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+--
+-- Table structure mesh_revision
+--
+CREATE TABLE mesh_resource_revision
+(
+    mesh_id  varchar(32)                 NOT NULL,
+    type_url varchar(96)                 NOT NULL,
+    revision varchar(32)                 NOT NULL,
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (mesh_id, type_url)
+);
+
+CREATE INDEX mesh_resource_revision_mtime ON mesh_resource_revision (mtime);
+
+CREATE TRIGGER mesh_resource_revision_update_mtime BEFORE UPDATE ON mesh_resource_revision
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure config_file
+--
+CREATE TABLE config_file
+(
+    id          bigserial                   NOT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    "group"     varchar(128)                NOT NULL DEFAULT '',
+    name        varchar(128)                NOT NULL,
+    content     text                        NOT NULL,
+    format      varchar(16)                 DEFAULT 'text',
+    comment     varchar(512)                DEFAULT NULL,
+    flag        smallint                    NOT NULL DEFAULT 0,
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT config_file_uk_file UNIQUE (namespace, "group", name)
+);
+
+CREATE TRIGGER config_file_update_modify_time BEFORE UPDATE ON config_file
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure config_file_group
+--
+CREATE TABLE config_file_group
+(
+    id          bigserial                   NOT NULL,
+    name        varchar(128)                NOT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    comment     varchar(512)                DEFAULT NULL,
+    owner       varchar(1024)               DEFAULT NULL,
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT config_file_group_uk_name UNIQUE (namespace, name)
+);
+
+CREATE TRIGGER config_file_group_update_modify_time BEFORE UPDATE ON config_file_group
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure config_file_release
+--
+CREATE TABLE config_file_release
+(
+    id          bigserial                   NOT NULL,
+    name        varchar(128)                DEFAULT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    "group"     varchar(128)                NOT NULL,
+    file_name   varchar(128)                NOT NULL,
+    content     text                        NOT NULL,
+    comment     varchar(512)                DEFAULT NULL,
+    md5         varchar(128)                NOT NULL,
+    version     integer                     NOT NULL,
+    flag        smallint                    NOT NULL DEFAULT 0,
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT config_file_release_uk_file UNIQUE (namespace, "group", file_name)
+);
+
+CREATE INDEX config_file_release_idx_modify_time ON config_file_release (modify_time);
+
+CREATE TRIGGER config_file_release_update_modify_time BEFORE UPDATE ON config_file_release
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure config_file_release_history
+--
+CREATE TABLE config_file_release_history
+(
+    id          bigserial                   NOT NULL,
+    name        varchar(64)                 DEFAULT '',
+    namespace   varchar(64)                 NOT NULL,
+    "group"     varchar(128)                NOT NULL,
+    file_name   varchar(128)                NOT NULL,
+    content     text                        NOT NULL,
+    format      varchar(16)                 DEFAULT 'text',
+    tags        varchar(2048)               DEFAULT '',
+    comment     varchar(512)                DEFAULT NULL,
+    md5         varchar(128)                NOT NULL,
+    type        varchar(32)                 NOT NULL,
+    status      varchar(16)                 NOT NULL DEFAULT 'success',
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX config_file_release_history_idx_file ON config_file_release_history (namespace, "group", file_name);
+
+CREATE TRIGGER config_file_release_history_update_modify_time BEFORE UPDATE ON config_file_release_history
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+-- --------------------------------------------------------
+--
+-- Table structure config_file_tag
+--
+CREATE TABLE config_file_tag
+(
+    id          bigserial                   NOT NULL,
+    key         varchar(128)                NOT NULL,
+    value       varchar(128)                NOT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    "group"     varchar(128)                NOT NULL DEFAULT '',
+    file_name   varchar(128)                NOT NULL,
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT config_file_tag_uk_tag UNIQUE (key, value, namespace, "group", file_name)
+);
+
+CREATE INDEX config_file_tag_idx_file ON config_file_tag (namespace, "group", file_name);
+
+CREATE TRIGGER config_file_tag_update_modify_time BEFORE UPDATE ON config_file_tag
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+CREATE TABLE "user"
+(
+    id               varchar(128)                NOT NULL,
+    name             varchar(100)                NOT NULL,
+    password         varchar(100)                NOT NULL,
+    owner            varchar(128)                NOT NULL,
+    source           varchar(32)                 NOT NULL,
+    mobile           varchar(12)                 NOT NULL DEFAULT '',
+    email            varchar(64)                 NOT NULL DEFAULT '',
+    password_history varchar(1024)               NOT NULL DEFAULT '',
+    password_mtime   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    token            varchar(255)                NOT NULL,
+    token_enable     smallint                    NOT NULL DEFAULT 1,
+    user_type        integer                     NOT NULL DEFAULT 20,
+    comment          varchar(255)                NOT NULL,
+    flag             smallint                    NOT NULL DEFAULT 0,
+    ctime            timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime            timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT user_name_owner UNIQUE (name, owner)
+);
+
+CREATE INDEX user_owner ON "user" (owner);
+CREATE INDEX user_mtime ON "user" (mtime);
+
+CREATE TRIGGER user_update_mtime BEFORE UPDATE ON "user"
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE user_group
+(
+    id           varchar(128)                NOT NULL,
+    name         varchar(100)                NOT NULL,
+    owner        varchar(128)                NOT NULL,
+    token        varchar(255)                NOT NULL,
+    comment      varchar(255)                NOT NULL,
+    token_enable smallint                    NOT NULL DEFAULT 1,
+    flag         smallint                    NOT NULL DEFAULT 0,
+    ctime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT user_group_name_owner UNIQUE (name, owner)
+);
+
+CREATE INDEX user_group_owner ON user_group (owner);
+CREATE INDEX user_group_mtime ON user_group (mtime);
+
+CREATE TRIGGER user_group_update_mtime BEFORE UPDATE ON user_group
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE user_group_relation
+(
+    user_id  varchar(128)                NOT NULL,
+    group_id varchar(128)                NOT NULL,
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id)
+);
+
+CREATE INDEX user_group_relation_mtime ON user_group_relation (mtime);
+
+CREATE TRIGGER user_group_relation_update_mtime BEFORE UPDATE ON user_group_relation
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE auth_strategy
+(
+    id        varchar(128)                NOT NULL,
+    name      varchar(100)                NOT NULL,
+    action    varchar(32)                 NOT NULL,
+    owner     varchar(128)                NOT NULL,
+    comment   varchar(255)                NOT NULL,
+    "default" smallint                    NOT NULL DEFAULT 0,
+    revision  varchar(128)                NOT NULL,
+    flag      smallint                    NOT NULL DEFAULT 0,
+    ctime     timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime     timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT auth_strategy_name_owner UNIQUE (name, owner)
+);
+
+CREATE INDEX auth_strategy_owner ON auth_strategy (owner);
+CREATE INDEX auth_strategy_mtime ON auth_strategy (mtime);
+
+CREATE TRIGGER auth_strategy_update_mtime BEFORE UPDATE ON auth_strategy
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE auth_principal
+(
+    strategy_id    varchar(128) NOT NULL,
+    principal_id   varchar(128) NOT NULL,
+    principal_role integer      NOT NULL,
+    PRIMARY KEY (strategy_id, principal_id, principal_role)
+);
+
+CREATE TABLE auth_strategy_resource
+(
+    strategy_id varchar(128)                NOT NULL,
+    res_type    integer                     NOT NULL,
+    res_id      varchar(128)                NOT NULL,
+    ctime       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (strategy_id, res_type, res_id)
+);
+
+CREATE INDEX auth_strategy_resource_mtime ON auth_strategy_resource (mtime);
+
+CREATE TRIGGER auth_strategy_resource_update_mtime BEFORE UPDATE ON auth_strategy_resource
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- Create a default master account, password is Polarismesh @ 2021
+INSERT INTO "user" (id,
+                    name,
+                    password,
+                    source,
+                    token,
+                    token_enable,
+                    user_type,
+                    comment,
+                    mobile,
+                    email,
+                    owner)
+VALUES ('65e4789a6d5b49669adf1e9e8387549c',
+        'polaris',
+        '$2a$10$3izWuZtE5SBdAtSZci.gs.iZ2pAn9I8hEqYrC6gwJp1dyjqQnrrum',
+        'Polaris',
+        'nu/0WRA4EqSR1FagrjRj0fZwPXuGlMpX+zCuWu4uMqy8xr1vRjisSbA25aAC3mtU8MeeRsKhQiDAynUR09I=',
+        1,
+        20,
+        'default polaris admin account',
+        '12345678910',
+        '12345678910',
+        '');
+
+-- Permissions policy inserted into Polaris-Admin
+INSERT INTO auth_strategy(id,
+                          name,
+                          action,
+                          owner,
+                          comment,
+                          "default",
+                          revision,
+                          flag,
+                          ctime,
+                          mtime)
+VALUES ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        '(用户) polaris的默认策略',
+        'READ_WRITE',
+        '65e4789a6d5b49669adf1e9e8387549c',
+        'default admin',
+        1,
+        'fbca9bfa04ae4ead86e1ecf5811e32a9',
+        0,
+        clock_timestamp(),
+        clock_timestamp());
+
+-- Sport rules inserted into Polaris-Admin to access
+INSERT INTO auth_strategy_resource(strategy_id,
+                                   res_type,
+                                   res_id,
+                                   ctime,
+                                   mtime)
+VALUES ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        0,
+        '*',
+        clock_timestamp(),
+        clock_timestamp()),
+       ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        1,
+        '*',
+        clock_timestamp(),
+        clock_timestamp()),
+       ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        2,
+        '*',
+        clock_timestamp(),
+        clock_timestamp());
+
+-- Insert permission policies and association relationships for Polaris-Admin accounts
+INSERT INTO auth_principal(strategy_id, principal_id, principal_role)
+VALUES ('fbca9bfa04ae4ead86e1ecf5811e32a9',
+        '65e4789a6d5b49669adf1e9e8387549c',
+        1);
+
+-- v1.8.0, support client info storage
+CREATE TABLE client
+(
+    id      varchar(128)                NOT NULL,
+    host    varchar(100)                NOT NULL,
+    type    varchar(100)                NOT NULL,
+    version varchar(32)                 NOT NULL,
+    region  varchar(128)                DEFAULT NULL,
+    zone    varchar(128)                DEFAULT NULL,
+    campus  varchar(128)                DEFAULT NULL,
+    flag    smallint                    NOT NULL DEFAULT 0,
+    ctime   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX client_mtime ON client (mtime);
+
+CREATE TRIGGER client_update_mtime BEFORE UPDATE ON client
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE client_stat
+(
+    client_id varchar(128) NOT NULL,
+    target    varchar(100) NOT NULL,
+    port      integer      NOT NULL,
+    protocol  varchar(100) NOT NULL,
+    path      varchar(128) NOT NULL,
+    PRIMARY KEY (client_id, target, port)
+);
+
+-- v1.9.0
+CREATE TABLE config_file_template
+(
+    id          bigserial                   NOT NULL,
+    name        varchar(128)                NOT NULL,
+    content     text                        NOT NULL,
+    format      varchar(16)                 DEFAULT 'text',
+    comment     varchar(512)                DEFAULT NULL,
+    create_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    create_by   varchar(32)                 DEFAULT NULL,
+    modify_time timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    modify_by   varchar(32)                 DEFAULT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT config_file_template_uk_name UNIQUE (name)
+);
+
+CREATE TRIGGER config_file_template_update_modify_time BEFORE UPDATE ON config_file_template
+    FOR EACH ROW EXECUTE PROCEDURE update_modify_time_column();
+
+INSERT INTO config_file_template (name, content, format, comment, create_time
+                                  , create_by, modify_time, modify_by)
+VALUES ('spring-cloud-gateway-braining', '{
+    "rules":[
+        {
+            "conditions":[
+                {
+                    "key":"${http.query.uid}",
+                    "values":["10000"],
+                    "operation":"EQUALS"
+                }
+            ],
+            "labels":[
+                {
+                    "key":"env",
+                    "value":"green"
+                }
+            ]
+        }
+    ]
+}', 'json', 'Spring Cloud Gateway  染色规则', NOW()
+	, 'polaris', NOW(), 'polaris');
+
+-- v1.12.0
+CREATE TABLE routing_config_v2
+(
+    id          varchar(128)                NOT NULL,
+    name        varchar(64)                 NOT NULL default '',
+    namespace   varchar(64)                 NOT NULL default '',
+    policy      varchar(64)                 NOT NULL,
+    config      text,
+    enable      integer                     NOT NULL DEFAULT 0,
+    revision    varchar(40)                 NOT NULL,
+    description varchar(500)                NOT NULL DEFAULT '',
+    priority    smallint                    NOT NULL DEFAULT 0,
+    flag        smallint                    NOT NULL DEFAULT 0,
+    ctime       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    etime       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    extend_info varchar(1024)               DEFAULT '',
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX routing_config_v2_mtime ON routing_config_v2 (mtime);
+
+CREATE TRIGGER routing_config_v2_update_mtime BEFORE UPDATE ON routing_config_v2
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE leader_election
+(
+    elect_key varchar(128)                NOT NULL,
+    version   bigint                      NOT NULL DEFAULT 0,
+    leader    varchar(128)                NOT NULL,
+    ctime     timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime     timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (elect_key)
+);
+
+CREATE INDEX leader_election_version ON leader_election (version);
+
+CREATE TRIGGER leader_election_update_mtime BEFORE UPDATE ON leader_election
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- v1.14.0
+CREATE TABLE circuitbreaker_rule_v2
+(
+    id            varchar(128)                NOT NULL,
+    name          varchar(64)                 NOT NULL,
+    namespace     varchar(64)                 NOT NULL default '',
+    enable        integer                     NOT NULL DEFAULT 0,
+    revision      varchar(40)                 NOT NULL,
+    description   varchar(1024)               NOT NULL DEFAULT '',
+    level         integer                     NOT NULL,
+    src_service   varchar(128)                NOT NULL,
+    src_namespace varchar(64)                 NOT NULL,
+    dst_service   varchar(128)                NOT NULL,
+    dst_namespace varchar(64)                 NOT NULL,
+    dst_method    varchar(128)                NOT NULL,
+    config        text,
+    flag          smallint                    NOT NULL DEFAULT 0,
+    ctime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    etime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX circuitbreaker_rule_v2_name ON circuitbreaker_rule_v2 (name);
+CREATE INDEX circuitbreaker_rule_v2_mtime ON circuitbreaker_rule_v2 (mtime);
+
+CREATE TRIGGER circuitbreaker_rule_v2_update_mtime BEFORE UPDATE ON circuitbreaker_rule_v2
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+CREATE TABLE fault_detect_rule
+(
+    id            varchar(128)                NOT NULL,
+    name          varchar(64)                 NOT NULL,
+    namespace     varchar(64)                 NOT NULL default 'default',
+    revision      varchar(40)                 NOT NULL,
+    description   varchar(1024)               NOT NULL DEFAULT '',
+    dst_service   varchar(128)                NOT NULL,
+    dst_namespace varchar(64)                 NOT NULL,
+    dst_method    varchar(128)                NOT NULL,
+    config        text,
+    flag          smallint                    NOT NULL DEFAULT 0,
+    ctime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    mtime         timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX fault_detect_rule_name ON fault_detect_rule (name);
+CREATE INDEX fault_detect_rule_mtime ON fault_detect_rule (mtime);
+
+CREATE TRIGGER fault_detect_rule_update_mtime BEFORE UPDATE ON fault_detect_rule
+    FOR EACH ROW EXECUTE PROCEDURE update_mtime_column();
+
+-- v1.16.0
+CREATE TABLE change_log
+(
+    seq      bigserial                   NOT NULL,
+    resource varchar(64)                 NOT NULL,
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (seq)
+);
+
+CREATE INDEX change_log_ctime ON change_log (ctime);
+
+-- Change log triggers are created by the server when changeLog is enabled, the argument is the resource type
+CREATE OR REPLACE FUNCTION polaris_change_log() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO change_log (resource) VALUES (TG_ARGV[0]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- v1.16.1
+CREATE TABLE maintain_job_run
+(
+    id           varchar(128)                NOT NULL,
+    name         varchar(128)                NOT NULL,
+    trigger_type varchar(32)                 NOT NULL,
+    operator     varchar(128)                NOT NULL DEFAULT '',
+    host         varchar(128)                NOT NULL,
+    status       varchar(32)                 NOT NULL,
+    message      text,
+    affected     text,
+    start_time   timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time     timestamp(0) with time zone NULL DEFAULT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX maintain_job_run_name_start_time ON maintain_job_run (name, start_time);
+
+-- v1.16.2
+CREATE TABLE whitelist_rule
+(
+    id       varchar(128)                NOT NULL,
+    api      varchar(256)                NOT NULL DEFAULT '',
+    cidr     varchar(64)                 NOT NULL,
+    action   varchar(16)                 NOT NULL,
+    comment  varchar(1024)               NOT NULL DEFAULT '',
+    operator varchar(128)                NOT NULL DEFAULT '',
+    ctime    timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+-- v1.16.3
+CREATE TABLE service_dependency
+(
+    caller_namespace varchar(64)                 NOT NULL,
+    caller_service   varchar(128)                NOT NULL,
+    caller_hosts     varchar(1024)               NOT NULL DEFAULT '',
+    caller_verified  smallint                    NOT NULL DEFAULT 0,
+    callee_namespace varchar(64)                 NOT NULL,
+    callee_service   varchar(128)                NOT NULL,
+    first_seen       timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen        timestamp(0) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (callee_namespace, callee_service, caller_namespace, caller_service)
+);
+
+CREATE INDEX service_dependency_caller ON service_dependency (caller_namespace, caller_service);
+CREATE INDEX service_dependency_last_seen ON service_dependency (last_seen);
+
+-- v1.16.4
+CREATE TABLE instance_event
+(
+    id          bigserial                   NOT NULL,
+    event_type  varchar(64)                 NOT NULL,
+    namespace   varchar(64)                 NOT NULL,
+    service     varchar(128)                NOT NULL,
+    instance_id varchar(128)                NOT NULL,
+    host        varchar(128)                NOT NULL,
+    port        integer                     NOT NULL,
+    weight      integer                     NOT NULL DEFAULT 0,
+    healthy     smallint                    NOT NULL DEFAULT 0,
+    isolate     smallint                    NOT NULL DEFAULT 0,
+    server      varchar(128)                NOT NULL DEFAULT '',
+    ctime       timestamp(3) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX instance_event_service_ctime ON instance_event (namespace, service, ctime);
+CREATE INDEX instance_event_instance_ctime ON instance_event (instance_id, ctime);
+CREATE INDEX instance_event_ctime ON instance_event (ctime);
+
+-- v1.16.5
+CREATE TABLE instance_heartbeat
+(
+    instance_id    varchar(128)                NOT NULL,
+    server         varchar(128)                NOT NULL DEFAULT '',
+    last_heartbeat bigint                      NOT NULL DEFAULT 0,
+    count          bigint                      NOT NULL DEFAULT 0,
+    mtime          timestamp(3) with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (instance_id)
+);
+
+CREATE INDEX instance_heartbeat_mtime ON instance_heartbeat (mtime);
\ No newline at end of file
@@ -0,0 +1,69 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_transaction_LockBootstrap(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	t.Run("随机选择槽位加advisory锁", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("select count(*) from start_lock where lock_key = $1").
+			WithArgs("sz").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec("select pg_advisory_xact_lock(hashtext($1::text), $2::integer)").
+			WithArgs("sz", 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("update start_lock set server = $1 where lock_id = $2 and lock_key = $3").
+			WithArgs("server-1", 1, "sz").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		trans := &transaction{tx: &BaseTx{Tx: tx}}
+		assert.NoError(t, trans.LockBootstrap("sz", "server-1"))
+		assert.NoError(t, trans.Commit())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("没有槽位时不加锁", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("select count(*) from start_lock where lock_key = $1").
+			WithArgs("sz").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectCommit()
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		trans := &transaction{tx: &BaseTx{Tx: tx}}
+		assert.NoError(t, trans.LockBootstrap("sz", "server-1"))
+		assert.NoError(t, trans.Commit())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,82 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package postgres
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// whitelistStore 白名单运行时规则的存储实现
+type whitelistStore struct {
+	master *BaseDB
+}
+
+// AddWhitelistRule 新增一条规则
+func (w *whitelistStore) AddWhitelistRule(rule *model.WhitelistRule) error {
+	_, err := w.master.Exec("insert into whitelist_rule (id, api, cidr, action, comment, operator, ctime) "+
+		"values (?, ?, ?, ?, ?, ?, TO_TIMESTAMP(?))",
+		rule.ID, rule.API, rule.CIDR, rule.Action, rule.Comment, rule.Operator, rule.CreateTime.Unix())
+	if err != nil {
+		log.Errorf("[Store][database] add whitelist rule(%s) err: %s", rule.CIDR, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// DeleteWhitelistRule 删除一条规则
+func (w *whitelistStore) DeleteWhitelistRule(id string) error {
+	if _, err := w.master.Exec("delete from whitelist_rule where id = ?", id); err != nil {
+		log.Errorf("[Store][database] delete whitelist rule(%s) err: %s", id, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetWhitelistRules 查询全部规则，按照创建时间排序
+func (w *whitelistStore) GetWhitelistRules() ([]*model.WhitelistRule, error) {
+	rows, err := w.master.Query("select id, api, cidr, action, comment, operator, " +
+		"EXTRACT(EPOCH FROM ctime)::bigint from whitelist_rule order by ctime, id")
+	if err != nil {
+		log.Errorf("[Store][database] get whitelist rules err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var rules []*model.WhitelistRule
+	for rows.Next() {
+		var (
+			rule  = &model.WhitelistRule{}
+			ctime int64
+		)
+		err := rows.Scan(&rule.ID, &rule.API, &rule.CIDR, &rule.Action, &rule.Comment, &rule.Operator, &ctime)
+		if err != nil {
+			log.Errorf("[Store][database] fetch whitelist rule rows err: %s", err.Error())
+			return nil, store.Error(err)
+		}
+		rule.CreateTime = time.Unix(ctime, 0)
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch whitelist rule rows next err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	return rules, nil
+}
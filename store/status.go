@@ -61,13 +61,16 @@ func Error(err error) error {
 	}
 
 	s := &StatusError{message: err.Error()}
-	if strings.Contains(s.message, "Data too long") {
+	// 同时兼容 mysql 以及 postgres 的错误信息
+	if strings.Contains(s.message, "Data too long") || strings.Contains(s.message, "value too long") {
 		s.code = OutOfRangeErr
-	} else if strings.Contains(s.message, "Duplicate entry") {
+	} else if strings.Contains(s.message, "Duplicate entry") ||
+		strings.Contains(s.message, "duplicate key value violates unique constraint") {
 		s.code = DuplicateEntryErr
-	} else if strings.Contains(s.message, "a foreign key constraint fails") {
+	} else if strings.Contains(s.message, "a foreign key constraint fails") ||
+		strings.Contains(s.message, "violates foreign key constraint") {
 		s.code = ForeignKeyErr
-	} else if strings.Contains(s.message, "Deadlock") {
+	} else if strings.Contains(s.message, "Deadlock") || strings.Contains(s.message, "deadlock detected") {
 		s.code = DeadlockErr
 	} else {
 		s.code = Unknown
@@ -128,9 +128,9 @@ func (d *DiscoverTestSuit) loadConfig() error {
 	d.cfg = new(TestConfig)
 
 	confFileName := testdata.Path("service_test.yaml")
-	if os.Getenv("STORE_MODE") == "sqldb" {
-		fmt.Printf("run store mode : sqldb\n")
-		confFileName = testdata.Path("service_test_sqldb.yaml")
+	if mode := os.Getenv("STORE_MODE"); mode == "sqldb" || mode == "postgres" {
+		fmt.Printf("run store mode : %s\n", mode)
+		confFileName = testdata.Path("service_test_" + mode + ".yaml")
 		d.DefaultCtx = context.WithValue(d.DefaultCtx, utils.ContextAuthTokenKey,
 			"nu/0WRA4EqSR1FagrjRj0fZwPXuGlMpX+zCuWu4uMqy8xr1vRjisSbA25aAC3mtU8MeeRsKhQiDAynUR09I=")
 	}